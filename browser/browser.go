@@ -0,0 +1,32 @@
+// Package browser opens URLs in the user's default web browser.
+package browser
+
+import (
+	"fmt"
+	"os/exec"
+	"runtime"
+)
+
+// Open launches the default browser with url. It returns once the opener
+// command has started; the browser itself keeps running independently.
+func Open(url string) error {
+	name, args := command(runtime.GOOS, url)
+	cmd := exec.Command(name, args...)
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("open browser with %s: %w", name, err)
+	}
+	go cmd.Wait()
+	return nil
+}
+
+// command returns the platform's opener command for url.
+func command(goos, url string) (string, []string) {
+	switch goos {
+	case "darwin":
+		return "open", []string{url}
+	case "windows":
+		return "rundll32", []string{"url.dll,FileProtocolHandler", url}
+	default:
+		return "xdg-open", []string{url}
+	}
+}
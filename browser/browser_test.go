@@ -0,0 +1,21 @@
+package browser
+
+import "testing"
+
+func TestCommand(t *testing.T) {
+	tests := []struct {
+		goos string
+		want string
+	}{
+		{"darwin", "open"},
+		{"windows", "rundll32"},
+		{"linux", "xdg-open"},
+		{"freebsd", "xdg-open"},
+	}
+	for _, tt := range tests {
+		name, args := command(tt.goos, "http://localhost:7331")
+		if name != tt.want || args[len(args)-1] != "http://localhost:7331" {
+			t.Errorf("%s: got %s %v", tt.goos, name, args)
+		}
+	}
+}
@@ -0,0 +1,222 @@
+package check
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"gomdoc/renderer"
+	"gomdoc/scanner"
+)
+
+// AnchorOptions configures Anchors.
+type AnchorOptions struct {
+	// File is the baseline: the heading IDs of every page at the last
+	// release, as JSON, kept with the docs. It is written when it does not
+	// exist yet.
+	File string
+	// Update rewrites File with the current heading IDs, accepting the
+	// changes.
+	Update bool
+	// Pin adds the old ID as a {#id} attribute to headings that were
+	// renamed, instead of reporting them. Dir is the documentation
+	// directory the markdown files are written to.
+	Pin bool
+	Dir string
+}
+
+// AnchorResult is the outcome of Anchors.
+type AnchorResult struct {
+	// Changed are the IDs of the baseline that no heading has anymore.
+	Changed []Problem
+	// Pinned are the renamed headings given back their old ID with Pin.
+	Pinned []Problem
+}
+
+// anchorRecord is a heading in the baseline.
+type anchorRecord struct {
+	ID      string `json:"id"`
+	Heading string `json:"heading"`
+}
+
+// Anchors compares the heading IDs of the pages with the baseline in
+// File and reports the ones that changed, such as the generated ID of a
+// renamed heading, which breaks deep links into the page. A renamed
+// heading keeps its old ID with a {#id} attribute. Pages that are not in
+// the baseline, or no longer exist, are not compared.
+func Anchors(fsys fs.FS, opts scanner.ScanOptions, r *renderer.Renderer, anchors AnchorOptions) (AnchorResult, error) {
+	var result AnchorResult
+	docs, err := loadDocuments(fsys, opts)
+	if err != nil {
+		return result, err
+	}
+	current := make(map[string][]renderer.HeadingAnchor, len(docs))
+	for _, doc := range docs {
+		headings := r.HeadingAnchors(doc.body)
+		for i := range headings {
+			headings[i].Line += doc.bodyLine
+		}
+		current[filepath.ToSlash(doc.entry.RelPath)] = headings
+	}
+
+	baseline, err := loadAnchorBaseline(anchors.File)
+	if errors.Is(err, fs.ErrNotExist) || anchors.Update {
+		return result, saveAnchorBaseline(anchors.File, current)
+	}
+	if err != nil {
+		return result, fmt.Errorf("read %s: %w", anchors.File, err)
+	}
+
+	for _, doc := range docs {
+		name := filepath.ToSlash(doc.entry.RelPath)
+		old, ok := baseline[name]
+		if !ok {
+			continue
+		}
+		var pins []renderer.HeadingAnchor
+		for _, change := range changedAnchors(old, current[name]) {
+			switch {
+			case change.renamed == nil:
+				result.Changed = append(result.Changed, Problem{File: doc.entry.RelPath, Message: fmt.Sprintf("heading anchor #%s (%s) is gone, breaking deep links to it", change.old.ID, change.old.Heading)})
+			case anchors.Pin:
+				pin := *change.renamed
+				pin.ID = change.old.ID
+				pins = append(pins, pin)
+				result.Pinned = append(result.Pinned, Problem{File: doc.entry.RelPath, Message: fmt.Sprintf("line %d: pinned #%s on renamed heading %q", pin.Line, pin.ID, pin.Text)})
+			default:
+				result.Changed = append(result.Changed, Problem{File: doc.entry.RelPath, Message: fmt.Sprintf("line %d: heading anchor #%s changed to #%s; add {#%s} to the heading to keep deep links working", change.renamed.Line, change.old.ID, change.renamed.ID, change.old.ID)})
+			}
+		}
+		if len(pins) > 0 {
+			if err := pinAnchors(filepath.Join(anchors.Dir, doc.entry.RelPath), pins); err != nil {
+				return result, err
+			}
+		}
+	}
+	return result, nil
+}
+
+// anchorChange is a heading ID of the baseline missing from the page.
+type anchorChange struct {
+	old anchorRecord
+	// renamed is the heading that took its place, when there is one.
+	renamed *renderer.HeadingAnchor
+}
+
+// changedAnchors returns the IDs of old missing from current. A missing ID
+// is matched to a renamed heading when the headings around it are
+// unchanged and as many new generated IDs appeared between them as old
+// ones disappeared.
+func changedAnchors(old []anchorRecord, current []renderer.HeadingAnchor) []anchorChange {
+	index := make(map[string]int, len(current))
+	for i, heading := range current {
+		index[heading.ID] = i
+	}
+	known := make(map[string]bool, len(old))
+	for _, record := range old {
+		known[record.ID] = true
+	}
+
+	var changes []anchorChange
+	for i := 0; i < len(old); {
+		if _, ok := index[old[i].ID]; ok {
+			i++
+			continue
+		}
+		// old[i:j] is a run of missing IDs between two kept headings, or
+		// the ends of the page.
+		j := i
+		for j < len(old) {
+			if _, ok := index[old[j].ID]; ok {
+				break
+			}
+			j++
+		}
+		from, to := 0, len(current)
+		if i > 0 {
+			from = index[old[i-1].ID] + 1
+		}
+		if j < len(old) {
+			to = index[old[j].ID]
+		}
+		var added []int
+		for k := from; k < to; k++ {
+			if !known[current[k].ID] && !current[k].Pinned {
+				added = append(added, k)
+			}
+		}
+		for k := i; k < j; k++ {
+			change := anchorChange{old: old[k]}
+			if len(added) == j-i {
+				change.renamed = &current[added[k-i]]
+			}
+			changes = append(changes, change)
+		}
+		i = j
+	}
+	return changes
+}
+
+// pinAnchors adds a {#id} attribute to the heading lines of the markdown
+// file name. The ID joins
+// an attribute list the heading already has; otherwise the list is
+// appended, dropping the closing hashes of ATX headings.
+func pinAnchors(name string, pins []renderer.HeadingAnchor) error {
+	content, err := os.ReadFile(name)
+	if err != nil {
+		return err
+	}
+	lines := bytes.Split(content, []byte("\n"))
+	for _, pin := range pins {
+		i := pin.Line - 1
+		line := bytes.TrimRight(lines[i], " \t\r")
+		if trimmed := bytes.TrimRight(line, "#"); len(trimmed) < len(line) && bytes.HasSuffix(trimmed, []byte(" ")) {
+			line = bytes.TrimRight(trimmed, " \t")
+		}
+		cr := bytes.HasSuffix(lines[i], []byte("\r"))
+		if bytes.HasSuffix(line, []byte("}")) && bytes.Contains(line, []byte("{")) {
+			lines[i] = append(append(bytes.Clone(line[:len(line)-1]), " #"+pin.ID...), '}')
+		} else {
+			lines[i] = append(bytes.Clone(line), " {#"+pin.ID+"}"...)
+		}
+		if cr {
+			lines[i] = append(lines[i], '\r')
+		}
+	}
+	return os.WriteFile(name, bytes.Join(lines, []byte("\n")), 0o644)
+}
+
+// loadAnchorBaseline reads the baseline of heading IDs per file.
+func loadAnchorBaseline(name string) (map[string][]anchorRecord, error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	var baseline map[string][]anchorRecord
+	if err := json.Unmarshal(data, &baseline); err != nil {
+		return nil, err
+	}
+	return baseline, nil
+}
+
+// saveAnchorBaseline writes the heading IDs of every file to name. The
+// files are sorted, so the baseline diffs well.
+func saveAnchorBaseline(name string, current map[string][]renderer.HeadingAnchor) error {
+	baseline := make(map[string][]anchorRecord, len(current))
+	for file, headings := range current {
+		records := make([]anchorRecord, 0, len(headings))
+		for _, heading := range headings {
+			records = append(records, anchorRecord{ID: heading.ID, Heading: heading.Text})
+		}
+		baseline[file] = records
+	}
+	data, err := json.MarshalIndent(baseline, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(name, append(data, '\n'), 0o644)
+}
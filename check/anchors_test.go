@@ -0,0 +1,73 @@
+package check
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gomdoc/renderer"
+	"gomdoc/scanner"
+)
+
+func TestAnchors(t *testing.T) {
+	dir := t.TempDir()
+	baseline := filepath.Join(t.TempDir(), "anchors.json")
+	write := func(name, content string) {
+		os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0o755)
+		os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644)
+	}
+	write("guide.md", "---\ntitle: Guide\n---\n# Guide\n\n## Prerequisites\n\n## Install ##\n\n## Usage {.wide}\n\n## Removed\n")
+	write("other.md", "# Other\n\n## Kept {#kept}\n\n## Dropped\n")
+	r := renderer.New()
+	options := AnchorOptions{File: baseline, Dir: dir}
+
+	result, err := Anchors(os.DirFS(dir), scanner.ScanOptions{}, r, options)
+	if err != nil || len(result.Changed) != 0 {
+		t.Fatalf("expected the baseline written without changes, got %+v, %v", result, err)
+	}
+	if _, err := os.Stat(baseline); err != nil {
+		t.Fatalf("expected the baseline written: %v", err)
+	}
+
+	write("guide.md", "---\ntitle: Guide\n---\n# Guide\n\n## Requirements\n\n## Installation ##\n\n## How to Use {.wide}\n\n## New Section\n\nText.\n")
+	write("other.md", "# Other\n\n## Still Kept {#kept}\n")
+	result, err = Anchors(os.DirFS(dir), scanner.ScanOptions{}, r, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var changed []string
+	for _, problem := range result.Changed {
+		changed = append(changed, problem.String())
+	}
+	want := []string{
+		"guide.md: line 6: heading anchor #prerequisites changed to #requirements; add {#prerequisites} to the heading to keep deep links working",
+		"guide.md: line 8: heading anchor #install changed to #installation; add {#install} to the heading to keep deep links working",
+		"guide.md: line 10: heading anchor #usage changed to #how-to-use; add {#usage} to the heading to keep deep links working",
+		"guide.md: line 12: heading anchor #removed changed to #new-section; add {#removed} to the heading to keep deep links working",
+		"other.md: heading anchor #dropped (Dropped) is gone, breaking deep links to it",
+	}
+	if strings.Join(changed, "\n") != strings.Join(want, "\n") {
+		t.Errorf("expected changes\n%s\ngot\n%s", strings.Join(want, "\n"), strings.Join(changed, "\n"))
+	}
+
+	options.Pin = true
+	result, err = Anchors(os.DirFS(dir), scanner.ScanOptions{}, r, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Pinned) != 4 || len(result.Changed) != 1 || !strings.Contains(result.Changed[0].Message, "#dropped (Dropped) is gone") {
+		t.Errorf("expected the renamed headings pinned and the removed one reported, got %+v", result)
+	}
+	data, _ := os.ReadFile(filepath.Join(dir, "guide.md"))
+	if got := string(data); got != "---\ntitle: Guide\n---\n# Guide\n\n## Requirements {#prerequisites}\n\n## Installation {#install}\n\n## How to Use {.wide #usage}\n\n## New Section {#removed}\n\nText.\n" {
+		t.Errorf("expected the old IDs pinned, got:\n%s", got)
+	}
+
+	options.Pin, options.Update = false, true
+	Anchors(os.DirFS(dir), scanner.ScanOptions{}, r, options)
+	options.Update = false
+	if result, _ := Anchors(os.DirFS(dir), scanner.ScanOptions{}, r, options); len(result.Changed) != 0 {
+		t.Errorf("expected no changes after updating the baseline, got %+v", result.Changed)
+	}
+}
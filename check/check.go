@@ -0,0 +1,64 @@
+// Package check validates a documentation tree: broken internal links and
+// common authoring mistakes, so problems surface before readers hit them.
+package check
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"strings"
+
+	"gomdoc/renderer"
+	"gomdoc/scanner"
+)
+
+// Problem is a single finding in a markdown file.
+type Problem struct {
+	// File is the path relative to the documentation root.
+	File string
+	// Message describes the problem.
+	Message string
+}
+
+// String formats the problem as "file: message".
+func (p Problem) String() string {
+	return p.File + ": " + p.Message
+}
+
+// document is a markdown file loaded for checking.
+type document struct {
+	entry       scanner.FileEntry
+	frontmatter renderer.Frontmatter
+	body        []byte
+	// bodyLine is the line of the file the body starts on, after the
+	// frontmatter, counting from 0.
+	bodyLine int
+}
+
+// loadDocuments scans fsys and reads every markdown file.
+func loadDocuments(fsys fs.FS, opts scanner.ScanOptions) ([]document, error) {
+	entries, err := scanner.ScanFS(fsys, opts)
+	if err != nil {
+		return nil, fmt.Errorf("scan: %w", err)
+	}
+
+	docs := make([]document, 0, len(entries))
+	for _, entry := range entries {
+		content, err := fs.ReadFile(fsys, filepath.ToSlash(entry.RelPath))
+		if err != nil {
+			return nil, fmt.Errorf("read %s: %w", entry.RelPath, err)
+		}
+		frontmatter, body := renderer.ParseFrontmatter(content)
+		bodyLine := bytes.Count(content[:len(content)-len(body)], []byte("\n"))
+		docs = append(docs, document{entry: entry, frontmatter: frontmatter, body: body, bodyLine: bodyLine})
+	}
+	return docs, nil
+}
+
+// sourceRoute returns the route derived from the file path itself, which
+// is what rendered links point to before numeric prefixes are stripped.
+func sourceRoute(entry scanner.FileEntry) string {
+	relPath := filepath.ToSlash(entry.RelPath)
+	return "/" + strings.TrimSuffix(relPath, filepath.Ext(relPath))
+}
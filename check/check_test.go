@@ -0,0 +1,130 @@
+package check
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"gomdoc/renderer"
+	"gomdoc/scanner"
+)
+
+// mapFS builds an in-memory documentation tree from file contents.
+func mapFS(files map[string]string) fstest.MapFS {
+	fsys := fstest.MapFS{}
+	for name, content := range files {
+		fsys[name] = &fstest.MapFile{Data: []byte(content)}
+	}
+	return fsys
+}
+
+func TestLinksReportsBrokenTargets(t *testing.T) {
+	files := mapFS(map[string]string{
+		"index.md":          "# Home\n[Setup](guide/setup.md) [Gone](missing.md) [Guide](/guide) [Web](https://example.com) [Top](#top)\n",
+		"guide/setup.md":    "# Setup\n[Back](../index.md) ![Diagram](diagram.png) [Sibling](other.md)\n",
+		"guide/diagram.png": "png",
+	})
+
+	problems, err := Links(files, scanner.ScanOptions{}, renderer.New())
+	if err != nil {
+		t.Fatalf("Links failed: %v", err)
+	}
+	var got []string
+	for _, p := range problems {
+		got = append(got, p.String())
+	}
+	want := []string{"guide/setup.md: broken link to /guide/other", "index.md: broken link to /missing"}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("unexpected problems:\n%s", strings.Join(got, "\n"))
+	}
+}
+
+func TestLinksAcceptsPrefixedTargets(t *testing.T) {
+	files := mapFS(map[string]string{
+		"01-intro.md": "# Intro\n[Setup](02-setup.md)\n",
+		"02-setup.md": "# Setup\n[Intro](/intro)\n",
+	})
+
+	problems, err := Links(files, scanner.ScanOptions{StripNumericPrefix: true}, renderer.New())
+	if err != nil {
+		t.Fatalf("Links failed: %v", err)
+	}
+	if len(problems) != 0 {
+		t.Errorf("expected no problems, got %v", problems)
+	}
+}
+
+func TestLint(t *testing.T) {
+	files := mapFS(map[string]string{
+		"empty.md":    "\n",
+		"good.md":     "---\ntitle: Good\ndate: March 1, 2024\nupdated: 15.1.2025\n---\nText.\n",
+		"dates.md":    "---\ndate: 2024-03-01\nupdated: 01/15/2025\n---\n# Dates\n",
+		"notitle.md":  "Just text.\n",
+		"twotitle.md": "# One\n\n# Two\n",
+		"fence.md":    "# Fence\n```go\n# not a heading\n",
+		"layout.md":   "---\nlayout: poster\n---\n# Layout\n",
+		"publish.md":  "---\npublish_at: next week\n---\n# Publish\n",
+		"series.md":   "---\nseries: Onboarding\nseries_part: three\n---\n# Series\n",
+		"schema.md":   "---\nschema_type: HowTo\n---\n# Schema\n",
+	})
+
+	problems, err := Lint(files, scanner.ScanOptions{})
+	if err != nil {
+		t.Fatalf("Lint failed: %v", err)
+	}
+	got := make(map[string]string)
+	for _, p := range problems {
+		got[p.File] = p.Message
+	}
+	if len(got) != 9 {
+		t.Errorf("expected problems in 9 files, got %v", problems)
+	}
+	if _, ok := got["good.md"]; ok {
+		t.Errorf("expected good.md to pass, got %q", got["good.md"])
+	}
+	if got["fence.md"] != "unclosed code fence" {
+		t.Errorf("expected unclosed fence in fence.md, got %q", got["fence.md"])
+	}
+	if got["twotitle.md"] != "multiple level-1 headings" {
+		t.Errorf("expected multiple headings in twotitle.md, got %q", got["twotitle.md"])
+	}
+	if !strings.HasPrefix(got["layout.md"], `unknown layout "poster"`) {
+		t.Errorf("expected unknown layout in layout.md, got %q", got["layout.md"])
+	}
+	if !strings.HasPrefix(got["publish.md"], `publish_at: invalid time "next week"`) {
+		t.Errorf("expected invalid publish_at in publish.md, got %q", got["publish.md"])
+	}
+	if !strings.HasPrefix(got["dates.md"], `updated: invalid date "01/15/2025"`) {
+		t.Errorf("expected invalid updated in dates.md, got %q", got["dates.md"])
+	}
+	if !strings.HasPrefix(got["schema.md"], `schema_type: unknown type "HowTo"`) {
+		t.Errorf("expected unknown schema_type in schema.md, got %q", got["schema.md"])
+	}
+	if !strings.HasPrefix(got["series.md"], `series_part: invalid part "three"`) {
+		t.Errorf("expected invalid series_part in series.md, got %q", got["series.md"])
+	}
+}
+
+func TestLinksReportsMissingAnchors(t *testing.T) {
+	files := mapFS(map[string]string{
+		"index.md":       "# Home\n\n## Usage\n\n[Usage](#usage) [Old](#installation) [Setup](guide/setup.md#requirements) [Renamed](guide/setup.md#prerequisites) [Top](#top)\n",
+		"guide/setup.md": "# Setup\n\n## Requirements\n\n## Steps {#install-steps}\n\n[Steps](#install-steps) [Home](../index.md#usage) [PDF](manual.pdf#page=2)\n",
+	})
+
+	problems, err := Links(files, scanner.ScanOptions{}, renderer.New())
+	if err != nil {
+		t.Fatalf("Links failed: %v", err)
+	}
+	var got []string
+	for _, p := range problems {
+		got = append(got, p.String())
+	}
+	want := []string{
+		"guide/setup.md: broken link to manual.pdf#page=2",
+		"index.md: missing anchor in link to #installation",
+		"index.md: missing anchor in link to /guide/setup#prerequisites",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("unexpected problems:\n%s", strings.Join(got, "\n"))
+	}
+}
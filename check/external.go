@@ -0,0 +1,251 @@
+package check
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"gomdoc/renderer"
+	"gomdoc/scanner"
+)
+
+// userAgent identifies the external link checker. Some sites refuse
+// requests without a browser-like agent.
+const userAgent = "Mozilla/5.0 (compatible; gomdoc link checker)"
+
+// ExternalOptions configures External. Zero values select the defaults.
+type ExternalOptions struct {
+	// Timeout limits each request. Defaults to 10 seconds.
+	Timeout time.Duration
+	// Concurrency is the number of URLs checked at once. Defaults to 8.
+	Concurrency int
+	// CacheFile stores the URLs that worked, so later runs skip them
+	// until CacheTTL has passed. Empty disables the cache.
+	CacheFile string
+	// CacheTTL is how long a working URL is trusted. Defaults to 24 hours.
+	CacheTTL time.Duration
+	// Skip lists domains that are not checked, such as flaky sites or
+	// ones that refuse robots. Subdomains are skipped too.
+	Skip []string
+	// Client sends the requests. Nil uses a client with Timeout.
+	Client *http.Client
+}
+
+// withDefaults fills in the zero values of o.
+func (o ExternalOptions) withDefaults() ExternalOptions {
+	if o.Timeout <= 0 {
+		o.Timeout = 10 * time.Second
+	}
+	if o.Concurrency <= 0 {
+		o.Concurrency = 8
+	}
+	if o.CacheTTL <= 0 {
+		o.CacheTTL = 24 * time.Hour
+	}
+	if o.Client == nil {
+		o.Client = &http.Client{Timeout: o.Timeout}
+	}
+	return o
+}
+
+// External reports external http and https links that fail: requests that
+// error, time out, or answer with a 4xx or 5xx status other than 429 Too
+// Many Requests. Each URL is requested once, however many pages link to it,
+// with HEAD first and GET when the server refuses HEAD.
+func External(fsys fs.FS, opts scanner.ScanOptions, r *renderer.Renderer, ext ExternalOptions) ([]Problem, error) {
+	ext = ext.withDefaults()
+	docs, err := loadDocuments(fsys, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var problems []Problem
+	linkedFrom := make(map[string][]string)
+	for _, doc := range docs {
+		html, err := r.RenderWithLinks(doc.body, pageDir(doc)[1:])
+		if err != nil {
+			problems = append(problems, Problem{File: doc.entry.RelPath, Message: "render failed: " + err.Error()})
+			continue
+		}
+		seen := make(map[string]bool)
+		for _, match := range hrefPattern.FindAllStringSubmatch(string(html), -1) {
+			link := strings.ReplaceAll(match[1], "&amp;", "&")
+			if !isExternal(link) || skipped(link, ext.Skip) || seen[link] {
+				continue
+			}
+			seen[link] = true
+			linkedFrom[link] = append(linkedFrom[link], doc.entry.RelPath)
+		}
+	}
+
+	cache := loadLinkCache(ext.CacheFile)
+	now := time.Now()
+	var pending []string
+	for link := range linkedFrom {
+		if checked, ok := cache[link]; !ok || now.Sub(checked) > ext.CacheTTL {
+			pending = append(pending, link)
+		}
+	}
+	sort.Strings(pending)
+
+	failures := checkURLs(ext, pending)
+	for link, checked := range cache {
+		if now.Sub(checked) > ext.CacheTTL {
+			delete(cache, link)
+		}
+	}
+	for _, link := range pending {
+		if _, failed := failures[link]; !failed {
+			cache[link] = now
+		}
+	}
+	if err := saveLinkCache(ext.CacheFile, cache); err != nil {
+		return nil, fmt.Errorf("save link cache: %w", err)
+	}
+
+	for link, reason := range failures {
+		for _, file := range linkedFrom[link] {
+			problems = append(problems, Problem{File: file, Message: "dead external link to " + link + " (" + reason + ")"})
+		}
+	}
+	sort.Slice(problems, func(i, j int) bool {
+		if problems[i].File != problems[j].File {
+			return problems[i].File < problems[j].File
+		}
+		return problems[i].Message < problems[j].Message
+	})
+	return problems, nil
+}
+
+// isExternal reports whether link is an absolute http or https URL.
+func isExternal(link string) bool {
+	u, err := url.Parse(link)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// skipped reports whether link points to one of the domains or their
+// subdomains.
+func skipped(link string, domains []string) bool {
+	u, err := url.Parse(link)
+	if err != nil {
+		return false
+	}
+	host := strings.ToLower(u.Hostname())
+	for _, domain := range domains {
+		domain = strings.ToLower(strings.TrimPrefix(domain, "."))
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkURLs requests the links with ext.Concurrency workers and returns
+// the reason each failing link failed.
+func checkURLs(ext ExternalOptions, links []string) map[string]string {
+	var mu sync.Mutex
+	failures := make(map[string]string)
+	queue := make(chan string)
+
+	var wg sync.WaitGroup
+	for range min(ext.Concurrency, len(links)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for link := range queue {
+				if reason := checkURL(ext, link); reason != "" {
+					mu.Lock()
+					failures[link] = reason
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+	for _, link := range links {
+		queue <- link
+	}
+	close(queue)
+	wg.Wait()
+	return failures
+}
+
+// checkURL requests link and returns why it failed, or an empty string
+// when it works. Servers that refuse HEAD are asked again with GET.
+func checkURL(ext ExternalOptions, link string) string {
+	status, err := request(ext, http.MethodHead, link)
+	if err == nil && status >= 400 && status != http.StatusTooManyRequests {
+		status, err = request(ext, http.MethodGet, link)
+	}
+	switch {
+	case isTimeout(err):
+		return "timed out after " + ext.Timeout.String()
+	case err != nil:
+		return err.Error()
+	case status >= 400 && status != http.StatusTooManyRequests:
+		return fmt.Sprintf("%d %s", status, http.StatusText(status))
+	}
+	return ""
+}
+
+// request sends a single request and returns the response status.
+func request(ext ExternalOptions, method, link string) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), ext.Timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, method, link, nil)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("User-Agent", userAgent)
+	resp, err := ext.Client.Do(req)
+	if err != nil {
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) && !urlErr.Timeout() {
+			return 0, urlErr.Err
+		}
+		return 0, err
+	}
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	resp.Body.Close()
+	return resp.StatusCode, nil
+}
+
+// isTimeout reports whether err is a network timeout.
+func isTimeout(err error) bool {
+	var timeout interface{ Timeout() bool }
+	return errors.As(err, &timeout) && timeout.Timeout()
+}
+
+// loadLinkCache reads the times the cached URLs last worked. A missing or
+// unreadable cache is empty.
+func loadLinkCache(name string) map[string]time.Time {
+	cache := make(map[string]time.Time)
+	if name == "" {
+		return cache
+	}
+	if data, err := os.ReadFile(name); err == nil {
+		json.Unmarshal(data, &cache)
+	}
+	return cache
+}
+
+// saveLinkCache writes the cache to name, unless name is empty.
+func saveLinkCache(name string, cache map[string]time.Time) error {
+	if name == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(cache, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(name, data, 0o644)
+}
@@ -0,0 +1,83 @@
+package check
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"gomdoc/renderer"
+	"gomdoc/scanner"
+)
+
+func TestExternalReportsDeadLinks(t *testing.T) {
+	var mu sync.Mutex
+	requests := make(map[string]int)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests[r.Method+" "+r.URL.Path]++
+		mu.Unlock()
+		switch r.URL.Path {
+		case "/gone":
+			http.NotFound(w, r)
+		case "/no-head":
+			if r.Method == http.MethodHead {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+			}
+		case "/slow":
+			time.Sleep(300 * time.Millisecond)
+		}
+	}))
+	defer srv.Close()
+	count := func(request string) int {
+		mu.Lock()
+		defer mu.Unlock()
+		return requests[request]
+	}
+
+	files := mapFS(map[string]string{
+		"index.md": "# Home\n[OK](" + srv.URL + "/ok) [Gone](" + srv.URL + "/gone) [No HEAD](" + srv.URL + "/no-head) [Local](guide.md)\n",
+		"guide.md": "# Guide\n[Gone](" + srv.URL + "/gone) [Slow](" + srv.URL + "/slow) [Flaky](https://flaky.example.com/page)\n",
+	})
+	cache := filepath.Join(t.TempDir(), "links.json")
+	ext := ExternalOptions{
+		Timeout:     100 * time.Millisecond,
+		Concurrency: 2,
+		CacheFile:   cache,
+		Skip:        []string{"example.com"},
+	}
+
+	problems, err := External(files, scanner.ScanOptions{}, renderer.New(), ext)
+	if err != nil {
+		t.Fatalf("External failed: %v", err)
+	}
+	var got []string
+	for _, p := range problems {
+		got = append(got, p.String())
+	}
+	want := []string{
+		"guide.md: dead external link to " + srv.URL + "/gone (404 Not Found)",
+		"guide.md: dead external link to " + srv.URL + "/slow (timed out after 100ms)",
+		"index.md: dead external link to " + srv.URL + "/gone (404 Not Found)",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("unexpected problems:\n%s", strings.Join(got, "\n"))
+	}
+	if count("HEAD /gone") != 1 || count("GET /no-head") != 1 {
+		t.Errorf("expected each URL checked once, falling back to GET")
+	}
+
+	// Working links are cached; dead ones are checked again.
+	mu.Lock()
+	clear(requests)
+	mu.Unlock()
+	if _, err := External(files, scanner.ScanOptions{}, renderer.New(), ext); err != nil {
+		t.Fatalf("External failed: %v", err)
+	}
+	if count("HEAD /ok") != 0 || count("HEAD /no-head") != 0 || count("HEAD /gone") != 1 {
+		t.Errorf("expected cached links skipped and dead links checked again")
+	}
+}
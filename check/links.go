@@ -0,0 +1,181 @@
+package check
+
+import (
+	"io/fs"
+	"net/url"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"gomdoc/renderer"
+	"gomdoc/scanner"
+)
+
+var (
+	// hrefPattern matches link targets in rendered HTML.
+	hrefPattern = regexp.MustCompile(`href="([^"]*)"`)
+	// schemePattern matches URLs with a scheme such as https: or mailto:.
+	schemePattern = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9+.-]*:`)
+	// idPattern matches element IDs in rendered HTML, the targets of
+	// #fragment links.
+	idPattern = regexp.MustCompile(`\s(?:id|name)="([^"]*)"`)
+)
+
+// knownAnchors are fragments that need no ID in the markdown: browsers
+// scroll to the top for #top, and the server adds the references section
+// of pages with citations.
+var knownAnchors = map[string]bool{"top": true, "references": true}
+
+// builtinRoutes are served by gomdoc itself rather than by a document.
+var builtinRoutes = []string{"/", "/browse"}
+
+// Links reports internal links that point to no document, directory, or
+// file, and #fragment links to a heading or other ID missing from the
+// linked page. Links are checked after rendering, so they are validated
+// exactly as the server rewrites them and against the heading IDs it
+// generates. fsys holds the documentation tree, such as os.DirFS of the
+// base directory.
+func Links(fsys fs.FS, opts scanner.ScanOptions, r *renderer.Renderer) ([]Problem, error) {
+	docs, err := loadDocuments(fsys, opts)
+	if err != nil {
+		return nil, err
+	}
+	routes := knownRoutes(docs)
+
+	var problems []Problem
+	pages := make([]string, len(docs))
+	anchors := make(map[string]map[string]bool)
+	for i, doc := range docs {
+		html, err := r.RenderWithLinks(doc.body, pageDir(doc)[1:])
+		if err != nil {
+			problems = append(problems, Problem{File: doc.entry.RelPath, Message: "render failed: " + err.Error()})
+			continue
+		}
+		pages[i] = string(html)
+		ids := pageAnchors(pages[i])
+		anchors[doc.entry.URLPath] = ids
+		anchors[sourceRoute(doc.entry)] = ids
+	}
+
+	for i, doc := range docs {
+		for _, target := range brokenLinks(pages[i], pageDir(doc), routes, fsys) {
+			problems = append(problems, Problem{File: doc.entry.RelPath, Message: "broken link to " + target})
+		}
+		for _, target := range brokenAnchors(pages[i], pageDir(doc), doc.entry.URLPath, anchors) {
+			problems = append(problems, Problem{File: doc.entry.RelPath, Message: "missing anchor in link to " + target})
+		}
+	}
+	return problems, nil
+}
+
+// pageDir returns the route directory of a document, used for its
+// relative links.
+func pageDir(doc document) string {
+	dir := path.Dir(filepath.ToSlash(doc.entry.RelPath))
+	if dir == "." {
+		return "/"
+	}
+	return "/" + dir
+}
+
+// pageAnchors collects the IDs and anchor names in a rendered page.
+func pageAnchors(html string) map[string]bool {
+	ids := make(map[string]bool)
+	for _, match := range idPattern.FindAllStringSubmatch(html, -1) {
+		ids[match[1]] = true
+	}
+	return ids
+}
+
+// brokenAnchors returns the links in html whose #fragment names no ID in
+// the linked document. Links to the current page, such as #usage, are
+// checked against pagePath. Fragments of links to anything but a document
+// are not checked, nor are the knownAnchors.
+func brokenAnchors(html, pageDir, pagePath string, anchors map[string]map[string]bool) []string {
+	var broken []string
+	for _, match := range hrefPattern.FindAllStringSubmatch(html, -1) {
+		href := match[1]
+		i := strings.Index(href, "#")
+		if i < 0 {
+			continue
+		}
+		fragment := href[i+1:]
+		if unescaped, err := url.PathUnescape(fragment); err == nil {
+			fragment = unescaped
+		}
+		if fragment == "" || knownAnchors[fragment] {
+			continue
+		}
+
+		target := pagePath
+		if i > 0 {
+			if target = internalTarget(href, pageDir); target == "" {
+				continue
+			}
+		}
+		if ids, ok := anchors[target]; ok && !ids[fragment] {
+			broken = append(broken, href)
+		}
+	}
+	return broken
+}
+
+// knownRoutes collects every document and directory route, both in pretty
+// and in source form.
+func knownRoutes(docs []document) map[string]bool {
+	routes := make(map[string]bool)
+	for _, route := range builtinRoutes {
+		routes[route] = true
+	}
+	for _, doc := range docs {
+		for _, route := range []string{doc.entry.URLPath, sourceRoute(doc.entry)} {
+			for ; route != "/" && route != "."; route = path.Dir(route) {
+				routes[route] = true
+			}
+		}
+	}
+	return routes
+}
+
+// brokenLinks returns the internal link targets in html that resolve to
+// nothing. pageDir is the route directory used for relative links.
+func brokenLinks(html, pageDir string, routes map[string]bool, fsys fs.FS) []string {
+	var broken []string
+	for _, match := range hrefPattern.FindAllStringSubmatch(html, -1) {
+		target := internalTarget(match[1], pageDir)
+		if target == "" || routes[target] || fileExists(fsys, target) {
+			continue
+		}
+		broken = append(broken, match[1])
+	}
+	return broken
+}
+
+// internalTarget resolves an href to an absolute site path without query
+// or fragment. It returns "" for external links and same-page anchors.
+func internalTarget(href, pageDir string) string {
+	if href == "" || strings.HasPrefix(href, "#") || strings.HasPrefix(href, "//") || schemePattern.MatchString(href) {
+		return ""
+	}
+	if i := strings.IndexAny(href, "?#"); i >= 0 {
+		href = href[:i]
+	}
+	if unescaped, err := url.PathUnescape(href); err == nil {
+		href = unescaped
+	}
+	if !strings.HasPrefix(href, "/") {
+		href = path.Join(pageDir, href)
+	}
+	return path.Clean(href)
+}
+
+// fileExists reports whether an asset such as an image exists in fsys.
+func fileExists(fsys fs.FS, sitePath string) bool {
+	name := strings.TrimPrefix(sitePath, "/")
+	if name == "" {
+		name = "."
+	}
+	_, err := fs.Stat(fsys, name)
+	return err == nil
+}
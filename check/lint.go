@@ -0,0 +1,86 @@
+package check
+
+import (
+	"fmt"
+	"io/fs"
+	"strings"
+
+	"gomdoc/scanner"
+	"gomdoc/search"
+	"gomdoc/templates"
+)
+
+// Lint reports authoring mistakes: empty documents, pages without a title,
+// several level-1 headings, unclosed code fences, unknown layouts or themes
+// in the frontmatter, invalid date, updated, publish_at, or expire_at
+// times, invalid series_part numbers, and unknown schema_type values.
+func Lint(fsys fs.FS, opts scanner.ScanOptions) ([]Problem, error) {
+	docs, err := loadDocuments(fsys, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var problems []Problem
+	for _, doc := range docs {
+		for _, message := range lintDocument(doc) {
+			problems = append(problems, Problem{File: doc.entry.RelPath, Message: message})
+		}
+	}
+	return problems, nil
+}
+
+// lintDocument returns the problems found in a single document.
+func lintDocument(doc document) []string {
+	if strings.TrimSpace(string(doc.body)) == "" {
+		return []string{"document is empty"}
+	}
+
+	var messages []string
+	titles, fenceOpen := scanStructure(string(doc.body))
+	if titles == 0 && doc.frontmatter.Title == "" {
+		messages = append(messages, "missing title: add a title to the frontmatter or a level-1 heading")
+	}
+	if titles > 1 {
+		messages = append(messages, "multiple level-1 headings")
+	}
+	if fenceOpen {
+		messages = append(messages, "unclosed code fence")
+	}
+	if layout := doc.frontmatter.Layout; layout != "" && !templates.HasLayout(layout) {
+		messages = append(messages, fmt.Sprintf("unknown layout %q (available: %s)", layout, strings.Join(templates.Layouts(), ", ")))
+	}
+	if theme := doc.frontmatter.Theme; theme != "" && !templates.HasTheme(theme) {
+		messages = append(messages, fmt.Sprintf("unknown theme %q (available: %s)", theme, strings.Join(templates.Themes(), ", ")))
+	}
+	for _, field := range []struct{ key, value string }{{"date", doc.frontmatter.Date}, {"updated", doc.frontmatter.Updated}} {
+		if _, err := search.ParseDate(field.value); field.value != "" && err != nil {
+			messages = append(messages, fmt.Sprintf("%s: %v", field.key, err))
+		}
+	}
+	if _, err := search.ParseSchedule(doc.frontmatter); err != nil {
+		messages = append(messages, err.Error())
+	}
+	if _, err := search.ParseSeriesPart(doc.frontmatter); err != nil {
+		messages = append(messages, err.Error())
+	}
+	if _, err := search.ParseSchemaType(doc.frontmatter); err != nil {
+		messages = append(messages, err.Error())
+	}
+	return messages
+}
+
+// scanStructure counts level-1 headings outside code fences and reports
+// whether a fence is left open at the end of the body.
+func scanStructure(body string) (titles int, fenceOpen bool) {
+	for _, line := range strings.Split(body, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			fenceOpen = !fenceOpen
+			continue
+		}
+		if !fenceOpen && strings.HasPrefix(line, "# ") {
+			titles++
+		}
+	}
+	return titles, fenceOpen
+}
@@ -0,0 +1,251 @@
+package cite
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// latexReplacer turns the LaTeX escapes common in titles into plain text.
+var latexReplacer = strings.NewReplacer(
+	`\&`, "&", `\%`, "%", `\$`, "$", `\_`, "_", `\#`, "#",
+	"---", "—", "--", "–", "~", " ",
+)
+
+// ParseBibTeX parses BibTeX entries. @string, @preamble, and @comment
+// blocks are skipped; string macros are not expanded.
+func ParseBibTeX(data []byte) (Bibliography, error) {
+	p := &bibParser{src: []rune(string(data))}
+	bib := make(Bibliography)
+	for p.skipTo('@') {
+		kind := strings.ToLower(p.readWhile(func(r rune) bool { return unicode.IsLetter(r) }))
+		p.skipSpace()
+		if !p.consume('{') && !p.consume('(') {
+			return nil, p.errorf("expected { after @%s", kind)
+		}
+		if kind == "string" || kind == "preamble" || kind == "comment" {
+			p.skipBalanced()
+			continue
+		}
+
+		key := strings.TrimSpace(p.readWhile(func(r rune) bool { return r != ',' && r != '}' && r != ')' }))
+		if key == "" {
+			return nil, p.errorf("@%s entry without key", kind)
+		}
+		fields, err := p.readFields()
+		if err != nil {
+			return nil, fmt.Errorf("entry %s: %w", key, err)
+		}
+		bib[key] = bibEntry(key, fields)
+	}
+	return bib, nil
+}
+
+// bibEntry converts raw BibTeX fields into an entry.
+func bibEntry(key string, fields map[string]string) Entry {
+	entry := Entry{
+		Key:       key,
+		Title:     cleanBibValue(fields["title"]),
+		Year:      cleanBibValue(fields["year"]),
+		Publisher: cleanBibValue(fields["publisher"]),
+		Volume:    cleanBibValue(fields["volume"]),
+		Pages:     cleanBibValue(fields["pages"]),
+		DOI:       cleanBibValue(fields["doi"]),
+		URL:       cleanBibValue(fields["url"]),
+	}
+	for _, name := range []string{"journal", "booktitle", "series"} {
+		if value := cleanBibValue(fields[name]); value != "" {
+			entry.Container = value
+			break
+		}
+	}
+	if entry.Publisher == "" {
+		entry.Publisher = cleanBibValue(fields["institution"])
+	}
+
+	authors := fields["author"]
+	if authors == "" {
+		authors = fields["editor"]
+	}
+	for _, name := range splitBibAuthors(authors) {
+		entry.Authors = append(entry.Authors, bibName(name))
+	}
+	return entry
+}
+
+// splitBibAuthors splits an author list on " and " outside braces.
+func splitBibAuthors(value string) []string {
+	var names []string
+	depth, start := 0, 0
+	for i := 0; i < len(value); i++ {
+		switch value[i] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+		}
+		if depth == 0 && i+5 <= len(value) && strings.EqualFold(value[i:i+5], " and ") {
+			names = append(names, value[start:i])
+			start = i + 5
+		}
+	}
+	if rest := strings.TrimSpace(value[start:]); rest != "" {
+		names = append(names, rest)
+	}
+	return names
+}
+
+// bibName formats a BibTeX name. Fully braced names such as
+// {World Health Organization} are kept literal.
+func bibName(name string) string {
+	name = strings.TrimSpace(name)
+	if strings.HasPrefix(name, "{") && strings.HasSuffix(name, "}") && !strings.Contains(name[1:len(name)-1], "{") {
+		return cleanBibValue(name)
+	}
+	name = cleanBibValue(name)
+	if family, given, ok := strings.Cut(name, ","); ok {
+		return formatName(family, given)
+	}
+	words := strings.Fields(name)
+	if len(words) < 2 {
+		return name
+	}
+	return formatName(words[len(words)-1], strings.Join(words[:len(words)-1], " "))
+}
+
+// cleanBibValue strips grouping braces, LaTeX escapes, and extra spaces.
+func cleanBibValue(value string) string {
+	value = strings.NewReplacer("{", "", "}", "").Replace(value)
+	return strings.Join(strings.Fields(latexReplacer.Replace(value)), " ")
+}
+
+// bibParser is a cursor over BibTeX source.
+type bibParser struct {
+	src []rune
+	pos int
+}
+
+// errorf reports an error at the current line.
+func (p *bibParser) errorf(format string, args ...any) error {
+	line := 1 + strings.Count(string(p.src[:p.pos]), "\n")
+	return fmt.Errorf("line %d: %s", line, fmt.Sprintf(format, args...))
+}
+
+// skipTo advances past the next occurrence of r, reporting whether one was
+// found.
+func (p *bibParser) skipTo(r rune) bool {
+	for p.pos < len(p.src) {
+		p.pos++
+		if p.src[p.pos-1] == r {
+			return true
+		}
+	}
+	return false
+}
+
+// skipSpace advances past whitespace.
+func (p *bibParser) skipSpace() {
+	for p.pos < len(p.src) && unicode.IsSpace(p.src[p.pos]) {
+		p.pos++
+	}
+}
+
+// consume advances past r if it is the next rune.
+func (p *bibParser) consume(r rune) bool {
+	if p.pos < len(p.src) && p.src[p.pos] == r {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+// readWhile returns the runes accepted by keep.
+func (p *bibParser) readWhile(keep func(rune) bool) string {
+	start := p.pos
+	for p.pos < len(p.src) && keep(p.src[p.pos]) {
+		p.pos++
+	}
+	return string(p.src[start:p.pos])
+}
+
+// skipBalanced skips to the brace closing the current block.
+func (p *bibParser) skipBalanced() {
+	for depth := 1; p.pos < len(p.src) && depth > 0; p.pos++ {
+		switch p.src[p.pos] {
+		case '{', '(':
+			depth++
+		case '}', ')':
+			depth--
+		}
+	}
+}
+
+// readFields reads "name = value" pairs up to the end of the entry.
+func (p *bibParser) readFields() (map[string]string, error) {
+	fields := make(map[string]string)
+	for {
+		p.skipSpace()
+		p.consume(',')
+		p.skipSpace()
+		if p.consume('}') || p.consume(')') {
+			return fields, nil
+		}
+		if p.pos >= len(p.src) {
+			return nil, p.errorf("unterminated entry")
+		}
+
+		name := strings.ToLower(strings.TrimSpace(p.readWhile(func(r rune) bool { return r != '=' && r != '}' && r != ',' })))
+		if !p.consume('=') {
+			return nil, p.errorf("expected = after field %q", name)
+		}
+		value, err := p.readValue()
+		if err != nil {
+			return nil, err
+		}
+		fields[name] = value
+	}
+}
+
+// readValue reads a braced, quoted, or bare value, joining parts
+// concatenated with #.
+func (p *bibParser) readValue() (string, error) {
+	var sb strings.Builder
+	for {
+		p.skipSpace()
+		if p.pos >= len(p.src) {
+			return "", p.errorf("unterminated value")
+		}
+		switch p.src[p.pos] {
+		case '{':
+			p.pos++
+			start := p.pos
+			p.skipBalanced()
+			sb.WriteString(string(p.src[start : p.pos-1]))
+		case '"':
+			p.pos++
+			start, depth := p.pos, 0
+			for p.pos < len(p.src) && (p.src[p.pos] != '"' || depth > 0) {
+				switch p.src[p.pos] {
+				case '{':
+					depth++
+				case '}':
+					depth--
+				}
+				p.pos++
+			}
+			if p.pos >= len(p.src) {
+				return "", p.errorf("unterminated quoted value")
+			}
+			sb.WriteString(string(p.src[start:p.pos]))
+			p.pos++
+		default:
+			sb.WriteString(strings.TrimSpace(p.readWhile(func(r rune) bool {
+				return r != ',' && r != '}' && r != ')' && r != '#' && !unicode.IsSpace(r)
+			})))
+		}
+		p.skipSpace()
+		if !p.consume('#') {
+			return sb.String(), nil
+		}
+	}
+}
@@ -0,0 +1,253 @@
+// Package cite resolves pandoc-style [@key] citations against a BibTeX or
+// CSL-JSON bibliography, numbering them in order of first use and appending
+// a references section.
+package cite
+
+import (
+	"fmt"
+	"html"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Entry is a single bibliography entry, normalized from BibTeX or CSL-JSON.
+type Entry struct {
+	Key string
+	// Authors are formatted as "Family, G." or kept literal for
+	// organizations.
+	Authors   []string
+	Title     string
+	Year      string
+	Container string // journal, book, or proceedings title
+	Publisher string
+	Volume    string
+	Pages     string
+	DOI       string
+	URL       string
+}
+
+// Bibliography maps citation keys to entries.
+type Bibliography map[string]Entry
+
+// Load reads a bibliography file: .bib files as BibTeX, .json files as
+// CSL-JSON.
+func Load(path string) (Bibliography, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return parse(path, data)
+}
+
+// LoadFS reads a bibliography file from fsys, like Load.
+func LoadFS(fsys fs.FS, name string) (Bibliography, error) {
+	data, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return nil, err
+	}
+	return parse(name, data)
+}
+
+// parse parses bibliography data in the format given by the extension of
+// path.
+func parse(path string, data []byte) (Bibliography, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".bib", ".bibtex":
+		return ParseBibTeX(data)
+	case ".json":
+		return ParseCSLJSON(data)
+	}
+	return nil, fmt.Errorf("%s: unsupported bibliography format, use .bib or .json", path)
+}
+
+// Merge returns a bibliography with the entries of both, preferring other
+// for keys present in both.
+func (b Bibliography) Merge(other Bibliography) Bibliography {
+	merged := make(Bibliography, len(b)+len(other))
+	for key, entry := range b {
+		merged[key] = entry
+	}
+	for key, entry := range other {
+		merged[key] = entry
+	}
+	return merged
+}
+
+var (
+	// citationPattern matches bracketed citations such as [@doe2020],
+	// [@doe2020, p. 12], or [@doe2020; @roe2021].
+	citationPattern = regexp.MustCompile(`\[@[^\[\]]+\]`)
+	// citeItemPattern splits one item of a citation into key and locator.
+	citeItemPattern = regexp.MustCompile(`^@([\w:.#$%&\-+?<>~/]+)\s*(?:,\s*(.*))?$`)
+	// codePattern matches code spans and blocks, which are never scanned
+	// for citations.
+	codePattern = regexp.MustCompile(`(?s)<pre[ >].*?</pre>|<code[ >].*?</code>`)
+)
+
+// Process replaces the citations in rendered HTML with numbered links and
+// appends a references section listing the cited entries in order of first
+// citation. Unknown keys are marked rather than dropped so authors notice
+// them. HTML without citations is returned unchanged.
+func Process(htmlContent []byte, bib Bibliography) []byte {
+	if !citationPattern.Match(htmlContent) {
+		return htmlContent
+	}
+
+	numbers := make(map[string]int)
+	var cited []Entry
+	replace := func(citation []byte) []byte {
+		return []byte(renderCitation(string(citation), bib, numbers, &cited))
+	}
+
+	var out []byte
+	last := 0
+	for _, loc := range codePattern.FindAllIndex(htmlContent, -1) {
+		out = append(out, citationPattern.ReplaceAllFunc(htmlContent[last:loc[0]], replace)...)
+		out = append(out, htmlContent[loc[0]:loc[1]]...)
+		last = loc[1]
+	}
+	out = append(out, citationPattern.ReplaceAllFunc(htmlContent[last:], replace)...)
+
+	if len(cited) == 0 {
+		return out
+	}
+	return append(out, renderReferences(cited)...)
+}
+
+// renderCitation renders one bracketed citation, assigning numbers to keys
+// cited for the first time.
+func renderCitation(citation string, bib Bibliography, numbers map[string]int, cited *[]Entry) string {
+	items := strings.Split(citation[1:len(citation)-1], ";")
+	parts := make([]string, 0, len(items))
+	for _, item := range items {
+		match := citeItemPattern.FindStringSubmatch(strings.TrimSpace(item))
+		if match == nil {
+			return citation
+		}
+		key, locator := html.UnescapeString(match[1]), match[2]
+		entry, ok := bib[key]
+		if !ok {
+			return `<span class="citation citation-missing" title="Unknown reference">` + citation + `</span>`
+		}
+		if numbers[key] == 0 {
+			*cited = append(*cited, entry)
+			numbers[key] = len(*cited)
+		}
+		part := fmt.Sprintf(`<a href="#%s">%d</a>`, refID(key), numbers[key])
+		if locator != "" {
+			part += ", " + locator
+		}
+		parts = append(parts, part)
+	}
+	return `<span class="citation">[` + strings.Join(parts, "; ") + `]</span>`
+}
+
+// renderReferences renders the references section.
+func renderReferences(cited []Entry) string {
+	var sb strings.Builder
+	sb.WriteString("\n" + `<section class="bibliography">` + "\n")
+	sb.WriteString(`<h2 id="references">References</h2>` + "\n")
+	sb.WriteString(`<ol class="references">` + "\n")
+	for _, entry := range cited {
+		sb.WriteString(`<li id="` + refID(entry.Key) + `">` + entry.HTML() + "</li>\n")
+	}
+	sb.WriteString("</ol>\n</section>\n")
+	return sb.String()
+}
+
+// refID returns the anchor ID of a reference.
+func refID(key string) string {
+	return "ref-" + html.EscapeString(key)
+}
+
+// HTML formats the entry in an author-year style:
+// Authors (Year). Title. Container, Volume, Pages. Publisher. DOI or URL.
+// The container is italicized when present, otherwise the title is.
+func (e Entry) HTML() string {
+	var parts []string
+	head := html.EscapeString(joinAuthors(e.Authors))
+	if e.Year != "" {
+		head = strings.TrimSpace(head + " (" + html.EscapeString(e.Year) + ")")
+	}
+	if head != "" {
+		parts = append(parts, head+".")
+	}
+
+	if e.Title != "" {
+		title := html.EscapeString(strings.TrimSuffix(e.Title, "."))
+		if e.Container == "" {
+			title = "<em>" + title + "</em>"
+		}
+		parts = append(parts, title+".")
+	}
+
+	if e.Container != "" {
+		source := []string{"<em>" + html.EscapeString(e.Container) + "</em>"}
+		for _, detail := range []string{e.Volume, e.Pages} {
+			if detail != "" {
+				source = append(source, html.EscapeString(detail))
+			}
+		}
+		parts = append(parts, strings.Join(source, ", ")+".")
+	}
+	if e.Publisher != "" {
+		parts = append(parts, html.EscapeString(e.Publisher)+".")
+	}
+
+	link := e.URL
+	if e.DOI != "" {
+		link = "https://doi.org/" + strings.TrimPrefix(e.DOI, "https://doi.org/")
+	}
+	if link != "" {
+		escaped := html.EscapeString(link)
+		parts = append(parts, `<a href="`+escaped+`">`+escaped+`</a>`)
+	}
+	return strings.Join(parts, " ")
+}
+
+// joinAuthors joins author names: "A", "A & B", or "A, B, & C".
+func joinAuthors(authors []string) string {
+	switch len(authors) {
+	case 0:
+		return ""
+	case 1:
+		return authors[0]
+	case 2:
+		return authors[0] + " & " + authors[1]
+	}
+	return strings.Join(authors[:len(authors)-1], ", ") + ", & " + authors[len(authors)-1]
+}
+
+// formatName formats a person as "Family, G. M." from its family and given
+// names.
+func formatName(family, given string) string {
+	family, given = strings.TrimSpace(family), strings.TrimSpace(given)
+	if given == "" {
+		return family
+	}
+	var initials []string
+	for _, name := range strings.Fields(given) {
+		for _, part := range strings.Split(name, "-") {
+			if r := []rune(part); len(r) > 0 {
+				initials = append(initials, string(r[0])+".")
+			}
+		}
+	}
+	return family + ", " + strings.Join(initials, " ")
+}
+
+// jsonString converts a JSON value that may be a number or a string, such
+// as a year or volume.
+func jsonString(value any) string {
+	switch v := value.(type) {
+	case float64:
+		return strconv.Itoa(int(v))
+	case string:
+		return v
+	}
+	return ""
+}
@@ -0,0 +1,124 @@
+package cite
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testBibTeX = `
+@string{acm = "ACM"}
+
+@article{doe2020,
+  author  = {Doe, Jane and John Smith and {World Health Organization}},
+  title   = {Reliable {Markdown} Pipelines},
+  journal = "Journal of Documentation",
+  volume  = 12,
+  pages   = {1--10},
+  year    = 2020,
+  doi     = {10.1000/xyz},
+}
+
+@book{roe2021,
+  author    = {Roe, Richard},
+  title     = {Writing \& Publishing},
+  publisher = {Example Press},
+  year      = {2021}
+}
+`
+
+func TestParseBibTeX(t *testing.T) {
+	bib, err := ParseBibTeX([]byte(testBibTeX))
+	if err != nil {
+		t.Fatalf("ParseBibTeX failed: %v", err)
+	}
+	if len(bib) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(bib))
+	}
+
+	doe := bib["doe2020"]
+	wantAuthors := []string{"Doe, J.", "Smith, J.", "World Health Organization"}
+	if strings.Join(doe.Authors, "|") != strings.Join(wantAuthors, "|") {
+		t.Errorf("unexpected authors %q", doe.Authors)
+	}
+	if doe.Title != "Reliable Markdown Pipelines" || doe.Container != "Journal of Documentation" ||
+		doe.Volume != "12" || doe.Pages != "1–10" || doe.Year != "2020" || doe.DOI != "10.1000/xyz" {
+		t.Errorf("unexpected entry %+v", doe)
+	}
+	if roe := bib["roe2021"]; roe.Title != "Writing & Publishing" || roe.Publisher != "Example Press" {
+		t.Errorf("unexpected entry %+v", roe)
+	}
+}
+
+func TestParseBibTeXErrors(t *testing.T) {
+	for _, input := range []string{"@article{key, title = {open", "@article{, title = {x}}"} {
+		if _, err := ParseBibTeX([]byte(input)); err == nil {
+			t.Errorf("expected error for %q", input)
+		}
+	}
+}
+
+func TestParseCSLJSON(t *testing.T) {
+	bib, err := ParseCSLJSON([]byte(`[
+		{"id": "doe2020", "title": "Reliable Markdown Pipelines",
+		 "author": [{"family": "Doe", "given": "Jane Ann"}, {"literal": "Docs Team"}],
+		 "issued": {"date-parts": [[2020, 5]]}, "container-title": "Journal of Documentation",
+		 "volume": "12", "page": "1-10"}
+	]`))
+	if err != nil {
+		t.Fatalf("ParseCSLJSON failed: %v", err)
+	}
+	doe := bib["doe2020"]
+	if strings.Join(doe.Authors, "|") != "Doe, J. A.|Docs Team" || doe.Year != "2020" || doe.Volume != "12" {
+		t.Errorf("unexpected entry %+v", doe)
+	}
+}
+
+func TestProcess(t *testing.T) {
+	bib, _ := ParseBibTeX([]byte(testBibTeX))
+	input := "<p>Pipelines fail [@roe2021, p. 4]. See [@doe2020; @roe2021] and [@nobody].</p>\n" +
+		"<pre><code>[@doe2020]</code></pre>\n<p>Mail [me] or <code>[@doe2020]</code>.</p>\n"
+
+	result := string(Process([]byte(input), bib))
+
+	for _, want := range []string{
+		`<span class="citation">[<a href="#ref-roe2021">1</a>, p. 4]</span>`,
+		`<span class="citation">[<a href="#ref-doe2020">2</a>; <a href="#ref-roe2021">1</a>]</span>`,
+		`<span class="citation citation-missing" title="Unknown reference">[@nobody]</span>`,
+		`<pre><code>[@doe2020]</code></pre>`,
+		`<code>[@doe2020]</code>`,
+		`<h2 id="references">References</h2>`,
+		`<li id="ref-roe2021">Roe, R. (2021). <em>Writing &amp; Publishing</em>. Example Press.</li>`,
+		`<li id="ref-doe2020">Doe, J., Smith, J., &amp; World Health Organization (2020). Reliable Markdown Pipelines. <em>Journal of Documentation</em>, 12, 1–10. <a href="https://doi.org/10.1000/xyz">https://doi.org/10.1000/xyz</a></li>`,
+	} {
+		if !strings.Contains(result, want) {
+			t.Errorf("expected output to contain %q, got:\n%s", want, result)
+		}
+	}
+	if strings.Index(result, "ref-roe2021\">") > strings.Index(result, "ref-doe2020\">") {
+		t.Errorf("expected references in order of first citation")
+	}
+}
+
+func TestProcessWithoutCitations(t *testing.T) {
+	input := []byte("<p>No citations [here].</p>")
+	if result := Process(input, Bibliography{}); string(result) != string(input) {
+		t.Errorf("expected unchanged HTML, got %s", result)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	bibPath := filepath.Join(dir, "refs.bib")
+	os.WriteFile(bibPath, []byte(testBibTeX), 0o644)
+	if bib, err := Load(bibPath); err != nil || len(bib) != 2 {
+		t.Errorf("expected 2 entries from .bib, got %d (%v)", len(bib), err)
+	}
+
+	txtPath := filepath.Join(dir, "refs.txt")
+	os.WriteFile(txtPath, []byte("x"), 0o644)
+	if _, err := Load(txtPath); err == nil {
+		t.Error("expected error for unsupported format")
+	}
+}
@@ -0,0 +1,82 @@
+package cite
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// cslItem is the subset of a CSL-JSON item used for references.
+type cslItem struct {
+	ID             string    `json:"id"`
+	Title          string    `json:"title"`
+	Author         []cslName `json:"author"`
+	Editor         []cslName `json:"editor"`
+	Issued         cslDate   `json:"issued"`
+	ContainerTitle string    `json:"container-title"`
+	Publisher      string    `json:"publisher"`
+	Volume         any       `json:"volume"`
+	Page           string    `json:"page"`
+	DOI            string    `json:"DOI"`
+	URL            string    `json:"URL"`
+}
+
+// cslName is a CSL-JSON person or organization.
+type cslName struct {
+	Family  string `json:"family"`
+	Given   string `json:"given"`
+	Literal string `json:"literal"`
+}
+
+// cslDate is a CSL-JSON date; only the year is used.
+type cslDate struct {
+	DateParts [][]any `json:"date-parts"`
+	Literal   string  `json:"literal"`
+}
+
+// ParseCSLJSON parses a CSL-JSON array of items, as exported by Zotero and
+// used by pandoc.
+func ParseCSLJSON(data []byte) (Bibliography, error) {
+	var items []cslItem
+	if err := json.Unmarshal(data, &items); err != nil {
+		return nil, fmt.Errorf("parse CSL-JSON: %w", err)
+	}
+
+	bib := make(Bibliography, len(items))
+	for _, item := range items {
+		if item.ID == "" {
+			continue
+		}
+		entry := Entry{
+			Key:       item.ID,
+			Title:     item.Title,
+			Year:      item.Issued.year(),
+			Container: item.ContainerTitle,
+			Publisher: item.Publisher,
+			Volume:    jsonString(item.Volume),
+			Pages:     item.Page,
+			DOI:       item.DOI,
+			URL:       item.URL,
+		}
+		names := item.Author
+		if len(names) == 0 {
+			names = item.Editor
+		}
+		for _, name := range names {
+			if name.Literal != "" {
+				entry.Authors = append(entry.Authors, name.Literal)
+			} else {
+				entry.Authors = append(entry.Authors, formatName(name.Family, name.Given))
+			}
+		}
+		bib[item.ID] = entry
+	}
+	return bib, nil
+}
+
+// year returns the year of the date.
+func (d cslDate) year() string {
+	if len(d.DateParts) > 0 && len(d.DateParts[0]) > 0 {
+		return jsonString(d.DateParts[0][0])
+	}
+	return d.Literal
+}
@@ -0,0 +1,61 @@
+// Package clock is the time pages are rendered at: the wall clock, or a
+// fixed time for reproducible exports, taken from SOURCE_DATE_EPOCH.
+package clock
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// EnvSourceDateEpoch is the environment variable of the reproducible
+// builds convention holding the build time in seconds since 1970.
+const EnvSourceDateEpoch = "SOURCE_DATE_EPOCH"
+
+// fixed is the time returned by Now, set by Set. Zero means the wall
+// clock.
+var fixed time.Time
+
+// Set fixes the time returned by Now to t; the zero time restores the wall
+// clock. Call it before serving or exporting.
+func Set(t time.Time) {
+	fixed = t
+}
+
+// Now returns the fixed time, or the current time when none is set.
+func Now() time.Time {
+	if !fixed.IsZero() {
+		return fixed
+	}
+	return time.Now()
+}
+
+// Fixed returns the time set with Set, or the zero time.
+func Fixed() time.Time {
+	return fixed
+}
+
+// Clamp returns t, or the fixed time when t is later, so that file times
+// newer than a reproducible build do not leak into its output.
+func Clamp(t time.Time) time.Time {
+	if !fixed.IsZero() && t.After(fixed) {
+		return fixed
+	}
+	return t
+}
+
+// SourceDateEpoch returns the time in SOURCE_DATE_EPOCH, and false when the
+// variable is unset.
+func SourceDateEpoch() (time.Time, bool, error) {
+	value := strings.TrimSpace(os.Getenv(EnvSourceDateEpoch))
+	if value == "" {
+		return time.Time{}, false, nil
+	}
+	seconds, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || seconds < 0 {
+		return time.Time{}, false, fmt.Errorf("invalid %s %q: must be a number of seconds since 1970", EnvSourceDateEpoch, value)
+	}
+	return time.Unix(seconds, 0).UTC(), true, nil
+}
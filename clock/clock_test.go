@@ -0,0 +1,47 @@
+package clock
+
+import (
+	"testing"
+	"time"
+)
+
+func TestClock(t *testing.T) {
+	t.Cleanup(func() { Set(time.Time{}) })
+
+	if Now().IsZero() || !Fixed().IsZero() {
+		t.Fatalf("expected the wall clock by default")
+	}
+	later := time.Now().Add(time.Hour)
+	if got := Clamp(later); !got.Equal(later) {
+		t.Errorf("expected no clamping without a fixed time, got %v", got)
+	}
+
+	epoch := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	Set(epoch)
+	if !Now().Equal(epoch) || !Fixed().Equal(epoch) {
+		t.Errorf("expected the fixed time, got %v", Now())
+	}
+	if got := Clamp(later); !got.Equal(epoch) {
+		t.Errorf("expected later times clamped to %v, got %v", epoch, got)
+	}
+	earlier := epoch.Add(-time.Hour)
+	if got := Clamp(earlier); !got.Equal(earlier) {
+		t.Errorf("expected earlier times kept, got %v", got)
+	}
+}
+
+func TestSourceDateEpoch(t *testing.T) {
+	t.Setenv(EnvSourceDateEpoch, "")
+	if _, ok, err := SourceDateEpoch(); ok || err != nil {
+		t.Errorf("expected no epoch when unset, got %v, %v", ok, err)
+	}
+	t.Setenv(EnvSourceDateEpoch, "1709294400")
+	got, ok, err := SourceDateEpoch()
+	if !ok || err != nil || !got.Equal(time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)) {
+		t.Errorf("got %v, %v, %v", got, ok, err)
+	}
+	t.Setenv(EnvSourceDateEpoch, "yesterday")
+	if _, _, err := SourceDateEpoch(); err == nil {
+		t.Errorf("expected an error for a non-number")
+	}
+}
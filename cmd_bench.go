@@ -0,0 +1,69 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"gomdoc/export"
+	"gomdoc/server"
+)
+
+// runBench renders every page of the site and reports the render times,
+// slowest first, to find the documents that make the server slow.
+func runBench(args []string) {
+	fs := newFlagSet("bench")
+	site := addSiteFlags(fs)
+	runs := fs.Int("runs", 3, "Number of times each page is rendered")
+	top := fs.Int("top", 20, "Number of slowest pages to list (0 lists all)")
+	asJSON := fs.Bool("json", false, "Print the timings of every page as JSON")
+	fs.Parse(args)
+
+	if *runs < 1 {
+		log.Fatalf("Invalid -runs %d: must be positive", *runs)
+	}
+	if *top < 0 {
+		log.Fatalf("Invalid -top %d: must not be negative", *top)
+	}
+
+	options := site.serverOptions()
+	srv := server.NewWithAuth(site.baseDir(), 0, *site.title, "", "", server.OAuth2Config{}, "", version)
+	srv.Configure(options)
+	entries, err := srv.Entries()
+	if err != nil {
+		log.Fatalf("Error scanning directory: %v", err)
+	}
+
+	start := time.Now()
+	timings, err := export.Bench(srv.Handler(), entries, *runs)
+	if err != nil {
+		log.Fatalf("Benchmark failed: %v", err)
+	}
+	elapsed := time.Since(start)
+
+	if *asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(timings); err != nil {
+			log.Fatalf("Error writing JSON: %v", err)
+		}
+		return
+	}
+	printTimings(timings, *top)
+	fmt.Fprintf(os.Stderr, "Rendered %d pages %d times in %s\n", len(timings), *runs, elapsed.Round(time.Millisecond))
+}
+
+// printTimings writes the top slowest page timings as a table.
+func printTimings(timings []export.Timing, top int) {
+	if top > 0 && len(timings) > top {
+		timings = timings[:top]
+	}
+	fmt.Printf("%10s %10s %10s %10s  %s\n", "MEAN", "MIN", "MAX", "ALLOC", "FILE")
+	for _, t := range timings {
+		fmt.Printf("%10s %10s %10s %9.1fK  %s\n",
+			t.Mean.Round(time.Microsecond), t.Min.Round(time.Microsecond), t.Max.Round(time.Microsecond),
+			float64(t.Alloc)/1024, t.File)
+	}
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"time"
+
+	"gomdoc/check"
+	"gomdoc/renderer"
+)
+
+// runCheck reports broken internal links, with -external dead external
+// links, and with -anchors changed heading IDs, and exits non-zero if any
+// exist.
+func runCheck(args []string) {
+	fs := newFlagSet("check")
+	site := addSiteFlags(fs)
+	external := fs.Bool("external", false, "Also request external links and report the ones that fail")
+	timeout := fs.Duration("external-timeout", 10*time.Second, "Timeout for each external link request")
+	concurrency := fs.Int("external-concurrency", 8, "Number of external links requested at once")
+	cacheFile := fs.String("external-cache", "", "File caching external links that worked, so later runs skip them")
+	cacheTTL := fs.Duration("external-cache-ttl", 24*time.Hour, "How long a cached external link is trusted")
+	skip := fs.String("external-skip", "", "Domains whose links are not requested, comma-separated, e.g. flaky sites")
+	anchors := fs.String("anchors", "", "Baseline file of heading IDs; report IDs that changed since it was written, breaking deep links")
+	anchorsUpdate := fs.Bool("anchors-update", false, "Rewrite the -anchors baseline with the current heading IDs, e.g. at a release")
+	anchorsPin := fs.Bool("anchors-pin", false, "Add {#id} attributes keeping the old -anchors ID to renamed headings")
+	fs.Parse(args)
+	if (*anchorsUpdate || *anchorsPin) && *anchors == "" {
+		log.Fatalf("-anchors-update and -anchors-pin require -anchors")
+	}
+	if *anchorsPin && site.archive() != nil {
+		log.Fatalf("-anchors-pin cannot write to an archive")
+	}
+
+	r := renderer.NewWithOptions(site.renderOptions())
+	problems, err := check.Links(site.files(), site.scanOptions(), r)
+	if err != nil {
+		log.Fatalf("Check failed: %v", err)
+	}
+	if *external {
+		dead, err := check.External(site.files(), site.scanOptions(), r, check.ExternalOptions{
+			Timeout:     *timeout,
+			Concurrency: *concurrency,
+			CacheFile:   *cacheFile,
+			CacheTTL:    *cacheTTL,
+			Skip:        splitCSV(*skip),
+		})
+		if err != nil {
+			log.Fatalf("Check failed: %v", err)
+		}
+		problems = append(problems, dead...)
+	}
+	if *anchors != "" {
+		result, err := check.Anchors(site.files(), site.scanOptions(), r, check.AnchorOptions{
+			File:   *anchors,
+			Update: *anchorsUpdate,
+			Pin:    *anchorsPin,
+			Dir:    site.baseDir(),
+		})
+		if err != nil {
+			log.Fatalf("Check failed: %v", err)
+		}
+		for _, pinned := range result.Pinned {
+			log.Printf("%s", pinned)
+		}
+		problems = append(problems, result.Changed...)
+	}
+	if *external || *anchors != "" {
+		sort.SliceStable(problems, func(i, j int) bool { return problems[i].File < problems[j].File })
+	}
+	reportProblems(problems)
+}
+
+// runLint reports authoring mistakes and exits non-zero if any exist.
+func runLint(args []string) {
+	fs := newFlagSet("lint")
+	site := addSiteFlags(fs)
+	fs.Parse(args)
+
+	problems, err := check.Lint(site.files(), site.scanOptions())
+	if err != nil {
+		log.Fatalf("Lint failed: %v", err)
+	}
+	reportProblems(problems)
+}
+
+// reportProblems prints the problems and exits with status 1 if there are
+// any, so the commands can gate CI pipelines.
+func reportProblems(problems []check.Problem) {
+	for _, problem := range problems {
+		fmt.Println(problem)
+	}
+	if len(problems) > 0 {
+		fmt.Fprintf(os.Stderr, "%d problem(s) found\n", len(problems))
+		os.Exit(1)
+	}
+	fmt.Println("No problems found")
+}
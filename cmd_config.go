@@ -0,0 +1,98 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"gomdoc/config"
+)
+
+// runConfig runs a config subcommand: validate checks the site
+// configuration, print shows the configuration in effect.
+func runConfig(args []string) {
+	if len(args) == 0 {
+		log.Fatalf("Usage: gomdoc config validate|print [flags]")
+	}
+	switch args[0] {
+	case "validate":
+		runConfigValidate(args[1:])
+	case "print":
+		runConfigPrint(args[1:])
+	default:
+		log.Fatalf("Unknown config command %q. Usage: gomdoc config validate|print [flags]", args[0])
+	}
+}
+
+// runConfigValidate reports every mistake in the site configuration with
+// its line, and exits non-zero on errors, or with -strict on warnings.
+func runConfigValidate(args []string) {
+	fs := newFlagSet("config")
+	site := addSiteFlags(fs)
+	strict := fs.Bool("strict", false, "Exit with status 1 on warnings too, such as unknown keys")
+	fs.Parse(args)
+
+	name, content, err := site.configSource()
+	if err != nil {
+		log.Fatalf("Error reading site configuration: %v", err)
+	}
+	if content == nil {
+		fmt.Printf("No %s found; the defaults apply\n", name)
+		return
+	}
+	_, problems := config.Validate(string(content))
+	failed := false
+	for _, problem := range problems {
+		kind := "error"
+		if problem.Warning {
+			kind = "warning"
+		}
+		fmt.Printf("%s:%d: %s: %s\n", name, problem.Line, kind, problem.Message)
+		failed = failed || !problem.Warning || *strict
+	}
+	if len(problems) == 0 {
+		fmt.Printf("%s is valid\n", name)
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// runConfigPrint prints the site configuration in effect: the settings
+// of the configuration file, defaults included, and the value of every
+// site flag, marking the ones set on the command line.
+func runConfigPrint(args []string) {
+	fs := newFlagSet("config")
+	site := addSiteFlags(fs)
+	fs.Parse(args)
+
+	name, content, err := site.configSource()
+	if err != nil {
+		log.Fatalf("Error reading site configuration: %v", err)
+	}
+	cfg := site.readConfig()
+	if content == nil {
+		fmt.Printf("# No %s found; defaults\n", name)
+	} else {
+		fmt.Printf("# %s\n", name)
+	}
+	fmt.Print(config.Format(cfg))
+
+	set := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	fmt.Println()
+	fmt.Println("# Site flags; the ones without a comment have their default value")
+	fs.VisitAll(func(f *flag.Flag) {
+		value := f.Value.String()
+		if value == "" || strings.ContainsAny(value, " \t\"'") {
+			value = fmt.Sprintf("%q", value)
+		}
+		if set[f.Name] {
+			fmt.Printf("-%s=%s  # set\n", f.Name, value)
+		} else {
+			fmt.Printf("-%s=%s\n", f.Name, value)
+		}
+	})
+}
@@ -0,0 +1,235 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"gomdoc/clock"
+	"gomdoc/export"
+	"gomdoc/search"
+	"gomdoc/server"
+	"gomdoc/source"
+)
+
+// runExport renders the site to static HTML files, or with -format jsonl
+// to plain-text chunks for embedding pipelines.
+func runExport(args []string) {
+	fs := newFlagSet("export")
+	site := addSiteFlags(fs)
+	outDir := fs.String("out", "site", "Directory to write the static site to; with -format jsonl, the file to write, - for standard output (default corpus.jsonl)")
+	incremental := fs.Bool("incremental", false, "Only render pages whose sources changed since the last incremental export to -out")
+	format := fs.String("format", "html", "Output format: html for a static site, or jsonl for plain-text chunks with metadata, one JSON object per line")
+	chunkSize := fs.Int("chunk-size", search.DefaultChunkSize, "Longest chunk in characters for -format jsonl")
+	watch := fs.Bool("watch", false, "Keep running and export the changed pages again whenever the sources change, like -incremental")
+	watchInterval := fs.Duration("watch-interval", time.Second, "How often -watch checks the sources for changes")
+	target := fs.String("target", "", "Where to publish the exported site after every export: a bucket as s3://bucket/prefix or gs://bucket/prefix, whose objects below the prefix without a file in -out are deleted, or a branch to commit to and push as git:branch or git:remote:branch")
+	afterExport := fs.String("after-export", "", "Command run after every export, e.g. \"rsync -a --delete site/ web:/srv/docs\" to publish it; arguments are split at spaces")
+	fs.Parse(args)
+
+	useSourceDateEpoch()
+	if *format != "html" && *format != "jsonl" {
+		log.Fatalf("Invalid -format %q. Use: -format html or -format jsonl", *format)
+	}
+	if *format == "jsonl" && (*incremental || *watch || *target != "" || *afterExport != "") {
+		log.Fatalf("-incremental, -watch, -target, and -after-export only work with -format html")
+	}
+	var bucket *source.Bucket
+	var branch *export.GitTarget
+	switch {
+	case *target == "":
+	case export.IsGitTarget(*target):
+		gitTarget, err := export.ParseGitTarget(*target)
+		if err != nil {
+			log.Fatalf("Invalid -target: %v", err)
+		}
+		branch = &gitTarget
+	case source.IsRemote(*target):
+		config, err := source.ParseBucketURL(*target)
+		if err != nil {
+			log.Fatalf("Invalid -target: %v", err)
+		}
+		bucket = source.NewBucket(config)
+	default:
+		log.Fatalf("Invalid -target %q. Use: -target s3://bucket/prefix, gs://bucket/prefix, or git:branch", *target)
+	}
+	if *watchInterval <= 0 {
+		log.Fatalf("Invalid -watch-interval %s: must be positive", *watchInterval)
+	}
+	if *chunkSize < 1 {
+		log.Fatalf("Invalid -chunk-size %d: must be positive", *chunkSize)
+	}
+
+	options := site.serverOptions()
+	options.Build = buildDetails()
+	baseDir := site.baseDir()
+
+	newServer := func() *server.Server {
+		srv := server.NewWithAuth(baseDir, 0, *site.title, "", "", server.OAuth2Config{}, "", version)
+		srv.Configure(options)
+		return srv
+	}
+	if *format == "jsonl" {
+		outFile := "corpus.jsonl"
+		fs.Visit(func(f *flag.Flag) {
+			if f.Name == "out" {
+				outFile = *outDir
+			}
+		})
+		exportCorpus(newServer().Handler(), outFile, *chunkSize)
+		return
+	}
+
+	// exportSite exports the site as it is now, with a fresh index.
+	exportSite := func() error {
+		srv := newServer()
+		handler := srv.Handler()
+		entries, err := srv.Entries()
+		if err != nil {
+			return fmt.Errorf("scanning directory: %w", err)
+		}
+		if *incremental || *watch {
+			result, err := export.Update(handler, entries, *outDir, export.Incremental{
+				Files:       srv.Files(),
+				Fingerprint: exportFingerprint(args, *site.templateDir),
+			})
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Exported %d files to %s (%d unchanged, %d removed)\n", result.Written, *outDir, result.Skipped, result.Removed)
+		} else {
+			written, err := export.Site(handler, entries, *outDir)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Exported %d files to %s\n", written, *outDir)
+		}
+		if bucket != nil {
+			result, err := export.Publish(context.Background(), *outDir, bucket)
+			if err != nil {
+				return fmt.Errorf("publish to %s: %w", *target, err)
+			}
+			fmt.Printf("Published to %s: %d uploaded, %d unchanged, %d deleted\n", *target, result.Uploaded, result.Unchanged, result.Deleted)
+		}
+		if branch != nil {
+			result, err := export.PublishGit(*outDir, *branch)
+			if err != nil {
+				return fmt.Errorf("publish to %s: %w", *target, err)
+			}
+			if result.Changed {
+				fmt.Printf("Published to %s: committed %.12s and pushed to %s\n", *target, result.Commit, branch.Remote)
+			} else {
+				fmt.Printf("Published to %s: no changes, %.12s pushed to %s\n", *target, result.Commit, branch.Remote)
+			}
+		}
+		return runAfterExport(*afterExport)
+	}
+
+	if err := exportSite(); err != nil {
+		log.Fatalf("Export failed: %v", err)
+	}
+	if !*watch {
+		return
+	}
+	if site.archive() != nil {
+		log.Fatalf("-watch needs a directory, not an archive")
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	fmt.Printf("Watching %s for changes; press Ctrl+C to stop\n", baseDir)
+	export.Watch(ctx, site.files(), *watchInterval, watchSkip(baseDir, *outDir), exportSite, func(err error) {
+		log.Printf("Export failed: %v", err)
+	})
+}
+
+// watchSkip returns the files -watch ignores below baseDir: hidden files
+// and directories, such as .git, and the output directory with the
+// export manifest, which would otherwise trigger the next export.
+func watchSkip(baseDir, outDir string) func(name string) bool {
+	out := ""
+	if abs, err := filepath.Abs(outDir); err == nil {
+		if rel, err := filepath.Rel(baseDir, abs); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			out = filepath.ToSlash(rel)
+		}
+	}
+	return func(name string) bool {
+		return strings.HasPrefix(path.Base(name), ".") || name == out
+	}
+}
+
+// runAfterExport runs the -after-export command, if any, with the output
+// of gomdoc. The command is split at spaces; there is no shell.
+func runAfterExport(command string) error {
+	args := strings.Fields(command)
+	if len(args) == 0 {
+		return nil
+	}
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("-after-export %s: %w", args[0], err)
+	}
+	return nil
+}
+
+// useSourceDateEpoch renders at the time in SOURCE_DATE_EPOCH when it is
+// set, for reproducible output: copyright years, stale warnings, and
+// scheduled pages are evaluated at that time, file dates after it are
+// clamped to it, and the written files carry it as their modification
+// time.
+func useSourceDateEpoch() {
+	epoch, ok, err := clock.SourceDateEpoch()
+	if err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+	if ok {
+		clock.Set(epoch)
+	}
+}
+
+// exportCorpus writes the plain-text chunks of the site to outFile, or to
+// standard output when it is -.
+func exportCorpus(handler http.Handler, outFile string, chunkSize int) {
+	if outFile == "-" {
+		if _, err := export.Corpus(handler, os.Stdout, chunkSize); err != nil {
+			log.Fatalf("Export failed: %v", err)
+		}
+		return
+	}
+	var buf bytes.Buffer
+	chunks, err := export.Corpus(handler, &buf, chunkSize)
+	if err != nil {
+		log.Fatalf("Export failed: %v", err)
+	}
+	if err := os.WriteFile(outFile, buf.Bytes(), 0o644); err != nil {
+		log.Fatalf("Export failed: %v", err)
+	}
+	fmt.Printf("Exported %d chunks to %s\n", chunks, outFile)
+}
+
+// exportFingerprint identifies the gomdoc version, the export flags, and
+// the custom templates, so that changing any of them renders the whole
+// site again on the next incremental export.
+func exportFingerprint(args []string, templateDir string) string {
+	parts := append([]string{version}, args...)
+	if templateDir != "" {
+		names, _ := filepath.Glob(filepath.Join(templateDir, "*.html"))
+		for _, name := range names {
+			data, _ := os.ReadFile(name)
+			parts = append(parts, name, string(data))
+		}
+	}
+	return strings.Join(parts, "\x00")
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"gomdoc/importer"
+)
+
+// runImport converts a Docusaurus or MkDocs site into a gomdoc tree.
+func runImport(args []string) {
+	fs := newFlagSet("import")
+	from := fs.String("from", string(importer.Auto), "Format of the source site: auto, docusaurus, mkdocs, or html")
+	src := fs.String("src", ".", "Root of the site to import, holding docusaurus.config.js, mkdocs.yml, or .html pages")
+	out := fs.String("out", "./docs-gomdoc", "Directory to write the gomdoc tree to")
+	force := fs.Bool("force", false, "Write into -out even when it is not empty, overwriting files")
+	report := fs.String("report", "", "Write a markdown review report of what needs manual attention to this file")
+	fs.Parse(args)
+
+	result, err := importer.Import(*src, *out, importer.Format(*from), *force)
+	if err != nil {
+		log.Fatalf("Error importing: %v", err)
+	}
+	for _, warning := range result.Warnings {
+		fmt.Fprintf(os.Stderr, "warning: %s\n", warning)
+	}
+	if *report != "" {
+		if err := os.WriteFile(*report, []byte(result.Report()), 0o644); err != nil {
+			log.Fatalf("Error writing report: %v", err)
+		}
+	}
+	fmt.Printf("Imported %s site: %d pages, %d other files, %d _order.yml files written to %s\n",
+		result.Format, result.Pages, result.Files, result.Orders, *out)
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+
+	"gomdoc/search"
+)
+
+// runIndex builds the search index and lists the indexed documents, which
+// shows what search and the MCP tools will see.
+func runIndex(args []string) {
+	fs := newFlagSet("index")
+	site := addSiteFlags(fs)
+	asJSON := fs.Bool("json", false, "Print the documents as JSON")
+	fs.Parse(args)
+
+	idx := search.NewIndexWithOptions(site.scanOptions())
+	if err := site.buildIndex(idx); err != nil {
+		log.Fatalf("Error building index: %v", err)
+	}
+	documents := idx.Documents()
+
+	if *asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(documents); err != nil {
+			log.Fatalf("Error writing JSON: %v", err)
+		}
+		return
+	}
+	for _, doc := range documents {
+		fmt.Printf("%s\t%s\n", doc.Path, doc.Title)
+	}
+	fmt.Fprintf(os.Stderr, "%d documents indexed\n", len(documents))
+}
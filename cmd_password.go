@@ -0,0 +1,34 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"gomdoc/server"
+)
+
+// runHashPassword reads a password from the first line of standard input
+// and prints its bcrypt hash, to use in -auth user:hash instead of the
+// password.
+func runHashPassword(args []string) {
+	newFlagSet("hash-password").Parse(args)
+	if info, err := os.Stdin.Stat(); err == nil && info.Mode()&os.ModeCharDevice != 0 {
+		fmt.Fprint(os.Stderr, "Password (shown as typed): ")
+	}
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		log.Fatalf("Error reading the password: %v", err)
+	}
+	password := strings.TrimRight(line, "\r\n")
+	if password == "" {
+		log.Fatalf("Empty password")
+	}
+	hash, err := server.HashPassword(password)
+	if err != nil {
+		log.Fatalf("Error hashing the password: %v", err)
+	}
+	fmt.Println(hash)
+}
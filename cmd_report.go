@@ -0,0 +1,80 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"gomdoc/search"
+)
+
+// runReport prints a documentation report. The only report so far is
+// owners, which lists the pages per owner and the overdue reviews.
+func runReport(args []string) {
+	if len(args) == 0 || args[0] != "owners" {
+		log.Fatalf("Usage: gomdoc report owners [flags]")
+	}
+	runOwnersReport(args[1:])
+}
+
+// runOwnersReport lists the pages of each owner and the pages whose
+// review_by date has passed.
+func runOwnersReport(args []string) {
+	fs := newFlagSet("report")
+	site := addSiteFlags(fs)
+	asJSON := fs.Bool("json", false, "Print the report as JSON")
+	failOverdue := fs.Bool("fail-overdue", false, "Exit with status 1 if any review is overdue")
+	fs.Parse(args)
+
+	idx := search.NewIndexWithOptions(site.scanOptions())
+	if err := site.buildIndex(idx); err != nil {
+		log.Fatalf("Error building index: %v", err)
+	}
+	report := idx.Ownership(time.Now())
+
+	if *asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(report); err != nil {
+			log.Fatalf("Error writing JSON: %v", err)
+		}
+	} else {
+		printOwnership(report)
+	}
+	if *failOverdue && len(report.Overdue) > 0 {
+		os.Exit(1)
+	}
+}
+
+// printOwnership writes the ownership report as text.
+func printOwnership(report search.Ownership) {
+	for _, group := range report.Owners {
+		owner := group.Owner
+		if owner == "" {
+			owner = "(no owner)"
+		}
+		fmt.Printf("%s (%d)\n", owner, len(group.Pages))
+		for _, page := range group.Pages {
+			if page.ReviewBy != "" {
+				fmt.Printf("  %s\t%s\treview by %s\n", page.Path, page.Title, page.ReviewBy)
+			} else {
+				fmt.Printf("  %s\t%s\n", page.Path, page.Title)
+			}
+		}
+	}
+
+	fmt.Printf("\nOverdue reviews (%d)\n", len(report.Overdue))
+	for _, page := range report.Overdue {
+		owner := page.Owner
+		if owner == "" {
+			owner = "(no owner)"
+		}
+		due := page.ReviewBy
+		if page.Invalid {
+			due += " (invalid date)"
+		}
+		fmt.Printf("  %s\t%s\t%s\t%s\n", page.Path, page.Title, owner, due)
+	}
+}
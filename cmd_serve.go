@@ -0,0 +1,413 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gomdoc/daemon"
+	"gomdoc/semantic"
+	"gomdoc/server"
+	"gomdoc/source"
+	"gomdoc/tracing"
+)
+
+// runServe starts the documentation server.
+func runServe(args []string) {
+	fs := newFlagSet("serve")
+	site := addSiteFlags(fs)
+	port := fs.Int("port", 7331, "Port to run the server on (0 picks a free port)")
+	portRetry := fs.Int("port-retry", 0, "Number of following ports to try when the port is busy")
+	openBrowser := fs.Bool("open", false, "Open the default browser at the server URL after startup")
+	printQR := fs.Bool("qr", false, "Print a QR code of the LAN URL on startup for opening the docs on a phone")
+	mdnsAdvertise := fs.Bool("mdns", false, "Advertise the server on the local network via mDNS/Bonjour")
+	host := fs.String("host", "", "Interface to bind, e.g. 127.0.0.1 (default: all interfaces)")
+	listenAddrs := fs.String("listen", "", "Addresses to listen on, comma-separated: host:port, unix:/path.sock, or systemd (overrides -host and -port)")
+	auth := fs.String("auth", "", "Basic auth credentials in user:password format, the password in plain text or as a bcrypt hash from gomdoc hash-password (or GOMDOC_AUTH); visible in process listings, so prefer -auth-file")
+	authFile := fs.String("auth-file", "", "File holding the basic auth credentials as user:password, kept out of process listings (or GOMDOC_AUTH_FILE)")
+	oauth2ClientID := fs.String("oauth2-client-id", "", "OAuth2 client ID")
+	oauth2ClientSecret := fs.String("oauth2-client-secret", "", "OAuth2 client secret")
+	oauth2AuthURL := fs.String("oauth2-auth-url", "", "OAuth2 authorization endpoint URL")
+	oauth2TokenURL := fs.String("oauth2-token-url", "", "OAuth2 token endpoint URL")
+	oauth2RedirectURL := fs.String("oauth2-redirect-url", "", "OAuth2 callback redirect URL")
+	oauth2UserInfoURL := fs.String("oauth2-userinfo-url", "", "OAuth2 userinfo endpoint URL")
+	oauth2Scopes := fs.String("oauth2-scopes", "", "OAuth2 scopes, comma-separated")
+	oauth2AllowedEmails := fs.String("oauth2-allowed-emails", "", "Allowed OAuth2 email addresses, comma-separated")
+	oauth2AllowedDomains := fs.String("oauth2-allowed-domains", "", "Allowed OAuth2 email domains, comma-separated")
+	oauth2CookieSecret := fs.String("oauth2-cookie-secret", "", "Secret used to sign OAuth2 session cookies")
+	oauth2RolesClaim := fs.String("oauth2-roles-claim", "", "Userinfo claim, e.g. groups or realm_access.roles, whose values reader, editor, or admin grant that role")
+	rolesFile := fs.String("roles-file", "", "Group file of \"role: user user\" lines assigning the reader, editor, and admin roles")
+	admins := fs.String("admins", "", "Comma-separated users, OAuth2 addresses, or @domains with the admin role")
+	editors := fs.String("editors", "", "Comma-separated users, OAuth2 addresses, or @domains with the editor role")
+	defaultRole := fs.String("default-role", "reader", "Role of signed-in users given none when roles are set: reader, editor, or admin")
+	mcpToken := fs.String("mcp-token", "", "Bearer token for MCP server authentication (or GOMDOC_MCP_TOKEN; auto-generated if empty)")
+	mcpNoAuth := fs.Bool("mcp-no-auth", false, "Disable MCP server authentication entirely")
+	tlsCert := fs.String("tls-cert", "", "TLS certificate file; enables HTTPS and HTTP/2 together with -tls-key")
+	tlsKey := fs.String("tls-key", "", "TLS private key file")
+	readHeaderTimeout := fs.Duration("read-header-timeout", 0, "Time limit for reading request headers (default 10s, negative disables)")
+	readTimeout := fs.Duration("read-timeout", 0, "Time limit for reading a request (default 30s, negative disables)")
+	writeTimeout := fs.Duration("write-timeout", 0, "Time limit for writing a response (default 60s, negative disables)")
+	idleTimeout := fs.Duration("idle-timeout", 0, "Time limit for idle keep-alive connections (default 2m, negative disables)")
+	renderTimeout := fs.Duration("render-timeout", 0, "Time limit for rendering one page (default 10s, negative disables)")
+	maxHeaderBytes := fs.Int("max-header-bytes", 0, "Maximum size of request headers in bytes (default 65536)")
+	maxURLLength := fs.Int("max-url-length", 0, "Maximum request URL length in bytes (default 4096)")
+	pprofEnabled := fs.Bool("pprof", false, "Serve runtime profiles at /debug/pprof/ to admins, e.g. for go tool pprof")
+	corsOrigins := fs.String("cors-origins", "", "Origins allowed to call the JSON API, comma-separated, or * for any")
+	logFile := fs.String("log-file", "", "Write logs to this file instead of stderr")
+	logMaxSize := fs.Int64("log-max-size", 100, "Rotate the log file after this many megabytes (0 disables)")
+	logMaxAge := fs.Duration("log-max-age", 0, "Rotate the log file after this long, e.g. 24h (0 disables)")
+	logMaxBackups := fs.Int("log-max-backups", 5, "Number of rotated log files to keep (0 keeps all)")
+	database := fs.String("db", "", "Database file for per-user data such as bookmarks (default: in memory)")
+	sourceSpec := fs.String("source", "", "Serve documentation from object storage instead of -dir: s3://bucket/prefix or gs://bucket/prefix")
+	sourceRefresh := fs.Duration("source-refresh", 5*time.Minute, "How often to reload the -source listing and rebuild the search index (0 disables)")
+	edit := fs.Bool("edit", false, "Enable edit mode: signed-in users can edit pages at /edit/ and upload images and attachments to assets/ (requires -auth or OAuth2)")
+	uploadMaxSize := fs.Int64("upload-max-size", 32, "Refuse uploads in edit mode larger than this many megabytes")
+	approvals := fs.Bool("approvals", false, "Hold page edits for approval by a -reviewers user at /admin/approvals before publishing (requires -edit)")
+	reviewers := fs.String("reviewers", "", "Comma-separated users or OAuth2 addresses who may approve edits with -approvals")
+	webDAV := fs.Bool("webdav", false, "Serve the base directory read-write over WebDAV at /dav/ (requires -auth)")
+	auditLog := fs.String("audit-log", "", "Append logins, uploads, WebDAV writes, and admin page visits to this file as JSON lines (requires -auth or OAuth2)")
+	auditViews := fs.Bool("audit-views", false, "Also record every page view in the -audit-log")
+	privacy := fs.Bool("privacy", false, "Privacy mode for GDPR: anonymize client addresses in the audit and error logs, never track page views, and leave user agents out of traces")
+	anonymizeIPs := fs.String("anonymize-ips", server.AnonymizeTruncate, "How -privacy anonymizes client addresses: truncate (zero the last IPv4 octet, keep 48 bits of IPv6) or hash (keyed hash, new key on every start)")
+	shareMaxAge := fs.Duration("share-max-age", 7*24*time.Hour, "Longest validity of signed links to single pages created at /api/share (0 disables sharing)")
+	shareSecret := fs.String("share-secret", "", "Secret signing share links, so they survive restarts (or GOMDOC_SHARE_SECRET; default: random)")
+	smtpAddr := fs.String("smtp-addr", "", "Mail server host:port sending daily and weekly digests of changed pages to users subscribed on /digest (requires -auth or OAuth2)")
+	smtpUser := fs.String("smtp-user", "", "User name for the -smtp-addr mail server")
+	smtpPassword := fs.String("smtp-password", "", "Password for the -smtp-addr mail server (or GOMDOC_SMTP_PASSWORD)")
+	smtpFrom := fs.String("smtp-from", "", "Sender address of the digests, e.g. docs@example.com")
+	offlineDownload := fs.Bool("offline-download", false, "Offer the site exported to static HTML as a zip at /download/site.zip")
+	askEndpoint := fs.String("ask-endpoint", "", "OpenAI-compatible API computing embeddings for /ask, e.g. https://api.openai.com/v1 or http://localhost:11434/v1")
+	askModel := fs.String("ask-model", semantic.DefaultModel, "Embedding model used for /ask")
+	askAPIKey := fs.String("ask-api-key", "", "API key of the -ask-endpoint (or GOMDOC_ASK_API_KEY)")
+	askCache := fs.String("ask-cache", "", "File keeping the /ask embeddings between runs (default: in memory)")
+	slackSigningSecret := fs.String("slack-signing-secret", "", "Signing secret of the Slack app sending slash commands to /api/slack/command (or GOMDOC_SLACK_SIGNING_SECRET)")
+	vhosts := fs.String("vhosts", "", "Virtual hosts as host=dir or host=dir=Title, comma-separated; other hosts get -dir")
+	workers := fs.Int("workers", 0, "Number of background jobs, such as index rebuilds, run at the same time (default 2)")
+	traceEndpoint := fs.String("trace-endpoint", "", "OpenTelemetry collector receiving request traces over OTLP/HTTP, e.g. http://localhost:4318 (or OTEL_EXPORTER_OTLP_ENDPOINT)")
+	traceService := fs.String("trace-service", "", "Service name of gomdoc in traces (or OTEL_SERVICE_NAME; default gomdoc)")
+	traceSample := fs.Float64("trace-sample", 1, "Share of new traces recorded, from 0 to 1; requests with a traceparent header follow its sampled flag")
+	pidFile := fs.String("pidfile", "", "Write the process ID to this file while running")
+	showVersion := fs.Bool("version", false, "Print version and exit")
+	fs.Parse(args)
+
+	if *showVersion {
+		fmt.Println(versionString())
+		return
+	}
+
+	if *logFile != "" {
+		logWriter, err := daemon.OpenLog(*logFile, daemon.LogOptions{
+			MaxSize:    *logMaxSize << 20,
+			MaxAge:     *logMaxAge,
+			MaxBackups: *logMaxBackups,
+		})
+		if err != nil {
+			log.Fatalf("Error opening log file: %v", err)
+		}
+		log.SetOutput(logWriter)
+	}
+
+	// Validate auth format if provided
+	var authUser, authPass string
+	if *auth != "" && *authFile != "" {
+		log.Fatalf("-auth and -auth-file cannot be combined")
+	}
+	credentials := *auth
+	if *authFile != "" {
+		var err error
+		if credentials, err = readSecretFile(*authFile); err != nil {
+			log.Fatalf("Error reading -auth-file: %v", err)
+		}
+		if credentials == "" {
+			log.Fatalf("-auth-file %s is empty. Write the credentials as user:password", *authFile)
+		}
+	}
+	if credentials = envFallback(credentials, "GOMDOC_AUTH"); credentials != "" {
+		parts := strings.SplitN(credentials, ":", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			log.Fatalf("Invalid auth format. Use: -auth user:password")
+		}
+		authUser = parts[0]
+		authPass = parts[1]
+		if err := server.ValidateAuthPassword(authPass); err != nil {
+			log.Fatalf("Invalid auth password: %v", err)
+		}
+	}
+
+	oauth2Config := server.OAuth2Config{
+		ClientID:       envFallback(*oauth2ClientID, "GOMDOC_OAUTH2_CLIENT_ID"),
+		ClientSecret:   envFallback(*oauth2ClientSecret, "GOMDOC_OAUTH2_CLIENT_SECRET"),
+		AuthURL:        envFallback(*oauth2AuthURL, "GOMDOC_OAUTH2_AUTH_URL"),
+		TokenURL:       envFallback(*oauth2TokenURL, "GOMDOC_OAUTH2_TOKEN_URL"),
+		RedirectURL:    envFallback(*oauth2RedirectURL, "GOMDOC_OAUTH2_REDIRECT_URL"),
+		UserInfoURL:    envFallback(*oauth2UserInfoURL, "GOMDOC_OAUTH2_USERINFO_URL"),
+		Scopes:         splitCSV(envFallback(*oauth2Scopes, "GOMDOC_OAUTH2_SCOPES")),
+		AllowedEmails:  splitCSV(envFallback(*oauth2AllowedEmails, "GOMDOC_OAUTH2_ALLOWED_EMAILS")),
+		AllowedDomains: splitCSV(envFallback(*oauth2AllowedDomains, "GOMDOC_OAUTH2_ALLOWED_DOMAINS")),
+		CookieSecret:   envFallback(*oauth2CookieSecret, "GOMDOC_OAUTH2_COOKIE_SECRET"),
+		RolesClaim:     envFallback(*oauth2RolesClaim, "GOMDOC_OAUTH2_ROLES_CLAIM"),
+	}
+	if err := server.ValidateOAuth2Config(oauth2Config, authUser != ""); err != nil {
+		log.Fatalf("Invalid OAuth2 config: %v", err)
+	}
+
+	httpOptions := server.HTTPOptions{
+		ReadHeaderTimeout: *readHeaderTimeout,
+		ReadTimeout:       *readTimeout,
+		WriteTimeout:      *writeTimeout,
+		IdleTimeout:       *idleTimeout,
+		MaxHeaderBytes:    *maxHeaderBytes,
+		TLSCertFile:       *tlsCert,
+		TLSKeyFile:        *tlsKey,
+	}
+	if err := server.ValidateHTTPOptions(httpOptions); err != nil {
+		log.Fatalf("Invalid HTTP config: %v", err)
+	}
+
+	if *webDAV && (authUser == "" || *sourceSpec != "" || site.archive() != nil) {
+		log.Fatalf("-webdav requires -auth and a directory for -dir")
+	}
+
+	if *auditLog != "" && authUser == "" && !oauth2Config.Enabled() {
+		log.Fatalf("-audit-log requires -auth or OAuth2")
+	}
+
+	if err := server.ValidateAnonymizeIPs(*anonymizeIPs); err != nil {
+		log.Fatalf("Invalid -anonymize-ips: %v", err)
+	}
+	if *privacy && *auditViews {
+		log.Printf("Warning: -audit-views is ignored with -privacy; page views are not recorded")
+	}
+
+	if *edit && ((authUser == "" && !oauth2Config.Enabled()) || *sourceSpec != "" || site.archive() != nil) {
+		log.Fatalf("-edit requires -auth or OAuth2 and a directory for -dir")
+	}
+
+	if *smtpAddr != "" && (*smtpFrom == "" || (authUser == "" && !oauth2Config.Enabled())) {
+		log.Fatalf("-smtp-addr requires -smtp-from and -auth or OAuth2")
+	}
+
+	if *uploadMaxSize < 1 {
+		log.Fatalf("Invalid -upload-max-size %d: must be positive", *uploadMaxSize)
+	}
+
+	roles, err := parseRoles(*rolesFile, *admins, *editors, *defaultRole)
+	if err != nil {
+		log.Fatalf("Invalid roles: %v", err)
+	}
+	if len(roles.Members) > 0 && authUser == "" && !oauth2Config.Enabled() {
+		log.Fatalf("-roles-file, -admins, and -editors require -auth or OAuth2")
+	}
+
+	if *approvals && (!*edit || (*reviewers == "" && len(roles.Members) == 0 && oauth2Config.RolesClaim == "")) {
+		log.Fatalf("-approvals requires -edit and -reviewers or roles")
+	}
+
+	options := site.serverOptions()
+	baseDir := site.baseDir()
+	virtualHosts, err := parseVirtualHosts(*vhosts)
+	if err != nil {
+		log.Fatalf("Invalid -vhosts: %v", err)
+	}
+
+	// Resolve MCP token: use provided, generate, or disable
+	resolvedMCPToken := envFallback(*mcpToken, "GOMDOC_MCP_TOKEN")
+	if !*mcpNoAuth && resolvedMCPToken == "" {
+		tokenBytes := make([]byte, 32)
+		if _, err := rand.Read(tokenBytes); err != nil {
+			log.Fatalf("Failed to generate MCP token: %v", err)
+		}
+		resolvedMCPToken = hex.EncodeToString(tokenBytes)
+	}
+	if *mcpNoAuth {
+		resolvedMCPToken = ""
+	}
+
+	if *pidFile != "" {
+		if err := daemon.WritePIDFile(*pidFile); err != nil {
+			log.Fatalf("Error writing PID file: %v", err)
+		}
+	}
+
+	fmt.Println("gomdoc - Markdown Documentation Server")
+	fmt.Println("=======================================")
+
+	options.Host = *host
+	options.Listen = splitCSV(*listenAddrs)
+	options.OpenBrowser = *openBrowser
+	options.PortRetry = *portRetry
+	options.MDNS = *mdnsAdvertise
+	options.PrintQR = *printQR
+	options.HTTP = httpOptions
+	options.MaxURLLength = *maxURLLength
+	options.RenderTimeout = *renderTimeout
+	options.CORSOrigins = splitCSV(*corsOrigins)
+	options.Pprof = *pprofEnabled
+	options.Database = *database
+	options.VirtualHosts = virtualHosts
+	options.Edit = *edit
+	options.Workers = *workers
+	options.MaxUploadBytes = *uploadMaxSize << 20
+	options.Approvals = *approvals
+	options.Reviewers = splitCSV(*reviewers)
+	options.Roles = roles
+	options.SMTP = server.SMTPOptions{
+		Addr:     *smtpAddr,
+		User:     *smtpUser,
+		Password: envFallback(*smtpPassword, "GOMDOC_SMTP_PASSWORD"),
+		From:     *smtpFrom,
+	}
+	if options.SMTP.Enabled() && options.BaseURL == "" {
+		log.Printf("Warning: digests link pages by path only; set -base-url for full links")
+	}
+	options.WebDAV = *webDAV
+	options.OfflineDownload = *offlineDownload
+	options.AuditLog = *auditLog
+	options.AuditViews = *auditViews
+	options.Privacy = *privacy
+	options.AnonymizeIPs = *anonymizeIPs
+	options.ShareMaxAge = *shareMaxAge
+	options.ShareSecret = envFallback(*shareSecret, "GOMDOC_SHARE_SECRET")
+	options.SlackSigningSecret = envFallback(*slackSigningSecret, "GOMDOC_SLACK_SIGNING_SECRET")
+	options.Ask = semantic.Options{
+		Endpoint:  *askEndpoint,
+		Model:     *askModel,
+		APIKey:    envFallback(*askAPIKey, "GOMDOC_ASK_API_KEY"),
+		CacheFile: *askCache,
+	}
+	if *sourceSpec != "" {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		files, err := source.Open(ctx, *sourceSpec)
+		cancel()
+		if err != nil {
+			log.Fatalf("Error opening source: %v", err)
+		}
+		options.Source = files
+		options.SourceRefresh = *sourceRefresh
+		log.Printf("Serving files from: %s", *sourceSpec)
+	} else if options.Source != nil {
+		log.Printf("Serving files from: %s", *site.dir)
+	}
+	options.Build = buildDetails()
+
+	stopTracing := startTracing(envFallback(*traceEndpoint, "OTEL_EXPORTER_OTLP_ENDPOINT"), envFallback(*traceService, "OTEL_SERVICE_NAME"), *traceSample)
+	defer stopTracing()
+
+	srv := server.NewWithAuth(baseDir, *port, *site.title, authUser, authPass, oauth2Config, resolvedMCPToken, version)
+	srv.Configure(options)
+	if daemon.IsService() {
+		if err := daemon.RunService(daemon.ServiceName, srv.Start); err != nil {
+			log.Fatalf("Service error: %v", err)
+		}
+		return
+	}
+	// Start returns nil once an interrupt or termination signal has been
+	// handled, after draining requests and background jobs.
+	err = srv.Start()
+	stopTracing()
+	if *pidFile != "" {
+		if removeErr := daemon.RemovePIDFile(*pidFile); removeErr != nil {
+			log.Printf("Warning: %v", removeErr)
+		}
+	}
+	if err != nil {
+		log.Fatalf("Server error: %v", err)
+	}
+}
+
+// startTracing sends spans of requests to the OTLP/HTTP collector at
+// endpoint, with export headers such as an API key taken from
+// OTEL_EXPORTER_OTLP_HEADERS, and returns a function sending the spans
+// still queued. It does nothing when endpoint is empty.
+func startTracing(endpoint, service string, ratio float64) func() {
+	if endpoint == "" {
+		return func() {}
+	}
+	headers, err := tracing.ParseHeaders(envFallback("", "OTEL_EXPORTER_OTLP_HEADERS"))
+	if err != nil {
+		log.Fatalf("Invalid OTEL_EXPORTER_OTLP_HEADERS: %v", err)
+	}
+	shutdown, err := tracing.Configure(tracing.Config{
+		Endpoint:       endpoint,
+		Headers:        headers,
+		ServiceName:    service,
+		ServiceVersion: version,
+		SampleRatio:    ratio,
+	})
+	if err != nil {
+		log.Fatalf("Invalid tracing config: %v", err)
+	}
+	log.Printf("Sending traces to: %s", endpoint)
+	return func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdown(ctx); err != nil {
+			log.Printf("Warning: %v", err)
+		}
+	}
+}
+
+// parseRoles combines the role members of the group file with those of
+// the -admins and -editors flags.
+func parseRoles(groupFile, admins, editors, defaultRole string) (server.Roles, error) {
+	roles := server.Roles{Members: make(map[server.Role][]string)}
+	if groupFile != "" {
+		members, err := server.ReadGroupFile(groupFile)
+		if err != nil {
+			return roles, err
+		}
+		roles.Members = members
+	}
+	roles.Members[server.RoleAdmin] = append(roles.Members[server.RoleAdmin], splitCSV(admins)...)
+	roles.Members[server.RoleEditor] = append(roles.Members[server.RoleEditor], splitCSV(editors)...)
+	for role, members := range roles.Members {
+		if len(members) == 0 {
+			delete(roles.Members, role)
+		}
+	}
+	var err error
+	roles.Default, err = server.ParseRole(defaultRole)
+	return roles, err
+}
+
+// parseVirtualHosts parses comma-separated host=dir or host=dir=Title
+// entries, resolving and checking each directory.
+func parseVirtualHosts(value string) ([]server.VirtualHost, error) {
+	var vhosts []server.VirtualHost
+	seen := make(map[string]bool)
+	for _, entry := range splitCSV(value) {
+		parts := strings.SplitN(entry, "=", 3)
+		if len(parts) < 2 || strings.TrimSpace(parts[0]) == "" || strings.TrimSpace(parts[1]) == "" {
+			return nil, fmt.Errorf("%q is not in host=dir format", entry)
+		}
+		vhost := server.VirtualHost{Host: strings.ToLower(strings.TrimSpace(parts[0]))}
+		if seen[vhost.Host] {
+			return nil, fmt.Errorf("host %s is listed twice", vhost.Host)
+		}
+		seen[vhost.Host] = true
+		if len(parts) == 3 {
+			vhost.Title = strings.TrimSpace(parts[2])
+		}
+
+		dir, err := filepath.Abs(strings.TrimSpace(parts[1]))
+		if err != nil {
+			return nil, err
+		}
+		info, err := os.Stat(dir)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			return nil, fmt.Errorf("%s is not a directory", dir)
+		}
+		vhost.Dir = dir
+		vhosts = append(vhosts, vhost)
+	}
+	return vhosts, nil
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"fmt"
+	"log"
+
+	"gomdoc/daemon"
+)
+
+// runServiceCommand manages the Windows service: install (passing any
+// further arguments to the service), uninstall, start, or stop.
+func runServiceCommand(args []string) {
+	if len(args) == 0 {
+		log.Fatalf("Usage: gomdoc service install [flags] | uninstall | start | stop")
+	}
+
+	var err error
+	switch args[0] {
+	case "install":
+		err = daemon.InstallService(daemon.ServiceName, append([]string{"serve"}, args[1:]...))
+	case "uninstall":
+		err = daemon.UninstallService(daemon.ServiceName)
+	case "start":
+		err = daemon.StartService(daemon.ServiceName)
+	case "stop":
+		err = daemon.StopService(daemon.ServiceName)
+	default:
+		log.Fatalf("Unknown service command %q. Use: install, uninstall, start, or stop", args[0])
+	}
+	if err != nil {
+		log.Fatalf("Service %s failed: %v", args[0], err)
+	}
+	fmt.Printf("Service %s: %s done\n", daemon.ServiceName, args[0])
+}
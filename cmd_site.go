@@ -0,0 +1,458 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"log"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gomdoc/config"
+	"gomdoc/renderer"
+	"gomdoc/scanner"
+	"gomdoc/search"
+	"gomdoc/server"
+	"gomdoc/source"
+	"gomdoc/static"
+	"gomdoc/templates"
+)
+
+// siteFlags are the flags shared by every command that reads the
+// documentation tree, so serve, export, and check see the same site.
+type siteFlags struct {
+	dir                 *string
+	title               *string
+	home                *string
+	baseURL             *string
+	externalLinksNewTab *bool
+	externalLinkIcon    *bool
+	allowedLinkDomains  *string
+	typographer         *bool
+	codeAliases         *string
+	codeDefaultLanguage *string
+	highlightMaxBytes   *int
+	sortOrder           *string
+	sortLocale          *string
+	stripNumericPrefix  *bool
+	footerText          *string
+	footerLinks         *string
+	copyright           *string
+	hideGeneratedBy     *bool
+	templateDir         *string
+	templateVars        *string
+	configFile          *string
+	bibliography        *string
+	staleAfter          *string
+	dateFormat          *string
+	dateLocale          *string
+	gitAuthors          *bool
+	attachments         *bool
+	attachmentTypes     *string
+	safeMode            *bool
+	mediaMaxSize        *int64
+	pageMaxSize         *int64
+	missingAssets       *string
+	serveCode           *bool
+	codeTypes           *string
+	officePreview       *bool
+	officeConverter     *string
+	mermaidCommand      *string
+	mermaidCache        *string
+	mermaidScript       *string
+	noJS                *bool
+	debugAssets         *bool
+	lazyTree            *bool
+
+	opened fs.FS // archive named by -dir, opened on first use
+}
+
+// addSiteFlags registers the shared site flags on fs.
+func addSiteFlags(fs *flag.FlagSet) *siteFlags {
+	return &siteFlags{
+		dir:                 fs.String("dir", ".", "Base directory to serve markdown files from, or a .zip, .tar, or .tar.gz archive"),
+		title:               fs.String("title", "gomdoc", "Custom title for the documentation site"),
+		home:                fs.String("home", "", "Markdown file served as the landing page (default: index.md or home.md if present)"),
+		baseURL:             fs.String("base-url", "", "Public URL of the site, e.g. https://docs.example.com, for canonical links and og:url tags"),
+		externalLinksNewTab: fs.Bool("external-links-new-tab", false, "Open external links in a new tab with rel=\"noopener noreferrer\""),
+		externalLinkIcon:    fs.Bool("external-link-icon", false, "Mark external links with an icon"),
+		allowedLinkDomains:  fs.String("allowed-link-domains", "", "Approved external link domains, comma-separated; other links are flagged and logged"),
+		typographer:         fs.Bool("typographer", false, "Use smart quotes, dashes, and ellipses; pages can override with typographer: frontmatter"),
+		codeAliases:         fs.String("code-aliases", "", "Code fence language aliases as alias=language pairs, comma-separated, e.g. shell=bash"),
+		codeDefaultLanguage: fs.String("code-default-language", "", "Language used to highlight code fences without one, e.g. text"),
+		highlightMaxBytes:   fs.Int("highlight-max-bytes", 0, "Render code blocks larger than this many bytes without highlighting (0 = no limit)"),
+		sortOrder:           fs.String("sort", "natural", "Navigation sort order: natural or lexical"),
+		sortLocale:          fs.String("sort-locale", "", "Collate navigation names using this locale, e.g. de or sv"),
+		stripNumericPrefix:  fs.Bool("strip-numeric-prefix", false, "Strip ordering prefixes like 01- from displayed names and URLs"),
+		footerText:          fs.String("footer-text", "", "Additional text shown in the page footer"),
+		footerLinks:         fs.String("footer-links", "", "Footer links as Label=URL pairs, comma-separated"),
+		copyright:           fs.String("copyright", "", "Copyright line shown in the footer; {year} expands to the current year"),
+		hideGeneratedBy:     fs.Bool("hide-generated-by", false, "Hide the \"Documentation created by gomdoc\" footer line"),
+		templateDir:         fs.String("templates", "", "Directory of custom page.html, landing.html, index.html, notfound.html, and layout templates"),
+		templateVars:        fs.String("template-vars", "", "Values for the config function of custom templates as key=value pairs, comma-separated"),
+		configFile:          fs.String("config", "", "Site configuration file with the top-bar nav: menu and the banner (default: "+config.File+" in -dir if present)"),
+		bibliography:        fs.String("bibliography", "", "BibTeX (.bib) or CSL-JSON (.json) file for [@key] citations, relative to -dir"),
+		staleAfter:          fs.String("stale-after", "", "Warn on pages not updated within this age, e.g. 180d; pages can override with stale_after: frontmatter"),
+		dateFormat:          fs.String("date-format", search.DefaultDateFormat, "Layout of the dates shown on pages, written as the date 2006-01-02, e.g. \"2 Jan 2006\" or 2006-01-02"),
+		dateLocale:          fs.String("date-locale", "", "Language of month and weekday names in page dates, e.g. de or fr (default English)"),
+		gitAuthors:          fs.Bool("git-authors", false, "List pages without author: frontmatter on /authors under the git author of most of their lines"),
+		attachments:         fs.Bool("attachments", false, "List non-markdown files of the -attachment-types in the navigation tree and serve them as downloads"),
+		attachmentTypes:     fs.String("attachment-types", strings.Join(scanner.DefaultAttachmentTypes, ","), "File extensions listed and served by -attachments, comma-separated"),
+		mediaMaxSize:        fs.Int64("media-max-size", 0, "Refuse to serve embedded video, audio, and terminal recordings larger than this many megabytes (0 = no limit)"),
+		pageMaxSize:         fs.Int64("page-max-size", 16, "Neither render nor index markdown files larger than this many megabytes (0 = no limit)"),
+		missingAssets:       fs.String("missing-assets", server.MissingWarn, "Pages referring to missing images, media, stylesheets, scripts, or bibliographies: warn shows a warning in their place, fail answers an error (exports fail)"),
+		safeMode:            fs.Bool("safe-mode", false, "Ignore js: frontmatter, don't serve scripts from assets/, and strip scripts from SVG, so pages cannot run their own code"),
+		serveCode:           fs.Bool("serve-code", false, "List text and source files of the -code-types in the navigation tree and show them as highlighted pages"),
+		codeTypes:           fs.String("code-types", strings.Join(scanner.DefaultCodeTypes, ","), "File extensions shown by -serve-code, comma-separated"),
+		officePreview:       fs.Bool("office-preview", false, "Preview DOCX and XLSX attachments as HTML pages with a download link"),
+		officeConverter:     fs.String("office-converter", "", "External command converting office attachments to HTML on stdout, e.g. \"pandoc {file} -t html\""),
+		mermaidCommand:      fs.String("mermaid-renderer", "", "Command pre-rendering mermaid diagrams to SVG, e.g. \"mmdc -i {input} -o {output}\"; diagrams it cannot render are drawn in the browser with -mermaid-script"),
+		mermaidCache:        fs.String("mermaid-cache", defaultMermaidCache(), "Directory caching pre-rendered mermaid diagrams between runs; empty keeps them in memory only"),
+		mermaidScript:       fs.String("mermaid-script", "", "URL of mermaid.min.js drawing mermaid diagrams in the browser, e.g. /assets/mermaid.min.js; empty loads no script from elsewhere"),
+		noJS:                fs.Bool("no-js", false, "Serve pages that work without JavaScript, with no scripts; combine with -mermaid-renderer for diagrams"),
+		lazyTree:            fs.Bool("lazy-tree", false, "Leave the entries of collapsed folders out of the navigation tree and load them when opened, for huge trees"),
+		debugAssets:         fs.Bool("debug-assets", false, "Include the built-in scripts as separate, unminified files instead of the minified bundle, for debugging"),
+	}
+}
+
+// defaultMermaidCache returns the directory for pre-rendered diagrams in
+// the user's cache directory, or an empty string when there is none.
+func defaultMermaidCache() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "gomdoc", "mermaid")
+}
+
+// baseDir resolves and validates the base directory, exiting on error.
+// When -dir names an archive, the directory holding it is returned and the
+// pages are read from archive instead.
+func (f *siteFlags) baseDir() string {
+	baseDir, err := filepath.Abs(*f.dir)
+	if err != nil {
+		log.Fatalf("Error resolving directory path: %v", err)
+	}
+
+	if files := f.archive(); files != nil {
+		if *f.home != "" {
+			if _, err := fs.Stat(files, filepath.ToSlash(*f.home)); err != nil {
+				log.Fatalf("Error accessing home page: %v", err)
+			}
+		}
+		return filepath.Dir(baseDir)
+	}
+	info, err := os.Stat(baseDir)
+	if err != nil {
+		log.Fatalf("Error accessing directory: %v", err)
+	}
+	if !info.IsDir() {
+		log.Fatalf("Path is not a directory: %s", baseDir)
+	}
+	if *f.home != "" {
+		if _, err := os.Stat(filepath.Join(baseDir, *f.home)); err != nil {
+			log.Fatalf("Error accessing home page: %v", err)
+		}
+	}
+	return baseDir
+}
+
+// archive opens -dir when it names a zip or tar archive, exiting on error.
+// It returns nil when -dir is a directory.
+func (f *siteFlags) archive() fs.FS {
+	if !source.IsArchive(*f.dir) {
+		return nil
+	}
+	if f.opened == nil {
+		files, err := source.OpenArchive(*f.dir)
+		if err != nil {
+			log.Fatalf("Error opening archive: %v", err)
+		}
+		f.opened = files
+	}
+	return f.opened
+}
+
+// files returns the documentation tree: the archive, or the base
+// directory.
+func (f *siteFlags) files() fs.FS {
+	if files := f.archive(); files != nil {
+		return files
+	}
+	return os.DirFS(f.baseDir())
+}
+
+// buildIndex builds idx from the base directory or the archive.
+func (f *siteFlags) buildIndex(idx *search.Index) error {
+	if files := f.archive(); files != nil {
+		return idx.BuildFS(files)
+	}
+	return idx.Build(f.baseDir())
+}
+
+// scanOptions returns how files map to names and routes.
+func (f *siteFlags) scanOptions() scanner.ScanOptions {
+	if *f.pageMaxSize < 0 {
+		log.Fatalf("Invalid -page-max-size %d: must not be negative", *f.pageMaxSize)
+	}
+	return scanner.ScanOptions{StripNumericPrefix: *f.stripNumericPrefix, MaxPageBytes: *f.pageMaxSize << 20}
+}
+
+// renderOptions returns the markdown rendering options, exiting on invalid
+// values.
+func (f *siteFlags) renderOptions() renderer.Options {
+	aliases, err := parseCodeAliases(*f.codeAliases)
+	if err != nil {
+		log.Fatalf("Invalid code aliases: %v", err)
+	}
+	if *f.highlightMaxBytes < 0 {
+		log.Fatalf("Invalid -highlight-max-bytes %d: must not be negative", *f.highlightMaxBytes)
+	}
+
+	return renderer.Options{
+		ExternalLinks: renderer.ExternalLinkOptions{
+			NewTab:         *f.externalLinksNewTab,
+			Icon:           *f.externalLinkIcon,
+			AllowedDomains: splitCSV(*f.allowedLinkDomains),
+		},
+		Code: renderer.CodeOptions{
+			Aliases:           aliases,
+			DefaultLanguage:   *f.codeDefaultLanguage,
+			MaxHighlightBytes: *f.highlightMaxBytes,
+		},
+		Typographer: *f.typographer,
+	}
+}
+
+// attachmentTypeList returns the normalized -attachment-types when
+// -attachments is set, and nil otherwise.
+func (f *siteFlags) attachmentTypeList() []string {
+	if !*f.attachments {
+		return nil
+	}
+	return extensionList(*f.attachmentTypes)
+}
+
+// codeTypeList returns the normalized -code-types when -serve-code is set,
+// and nil otherwise.
+func (f *siteFlags) codeTypeList() []string {
+	if !*f.serveCode {
+		return nil
+	}
+	return extensionList(*f.codeTypes)
+}
+
+// extensionList parses comma-separated file extensions, lowercased and
+// without a leading dot.
+func extensionList(value string) []string {
+	var types []string
+	for _, ext := range splitCSV(value) {
+		types = append(types, strings.ToLower(strings.TrimPrefix(ext, ".")))
+	}
+	return types
+}
+
+// serverOptions validates the site flags and returns the matching server
+// options, exiting on invalid values.
+func (f *siteFlags) serverOptions() server.Options {
+	if *f.sortOrder != "natural" && *f.sortOrder != "lexical" {
+		log.Fatalf("Invalid sort order %q. Use: -sort natural or -sort lexical", *f.sortOrder)
+	}
+	sortOptions := scanner.SortOptions{Lexical: *f.sortOrder == "lexical", Locale: *f.sortLocale}
+	if err := sortOptions.Validate(); err != nil {
+		log.Fatalf("Invalid sort config: %v", err)
+	}
+
+	footerLinks, err := parseFooterLinks(*f.footerLinks)
+	if err != nil {
+		log.Fatalf("Invalid footer links: %v", err)
+	}
+
+	f.loadTemplates()
+	cfg := f.loadConfig()
+	static.SetDebug(*f.debugAssets)
+	templates.SetMermaidScript(*f.mermaidScript)
+
+	if *f.baseURL != "" {
+		if u, err := url.Parse(*f.baseURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			log.Fatalf("Invalid -base-url %q: use an absolute http or https URL such as https://docs.example.com", *f.baseURL)
+		}
+	}
+
+	if *f.noJS && *f.mermaidCommand == "" {
+		log.Printf("Warning: -no-js without -mermaid-renderer shows mermaid diagrams as code")
+	}
+
+	if err := server.ValidateMissingAssets(*f.missingAssets); err != nil {
+		log.Fatalf("Invalid -missing-assets: %v", err)
+	}
+
+	if *f.mediaMaxSize < 0 {
+		log.Fatalf("Invalid -media-max-size %d: must not be negative", *f.mediaMaxSize)
+	}
+
+	var staleAfter time.Duration
+	if *f.staleAfter != "" {
+		if staleAfter, err = server.ParseAge(*f.staleAfter); err != nil {
+			log.Fatalf("Invalid -stale-after: %v", err)
+		}
+	}
+
+	if err := search.ValidateDateFormat(*f.dateFormat); err != nil {
+		log.Fatalf("Invalid -date-format: %v", err)
+	}
+	if err := search.ValidateDateLocale(*f.dateLocale); err != nil {
+		log.Fatalf("Invalid -date-locale: %v", err)
+	}
+
+	return server.Options{
+		Render:          f.renderOptions(),
+		Sort:            sortOptions,
+		Scan:            f.scanOptions(),
+		Bibliography:    *f.bibliography,
+		StaleAfter:      staleAfter,
+		DateFormat:      *f.dateFormat,
+		DateLocale:      *f.dateLocale,
+		GitAuthors:      *f.gitAuthors,
+		Home:            *f.home,
+		BaseURL:         *f.baseURL,
+		Source:          f.archive(),
+		Attachments:     f.attachmentTypeList(),
+		FileTypes:       cfg.Types,
+		CodeFiles:       f.codeTypeList(),
+		SafeMode:        *f.safeMode,
+		MaxMediaBytes:   *f.mediaMaxSize << 20,
+		MissingAssets:   *f.missingAssets,
+		OfficePreview:   *f.officePreview,
+		OfficeConverter: *f.officeConverter,
+		MermaidCommand:  *f.mermaidCommand,
+		MermaidCache:    *f.mermaidCache,
+		NoJS:            *f.noJS,
+		LazyTree:        *f.lazyTree,
+		Footer: templates.Footer{
+			Text:            *f.footerText,
+			Links:           footerLinks,
+			Copyright:       *f.copyright,
+			HideGeneratedBy: *f.hideGeneratedBy,
+		},
+	}
+}
+
+// loadTemplates loads the custom templates of -templates, exiting on
+// error. The config function returns the -template-vars and, under title,
+// the site title.
+func (f *siteFlags) loadTemplates() {
+	if *f.templateDir == "" {
+		return
+	}
+	values := map[string]string{"title": *f.title}
+	for _, pair := range splitCSV(*f.templateVars) {
+		key, value, ok := strings.Cut(pair, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			log.Fatalf("Invalid -template-vars: %q is not in key=value format", pair)
+		}
+		values[key] = strings.TrimSpace(value)
+	}
+	if err := templates.Load(os.DirFS(*f.templateDir), values); err != nil {
+		log.Fatalf("Error loading templates from %s: %v", *f.templateDir, err)
+	}
+}
+
+// configSource returns the name and content of the -config file, or of
+// gomdoc.yml at the top of the documentation tree when -config is unset.
+// The content is nil when there is no gomdoc.yml.
+func (f *siteFlags) configSource() (string, []byte, error) {
+	if *f.configFile != "" {
+		content, err := os.ReadFile(*f.configFile)
+		return *f.configFile, content, err
+	}
+	content, err := fs.ReadFile(f.files(), config.File)
+	if errors.Is(err, fs.ErrNotExist) {
+		return config.File, nil, nil
+	}
+	return config.File, content, err
+}
+
+// readConfig reads the site configuration, exiting on error. Warnings,
+// such as unknown keys, are logged.
+func (f *siteFlags) readConfig() config.Config {
+	name, content, err := f.configSource()
+	if err != nil {
+		log.Fatalf("Error loading site configuration: %v", err)
+	}
+	cfg, problems := config.Validate(string(content))
+	for _, problem := range problems {
+		if !problem.Warning {
+			log.Fatalf("Error loading site configuration: %s: %v", name, problem)
+		}
+	}
+	for _, problem := range problems {
+		log.Printf("Warning: %s: %v", name, problem)
+	}
+	return cfg
+}
+
+// loadConfig reads the -config file, or gomdoc.yml at the top of the
+// documentation tree when -config is unset, and sets the top-bar menu,
+// the banner, and the table settings, exiting on error. It returns the
+// configuration for the settings the server takes.
+func (f *siteFlags) loadConfig() config.Config {
+	cfg := f.readConfig()
+	templates.SetMenu(menuItems(cfg.Nav))
+	templates.SetBanner(templates.Banner{
+		Text:        cfg.Banner.Text,
+		Severity:    cfg.Banner.Severity,
+		Expires:     cfg.Banner.Expires,
+		Dismissible: cfg.Banner.Dismissible,
+	})
+	templates.SetTables(templates.Tables{Sortable: cfg.Tables.Sortable, PageSize: cfg.Tables.PageSize})
+	return cfg
+}
+
+// menuItems converts the nav: entries of the configuration to the
+// top-bar menu of the templates.
+func menuItems(nav []config.NavItem) []templates.MenuItem {
+	var items []templates.MenuItem
+	for _, entry := range nav {
+		items = append(items, templates.MenuItem{
+			Title:    entry.Title,
+			URL:      entry.URL,
+			External: entry.External(),
+			Children: menuItems(entry.Children),
+		})
+	}
+	return items
+}
+
+// parseCodeAliases parses comma-separated alias=language pairs.
+func parseCodeAliases(value string) (map[string]string, error) {
+	aliases := make(map[string]string)
+	for _, pair := range splitCSV(value) {
+		alias, lang, ok := strings.Cut(pair, "=")
+		alias, lang = strings.TrimSpace(alias), strings.TrimSpace(lang)
+		if !ok || alias == "" || lang == "" {
+			return nil, fmt.Errorf("%q is not in alias=language format", pair)
+		}
+		aliases[strings.ToLower(alias)] = lang
+	}
+	return aliases, nil
+}
+
+// parseFooterLinks parses comma-separated Label=URL pairs.
+func parseFooterLinks(value string) ([]templates.FooterLink, error) {
+	var links []templates.FooterLink
+	for _, pair := range splitCSV(value) {
+		label, url, ok := strings.Cut(pair, "=")
+		label, url = strings.TrimSpace(label), strings.TrimSpace(url)
+		if !ok || label == "" || url == "" {
+			return nil, fmt.Errorf("%q is not in Label=URL format", pair)
+		}
+		links = append(links, templates.FooterLink{Label: label, URL: url})
+	}
+	return links, nil
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"gomdoc/clock"
+	"gomdoc/export"
+	"gomdoc/server"
+)
+
+// snapshotTimeFormat names snapshot archives by the UTC time they were
+// taken, so that they sort in order.
+const snapshotTimeFormat = "20060102T150405Z"
+
+// runSnapshot writes a timestamped tarball of the source tree with a
+// manifest of content hashes and the rendered sitemap, for point-in-time
+// records of the documentation.
+func runSnapshot(args []string) {
+	fs := newFlagSet("snapshot")
+	site := addSiteFlags(fs)
+	outDir := fs.String("out", ".", "Directory to write the snapshot archive to")
+	fs.Parse(args)
+
+	useSourceDateEpoch()
+	options := site.serverOptions()
+	options.Build = buildDetails()
+	if options.BaseURL == "" {
+		fmt.Fprintln(os.Stderr, "warning: no -base-url given, the sitemap lists http://localhost URLs")
+		options.BaseURL = "http://localhost"
+	}
+	srv := server.NewWithAuth(site.baseDir(), 0, *site.title, "", "", server.OAuth2Config{}, "", version)
+	srv.Configure(options)
+
+	taken := clock.Now().UTC().Truncate(time.Second)
+	if err := os.MkdirAll(*outDir, 0o755); err != nil {
+		log.Fatalf("Snapshot failed: %v", err)
+	}
+	target := filepath.Join(*outDir, "gomdoc-snapshot-"+taken.Format(snapshotTimeFormat)+".tar.gz")
+	f, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		log.Fatalf("Snapshot failed: %v", err)
+	}
+	hash := sha256.New()
+	files, err := export.WriteSnapshot(srv.Handler(), export.Snapshot{Files: srv.Files(), Taken: taken, Version: version}, io.MultiWriter(f, hash))
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(target)
+		log.Fatalf("Snapshot failed: %v", err)
+	}
+	fmt.Printf("Wrote %s with %d files\nsha256 %s\n", target, files, hex.EncodeToString(hash.Sum(nil)))
+}
@@ -0,0 +1,95 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"strings"
+)
+
+// AuthorsFile is the name of the file with the author bios in the base
+// directory.
+const AuthorsFile = "authors.yml"
+
+// Author is an entry of authors.yml.
+type Author struct {
+	// Key is the top-level key of the entry, matched against the author
+	// frontmatter like Name.
+	Key string
+	// Name is the display name; the key when unset.
+	Name string
+	// Bio is a short biography in markdown.
+	Bio string
+	// URL links to the author's profile or homepage.
+	URL string
+	// Avatar is the URL of a picture of the author.
+	Avatar string
+}
+
+// LoadAuthors reads the author bios from authors.yml in fsys. A missing
+// file gives no authors.
+func LoadAuthors(fsys fs.FS) ([]Author, error) {
+	content, err := fs.ReadFile(fsys, AuthorsFile)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	authors, err := ParseAuthors(string(content))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", AuthorsFile, err)
+	}
+	return authors, nil
+}
+
+// ParseAuthors parses the content of an authors file: a top-level key per
+// author with the name, bio, url, and avatar settings indented below it.
+// Errors name the line they were found on.
+func ParseAuthors(content string) ([]Author, error) {
+	var authors []Author
+	lines := strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n")
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		if line[0] == ' ' || line[0] == '-' {
+			return nil, fmt.Errorf("line %d: unexpected indentation", i+1)
+		}
+		key, rest, found := strings.Cut(trimmed, ":")
+		if !found || unquote(key) == "" || unquote(rest) != "" {
+			return nil, fmt.Errorf("line %d: expected an author key such as \"jane:\"", i+1)
+		}
+		author := Author{Key: unquote(key)}
+		block := listBlock(lines, i+1)
+		for _, setting := range block {
+			name, value, found := strings.Cut(strings.TrimSpace(setting.text), ":")
+			if !found {
+				return nil, fmt.Errorf("line %d: expected \"key: value\"", setting.number)
+			}
+			value = unquote(value)
+			switch strings.TrimSpace(name) {
+			case "name":
+				author.Name = value
+			case "bio":
+				author.Bio = value
+			case "url":
+				author.URL = value
+			case "avatar":
+				author.Avatar = value
+			default:
+				return nil, fmt.Errorf("line %d: unknown author setting %q", setting.number, strings.TrimSpace(name))
+			}
+		}
+		if len(block) > 0 {
+			i = block[len(block)-1].number - 1
+		}
+		if author.Name == "" {
+			author.Name = author.Key
+		}
+		authors = append(authors, author)
+	}
+	return authors, nil
+}
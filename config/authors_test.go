@@ -0,0 +1,53 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestParseAuthors(t *testing.T) {
+	authors, err := ParseAuthors(`# Who writes the docs
+jane:
+  name: Jane Doe
+  bio: "Maintains the **API** guides #api"
+  url: https://example.com/jane
+
+"Max Mustermann":
+  avatar: /img/max.png
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Author{
+		{Key: "jane", Name: "Jane Doe", Bio: "Maintains the **API** guides #api", URL: "https://example.com/jane"},
+		{Key: "Max Mustermann", Name: "Max Mustermann", Avatar: "/img/max.png"},
+	}
+	if !reflect.DeepEqual(authors, want) {
+		t.Errorf("got %+v, want %+v", authors, want)
+	}
+
+	for content, wantErr := range map[string]string{
+		"jane:\n  email: jane@example.com\n": "line 2: unknown author setting",
+		"jane: Jane Doe\n":                   "line 1: expected an author key",
+		"  name: Jane\n":                     "line 1: unexpected indentation",
+		"jane:\n  bio\n":                     "line 2: expected",
+	} {
+		if _, err := ParseAuthors(content); err == nil || !strings.Contains(err.Error(), wantErr) {
+			t.Errorf("ParseAuthors(%q): expected error %q, got %v", content, wantErr, err)
+		}
+	}
+}
+
+func TestLoadAuthors(t *testing.T) {
+	authors, err := LoadAuthors(fstest.MapFS{})
+	if err != nil || authors != nil {
+		t.Errorf("expected no authors without a file, got %+v, %v", authors, err)
+	}
+
+	_, err = LoadAuthors(fstest.MapFS{AuthorsFile: {Data: []byte("jane:\n  nick: J\n")}})
+	if err == nil || !strings.HasPrefix(err.Error(), "authors.yml: line 2:") {
+		t.Errorf("expected the file name and line in the error, got %v", err)
+	}
+}
@@ -0,0 +1,325 @@
+// Package config reads gomdoc.yml, the site configuration kept at the
+// top of the documentation tree. It understands a small subset of YAML:
+// top-level keys and the nested lists below them.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// File is the name of the configuration file in the base directory.
+const File = "gomdoc.yml"
+
+// Config is the site configuration.
+type Config struct {
+	// Nav is the menu shown in the top bar of every page, separate from
+	// the navigation tree generated from the files.
+	Nav []NavItem
+	// Banner is the notice shown above the menu of every page, e.g. "docs
+	// migration in progress". It is off when its Text is empty.
+	Banner Banner
+	// Tables configures the tables of every page.
+	Tables Tables
+	// Types maps lowercase file extensions, such as .drawio, to how files
+	// of that type are served.
+	Types map[string]FileType
+}
+
+// Tables configures how the tables of the pages behave in the browser.
+// Single tables opt in with a {.sortable} attribute list instead.
+type Tables struct {
+	// Sortable lets readers sort every table by a column and filter its
+	// rows.
+	Sortable bool
+	// PageSize shows tables with more rows a page of this many rows at a
+	// time. Zero shows all rows; single tables can set data-page-size.
+	PageSize int
+}
+
+// Severities are the banner severities, from the least to the most urgent.
+// Each has its own color.
+var Severities = []string{"info", "warning", "danger"}
+
+// Banner is a site-wide notice.
+type Banner struct {
+	// Text is the notice in markdown.
+	Text string
+	// Severity is one of Severities; info when unset.
+	Severity string
+	// Expires is when the banner stops showing; zero shows it for good.
+	Expires time.Time
+	// Dismissible adds a close button that hides the banner for the
+	// reader until its text changes.
+	Dismissible bool
+}
+
+// NavItem is an entry of the top-bar menu: a link, or a dropdown of links
+// when Children is set.
+type NavItem struct {
+	// Title is the text of the entry.
+	Title string
+	// URL is the route of a page, e.g. /guide/setup, or an external URL.
+	// Pages may be given by file, as guide/setup.md. It is empty for
+	// dropdowns.
+	URL string
+	// Children are the links of a dropdown.
+	Children []NavItem
+}
+
+// External reports whether the item links to another site.
+func (item NavItem) External() bool {
+	return strings.Contains(item.URL, "://") || strings.HasPrefix(item.URL, "mailto:")
+}
+
+// Load reads the configuration file name from fsys. A missing file gives
+// the zero Config.
+func Load(fsys fs.FS, name string) (Config, error) {
+	content, err := fs.ReadFile(fsys, name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return Config{}, nil
+	}
+	if err != nil {
+		return Config{}, err
+	}
+	cfg, err := Parse(string(content))
+	if err != nil {
+		return cfg, fmt.Errorf("%s: %w", name, err)
+	}
+	return cfg, nil
+}
+
+// Parse parses the content of a configuration file. Errors name the line
+// they were found on. Unknown and deprecated keys are left for Validate
+// to report.
+func Parse(content string) (Config, error) {
+	cfg, problems := Validate(content)
+	for _, problem := range problems {
+		if !problem.Warning {
+			return cfg, problem
+		}
+	}
+	return cfg, nil
+}
+
+// parseSection parses the block of the top-level key into cfg.
+func parseSection(cfg *Config, key string, block []numberedLine) error {
+	var err error
+	switch key {
+	case "nav":
+		cfg.Nav, err = parseNav(block)
+	case "banner":
+		cfg.Banner, err = parseBanner(block)
+	case "tables":
+		cfg.Tables, err = parseTables(block)
+	case "types":
+		cfg.Types, err = parseTypes(block)
+	}
+	return err
+}
+
+// numberedLine is a line of the file with its 1-based number.
+type numberedLine struct {
+	number int
+	text   string
+}
+
+// listBlock returns the indented or list lines following a top-level key
+// at lines[start], without blank lines and comments.
+func listBlock(lines []string, start int) []numberedLine {
+	var block []numberedLine
+	for i := start; i < len(lines); i++ {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		if line[0] != ' ' && line[0] != '-' {
+			break
+		}
+		block = append(block, numberedLine{i + 1, line})
+	}
+	return block
+}
+
+// parseNav parses the items of the nav list: "- Title: target" links and
+// "- Title:" dropdowns with their links indented below.
+func parseNav(block []numberedLine) ([]NavItem, error) {
+	var items []NavItem
+	if len(block) == 0 {
+		return nil, nil
+	}
+	indent := indentation(block[0].text)
+	for i := 0; i < len(block); i++ {
+		line := block[i]
+		if indentation(line.text) != indent {
+			return nil, lineErrorf(line.number, "unexpected indentation")
+		}
+		item, err := parseNavEntry(line)
+		if err != nil {
+			return nil, err
+		}
+		if item.URL != "" {
+			items = append(items, item)
+			continue
+		}
+		var children []numberedLine
+		for i+1 < len(block) && indentation(block[i+1].text) > indent {
+			i++
+			children = append(children, block[i])
+		}
+		if len(children) == 0 {
+			return nil, lineErrorf(line.number, "%q needs a link or a list of links", item.Title)
+		}
+		childIndent := indentation(children[0].text)
+		for _, child := range children {
+			if indentation(child.text) != childIndent {
+				return nil, lineErrorf(child.number, "dropdowns cannot be nested")
+			}
+			link, err := parseNavEntry(child)
+			if err != nil {
+				return nil, err
+			}
+			if link.URL == "" {
+				return nil, lineErrorf(child.number, "dropdowns cannot be nested")
+			}
+			item.Children = append(item.Children, link)
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// parseNavEntry parses a "- Title: target" or "- Title:" list item.
+func parseNavEntry(line numberedLine) (NavItem, error) {
+	text, isItem := strings.CutPrefix(strings.TrimSpace(line.text), "- ")
+	if !isItem {
+		return NavItem{}, lineErrorf(line.number, "expected a list item such as \"- Guides: /guide\"")
+	}
+	title, target, found := strings.Cut(strings.TrimSpace(text), ":")
+	if strings.HasPrefix(target, "//") {
+		// A URL without a title, e.g. "- https://example.com".
+		found = false
+	}
+	title = unquote(title)
+	if !found || title == "" {
+		return NavItem{}, lineErrorf(line.number, "expected \"Title: target\"")
+	}
+	return NavItem{Title: title, URL: route(unquote(target))}, nil
+}
+
+// parseBanner parses the "key: value" lines of the banner section.
+func parseBanner(block []numberedLine) (Banner, error) {
+	banner := Banner{Severity: Severities[0]}
+	for _, line := range block {
+		key, value, found := strings.Cut(strings.TrimSpace(line.text), ":")
+		if !found {
+			return banner, lineErrorf(line.number, "expected \"key: value\"")
+		}
+		value = unquote(value)
+		switch strings.TrimSpace(key) {
+		case "text":
+			banner.Text = value
+		case "severity":
+			if !slices.Contains(Severities, value) {
+				return banner, lineErrorf(line.number, "unknown severity %q, use one of %s", value, strings.Join(Severities, ", "))
+			}
+			banner.Severity = value
+		case "expires":
+			expires, err := parseExpiry(value)
+			if err != nil {
+				return banner, lineErrorf(line.number, "invalid expires %q: use a date such as 2025-12-31 or a time such as 2025-12-31T18:00:00Z", value)
+			}
+			banner.Expires = expires
+		case "dismissible":
+			dismissible, err := strconv.ParseBool(value)
+			if err != nil {
+				return banner, lineErrorf(line.number, "invalid dismissible %q: use true or false", value)
+			}
+			banner.Dismissible = dismissible
+		default:
+			return banner, lineErrorf(line.number, "unknown banner setting %q%s", strings.TrimSpace(key), didYouMean(strings.TrimSpace(key), sections["banner"].keys))
+		}
+	}
+	if len(block) > 0 && banner.Text == "" {
+		return banner, lineErrorf(block[0].number, "the banner needs a text")
+	}
+	return banner, nil
+}
+
+// parseTables parses the "key: value" lines of the tables section.
+func parseTables(block []numberedLine) (Tables, error) {
+	var tables Tables
+	for _, line := range block {
+		key, value, found := strings.Cut(strings.TrimSpace(line.text), ":")
+		if !found {
+			return tables, lineErrorf(line.number, "expected \"key: value\"")
+		}
+		value = unquote(value)
+		switch strings.TrimSpace(key) {
+		case "sortable":
+			sortable, err := strconv.ParseBool(value)
+			if err != nil {
+				return tables, lineErrorf(line.number, "invalid sortable %q: use true or false", value)
+			}
+			tables.Sortable = sortable
+		case "page_size":
+			size, err := strconv.Atoi(value)
+			if err != nil || size < 0 {
+				return tables, lineErrorf(line.number, "invalid page_size %q: use a number of rows, or 0 for no pages", value)
+			}
+			tables.PageSize = size
+		default:
+			return tables, lineErrorf(line.number, "unknown tables setting %q%s", strings.TrimSpace(key), didYouMean(strings.TrimSpace(key), sections["tables"].keys))
+		}
+	}
+	return tables, nil
+}
+
+// parseExpiry parses the expires value of the banner. A date expires at
+// the end of that day in local time.
+func parseExpiry(value string) (time.Time, error) {
+	if t, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		return t.AddDate(0, 0, 1), nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
+
+// route turns a page file such as guide/setup.md into its route, and
+// leaves routes and external URLs as they are.
+func route(target string) string {
+	item := NavItem{URL: target}
+	if target == "" || item.External() || strings.HasPrefix(target, "#") {
+		return target
+	}
+	target = strings.TrimSuffix(strings.TrimSuffix(target, ".md"), ".MD")
+	if !strings.HasPrefix(target, "/") {
+		target = "/" + target
+	}
+	return target
+}
+
+// unquote trims spaces, a trailing comment, and the quotes around a YAML
+// scalar. Quoted values keep a " #" in them.
+func unquote(value string) string {
+	value = strings.TrimSpace(value)
+	if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') {
+		if end := strings.IndexByte(value[1:], value[0]); end >= 0 {
+			return value[1 : end+1]
+		}
+	}
+	if i := strings.Index(value, " #"); i >= 0 {
+		value = strings.TrimSpace(value[:i])
+	}
+	return value
+}
+
+// indentation returns the number of leading spaces of line.
+func indentation(line string) int {
+	return len(line) - len(strings.TrimLeft(line, " "))
+}
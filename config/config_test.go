@@ -0,0 +1,141 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	cfg, err := Parse(`# Site configuration
+nav:
+  - Home: /
+  - Guides:
+      - Setup: guide/setup.md
+      - "Upgrading": /guide/upgrading   # since 2.0
+  - GitHub: https://github.com/lacrioque/gomdoc
+other: ignored
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []NavItem{
+		{Title: "Home", URL: "/"},
+		{Title: "Guides", Children: []NavItem{
+			{Title: "Setup", URL: "/guide/setup"},
+			{Title: "Upgrading", URL: "/guide/upgrading"},
+		}},
+		{Title: "GitHub", URL: "https://github.com/lacrioque/gomdoc"},
+	}
+	if !reflect.DeepEqual(cfg.Nav, want) {
+		t.Errorf("got nav %+v, want %+v", cfg.Nav, want)
+	}
+	if cfg.Nav[0].External() || !cfg.Nav[2].External() {
+		t.Errorf("expected only the GitHub link external")
+	}
+
+	for content, wantErr := range map[string]string{
+		"nav:\n  - Guides:\n": "line 2:",
+		"nav:\n  - Guides:\n      - More:\n          - Deep: /deep\n": "line 3: dropdowns cannot be nested",
+		"nav:\n  - /guide\n":   "line 2:",
+		"nav:\n  Guides: /x\n": "line 2: expected a list item",
+	} {
+		if _, err := Parse(content); err == nil || !strings.Contains(err.Error(), wantErr) {
+			t.Errorf("Parse(%q): expected error %q, got %v", content, wantErr, err)
+		}
+	}
+}
+
+func TestParseBanner(t *testing.T) {
+	cfg, err := Parse(`banner:
+  text: "Docs are moving to [the new site](https://docs.example.com) #soon"
+  severity: warning
+  expires: 2025-12-31
+  dismissible: true
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Banner{
+		Text:        "Docs are moving to [the new site](https://docs.example.com) #soon",
+		Severity:    "warning",
+		Expires:     time.Date(2026, 1, 1, 0, 0, 0, 0, time.Local),
+		Dismissible: true,
+	}
+	if !reflect.DeepEqual(cfg.Banner, want) {
+		t.Errorf("got banner %+v, want %+v", cfg.Banner, want)
+	}
+
+	if cfg, _ := Parse("banner:\n  text: Maintenance tonight\n"); cfg.Banner.Severity != "info" {
+		t.Errorf("expected the info severity by default, got %q", cfg.Banner.Severity)
+	}
+	for content, wantErr := range map[string]string{
+		"banner:\n  text: x\n  severity: loud\n":     "line 3: unknown severity",
+		"banner:\n  text: x\n  expires: next week\n": "line 3: invalid expires",
+		"banner:\n  text: x\n  dismissable: true\n":  "line 3: unknown banner setting",
+		"banner:\n  severity: info\n":                "needs a text",
+	} {
+		if _, err := Parse(content); err == nil || !strings.Contains(err.Error(), wantErr) {
+			t.Errorf("Parse(%q): expected error %q, got %v", content, wantErr, err)
+		}
+	}
+}
+
+func TestParseTables(t *testing.T) {
+	cfg, err := Parse("tables:\n  sortable: true\n  page_size: 50\n")
+	if want := (Tables{Sortable: true, PageSize: 50}); err != nil || cfg.Tables != want {
+		t.Errorf("expected %+v, got %+v, %v", want, cfg.Tables, err)
+	}
+	for content, wantErr := range map[string]string{
+		"tables:\n  sortable: sometimes\n": "line 2: invalid sortable",
+		"tables:\n  page_size: -5\n":       "line 2: invalid page_size",
+		"tables:\n  sorted: true\n":        "line 2: unknown tables setting",
+	} {
+		if _, err := Parse(content); err == nil || !strings.Contains(err.Error(), wantErr) {
+			t.Errorf("Parse(%q): expected error %q, got %v", content, wantErr, err)
+		}
+	}
+}
+
+func TestParseTypes(t *testing.T) {
+	cfg, err := Parse(`types:
+  .drawio: attachment
+  .PUML: text/plain; charset=utf-8
+  .har: "application/json, attachment"   # HTTP archives
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]FileType{
+		".drawio": {Disposition: "attachment"},
+		".puml":   {ContentType: "text/plain; charset=utf-8"},
+		".har":    {ContentType: "application/json", Disposition: "attachment"},
+	}
+	if !reflect.DeepEqual(cfg.Types, want) {
+		t.Errorf("got types %+v, want %+v", cfg.Types, want)
+	}
+	for content, wantErr := range map[string]string{
+		"types:\n  drawio: attachment\n": "line 2: expected",
+		"types:\n  .drawio: download\n":  `line 2: unknown type "download"`,
+		"types:\n  .drawio: text/;x\n":   "line 2: invalid content type",
+		"types:\n  .drawio:\n":           "line 2: .drawio needs",
+		"types: .drawio\n":               `line 1: types takes settings on their own lines below it, such as "  .drawio: attachment"`,
+	} {
+		if _, err := Parse(content); err == nil || !strings.Contains(err.Error(), wantErr) {
+			t.Errorf("Parse(%q): expected error %q, got %v", content, wantErr, err)
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	cfg, err := Load(fstest.MapFS{}, File)
+	if err != nil || cfg.Nav != nil {
+		t.Errorf("expected an empty config without a file, got %+v, %v", cfg, err)
+	}
+	_, err = Load(fstest.MapFS{File: {Data: []byte("nav:\n  - Broken\n")}}, File)
+	if err == nil || !strings.HasPrefix(err.Error(), "gomdoc.yml: line 2:") {
+		t.Errorf("expected an error naming the file and line, got %v", err)
+	}
+}
@@ -0,0 +1,64 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Format writes cfg in the syntax of the configuration file, so that
+// Parse reads it back. Sections left at their zero value are left out,
+// except tables, whose defaults are shown.
+func Format(cfg Config) string {
+	var sb strings.Builder
+	if len(cfg.Nav) > 0 {
+		sb.WriteString("nav:\n")
+		for _, item := range cfg.Nav {
+			if len(item.Children) == 0 {
+				fmt.Fprintf(&sb, "  - %s: %s\n", quote(item.Title), quote(item.URL))
+				continue
+			}
+			fmt.Fprintf(&sb, "  - %s:\n", quote(item.Title))
+			for _, child := range item.Children {
+				fmt.Fprintf(&sb, "      - %s: %s\n", quote(child.Title), quote(child.URL))
+			}
+		}
+	}
+	if cfg.Banner.Text != "" {
+		sb.WriteString("banner:\n")
+		fmt.Fprintf(&sb, "  text: %s\n", quote(cfg.Banner.Text))
+		fmt.Fprintf(&sb, "  severity: %s\n", cfg.Banner.Severity)
+		if !cfg.Banner.Expires.IsZero() {
+			fmt.Fprintf(&sb, "  expires: %s\n", cfg.Banner.Expires.Format(time.RFC3339))
+		}
+		fmt.Fprintf(&sb, "  dismissible: %t\n", cfg.Banner.Dismissible)
+	}
+	sb.WriteString("tables:\n")
+	fmt.Fprintf(&sb, "  sortable: %t\n", cfg.Tables.Sortable)
+	fmt.Fprintf(&sb, "  page_size: %d\n", cfg.Tables.PageSize)
+	if len(cfg.Types) > 0 {
+		sb.WriteString("types:\n")
+		exts := make([]string, 0, len(cfg.Types))
+		for ext := range cfg.Types {
+			exts = append(exts, ext)
+		}
+		slices.Sort(exts)
+		for _, ext := range exts {
+			fmt.Fprintf(&sb, "  %s: %s\n", ext, quote(cfg.Types[ext].String()))
+		}
+	}
+	return sb.String()
+}
+
+// quote quotes a value that unquote would change, such as one with a
+// comment or quotes around it.
+func quote(value string) string {
+	if value != "" && unquote(value) == value && !strings.ContainsAny(value[:1], `"'`) {
+		return value
+	}
+	if strings.Contains(value, `"`) {
+		return "'" + value + "'"
+	}
+	return `"` + value + `"`
+}
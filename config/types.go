@@ -0,0 +1,66 @@
+package config
+
+import (
+	"mime"
+	"slices"
+	"strings"
+)
+
+// Dispositions are the ways a browser can be told to handle a file:
+// show it, or save it.
+var Dispositions = []string{"inline", "attachment"}
+
+// FileType is how files with an extension are served.
+type FileType struct {
+	// ContentType is the Content-Type header, such as
+	// application/vnd.jgraph.mxfile. Empty keeps the built-in type.
+	ContentType string
+	// Disposition is one of Dispositions. Empty leaves the choice to the
+	// browser.
+	Disposition string
+}
+
+// String writes the file type as in the configuration file.
+func (t FileType) String() string {
+	var parts []string
+	for _, part := range []string{t.ContentType, t.Disposition} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// parseTypes parses the ".ext: content/type, disposition" lines of the
+// types section. Either part may be left out.
+func parseTypes(block []numberedLine) (map[string]FileType, error) {
+	types := make(map[string]FileType)
+	for _, line := range block {
+		ext, value, found := strings.Cut(strings.TrimSpace(line.text), ":")
+		ext = strings.ToLower(unquote(ext))
+		if !found || len(ext) < 2 || ext[0] != '.' || strings.ContainsAny(ext[1:], "./ ") {
+			return nil, lineErrorf(line.number, "expected \".ext: content/type, attachment\"")
+		}
+		var fileType FileType
+		for _, part := range strings.Split(unquote(value), ",") {
+			part = strings.TrimSpace(part)
+			switch {
+			case part == "":
+			case slices.Contains(Dispositions, strings.ToLower(part)):
+				fileType.Disposition = strings.ToLower(part)
+			case strings.Contains(part, "/"):
+				if _, _, err := mime.ParseMediaType(part); err != nil {
+					return nil, lineErrorf(line.number, "invalid content type %q: %v", part, err)
+				}
+				fileType.ContentType = part
+			default:
+				return nil, lineErrorf(line.number, "unknown type %q for %s: use a content type such as text/plain, %s", part, ext, strings.Join(Dispositions, ", or "))
+			}
+		}
+		if fileType == (FileType{}) {
+			return nil, lineErrorf(line.number, "%s needs a content type, a disposition, or both", ext)
+		}
+		types[ext] = fileType
+	}
+	return types, nil
+}
@@ -0,0 +1,167 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// section describes a top-level key of the configuration file.
+type section struct {
+	// list marks sections holding a list, such as nav; the others hold
+	// "key: value" settings.
+	list bool
+	// keys are the settings of the others.
+	keys []string
+	// example is a first line of the block, suggested when the section
+	// is given a value on its own line.
+	example string
+}
+
+// sections is the schema of the configuration file: its top-level keys
+// and the settings below them.
+var sections = map[string]section{
+	"nav":    {list: true, example: "- Home: /"},
+	"banner": {keys: []string{"text", "severity", "expires", "dismissible"}, example: "text: ..."},
+	"tables": {keys: []string{"sortable", "page_size"}, example: "sortable: ..."},
+	"types":  {example: ".drawio: attachment"},
+}
+
+// deprecated maps keys that still work but are going away, given as
+// section or section.setting, to what to use instead.
+var deprecated = map[string]string{}
+
+// Problem is a mistake in a configuration file.
+type Problem struct {
+	// Line is the 1-based line number.
+	Line int
+	// Message describes the mistake.
+	Message string
+	// Warning marks mistakes that do not stop the file from loading, such
+	// as unknown and deprecated keys.
+	Warning bool
+}
+
+// Error returns the message with its line.
+func (p Problem) Error() string {
+	return fmt.Sprintf("line %d: %s", p.Line, p.Message)
+}
+
+// lineErrorf returns a Problem on line.
+func lineErrorf(line int, format string, args ...any) error {
+	return Problem{Line: line, Message: fmt.Sprintf(format, args...)}
+}
+
+// Validate parses the content of a configuration file like Parse, but
+// goes on after mistakes and returns all of them in line order: errors in
+// the sections, which Parse fails on, and warnings about unknown,
+// repeated, and deprecated keys. A section with an error keeps its zero
+// value.
+func Validate(content string) (Config, []Problem) {
+	var cfg Config
+	var problems []Problem
+	report := func(err error) {
+		var problem Problem
+		if errors.As(err, &problem) {
+			problems = append(problems, problem)
+		} else if err != nil {
+			problems = append(problems, Problem{Message: err.Error()})
+		}
+	}
+
+	seen := make(map[string]int)
+	lines := strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n")
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") || line[0] == ' ' || line[0] == '-' {
+			continue
+		}
+		key, value, found := strings.Cut(trimmed, ":")
+		key = strings.TrimSpace(key)
+		number := i + 1
+		schema, known := sections[key]
+		switch {
+		case !found:
+			problems = append(problems, Problem{Line: number, Message: fmt.Sprintf("expected \"key:\", ignoring %q", trimmed), Warning: true})
+			continue
+		case !known:
+			problems = append(problems, Problem{Line: number, Message: fmt.Sprintf("unknown key %q%s", key, didYouMean(key, keys(sections))), Warning: true})
+			continue
+		}
+		if first, ok := seen[key]; ok {
+			problems = append(problems, Problem{Line: number, Message: fmt.Sprintf("%s is already set on line %d; this one replaces it", key, first), Warning: true})
+		}
+		seen[key] = number
+		if advice, ok := deprecated[key]; ok {
+			problems = append(problems, Problem{Line: number, Message: fmt.Sprintf("%s is deprecated: %s", key, advice), Warning: true})
+		}
+
+		block := listBlock(lines, i+1)
+		if unquote(value) != "" {
+			if schema.list {
+				report(lineErrorf(number, "%s takes a list, with each item on its own line below it, such as \"  %s\"", key, schema.example))
+			} else {
+				report(lineErrorf(number, "%s takes settings on their own lines below it, such as \"  %s\"", key, schema.example))
+			}
+			continue
+		}
+		if !schema.list {
+			for _, line := range block {
+				setting, _, _ := strings.Cut(strings.TrimSpace(line.text), ":")
+				if advice, ok := deprecated[key+"."+strings.TrimSpace(setting)]; ok {
+					problems = append(problems, Problem{Line: line.number, Message: fmt.Sprintf("%s.%s is deprecated: %s", key, strings.TrimSpace(setting), advice), Warning: true})
+				}
+			}
+		}
+		report(parseSection(&cfg, key, block))
+	}
+	slices.SortStableFunc(problems, func(a, b Problem) int { return a.Line - b.Line })
+	return cfg, problems
+}
+
+// keys returns the top-level keys of the schema, sorted.
+func keys(sections map[string]section) []string {
+	names := make([]string, 0, len(sections))
+	for name := range sections {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// didYouMean suggests the known key closest to a mistyped key, as
+// `; did you mean "key"?`, or returns "" when none is close.
+func didYouMean(key string, known []string) string {
+	best, bestDistance := "", 3
+	for _, candidate := range known {
+		if d := editDistance(strings.ToLower(key), candidate); d < bestDistance {
+			best, bestDistance = candidate, d
+		}
+	}
+	if best == "" {
+		return ""
+	}
+	return fmt.Sprintf("; did you mean %q?", best)
+}
+
+// editDistance returns the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
@@ -0,0 +1,84 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidate(t *testing.T) {
+	cfg, problems := Validate(`navv:
+  - Home: /
+nav:
+  - Home: /
+banner:
+  text: Moving
+  dismissable: true
+tables: true
+tables:
+  sortable: true
+stray line
+`)
+	var got []string
+	for _, problem := range problems {
+		kind := "error"
+		if problem.Warning {
+			kind = "warning"
+		}
+		got = append(got, kind+": "+problem.Error())
+	}
+	want := []string{
+		`warning: line 1: unknown key "navv"; did you mean "nav"?`,
+		`error: line 7: unknown banner setting "dismissable"; did you mean "dismissible"?`,
+		`error: line 8: tables takes settings on their own lines below it, such as "  sortable: ..."`,
+		`warning: line 9: tables is already set on line 8; this one replaces it`,
+		`warning: line 11: expected "key:", ignoring "stray line"`,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got problems\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	if len(cfg.Nav) != 1 || !cfg.Tables.Sortable {
+		t.Errorf("expected the valid sections parsed, got %+v", cfg)
+	}
+
+	if _, err := Parse("navv:\n  - Home: /\n"); err != nil {
+		t.Errorf("expected warnings not to fail Parse, got %v", err)
+	}
+	if _, err := Parse("nav: /home\n"); err == nil || !strings.Contains(err.Error(), "line 1: nav takes a list") {
+		t.Errorf("expected an error for a list given as a value, got %v", err)
+	}
+
+	deprecated["tables.sortable"] = "use the {.sortable} attribute list"
+	defer delete(deprecated, "tables.sortable")
+	if _, problems := Validate("tables:\n  sortable: true\n"); len(problems) != 1 || problems[0].Line != 2 || !problems[0].Warning {
+		t.Errorf("expected a deprecation warning on line 2, got %+v", problems)
+	}
+}
+
+func TestFormat(t *testing.T) {
+	cfg := Config{
+		Nav: []NavItem{
+			{Title: "Home", URL: "/"},
+			{Title: "Guides", Children: []NavItem{{Title: "Setup", URL: "/guide/setup"}}},
+			{Title: "GitHub", URL: "https://github.com/lacrioque/gomdoc"},
+		},
+		Banner: Banner{Text: "Docs are moving #soon", Severity: "warning", Expires: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), Dismissible: true},
+		Tables: Tables{Sortable: true, PageSize: 50},
+		Types: map[string]FileType{
+			".drawio": {Disposition: "attachment"},
+			".har":    {ContentType: "application/json", Disposition: "inline"},
+		},
+	}
+	parsed, err := Parse(Format(cfg))
+	if err != nil {
+		t.Fatalf("Parse(Format(cfg)) failed: %v\n%s", err, Format(cfg))
+	}
+	if !parsed.Banner.Expires.Equal(cfg.Banner.Expires) {
+		t.Errorf("expected expires %v, got %v", cfg.Banner.Expires, parsed.Banner.Expires)
+	}
+	parsed.Banner.Expires = cfg.Banner.Expires
+	if !reflect.DeepEqual(parsed, cfg) {
+		t.Errorf("expected the config back, got %+v\n%s", parsed, Format(cfg))
+	}
+}
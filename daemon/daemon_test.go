@@ -0,0 +1,90 @@
+package daemon
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestRotatingLogRotatesBySize(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "gomdoc.log")
+	l, err := OpenLog(path, LogOptions{MaxSize: 10, MaxBackups: 2})
+	if err != nil {
+		t.Fatalf("OpenLog failed: %v", err)
+	}
+	defer l.Close()
+
+	for _, line := range []string{"first\n", "second\n", "third\n", "fourth\n"} {
+		if _, err := l.Write([]byte(line)); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+	}
+
+	content, _ := os.ReadFile(path)
+	if string(content) != "fourth\n" {
+		t.Errorf("expected current log to hold the last line, got %q", content)
+	}
+	backups, _ := filepath.Glob(path + ".*")
+	if len(backups) != 2 {
+		t.Errorf("expected 2 backups to be kept, got %d", len(backups))
+	}
+}
+
+func TestRotatingLogReopensAfterFailedRotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "gomdoc.log")
+	l, err := OpenLog(path, LogOptions{MaxSize: 12})
+	if err != nil {
+		t.Fatalf("OpenLog failed: %v", err)
+	}
+	defer l.Close()
+
+	l.Write([]byte("first\n"))
+	os.Remove(path) // moving the log aside fails now
+	if _, err := l.Write([]byte("second\n")); err == nil {
+		t.Error("expected the failed rotation to be reported")
+	}
+	if _, err := l.Write([]byte("end\n")); err != nil {
+		t.Fatalf("expected logging to go on, got %v", err)
+	}
+
+	content, _ := os.ReadFile(path)
+	if string(content) != "second\nend\n" {
+		t.Errorf("expected the log file opened again, got %q", content)
+	}
+}
+
+func TestRotatingLogAppendsToExistingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "gomdoc.log")
+	os.WriteFile(path, []byte("old\n"), 0o644)
+	l, err := OpenLog(path, LogOptions{})
+	if err != nil {
+		t.Fatalf("OpenLog failed: %v", err)
+	}
+	l.Write([]byte("new\n"))
+	l.Close()
+
+	content, _ := os.ReadFile(path)
+	if string(content) != "old\nnew\n" {
+		t.Errorf("expected appended content, got %q", content)
+	}
+}
+
+func TestPIDFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "gomdoc.pid")
+	if err := WritePIDFile(path); err != nil {
+		t.Fatalf("WritePIDFile failed: %v", err)
+	}
+	content, _ := os.ReadFile(path)
+	if strings.TrimSpace(string(content)) != strconv.Itoa(os.Getpid()) {
+		t.Errorf("unexpected PID file content %q", content)
+	}
+
+	if err := RemovePIDFile(path); err != nil {
+		t.Fatalf("RemovePIDFile failed: %v", err)
+	}
+	if err := RemovePIDFile(path); err != nil {
+		t.Errorf("expected removing a missing PID file to succeed, got %v", err)
+	}
+}
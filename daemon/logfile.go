@@ -0,0 +1,157 @@
+// Package daemon provides helpers for running gomdoc as a long-lived
+// service without external wrappers: a rotating log file and a PID file.
+package daemon
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// backupTimeFormat names rotated log files so they sort chronologically.
+const backupTimeFormat = "20060102-150405.000"
+
+// LogOptions controls when a log file is rotated and how many old files
+// are kept. Zero values disable the respective limit.
+type LogOptions struct {
+	// MaxSize rotates the log once it would grow beyond this many bytes.
+	MaxSize int64
+	// MaxAge rotates the log once it has been open for this long.
+	MaxAge time.Duration
+	// MaxBackups is the number of rotated files to keep.
+	MaxBackups int
+}
+
+// RotatingLog is an io.Writer appending to a log file that is rotated by
+// size and age. Rotated files get a timestamp suffix, e.g.
+// gomdoc.log.20240131-120000.000.
+type RotatingLog struct {
+	mu      sync.Mutex
+	path    string
+	options LogOptions
+	file    *os.File
+	size    int64
+	opened  time.Time
+}
+
+// OpenLog opens path for appending, creating it if needed.
+func OpenLog(path string, opts LogOptions) (*RotatingLog, error) {
+	l := &RotatingLog{path: path, options: opts}
+	if err := l.open(); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// Write appends p to the log, rotating first if a limit is reached. When
+// rotating fails, p is still appended to the current file and the
+// rotation error returned.
+func (l *RotatingLog) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var rotateErr error
+	if l.shouldRotate(len(p)) {
+		rotateErr = l.rotate()
+	}
+	n, err := l.file.Write(p)
+	l.size += int64(n)
+	if err != nil {
+		return n, errors.Join(rotateErr, err)
+	}
+	return n, rotateErr
+}
+
+// Close closes the current log file.
+func (l *RotatingLog) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.file.Close()
+}
+
+// shouldRotate reports whether writing n more bytes exceeds a limit. An
+// empty file is never rotated, so a single large write cannot loop.
+func (l *RotatingLog) shouldRotate(n int) bool {
+	if l.size == 0 {
+		return false
+	}
+	if l.options.MaxSize > 0 && l.size+int64(n) > l.options.MaxSize {
+		return true
+	}
+	return l.options.MaxAge > 0 && time.Since(l.opened) >= l.options.MaxAge
+}
+
+// open opens the log file for appending and records its current size.
+func (l *RotatingLog) open() error {
+	file, err := os.OpenFile(l.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("open log file %s: %w", l.path, err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("stat log file %s: %w", l.path, err)
+	}
+	l.file = file
+	l.size = info.Size()
+	l.opened = time.Now()
+	return nil
+}
+
+// rotate moves the current file aside, opens a fresh one, and prunes old
+// backups. When closing or moving the file fails, the log file is opened
+// again for appending, so that logging goes on.
+func (l *RotatingLog) rotate() error {
+	var err error
+	if closeErr := l.file.Close(); closeErr != nil {
+		err = fmt.Errorf("close log file %s: %w", l.path, closeErr)
+	} else if renameErr := os.Rename(l.path, l.backupName()); renameErr != nil {
+		err = fmt.Errorf("rotate log file %s: %w", l.path, renameErr)
+	}
+	if openErr := l.open(); openErr != nil || err != nil {
+		return errors.Join(err, openErr)
+	}
+	return l.prune()
+}
+
+// backupName returns an unused timestamped name for the rotated file. A
+// counter is appended when several rotations happen within a millisecond.
+func (l *RotatingLog) backupName() string {
+	base := l.path + "." + time.Now().Format(backupTimeFormat)
+	name := base
+	for i := 1; fileExists(name); i++ {
+		name = fmt.Sprintf("%s-%d", base, i)
+	}
+	return name
+}
+
+// fileExists reports whether path exists.
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// prune removes the oldest rotated files beyond MaxBackups.
+func (l *RotatingLog) prune() error {
+	if l.options.MaxBackups <= 0 {
+		return nil
+	}
+	backups, err := filepath.Glob(l.path + ".*")
+	if err != nil {
+		return err
+	}
+	if len(backups) <= l.options.MaxBackups {
+		return nil
+	}
+	sort.Strings(backups)
+	for _, backup := range backups[:len(backups)-l.options.MaxBackups] {
+		if err := os.Remove(backup); err != nil {
+			return fmt.Errorf("remove old log file %s: %w", backup, err)
+		}
+	}
+	return nil
+}
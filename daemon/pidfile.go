@@ -0,0 +1,26 @@
+package daemon
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+)
+
+// WritePIDFile records the current process ID in path so init scripts and
+// monitoring can find the running server.
+func WritePIDFile(path string) error {
+	pid := strconv.Itoa(os.Getpid()) + "\n"
+	if err := os.WriteFile(path, []byte(pid), 0o644); err != nil {
+		return fmt.Errorf("write PID file %s: %w", path, err)
+	}
+	return nil
+}
+
+// RemovePIDFile deletes the PID file written by WritePIDFile. A missing
+// file is not an error.
+func RemovePIDFile(path string) error {
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("remove PID file %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,10 @@
+package daemon
+
+import "errors"
+
+// ServiceName is the name gomdoc registers with the Windows service manager.
+const ServiceName = "gomdoc"
+
+// ErrServiceUnsupported is returned by the service helpers on platforms
+// without a Windows-style service manager. Use systemd or launchd there.
+var ErrServiceUnsupported = errors.New("service management is only supported on Windows")
@@ -0,0 +1,34 @@
+//go:build !windows
+
+package daemon
+
+// IsService reports whether the process was started by a service manager.
+// It is always false outside Windows.
+func IsService() bool {
+	return false
+}
+
+// RunService is not supported outside Windows.
+func RunService(name string, run func() error) error {
+	return ErrServiceUnsupported
+}
+
+// InstallService is not supported outside Windows.
+func InstallService(name string, args []string) error {
+	return ErrServiceUnsupported
+}
+
+// UninstallService is not supported outside Windows.
+func UninstallService(name string) error {
+	return ErrServiceUnsupported
+}
+
+// StartService is not supported outside Windows.
+func StartService(name string) error {
+	return ErrServiceUnsupported
+}
+
+// StopService is not supported outside Windows.
+func StopService(name string) error {
+	return ErrServiceUnsupported
+}
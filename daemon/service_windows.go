@@ -0,0 +1,139 @@
+//go:build windows
+
+package daemon
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"golang.org/x/sys/windows/svc"
+	"golang.org/x/sys/windows/svc/mgr"
+)
+
+// stopTimeout bounds how long StopService waits for the service to stop.
+const stopTimeout = 30 * time.Second
+
+// IsService reports whether the process was started by the Windows
+// service control manager.
+func IsService() bool {
+	isService, err := svc.IsWindowsService()
+	return err == nil && isService
+}
+
+// RunService runs the server under the service control manager. run
+// blocks while serving; a stop request from the manager ends the process.
+func RunService(name string, run func() error) error {
+	return svc.Run(name, &serviceHandler{run: run})
+}
+
+// serviceHandler adapts the blocking server to the svc.Handler interface.
+type serviceHandler struct {
+	run func() error
+}
+
+// Execute implements svc.Handler.
+func (h *serviceHandler) Execute(_ []string, requests <-chan svc.ChangeRequest, status chan<- svc.Status) (bool, uint32) {
+	status <- svc.Status{State: svc.StartPending}
+	errs := make(chan error, 1)
+	go func() { errs <- h.run() }()
+	status <- svc.Status{State: svc.Running, Accepts: svc.AcceptStop | svc.AcceptShutdown}
+
+	for {
+		select {
+		case err := <-errs:
+			if err != nil {
+				return true, 1
+			}
+			return false, 0
+		case request := <-requests:
+			switch request.Cmd {
+			case svc.Interrogate:
+				status <- request.CurrentStatus
+			case svc.Stop, svc.Shutdown:
+				status <- svc.Status{State: svc.StopPending}
+				return false, 0
+			}
+		}
+	}
+}
+
+// InstallService registers the running executable as an automatically
+// started service. args are passed to gomdoc on every service start.
+func InstallService(name string, args []string) error {
+	exePath, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("locate executable: %w", err)
+	}
+	m, err := mgr.Connect()
+	if err != nil {
+		return fmt.Errorf("connect to service manager: %w", err)
+	}
+	defer m.Disconnect()
+
+	config := mgr.Config{
+		DisplayName: "gomdoc",
+		Description: "Markdown documentation server",
+		StartType:   mgr.StartAutomatic,
+	}
+	s, err := m.CreateService(name, exePath, config, args...)
+	if err != nil {
+		return fmt.Errorf("create service %s: %w", name, err)
+	}
+	defer s.Close()
+	return nil
+}
+
+// UninstallService removes the service registration.
+func UninstallService(name string) error {
+	return withService(name, func(s *mgr.Service) error {
+		return s.Delete()
+	})
+}
+
+// StartService asks the service manager to start the service.
+func StartService(name string) error {
+	return withService(name, func(s *mgr.Service) error {
+		return s.Start()
+	})
+}
+
+// StopService asks the service to stop and waits until it has.
+func StopService(name string) error {
+	return withService(name, func(s *mgr.Service) error {
+		status, err := s.Control(svc.Stop)
+		if err != nil {
+			return err
+		}
+		deadline := time.Now().Add(stopTimeout)
+		for status.State != svc.Stopped {
+			if time.Now().After(deadline) {
+				return fmt.Errorf("timed out waiting for service to stop")
+			}
+			time.Sleep(300 * time.Millisecond)
+			if status, err = s.Query(); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// withService opens the named service and calls fn with it.
+func withService(name string, fn func(*mgr.Service) error) error {
+	m, err := mgr.Connect()
+	if err != nil {
+		return fmt.Errorf("connect to service manager: %w", err)
+	}
+	defer m.Disconnect()
+
+	s, err := m.OpenService(name)
+	if err != nil {
+		return fmt.Errorf("open service %s: %w", name, err)
+	}
+	defer s.Close()
+	if err := fn(s); err != nil {
+		return fmt.Errorf("service %s: %w", name, err)
+	}
+	return nil
+}
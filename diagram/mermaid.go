@@ -0,0 +1,136 @@
+// Package diagram pre-renders Mermaid diagrams to SVG with an external
+// command such as mermaid-cli, so exported sites, printouts, and browsers
+// without JavaScript show them too.
+package diagram
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"gomdoc/tracing"
+)
+
+// Renderer runs the diagram command and caches its output by the hash of
+// the command and the diagram source, in memory and in CacheDir.
+type Renderer struct {
+	command  string
+	cacheDir string
+
+	mu     sync.Mutex
+	memory map[string]string
+}
+
+// New returns a Renderer for command, which is split into words with
+// {input} standing for a file holding the diagram source and {output} for
+// the SVG file it writes, e.g. "mmdc -i {input} -o {output}". Rendered
+// diagrams are also stored in cacheDir, unless it is empty, so they are
+// reused across runs.
+func New(command, cacheDir string) *Renderer {
+	return &Renderer{command: command, cacheDir: cacheDir, memory: make(map[string]string)}
+}
+
+// Render returns the SVG of the Mermaid diagram source. Diagrams that fail
+// to render are not cached, so they are tried again next time. The lookup
+// is traced as a cache.diagram span naming where the diagram came from.
+func (r *Renderer) Render(ctx context.Context, source string) (svg string, err error) {
+	ctx, span := tracing.Start(ctx, "cache.diagram")
+	defer func() {
+		span.SetError(err)
+		span.End()
+	}()
+	key := r.key(source)
+	r.mu.Lock()
+	svg, ok := r.memory[key]
+	r.mu.Unlock()
+	if ok {
+		span.SetAttribute("gomdoc.cache", "memory")
+		return svg, nil
+	}
+
+	cacheFile := ""
+	if r.cacheDir != "" {
+		cacheFile = filepath.Join(r.cacheDir, key+".svg")
+		if data, err := os.ReadFile(cacheFile); err == nil {
+			svg = string(data)
+			r.remember(key, svg)
+			span.SetAttribute("gomdoc.cache", "disk")
+			return svg, nil
+		}
+	}
+
+	span.SetAttribute("gomdoc.cache", "miss")
+	svg, err = r.run(ctx, source)
+	if err != nil {
+		return "", err
+	}
+	r.remember(key, svg)
+	if cacheFile != "" {
+		if err := os.MkdirAll(r.cacheDir, 0o755); err == nil {
+			os.WriteFile(cacheFile, []byte(svg), 0o644)
+		}
+	}
+	return svg, nil
+}
+
+// key returns the cache key of source.
+func (r *Renderer) key(source string) string {
+	sum := sha256.Sum256([]byte(r.command + "\x00" + source))
+	return hex.EncodeToString(sum[:])
+}
+
+// remember stores a rendered diagram in memory.
+func (r *Renderer) remember(key, svg string) {
+	r.mu.Lock()
+	r.memory[key] = svg
+	r.mu.Unlock()
+}
+
+// run writes source to a temporary file, runs the command on it, and
+// reads the SVG it wrote.
+func (r *Renderer) run(ctx context.Context, source string) (string, error) {
+	args := strings.Fields(r.command)
+	if len(args) == 0 {
+		return "", fmt.Errorf("no diagram command")
+	}
+
+	dir, err := os.MkdirTemp("", "gomdoc-diagram-")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(dir)
+	input := filepath.Join(dir, "diagram.mmd")
+	output := filepath.Join(dir, "diagram.svg")
+	if err := os.WriteFile(input, []byte(source), 0o600); err != nil {
+		return "", err
+	}
+
+	for i, arg := range args {
+		arg = strings.ReplaceAll(arg, "{input}", input)
+		args[i] = strings.ReplaceAll(arg, "{output}", output)
+	}
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Dir = dir
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("%s: %w: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	data, err := os.ReadFile(output)
+	if err != nil {
+		return "", fmt.Errorf("%s wrote no SVG: %w", args[0], err)
+	}
+	// Drop the XML prolog, so the SVG can be inlined into a page.
+	start := bytes.Index(data, []byte("<svg"))
+	if start < 0 {
+		return "", fmt.Errorf("%s wrote no SVG", args[0])
+	}
+	return string(data[start:]), nil
+}
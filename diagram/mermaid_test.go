@@ -0,0 +1,41 @@
+package diagram
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestRender(t *testing.T) {
+	if _, err := exec.LookPath("cp"); err != nil {
+		t.Skip("cp not available")
+	}
+	cacheDir := t.TempDir()
+	source := `<?xml version="1.0"?><svg id="diagram"></svg>`
+	r := New("cp {input} {output}", cacheDir)
+	got, err := r.Render(context.Background(), source)
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	if got != `<svg id="diagram"></svg>` {
+		t.Errorf("expected the SVG without its prolog, got %q", got)
+	}
+
+	files, _ := filepath.Glob(filepath.Join(cacheDir, "*.svg"))
+	if len(files) != 1 {
+		t.Fatalf("expected one cached diagram, got %v", files)
+	}
+	os.WriteFile(files[0], []byte("<svg>cached</svg>"), 0o644)
+	if got, _ := New("cp {input} {output}", cacheDir).Render(context.Background(), source); got != "<svg>cached</svg>" {
+		t.Errorf("expected the diagram from the cache directory, got %q", got)
+	}
+
+	if _, err := r.Render(context.Background(), "graph TD"); err == nil {
+		t.Error("expected an error when the command writes no SVG")
+	}
+	if files, _ := filepath.Glob(filepath.Join(cacheDir, "*.svg")); len(files) != 1 {
+		t.Errorf("expected failed diagrams not cached, got %v", files)
+	}
+}
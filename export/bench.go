@@ -0,0 +1,75 @@
+package export
+
+import (
+	"fmt"
+	"net/http"
+	"runtime"
+	"sort"
+	"time"
+
+	"gomdoc/scanner"
+)
+
+// Timing is how long one page took to render in Bench.
+type Timing struct {
+	// Route is the URL path of the page.
+	Route string `json:"route"`
+	// File is the markdown file, relative to the base directory.
+	File string `json:"file"`
+	// Bytes is the size of the rendered HTML.
+	Bytes int `json:"bytes"`
+	// Min, Mean, and Max are the fastest, average, and slowest of the
+	// renders.
+	Min  time.Duration `json:"min_ns"`
+	Mean time.Duration `json:"mean_ns"`
+	Max  time.Duration `json:"max_ns"`
+	// Alloc is the memory allocated by one render, on average.
+	Alloc uint64 `json:"alloc_bytes"`
+}
+
+// Bench renders every markdown page of entries runs times through handler
+// and returns the timings, slowest first on average. The landing page is
+// requested once up front, so that the time spent building the indexes on
+// the first request is not charged to a page.
+func Bench(handler http.Handler, entries []scanner.FileEntry, runs int) ([]Timing, error) {
+	if runs < 1 {
+		return nil, fmt.Errorf("runs must be positive, got %d", runs)
+	}
+	if _, err := fetchRoute(handler, "/"); err != nil {
+		return nil, err
+	}
+
+	var timings []Timing
+	var stats runtime.MemStats
+	for _, entry := range entries {
+		if entry.Attachment {
+			continue
+		}
+		timing := Timing{Route: entry.URLPath, File: entry.RelPath}
+		var total time.Duration
+		runtime.ReadMemStats(&stats)
+		allocated := stats.TotalAlloc
+		for i := 0; i < runs; i++ {
+			start := time.Now()
+			data, err := fetchRoute(handler, entry.URLPath)
+			elapsed := time.Since(start)
+			if err != nil {
+				return nil, err
+			}
+			timing.Bytes = len(data)
+			total += elapsed
+			if i == 0 || elapsed < timing.Min {
+				timing.Min = elapsed
+			}
+			timing.Max = max(timing.Max, elapsed)
+		}
+		runtime.ReadMemStats(&stats)
+		timing.Alloc = (stats.TotalAlloc - allocated) / uint64(runs)
+		timing.Mean = total / time.Duration(runs)
+		timings = append(timings, timing)
+	}
+	sort.SliceStable(timings, func(i, j int) bool {
+		return timings[i].Mean > timings[j].Mean
+	})
+	return timings, nil
+}
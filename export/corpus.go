@@ -0,0 +1,29 @@
+package export
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// corpusRoute serves the plain-text chunks of the site.
+const corpusRoute = "/api/corpus"
+
+// Corpus writes the published pages as plain-text chunks of at most
+// chunkSize characters to w, one JSON object per line, and returns the
+// number of chunks. A chunkSize of zero uses the server's default.
+func Corpus(handler http.Handler, w io.Writer, chunkSize int) (int, error) {
+	route := corpusRoute
+	if chunkSize > 0 {
+		route = fmt.Sprintf("%s?chunk_size=%d", corpusRoute, chunkSize)
+	}
+	data, err := fetchRoute(handler, route)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := w.Write(data); err != nil {
+		return 0, err
+	}
+	return bytes.Count(data, []byte("\n")), nil
+}
@@ -0,0 +1,131 @@
+// Package export renders the documentation site to static HTML files that
+// can be hosted on any web server without gomdoc.
+package export
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gomdoc/clock"
+	"gomdoc/scanner"
+	"gomdoc/static"
+)
+
+// staticRoutes are the non-document routes every exported site needs.
+var staticRoutes = []string{"/", "/browse", "/search", "/static/style.css", "/static/search-index.json"}
+
+// Site requests every route of the site from handler and writes the
+// responses below outDir. Pages are written as <route>/index.html so the
+// server's pretty URLs keep working on static hosts; attachments keep
+// their file name. It returns the number of files written.
+func Site(handler http.Handler, entries []scanner.FileEntry, outDir string) (int, error) {
+	return render(handler, entries, func(file string, data []byte) error {
+		return writeFile(filepath.Join(outDir, filepath.FromSlash(file)), data)
+	})
+}
+
+// Zip exports the site like Site but streams it to w as a zip archive,
+// e.g. for downloading an offline copy. Entries carry the time fixed with
+// clock.Set, or none. It returns the number of files in the archive.
+func Zip(handler http.Handler, entries []scanner.FileEntry, w io.Writer) (int, error) {
+	zw := zip.NewWriter(w)
+	written, err := render(handler, entries, func(file string, data []byte) error {
+		f, err := zw.CreateHeader(&zip.FileHeader{Name: file, Method: zip.Deflate, Modified: clock.Fixed()})
+		if err != nil {
+			return err
+		}
+		_, err = f.Write(data)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	return written, zw.Close()
+}
+
+// render requests every route of the site from handler and passes the
+// responses to write with their slash separated output path. It returns
+// the number of files written.
+func render(handler http.Handler, entries []scanner.FileEntry, write func(file string, data []byte) error) (int, error) {
+	attachments := make(map[string]bool)
+	for _, entry := range entries {
+		if entry.Attachment {
+			attachments[entry.URLPath] = true
+		}
+	}
+
+	routes := Routes(entries)
+	for _, route := range routes {
+		data, err := fetchRoute(handler, route)
+		if err != nil {
+			return 0, err
+		}
+		if err := write(outputPath(route, attachments[route]), data); err != nil {
+			return 0, fmt.Errorf("export %s: %w", route, err)
+		}
+	}
+	return len(routes), nil
+}
+
+// Routes lists the routes to export: the landing page, the file index,
+// the search page, the stylesheet and search index, the assets at their
+// hashed URLs, every document and attachment, and a listing for every
+// directory with visible pages.
+func Routes(entries []scanner.FileEntry) []string {
+	seen := make(map[string]bool)
+	var routes []string
+	add := func(route string) {
+		if !seen[route] {
+			seen[route] = true
+			routes = append(routes, route)
+		}
+	}
+
+	for _, route := range staticRoutes {
+		add(route)
+	}
+	for _, route := range static.URLs() {
+		add(route)
+	}
+	var documents []string
+	for _, entry := range entries {
+		documents = append(documents, entry.URLPath)
+		if entry.Hidden || entry.Attachment {
+			continue
+		}
+		for dir := path.Dir(entry.URLPath); dir != "/"; dir = path.Dir(dir) {
+			documents = append(documents, dir)
+		}
+	}
+	sort.Strings(documents)
+	for _, route := range documents {
+		add(route)
+	}
+	return routes
+}
+
+// fetchRoute renders a single route, failing unless it answers 200 OK.
+func fetchRoute(handler http.Handler, route string) ([]byte, error) {
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, route, nil))
+	if rec.Code != http.StatusOK {
+		return nil, fmt.Errorf("export %s: status %d", route, rec.Code)
+	}
+	return rec.Body.Bytes(), nil
+}
+
+// outputPath maps a route to its file below the output directory. Static
+// assets and attachments keep their name; pages become directory indexes.
+func outputPath(route string, attachment bool) string {
+	if attachment || strings.HasPrefix(route, "/static/") {
+		return strings.TrimPrefix(route, "/")
+	}
+	return path.Join(strings.TrimPrefix(route, "/"), "index.html")
+}
@@ -0,0 +1,135 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"gomdoc/clock"
+	"gomdoc/scanner"
+)
+
+func TestRoutes(t *testing.T) {
+	entries := []scanner.FileEntry{
+		{URLPath: "/guide/advanced/tuning"},
+		{URLPath: "/archive/old", Hidden: true},
+		{URLPath: "/intro"},
+	}
+
+	got := strings.Join(Routes(entries), " ")
+	want := "/ /browse /search /static/style.css /static/search-index.json /archive/old /guide /guide/advanced /guide/advanced/tuning /intro"
+	if got != want {
+		t.Errorf("expected routes %q, got %q", want, got)
+	}
+}
+
+func TestSiteWritesPages(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("page " + r.URL.Path))
+	})
+	outDir := t.TempDir()
+
+	entries := []scanner.FileEntry{{URLPath: "/intro"}, {URLPath: "/files/manual.pdf", Attachment: true}}
+	written, err := Site(handler, entries, outDir)
+	if err != nil {
+		t.Fatalf("Site failed: %v", err)
+	}
+	if written != 7 {
+		t.Errorf("expected 7 files, got %d", written)
+	}
+	for file, want := range map[string]string{
+		"index.html":       "page /",
+		"intro/index.html": "page /intro",
+		"static/style.css": "page /static/style.css",
+		"files/manual.pdf": "page /files/manual.pdf",
+	} {
+		content, err := os.ReadFile(filepath.Join(outDir, file))
+		if err != nil || string(content) != want {
+			t.Errorf("expected %s to contain %q, got %q (%v)", file, want, content, err)
+		}
+	}
+}
+
+func TestSiteFailsOnErrorStatus(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.NotFound(w, r)
+	})
+	if _, err := Site(handler, nil, t.TempDir()); err == nil {
+		t.Error("expected error for a failing route")
+	}
+}
+
+func TestReproducibleOutput(t *testing.T) {
+	epoch := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	clock.Set(epoch)
+	t.Cleanup(func() { clock.Set(time.Time{}) })
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("page " + r.URL.Path))
+	})
+	entries := []scanner.FileEntry{{URLPath: "/intro"}, {URLPath: "/guide/setup"}}
+
+	outDir := t.TempDir()
+	if _, err := Site(handler, entries, outDir); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(filepath.Join(outDir, "intro", "index.html"))
+	if err != nil || !info.ModTime().Equal(epoch) {
+		t.Errorf("expected files dated %v, got %v (%v)", epoch, info.ModTime(), err)
+	}
+
+	var first, second bytes.Buffer
+	if _, err := Zip(handler, entries, &first); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Zip(handler, entries, &second); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(first.Bytes(), second.Bytes()) {
+		t.Errorf("expected byte-identical archives")
+	}
+	zr, err := zip.NewReader(bytes.NewReader(first.Bytes()), int64(first.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if modified := zr.File[0].Modified; !modified.Equal(epoch) {
+		t.Errorf("expected entries dated %v, got %v", epoch, modified)
+	}
+}
+
+func TestBench(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			time.Sleep(20 * time.Millisecond)
+		}
+		w.Write([]byte("page " + r.URL.Path))
+	})
+	entries := []scanner.FileEntry{
+		{URLPath: "/fast", RelPath: "fast.md"},
+		{URLPath: "/slow", RelPath: "slow.md"},
+		{URLPath: "/files/manual.pdf", Attachment: true},
+	}
+
+	timings, err := Bench(handler, entries, 2)
+	if err != nil {
+		t.Fatalf("Bench failed: %v", err)
+	}
+	if len(timings) != 2 {
+		t.Fatalf("expected timings for the two pages, got %+v", timings)
+	}
+	slow := timings[0]
+	if slow.File != "slow.md" || slow.Bytes != len("page /slow") {
+		t.Errorf("expected the slow page first, got %+v", timings)
+	}
+	if slow.Min < 20*time.Millisecond || slow.Min > slow.Mean || slow.Mean > slow.Max {
+		t.Errorf("expected min <= mean <= max of at least 20ms, got %+v", slow)
+	}
+
+	if _, err := Bench(handler, entries, 0); err == nil {
+		t.Error("expected an error for zero runs")
+	}
+}
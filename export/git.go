@@ -0,0 +1,151 @@
+package export
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// GitTarget is a branch an exported site is committed to, such as the
+// gh-pages branch GitHub Pages publishes.
+type GitTarget struct {
+	// Repo is a directory of the git repository; "" is the current one.
+	Repo string
+	// Remote is the remote the branch is pushed to, e.g. origin.
+	Remote string
+	// Branch is the branch holding the site, e.g. gh-pages.
+	Branch string
+	// Message is the commit message.
+	Message string
+}
+
+// IsGitTarget reports whether target names a git branch, as git:branch,
+// rather than a bucket.
+func IsGitTarget(target string) bool {
+	return strings.HasPrefix(target, "git:")
+}
+
+// ParseGitTarget reads git:branch, pushing to origin, or
+// git:remote:branch.
+func ParseGitTarget(target string) (GitTarget, error) {
+	spec, ok := strings.CutPrefix(target, "git:")
+	if !ok {
+		return GitTarget{}, fmt.Errorf("%s: not a git target, use git:branch or git:remote:branch", target)
+	}
+	remote, branch, found := strings.Cut(spec, ":")
+	if !found {
+		remote, branch = "origin", spec
+	}
+	if remote == "" || branch == "" {
+		return GitTarget{}, fmt.Errorf("%s: missing remote or branch, use git:branch or git:remote:branch", target)
+	}
+	return GitTarget{Remote: remote, Branch: branch, Message: "Publish documentation"}, nil
+}
+
+// GitResult tells what PublishGit did.
+type GitResult struct {
+	// Commit is the commit the branch points to.
+	Commit string
+	// Changed reports whether a new commit was made.
+	Changed bool
+}
+
+// PublishGit commits the exported site in outDir as the whole tree of the
+// target branch, on top of its last commit, and pushes the branch. The
+// working tree, index, and checked out branch of the repository are left
+// alone. Nothing is committed when the site did not change, but the branch
+// is pushed anyway, in case an earlier push failed. The manifest of
+// incremental exports is not committed, and an empty .nojekyll file is
+// added so GitHub Pages serves the files as they are.
+func PublishGit(outDir string, target GitTarget) (GitResult, error) {
+	repo := target.Repo
+	if repo == "" {
+		repo = "."
+	}
+	ref := "refs/heads/" + target.Branch
+	if _, err := git(repo, nil, "", "check-ref-format", ref); err != nil {
+		return GitResult{}, fmt.Errorf("invalid branch name %q", target.Branch)
+	}
+	if head, _ := git(repo, nil, "", "symbolic-ref", "-q", "HEAD"); head == ref {
+		return GitResult{}, fmt.Errorf("branch %s is checked out; publish to a branch of its own", target.Branch)
+	}
+	gitDir, err := git(repo, nil, "", "rev-parse", "--absolute-git-dir")
+	if err != nil {
+		return GitResult{}, err
+	}
+	workTree, err := filepath.Abs(outDir)
+	if err != nil {
+		return GitResult{}, err
+	}
+
+	// Stage the site in an index of its own.
+	tmp, err := os.MkdirTemp("", "gomdoc-publish-")
+	if err != nil {
+		return GitResult{}, err
+	}
+	defer os.RemoveAll(tmp)
+	env := []string{"GIT_DIR=" + gitDir, "GIT_WORK_TREE=" + workTree, "GIT_INDEX_FILE=" + filepath.Join(tmp, "index")}
+	if _, err := git(workTree, env, "", "add", "--all", "--force", "--", ".", ":(exclude)"+ManifestFile); err != nil {
+		return GitResult{}, err
+	}
+	if _, err := os.Stat(filepath.Join(workTree, ".nojekyll")); os.IsNotExist(err) {
+		blob, err := git(workTree, env, "", "hash-object", "-w", "--stdin")
+		if err != nil {
+			return GitResult{}, err
+		}
+		if _, err := git(workTree, env, "", "update-index", "--add", "--cacheinfo", "100644,"+blob+",.nojekyll"); err != nil {
+			return GitResult{}, err
+		}
+	}
+	tree, err := git(workTree, env, "", "write-tree")
+	if err != nil {
+		return GitResult{}, err
+	}
+
+	result := GitResult{}
+	parent, _ := git(repo, nil, "", "rev-parse", "--verify", "-q", ref)
+	if parentTree, _ := git(repo, nil, "", "rev-parse", "--verify", "-q", ref+"^{tree}"); parent != "" && parentTree == tree {
+		result.Commit = parent
+	} else {
+		args := []string{"commit-tree", tree, "-F", "-"}
+		if parent != "" {
+			args = append(args, "-p", parent)
+		}
+		if result.Commit, err = git(repo, nil, target.Message, args...); err != nil {
+			return GitResult{}, err
+		}
+		// Passing the parent fails the update if the branch moved meanwhile.
+		if _, err := git(repo, nil, "", "update-ref", ref, result.Commit, parent); err != nil {
+			return GitResult{}, err
+		}
+		result.Changed = true
+	}
+
+	if _, err := git(repo, nil, "", "push", "--quiet", target.Remote, ref+":"+ref); err != nil {
+		return result, err
+	}
+	return result, nil
+}
+
+// git runs git in dir with the extra environment variables env and stdin
+// as input, and returns its trimmed output. Errors include what git wrote
+// to standard error.
+func git(dir string, env []string, stdin string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), env...)
+	cmd.Stdin = strings.NewReader(stdin)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("git %s: %s", args[0], msg)
+		}
+		return "", fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
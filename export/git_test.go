@@ -0,0 +1,95 @@
+package export
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseGitTarget(t *testing.T) {
+	target, err := ParseGitTarget("git:gh-pages")
+	if err != nil || target.Remote != "origin" || target.Branch != "gh-pages" {
+		t.Errorf("unexpected target %+v, %v", target, err)
+	}
+	target, err = ParseGitTarget("git:upstream:pages/docs")
+	if err != nil || target.Remote != "upstream" || target.Branch != "pages/docs" {
+		t.Errorf("unexpected target %+v, %v", target, err)
+	}
+	for _, bad := range []string{"gh-pages", "git:", "git::gh-pages", "git:origin:"} {
+		if _, err := ParseGitTarget(bad); err == nil {
+			t.Errorf("expected error for %q", bad)
+		}
+	}
+}
+
+func TestPublishGit(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	dir := t.TempDir()
+	remote := filepath.Join(dir, "remote.git")
+	repo := filepath.Join(dir, "repo")
+	outDir := filepath.Join(dir, "site")
+	run := func(dir string, args ...string) string {
+		t.Helper()
+		out, err := git(dir, nil, "", args...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return out
+	}
+	run(dir, "init", "--quiet", "--bare", remote)
+	run(dir, "init", "--quiet", repo)
+	run(repo, "config", "user.name", "Docs")
+	run(repo, "config", "user.email", "docs@example.com")
+	run(repo, "remote", "add", "origin", remote)
+
+	write := func(name, content string) {
+		file := filepath.Join(outDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("index.html", "<h1>Home</h1>")
+	write("guide/index.html", "<h1>Guide</h1>")
+	write(ManifestFile, "{}")
+
+	target := GitTarget{Repo: repo, Remote: "origin", Branch: "gh-pages", Message: "Publish documentation"}
+	first, err := PublishGit(outDir, target)
+	if err != nil {
+		t.Fatalf("PublishGit failed: %v", err)
+	}
+	if !first.Changed {
+		t.Error("expected a commit for the first publish")
+	}
+	files := run(remote, "ls-tree", "-r", "--name-only", "gh-pages")
+	if files != ".nojekyll\nguide/index.html\nindex.html" {
+		t.Errorf("unexpected published files %q", files)
+	}
+	if status := run(repo, "status", "--porcelain"); status != "" {
+		t.Errorf("expected the work tree left alone, got %q", status)
+	}
+
+	if again, err := PublishGit(outDir, target); err != nil || again.Changed || again.Commit != first.Commit {
+		t.Errorf("expected no commit for an unchanged site, got %+v, %v", again, err)
+	}
+
+	if err := os.RemoveAll(filepath.Join(outDir, "guide")); err != nil {
+		t.Fatal(err)
+	}
+	second, err := PublishGit(outDir, target)
+	if err != nil || !second.Changed {
+		t.Fatalf("expected a commit for the removed page, got %+v, %v", second, err)
+	}
+	if parent := run(remote, "rev-parse", "gh-pages^"); parent != first.Commit {
+		t.Errorf("expected the new commit on top of the last one, got parent %s", parent)
+	}
+	if files := run(remote, "ls-tree", "-r", "--name-only", "gh-pages"); strings.Contains(files, "guide") {
+		t.Errorf("expected the removed page gone, got %q", files)
+	}
+}
@@ -0,0 +1,251 @@
+package export
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"gomdoc/clock"
+	"gomdoc/scanner"
+)
+
+// ManifestFile is the file below the output directory in which Update
+// records the source hash of every exported route.
+const ManifestFile = ".gomdoc-export.json"
+
+// sitewideRoutes are rendered from every page, so any change to a page
+// renders them again.
+var sitewideRoutes = map[string]bool{"/": true, "/static/search-index.json": true}
+
+// layoutRoute is the route whose output captures the navigation tree shown
+// on every page. When it changes, every page is rendered again.
+const layoutRoute = "/browse"
+
+// Incremental holds what Update needs besides the site itself.
+type Incremental struct {
+	// Files is the documentation tree the entries were scanned from. Their
+	// contents are hashed to find the pages that changed.
+	Files fs.FS
+	// Fingerprint identifies everything outside Files that shapes the
+	// output, such as the gomdoc version and the export options. A new
+	// fingerprint renders the whole site again.
+	Fingerprint string
+}
+
+// Result counts the files touched by an incremental export.
+type Result struct {
+	// Written is the number of files rendered and written.
+	Written int
+	// Skipped is the number of files left as they were.
+	Skipped int
+	// Removed is the number of files deleted because their route is gone.
+	Removed int
+}
+
+// manifest is the content of ManifestFile.
+type manifest struct {
+	// Routes maps each exported route to its output file and source hash.
+	Routes map[string]manifestRoute `json:"routes"`
+}
+
+// manifestRoute records one exported route.
+type manifestRoute struct {
+	File string `json:"file"`
+	Hash string `json:"hash"`
+}
+
+// Update exports the site to outDir like Site, but only renders the
+// routes whose sources changed since the last Update into the same
+// directory, along with the pages that depend on them: the listings of
+// their directories and the landing page. Changes to the navigation tree,
+// to files that are not pages or attachments, or to the fingerprint render
+// everything again. Files of routes that no longer exist are removed.
+func Update(handler http.Handler, entries []scanner.FileEntry, outDir string, inc Incremental) (Result, error) {
+	var result Result
+	previous := readManifest(outDir)
+
+	layout, err := fetchRoute(handler, layoutRoute)
+	if err != nil {
+		return result, err
+	}
+	sources, shared, err := hashSources(inc.Files, entries)
+	if err != nil {
+		return result, err
+	}
+	site := hashStrings(inc.Fingerprint, hashBytes(layout), shared)
+
+	attachments := make(map[string]bool)
+	for _, entry := range entries {
+		if entry.Attachment {
+			attachments[entry.URLPath] = true
+		}
+	}
+
+	current := manifest{Routes: make(map[string]manifestRoute)}
+	for _, route := range Routes(entries) {
+		file := outputPath(route, attachments[route])
+		hash := hashStrings(site, routeHash(route, entries, sources))
+		current.Routes[route] = manifestRoute{File: file, Hash: hash}
+
+		target := filepath.Join(outDir, filepath.FromSlash(file))
+		if old, found := previous.Routes[route]; found && old.Hash == hash && old.File == file {
+			if _, err := os.Stat(target); err == nil {
+				result.Skipped++
+				continue
+			}
+		}
+
+		data := layout
+		if route != layoutRoute {
+			if data, err = fetchRoute(handler, route); err != nil {
+				return result, err
+			}
+		}
+		if err := writeFile(target, data); err != nil {
+			return result, fmt.Errorf("export %s: %w", route, err)
+		}
+		result.Written++
+	}
+
+	for route, old := range previous.Routes {
+		if _, found := current.Routes[route]; found || !filepath.IsLocal(filepath.FromSlash(old.File)) {
+			continue
+		}
+		err := os.Remove(filepath.Join(outDir, filepath.FromSlash(old.File)))
+		if err == nil {
+			result.Removed++
+		} else if !errors.Is(err, fs.ErrNotExist) {
+			return result, err
+		}
+	}
+
+	data, err := json.MarshalIndent(current, "", "  ")
+	if err != nil {
+		return result, err
+	}
+	return result, writeFile(filepath.Join(outDir, ManifestFile), data)
+}
+
+// hashSources hashes the file of every entry, keyed by route, and returns
+// a combined hash of all other files in fsys, such as bibliographies and
+// folder settings, which pages may depend on in ways the export cannot
+// tell.
+func hashSources(fsys fs.FS, entries []scanner.FileEntry) (map[string]string, string, error) {
+	sources := make(map[string]string)
+	owned := make(map[string]bool)
+	for _, entry := range entries {
+		if entry.RelPath == "" {
+			continue
+		}
+		name := filepath.ToSlash(entry.RelPath)
+		data, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return nil, "", err
+		}
+		sources[entry.URLPath] = hashBytes(data)
+		owned[name] = true
+	}
+
+	var others []string
+	err := fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			// Skip hidden directories and earlier exports kept next to the
+			// sources, such as the default ./site.
+			if name != "." && strings.HasPrefix(d.Name(), ".") {
+				return fs.SkipDir
+			}
+			if _, err := fs.Stat(fsys, path.Join(name, ManifestFile)); err == nil {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if owned[name] || strings.HasPrefix(d.Name(), ".") {
+			return nil
+		}
+		data, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return err
+		}
+		others = append(others, name, hashBytes(data))
+		return nil
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	return sources, hashStrings(others...), nil
+}
+
+// routeHash returns the hash of the sources a route is rendered from: its
+// own file for documents and attachments, the pages below it for
+// directory listings, and every page for the landing page and the search
+// index.
+func routeHash(route string, entries []scanner.FileEntry, sources map[string]string) string {
+	if hash, found := sources[route]; found {
+		return hash
+	}
+	prefix := route + "/"
+	switch {
+	case sitewideRoutes[route]:
+		prefix = "/"
+	case route == layoutRoute || route == "/search" || strings.HasPrefix(route, "/static/"):
+		return ""
+	}
+	var hashes []string
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.URLPath, prefix) {
+			hashes = append(hashes, entry.URLPath, sources[entry.URLPath])
+		}
+	}
+	return hashStrings(hashes...)
+}
+
+// readManifest loads the manifest of the previous export to outDir. A
+// missing or unreadable manifest is empty, so everything is rendered.
+func readManifest(outDir string) manifest {
+	var m manifest
+	if data, err := os.ReadFile(filepath.Join(outDir, ManifestFile)); err == nil {
+		json.Unmarshal(data, &m)
+	}
+	return m
+}
+
+// writeFile writes data to target, creating its directory.
+func writeFile(target string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(target, data, 0o644); err != nil {
+		return err
+	}
+	if fixed := clock.Fixed(); !fixed.IsZero() {
+		return os.Chtimes(target, fixed, fixed)
+	}
+	return nil
+}
+
+// hashBytes returns the hex-encoded SHA-256 of data.
+func hashBytes(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// hashStrings returns the hex-encoded SHA-256 of values, separated so
+// that different splits of the same text hash differently.
+func hashStrings(values ...string) string {
+	h := sha256.New()
+	for _, value := range values {
+		fmt.Fprintf(h, "%d:%s;", len(value), value)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
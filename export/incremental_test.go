@@ -0,0 +1,78 @@
+package export
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"testing/fstest"
+
+	"gomdoc/scanner"
+)
+
+func TestUpdateRendersChangedPages(t *testing.T) {
+	var rendered []string
+	tree := "tree"
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rendered = append(rendered, r.URL.Path)
+		if r.URL.Path == "/browse" {
+			w.Write([]byte(tree))
+			return
+		}
+		w.Write([]byte("page " + r.URL.Path))
+	})
+	files := fstest.MapFS{
+		"intro.md":        {Data: []byte("# Intro")},
+		"guide/setup.md":  {Data: []byte("# Setup")},
+		"guide/deploy.md": {Data: []byte("# Deploy")},
+	}
+	entries := []scanner.FileEntry{
+		{URLPath: "/intro", RelPath: "intro.md"},
+		{URLPath: "/guide/setup", RelPath: "guide/setup.md"},
+		{URLPath: "/guide/deploy", RelPath: "guide/deploy.md"},
+	}
+	outDir := t.TempDir()
+	inc := Incremental{Files: files, Fingerprint: "v1"}
+
+	update := func() Result {
+		t.Helper()
+		rendered = nil
+		result, err := Update(handler, entries, outDir, inc)
+		if err != nil {
+			t.Fatalf("Update failed: %v", err)
+		}
+		return result
+	}
+
+	if result := update(); result.Written != 9 || result.Skipped != 0 {
+		t.Errorf("expected a full first export, got %+v", result)
+	}
+	if result := update(); result.Written != 0 || result.Skipped != 9 {
+		t.Errorf("expected nothing rendered without changes, got %+v", result)
+	}
+
+	files["guide/setup.md"] = &fstest.MapFile{Data: []byte("# Setup, revised")}
+	update()
+	want := []string{"/browse", "/", "/static/search-index.json", "/guide", "/guide/setup"}
+	if !slices.Equal(rendered, want) {
+		t.Errorf("expected %v rendered after an edit, got %v", want, rendered)
+	}
+
+	tree = "new tree"
+	if result := update(); result.Written != 9 {
+		t.Errorf("expected a full export after the tree changed, got %+v", result)
+	}
+	inc.Fingerprint = "v2"
+	if result := update(); result.Written != 9 {
+		t.Errorf("expected a full export after the fingerprint changed, got %+v", result)
+	}
+
+	entries = entries[:2]
+	if result := update(); result.Removed != 1 {
+		t.Errorf("expected the deleted page removed, got %+v", result)
+	}
+	if _, err := os.Stat(filepath.Join(outDir, "guide", "deploy", "index.html")); !os.IsNotExist(err) {
+		t.Errorf("expected guide/deploy/index.html removed, got %v", err)
+	}
+}
@@ -0,0 +1,109 @@
+package export
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"maps"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"slices"
+
+	"gomdoc/static"
+)
+
+// Store is object storage an exported site is published to, such as a
+// *source.Bucket. Names are slash separated paths below its prefix.
+type Store interface {
+	// Refresh lists the stored objects again.
+	Refresh(ctx context.Context) error
+	// ETags returns the hex-encoded MD5 of every stored object by name.
+	ETags() map[string]string
+	// Put uploads an object with its Content-Type and Cache-Control.
+	Put(ctx context.Context, name string, data []byte, contentType, cacheControl string) error
+	// Delete removes an object.
+	Delete(ctx context.Context, name string) error
+}
+
+// PublishResult counts what Publish did.
+type PublishResult struct {
+	// Uploaded is the number of new or changed files uploaded.
+	Uploaded int
+	// Unchanged is the number of files the store already had.
+	Unchanged int
+	// Deleted is the number of objects removed because their file is gone.
+	Deleted int
+}
+
+// Publish copies the exported site in outDir to store. Files the store
+// already has with the same content are skipped, and stored objects
+// without a file in outDir, such as deleted pages, are removed, so the
+// store mirrors outDir. The manifest of incremental exports is not
+// published.
+func Publish(ctx context.Context, outDir string, store Store) (PublishResult, error) {
+	var result PublishResult
+	if err := store.Refresh(ctx); err != nil {
+		return result, fmt.Errorf("list target: %w", err)
+	}
+	stored := store.ETags()
+
+	fsys := os.DirFS(outDir)
+	var names []string
+	local := make(map[string]bool)
+	err := fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || name == ManifestFile {
+			return err
+		}
+		names = append(names, name)
+		local[name] = true
+		return nil
+	})
+	if err != nil {
+		return result, err
+	}
+
+	for _, name := range names {
+		data, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return result, err
+		}
+		sum := md5.Sum(data)
+		if stored[name] == hex.EncodeToString(sum[:]) {
+			result.Unchanged++
+			continue
+		}
+		if err := store.Put(ctx, name, data, contentType(name, data), static.CacheControl("/"+name)); err != nil {
+			return result, fmt.Errorf("upload %s: %w", name, err)
+		}
+		result.Uploaded++
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(stored)) {
+		if local[name] {
+			continue
+		}
+		if err := store.Delete(ctx, name); err != nil {
+			return result, fmt.Errorf("delete %s: %w", name, err)
+		}
+		result.Deleted++
+	}
+	return result, nil
+}
+
+// contentType returns the media type of an exported file: that of the
+// asset for static files, or else by extension or, failing that, content.
+func contentType(name string, data []byte) string {
+	if asset, _, ok := static.Lookup("/" + name); ok {
+		return asset.ContentType
+	}
+	if ext := path.Ext(name); ext == ".html" {
+		return "text/html; charset=utf-8"
+	} else if t := mime.TypeByExtension(ext); t != "" {
+		return t
+	}
+	return http.DetectContentType(data)
+}
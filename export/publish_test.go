@@ -0,0 +1,93 @@
+package export
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// memoryStore is a Store in memory, recording uploads.
+type memoryStore struct {
+	objects map[string][]byte
+	types   map[string]string
+	caches  map[string]string
+}
+
+func (m *memoryStore) Refresh(ctx context.Context) error { return nil }
+
+func (m *memoryStore) ETags() map[string]string {
+	etags := make(map[string]string)
+	for name, data := range m.objects {
+		sum := md5.Sum(data)
+		etags[name] = hex.EncodeToString(sum[:])
+	}
+	return etags
+}
+
+func (m *memoryStore) Put(ctx context.Context, name string, data []byte, contentType, cacheControl string) error {
+	m.objects[name] = data
+	m.types[name] = contentType
+	m.caches[name] = cacheControl
+	return nil
+}
+
+func (m *memoryStore) Delete(ctx context.Context, name string) error {
+	delete(m.objects, name)
+	return nil
+}
+
+func TestPublish(t *testing.T) {
+	outDir := t.TempDir()
+	files := map[string]string{
+		"index.html":               "<h1>Home</h1>",
+		"guide/index.html":         "<h1>Guide</h1>",
+		"static/search-index.json": "[]",
+		"guide/diagram.svg":        "<svg/>",
+		ManifestFile:               "{}",
+	}
+	for name, content := range files {
+		file := filepath.Join(outDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	store := &memoryStore{
+		objects: map[string][]byte{"index.html": []byte("<h1>Home</h1>"), "removed/index.html": []byte("gone")},
+		types:   map[string]string{},
+		caches:  map[string]string{},
+	}
+	result, err := Publish(context.Background(), outDir, store)
+	if err != nil {
+		t.Fatalf("Publish failed: %v", err)
+	}
+	if result != (PublishResult{Uploaded: 3, Unchanged: 1, Deleted: 1}) {
+		t.Errorf("unexpected result %+v", result)
+	}
+	if _, ok := store.objects[ManifestFile]; ok {
+		t.Error("expected the manifest not published")
+	}
+	if _, ok := store.objects["removed/index.html"]; ok {
+		t.Error("expected the removed page deleted")
+	}
+	if got := store.types["guide/index.html"]; got != "text/html; charset=utf-8" {
+		t.Errorf("expected HTML content type, got %q", got)
+	}
+	if got := store.types["guide/diagram.svg"]; got != "image/svg+xml" {
+		t.Errorf("expected SVG content type, got %q", got)
+	}
+	if got := store.caches["guide/index.html"]; got != "no-cache" {
+		t.Errorf("expected pages revalidated, got %q", got)
+	}
+
+	result, err = Publish(context.Background(), outDir, store)
+	if err != nil || result != (PublishResult{Unchanged: 4}) {
+		t.Errorf("expected nothing to do on the second run, got %+v, %v", result, err)
+	}
+}
@@ -0,0 +1,128 @@
+package export
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"io/fs"
+	"net/http"
+	"path"
+	"time"
+
+	"gomdoc/clock"
+)
+
+// Files in a snapshot archive besides the source tree.
+const (
+	// SnapshotManifest lists every file of the snapshot with its hash.
+	SnapshotManifest = "manifest.json"
+	// SnapshotSitemap is the sitemap of the rendered site.
+	SnapshotSitemap = "sitemap.xml"
+	// snapshotSource is the directory holding the source tree.
+	snapshotSource = "source"
+)
+
+// sitemapRoute serves the sitemap of the site.
+const sitemapRoute = "/sitemap.xml"
+
+// Snapshot holds what WriteSnapshot needs besides the site itself.
+type Snapshot struct {
+	// Files is the documentation tree to archive.
+	Files fs.FS
+	// Taken is the point in time the snapshot records.
+	Taken time.Time
+	// Version is the gomdoc version that took the snapshot.
+	Version string
+}
+
+// snapshotManifest is the content of SnapshotManifest.
+type snapshotManifest struct {
+	Taken   string                 `json:"taken"`
+	Version string                 `json:"version,omitempty"`
+	Files   []snapshotManifestFile `json:"files"`
+}
+
+// snapshotManifestFile records one file of a snapshot.
+type snapshotManifestFile struct {
+	Path   string `json:"path"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// WriteSnapshot writes a point-in-time snapshot of the documentation to w
+// as a gzipped tarball: every file of the source tree below source/, the
+// sitemap rendered by handler, and a manifest with the SHA-256 hash of
+// each, which is written last. Version control directories are left out.
+// It returns the number of source files archived.
+func WriteSnapshot(handler http.Handler, snap Snapshot, w io.Writer) (int, error) {
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	manifest := snapshotManifest{Taken: snap.Taken.UTC().Format(time.RFC3339), Version: snap.Version}
+	add := func(name string, data []byte, modTime time.Time) error {
+		sum := sha256.Sum256(data)
+		manifest.Files = append(manifest.Files, snapshotManifestFile{Path: name, Size: int64(len(data)), SHA256: hex.EncodeToString(sum[:])})
+		return writeTarFile(tw, name, data, modTime)
+	}
+
+	files := 0
+	err := fs.WalkDir(snap.Files, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if d.Name() == ".git" || d.Name() == ".hg" || d.Name() == ".svn" {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		data, err := fs.ReadFile(snap.Files, name)
+		if err != nil {
+			return err
+		}
+		modTime := snap.Taken
+		if info, err := d.Info(); err == nil {
+			modTime = clock.Clamp(info.ModTime())
+		}
+		files++
+		return add(path.Join(snapshotSource, name), data, modTime)
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	sitemap, err := fetchRoute(handler, sitemapRoute)
+	if err != nil {
+		return 0, err
+	}
+	if err := add(SnapshotSitemap, sitemap, snap.Taken); err != nil {
+		return 0, err
+	}
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return 0, err
+	}
+	if err := writeTarFile(tw, SnapshotManifest, append(data, '\n'), snap.Taken); err != nil {
+		return 0, err
+	}
+	if err := tw.Close(); err != nil {
+		return 0, err
+	}
+	return files, gz.Close()
+}
+
+// writeTarFile adds a regular file to tw.
+func writeTarFile(tw *tar.Writer, name string, data []byte, modTime time.Time) error {
+	header := &tar.Header{Name: name, Mode: 0o644, Size: int64(len(data)), ModTime: modTime, Typeflag: tar.TypeReg}
+	if err := tw.WriteHeader(header); err != nil {
+		return err
+	}
+	_, err := tw.Write(data)
+	return err
+}
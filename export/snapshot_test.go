@@ -0,0 +1,80 @@
+package export
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestWriteSnapshot(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("<urlset>" + r.URL.Path + "</urlset>"))
+	})
+	files := fstest.MapFS{
+		"intro.md":         {Data: []byte("# Intro\n")},
+		"img/logo.png":     {Data: []byte("png")},
+		".git/HEAD":        {Data: []byte("ref: refs/heads/main\n")},
+		"guide/_order.yml": {Data: []byte("- setup.md\n")},
+	}
+	taken := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	var buf bytes.Buffer
+	count, err := WriteSnapshot(handler, Snapshot{Files: files, Taken: taken, Version: "v1"}, &buf)
+	if err != nil {
+		t.Fatalf("WriteSnapshot failed: %v", err)
+	}
+	if count != 3 {
+		t.Errorf("expected 3 source files, got %d", count)
+	}
+
+	gz, err := gzip.NewReader(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	contents := make(map[string][]byte)
+	var names []string
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, _ := io.ReadAll(tr)
+		contents[header.Name] = data
+		names = append(names, header.Name)
+	}
+	if len(names) != 5 || names[len(names)-1] != SnapshotManifest {
+		t.Fatalf("expected three sources, the sitemap, and the manifest last, got %v", names)
+	}
+	if string(contents[SnapshotSitemap]) != "<urlset>/sitemap.xml</urlset>" {
+		t.Errorf("unexpected sitemap %q", contents[SnapshotSitemap])
+	}
+
+	var manifest snapshotManifest
+	if err := json.Unmarshal(contents[SnapshotManifest], &manifest); err != nil {
+		t.Fatal(err)
+	}
+	if manifest.Taken != "2024-05-01T12:00:00Z" || manifest.Version != "v1" || len(manifest.Files) != 4 {
+		t.Fatalf("unexpected manifest %+v", manifest)
+	}
+	for _, file := range manifest.Files {
+		sum := sha256.Sum256(contents[file.Path])
+		if file.SHA256 != hex.EncodeToString(sum[:]) || file.Size != int64(len(contents[file.Path])) {
+			t.Errorf("manifest entry %+v does not match the archive", file)
+		}
+	}
+	if _, found := contents["source/intro.md"]; !found {
+		t.Errorf("expected the sources below source/, got %v", names)
+	}
+}
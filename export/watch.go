@@ -0,0 +1,69 @@
+package export
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"time"
+)
+
+// Watch calls run whenever the files in fsys change, until ctx ends. It
+// checks the names, sizes, and modification times of the files every
+// interval, and waits for a check without further changes before calling
+// run, so that a burst of saves exports once. Files and directories for
+// which skip reports true, such as the output directory, are not watched.
+// Errors of run are passed to failed and watching goes on.
+func Watch(ctx context.Context, fsys fs.FS, interval time.Duration, skip func(name string) bool, run func() error, failed func(error)) {
+	last := treeState(fsys, skip)
+	pending := false
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if state := treeState(fsys, skip); state != last {
+			last = state
+			pending = true
+			continue
+		}
+		if !pending {
+			continue
+		}
+		pending = false
+		if err := run(); err != nil {
+			failed(err)
+		}
+	}
+}
+
+// treeState hashes the names, sizes, and modification times of the files
+// in fsys. Files that disappear while it walks are left out.
+func treeState(fsys fs.FS, skip func(name string) bool) string {
+	h := sha256.New()
+	fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || name == "." {
+			return nil
+		}
+		if skip != nil && skip(name) {
+			if d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		fmt.Fprintf(h, "%s\x00%d\x00%d\n", name, info.Size(), info.ModTime().UnixNano())
+		return nil
+	})
+	return hex.EncodeToString(h.Sum(nil))
+}
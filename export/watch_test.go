@@ -0,0 +1,61 @@
+package export
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWatch(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "intro.md"), []byte("# Intro"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(filepath.Join(dir, ".git"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	runs := make(chan struct{}, 10)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		skip := func(name string) bool { return strings.HasPrefix(name, ".") }
+		Watch(ctx, os.DirFS(dir), 10*time.Millisecond, skip, func() error {
+			runs <- struct{}{}
+			return nil
+		}, func(err error) { t.Error(err) })
+	}()
+
+	// Changes to skipped files do not export.
+	if err := os.WriteFile(filepath.Join(dir, ".git", "HEAD"), []byte("ref"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-runs:
+		t.Fatal("expected no export for a skipped file")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "guide.md"), []byte("# Guide"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-runs:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected an export after adding a page")
+	}
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected Watch to return when the context ends")
+	}
+	if len(runs) != 0 {
+		t.Errorf("expected one export per change, got %d more", len(runs))
+	}
+}
@@ -0,0 +1,273 @@
+package importer
+
+import (
+	"encoding/json"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gomdoc/scanner"
+)
+
+// docusaurusDroppedKeys are Docusaurus frontmatter keys gomdoc has no use
+// for.
+var docusaurusDroppedKeys = []string{
+	"id", "slug", "sidebar_position", "sidebar_label", "sidebar_class_name",
+	"sidebar_custom_props", "displayed_sidebar", "pagination_label",
+	"pagination_next", "pagination_prev", "hide_title", "hide_table_of_contents",
+	"toc_min_heading_level", "toc_max_heading_level", "custom_edit_url",
+	"parse_number_prefixes", "last_update", "unlisted", "draft", "image", "keywords",
+}
+
+// docusaurusAdmonition matches the opening line of a Docusaurus
+// admonition, such as :::tip or :::warning[Mind the gap] or :::note Title.
+var docusaurusAdmonition = regexp.MustCompile(`^(\s*)(:{3,})([\w-]+)(?:\[(.*)\]|[ \t]+(.*?))?\s*$`)
+
+// sidebarString matches the quoted strings of a sidebars file, among
+// which are the IDs of the listed docs.
+var sidebarString = regexp.MustCompile(`["'\x60]([\w./-]+)["'\x60]`)
+
+// sidebarAutogenerated matches the directory of an autogenerated sidebar
+// item.
+var sidebarAutogenerated = regexp.MustCompile(`dirName:\s*["'\x60]([^"'\x60]+)["'\x60]`)
+
+// mdxStatement matches the import and export lines of MDX pages.
+var mdxStatement = regexp.MustCompile(`^(import|export)\s`)
+
+// mdxComponent matches a line starting with a JSX component, such as
+// <Tabs>.
+var mdxComponent = regexp.MustCompile(`^\s*</?[A-Z]\w*`)
+
+// docusaurusPage is what loadDocusaurus learns about a page up front.
+type docusaurusPage struct {
+	id       string
+	position float64
+	ordered  bool
+	draft    bool
+}
+
+// loadDocusaurus reads the Docusaurus site in dir.
+func loadDocusaurus(dir string, result *Result) (project, error) {
+	p := project{
+		docs:  filepath.Join(dir, "docs"),
+		order: make(map[string][]string),
+		skip:  make(map[string]bool),
+	}
+	if info, err := os.Stat(filepath.Join(dir, "static")); err == nil && info.IsDir() {
+		p.static = filepath.Join(dir, "static")
+	}
+
+	docs := os.DirFS(p.docs)
+	pages := make(map[string]docusaurusPage)
+	ids := make(map[string]string)
+	// positions holds the sidebar position of pages and categories, keyed
+	// by their path.
+	positions := make(map[string]float64)
+	err := fs.WalkDir(docs, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if position, ok := categoryPosition(docs, name); ok {
+				positions[name] = position
+			}
+			return nil
+		}
+		if !isPage(name) {
+			return nil
+		}
+		data, err := fs.ReadFile(docs, name)
+		if err != nil {
+			return err
+		}
+		fm, _ := splitFrontmatter(string(data))
+		page := docusaurusPage{id: docID(name, fm.get("id")), draft: fm.get("draft") == "true"}
+		if value := fm.get("sidebar_position"); value != "" {
+			if position, err := strconv.ParseFloat(value, 64); err == nil {
+				positions[name] = position
+			}
+		}
+		if page.draft {
+			p.skip[name] = true
+			result.warn(name, "draft left out")
+		}
+		pages[name] = page
+		ids[page.id] = name
+		return nil
+	})
+	if err != nil {
+		return project{}, err
+	}
+
+	// Docs listed in a sidebars file keep their order there. Without one,
+	// Docusaurus generates the sidebar from all docs.
+	listed := func(name string) bool { return true }
+	for _, sidebars := range []string{"sidebars.js", "sidebars.ts", "sidebars.json"} {
+		data, err := os.ReadFile(filepath.Join(dir, sidebars))
+		if err != nil {
+			continue
+		}
+		explicit := make(map[string]bool)
+		for _, match := range sidebarString.FindAllStringSubmatch(string(data), -1) {
+			if name, found := ids[match[1]]; found && !p.skip[name] {
+				addPage(p.order, name)
+				explicit[name] = true
+			}
+		}
+		var generated []string
+		for _, match := range sidebarAutogenerated.FindAllStringSubmatch(string(data), -1) {
+			generated = append(generated, path.Clean(match[1]))
+		}
+		listed = func(name string) bool {
+			for _, dir := range generated {
+				if dir == "." || strings.HasPrefix(name, dir+"/") {
+					return true
+				}
+			}
+			return explicit[name]
+		}
+		break
+	}
+	// The rest follows the positions of autogenerated sidebars.
+	addPositions(docs, p.order, positions, p.skip)
+
+	p.convert = func(name, content string, result *Result) string {
+		fm, body := splitFrontmatter(content)
+		if fm.get("unlisted") == "true" || !listed(name) {
+			fm.set("nav", "false")
+		}
+		if fm.get("title") == "" {
+			if title := firstHeading(body); title != "" {
+				fm.set("title", title)
+			} else if label := fm.get("sidebar_label"); label != "" {
+				fm.set("title", label)
+			}
+		}
+		fm.remove(docusaurusDroppedKeys...)
+		return fm.join(convertDocusaurusBody(name, body, result))
+	}
+	return p, nil
+}
+
+// docID returns the ID Docusaurus gives the page at name: the id
+// frontmatter or the file name, below its directory, with number prefixes
+// removed.
+func docID(name, id string) string {
+	dir, file := path.Split(name)
+	if id == "" {
+		id = scanner.StripNumericPrefix(strings.TrimSuffix(file, path.Ext(file)))
+	}
+	if dir == "" {
+		return id
+	}
+	return scanner.StripNumericPrefixes(strings.TrimSuffix(dir, "/")) + "/" + id
+}
+
+// categoryPosition reads the position of a directory from its
+// _category_.json or _category_.yml.
+func categoryPosition(docs fs.FS, dir string) (float64, bool) {
+	if data, err := fs.ReadFile(docs, path.Join(dir, "_category_.json")); err == nil {
+		var category struct {
+			Position *float64 `json:"position"`
+		}
+		if json.Unmarshal(data, &category) == nil && category.Position != nil {
+			return *category.Position, true
+		}
+		return 0, false
+	}
+	for _, name := range []string{"_category_.yml", "_category_.yaml"} {
+		if data, err := fs.ReadFile(docs, path.Join(dir, name)); err == nil {
+			position, err := strconv.ParseFloat(topLevelValue(string(data), "position"), 64)
+			return position, err == nil
+		}
+	}
+	return 0, false
+}
+
+// addPositions orders the entries of each directory that are not ordered
+// yet, after those that are, when the directory holds entries with a
+// sidebar position or is partly ordered: positioned entries first, by
+// position, then the others by name, as autogenerated sidebars do.
+func addPositions(docs fs.FS, order map[string][]string, positions map[string]float64, skip map[string]bool) {
+	fs.WalkDir(docs, ".", func(dir string, d fs.DirEntry, err error) error {
+		if err != nil || !d.IsDir() {
+			return err
+		}
+		entries, err := fs.ReadDir(docs, dir)
+		if err != nil {
+			return err
+		}
+		var names []string
+		_, positioned := order[dir]
+		for _, entry := range entries {
+			name := path.Join(dir, entry.Name())
+			if skip[name] || (!entry.IsDir() && !isPage(name)) || contains(order[dir], entry.Name()) {
+				continue
+			}
+			names = append(names, entry.Name())
+			_, found := positions[name]
+			positioned = positioned || found
+		}
+		if !positioned {
+			return nil
+		}
+		sort.SliceStable(names, func(i, j int) bool {
+			a, aFound := positions[path.Join(dir, names[i])]
+			b, bFound := positions[path.Join(dir, names[j])]
+			if aFound != bFound {
+				return aFound
+			}
+			return aFound && a < b
+		})
+		order[dir] = append(order[dir], names...)
+		return nil
+	})
+}
+
+// convertDocusaurusBody turns admonitions into GitHub-style alerts and
+// drops MDX import and export statements, warning about JSX components,
+// which have no markdown equivalent.
+func convertDocusaurusBody(name, body string, result *Result) string {
+	lines := strings.Split(body, "\n")
+	var out []string
+	var f fence
+	warned := false
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		if f.inside(line) {
+			out = append(out, line)
+			continue
+		}
+		if mdxStatement.MatchString(line) {
+			continue
+		}
+		if mdxComponent.MatchString(line) && !warned {
+			result.warn(name, "MDX component on %q needs manual conversion", strings.TrimSpace(line))
+			warned = true
+		}
+		match := docusaurusAdmonition.FindStringSubmatch(line)
+		if match == nil {
+			out = append(out, line)
+			continue
+		}
+		closing := regexp.MustCompile(`^\s*` + match[2] + `\s*$`)
+		var content []string
+		for i+1 < len(lines) && !closing.MatchString(lines[i+1]) {
+			content = append(content, strings.TrimPrefix(lines[i+1], match[1]))
+			i++
+		}
+		i++ // the closing line
+		title := match[4]
+		if title == "" {
+			title = match[5]
+		}
+		content = strings.Split(convertDocusaurusBody(name, strings.Join(content, "\n"), result), "\n")
+		out = append(out, alert(match[1], match[3], title, content)...)
+	}
+	return strings.Join(out, "\n")
+}
@@ -0,0 +1,547 @@
+package importer
+
+import (
+	"bytes"
+	"io/fs"
+	"os"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+	"golang.org/x/net/html/charset"
+)
+
+// markdownEscaper escapes the characters of HTML text that markdown would
+// read as syntax.
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`, "*", `\*`, "_", `\_`, "`", "\\`", "[", `\[`, "]", `\]`, "<", `\<`,
+)
+
+// blockStart matches the start of a line that markdown would read as a
+// heading, list item, quote, or rule.
+var blockStart = regexp.MustCompile(`^(#|>|[-+] |-{3,}$|={3,}$)`)
+
+// orderedStart matches the start of a line that markdown would read as an
+// ordered list item.
+var orderedStart = regexp.MustCompile(`^(\d+)([.)] )`)
+
+// isHTML reports whether name is an HTML page.
+func isHTML(name string) bool {
+	ext := strings.ToLower(path.Ext(name))
+	return ext == ".html" || ext == ".htm"
+}
+
+// loadHTML reads the tree of legacy pages in dir. Pages with a markdown
+// file of the same name next to them were converted before and are
+// copied as they are.
+func loadHTML(dir string, result *Result) project {
+	docs := os.DirFS(dir)
+	return project{
+		docs: dir,
+		page: func(name string) bool {
+			if !isHTML(name) {
+				return false
+			}
+			if _, err := fs.Stat(docs, strings.TrimSuffix(name, path.Ext(name))+".md"); err == nil {
+				result.warn(name, "not converted, a markdown page of the same name exists")
+				return false
+			}
+			return true
+		},
+		convert: func(name, content string, result *Result) string {
+			return convertHTML(name, []byte(content), result)
+		},
+	}
+}
+
+// htmlConverter converts one HTML page to markdown, recording what it
+// could not convert.
+type htmlConverter struct {
+	name   string
+	result *Result
+	warned map[string]bool
+}
+
+// warn records a warning about the page once.
+func (c *htmlConverter) warn(message string) {
+	if !c.warned[message] {
+		c.warned[message] = true
+		c.result.warn(c.name, "%s", message)
+	}
+}
+
+// convertHTML returns the markdown for an HTML page: its title, author,
+// description, and keywords as frontmatter, and the content of its main
+// or article element, or else its body.
+func convertHTML(name string, data []byte, result *Result) string {
+	c := &htmlConverter{name: name, result: result, warned: make(map[string]bool)}
+	reader, err := charset.NewReader(bytes.NewReader(data), "")
+	if err != nil {
+		reader = bytes.NewReader(data)
+	}
+	doc, err := html.Parse(reader)
+	if err != nil {
+		c.warn("not parsed: " + err.Error())
+		return string(data)
+	}
+
+	fm := frontmatter{blocks: make(map[string][]string)}
+	if title := findElement(doc, atom.Title); title != nil {
+		if text := strings.Join(strings.Fields(textContent(title)), " "); text != "" {
+			fm.set("title", text)
+		}
+	}
+	for _, meta := range findElements(doc, atom.Meta) {
+		content := strings.Join(strings.Fields(attr(meta, "content")), " ")
+		if content == "" {
+			continue
+		}
+		switch strings.ToLower(attr(meta, "name")) {
+		case "description":
+			fm.set("description", content)
+		case "author":
+			fm.set("author", content)
+		case "keywords":
+			var tags []string
+			for _, tag := range strings.Split(content, ",") {
+				if tag = strings.TrimSpace(tag); tag != "" {
+					tags = append(tags, tag)
+				}
+			}
+			fm.set("tags", "["+strings.Join(tags, ", ")+"]")
+		}
+	}
+
+	root := findElement(doc, atom.Main)
+	if root == nil {
+		root = findElement(doc, atom.Article)
+	}
+	if root == nil {
+		root = findElement(doc, atom.Body)
+	}
+	if root == nil {
+		root = doc
+	}
+	body := strings.TrimSpace(c.blocks(root, "\n\n"))
+	if fm.get("title") == "" {
+		if title := firstHeading(body); title != "" {
+			fm.set("title", title)
+		}
+	}
+	return fm.join(body + "\n")
+}
+
+// blocks renders the children of n as markdown blocks separated by sep.
+// Runs of text and inline elements become paragraphs.
+func (c *htmlConverter) blocks(n *html.Node, sep string) string {
+	var parts []string
+	var run strings.Builder
+	flush := func() {
+		if paragraph := paragraphText(run.String()); paragraph != "" {
+			parts = append(parts, paragraph)
+		}
+		run.Reset()
+	}
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type == html.ElementNode && isBlock(child) {
+			flush()
+			if block := c.block(child); strings.TrimSpace(block) != "" {
+				parts = append(parts, block)
+			}
+			continue
+		}
+		run.WriteString(c.inline(child))
+	}
+	flush()
+	return strings.Join(parts, sep)
+}
+
+// block renders a block element.
+func (c *htmlConverter) block(n *html.Node) string {
+	switch n.DataAtom {
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+		level, _ := strconv.Atoi(n.Data[1:])
+		return strings.Repeat("#", level) + " " + strings.ReplaceAll(paragraphText(c.children(n)), "\\\n", " ")
+	case atom.P, atom.Address, atom.Figcaption, atom.Summary:
+		return paragraphText(c.children(n))
+	case atom.Dt:
+		if text := paragraphText(c.children(n)); text != "" {
+			return "**" + text + "**"
+		}
+		return ""
+	case atom.Ul, atom.Ol, atom.Menu:
+		return c.list(n)
+	case atom.Pre:
+		return codeBlock(n)
+	case atom.Blockquote:
+		return quoteLines(c.blocks(n, "\n\n"))
+	case atom.Hr:
+		return "---"
+	case atom.Table:
+		return c.table(n)
+	case atom.Script:
+		c.warn("scripts dropped")
+		return ""
+	case atom.Style, atom.Noscript, atom.Template, atom.Head:
+		return ""
+	case atom.Nav:
+		c.warn("navigation menu dropped")
+		return ""
+	case atom.Form:
+		c.warn("form dropped")
+		return ""
+	case atom.Iframe, atom.Object, atom.Embed:
+		c.warn(n.Data + " replaced by a link to its source")
+		source := attr(n, "src")
+		if source == "" {
+			source = attr(n, "data")
+		}
+		if source == "" {
+			return ""
+		}
+		return "[" + markdownEscaper.Replace(source) + "](" + linkTarget(source) + ")"
+	}
+	return c.blocks(n, "\n\n")
+}
+
+// list renders an ordered or unordered list, indenting the content of
+// each item below its marker.
+func (c *htmlConverter) list(n *html.Node) string {
+	var items []string
+	number := 1
+	if start, err := strconv.Atoi(attr(n, "start")); err == nil {
+		number = start
+	}
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type != html.ElementNode {
+			continue
+		}
+		marker := "- "
+		if n.DataAtom == atom.Ol {
+			marker = strconv.Itoa(number) + ". "
+			number++
+		}
+		content := c.blocks(child, "\n")
+		if child.DataAtom != atom.Li {
+			content = c.block(child)
+		}
+		lines := strings.Split(content, "\n")
+		for i := range lines {
+			if i > 0 && lines[i] != "" {
+				lines[i] = strings.Repeat(" ", len(marker)) + lines[i]
+			}
+		}
+		items = append(items, marker+strings.Join(lines, "\n"))
+	}
+	return strings.Join(items, "\n")
+}
+
+// table renders a table as a GitHub table, or keeps it as HTML when cells
+// span rows or columns or hold blocks that a GitHub table cannot.
+func (c *htmlConverter) table(n *html.Node) string {
+	var rows [][]*html.Node
+	var header bool
+	var collect func(*html.Node)
+	collect = func(node *html.Node) {
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			switch child.DataAtom {
+			case atom.Thead, atom.Tbody, atom.Tfoot:
+				collect(child)
+			case atom.Tr:
+				var cells []*html.Node
+				for cell := child.FirstChild; cell != nil; cell = cell.NextSibling {
+					if cell.DataAtom == atom.Td || cell.DataAtom == atom.Th {
+						cells = append(cells, cell)
+					}
+				}
+				if len(rows) == 0 && (node.DataAtom == atom.Thead || (len(cells) > 0 && cells[0].DataAtom == atom.Th)) {
+					header = true
+				}
+				rows = append(rows, cells)
+			}
+		}
+	}
+	collect(n)
+	if len(rows) == 0 {
+		return ""
+	}
+
+	columns := 0
+	for _, row := range rows {
+		columns = max(columns, len(row))
+		for _, cell := range row {
+			if attr(cell, "colspan") != "" || attr(cell, "rowspan") != "" || hasBlocks(cell) {
+				c.warn("complex table kept as HTML")
+				var sb strings.Builder
+				html.Render(&sb, n)
+				return sb.String()
+			}
+		}
+	}
+	if !header {
+		c.warn("table without a header row: the first row became the header")
+	}
+	var lines []string
+	for i, row := range rows {
+		cells := make([]string, columns)
+		for j, cell := range row {
+			text := paragraphText(c.children(cell))
+			text = strings.ReplaceAll(strings.ReplaceAll(text, "\\\n", " "), "\n", " ")
+			cells[j] = strings.ReplaceAll(text, "|", `\|`)
+		}
+		lines = append(lines, "| "+strings.Join(cells, " | ")+" |")
+		if i == 0 {
+			lines = append(lines, "|"+strings.Repeat(" --- |", columns))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// children renders the children of n as inline markdown.
+func (c *htmlConverter) children(n *html.Node) string {
+	var sb strings.Builder
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		sb.WriteString(c.inline(child))
+	}
+	return sb.String()
+}
+
+// inline renders a node inside a paragraph.
+func (c *htmlConverter) inline(n *html.Node) string {
+	switch n.Type {
+	case html.TextNode:
+		return markdownEscaper.Replace(collapseSpace(n.Data))
+	case html.ElementNode:
+	default:
+		return ""
+	}
+	switch n.DataAtom {
+	case atom.Strong, atom.B:
+		return wrap(c.children(n), "**")
+	case atom.Em, atom.I, atom.Cite, atom.Var:
+		return wrap(c.children(n), "*")
+	case atom.Del, atom.S, atom.Strike:
+		return wrap(c.children(n), "~~")
+	case atom.Code, atom.Tt, atom.Kbd, atom.Samp:
+		return inlineCode(collapseSpace(textContent(n)))
+	case atom.Br:
+		return "\\\n"
+	case atom.A:
+		text := c.children(n)
+		href := attr(n, "href")
+		if href == "" || strings.HasPrefix(strings.ToLower(href), "javascript:") {
+			return text
+		}
+		if strings.TrimSpace(text) == "" {
+			text = markdownEscaper.Replace(href)
+		}
+		return "[" + strings.TrimSpace(text) + "](" + linkTarget(href) + ")"
+	case atom.Img:
+		alt := markdownEscaper.Replace(collapseSpace(attr(n, "alt")))
+		return "![" + strings.TrimSpace(alt) + "](" + linkTarget(attr(n, "src")) + ")"
+	case atom.Script, atom.Style, atom.Noscript, atom.Template:
+		return ""
+	case atom.Input, atom.Select, atom.Textarea, atom.Button:
+		c.warn("form controls dropped")
+		return ""
+	}
+	return c.children(n)
+}
+
+// codeBlock renders a pre element as a fenced code block, taking the
+// language from a language- or lang- class.
+func codeBlock(n *html.Node) string {
+	text := strings.Trim(textContent(n), "\n")
+	language := ""
+	for _, node := range []*html.Node{n, findElement(n, atom.Code)} {
+		if node == nil {
+			continue
+		}
+		for _, class := range strings.Fields(attr(node, "class")) {
+			for _, prefix := range []string{"language-", "lang-"} {
+				if lang, found := strings.CutPrefix(class, prefix); found && language == "" {
+					language = lang
+				}
+			}
+		}
+	}
+	fence := "```"
+	for strings.Contains(text, fence) {
+		fence += "`"
+	}
+	return fence + language + "\n" + text + "\n" + fence
+}
+
+// inlineCode returns text as a code span, with a fence longer than any
+// run of backticks inside.
+func inlineCode(text string) string {
+	if strings.TrimSpace(text) == "" {
+		return text
+	}
+	fence := "`"
+	for strings.Contains(text, fence) {
+		fence += "`"
+	}
+	if strings.HasPrefix(text, "`") || strings.HasSuffix(text, "`") {
+		text = " " + text + " "
+	}
+	return fence + text + fence
+}
+
+// wrap surrounds text with an emphasis marker, keeping surrounding spaces
+// outside, as markdown requires.
+func wrap(text, marker string) string {
+	trimmed := strings.TrimSpace(text)
+	if trimmed == "" {
+		return text
+	}
+	lead := text[:len(text)-len(strings.TrimLeft(text, " \n"))]
+	trail := text[len(strings.TrimRight(text, " \n")):]
+	return lead + marker + trimmed + marker + trail
+}
+
+// linkTarget returns a link target for markdown, pointing links to other
+// legacy pages at their converted markdown files.
+func linkTarget(href string) string {
+	href = strings.TrimSpace(href)
+	if !strings.Contains(href, ":") && !strings.HasPrefix(href, "//") {
+		target, rest := href, ""
+		if i := strings.IndexAny(href, "?#"); i >= 0 {
+			target, rest = href[:i], href[i:]
+		}
+		if isHTML(target) {
+			href = strings.TrimSuffix(target, path.Ext(target)) + ".md" + rest
+		}
+	}
+	if strings.ContainsAny(href, " ()") {
+		return "<" + href + ">"
+	}
+	return href
+}
+
+// quoteLines prefixes every line with a quote marker.
+func quoteLines(text string) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		if line == "" {
+			lines[i] = ">"
+		} else {
+			lines[i] = "> " + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// paragraphText tidies the inline markdown of a paragraph: spaces
+// collapsed, lines trimmed, and a leading character escaped where
+// markdown would start a block instead.
+func paragraphText(text string) string {
+	lines := strings.Split(text, "\n")
+	var kept []string
+	for _, line := range lines {
+		line = strings.Join(strings.Fields(line), " ")
+		if line == "" {
+			continue
+		}
+		if blockStart.MatchString(line) {
+			line = `\` + line
+		}
+		line = orderedStart.ReplaceAllString(line, `$1\$2`)
+		kept = append(kept, line)
+	}
+	result := strings.Join(kept, "\n")
+	return strings.TrimSuffix(result, `\`)
+}
+
+// collapseSpace replaces runs of whitespace with single spaces, as
+// browsers do.
+func collapseSpace(text string) string {
+	var sb strings.Builder
+	space := false
+	for _, r := range text {
+		if r == ' ' || r == '\n' || r == '\t' || r == '\r' || r == '\f' {
+			if !space {
+				sb.WriteByte(' ')
+			}
+			space = true
+			continue
+		}
+		space = false
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
+
+// isBlock reports whether an element starts a block of its own.
+func isBlock(n *html.Node) bool {
+	switch n.DataAtom {
+	case atom.Address, atom.Article, atom.Aside, atom.Blockquote, atom.Center, atom.Details,
+		atom.Dd, atom.Div, atom.Dl, atom.Dt, atom.Fieldset, atom.Figcaption, atom.Figure,
+		atom.Footer, atom.Form, atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6,
+		atom.Head, atom.Header, atom.Hr, atom.Iframe, atom.Li, atom.Main, atom.Menu, atom.Nav,
+		atom.Noscript, atom.Object, atom.Embed, atom.Ol, atom.P, atom.Pre, atom.Script,
+		atom.Section, atom.Style, atom.Summary, atom.Table, atom.Template, atom.Ul:
+		return true
+	}
+	return false
+}
+
+// hasBlocks reports whether a block element is nested in n.
+func hasBlocks(n *html.Node) bool {
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type == html.ElementNode && child.DataAtom != atom.P && (isBlock(child) || hasBlocks(child)) {
+			return true
+		}
+	}
+	return false
+}
+
+// findElement returns the first element of type a below n.
+func findElement(n *html.Node, a atom.Atom) *html.Node {
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type == html.ElementNode && child.DataAtom == a {
+			return child
+		}
+		if found := findElement(child, a); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// findElements returns every element of type a below n.
+func findElements(n *html.Node, a atom.Atom) []*html.Node {
+	var found []*html.Node
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type == html.ElementNode && child.DataAtom == a {
+			found = append(found, child)
+		}
+		found = append(found, findElements(child, a)...)
+	}
+	return found
+}
+
+// textContent returns the text below n.
+func textContent(n *html.Node) string {
+	if n.Type == html.TextNode {
+		return n.Data
+	}
+	var sb strings.Builder
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		sb.WriteString(textContent(child))
+	}
+	return sb.String()
+}
+
+// attr returns the value of the attribute key of n.
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
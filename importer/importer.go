@@ -0,0 +1,311 @@
+// Package importer converts documentation written for other generators,
+// Docusaurus and MkDocs, or as legacy HTML pages, into a gomdoc tree: pages
+// keep their place in the navigation through _order.yml files, admonitions
+// become GitHub-style alerts, and frontmatter only keeps the keys gomdoc
+// understands.
+package importer
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gomdoc/scanner"
+)
+
+// Format names a documentation generator Import reads.
+type Format string
+
+const (
+	// Auto detects the format from the configuration file in the source.
+	Auto Format = "auto"
+	// Docusaurus reads a Docusaurus site: docs/, sidebars.js, and
+	// _category_ files.
+	Docusaurus Format = "docusaurus"
+	// MkDocs reads an MkDocs site: mkdocs.yml and its docs_dir.
+	MkDocs Format = "mkdocs"
+	// HTML reads a tree of legacy .html pages, such as an old intranet,
+	// next to files that are kept as they are.
+	HTML Format = "html"
+)
+
+// Result describes an import.
+type Result struct {
+	// Format is the format that was read.
+	Format Format
+	// Pages is the number of markdown pages written.
+	Pages int
+	// Files is the number of other files copied, such as images.
+	Files int
+	// Orders is the number of _order.yml files written.
+	Orders int
+	// Warnings lists what could not be converted, for manual review.
+	Warnings []Warning
+}
+
+// Warning is something in a file that needs manual review.
+type Warning struct {
+	// File is the path of the file in the source, relative to its docs.
+	File string
+	// Message describes the problem.
+	Message string
+}
+
+// String returns the warning as "file: message".
+func (w Warning) String() string {
+	return w.File + ": " + w.Message
+}
+
+// warn records a warning about file.
+func (r *Result) warn(file, format string, args ...any) {
+	r.Warnings = append(r.Warnings, Warning{File: file, Message: fmt.Sprintf(format, args...)})
+}
+
+// Report returns a review report of the import as a markdown page hidden
+// from navigation, listing the warnings by file.
+func (r Result) Report() string {
+	var sb strings.Builder
+	sb.WriteString("---\ntitle: Import review\nnav: false\n---\n# Import review\n\n")
+	fmt.Fprintf(&sb, "Imported %d pages and %d other files from a %s site.", r.Pages, r.Files, r.Format)
+	if len(r.Warnings) == 0 {
+		sb.WriteString(" Nothing needs review.\n")
+		return sb.String()
+	}
+	files := make(map[string][]string)
+	var names []string
+	for _, w := range r.Warnings {
+		if _, found := files[w.File]; !found {
+			names = append(names, w.File)
+		}
+		files[w.File] = append(files[w.File], w.Message)
+	}
+	sort.Strings(names)
+	fmt.Fprintf(&sb, " %d files need review.\n", len(names))
+	for _, name := range names {
+		sb.WriteString("\n## " + name + "\n\n")
+		for _, message := range files[name] {
+			sb.WriteString("- [ ] " + message + "\n")
+		}
+	}
+	return sb.String()
+}
+
+// project is a source site ready to be written as a gomdoc tree.
+type project struct {
+	// docs is the directory holding the pages.
+	docs string
+	// static is a directory copied to the root of the output, so that
+	// absolute links such as /img/logo.png keep working. Empty when the
+	// site has none.
+	static string
+	// order lists the entries of each directory, by slash-separated path
+	// relative to docs with "." for the root, in navigation order.
+	order map[string][]string
+	// skip reports pages that are not imported, such as drafts.
+	skip map[string]bool
+	// page reports whether a file is a page to convert. Nil selects
+	// markdown and MDX files.
+	page func(name string) bool
+	// convert translates a page, given its slash-separated path relative
+	// to docs.
+	convert func(name string, content string, result *Result) string
+}
+
+// Detect returns the format of the site in dir.
+func Detect(dir string) (Format, error) {
+	for _, name := range []string{"mkdocs.yml", "mkdocs.yaml"} {
+		if fileExists(filepath.Join(dir, name)) {
+			return MkDocs, nil
+		}
+	}
+	for _, name := range []string{"docusaurus.config.js", "docusaurus.config.ts", "docusaurus.config.mjs", "sidebars.js", "sidebars.ts"} {
+		if fileExists(filepath.Join(dir, name)) {
+			return Docusaurus, nil
+		}
+	}
+	if pages, _ := fs.Glob(os.DirFS(dir), "*.htm*"); len(pages) > 0 {
+		return HTML, nil
+	}
+	return "", fmt.Errorf("%s has neither mkdocs.yml, docusaurus.config.js, nor .html pages", dir)
+}
+
+// Import converts the site in src to a gomdoc tree in out, which must not
+// exist or be empty unless overwrite is set.
+func Import(src, out string, format Format, overwrite bool) (Result, error) {
+	var result Result
+	if format == Auto || format == "" {
+		detected, err := Detect(src)
+		if err != nil {
+			return result, err
+		}
+		format = detected
+	}
+	result.Format = format
+	if !overwrite {
+		if entries, err := os.ReadDir(out); err == nil && len(entries) > 0 {
+			return result, fmt.Errorf("%s is not empty", out)
+		}
+	}
+
+	var p project
+	var err error
+	switch format {
+	case MkDocs:
+		p, err = loadMkDocs(src, &result)
+	case Docusaurus:
+		p, err = loadDocusaurus(src, &result)
+	case HTML:
+		p = loadHTML(src, &result)
+	default:
+		return result, fmt.Errorf("unknown format %q", format)
+	}
+	if err != nil {
+		return result, err
+	}
+
+	if p.static != "" {
+		if err := copyTree(os.DirFS(p.static), out, &result); err != nil {
+			return result, err
+		}
+	}
+	if p.page == nil {
+		p.page = isPage
+	}
+	// The files are listed up front, so that importing into the source
+	// tree does not pick up the pages it writes.
+	var names []string
+	err = fs.WalkDir(os.DirFS(p.docs), ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if name != "." && strings.HasPrefix(d.Name(), ".") {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if !skippedFile(d.Name()) && !p.skip[name] {
+			names = append(names, name)
+		}
+		return nil
+	})
+	if err != nil {
+		return result, err
+	}
+	for _, name := range names {
+		data, err := os.ReadFile(filepath.Join(p.docs, filepath.FromSlash(name)))
+		if err != nil {
+			return result, err
+		}
+		target, content := name, data
+		if p.page(name) {
+			result.Pages++
+			target = strings.TrimSuffix(name, path.Ext(name)) + ".md"
+			content = []byte(p.convert(name, string(data), &result))
+		} else {
+			result.Files++
+		}
+		if err := writeFile(filepath.Join(out, filepath.FromSlash(target)), content); err != nil {
+			return result, err
+		}
+	}
+
+	dirs := make([]string, 0, len(p.order))
+	for dir := range p.order {
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+	for _, dir := range dirs {
+		var names []string
+		for _, name := range p.order[dir] {
+			names = append(names, pageName(name))
+		}
+		target := filepath.Join(out, filepath.FromSlash(dir), scanner.OrderFile)
+		if err := writeFile(target, []byte(scanner.FormatOrder(names))); err != nil {
+			return result, err
+		}
+		result.Orders++
+	}
+	return result, nil
+}
+
+// copyTree copies every file of fsys below out.
+func copyTree(fsys fs.FS, out string, result *Result) error {
+	return fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		data, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return err
+		}
+		result.Files++
+		return writeFile(filepath.Join(out, filepath.FromSlash(name)), data)
+	})
+}
+
+// addPage adds the directories and file of the page at name to order,
+// keeping the first position of each.
+func addPage(order map[string][]string, name string) {
+	dir := "."
+	for _, segment := range strings.Split(name, "/") {
+		if !contains(order[dir], segment) {
+			order[dir] = append(order[dir], segment)
+		}
+		dir = path.Join(dir, segment)
+	}
+}
+
+// isPage reports whether name is a markdown page.
+func isPage(name string) bool {
+	ext := strings.ToLower(path.Ext(name))
+	return ext == ".md" || ext == ".mdx" || ext == ".markdown"
+}
+
+// pageName returns the name of a page in the gomdoc tree, which only
+// serves .md files.
+func pageName(name string) string {
+	if isPage(name) {
+		return strings.TrimSuffix(name, path.Ext(name)) + ".md"
+	}
+	return name
+}
+
+// skippedFile reports whether a file is generator configuration rather
+// than content.
+func skippedFile(name string) bool {
+	switch name {
+	case "_category_.json", "_category_.yml", "_category_.yaml", ".pages":
+		return true
+	}
+	return strings.HasPrefix(name, ".")
+}
+
+// writeFile writes data to target, creating its directory.
+func writeFile(target string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(target, data, 0o644)
+}
+
+// fileExists reports whether name exists.
+func fileExists(name string) bool {
+	_, err := os.Stat(name)
+	return !errors.Is(err, fs.ErrNotExist)
+}
+
+// contains reports whether names holds name.
+func contains(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,186 @@
+package importer
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeTree writes files, keyed by slash-separated path, below dir.
+func writeTree(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		target := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(target, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// readFile returns the content of a slash-separated path below dir.
+func readFile(t *testing.T, dir, name string) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(name)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestImportMkDocs(t *testing.T) {
+	src, out := t.TempDir(), t.TempDir()
+	writeTree(t, src, map[string]string{
+		"mkdocs.yml":             "site_name: Demo\nnav:\n  - Home: index.md\n  - 'User Guide':\n      - 'Install: Linux': guide/install.md\n      - guide/advanced.md\n  - GitHub: https://github.com/example\ntheme: material\n",
+		"docs/index.md":          "# Welcome\n",
+		"docs/guide/install.md":  "---\nhide:\n  - toc\n---\n!!! warning \"Root needed\"\n    Run as root.\n\n    Really.\n\n```\n!!! note\n    not converted\n```\n",
+		"docs/guide/advanced.md": "# Advanced\n\n!!! tip\n    Nested:\n\n    ??? danger\n        Careful.\n",
+		"docs/guide/orphan.md":   "# Orphan\n",
+		"docs/img/logo.png":      "png",
+	})
+	result, err := Import(src, out, Auto, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Format != MkDocs || result.Pages != 4 || result.Files != 1 || len(result.Warnings) != 1 {
+		t.Errorf("unexpected result %+v", result)
+	}
+	if got := readFile(t, out, "_order.yml"); got != "- index.md\n- guide\n" {
+		t.Errorf("root order %q", got)
+	}
+	if got := readFile(t, out, "guide/_order.yml"); got != "- install.md\n- advanced.md\n" {
+		t.Errorf("guide order %q", got)
+	}
+	want := "---\ntitle: Install: Linux\n---\n> [!WARNING]\n> **Root needed**\n> Run as root.\n>\n> Really.\n\n```\n!!! note\n    not converted\n```\n"
+	if got := readFile(t, out, "guide/install.md"); got != want {
+		t.Errorf("install page:\n%s\nwant:\n%s", got, want)
+	}
+	want = "---\ntitle: Advanced\n---\n# Advanced\n\n> [!TIP]\n> Nested:\n>\n> > [!DANGER]\n> > Careful.\n"
+	if got := readFile(t, out, "guide/advanced.md"); got != want {
+		t.Errorf("advanced page:\n%s\nwant:\n%s", got, want)
+	}
+	if got := readFile(t, out, "guide/orphan.md"); !strings.Contains(got, "nav: false") {
+		t.Errorf("page missing from the nav is not hidden:\n%s", got)
+	}
+
+	if _, err := Import(src, out, Auto, false); err == nil {
+		t.Error("expected an error for a non-empty output directory")
+	}
+}
+
+func TestImportDocusaurus(t *testing.T) {
+	src, out := t.TempDir(), t.TempDir()
+	writeTree(t, src, map[string]string{
+		"docusaurus.config.js":             "module.exports = {};\n",
+		"static/img/logo.svg":              "<svg/>",
+		"docs/intro.md":                    "---\nsidebar_position: 2\nsidebar_label: Start\n---\nHello.\n",
+		"docs/faq.mdx":                     "---\nid: questions\ntitle: FAQ\nslug: /faq\n---\nimport Tabs from '@theme/Tabs';\n\n:::info[Heads up]\nRead this.\n:::\n\n<Tabs>\n</Tabs>\n",
+		"docs/01-tutorial/_category_.json": `{"label": "Tutorial", "position": 1}`,
+		"docs/01-tutorial/b.md":            "# B\n",
+		"docs/01-tutorial/a.md":            "# A\n",
+		"docs/wip.md":                      "---\ndraft: true\n---\n# WIP\n",
+	})
+	writeTree(t, src, map[string]string{
+		"sidebars.js": "module.exports = {docs: [{type: 'category', label: 'Tutorial', items: ['tutorial/b', 'tutorial/a']}, {type: 'autogenerated', dirName: '.'}]};\n",
+	})
+	result, err := Import(src, out, Auto, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Format != Docusaurus || result.Pages != 4 || result.Files != 1 {
+		t.Errorf("unexpected result %+v", result)
+	}
+	if _, err := os.Stat(filepath.Join(out, "wip.md")); err == nil {
+		t.Error("draft was imported")
+	}
+	if readFile(t, out, "img/logo.svg") != "<svg/>" {
+		t.Error("static files not copied to the root")
+	}
+	// The sidebar orders the tutorial; positions order the rest.
+	if got := readFile(t, out, "01-tutorial/_order.yml"); got != "- b.md\n- a.md\n" {
+		t.Errorf("tutorial order %q", got)
+	}
+	if got := readFile(t, out, "_order.yml"); got != "- 01-tutorial\n- intro.md\n- faq.md\n" {
+		t.Errorf("root order %q", got)
+	}
+	if got := readFile(t, out, "intro.md"); got != "---\ntitle: Start\n---\nHello.\n" {
+		t.Errorf("intro page %q", got)
+	}
+	want := "---\ntitle: FAQ\n---\n\n> [!NOTE]\n> **Heads up**\n> Read this.\n\n<Tabs>\n</Tabs>\n"
+	if got := readFile(t, out, "faq.md"); got != want {
+		t.Errorf("faq page:\n%s\nwant:\n%s", got, want)
+	}
+	if len(result.Warnings) != 2 {
+		t.Errorf("expected warnings for the draft and the MDX component, got %v", result.Warnings)
+	}
+}
+
+func TestImportHTML(t *testing.T) {
+	src, out := t.TempDir(), t.TempDir()
+	writeTree(t, src, map[string]string{
+		"index.html": `<html><head><meta charset="utf-8"><title>Intranet</title>
+<meta name="description" content="The old portal">
+<meta name="keywords" content="legacy, portal">
+<script>track()</script></head>
+<body><nav><a href="/">Home</a></nav><main>
+<h1>Welcome</h1>
+<p>See the <a href="guide/setup.html#linux">setup guide</a> and <b>read</b> <code>README</code>.</p>
+<ul><li>One</li><li>Two<ol><li>Nested</li></ol></li></ul>
+<pre><code class="language-go">fmt.Println("hi")</code></pre>
+<table><tr><th>Key</th><th>Value</th></tr><tr><td>a|b</td><td>1</td></tr></table>
+<table><tr><th colspan="2">Merged</th></tr><tr><td>x</td><td>y</td></tr></table>
+<img src="logo.png" alt="Logo">
+</main></body></html>`,
+		"guide/setup.html": "<h1>Setup</h1><p>2024. was a year.</p>",
+		"notes.html":       "<p>old</p>",
+		"notes.md":         "# Notes\n",
+		"logo.png":         "png",
+	})
+
+	if format, err := Detect(src); err != nil || format != HTML {
+		t.Fatalf("expected html, got %q, %v", format, err)
+	}
+	result, err := Import(src, out, Auto, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Pages != 2 || result.Files != 3 {
+		t.Errorf("unexpected result %+v", result)
+	}
+
+	index := readFile(t, out, "index.md")
+	for _, want := range []string{
+		"---\ntitle: Intranet\ndescription: The old portal\ntags: [legacy, portal]\n---\n",
+		"# Welcome\n",
+		"[setup guide](guide/setup.md#linux)",
+		"**read** `README`",
+		"- One\n- Two\n  1. Nested\n",
+		"```go\nfmt.Println(\"hi\")\n```",
+		"| Key | Value |\n| --- | --- |\n| a\\|b | 1 |",
+		"<table>",
+		"![Logo](logo.png)",
+	} {
+		if !strings.Contains(index, want) {
+			t.Errorf("expected %q in\n%s", want, index)
+		}
+	}
+	if strings.Contains(index, "track()") || strings.Contains(index, "[Home]") {
+		t.Errorf("expected scripts and navigation dropped, got\n%s", index)
+	}
+	if setup := readFile(t, out, "guide/setup.md"); !strings.Contains(setup, "title: Setup") || !strings.Contains(setup, "2024\\. was a year.") {
+		t.Errorf("unexpected setup page\n%s", setup)
+	}
+	if notes := readFile(t, out, "notes.md"); notes != "# Notes\n" {
+		t.Errorf("expected the markdown page kept, got %q", notes)
+	}
+
+	report := result.Report()
+	for _, want := range []string{"title: Import review", "## index.html", "- [ ] complex table kept as HTML", "## notes.html"} {
+		if !strings.Contains(report, want) {
+			t.Errorf("expected %q in report\n%s", want, report)
+		}
+	}
+}
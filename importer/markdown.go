@@ -0,0 +1,197 @@
+package importer
+
+import (
+	"strings"
+)
+
+// alertTypes maps admonition types of Docusaurus and MkDocs to the
+// GitHub-style alerts gomdoc renders. Other types become notes.
+var alertTypes = map[string]string{
+	"note":      "NOTE",
+	"info":      "NOTE",
+	"abstract":  "NOTE",
+	"summary":   "NOTE",
+	"tldr":      "NOTE",
+	"question":  "NOTE",
+	"help":      "NOTE",
+	"faq":       "NOTE",
+	"example":   "NOTE",
+	"quote":     "NOTE",
+	"cite":      "NOTE",
+	"tip":       "TIP",
+	"hint":      "TIP",
+	"success":   "TIP",
+	"check":     "TIP",
+	"done":      "TIP",
+	"important": "IMPORTANT",
+	"warning":   "WARNING",
+	"attention": "WARNING",
+	"caution":   "CAUTION",
+	"danger":    "DANGER",
+	"error":     "DANGER",
+	"failure":   "DANGER",
+	"fail":      "DANGER",
+	"missing":   "DANGER",
+	"bug":       "DANGER",
+}
+
+// alert returns body as a GitHub-style alert blockquote of the given
+// admonition type, with the title in bold on its first line. Lines are
+// prefixed with indent.
+func alert(indent, kind, title string, body []string) []string {
+	marker, ok := alertTypes[strings.ToLower(kind)]
+	if !ok {
+		marker = "NOTE"
+	}
+	lines := []string{indent + "> [!" + marker + "]"}
+	if title != "" {
+		lines = append(lines, indent+"> **"+title+"**")
+	}
+	// Leading and trailing blank lines would end the quote early.
+	for len(body) > 0 && strings.TrimSpace(body[0]) == "" {
+		body = body[1:]
+	}
+	for len(body) > 0 && strings.TrimSpace(body[len(body)-1]) == "" {
+		body = body[:len(body)-1]
+	}
+	for _, line := range body {
+		if strings.TrimSpace(line) == "" {
+			lines = append(lines, indent+">")
+		} else {
+			lines = append(lines, indent+"> "+line)
+		}
+	}
+	return lines
+}
+
+// fence tracks fenced code blocks while scanning lines, so admonition
+// syntax inside code examples is left alone.
+type fence struct {
+	marker string
+}
+
+// inside updates the fence state with line and reports whether the line
+// belongs to a code block, including its fence lines.
+func (f *fence) inside(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	if f.marker != "" {
+		if strings.HasPrefix(trimmed, f.marker) && strings.Trim(trimmed, f.marker[:1]) == "" {
+			f.marker = ""
+		}
+		return true
+	}
+	for _, marker := range []string{"```", "~~~"} {
+		if strings.HasPrefix(trimmed, marker) {
+			f.marker = trimmed[:len(trimmed)-len(strings.TrimLeft(trimmed, marker[:1]))]
+			return true
+		}
+	}
+	return false
+}
+
+// frontmatter is a page's YAML frontmatter as top-level keys, each with
+// its lines, including indented continuation lines.
+type frontmatter struct {
+	keys   []string
+	blocks map[string][]string
+}
+
+// splitFrontmatter separates the frontmatter of a page from its body.
+func splitFrontmatter(content string) (frontmatter, string) {
+	fm := frontmatter{blocks: make(map[string][]string)}
+	content = strings.ReplaceAll(content, "\r\n", "\n")
+	if !strings.HasPrefix(content, "---\n") {
+		return fm, content
+	}
+	end := strings.Index(content[4:], "\n---")
+	if end < 0 {
+		return fm, content
+	}
+	block := content[4 : 4+end]
+	body := strings.TrimPrefix(content[4+end+4:], "\n")
+
+	var key string
+	for _, line := range strings.Split(block, "\n") {
+		if line != "" && line[0] != ' ' && line[0] != '\t' && line[0] != '-' && line[0] != '#' {
+			if i := strings.Index(line, ":"); i > 0 {
+				key = strings.TrimSpace(line[:i])
+				if _, found := fm.blocks[key]; !found {
+					fm.keys = append(fm.keys, key)
+				}
+				fm.blocks[key] = []string{line}
+				continue
+			}
+		}
+		if key != "" {
+			fm.blocks[key] = append(fm.blocks[key], line)
+		}
+	}
+	return fm, body
+}
+
+// get returns the value of a single-line key without quotes.
+func (fm frontmatter) get(key string) string {
+	lines := fm.blocks[key]
+	if len(lines) == 0 {
+		return ""
+	}
+	value := strings.TrimSpace(lines[0][strings.Index(lines[0], ":")+1:])
+	return strings.Trim(value, `"'`)
+}
+
+// set sets key to a single-line value, keeping its position if present.
+func (fm *frontmatter) set(key, value string) {
+	if _, found := fm.blocks[key]; !found {
+		fm.keys = append(fm.keys, key)
+	}
+	fm.blocks[key] = []string{key + ": " + value}
+}
+
+// remove drops keys.
+func (fm *frontmatter) remove(keys ...string) {
+	for _, key := range keys {
+		if _, found := fm.blocks[key]; !found {
+			continue
+		}
+		delete(fm.blocks, key)
+		for i, k := range fm.keys {
+			if k == key {
+				fm.keys = append(fm.keys[:i], fm.keys[i+1:]...)
+				break
+			}
+		}
+	}
+}
+
+// join returns the page with the frontmatter in front of body, leaving it
+// out when empty.
+func (fm frontmatter) join(body string) string {
+	if len(fm.keys) == 0 {
+		return body
+	}
+	var sb strings.Builder
+	sb.WriteString("---\n")
+	for _, key := range fm.keys {
+		for _, line := range fm.blocks[key] {
+			sb.WriteString(line + "\n")
+		}
+	}
+	sb.WriteString("---\n")
+	sb.WriteString(body)
+	return sb.String()
+}
+
+// firstHeading returns the text of the first level-one heading of body,
+// outside code blocks.
+func firstHeading(body string) string {
+	var f fence
+	for _, line := range strings.Split(body, "\n") {
+		if f.inside(line) {
+			continue
+		}
+		if strings.HasPrefix(line, "# ") {
+			return strings.TrimSpace(line[2:])
+		}
+	}
+	return ""
+}
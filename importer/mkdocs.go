@@ -0,0 +1,247 @@
+package importer
+
+import (
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// mkdocsDroppedKeys are MkDocs frontmatter keys gomdoc has no use for.
+var mkdocsDroppedKeys = []string{"hide", "template", "search", "icon", "subtitle"}
+
+// mkdocsAdmonition matches the first line of an MkDocs admonition, such as
+// !!! warning "Mind the gap" or a collapsible ??? note.
+var mkdocsAdmonition = regexp.MustCompile(`^(\s*)(?:!!!|\?\?\?\+?)\s+([\w-]+)(?:\s+"(.*)")?\s*$`)
+
+// navItem is an entry of the MkDocs nav: a page, a link, or a section.
+type navItem struct {
+	title    string
+	target   string
+	children []navItem
+}
+
+// loadMkDocs reads the MkDocs site in dir.
+func loadMkDocs(dir string, result *Result) (project, error) {
+	config, err := os.ReadFile(filepath.Join(dir, "mkdocs.yml"))
+	if err != nil {
+		if config, err = os.ReadFile(filepath.Join(dir, "mkdocs.yaml")); err != nil {
+			return project{}, err
+		}
+	}
+	docsDir := "docs"
+	if value := topLevelValue(string(config), "docs_dir"); value != "" {
+		docsDir = value
+	}
+	p := project{docs: filepath.Join(dir, filepath.FromSlash(docsDir)), order: make(map[string][]string)}
+
+	nav := parseNav(string(config))
+	titles := make(map[string]string)
+	var visit func(items []navItem)
+	visit = func(items []navItem) {
+		for _, item := range items {
+			switch {
+			case len(item.children) > 0:
+				visit(item.children)
+			case strings.Contains(item.target, "://"):
+				result.warn("mkdocs.yml", "nav link %s to %s left out", item.title, item.target)
+			case isPage(item.target):
+				name := path.Clean(strings.TrimPrefix(item.target, "/"))
+				addPage(p.order, name)
+				if item.title != "" {
+					titles[name] = item.title
+				}
+			}
+		}
+	}
+	visit(nav)
+
+	listed := make(map[string]bool)
+	for dir, names := range p.order {
+		for _, name := range names {
+			listed[path.Join(dir, name)] = true
+		}
+	}
+	p.convert = func(name, content string, result *Result) string {
+		fm, body := splitFrontmatter(content)
+		fm.remove(mkdocsDroppedKeys...)
+		if fm.get("title") == "" {
+			if title := titles[name]; title != "" {
+				fm.set("title", title)
+			} else if title := firstHeading(body); title != "" {
+				fm.set("title", title)
+			}
+		}
+		// MkDocs builds pages left out of an explicit nav without linking
+		// them, as gomdoc does with hidden pages.
+		if len(nav) > 0 && !listed[name] {
+			fm.set("nav", "false")
+		}
+		return fm.join(convertMkDocsAdmonitions(body))
+	}
+	if _, err := fs.Stat(os.DirFS(p.docs), "."); err != nil {
+		return project{}, err
+	}
+	return p, nil
+}
+
+// convertMkDocsAdmonitions turns MkDocs admonitions, whose content is
+// indented by four spaces, into GitHub-style alerts.
+func convertMkDocsAdmonitions(body string) string {
+	lines := strings.Split(body, "\n")
+	var out []string
+	var f fence
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		if f.inside(line) {
+			out = append(out, line)
+			continue
+		}
+		match := mkdocsAdmonition.FindStringSubmatch(line)
+		if match == nil {
+			out = append(out, line)
+			continue
+		}
+		indent := match[1]
+		inner := indent + "    "
+		var content []string
+		for i+1 < len(lines) {
+			next := lines[i+1]
+			if strings.TrimSpace(next) == "" {
+				// A blank line belongs to the admonition only when it
+				// continues below.
+				j := i + 1
+				for j < len(lines) && strings.TrimSpace(lines[j]) == "" {
+					j++
+				}
+				if j == len(lines) || !strings.HasPrefix(expandTabs(lines[j]), inner) {
+					break
+				}
+				content = append(content, "")
+				i++
+				continue
+			}
+			next = expandTabs(next)
+			if !strings.HasPrefix(next, inner) {
+				break
+			}
+			content = append(content, next[len(inner):])
+			i++
+		}
+		// Without a quoted title MkDocs shows the type as the title.
+		title := match[3]
+		if !strings.Contains(line, `"`) {
+			title = strings.ToUpper(match[2][:1]) + match[2][1:]
+		}
+		content = strings.Split(convertMkDocsAdmonitions(strings.Join(content, "\n")), "\n")
+		out = append(out, alert(indent, match[2], defaultTitle(title, match[2]), content)...)
+	}
+	return strings.Join(out, "\n")
+}
+
+// defaultTitle returns title unless it only repeats the name of the alert
+// the admonition type becomes, which the alert already shows.
+func defaultTitle(title, kind string) string {
+	if strings.EqualFold(title, kind) && strings.EqualFold(alertTypes[strings.ToLower(kind)], kind) {
+		return ""
+	}
+	return title
+}
+
+// expandTabs replaces a leading tab with four spaces, as MkDocs does.
+func expandTabs(line string) string {
+	trimmed := strings.TrimLeft(line, "\t")
+	return strings.Repeat("    ", len(line)-len(trimmed)) + trimmed
+}
+
+// topLevelValue returns the value of a top-level key of a YAML file.
+func topLevelValue(config, key string) string {
+	for _, line := range strings.Split(config, "\n") {
+		if value, found := strings.CutPrefix(line, key+":"); found {
+			return strings.Trim(strings.TrimSpace(value), `"'`)
+		}
+	}
+	return ""
+}
+
+// parseNav reads the nav list of an mkdocs.yml.
+func parseNav(config string) []navItem {
+	var lines []string
+	inNav := false
+	for _, line := range strings.Split(strings.ReplaceAll(config, "\r\n", "\n"), "\n") {
+		trimmed := strings.TrimSpace(line)
+		if !inNav {
+			inNav = strings.TrimRight(line, " ") == "nav:"
+			continue
+		}
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		if line[0] != ' ' && line[0] != '-' {
+			break
+		}
+		lines = append(lines, line)
+	}
+	i := 0
+	return parseNavItems(lines, &i)
+}
+
+// parseNavItems parses the list items starting at lines[*i] that share its
+// indentation, with their nested sections.
+func parseNavItems(lines []string, i *int) []navItem {
+	if *i >= len(lines) {
+		return nil
+	}
+	indent := indentation(lines[*i])
+	var items []navItem
+	for *i < len(lines) {
+		line := lines[*i]
+		if indentation(line) < indent {
+			break
+		}
+		text, isItem := strings.CutPrefix(strings.TrimSpace(line), "- ")
+		if indentation(line) > indent || !isItem {
+			*i++
+			continue
+		}
+		*i++
+		item := parseNavEntry(text)
+		if item.target == "" && *i < len(lines) && indentation(lines[*i]) > indent {
+			item.children = parseNavItems(lines, i)
+		}
+		items = append(items, item)
+	}
+	return items
+}
+
+// parseNavEntry parses "Title: target", "Title:", or "target".
+func parseNavEntry(text string) navItem {
+	text = strings.TrimSpace(text)
+	var title, rest string
+	if text != "" && (text[0] == '"' || text[0] == '\'') {
+		end := strings.IndexByte(text[1:], text[0])
+		if end < 0 {
+			return navItem{target: strings.Trim(text, `"'`)}
+		}
+		title, rest = text[1:end+1], strings.TrimSpace(text[end+2:])
+		after, found := strings.CutPrefix(rest, ":")
+		if !found {
+			return navItem{target: title}
+		}
+		return navItem{title: title, target: strings.Trim(strings.TrimSpace(after), `"'`)}
+	}
+	if strings.HasSuffix(text, ":") {
+		return navItem{title: strings.TrimSuffix(text, ":")}
+	}
+	if i := strings.Index(text, ": "); i >= 0 {
+		return navItem{title: text[:i], target: strings.Trim(strings.TrimSpace(text[i+2:]), `"'`)}
+	}
+	return navItem{target: strings.Trim(text, `"'`)}
+}
+
+// indentation returns the number of leading spaces of line.
+func indentation(line string) int {
+	return len(line) - len(strings.TrimLeft(line, " "))
+}
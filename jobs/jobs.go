@@ -0,0 +1,212 @@
+// Package jobs runs background work, such as rebuilding the search
+// indexes, on a bounded pool of workers, and keeps the state of recent jobs
+// for the admin page.
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// State is where a job is in its life.
+type State string
+
+const (
+	// Queued jobs wait for a free worker.
+	Queued State = "queued"
+	// Running jobs are being worked on.
+	Running State = "running"
+	// Done jobs finished without an error.
+	Done State = "done"
+	// Failed jobs returned an error or panicked.
+	Failed State = "failed"
+)
+
+// queueSize is the number of jobs that may wait for a worker. Submit
+// refuses more.
+const queueSize = 64
+
+// historySize is the number of finished jobs kept for Jobs.
+const historySize = 50
+
+// Status describes a job.
+type Status struct {
+	// ID numbers the jobs in the order they were submitted.
+	ID int
+	// Name says what the job does, e.g. "search index".
+	Name string
+	// State is where the job is in its life.
+	State State
+	// Queued, Started, and Finished are when the job was submitted, picked
+	// up by a worker, and completed. The last two are zero until then.
+	Queued   time.Time
+	Started  time.Time
+	Finished time.Time
+	// Error is the reason a failed job failed.
+	Error string
+}
+
+// Duration returns how long the job ran, or has been running so far.
+func (s Status) Duration() time.Duration {
+	switch {
+	case s.Started.IsZero():
+		return 0
+	case s.Finished.IsZero():
+		return time.Since(s.Started)
+	}
+	return s.Finished.Sub(s.Started)
+}
+
+// job is a submitted job with its work.
+type job struct {
+	status Status
+	run    func(ctx context.Context) error
+}
+
+// Pool runs jobs on a fixed number of workers.
+type Pool struct {
+	mu      sync.Mutex
+	queue   chan *job
+	jobs    []*job
+	nextID  int
+	closed  bool
+	ctx     context.Context
+	cancel  context.CancelFunc
+	workers sync.WaitGroup
+}
+
+// New starts a pool of workers goroutines, at least one.
+func New(workers int) *Pool {
+	ctx, cancel := context.WithCancel(context.Background())
+	p := &Pool{queue: make(chan *job, queueSize), ctx: ctx, cancel: cancel}
+	for range max(workers, 1) {
+		p.workers.Add(1)
+		go p.work()
+	}
+	return p
+}
+
+// Submit queues run under name. A job of the same name that is still
+// waiting already covers the work, so run is dropped; so it is when the
+// queue is full or the pool drains. Submit reports whether run was queued.
+func (p *Pool) Submit(name string, run func(ctx context.Context) error) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return false
+	}
+	for _, j := range p.jobs {
+		if j.status.Name == name && j.status.State == Queued {
+			return false
+		}
+	}
+	p.nextID++
+	j := &job{status: Status{ID: p.nextID, Name: name, State: Queued, Queued: time.Now()}, run: run}
+	select {
+	case p.queue <- j:
+	default:
+		log.Printf("Warning: job queue full, dropping %s", name)
+		return false
+	}
+	p.jobs = append(p.jobs, j)
+	return true
+}
+
+// Jobs returns the waiting, running, and recently finished jobs, newest
+// first.
+func (p *Pool) Jobs() []Status {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	statuses := make([]Status, 0, len(p.jobs))
+	for i := len(p.jobs) - 1; i >= 0; i-- {
+		statuses = append(statuses, p.jobs[i].status)
+	}
+	return statuses
+}
+
+// Drain stops accepting jobs and waits for the queued and running ones to
+// finish. When ctx ends first, the running jobs are canceled through their
+// context and Drain returns the context's error.
+func (p *Pool) Drain(ctx context.Context) error {
+	p.mu.Lock()
+	if !p.closed {
+		p.closed = true
+		close(p.queue)
+	}
+	p.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		p.workers.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		p.cancel()
+		return nil
+	case <-ctx.Done():
+		p.cancel()
+		return ctx.Err()
+	}
+}
+
+// work runs queued jobs until the queue is closed.
+func (p *Pool) work() {
+	defer p.workers.Done()
+	for j := range p.queue {
+		p.update(j, func(s *Status) {
+			s.State = Running
+			s.Started = time.Now()
+		})
+		err := p.run(j)
+		p.update(j, func(s *Status) {
+			s.Finished = time.Now()
+			s.State = Done
+			if err != nil {
+				s.State = Failed
+				s.Error = err.Error()
+			}
+		})
+		if err != nil {
+			log.Printf("Warning: %s: %v", j.status.Name, err)
+		}
+	}
+}
+
+// run runs a job, turning a panic into an error so one broken job cannot
+// stop a worker.
+func (p *Pool) run(j *job) (err error) {
+	defer func() {
+		if v := recover(); v != nil {
+			err = fmt.Errorf("panic: %v", v)
+		}
+	}()
+	return j.run(p.ctx)
+}
+
+// update changes the status of j and forgets the oldest finished jobs
+// beyond the history size.
+func (p *Pool) update(j *job, change func(*Status)) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	change(&j.status)
+
+	finished := 0
+	for _, j := range p.jobs {
+		if j.status.State == Done || j.status.State == Failed {
+			finished++
+		}
+	}
+	kept := p.jobs[:0]
+	for _, j := range p.jobs {
+		if finished > historySize && (j.status.State == Done || j.status.State == Failed) {
+			finished--
+			continue
+		}
+		kept = append(kept, j)
+	}
+	p.jobs = kept
+}
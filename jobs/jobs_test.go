@@ -0,0 +1,92 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestPool(t *testing.T) {
+	p := New(1)
+	release := make(chan struct{})
+	ran := make(chan string, 4)
+
+	p.Submit("slow", func(ctx context.Context) error {
+		<-release
+		ran <- "slow"
+		return nil
+	})
+	for !running(p, "slow") {
+		time.Sleep(time.Millisecond)
+	}
+	if !p.Submit("index", func(ctx context.Context) error { ran <- "index"; return nil }) {
+		t.Fatal("expected the index job to be queued")
+	}
+	if p.Submit("index", func(ctx context.Context) error { ran <- "again"; return nil }) {
+		t.Error("expected a second index job to be covered by the queued one")
+	}
+	p.Submit("broken", func(ctx context.Context) error { return errors.New("no such bucket") })
+	p.Submit("panics", func(ctx context.Context) error { panic("bad page") })
+	close(release)
+
+	if err := p.Drain(context.Background()); err != nil {
+		t.Fatalf("Drain failed: %v", err)
+	}
+	close(ran)
+	var order []string
+	for name := range ran {
+		order = append(order, name)
+	}
+	if len(order) != 2 || order[0] != "slow" || order[1] != "index" {
+		t.Errorf("expected slow then index to run, got %v", order)
+	}
+
+	jobs := p.Jobs()
+	if len(jobs) != 4 || jobs[0].Name != "panics" || jobs[3].Name != "slow" {
+		t.Fatalf("expected the four jobs newest first, got %+v", jobs)
+	}
+	if jobs[0].State != Failed || jobs[0].Error != "panic: bad page" {
+		t.Errorf("expected the panic recorded as a failure, got %+v", jobs[0])
+	}
+	if jobs[1].State != Failed || jobs[1].Error != "no such bucket" {
+		t.Errorf("expected the error recorded, got %+v", jobs[1])
+	}
+	if jobs[3].State != Done || jobs[3].Duration() <= 0 {
+		t.Errorf("expected the slow job done with a duration, got %+v", jobs[3])
+	}
+	if p.Submit("late", func(ctx context.Context) error { return nil }) {
+		t.Error("expected no jobs accepted after Drain")
+	}
+}
+
+func TestDrainTimeout(t *testing.T) {
+	p := New(1)
+	canceled := make(chan struct{})
+	p.Submit("stuck", func(ctx context.Context) error {
+		<-ctx.Done()
+		close(canceled)
+		return ctx.Err()
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := p.Drain(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the deadline error, got %v", err)
+	}
+	select {
+	case <-canceled:
+	case <-time.After(time.Second):
+		t.Error("expected the running job to be canceled")
+	}
+}
+
+// running reports whether the job called name is running.
+func running(p *Pool, name string) bool {
+	for _, job := range p.Jobs() {
+		if job.Name == name && job.State == Running {
+			return true
+		}
+	}
+	return false
+}
@@ -3,114 +3,183 @@
 package main
 
 import (
-	"crypto/rand"
-	"encoding/hex"
 	"flag"
 	"fmt"
+	"io"
 	"log"
 	"os"
-	"path/filepath"
+	"runtime/debug"
 	"strings"
 
 	"gomdoc/server"
 )
 
-// version is set at build time via -ldflags.
-var version = "dev"
+// Build details, set at build time via -ldflags, e.g.
+// -X main.version=v2.3.0 -X main.commit=abc1234 -X main.date=2024-01-31T12:00:00Z
+var (
+	version = "dev"
+	commit  = ""
+	date    = ""
+)
 
-func main() {
-	port := flag.Int("port", 7331, "Port to run the server on")
-	dir := flag.String("dir", ".", "Base directory to serve markdown files from")
-	title := flag.String("title", "gomdoc", "Custom title for the documentation site")
-	auth := flag.String("auth", "", "Basic auth credentials in user:password format")
-	oauth2ClientID := flag.String("oauth2-client-id", "", "OAuth2 client ID")
-	oauth2ClientSecret := flag.String("oauth2-client-secret", "", "OAuth2 client secret")
-	oauth2AuthURL := flag.String("oauth2-auth-url", "", "OAuth2 authorization endpoint URL")
-	oauth2TokenURL := flag.String("oauth2-token-url", "", "OAuth2 token endpoint URL")
-	oauth2RedirectURL := flag.String("oauth2-redirect-url", "", "OAuth2 callback redirect URL")
-	oauth2UserInfoURL := flag.String("oauth2-userinfo-url", "", "OAuth2 userinfo endpoint URL")
-	oauth2Scopes := flag.String("oauth2-scopes", "", "OAuth2 scopes, comma-separated")
-	oauth2AllowedEmails := flag.String("oauth2-allowed-emails", "", "Allowed OAuth2 email addresses, comma-separated")
-	oauth2AllowedDomains := flag.String("oauth2-allowed-domains", "", "Allowed OAuth2 email domains, comma-separated")
-	oauth2CookieSecret := flag.String("oauth2-cookie-secret", "", "Secret used to sign OAuth2 session cookies")
-	mcpToken := flag.String("mcp-token", "", "Bearer token for MCP server authentication (auto-generated if empty)")
-	mcpNoAuth := flag.Bool("mcp-no-auth", false, "Disable MCP server authentication entirely")
-	showVersion := flag.Bool("version", false, "Print version and exit")
-	flag.Parse()
-
-	if *showVersion {
-		fmt.Println(version)
-		return
-	}
-
-	// Validate auth format if provided
-	var authUser, authPass string
-	if *auth != "" {
-		parts := strings.SplitN(*auth, ":", 2)
-		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
-			log.Fatalf("Invalid auth format. Use: -auth user:password")
-		}
-		authUser = parts[0]
-		authPass = parts[1]
-	}
+// command is a gomdoc subcommand with its own flag set.
+type command struct {
+	name    string
+	summary string
+	run     func(args []string)
+}
 
-	oauth2Config := server.OAuth2Config{
-		ClientID:       envFallback(*oauth2ClientID, "GOMDOC_OAUTH2_CLIENT_ID"),
-		ClientSecret:   envFallback(*oauth2ClientSecret, "GOMDOC_OAUTH2_CLIENT_SECRET"),
-		AuthURL:        envFallback(*oauth2AuthURL, "GOMDOC_OAUTH2_AUTH_URL"),
-		TokenURL:       envFallback(*oauth2TokenURL, "GOMDOC_OAUTH2_TOKEN_URL"),
-		RedirectURL:    envFallback(*oauth2RedirectURL, "GOMDOC_OAUTH2_REDIRECT_URL"),
-		UserInfoURL:    envFallback(*oauth2UserInfoURL, "GOMDOC_OAUTH2_USERINFO_URL"),
-		Scopes:         splitCSV(envFallback(*oauth2Scopes, "GOMDOC_OAUTH2_SCOPES")),
-		AllowedEmails:  splitCSV(envFallback(*oauth2AllowedEmails, "GOMDOC_OAUTH2_ALLOWED_EMAILS")),
-		AllowedDomains: splitCSV(envFallback(*oauth2AllowedDomains, "GOMDOC_OAUTH2_ALLOWED_DOMAINS")),
-		CookieSecret:   envFallback(*oauth2CookieSecret, "GOMDOC_OAUTH2_COOKIE_SECRET"),
+// commands lists the subcommands in help order. It is filled in init
+// because the help command refers back to it.
+var commands []command
+
+func init() {
+	commands = []command{
+		{"serve", "Serve the documentation over HTTP (default when no command is given)", runServe},
+		{"export", "Render the documentation to static HTML files", runExport},
+		{"check", "Report broken internal links", runCheck},
+		{"lint", "Report authoring mistakes such as missing titles", runLint},
+		{"snapshot", "Archive the sources with content hashes and the sitemap", runSnapshot},
+		{"import", "Convert a Docusaurus or MkDocs site, or HTML pages, into a gomdoc tree", runImport},
+		{"index", "Build the search index and list the indexed documents", runIndex},
+		{"bench", "Render every page and report the slowest to render", runBench},
+		{"report", "Print a report: owners lists pages per owner and overdue reviews", runReport},
+		{"hash-password", "Print the bcrypt hash of a password read from standard input, for -auth", runHashPassword},
+		{"config", "Check the site configuration: validate reports mistakes, print shows the settings in effect", runConfig},
+		{"version", "Print version and exit", runVersion},
+		{"service", "Manage the Windows service: install, uninstall, start, stop", runServiceCommand},
+		{"help", "Show this help", runHelp},
 	}
-	if err := server.ValidateOAuth2Config(oauth2Config, authUser != ""); err != nil {
-		log.Fatalf("Invalid OAuth2 config: %v", err)
+}
+
+func main() {
+	name, args := "serve", os.Args[1:]
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		name, args = args[0], args[1:]
 	}
 
-	// Resolve and validate the base directory
-	baseDir, err := filepath.Abs(*dir)
-	if err != nil {
-		log.Fatalf("Error resolving directory path: %v", err)
+	cmd, ok := findCommand(name)
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Unknown command %q\n\n", name)
+		printCommands(os.Stderr)
+		os.Exit(2)
 	}
+	cmd.run(args)
+}
 
-	info, err := os.Stat(baseDir)
-	if err != nil {
-		log.Fatalf("Error accessing directory: %v", err)
+// findCommand looks up a subcommand by name.
+func findCommand(name string) (command, bool) {
+	for _, cmd := range commands {
+		if cmd.name == name {
+			return cmd, true
+		}
 	}
-	if !info.IsDir() {
-		log.Fatalf("Path is not a directory: %s", baseDir)
+	return command{}, false
+}
+
+// printCommands writes the list of subcommands.
+func printCommands(w io.Writer) {
+	fmt.Fprintln(w, "Usage: gomdoc [command] [flags]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %-9s %s\n", cmd.name, cmd.summary)
 	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Run 'gomdoc <command> -h' for the flags of a command.")
+}
 
-	// Resolve MCP token: use provided, generate, or disable
-	resolvedMCPToken := *mcpToken
-	if !*mcpNoAuth && resolvedMCPToken == "" {
-		tokenBytes := make([]byte, 32)
-		if _, err := rand.Read(tokenBytes); err != nil {
-			log.Fatalf("Failed to generate MCP token: %v", err)
+// newFlagSet creates the flag set of a subcommand with a usage message
+// naming the command and its purpose.
+func newFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	fs.Usage = func() {
+		cmd, _ := findCommand(name)
+		out := fs.Output()
+		fmt.Fprintf(out, "Usage: gomdoc %s [flags]\n\n%s\n\nFlags:\n", name, cmd.summary)
+		fs.PrintDefaults()
+		if name == "serve" {
+			fmt.Fprintln(out)
+			printCommands(out)
 		}
-		resolvedMCPToken = hex.EncodeToString(tokenBytes)
-	}
-	if *mcpNoAuth {
-		resolvedMCPToken = ""
 	}
+	return fs
+}
 
-	fmt.Println("gomdoc - Markdown Documentation Server")
-	fmt.Println("=======================================")
+// runVersion prints the version.
+func runVersion(args []string) {
+	newFlagSet("version").Parse(args)
+	fmt.Println(versionString())
+}
 
-	srv := server.NewWithAuth(baseDir, *port, *title, authUser, authPass, oauth2Config, resolvedMCPToken, version)
-	if err := srv.Start(); err != nil {
-		log.Fatalf("Server error: %v", err)
+// buildDetails returns the build details. Commit and date fall back to the
+// VCS information Go embeds when they are not set via -ldflags.
+func buildDetails() server.BuildInfo {
+	info := server.BuildInfo{Version: version, Commit: commit, Date: date}
+	buildInfo, ok := debug.ReadBuildInfo()
+	if !ok {
+		return info
 	}
+	for _, setting := range buildInfo.Settings {
+		if setting.Key == "vcs.revision" && info.Commit == "" {
+			info.Commit = setting.Value
+		}
+		if setting.Key == "vcs.time" && info.Date == "" {
+			info.Date = setting.Value
+		}
+	}
+	return info
+}
+
+// versionString formats the version with commit and build date when known.
+func versionString() string {
+	info := buildDetails()
+	if info.Commit == "" {
+		return info.Version
+	}
+	details := "commit " + info.Commit
+	if info.Date != "" {
+		details += ", built " + info.Date
+	}
+	return fmt.Sprintf("%s (%s)", info.Version, details)
+}
+
+// runHelp prints the list of subcommands.
+func runHelp(args []string) {
+	printCommands(os.Stdout)
 }
 
+// envFallback returns value, or when it is empty the environment
+// variable key, or the content of the file named by key_FILE, as with
+// Docker secrets, so that secrets stay out of process listings and shell
+// history. It exits when both variables are set or the file cannot be
+// read.
 func envFallback(value, key string) string {
 	if value != "" {
 		return value
 	}
-	return os.Getenv(key)
+	file := os.Getenv(key + "_FILE")
+	if file == "" {
+		return os.Getenv(key)
+	}
+	if os.Getenv(key) != "" {
+		log.Fatalf("Both %s and %s_FILE are set; use one of them", key, key)
+	}
+	secret, err := readSecretFile(file)
+	if err != nil {
+		log.Fatalf("Error reading %s_FILE: %v", key, err)
+	}
+	return secret
+}
+
+// readSecretFile returns the content of a file holding a secret, such as
+// a Docker secret, without its trailing line break.
+func readSecretFile(name string) (string, error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
 }
 
 func splitCSV(value string) []string {
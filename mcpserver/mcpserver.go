@@ -8,14 +8,17 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"io/fs"
 	"log/slog"
 	"net/http"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 
+	"gomdoc/clock"
 	"gomdoc/renderer"
 	"gomdoc/scanner"
 	"gomdoc/search"
@@ -24,15 +27,29 @@ import (
 // Server wraps the MCP server with access to the documentation directory.
 type Server struct {
 	baseDir string
-	index   *search.Index
-	mcp     *mcp.Server
+	// files holds the documentation when it does not come from baseDir,
+	// e.g. from object storage.
+	files fs.FS
+	index *search.Index
+	mcp   *mcp.Server
 }
 
 // New creates a new MCP server for the given documentation directory.
 // The version parameter is displayed in MCP server info responses.
 func New(baseDir, version string) *Server {
+	return newServer(baseDir, nil, version)
+}
+
+// NewFS creates a new MCP server for the documentation in fsys.
+func NewFS(fsys fs.FS, version string) *Server {
+	return newServer("", fsys, version)
+}
+
+// newServer creates an MCP server reading from baseDir or, when set, fsys.
+func newServer(baseDir string, fsys fs.FS, version string) *Server {
 	s := &Server{
 		baseDir: baseDir,
+		files:   fsys,
 		index:   search.NewIndex(),
 	}
 
@@ -51,9 +68,35 @@ func New(baseDir, version string) *Server {
 
 // BuildIndex builds the search index from the documentation directory.
 func (s *Server) BuildIndex() error {
+	if s.files != nil {
+		return s.index.BuildFS(s.files)
+	}
 	return s.index.Build(s.baseDir)
 }
 
+// fsys returns the documentation files.
+func (s *Server) fsys() fs.FS {
+	if s.files != nil {
+		return s.files
+	}
+	return os.DirFS(s.baseDir)
+}
+
+// docName turns a document path given by a client, such as /guide/setup,
+// into a name within the documentation files. Cleaning it as an absolute
+// path keeps it from escaping the documentation root.
+func docName(docPath string) string {
+	return strings.TrimPrefix(path.Clean("/"+filepath.ToSlash(docPath)), "/")
+}
+
+// live reports whether the document with the given name is inside its
+// publish_at and expire_at window, like the pages the web UI serves.
+// Documents missing from the index count as published.
+func (s *Server) live(name string) bool {
+	schedule, ok := s.index.Schedule("/" + name)
+	return !ok || schedule.Live(clock.Now())
+}
+
 // SSEHandler returns an http.Handler that serves the MCP protocol over SSE.
 // Mount this on your HTTP server to expose MCP alongside the web UI.
 func (s *Server) SSEHandler() http.Handler {
@@ -236,12 +279,16 @@ The server indexes all .md files at startup and serves MCP over SSE.
 
 // handleListDocuments returns a list of all available documents.
 func (s *Server) handleListDocuments(_ context.Context, _ *mcp.CallToolRequest, args listArgs) (*mcp.CallToolResult, any, error) {
-	scanDir := s.baseDir
-	if args.Path != "" {
-		scanDir = filepath.Join(s.baseDir, filepath.Clean(args.Path))
+	files := s.fsys()
+	if dir := docName(args.Path); dir != "" {
+		sub, err := fs.Sub(files, dir)
+		if err != nil {
+			return nil, nil, fmt.Errorf("scanning directory: %w", err)
+		}
+		files = sub
 	}
 
-	entries, err := scanner.ScanDirectory(scanDir)
+	entries, err := scanner.ScanFS(files, scanner.ScanOptions{})
 	if err != nil {
 		return nil, nil, fmt.Errorf("scanning directory: %w", err)
 	}
@@ -252,6 +299,9 @@ func (s *Server) handleListDocuments(_ context.Context, _ *mcp.CallToolRequest,
 		if args.Path != "" {
 			urlPath = args.Path + "/" + urlPath
 		}
+		if !s.live(docName(urlPath)) {
+			continue
+		}
 		lines = append(lines, fmt.Sprintf("- %s (path: %s)", entry.Name, filepath.ToSlash(urlPath)))
 	}
 
@@ -268,14 +318,14 @@ func (s *Server) handleReadDocument(_ context.Context, _ *mcp.CallToolRequest, a
 		return textResult("Error: path is required."), nil, nil
 	}
 
-	cleanPath := filepath.Clean(args.Path)
-	filePath := filepath.Join(s.baseDir, cleanPath+".md")
-
-	content, err := os.ReadFile(filePath)
+	name := docName(args.Path)
+	if !s.live(name) {
+		return textResult(fmt.Sprintf("Document not found: %s", args.Path)), nil, nil
+	}
+	content, err := fs.ReadFile(s.fsys(), name+".md")
 	if err != nil {
 		// Try uppercase extension
-		filePath = filepath.Join(s.baseDir, cleanPath+".MD")
-		content, err = os.ReadFile(filePath)
+		content, err = fs.ReadFile(s.fsys(), name+".MD")
 		if err != nil {
 			return textResult(fmt.Sprintf("Document not found: %s", args.Path)), nil, nil
 		}
@@ -388,6 +438,9 @@ func formatMetadata(meta search.Metadata) string {
 	if meta.Date != "" {
 		parts = append(parts, fmt.Sprintf("date: %s", meta.Date))
 	}
+	if meta.Updated != "" {
+		parts = append(parts, fmt.Sprintf("updated: %s", meta.Updated))
+	}
 	if len(meta.Tags) > 0 {
 		parts = append(parts, fmt.Sprintf("tags: [%s]", strings.Join(meta.Tags, ", ")))
 	}
@@ -444,6 +497,9 @@ func renderFrontmatterHeader(fm renderer.Frontmatter) string {
 	if fm.Date != "" {
 		fields = append(fields, fmt.Sprintf("date: %s", fm.Date))
 	}
+	if fm.Updated != "" {
+		fields = append(fields, fmt.Sprintf("updated: %s", fm.Updated))
+	}
 	if len(fm.Tags) > 0 {
 		fields = append(fields, fmt.Sprintf("tags: [%s]", strings.Join(fm.Tags, ", ")))
 	}
@@ -6,9 +6,11 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 
+	"gomdoc/clock"
 	"gomdoc/search"
 )
 
@@ -402,3 +404,48 @@ func TestHandleReadSectionMissingArgs(t *testing.T) {
 		t.Errorf("expected 'required' error, got: %s", text)
 	}
 }
+
+func TestHandleDocumentsOutsideSchedule(t *testing.T) {
+	clock.Set(time.Date(2026, 3, 1, 12, 0, 0, 0, time.Local))
+	t.Cleanup(func() { clock.Set(time.Time{}) })
+
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "news"), 0o755)
+	os.WriteFile(filepath.Join(dir, "hello.md"), []byte("# Hello\nPublished page."), 0o644)
+	os.WriteFile(filepath.Join(dir, "news", "launch.md"), []byte("---\npublish_at: 2026-04-01\n---\n# Launch\nNot yet announced."), 0o644)
+	os.WriteFile(filepath.Join(dir, "news", "sale.md"), []byte("---\nexpire_at: 2026-02-01\n---\n# Sale\nAlready over."), 0o644)
+	idx := search.NewIndex()
+	idx.Build(dir)
+	s := &Server{baseDir: dir, index: idx}
+	ctx := context.Background()
+
+	for _, args := range []listArgs{{}, {Path: "news"}} {
+		result, _, err := s.handleListDocuments(ctx, nil, args)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		text := result.Content[0].(*mcp.TextContent).Text
+		if strings.Contains(text, "launch") || strings.Contains(text, "sale") {
+			t.Errorf("list of %q shows pages outside their schedule: %s", args.Path, text)
+		}
+	}
+
+	for _, path := range []string{"news/launch", "news/sale"} {
+		result, _, err := s.handleReadDocument(ctx, nil, readArgs{Path: path})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		text := result.Content[0].(*mcp.TextContent).Text
+		if !strings.Contains(text, "not found") {
+			t.Errorf("expected %s to be not found, got: %s", path, text)
+		}
+	}
+
+	result, _, err := s.handleReadDocument(ctx, nil, readArgs{Path: "hello"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if text := result.Content[0].(*mcp.TextContent).Text; !strings.Contains(text, "Published page") {
+		t.Errorf("expected published page content, got: %s", text)
+	}
+}
@@ -0,0 +1,258 @@
+// Package mdns advertises the server on the local network via multicast
+// DNS service discovery (Bonjour/zeroconf), so teammates can find a locally
+// running instance without sharing IP addresses.
+package mdns
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+const (
+	// serviceType is the DNS-SD service type for web servers.
+	serviceType = "_http._tcp.local."
+	// servicesMeta lists all service types when browsers enumerate them.
+	servicesMeta = "_services._dns-sd._udp.local."
+	// recordTTL is how long resolvers may cache the records.
+	recordTTL = 120
+	// maxLabelLength is the DNS limit for a single name label.
+	maxLabelLength = 63
+	// announceDelay is the pause between the two startup announcements, as
+	// recommended by RFC 6762 so a single lost packet does not hide the service.
+	announceDelay = time.Second
+)
+
+// groupAddr is the IPv4 mDNS multicast group.
+var groupAddr = &net.UDPAddr{IP: net.IPv4(224, 0, 0, 251), Port: 5353}
+
+// Service describes the advertised HTTP service.
+type Service struct {
+	// Instance is the human-readable name shown in browsers, e.g. the site title.
+	Instance string
+	// Port is the TCP port the server listens on.
+	Port int
+	// TXT holds key=value attributes, e.g. "path=/".
+	TXT []string
+}
+
+// Advertiser answers mDNS queries for a service until closed.
+type Advertiser struct {
+	conn     *net.UDPConn
+	instance dnsmessage.Name
+	host     dnsmessage.Name
+	service  Service
+	addrs    []net.IP
+	once     sync.Once
+	done     chan struct{}
+}
+
+// Advertise joins the mDNS multicast group, announces the service, and
+// answers queries in the background.
+func Advertise(svc Service) (*Advertiser, error) {
+	a, err := newAdvertiser(svc, localHostname(), localIPv4Addrs())
+	if err != nil {
+		return nil, err
+	}
+	conn, err := net.ListenMulticastUDP("udp4", nil, groupAddr)
+	if err != nil {
+		return nil, fmt.Errorf("join mDNS group: %w", err)
+	}
+	a.conn = conn
+
+	go a.announce()
+	go a.serve()
+	return a, nil
+}
+
+// newAdvertiser prepares the DNS names and records for a service.
+func newAdvertiser(svc Service, hostname string, addrs []net.IP) (*Advertiser, error) {
+	instance, err := dnsmessage.NewName(instanceLabel(svc.Instance) + "." + serviceType)
+	if err != nil {
+		return nil, fmt.Errorf("mDNS instance name: %w", err)
+	}
+	host, err := dnsmessage.NewName(hostname + ".local.")
+	if err != nil {
+		return nil, fmt.Errorf("mDNS host name: %w", err)
+	}
+	return &Advertiser{instance: instance, host: host, service: svc, addrs: addrs, done: make(chan struct{})}, nil
+}
+
+// announce sends the unsolicited startup announcements.
+func (a *Advertiser) announce() {
+	a.send(a.records(recordTTL))
+	select {
+	case <-time.After(announceDelay):
+		a.send(a.records(recordTTL))
+	case <-a.done:
+	}
+}
+
+// Close sends a goodbye announcement so browsers drop the service at once,
+// then stops answering queries.
+func (a *Advertiser) Close() error {
+	var err error
+	a.once.Do(func() {
+		close(a.done)
+		a.send(a.records(0))
+		err = a.conn.Close()
+	})
+	return err
+}
+
+// serve answers queries until the connection is closed.
+func (a *Advertiser) serve() {
+	buf := make([]byte, 9000)
+	for {
+		n, _, err := a.conn.ReadFromUDP(buf)
+		if err != nil {
+			return
+		}
+		if answers := a.answer(buf[:n]); len(answers) > 0 {
+			a.send(answers)
+		}
+	}
+}
+
+// answer returns the records answering the questions in a query packet.
+func (a *Advertiser) answer(packet []byte) []dnsmessage.Resource {
+	var msg dnsmessage.Message
+	if err := msg.Unpack(packet); err != nil || msg.Header.Response {
+		return nil
+	}
+	var answers []dnsmessage.Resource
+	for _, q := range msg.Questions {
+		answers = append(answers, a.answersFor(q)...)
+	}
+	return answers
+}
+
+// answersFor returns the records matching a single question.
+func (a *Advertiser) answersFor(q dnsmessage.Question) []dnsmessage.Resource {
+	name := strings.ToLower(q.Name.String())
+	wants := func(t dnsmessage.Type) bool { return q.Type == t || q.Type == dnsmessage.TypeALL }
+
+	switch {
+	case name == serviceType && wants(dnsmessage.TypePTR):
+		return a.records(recordTTL)
+	case name == servicesMeta && wants(dnsmessage.TypePTR):
+		return []dnsmessage.Resource{a.metaRecord()}
+	case name == strings.ToLower(a.instance.String()):
+		return a.records(recordTTL)[1:]
+	case name == strings.ToLower(a.host.String()) && wants(dnsmessage.TypeA):
+		return a.addressRecords(recordTTL)
+	}
+	return nil
+}
+
+// send multicasts a response carrying the given records.
+func (a *Advertiser) send(answers []dnsmessage.Resource) {
+	msg := dnsmessage.Message{
+		Header:  dnsmessage.Header{Response: true, Authoritative: true},
+		Answers: answers,
+	}
+	packet, err := msg.Pack()
+	if err != nil {
+		log.Printf("Warning: failed to pack mDNS response: %v", err)
+		return
+	}
+	if _, err := a.conn.WriteToUDP(packet, groupAddr); err != nil {
+		log.Printf("Warning: failed to send mDNS response: %v", err)
+	}
+}
+
+// records returns the full record set: PTR, SRV, TXT, and A records.
+func (a *Advertiser) records(ttl uint32) []dnsmessage.Resource {
+	serviceName := dnsmessage.MustNewName(serviceType)
+	records := []dnsmessage.Resource{
+		{
+			Header: dnsmessage.ResourceHeader{Name: serviceName, Type: dnsmessage.TypePTR, Class: dnsmessage.ClassINET, TTL: ttl},
+			Body:   &dnsmessage.PTRResource{PTR: a.instance},
+		},
+		{
+			Header: dnsmessage.ResourceHeader{Name: a.instance, Type: dnsmessage.TypeSRV, Class: dnsmessage.ClassINET, TTL: ttl},
+			Body:   &dnsmessage.SRVResource{Port: uint16(a.service.Port), Target: a.host},
+		},
+		{
+			Header: dnsmessage.ResourceHeader{Name: a.instance, Type: dnsmessage.TypeTXT, Class: dnsmessage.ClassINET, TTL: ttl},
+			Body:   &dnsmessage.TXTResource{TXT: a.txt()},
+		},
+	}
+	return append(records, a.addressRecords(ttl)...)
+}
+
+// metaRecord announces the service type for service enumeration.
+func (a *Advertiser) metaRecord() dnsmessage.Resource {
+	return dnsmessage.Resource{
+		Header: dnsmessage.ResourceHeader{Name: dnsmessage.MustNewName(servicesMeta), Type: dnsmessage.TypePTR, Class: dnsmessage.ClassINET, TTL: recordTTL},
+		Body:   &dnsmessage.PTRResource{PTR: dnsmessage.MustNewName(serviceType)},
+	}
+}
+
+// addressRecords returns an A record for every local IPv4 address.
+func (a *Advertiser) addressRecords(ttl uint32) []dnsmessage.Resource {
+	var records []dnsmessage.Resource
+	for _, ip := range a.addrs {
+		var addr [4]byte
+		copy(addr[:], ip.To4())
+		records = append(records, dnsmessage.Resource{
+			Header: dnsmessage.ResourceHeader{Name: a.host, Type: dnsmessage.TypeA, Class: dnsmessage.ClassINET, TTL: ttl},
+			Body:   &dnsmessage.AResource{A: addr},
+		})
+	}
+	return records
+}
+
+// txt returns the TXT strings; DNS-SD requires at least one, possibly empty.
+func (a *Advertiser) txt() []string {
+	if len(a.service.TXT) == 0 {
+		return []string{""}
+	}
+	return a.service.TXT
+}
+
+// instanceLabel turns a title into a single DNS label: dots would split it
+// into several labels, and labels are limited to 63 bytes.
+func instanceLabel(title string) string {
+	label := strings.TrimSpace(strings.ReplaceAll(title, ".", " "))
+	if label == "" {
+		label = "gomdoc"
+	}
+	for len(label) > maxLabelLength {
+		runes := []rune(label)
+		label = string(runes[:len(runes)-1])
+	}
+	return label
+}
+
+// localHostname returns the machine name without any domain suffix.
+func localHostname() string {
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		return "gomdoc"
+	}
+	name, _, _ := strings.Cut(hostname, ".")
+	return name
+}
+
+// localIPv4Addrs lists the non-loopback IPv4 addresses of this machine.
+func localIPv4Addrs() []net.IP {
+	addrs, err := net.InterfaceAddrs()
+	if err != nil {
+		return nil
+	}
+	var ips []net.IP
+	for _, addr := range addrs {
+		ipNet, ok := addr.(*net.IPNet)
+		if ok && !ipNet.IP.IsLoopback() && ipNet.IP.To4() != nil {
+			ips = append(ips, ipNet.IP.To4())
+		}
+	}
+	return ips
+}
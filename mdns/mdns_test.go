@@ -0,0 +1,106 @@
+package mdns
+
+import (
+	"net"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+func testAdvertiser(t *testing.T) *Advertiser {
+	t.Helper()
+	a, err := newAdvertiser(Service{Instance: "Team Docs", Port: 7331, TXT: []string{"path=/"}},
+		"workstation", []net.IP{net.IPv4(192, 168, 1, 20)})
+	if err != nil {
+		t.Fatalf("newAdvertiser: %v", err)
+	}
+	return a
+}
+
+func query(t *testing.T, name string, qtype dnsmessage.Type) []byte {
+	t.Helper()
+	msg := dnsmessage.Message{
+		Questions: []dnsmessage.Question{{Name: dnsmessage.MustNewName(name), Type: qtype, Class: dnsmessage.ClassINET}},
+	}
+	packet, err := msg.Pack()
+	if err != nil {
+		t.Fatalf("Pack: %v", err)
+	}
+	return packet
+}
+
+func TestAnswerServiceBrowse(t *testing.T) {
+	a := testAdvertiser(t)
+
+	answers := a.answer(query(t, "_http._tcp.local.", dnsmessage.TypePTR))
+	if len(answers) != 4 {
+		t.Fatalf("got %d answers, want PTR, SRV, TXT, and A", len(answers))
+	}
+
+	ptr, ok := answers[0].Body.(*dnsmessage.PTRResource)
+	if !ok || ptr.PTR.String() != "Team Docs._http._tcp.local." {
+		t.Errorf("PTR = %v, want Team Docs._http._tcp.local.", answers[0].Body)
+	}
+	srv, ok := answers[1].Body.(*dnsmessage.SRVResource)
+	if !ok || srv.Port != 7331 || srv.Target.String() != "workstation.local." {
+		t.Errorf("SRV = %v, want port 7331 on workstation.local.", answers[1].Body)
+	}
+	txt, ok := answers[2].Body.(*dnsmessage.TXTResource)
+	if !ok || len(txt.TXT) != 1 || txt.TXT[0] != "path=/" {
+		t.Errorf("TXT = %v, want path=/", answers[2].Body)
+	}
+	addr, ok := answers[3].Body.(*dnsmessage.AResource)
+	if !ok || addr.A != [4]byte{192, 168, 1, 20} {
+		t.Errorf("A = %v, want 192.168.1.20", answers[3].Body)
+	}
+}
+
+func TestAnswerIgnoresOtherQueries(t *testing.T) {
+	a := testAdvertiser(t)
+
+	if answers := a.answer(query(t, "_ipp._tcp.local.", dnsmessage.TypePTR)); len(answers) != 0 {
+		t.Errorf("unrelated query got %d answers", len(answers))
+	}
+
+	response := dnsmessage.Message{Header: dnsmessage.Header{Response: true}, Answers: a.records(recordTTL)}
+	packet, err := response.Pack()
+	if err != nil {
+		t.Fatalf("Pack: %v", err)
+	}
+	if answers := a.answer(packet); len(answers) != 0 {
+		t.Errorf("response packet got %d answers", len(answers))
+	}
+}
+
+func TestAnswerHostAndEnumeration(t *testing.T) {
+	a := testAdvertiser(t)
+
+	if answers := a.answer(query(t, "WORKSTATION.local.", dnsmessage.TypeA)); len(answers) != 1 {
+		t.Errorf("host query got %d answers, want 1", len(answers))
+	}
+
+	answers := a.answer(query(t, "_services._dns-sd._udp.local.", dnsmessage.TypePTR))
+	if len(answers) != 1 {
+		t.Fatalf("enumeration got %d answers, want 1", len(answers))
+	}
+	if ptr := answers[0].Body.(*dnsmessage.PTRResource); ptr.PTR.String() != serviceType {
+		t.Errorf("enumeration PTR = %s, want %s", ptr.PTR, serviceType)
+	}
+}
+
+func TestInstanceLabel(t *testing.T) {
+	tests := []struct {
+		title, want string
+	}{
+		{"Team Docs", "Team Docs"},
+		{"docs.example.com", "docs example com"},
+		{"  ", "gomdoc"},
+		{strings.Repeat("ä", 40), strings.Repeat("ä", 31)},
+	}
+	for _, tt := range tests {
+		if got := instanceLabel(tt.title); got != tt.want {
+			t.Errorf("instanceLabel(%q) = %q, want %q", tt.title, got, tt.want)
+		}
+	}
+}
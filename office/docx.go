@@ -0,0 +1,198 @@
+package office
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// headingStyle matches the style IDs of Word headings, such as Heading1.
+var headingStyle = regexp.MustCompile(`(?i)^heading\s*([1-6])$`)
+
+// docxWriter turns the body of a Word document into HTML paragraphs,
+// headings, bulleted lists, and tables.
+type docxWriter struct {
+	out    strings.Builder
+	inList bool
+
+	para  *docxParagraph
+	run   *docxRun
+	inRun bool
+}
+
+// docxParagraph is the paragraph being read.
+type docxParagraph struct {
+	style string
+	list  bool
+	text  strings.Builder
+}
+
+// docxRun is the run of equally formatted text being read.
+type docxRun struct {
+	bold, italic bool
+	text         strings.Builder
+}
+
+// docxToHTML converts word/document.xml to HTML.
+func docxToHTML(archive *zip.Reader) (string, error) {
+	data, err := readPart(archive, "word/document.xml")
+	if err != nil {
+		return "", err
+	}
+	if data == nil {
+		return "", fmt.Errorf("not a Word document: word/document.xml is missing")
+	}
+
+	w := &docxWriter{}
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", fmt.Errorf("word/document.xml: %w", err)
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			if err := w.start(decoder, t); err != nil {
+				return "", err
+			}
+		case xml.EndElement:
+			w.end(t)
+		}
+	}
+	w.closeList()
+	return w.out.String(), nil
+}
+
+// start handles an opening element.
+func (w *docxWriter) start(decoder *xml.Decoder, el xml.StartElement) error {
+	switch el.Name.Local {
+	case "p":
+		w.para = &docxParagraph{}
+	case "pStyle":
+		if w.para != nil {
+			w.para.style = attr(el, "val")
+		}
+	case "numPr":
+		if w.para != nil {
+			w.para.list = true
+		}
+	case "r":
+		w.run = &docxRun{}
+		w.inRun = true
+	case "b", "i":
+		if w.inRun && w.run != nil && attr(el, "val") != "0" && attr(el, "val") != "false" {
+			w.run.bold = w.run.bold || el.Name.Local == "b"
+			w.run.italic = w.run.italic || el.Name.Local == "i"
+		}
+	case "t":
+		var text string
+		if err := decoder.DecodeElement(&text, &el); err != nil {
+			return fmt.Errorf("word/document.xml: %w", err)
+		}
+		if w.run != nil {
+			w.run.text.WriteString(escape(text))
+		}
+	case "tab":
+		if w.run != nil {
+			w.run.text.WriteString(" ")
+		}
+	case "br", "cr":
+		if w.run != nil {
+			w.run.text.WriteString("<br>")
+		}
+	case "tbl":
+		w.closeList()
+		w.out.WriteString("<table>\n")
+	case "tr":
+		w.out.WriteString("<tr>")
+	case "tc":
+		w.out.WriteString("<td>")
+	}
+	return nil
+}
+
+// end handles a closing element.
+func (w *docxWriter) end(el xml.EndElement) {
+	switch el.Name.Local {
+	case "r":
+		w.endRun()
+	case "p":
+		w.endParagraph()
+	case "tbl":
+		w.out.WriteString("</table>\n")
+	case "tr":
+		w.out.WriteString("</tr>\n")
+	case "tc":
+		w.out.WriteString("</td>")
+	}
+}
+
+// endRun appends the finished run to its paragraph.
+func (w *docxWriter) endRun() {
+	w.inRun = false
+	if w.run == nil || w.para == nil {
+		return
+	}
+	text := w.run.text.String()
+	if w.run.italic && text != "" {
+		text = "<em>" + text + "</em>"
+	}
+	if w.run.bold && text != "" {
+		text = "<strong>" + text + "</strong>"
+	}
+	w.para.text.WriteString(text)
+	w.run = nil
+}
+
+// endParagraph writes the finished paragraph as a heading, list item, or
+// plain paragraph. Empty paragraphs, used for spacing in Word, are dropped.
+func (w *docxWriter) endParagraph() {
+	para := w.para
+	w.para = nil
+	if para == nil || strings.TrimSpace(para.text.String()) == "" {
+		return
+	}
+
+	if para.list {
+		if !w.inList {
+			w.out.WriteString("<ul>\n")
+			w.inList = true
+		}
+		w.out.WriteString("<li>" + para.text.String() + "</li>\n")
+		return
+	}
+	w.closeList()
+
+	tag := "p"
+	if m := headingStyle.FindStringSubmatch(para.style); m != nil {
+		tag = "h" + m[1]
+	} else if strings.EqualFold(para.style, "Title") {
+		tag = "h1"
+	}
+	w.out.WriteString("<" + tag + ">" + para.text.String() + "</" + tag + ">\n")
+}
+
+// closeList ends an open bulleted list.
+func (w *docxWriter) closeList() {
+	if w.inList {
+		w.out.WriteString("</ul>\n")
+		w.inList = false
+	}
+}
+
+// attr returns the value of the attribute with the given local name.
+func attr(el xml.StartElement, name string) string {
+	for _, a := range el.Attr {
+		if a.Name.Local == name {
+			return a.Value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,106 @@
+// Package office converts office documents to HTML previews, so legacy
+// Word and Excel files mixed into a documentation tree can be read in the
+// browser. DOCX and XLSX are converted in pure Go; other formats need an
+// external converter command.
+package office
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"fmt"
+	"html"
+	"io"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// maxPartBytes caps how much of a single XML part is read, so a
+// compressed archive cannot expand without bound.
+const maxPartBytes = 64 << 20
+
+// Supported reports whether name can be converted without an external
+// tool.
+func Supported(name string) bool {
+	switch strings.ToLower(path.Ext(name)) {
+	case ".docx", ".xlsx":
+		return true
+	}
+	return false
+}
+
+// ToHTML converts a DOCX or XLSX document to an HTML fragment. The text is
+// escaped, so the result is safe to embed in a page.
+func ToHTML(name string, data []byte) (string, error) {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", name, err)
+	}
+	switch strings.ToLower(path.Ext(name)) {
+	case ".docx":
+		return docxToHTML(archive)
+	case ".xlsx":
+		return xlsxToHTML(archive)
+	}
+	return "", fmt.Errorf("%s: unsupported document type", name)
+}
+
+// Convert runs an external converter on a document and returns its
+// standard output as HTML. command is split into words; {file} is
+// replaced by the path of a temporary copy of the document, e.g.
+// "pandoc {file} -t html". The output is trusted as it is.
+func Convert(ctx context.Context, command, name string, data []byte) (string, error) {
+	args := strings.Fields(command)
+	if len(args) == 0 {
+		return "", fmt.Errorf("no converter command")
+	}
+
+	dir, err := os.MkdirTemp("", "gomdoc-office-")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "document"+strings.ToLower(path.Ext(name)))
+	if err := os.WriteFile(file, data, 0o600); err != nil {
+		return "", err
+	}
+
+	for i, arg := range args {
+		args[i] = strings.ReplaceAll(arg, "{file}", file)
+	}
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Dir = dir
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("%s: %w: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.String(), nil
+}
+
+// readPart returns the content of a file in the archive, or nil when it
+// is missing.
+func readPart(archive *zip.Reader, name string) ([]byte, error) {
+	f, err := archive.Open(name)
+	if err != nil {
+		return nil, nil
+	}
+	defer f.Close()
+	data, err := io.ReadAll(io.LimitReader(f, maxPartBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	if len(data) > maxPartBytes {
+		return nil, fmt.Errorf("%s: larger than %d bytes", name, maxPartBytes)
+	}
+	return data, nil
+}
+
+// escape escapes text for HTML.
+func escape(text string) string {
+	return html.EscapeString(text)
+}
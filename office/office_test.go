@@ -0,0 +1,106 @@
+package office
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+// archive builds a zip file from part names and contents.
+func archive(t *testing.T, parts map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range parts {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(content))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+const wordNS = `xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"`
+
+func TestDOCXToHTML(t *testing.T) {
+	data := archive(t, map[string]string{"word/document.xml": `<?xml version="1.0"?>
+<w:document ` + wordNS + `><w:body>
+<w:p><w:pPr><w:pStyle w:val="Heading1"/></w:pPr><w:r><w:t>Release &lt;Plan&gt;</w:t></w:r></w:p>
+<w:p><w:r><w:t xml:space="preserve">Ship </w:t></w:r><w:r><w:rPr><w:b/></w:rPr><w:t>on time</w:t></w:r><w:r><w:rPr><w:i w:val="0"/></w:rPr><w:t>.</w:t></w:r></w:p>
+<w:p></w:p>
+<w:p><w:pPr><w:numPr><w:ilvl w:val="0"/></w:numPr></w:pPr><w:r><w:t>First</w:t></w:r></w:p>
+<w:p><w:pPr><w:numPr><w:ilvl w:val="0"/></w:numPr></w:pPr><w:r><w:t>Second</w:t></w:r></w:p>
+<w:tbl><w:tr><w:tc><w:p><w:r><w:t>Cell</w:t></w:r></w:p></w:tc></w:tr></w:tbl>
+</w:body></w:document>`})
+
+	got, err := ToHTML("plan.docx", data)
+	if err != nil {
+		t.Fatalf("ToHTML failed: %v", err)
+	}
+	want := "<h1>Release &lt;Plan&gt;</h1>\n" +
+		"<p>Ship <strong>on time</strong>.</p>\n" +
+		"<ul>\n<li>First</li>\n<li>Second</li>\n</ul>\n" +
+		"<table>\n<tr><td><p>Cell</p>\n</td></tr>\n</table>\n"
+	if got != want {
+		t.Errorf("unexpected HTML:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestXLSXToHTML(t *testing.T) {
+	data := archive(t, map[string]string{
+		"xl/workbook.xml": `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+<sheets><sheet name="Budget &amp; Costs" sheetId="1" r:id="rId1"/></sheets></workbook>`,
+		"xl/_rels/workbook.xml.rels": `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="worksheet" Target="worksheets/data.xml"/></Relationships>`,
+		"xl/sharedStrings.xml": `<sst xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+<si><t>Item</t></si><si><r><t>Rich </t></r><r><t>&lt;text&gt;</t></r></si></sst>`,
+		"xl/worksheets/data.xml": `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>
+<row r="1"><c r="A1" t="s"><v>0</v></c><c r="C1" t="s"><v>1</v></c></row>
+<row r="2"><c r="A2"><v>42.5</v></c><c r="B2" t="b"><v>1</v></c><c r="C2" t="inlineStr"><is><t>inline</t></is></c></row>
+</sheetData></worksheet>`,
+	})
+
+	got, err := ToHTML("budget.XLSX", data)
+	if err != nil {
+		t.Fatalf("ToHTML failed: %v", err)
+	}
+	want := "<h2>Budget &amp; Costs</h2>\n<table>\n" +
+		"<tr><td>Item</td><td></td><td>Rich &lt;text&gt;</td></tr>\n" +
+		"<tr><td>42.5</td><td>TRUE</td><td>inline</td></tr>\n" +
+		"</table>\n"
+	if got != want {
+		t.Errorf("unexpected HTML:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestToHTMLRejectsOtherFiles(t *testing.T) {
+	if _, err := ToHTML("notes.docx", []byte("not a zip")); err == nil {
+		t.Error("expected error for a file that is no archive")
+	}
+	if _, err := ToHTML("empty.docx", archive(t, map[string]string{"other.xml": "<x/>"})); err == nil {
+		t.Error("expected error for an archive without a document")
+	}
+	if Supported("slides.pptx") || !Supported("Report.DOCX") {
+		t.Error("unexpected Supported result")
+	}
+}
+
+func TestConvert(t *testing.T) {
+	if _, err := exec.LookPath("cat"); err != nil {
+		t.Skip("cat not available")
+	}
+	got, err := Convert(context.Background(), "cat {file}", "slides.pptx", []byte("<p>converted</p>"))
+	if err != nil {
+		t.Fatalf("Convert failed: %v", err)
+	}
+	if strings.TrimSpace(got) != "<p>converted</p>" {
+		t.Errorf("expected converter output, got %q", got)
+	}
+}
@@ -0,0 +1,181 @@
+package office
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// maxSheetRows caps the rows shown per worksheet; larger sheets are
+// truncated with a note, since the preview is for reading, not analysis.
+const maxSheetRows = 1000
+
+// workbook lists the worksheets of an XLSX file.
+type workbook struct {
+	Sheets []struct {
+		Name string `xml:"name,attr"`
+		ID   string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+	} `xml:"sheets>sheet"`
+}
+
+// relationships maps relationship IDs to the parts they point to.
+type relationships struct {
+	Relationships []struct {
+		ID     string `xml:"Id,attr"`
+		Target string `xml:"Target,attr"`
+	} `xml:"Relationship"`
+}
+
+// sharedStrings holds the strings cells refer to by index.
+type sharedStrings struct {
+	Items []stringItem `xml:"si"`
+}
+
+// stringItem is a plain or rich text string.
+type stringItem struct {
+	Text string `xml:"t"`
+	Runs []struct {
+		Text string `xml:"t"`
+	} `xml:"r"`
+}
+
+// worksheet holds the rows of a sheet.
+type worksheet struct {
+	Rows []struct {
+		Cells []struct {
+			Ref    string     `xml:"r,attr"`
+			Type   string     `xml:"t,attr"`
+			Value  string     `xml:"v"`
+			Inline stringItem `xml:"is"`
+		} `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+// String returns the text of the item, joining rich text runs.
+func (item stringItem) String() string {
+	if len(item.Runs) == 0 {
+		return item.Text
+	}
+	var sb strings.Builder
+	for _, run := range item.Runs {
+		sb.WriteString(run.Text)
+	}
+	return sb.String()
+}
+
+// xlsxToHTML converts every worksheet to a table headed by its name.
+func xlsxToHTML(archive *zip.Reader) (string, error) {
+	var book workbook
+	if err := decodePart(archive, "xl/workbook.xml", &book); err != nil {
+		return "", err
+	}
+	if len(book.Sheets) == 0 {
+		return "", fmt.Errorf("not an Excel workbook: no worksheets")
+	}
+	var rels relationships
+	if err := decodePart(archive, "xl/_rels/workbook.xml.rels", &rels); err != nil {
+		return "", err
+	}
+	var shared sharedStrings
+	if err := decodePart(archive, "xl/sharedStrings.xml", &shared); err != nil {
+		return "", err
+	}
+
+	targets := make(map[string]string)
+	for _, rel := range rels.Relationships {
+		targets[rel.ID] = path.Join("xl", rel.Target)
+		if strings.HasPrefix(rel.Target, "/") {
+			targets[rel.ID] = strings.TrimPrefix(rel.Target, "/")
+		}
+	}
+
+	var sb strings.Builder
+	for i, sheet := range book.Sheets {
+		part, ok := targets[sheet.ID]
+		if !ok {
+			part = fmt.Sprintf("xl/worksheets/sheet%d.xml", i+1)
+		}
+		var ws worksheet
+		if err := decodePart(archive, part, &ws); err != nil {
+			return "", err
+		}
+		sb.WriteString("<h2>" + escape(sheet.Name) + "</h2>\n")
+		writeSheet(&sb, ws, shared)
+	}
+	return sb.String(), nil
+}
+
+// writeSheet renders the rows of a worksheet as a table, keeping empty
+// cells so columns stay aligned.
+func writeSheet(sb *strings.Builder, ws worksheet, shared sharedStrings) {
+	sb.WriteString("<table>\n")
+	for i, row := range ws.Rows {
+		if i == maxSheetRows {
+			break
+		}
+		sb.WriteString("<tr>")
+		column := 0
+		for _, cell := range row.Cells {
+			if index := columnIndex(cell.Ref); index > column {
+				sb.WriteString(strings.Repeat("<td></td>", index-column))
+				column = index
+			}
+			sb.WriteString("<td>" + escape(cellText(cell.Type, cell.Value, cell.Inline, shared)) + "</td>")
+			column++
+		}
+		sb.WriteString("</tr>\n")
+	}
+	sb.WriteString("</table>\n")
+	if len(ws.Rows) > maxSheetRows {
+		fmt.Fprintf(sb, "<p><em>Showing the first %d of %d rows.</em></p>\n", maxSheetRows, len(ws.Rows))
+	}
+}
+
+// cellText returns the displayed text of a cell of the given type.
+func cellText(cellType, value string, inline stringItem, shared sharedStrings) string {
+	switch cellType {
+	case "s":
+		index, err := strconv.Atoi(value)
+		if err != nil || index < 0 || index >= len(shared.Items) {
+			return ""
+		}
+		return shared.Items[index].String()
+	case "inlineStr":
+		return inline.String()
+	case "b":
+		if value == "1" {
+			return "TRUE"
+		}
+		return "FALSE"
+	}
+	return value
+}
+
+// columnIndex returns the zero-based column of a cell reference such as
+// C7, or -1 when the reference is missing.
+func columnIndex(ref string) int {
+	column := 0
+	for _, c := range ref {
+		if c < 'A' || c > 'Z' {
+			break
+		}
+		column = column*26 + int(c-'A'+1)
+	}
+	return column - 1
+}
+
+// decodePart unmarshals an XML part of the archive. Missing parts leave v
+// unchanged.
+func decodePart(archive *zip.Reader, name string, v any) error {
+	data, err := readPart(archive, name)
+	if err != nil || data == nil {
+		return err
+	}
+	if err := xml.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package renderer
+
+import (
+	"bytes"
+	"regexp"
+
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/text"
+)
+
+// pinnedIDPattern matches a {#id} attribute list at the end of a heading
+// line, possibly among classes and other attributes.
+var pinnedIDPattern = regexp.MustCompile(`\{[^{}]*#([^\s{}.#]+)[^{}]*\}\s*#*\s*$`)
+
+// HeadingAnchor is a heading of a document and the ID deep links use.
+type HeadingAnchor struct {
+	// ID is the id attribute of the heading.
+	ID string
+	// Text is the heading text.
+	Text string
+	// Line is the line of the heading in the markdown, starting at 1.
+	Line int
+	// Pinned is set when the ID is given with a {#id} attribute rather
+	// than generated from the text, so renaming the heading keeps it.
+	Pinned bool
+}
+
+// HeadingAnchors returns the headings of markdown content, without
+// frontmatter, with the IDs they get when rendered, in document order.
+func (r *Renderer) HeadingAnchors(content []byte) []HeadingAnchor {
+	doc := r.md.Parser().Parse(text.NewReader(content))
+	var anchors []HeadingAnchor
+	_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		heading, ok := n.(*ast.Heading)
+		if !entering || !ok {
+			return ast.WalkContinue, nil
+		}
+		id, _ := heading.AttributeString("id")
+		idBytes, _ := id.([]byte)
+		if len(idBytes) == 0 || heading.Lines().Len() == 0 {
+			return ast.WalkSkipChildren, nil
+		}
+		start := heading.Lines().At(0).Start
+		lineStart := bytes.LastIndexByte(content[:start], '\n') + 1
+		lineEnd := bytes.IndexByte(content[start:], '\n')
+		if lineEnd < 0 {
+			lineEnd = len(content)
+		} else {
+			lineEnd += start
+		}
+		pinned := false
+		if match := pinnedIDPattern.FindSubmatch(content[lineStart:lineEnd]); match != nil {
+			pinned = string(match[1]) == string(idBytes)
+		}
+		anchors = append(anchors, HeadingAnchor{
+			ID:     string(idBytes),
+			Text:   string(bytes.TrimSpace(heading.Lines().Value(content))),
+			Line:   bytes.Count(content[:start], []byte("\n")) + 1,
+			Pinned: pinned,
+		})
+		return ast.WalkSkipChildren, nil
+	})
+	return anchors
+}
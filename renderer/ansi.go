@@ -0,0 +1,222 @@
+package renderer
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ansiLanguage is the fence language of terminal output with ANSI escape
+// codes.
+const ansiLanguage = "ansi"
+
+// escapeSequence matches a CSI sequence such as ESC[1;31m or an OSC
+// sequence such as a terminal title or hyperlink.
+var escapeSequence = regexp.MustCompile(`\x1b\[([0-9;?]*)([@-~])|\x1b\][^\x07\x1b]*(?:\x07|\x1b\\)`)
+
+// writtenEscape matches the escape character spelled out as text, as in
+// output pasted from scripts: \e, \033, \x1b, \u001b, or ^[.
+var writtenEscape = regexp.MustCompile(`\\e\[|\\033\[|\\x1[bB]\[|\\u001[bB]\[|\^\[\[`)
+
+// ansiColors are the names of the eight basic colors, in SGR order.
+var ansiColors = []string{"black", "red", "green", "yellow", "blue", "magenta", "cyan", "white"}
+
+// isANSI reports whether a code block is rendered as terminal output: it
+// is labeled ansi or contains escape codes.
+func isANSI(lang string, code []byte) bool {
+	return strings.EqualFold(lang, ansiLanguage) || bytes.Contains(code, []byte("\x1b["))
+}
+
+// ansiStyle is the text style set by SGR escape codes.
+type ansiStyle struct {
+	bold, dim, italic, underline bool
+	fg, bg                       string // class suffix, e.g. red or bright-blue
+	fgRGB, bgRGB                 string // CSS color of 256-color and true color codes
+}
+
+// open returns the span starting text in the style, or "" for plain text.
+func (s ansiStyle) open() string {
+	var classes, styles []string
+	for _, flag := range []struct {
+		set  bool
+		name string
+	}{{s.bold, "bold"}, {s.dim, "dim"}, {s.italic, "italic"}, {s.underline, "underline"}} {
+		if flag.set {
+			classes = append(classes, "ansi-"+flag.name)
+		}
+	}
+	if s.fg != "" {
+		classes = append(classes, "ansi-fg-"+s.fg)
+	}
+	if s.bg != "" {
+		classes = append(classes, "ansi-bg-"+s.bg)
+	}
+	if s.fgRGB != "" {
+		styles = append(styles, "color:"+s.fgRGB)
+	}
+	if s.bgRGB != "" {
+		styles = append(styles, "background-color:"+s.bgRGB)
+	}
+	if len(classes) == 0 && len(styles) == 0 {
+		return ""
+	}
+	span := "<span"
+	if len(classes) > 0 {
+		span += ` class="` + strings.Join(classes, " ") + `"`
+	}
+	if len(styles) > 0 {
+		span += ` style="` + strings.Join(styles, ";") + `"`
+	}
+	return span + ">"
+}
+
+// apply updates the style with the parameters of an SGR sequence.
+func (s *ansiStyle) apply(params string) {
+	codes := strings.Split(params, ";")
+	for i := 0; i < len(codes); i++ {
+		code, _ := strconv.Atoi(codes[i]) // empty means 0
+		switch {
+		case code == 0:
+			*s = ansiStyle{}
+		case code == 1:
+			s.bold = true
+		case code == 2:
+			s.dim = true
+		case code == 3:
+			s.italic = true
+		case code == 4:
+			s.underline = true
+		case code == 22:
+			s.bold, s.dim = false, false
+		case code == 23:
+			s.italic = false
+		case code == 24:
+			s.underline = false
+		case 30 <= code && code <= 37:
+			s.fg, s.fgRGB = ansiColors[code-30], ""
+		case 90 <= code && code <= 97:
+			s.fg, s.fgRGB = "bright-"+ansiColors[code-90], ""
+		case 40 <= code && code <= 47:
+			s.bg, s.bgRGB = ansiColors[code-40], ""
+		case 100 <= code && code <= 107:
+			s.bg, s.bgRGB = "bright-"+ansiColors[code-100], ""
+		case code == 39:
+			s.fg, s.fgRGB = "", ""
+		case code == 49:
+			s.bg, s.bgRGB = "", ""
+		case code == 38 || code == 48:
+			color, used := extendedColor(codes[i+1:])
+			i += used
+			if color == "" {
+				continue
+			}
+			if code == 38 {
+				s.fg, s.fgRGB = "", color
+			} else {
+				s.bg, s.bgRGB = "", color
+			}
+		}
+	}
+}
+
+// extendedColor reads a 256-color (5;n) or true color (2;r;g;b) argument
+// and returns its CSS color and the number of parameters used.
+func extendedColor(args []string) (string, int) {
+	if len(args) == 0 {
+		return "", 0
+	}
+	switch args[0] {
+	case "5":
+		if len(args) < 2 {
+			return "", len(args)
+		}
+		n, err := strconv.Atoi(args[1])
+		if err != nil || n < 0 || n > 255 {
+			return "", 2
+		}
+		return palette256(n), 2
+	case "2":
+		if len(args) < 4 {
+			return "", len(args)
+		}
+		var rgb [3]int
+		for i := range rgb {
+			v, err := strconv.Atoi(args[i+1])
+			if err != nil || v < 0 || v > 255 {
+				return "", 4
+			}
+			rgb[i] = v
+		}
+		return fmt.Sprintf("#%02x%02x%02x", rgb[0], rgb[1], rgb[2]), 4
+	}
+	return "", 1
+}
+
+// basicPalette are the xterm colors of the first 16 entries of the
+// 256-color palette.
+var basicPalette = []string{
+	"#000000", "#cd0000", "#00cd00", "#cdcd00", "#0000ee", "#cd00cd", "#00cdcd", "#e5e5e5",
+	"#7f7f7f", "#ff0000", "#00ff00", "#ffff00", "#5c5cff", "#ff00ff", "#00ffff", "#ffffff",
+}
+
+// palette256 returns the CSS color of entry n of the xterm 256-color
+// palette: 16 basic colors, a 6×6×6 color cube, and 24 grays.
+func palette256(n int) string {
+	if n < 16 {
+		return basicPalette[n]
+	}
+	if n >= 232 {
+		gray := 8 + (n-232)*10
+		return fmt.Sprintf("#%02x%02x%02x", gray, gray, gray)
+	}
+	n -= 16
+	level := func(v int) int {
+		if v == 0 {
+			return 0
+		}
+		return 55 + v*40
+	}
+	return fmt.Sprintf("#%02x%02x%02x", level(n/36), level(n/6%6), level(n%6))
+}
+
+// renderANSI writes terminal output as a code block, turning SGR color
+// and style codes into spans and dropping every other escape sequence.
+func renderANSI(code []byte) []byte {
+	text := writtenEscape.ReplaceAllFunc(code, func([]byte) []byte { return []byte("\x1b[") })
+
+	var out bytes.Buffer
+	out.WriteString(`<pre class="ansi"><code>`)
+	var style ansiStyle
+	open := false
+	last := 0
+	write := func(chunk []byte) {
+		if len(chunk) > 0 {
+			out.WriteString(html.EscapeString(strings.ReplaceAll(string(chunk), "\x1b", "")))
+		}
+	}
+	for _, m := range escapeSequence.FindAllSubmatchIndex(text, -1) {
+		write(text[last:m[0]])
+		last = m[1]
+		if m[4] < 0 || text[m[4]] != 'm' {
+			continue // cursor movement, title, or hyperlink
+		}
+		if open {
+			out.WriteString("</span>")
+			open = false
+		}
+		style.apply(string(text[m[2]:m[3]]))
+		if span := style.open(); span != "" {
+			out.WriteString(span)
+			open = true
+		}
+	}
+	write(text[last:])
+	if open {
+		out.WriteString("</span>")
+	}
+	out.WriteString("</code></pre>\n")
+	return out.Bytes()
+}
@@ -0,0 +1,195 @@
+package renderer
+
+import (
+	"bytes"
+	"strconv"
+
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+)
+
+// attributeTransformer applies {#id .class key=value} attribute lists that
+// goldmark only supports on headings:
+//
+//   - directly after an image or link: ![Logo](logo.png){.logo width=120}
+//   - on the last line of a paragraph: "Text\n{.lead}"
+//   - as a paragraph of its own after any block, e.g. a table or list
+type attributeTransformer struct{}
+
+// Transform implements parser.ASTTransformer.
+func (t *attributeTransformer) Transform(doc *ast.Document, reader text.Reader, _ parser.Context) {
+	source := reader.Source()
+	var texts []*ast.Text
+	var paragraphs []*ast.Paragraph
+	_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch node := n.(type) {
+		case *ast.Paragraph:
+			paragraphs = append(paragraphs, node)
+		case *ast.Text:
+			texts = append(texts, node)
+		}
+		return ast.WalkContinue, nil
+	})
+
+	// Nodes are handled after the walk because applying attributes removes
+	// the attribute lists from the tree.
+	for _, txt := range texts {
+		if txt.Parent() != nil {
+			applyInlineAttributes(txt, source)
+		}
+	}
+	for _, paragraph := range paragraphs {
+		applyBlockAttributes(paragraph, source)
+	}
+}
+
+// applyInlineAttributes moves an attribute list at the start of a text node
+// onto the image or link right before it.
+func applyInlineAttributes(txt *ast.Text, source []byte) {
+	prev := txt.PreviousSibling()
+	if prev == nil || (prev.Kind() != ast.KindImage && prev.Kind() != ast.KindLink) {
+		return
+	}
+	run := textRun(txt)
+	attrs, consumed := parseAttributeList(runValue(run, source))
+	if attrs == nil {
+		return
+	}
+	setAttributes(prev, attrs)
+	consumeText(run, consumed)
+}
+
+// applyBlockAttributes handles a paragraph that is an attribute list on its
+// own, applying it to the previous block, or that ends with one, applying
+// it to the paragraph itself.
+func applyBlockAttributes(paragraph *ast.Paragraph, source []byte) {
+	run := lastLine(paragraph)
+	if len(run) == 0 {
+		return
+	}
+	value := runValue(run, source)
+	attrs, consumed := parseAttributeList(value)
+	if attrs == nil || consumed != len(value) {
+		return
+	}
+
+	prevText, _ := run[0].PreviousSibling().(*ast.Text)
+	switch {
+	case run[0].PreviousSibling() == nil:
+		target := paragraph.PreviousSibling()
+		if target == nil {
+			return
+		}
+		setAttributes(target, attrs)
+		paragraph.Parent().RemoveChild(paragraph.Parent(), paragraph)
+	case prevText != nil:
+		setAttributes(paragraph, attrs)
+		prevText.SetSoftLineBreak(false)
+		prevText.SetHardLineBreak(false)
+		consumeText(run, consumed)
+	}
+}
+
+// textRun returns txt and the text nodes following it on the same line.
+// Goldmark splits text at characters that may start inline elements, so an
+// attribute list can span several nodes.
+func textRun(txt *ast.Text) []*ast.Text {
+	run := []*ast.Text{txt}
+	for !txt.SoftLineBreak() && !txt.HardLineBreak() {
+		next, ok := txt.NextSibling().(*ast.Text)
+		if !ok || next.Segment.Start != txt.Segment.Stop {
+			break
+		}
+		run = append(run, next)
+		txt = next
+	}
+	return run
+}
+
+// lastLine returns the text nodes forming the last line of a paragraph, or
+// nil when the line contains other inline elements.
+func lastLine(paragraph *ast.Paragraph) []*ast.Text {
+	var run []*ast.Text
+	for n := paragraph.LastChild(); n != nil; n = n.PreviousSibling() {
+		txt, ok := n.(*ast.Text)
+		if !ok {
+			return nil
+		}
+		if len(run) > 0 && (txt.SoftLineBreak() || txt.HardLineBreak()) {
+			break
+		}
+		if len(run) > 0 && txt.Segment.Stop != run[0].Segment.Start {
+			return nil
+		}
+		run = append([]*ast.Text{txt}, run...)
+	}
+	return run
+}
+
+// runValue returns the source text covered by a run of text nodes.
+func runValue(run []*ast.Text, source []byte) []byte {
+	return source[run[0].Segment.Start:run[len(run)-1].Segment.Stop]
+}
+
+// consumeText removes the first n bytes of a run of text nodes, dropping
+// nodes that become empty.
+func consumeText(run []*ast.Text, n int) {
+	end := run[0].Segment.Start + n
+	for _, txt := range run {
+		if txt.Segment.Start >= end {
+			return
+		}
+		if txt.Segment.Stop > end || txt.SoftLineBreak() || txt.HardLineBreak() {
+			txt.Segment = txt.Segment.WithStart(min(end, txt.Segment.Stop))
+			return
+		}
+		txt.Parent().RemoveChild(txt.Parent(), txt)
+	}
+}
+
+// parseAttributeList parses a non-empty attribute list at the start of
+// value, returning the attributes and the number of bytes consumed.
+func parseAttributeList(value []byte) (parser.Attributes, int) {
+	if !bytes.HasPrefix(value, []byte("{")) {
+		return nil, 0
+	}
+	reader := text.NewReader(value)
+	attrs, ok := parser.ParseAttributes(reader)
+	if !ok || len(attrs) == 0 {
+		return nil, 0
+	}
+	_, pos := reader.Position()
+	return attrs, pos.Start
+}
+
+// setAttributes sets attributes on a node, appending classes to any the
+// node already has.
+func setAttributes(n ast.Node, attrs parser.Attributes) {
+	for _, attr := range attrs {
+		if bytes.Equal(attr.Name, []byte("class")) {
+			if existing, ok := n.AttributeString("class"); ok {
+				if classes, ok := existing.([]byte); ok {
+					n.SetAttribute(attr.Name, append(append(append([]byte{}, classes...), ' '), attr.Value.([]byte)...))
+					continue
+				}
+			}
+		}
+		n.SetAttribute(attr.Name, attributeValue(attr.Value))
+	}
+}
+
+// attributeValue converts parsed numbers and booleans to the byte slices
+// the HTML renderer writes.
+func attributeValue(value any) any {
+	switch v := value.(type) {
+	case float64:
+		return []byte(strconv.FormatFloat(v, 'f', -1, 64))
+	case bool:
+		return []byte(strconv.FormatBool(v))
+	}
+	return value
+}
@@ -0,0 +1,55 @@
+package renderer
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAttributes(t *testing.T) {
+	r := New()
+	cases := []struct {
+		name, input, want string
+	}{
+		{"heading", "## Setup {#install .step}\n", `<h2 id="install" class="step">Setup</h2>`},
+		{"image", "![Logo](logo.png){.logo width=120}\n", `<img src="logo.png" alt="Logo" class="logo" width="120">`},
+		{"two images", "![A](a.png){.left} and ![B](b.png){.right}\n", `<img src="b.png" alt="B" class="right">`},
+		{"link", "[Docs](https://example.com){target=_blank} here\n", `<a href="https://example.com" target="_blank">Docs</a> here`},
+		{"paragraph last line", "Intro text.\n{.lead}\n", `<p class="lead">Intro text.</p>`},
+		{"block after table", "| a |\n|---|\n| 1 |\n\n{.wide-table}\n", `<table class="wide-table">`},
+		{"list", "- one\n- two\n\n{#steps}\n", `<ul id="steps">`},
+	}
+
+	for _, tc := range cases {
+		html, err := r.Render([]byte(tc.input))
+		if err != nil {
+			t.Fatalf("%s: render failed: %v", tc.name, err)
+		}
+		if !strings.Contains(string(html), tc.want) {
+			t.Errorf("%s: expected %q in:\n%s", tc.name, tc.want, html)
+		}
+		if strings.Contains(string(html), "{") {
+			t.Errorf("%s: expected the attribute list to be removed, got:\n%s", tc.name, html)
+		}
+	}
+}
+
+func TestAttributesKeepLiteralBraces(t *testing.T) {
+	html, err := New().Render([]byte("Use {} or { \"a\": 1 } in JSON.\n\n{}\n"))
+	if err != nil {
+		t.Fatalf("render failed: %v", err)
+	}
+	if !strings.Contains(string(html), `Use {} or { &quot;a&quot;: 1 } in JSON.`) || !strings.Contains(string(html), "<p>{}</p>") {
+		t.Errorf("expected literal braces to be kept, got:\n%s", html)
+	}
+}
+
+func TestAttributesMergeClasses(t *testing.T) {
+	r := NewWithOptions(Options{ExternalLinks: ExternalLinkOptions{Icon: true}})
+	html, err := r.Render([]byte("[Site](https://example.com){.cta}\n"))
+	if err != nil {
+		t.Fatalf("render failed: %v", err)
+	}
+	if !strings.Contains(string(html), `class="external-link cta"`) && !strings.Contains(string(html), `class="cta external-link"`) {
+		t.Errorf("expected both classes, got:\n%s", html)
+	}
+}
@@ -0,0 +1,198 @@
+package renderer
+
+import (
+	"bytes"
+	"path"
+	"strings"
+
+	"github.com/yuin/goldmark"
+	highlighting "github.com/yuin/goldmark-highlighting/v2"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+// CodeOptions controls syntax highlighting of fenced code blocks.
+type CodeOptions struct {
+	// Aliases maps fence languages to the language used for highlighting,
+	// e.g. shell → bash. Keys are matched case-insensitively.
+	Aliases map[string]string
+	// DefaultLanguage highlights fences without a language, e.g. text.
+	DefaultLanguage string
+	// MaxHighlightBytes renders larger code blocks without highlighting to
+	// keep render latency bounded. Zero highlights every block.
+	MaxHighlightBytes int
+}
+
+// language returns the language a fence labeled lang is highlighted as.
+func (o CodeOptions) language(lang string) string {
+	if lang == "" {
+		return o.DefaultLanguage
+	}
+	if alias, ok := o.Aliases[strings.ToLower(lang)]; ok {
+		return alias
+	}
+	return lang
+}
+
+// RenderFile renders a whole text or source file as one code block,
+// highlighted as the language of its extension, e.g. yaml for config.yaml.
+// Aliases and the highlighting size limit of CodeOptions apply.
+func (r *Renderer) RenderFile(name string, content []byte) ([]byte, error) {
+	lang := strings.TrimPrefix(strings.ToLower(path.Ext(name)), ".")
+	fence := "```"
+	for strings.Contains(string(content), fence) {
+		fence += "`"
+	}
+	var md bytes.Buffer
+	md.WriteString(fence + lang + "\n")
+	md.Write(content)
+	if len(content) > 0 && content[len(content)-1] != '\n' {
+		md.WriteByte('\n')
+	}
+	md.WriteString(fence + "\n")
+	return r.Render(md.Bytes())
+}
+
+// codeBlocks is a goldmark extension rendering fenced code blocks through
+// the highlighter after applying CodeOptions.
+type codeBlocks struct {
+	options CodeOptions
+}
+
+// Extend implements goldmark.Extender.
+func (e *codeBlocks) Extend(m goldmark.Markdown) {
+	m.Renderer().AddOptions(renderer.WithNodeRenderers(
+		util.Prioritized(newCodeBlockRenderer(e.options), 200),
+	))
+}
+
+// codeBlockRenderer wraps the highlighting renderer, rewriting the fence
+// language and skipping highlighting for oversized blocks.
+type codeBlockRenderer struct {
+	options     CodeOptions
+	highlighter renderer.NodeRenderer
+	highlight   renderer.NodeRendererFunc
+}
+
+// newCodeBlockRenderer creates a renderer highlighting with the monokai style.
+func newCodeBlockRenderer(opts CodeOptions) *codeBlockRenderer {
+	r := &codeBlockRenderer{
+		options: opts,
+		highlighter: highlighting.NewHTMLRenderer(
+			highlighting.WithStyle("monokai"),
+			highlighting.WithFormatOptions(),
+		),
+	}
+	r.highlighter.RegisterFuncs(funcCapture{kind: ast.KindFencedCodeBlock, fn: &r.highlight})
+	return r
+}
+
+// SetOption forwards renderer options such as html.WithUnsafe to the
+// highlighter.
+func (r *codeBlockRenderer) SetOption(name renderer.OptionName, value any) {
+	if setter, ok := r.highlighter.(renderer.SetOptioner); ok {
+		setter.SetOption(name, value)
+	}
+}
+
+// RegisterFuncs implements renderer.NodeRenderer.
+func (r *codeBlockRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(ast.KindFencedCodeBlock, r.renderFencedCodeBlock)
+}
+
+// renderFencedCodeBlock renders a fenced code block, handing it to the
+// highlighter under its resolved language unless it is too large. Terminal
+// output with ANSI escape codes is colored by the codes instead.
+func (r *codeBlockRenderer) renderFencedCodeBlock(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	n := node.(*ast.FencedCodeBlock)
+	if !entering {
+		return ast.WalkContinue, nil
+	}
+	lang := string(n.Language(source))
+	resolved := r.options.language(lang)
+
+	lines := n.Lines()
+	var code []byte
+	for i := 0; i < lines.Len(); i++ {
+		line := lines.At(i)
+		code = append(code, line.Value(source)...)
+	}
+	if isANSI(resolved, code) {
+		_, _ = w.Write(renderANSI(code))
+		return ast.WalkContinue, nil
+	}
+	size := len(code)
+	if r.options.MaxHighlightBytes > 0 && size > r.options.MaxHighlightBytes {
+		renderPlainCode(w, source, n, resolved)
+		return ast.WalkContinue, nil
+	}
+	if resolved == lang {
+		return r.highlight(w, source, node, entering)
+	}
+	block, blockSource := relabelFence(n, source, resolved)
+	return r.highlight(w, blockSource, block, entering)
+}
+
+// relabelFence copies a fenced code block with its language replaced. The
+// highlighter reads the language from the source, so the copy comes with a
+// source of its own: the new info string followed by the code.
+func relabelFence(n *ast.FencedCodeBlock, source []byte, lang string) (*ast.FencedCodeBlock, []byte) {
+	info := lang
+	if n.Info != nil {
+		// Keep anything after the language, such as {hl_lines=[2]}.
+		value := string(n.Info.Segment.Value(source))
+		if i := strings.IndexAny(value, " {"); i >= 0 {
+			info += value[i:]
+		}
+	}
+	buf := []byte(info + "\n")
+	block := ast.NewFencedCodeBlock(nil)
+	if info != "" {
+		block.Info = ast.NewTextSegment(text.NewSegment(0, len(info)))
+	}
+	lines := text.NewSegments()
+	for i := 0; i < n.Lines().Len(); i++ {
+		start := len(buf)
+		line := n.Lines().At(i)
+		buf = append(buf, line.Value(source)...)
+		lines.Append(text.NewSegment(start, len(buf)))
+	}
+	block.SetLines(lines)
+	for _, attr := range n.Attributes() {
+		block.SetAttribute(attr.Name, attr.Value)
+	}
+	return block, buf
+}
+
+// renderPlainCode writes a code block without highlighting, keeping the
+// language class for client-side tools.
+func renderPlainCode(w util.BufWriter, source []byte, n *ast.FencedCodeBlock, lang string) {
+	_, _ = w.WriteString("<pre><code")
+	if lang != "" {
+		_, _ = w.WriteString(` class="language-`)
+		_, _ = w.Write(util.EscapeHTML([]byte(lang)))
+		_, _ = w.WriteString(`"`)
+	}
+	_ = w.WriteByte('>')
+	for i := 0; i < n.Lines().Len(); i++ {
+		line := n.Lines().At(i)
+		_, _ = w.Write(util.EscapeHTML(line.Value(source)))
+	}
+	_, _ = w.WriteString("</code></pre>\n")
+}
+
+// funcCapture is a registerer that keeps the function registered for one
+// node kind, used to call the highlighter directly.
+type funcCapture struct {
+	kind ast.NodeKind
+	fn   *renderer.NodeRendererFunc
+}
+
+// Register implements renderer.NodeRendererFuncRegisterer.
+func (c funcCapture) Register(kind ast.NodeKind, fn renderer.NodeRendererFunc) {
+	if kind == c.kind {
+		*c.fn = fn
+	}
+}
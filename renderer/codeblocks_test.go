@@ -0,0 +1,100 @@
+package renderer
+
+import (
+	"strings"
+	"testing"
+)
+
+// goKeyword is how the monokai style renders the Go func keyword.
+const goKeyword = `<span style="color:#66d9ef">func</span>`
+
+func TestCodeBlockAliases(t *testing.T) {
+	source := []byte("```GoSource {.example}\nfunc main() {}\n```\n")
+
+	out, _ := New().Render(source)
+	if strings.Contains(string(out), goKeyword) {
+		t.Fatalf("expected unknown language to stay plain, got:\n%s", out)
+	}
+
+	r := NewWithOptions(Options{Code: CodeOptions{Aliases: map[string]string{"gosource": "go"}}})
+	out, err := r.Render(source)
+	if err != nil {
+		t.Fatalf("render failed: %v", err)
+	}
+	if !strings.Contains(string(out), goKeyword) {
+		t.Errorf("expected fence highlighted as go, got:\n%s", out)
+	}
+}
+
+func TestCodeBlockDefaultLanguage(t *testing.T) {
+	r := NewWithOptions(Options{Code: CodeOptions{DefaultLanguage: "go"}})
+
+	out, err := r.Render([]byte("```\nfunc main() {}\n```\n"))
+	if err != nil {
+		t.Fatalf("render failed: %v", err)
+	}
+	if !strings.Contains(string(out), goKeyword) {
+		t.Errorf("expected unlabeled fence highlighted as go, got:\n%s", out)
+	}
+
+	out, _ = New().Render([]byte("```\nfunc main() {}\n```\n"))
+	if strings.Contains(string(out), goKeyword) {
+		t.Errorf("expected no highlighting without a default, got:\n%s", out)
+	}
+}
+
+func TestCodeBlockMaxHighlightBytes(t *testing.T) {
+	r := NewWithOptions(Options{Code: CodeOptions{
+		Aliases:           map[string]string{"golang": "go"},
+		MaxHighlightBytes: 20,
+	}})
+
+	out, err := r.Render([]byte("```golang\nfunc main() { println(\"<big>\") }\n```\n\n```go\nx := 1\n```\n"))
+	if err != nil {
+		t.Fatalf("render failed: %v", err)
+	}
+	html := string(out)
+
+	want := `<pre><code class="language-go">func main() { println(&quot;&lt;big&gt;&quot;) }` + "\n</code></pre>"
+	if !strings.Contains(html, want) {
+		t.Errorf("expected large block rendered plain, got:\n%s", html)
+	}
+	if !strings.Contains(html, `<pre tabindex="0"`) {
+		t.Errorf("expected small block still highlighted, got:\n%s", html)
+	}
+}
+
+func TestRenderFile(t *testing.T) {
+	html, err := New().RenderFile("deploy/config.yaml", []byte("name: gomdoc\nnote: \"```\"\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := string(html)
+	if !strings.Contains(out, `<span style="color:#f92672">name</span>`) || !strings.Contains(out, "```") {
+		t.Errorf("expected the whole file in one highlighted block, got:\n%s", out)
+	}
+	if strings.Count(out, "<pre") != 1 {
+		t.Errorf("expected a backtick run in the file to stay inside the block, got:\n%s", out)
+	}
+}
+
+func TestANSICodeBlocks(t *testing.T) {
+	source := []byte("```ansi\n\\e[1;31mFAIL\\e[0m 3 tests <a>\n\\e[38;5;208morange\\e[38;2;1;2;3m rgb\\e[39m\\e[2K done\n```\n\n```\nplain \x1b]0;title\x07\x1b[32mok\x1b[0m\n```\n")
+	html, err := New().Render(source)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := string(html)
+	for _, want := range []string{
+		`<pre class="ansi"><code><span class="ansi-bold ansi-fg-red">FAIL</span> 3 tests &lt;a&gt;`,
+		`<span style="color:#ff8700">orange</span><span style="color:#010203"> rgb</span> done`,
+		`plain <span class="ansi-fg-green">ok</span>`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q, got:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "\x1b") || strings.Contains(out, "title") {
+		t.Errorf("expected escape sequences removed, got:\n%s", out)
+	}
+}
@@ -0,0 +1,112 @@
+package renderer
+
+import (
+	"log"
+	"net/url"
+	"strings"
+
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+)
+
+// ExternalLinkOptions controls how links that leave the documentation site
+// are rendered.
+type ExternalLinkOptions struct {
+	// NewTab opens external links in a new tab with rel="noopener noreferrer".
+	NewTab bool
+	// Icon adds the external-link class, which the stylesheet decorates
+	// with an arrow icon.
+	Icon bool
+	// AllowedDomains, when non-empty, lists the approved link targets.
+	// Links to other domains still render but are flagged with the
+	// unapproved-link class and logged so authors can fix them.
+	AllowedDomains []string
+}
+
+// enabled reports whether any external link handling is configured.
+func (o ExternalLinkOptions) enabled() bool {
+	return o.NewTab || o.Icon || len(o.AllowedDomains) > 0
+}
+
+// isAllowed reports whether host is an approved domain or one of its subdomains.
+func (o ExternalLinkOptions) isAllowed(host string) bool {
+	host = strings.ToLower(host)
+	for _, domain := range o.AllowedDomains {
+		domain = strings.ToLower(strings.TrimPrefix(domain, "."))
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return true
+		}
+	}
+	return false
+}
+
+// externalLinkTransformer decorates external links in the AST so the
+// attributes survive rendering without post-processing the HTML.
+type externalLinkTransformer struct {
+	options ExternalLinkOptions
+}
+
+// Transform implements parser.ASTTransformer.
+func (t *externalLinkTransformer) Transform(doc *ast.Document, reader text.Reader, _ parser.Context) {
+	source := reader.Source()
+	_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		target := externalURL(n, source)
+		if target == nil {
+			return ast.WalkContinue, nil
+		}
+		t.decorate(n, target)
+		return ast.WalkContinue, nil
+	})
+}
+
+// decorate sets target, rel, and class attributes on an external link node.
+func (t *externalLinkTransformer) decorate(n ast.Node, target *url.URL) {
+	var classes []string
+	if t.options.NewTab {
+		n.SetAttributeString("target", []byte("_blank"))
+		n.SetAttributeString("rel", []byte("noopener noreferrer"))
+	}
+	if t.options.Icon {
+		classes = append(classes, "external-link")
+	}
+	if len(t.options.AllowedDomains) > 0 && !t.options.isAllowed(target.Hostname()) {
+		log.Printf("Warning: link to non-approved domain %q: %s", target.Hostname(), target)
+		classes = append(classes, "unapproved-link")
+	}
+	if len(classes) > 0 {
+		n.SetAttributeString("class", []byte(strings.Join(classes, " ")))
+	}
+}
+
+// externalURL returns the parsed destination of a link or autolink node
+// if it points to another site, or nil otherwise.
+func externalURL(n ast.Node, source []byte) *url.URL {
+	var dest string
+	switch link := n.(type) {
+	case *ast.Link:
+		dest = string(link.Destination)
+	case *ast.AutoLink:
+		if link.AutoLinkType != ast.AutoLinkURL {
+			return nil
+		}
+		dest = string(link.URL(source))
+		if strings.HasPrefix(dest, "www.") {
+			dest = "http://" + dest
+		}
+	default:
+		return nil
+	}
+
+	parsed, err := url.Parse(dest)
+	if err != nil || parsed.Host == "" {
+		return nil
+	}
+	if parsed.Scheme != "http" && parsed.Scheme != "https" {
+		return nil
+	}
+	return parsed
+}
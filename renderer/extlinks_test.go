@@ -0,0 +1,64 @@
+package renderer
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestExternalLinksNewTabAndIcon(t *testing.T) {
+	r := NewWithOptions(Options{ExternalLinks: ExternalLinkOptions{NewTab: true, Icon: true}})
+
+	out, err := r.Render([]byte("[site](https://example.com) and [local](other.md)"))
+	if err != nil {
+		t.Fatalf("render failed: %v", err)
+	}
+	html := string(out)
+
+	if !strings.Contains(html, `<a href="https://example.com" target="_blank" rel="noopener noreferrer" class="external-link">`) {
+		t.Errorf("expected decorated external link, got:\n%s", html)
+	}
+	if !strings.Contains(html, `<a href="other.md">`) {
+		t.Errorf("expected internal link untouched, got:\n%s", html)
+	}
+}
+
+func TestExternalLinksDecoratesAutolinks(t *testing.T) {
+	r := NewWithOptions(Options{ExternalLinks: ExternalLinkOptions{NewTab: true}})
+
+	out, err := r.Render([]byte("Visit https://example.org today."))
+	if err != nil {
+		t.Fatalf("render failed: %v", err)
+	}
+
+	if !strings.Contains(string(out), `target="_blank"`) {
+		t.Errorf("expected autolink to open in new tab, got:\n%s", out)
+	}
+}
+
+func TestExternalLinksAllowlist(t *testing.T) {
+	r := NewWithOptions(Options{ExternalLinks: ExternalLinkOptions{AllowedDomains: []string{"example.com"}}})
+
+	out, err := r.Render([]byte("[ok](https://docs.example.com/x) [bad](https://evil.test/)"))
+	if err != nil {
+		t.Fatalf("render failed: %v", err)
+	}
+	html := string(out)
+
+	if strings.Contains(html, `href="https://docs.example.com/x" class="unapproved-link"`) {
+		t.Errorf("expected subdomain of approved domain to pass, got:\n%s", html)
+	}
+	if !strings.Contains(html, `href="https://evil.test/" class="unapproved-link"`) {
+		t.Errorf("expected unapproved domain to be flagged, got:\n%s", html)
+	}
+}
+
+func TestExternalLinksDisabledByDefault(t *testing.T) {
+	out, err := New().Render([]byte("[site](https://example.com)"))
+	if err != nil {
+		t.Fatalf("render failed: %v", err)
+	}
+
+	if strings.Contains(string(out), "target=") || strings.Contains(string(out), "class=") {
+		t.Errorf("expected plain link by default, got:\n%s", out)
+	}
+}
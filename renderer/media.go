@@ -0,0 +1,131 @@
+package renderer
+
+import (
+	"path"
+	"strings"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/renderer/html"
+	"github.com/yuin/goldmark/util"
+)
+
+// castExtension is the file extension of asciinema terminal recordings.
+const castExtension = ".cast"
+
+// mediaElements maps the extensions of video and audio files to the HTML5
+// element playing them.
+var mediaElements = map[string]string{
+	".mp4":  "video",
+	".webm": "video",
+	".mp3":  "audio",
+}
+
+// media is a goldmark extension embedding players for media linked with
+// image syntax, e.g. ![Deploying](deploy.cast) for a terminal recording or
+// ![Walkthrough](intro.mp4) for a video.
+type media struct{}
+
+// Extend implements goldmark.Extender.
+func (e *media) Extend(m goldmark.Markdown) {
+	m.Renderer().AddOptions(renderer.WithNodeRenderers(
+		util.Prioritized(newMediaRenderer(), 200),
+	))
+}
+
+// mediaRenderer renders images pointing to media files as players and
+// hands every other image to the default renderer.
+type mediaRenderer struct {
+	images renderer.NodeRenderer
+	image  renderer.NodeRendererFunc
+}
+
+// newMediaRenderer creates a renderer delegating plain images to the
+// goldmark HTML renderer.
+func newMediaRenderer() *mediaRenderer {
+	r := &mediaRenderer{images: html.NewRenderer()}
+	r.images.RegisterFuncs(funcCapture{kind: ast.KindImage, fn: &r.image})
+	return r
+}
+
+// SetOption forwards renderer options such as html.WithUnsafe to the
+// image renderer.
+func (r *mediaRenderer) SetOption(name renderer.OptionName, value any) {
+	if setter, ok := r.images.(renderer.SetOptioner); ok {
+		setter.SetOption(name, value)
+	}
+}
+
+// RegisterFuncs implements renderer.NodeRenderer.
+func (r *mediaRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(ast.KindImage, r.renderImage)
+}
+
+// renderImage renders video and audio files with the HTML5 players and a
+// terminal recording as a player placeholder, which the page script turns
+// into a player. Each comes with a link for readers without support.
+func (r *mediaRenderer) renderImage(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	n := node.(*ast.Image)
+	ext := strings.ToLower(path.Ext(strings.SplitN(string(n.Destination), "?", 2)[0]))
+	element, isMedia := mediaElements[ext]
+	if !isMedia && ext != castExtension {
+		return r.image(w, source, node, entering)
+	}
+	if !entering {
+		return ast.WalkSkipChildren, nil
+	}
+	src := util.EscapeHTML(util.URLEscape(n.Destination, true))
+	label := util.EscapeHTML(nodeText(n, source))
+	if isMedia {
+		renderMediaElement(w, element, src, label)
+		return ast.WalkSkipChildren, nil
+	}
+	if len(label) == 0 {
+		label = []byte("Terminal recording")
+	}
+	_, _ = w.WriteString(`<span class="cast-player" data-src="`)
+	_, _ = w.Write(src)
+	_, _ = w.WriteString(`" data-title="`)
+	_, _ = w.Write(label)
+	_, _ = w.WriteString(`"><a href="`)
+	_, _ = w.Write(src)
+	_, _ = w.WriteString(`">▶ `)
+	_, _ = w.Write(label)
+	_, _ = w.WriteString(`</a></span>`)
+	return ast.WalkSkipChildren, nil
+}
+
+// renderMediaElement writes a video or audio element loading only its
+// metadata until played, so pages with several players stay light.
+func renderMediaElement(w util.BufWriter, element string, src, label []byte) {
+	_, _ = w.WriteString("<" + element + ` class="media-player" controls preload="metadata" src="`)
+	_, _ = w.Write(src)
+	_ = w.WriteByte('"')
+	if len(label) > 0 {
+		_, _ = w.WriteString(` title="`)
+		_, _ = w.Write(label)
+		_ = w.WriteByte('"')
+	} else {
+		label = src
+	}
+	_, _ = w.WriteString(`><a href="`)
+	_, _ = w.Write(src)
+	_, _ = w.WriteString(`">`)
+	_, _ = w.Write(label)
+	_, _ = w.WriteString("</a></" + element + ">")
+}
+
+// nodeText returns the plain text of a node's children, such as the alt
+// text of an image.
+func nodeText(n ast.Node, source []byte) []byte {
+	var text []byte
+	for child := n.FirstChild(); child != nil; child = child.NextSibling() {
+		if t, ok := child.(*ast.Text); ok {
+			text = append(text, t.Segment.Value(source)...)
+		} else {
+			text = append(text, nodeText(child, source)...)
+		}
+	}
+	return text
+}
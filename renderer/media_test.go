@@ -0,0 +1,34 @@
+package renderer
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCastPlayer(t *testing.T) {
+	html, err := New().Render([]byte("![Deploying *v2*](demos/deploy.cast)\n\n![Logo](logo.png)\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := string(html)
+	if !strings.Contains(out, `<span class="cast-player" data-src="demos/deploy.cast" data-title="Deploying v2"><a href="demos/deploy.cast">▶ Deploying v2</a></span>`) {
+		t.Errorf("expected cast player, got:\n%s", out)
+	}
+	if !strings.Contains(out, `<img src="logo.png" alt="Logo">`) {
+		t.Errorf("expected other images rendered as before, got:\n%s", out)
+	}
+}
+
+func TestVideoAndAudio(t *testing.T) {
+	html, err := New().Render([]byte("![Walkthrough](media/intro.mp4)\n\n![](podcast.MP3)\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := string(html)
+	if !strings.Contains(out, `<video class="media-player" controls preload="metadata" src="media/intro.mp4" title="Walkthrough"><a href="media/intro.mp4">Walkthrough</a></video>`) {
+		t.Errorf("expected video player, got:\n%s", out)
+	}
+	if !strings.Contains(out, `<audio class="media-player" controls preload="metadata" src="podcast.MP3"><a href="podcast.MP3">podcast.MP3</a></audio>`) {
+		t.Errorf("expected audio player, got:\n%s", out)
+	}
+}
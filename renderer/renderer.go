@@ -9,22 +9,78 @@ import (
 	"strings"
 
 	"github.com/yuin/goldmark"
-	highlighting "github.com/yuin/goldmark-highlighting/v2"
 	"github.com/yuin/goldmark/extension"
 	"github.com/yuin/goldmark/parser"
 	"github.com/yuin/goldmark/renderer/html"
+	"github.com/yuin/goldmark/util"
 )
 
 // Frontmatter holds metadata parsed from YAML frontmatter.
 type Frontmatter struct {
-	Title     string
-	Author    string
-	Status    string
-	Date      string
+	Title       string
+	Description string
+	Author      string
+	Status      string
+	Date        string
+	// Updated is the date of the last substantial change, when it differs
+	// from Date, the date the page was written.
+	Updated   string
 	Tags      []string
 	Category  string
 	Version   string
 	Reviewers []string
+	// Hidden removes the page from navigation (nav: false or hidden: true)
+	// while keeping it reachable by URL and search.
+	Hidden bool
+	// Subtitle and Logo appear on the print cover page. Logo is an image
+	// URL, resolved like image links in the document.
+	Subtitle string
+	Logo     string
+	// Cover requests a print cover page even without subtitle or logo.
+	Cover bool
+	// Typographer overrides the site's smart typography setting for the
+	// page when set (typographer: true or false).
+	Typographer *bool
+	// Bibliography is a BibTeX or CSL-JSON file resolving the page's
+	// [@key] citations, relative to the page.
+	Bibliography string
+	// Layout selects the page template, e.g. wide or landing.
+	Layout string
+	// Theme selects a style variant, e.g. paper or compact.
+	Theme string
+	// StaleAfter overrides the site's age for outdated-page warnings, e.g.
+	// 90d, or disables them for the page with never.
+	StaleAfter string
+	// Owner is the person or team responsible for the page.
+	Owner string
+	// ReviewBy is the date by which the owner should review the page,
+	// e.g. 2025-06-30.
+	ReviewBy string
+	// PublishAt and ExpireAt limit when the page is served, e.g.
+	// 2025-03-01 09:00. Outside the window it answers 404 before
+	// publication and 410 after expiry.
+	PublishAt string
+	ExpireAt  string
+	// CSS and JS list stylesheets and scripts from the assets folder
+	// included in the page's head, e.g. css: [calculator.css].
+	CSS []string
+	JS  []string
+	// Acknowledge asks signed-in readers to confirm they have read the
+	// page, e.g. for policies (acknowledge: true).
+	Acknowledge bool
+	// Series groups pages read in order, wherever they are in the tree,
+	// and SeriesPart is the position of the page in it, e.g. 3.
+	Series     string
+	SeriesPart string
+	// SchemaType is the schema.org type in the page's structured data,
+	// Article or TechArticle.
+	SchemaType string
+}
+
+// HasCover reports whether the page gets a print cover page: requested with
+// cover: true or implied by a subtitle or logo.
+func (fm Frontmatter) HasCover() bool {
+	return fm.Cover || fm.Subtitle != "" || fm.Logo != ""
 }
 
 // ParseFrontmatter extracts YAML frontmatter from markdown content.
@@ -64,8 +120,25 @@ func ParseFrontmatter(content []byte) (Frontmatter, []byte) {
 		fmBlock = text[4:endIndex]
 	}
 
-	// Parse key-value pairs
-	lines := strings.Split(fmBlock, "\n")
+	fm = ParseMetadata(fmBlock)
+
+	// Find the end of the closing delimiter line
+	remaining := text[endIndex+4:] // Skip "\n---"
+	if strings.HasPrefix(remaining, "\r\n") {
+		remaining = remaining[2:]
+	} else if strings.HasPrefix(remaining, "\n") {
+		remaining = remaining[1:]
+	}
+
+	return fm, []byte(remaining)
+}
+
+// ParseMetadata parses a block of YAML-style "key: value" lines into a
+// Frontmatter. It is shared by page frontmatter and directory metadata files
+// so both understand the same keys.
+func ParseMetadata(block string) Frontmatter {
+	fm := Frontmatter{}
+	lines := strings.Split(block, "\n")
 	var currentListKey string
 	for _, line := range lines {
 		trimmed := strings.TrimSpace(line)
@@ -83,6 +156,10 @@ func ParseFrontmatter(content []byte) (Frontmatter, []byte) {
 				fm.Tags = append(fm.Tags, item)
 			case "reviewers":
 				fm.Reviewers = append(fm.Reviewers, item)
+			case "css":
+				fm.CSS = append(fm.CSS, item)
+			case "js":
+				fm.JS = append(fm.JS, item)
 			}
 			continue
 		}
@@ -108,6 +185,10 @@ func ParseFrontmatter(content []byte) (Frontmatter, []byte) {
 				fm.Tags = items
 			case "reviewers":
 				fm.Reviewers = items
+			case "css":
+				fm.CSS = items
+			case "js":
+				fm.JS = items
 			}
 			currentListKey = ""
 			continue
@@ -115,7 +196,8 @@ func ParseFrontmatter(content []byte) (Frontmatter, []byte) {
 
 		// If value is empty, the next lines may be YAML list items
 		if value == "" {
-			if lowerKey == "tags" || lowerKey == "reviewers" {
+			switch lowerKey {
+			case "tags", "reviewers", "css", "js":
 				currentListKey = lowerKey
 			}
 			continue
@@ -127,12 +209,16 @@ func ParseFrontmatter(content []byte) (Frontmatter, []byte) {
 		switch lowerKey {
 		case "title":
 			fm.Title = value
+		case "description":
+			fm.Description = value
 		case "author":
 			fm.Author = value
 		case "status":
 			fm.Status = value
 		case "date":
 			fm.Date = value
+		case "updated":
+			fm.Updated = value
 		case "tags":
 			fm.Tags = parseList(value)
 		case "category":
@@ -141,18 +227,55 @@ func ParseFrontmatter(content []byte) (Frontmatter, []byte) {
 			fm.Version = value
 		case "reviewers":
 			fm.Reviewers = parseList(value)
+		case "nav":
+			if !parseBool(value) {
+				fm.Hidden = true
+			}
+		case "hidden":
+			if parseBool(value) {
+				fm.Hidden = true
+			}
+		case "subtitle":
+			fm.Subtitle = value
+		case "logo":
+			fm.Logo = value
+		case "cover":
+			fm.Cover = parseBool(value)
+		case "typographer":
+			enabled := parseBool(value)
+			fm.Typographer = &enabled
+		case "bibliography":
+			fm.Bibliography = value
+		case "layout":
+			fm.Layout = strings.ToLower(value)
+		case "theme":
+			fm.Theme = strings.ToLower(value)
+		case "stale_after":
+			fm.StaleAfter = value
+		case "owner":
+			fm.Owner = value
+		case "review_by":
+			fm.ReviewBy = value
+		case "publish_at":
+			fm.PublishAt = value
+		case "expire_at":
+			fm.ExpireAt = value
+		case "acknowledge":
+			fm.Acknowledge = parseBool(value)
+		case "series":
+			fm.Series = value
+		case "series_part":
+			fm.SeriesPart = value
+		case "schema_type":
+			fm.SchemaType = value
+		case "css":
+			fm.CSS = parseList(value)
+		case "js":
+			fm.JS = parseList(value)
 		}
 	}
 
-	// Find the end of the closing delimiter line
-	remaining := text[endIndex+4:] // Skip "\n---"
-	if strings.HasPrefix(remaining, "\r\n") {
-		remaining = remaining[2:]
-	} else if strings.HasPrefix(remaining, "\n") {
-		remaining = remaining[1:]
-	}
-
-	return fm, []byte(remaining)
+	return fm
 }
 
 // parseList splits a comma-separated or YAML inline list value into trimmed items.
@@ -173,31 +296,96 @@ func parseList(value string) []string {
 	return items
 }
 
+// parseBool interprets common YAML truthy spellings (true, yes, on, 1).
+func parseBool(value string) bool {
+	switch strings.ToLower(value) {
+	case "true", "yes", "on", "1":
+		return true
+	}
+	return false
+}
+
 // Renderer handles markdown to HTML conversion.
 type Renderer struct {
-	md goldmark.Markdown
+	md      goldmark.Markdown
+	options Options
+	// toggled renders with the typographer setting inverted, for pages
+	// overriding the site default in their frontmatter.
+	toggled goldmark.Markdown
+}
+
+// Options configures optional rendering behavior. The zero value renders
+// plain GitHub Flavored Markdown.
+type Options struct {
+	// ExternalLinks controls decoration of links that leave the site.
+	ExternalLinks ExternalLinkOptions
+	// Code controls syntax highlighting of fenced code blocks.
+	Code CodeOptions
+	// Typographer replaces straight quotes, dashes, and ellipses with their
+	// typographic forms.
+	Typographer bool
 }
 
 // New creates a new Renderer with all necessary extensions enabled.
 func New() *Renderer {
-	md := goldmark.New(
-		goldmark.WithExtensions(
-			extension.GFM, // GitHub Flavored Markdown (tables, autolinks, strikethrough, etc.)
-			highlighting.NewHighlighting(
-				highlighting.WithStyle("monokai"),
-				highlighting.WithFormatOptions(),
-			),
-		),
-		goldmark.WithParserOptions(
-			parser.WithAutoHeadingID(),
-		),
+	return NewWithOptions(Options{})
+}
+
+// NewWithOptions creates a Renderer with the given optional behavior enabled.
+func NewWithOptions(opts Options) *Renderer {
+	toggled := opts
+	toggled.Typographer = !opts.Typographer
+	return &Renderer{md: newMarkdown(opts), options: opts, toggled: newMarkdown(toggled)}
+}
+
+// newMarkdown builds the goldmark converter for opts.
+func newMarkdown(opts Options) goldmark.Markdown {
+	extensions := []goldmark.Extender{
+		extension.GFM, // GitHub Flavored Markdown (tables, autolinks, strikethrough, etc.)
+		&codeBlocks{options: opts.Code},
+		&media{},
+		&tableScroll{},
+	}
+	if opts.Typographer {
+		extensions = append(extensions, extension.Typographer)
+	}
+
+	return goldmark.New(
+		goldmark.WithExtensions(extensions...),
+		goldmark.WithParserOptions(parserOptions(opts)...),
 		goldmark.WithRendererOptions(
 			html.WithHardWraps(),
 			html.WithUnsafe(), // Allow raw HTML in markdown
 		),
 	)
+}
 
-	return &Renderer{md: md}
+// WithTypographer returns a renderer with smart typography switched on or
+// off, sharing everything else with r. Pages use it to override the site
+// default.
+func (r *Renderer) WithTypographer(enabled bool) *Renderer {
+	if enabled == r.options.Typographer {
+		return r
+	}
+	opts := r.options
+	opts.Typographer = enabled
+	return &Renderer{md: r.toggled, options: opts, toggled: r.md}
+}
+
+// parserOptions assembles the goldmark parser options, including any AST
+// transformers required by opts.
+func parserOptions(opts Options) []parser.Option {
+	options := []parser.Option{
+		parser.WithAutoHeadingID(),
+		parser.WithAttribute(),
+		parser.WithASTTransformers(util.Prioritized(&attributeTransformer{}, 200)),
+	}
+	if opts.ExternalLinks.enabled() {
+		options = append(options, parser.WithASTTransformers(
+			util.Prioritized(&externalLinkTransformer{options: opts.ExternalLinks}, 100),
+		))
+	}
+	return options
 }
 
 // Render converts markdown content to HTML.
@@ -220,6 +408,7 @@ func (r *Renderer) RenderWithLinks(content []byte, currentDir string) ([]byte, e
 
 	htmlOut = RewriteLinks(htmlOut, currentDir)
 	htmlOut = TransformAdmonitions(htmlOut)
+	htmlOut = TransformVisibilityMarkers(htmlOut)
 
 	return htmlOut, nil
 }
@@ -275,8 +464,9 @@ func TransformAdmonitions(htmlContent []byte) []byte {
 	})
 }
 
-// linkPattern matches markdown-style links in HTML: href="something.md" or href="./path/to/file.md"
-var linkPattern = regexp.MustCompile(`href="([^"]*\.(?:md|MD))"`)
+// linkPattern matches markdown-style links in HTML: href="something.md" or href="./path/to/file.md",
+// optionally followed by a query or #fragment, as in href="setup.md#install"
+var linkPattern = regexp.MustCompile(`href="([^"?#]*\.(?:md|MD))([?#][^"]*)?"`)
 
 // RewriteLinks transforms .md links to server routes.
 // External links (http://, https://) are preserved.
@@ -308,7 +498,7 @@ func RewriteLinks(htmlContent []byte, currentDir string) []byte {
 			resolvedPath = "/" + resolvedPath
 		}
 
-		return []byte(`href="` + resolvedPath + `"`)
+		return []byte(`href="` + resolvedPath + string(matches[2]) + `"`)
 	})
 }
 
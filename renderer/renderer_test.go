@@ -113,6 +113,11 @@ func TestRewriteLinks(t *testing.T) {
 	if !strings.Contains(result, `href="/other"`) {
 		t.Errorf("expected rewritten link, got: %s", result)
 	}
+
+	result = string(RewriteLinks([]byte(`<a href="../setup.md#install">Link</a>`), "guide/advanced"))
+	if !strings.Contains(result, `href="/guide/setup#install"`) {
+		t.Errorf("expected rewritten link keeping the fragment, got: %s", result)
+	}
 }
 
 func TestParseFrontmatter(t *testing.T) {
@@ -238,3 +243,105 @@ func TestParseFrontmatterQuotedValues(t *testing.T) {
 		t.Errorf("expected status 'draft', got '%s'", fm.Status)
 	}
 }
+
+func TestParseFrontmatterHidden(t *testing.T) {
+	cases := map[string]bool{
+		"---\nnav: false\n---\nBody\n":   true,
+		"---\nhidden: true\n---\nBody\n": true,
+		"---\nnav: true\n---\nBody\n":    false,
+		"---\ntitle: Shown\n---\nBody\n": false,
+	}
+
+	for input, want := range cases {
+		fm, _ := ParseFrontmatter([]byte(input))
+		if fm.Hidden != want {
+			t.Errorf("input %q: expected Hidden=%v, got %v", input, want, fm.Hidden)
+		}
+	}
+}
+
+func TestParseFrontmatterLayoutAndTheme(t *testing.T) {
+	fm, _ := ParseFrontmatter([]byte("---\nlayout: Wide\ntheme: \"paper\"\n---\nBody\n"))
+	if fm.Layout != "wide" || fm.Theme != "paper" {
+		t.Errorf("expected layout wide and theme paper, got %q and %q", fm.Layout, fm.Theme)
+	}
+}
+
+func TestParseFrontmatterPageAssets(t *testing.T) {
+	fm, _ := ParseFrontmatter([]byte("---\ncss: [demo.css, print.css]\njs: calculator.js\n---\nBody\n"))
+	if len(fm.CSS) != 2 || fm.CSS[1] != "print.css" || len(fm.JS) != 1 || fm.JS[0] != "calculator.js" {
+		t.Errorf("expected page stylesheets and scripts, got %q and %q", fm.CSS, fm.JS)
+	}
+}
+
+func TestParseFrontmatterCover(t *testing.T) {
+	cases := map[string]bool{
+		"---\nsubtitle: Operations Manual\n---\nBody\n": true,
+		"---\nlogo: logo.svg\n---\nBody\n":              true,
+		"---\ncover: yes\n---\nBody\n":                  true,
+		"---\ntitle: Plain\n---\nBody\n":                false,
+	}
+
+	for input, want := range cases {
+		fm, _ := ParseFrontmatter([]byte(input))
+		if fm.HasCover() != want {
+			t.Errorf("input %q: expected HasCover=%v, got %v", input, want, fm.HasCover())
+		}
+	}
+}
+
+func TestTypographer(t *testing.T) {
+	input := []byte(`Say "hello" -- it's done...` + "\n")
+
+	plain, _ := New().Render(input)
+	if !strings.Contains(string(plain), "&quot;hello&quot; -- it's done...") {
+		t.Errorf("expected straight quotes by default, got %s", plain)
+	}
+
+	smart := NewWithOptions(Options{Typographer: true})
+	html, _ := smart.Render(input)
+	if !strings.Contains(string(html), "&ldquo;hello&rdquo; &ndash; it&rsquo;s done&hellip;") {
+		t.Errorf("expected smart typography, got %s", html)
+	}
+
+	html, _ = smart.WithTypographer(false).Render(input)
+	if !strings.Contains(string(html), "&quot;hello&quot;") {
+		t.Errorf("expected page override to disable smart typography, got %s", html)
+	}
+	if smart.WithTypographer(true) != smart {
+		t.Error("expected the same renderer when the setting does not change")
+	}
+}
+
+func TestParseFrontmatterTypographer(t *testing.T) {
+	fm, _ := ParseFrontmatter([]byte("---\ntypographer: false\n---\nBody\n"))
+	if fm.Typographer == nil || *fm.Typographer {
+		t.Errorf("expected typographer override false, got %v", fm.Typographer)
+	}
+	fm, _ = ParseFrontmatter([]byte("---\ntitle: Plain\n---\nBody\n"))
+	if fm.Typographer != nil {
+		t.Errorf("expected no typographer override, got %v", *fm.Typographer)
+	}
+}
+
+func TestHeadingAnchors(t *testing.T) {
+	r := New()
+	content := []byte("# Guide\n\nIntro.\n\n## Quick Start {#start}\n\n## Setup ##\n\nSetext Heading\n--------------\n\n## Options {.wide #opts}\n\n## Setup\n")
+	anchors := r.HeadingAnchors(content)
+	want := []HeadingAnchor{
+		{ID: "guide", Text: "Guide", Line: 1},
+		{ID: "start", Text: "Quick Start", Line: 5, Pinned: true},
+		{ID: "setup", Text: "Setup", Line: 7},
+		{ID: "setext-heading", Text: "Setext Heading", Line: 9},
+		{ID: "opts", Text: "Options", Line: 12, Pinned: true},
+		{ID: "setup-1", Text: "Setup", Line: 14},
+	}
+	if len(anchors) != len(want) {
+		t.Fatalf("expected %d headings, got %+v", len(want), anchors)
+	}
+	for i := range want {
+		if anchors[i] != want[i] {
+			t.Errorf("heading %d: expected %+v, got %+v", i, want[i], anchors[i])
+		}
+	}
+}
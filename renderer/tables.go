@@ -0,0 +1,70 @@
+package renderer
+
+import (
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	extast "github.com/yuin/goldmark/extension/ast"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/util"
+)
+
+// LongTableRows is the number of body rows from which a table counts as
+// long: it scrolls within a box of limited height, under its header row.
+const LongTableRows = 25
+
+// tableScroll is a goldmark extension wrapping tables in a box that
+// scrolls sideways, so wide tables do not break the layout on small
+// screens. Long tables get the table-long class as well.
+type tableScroll struct{}
+
+// Extend implements goldmark.Extender.
+func (e *tableScroll) Extend(m goldmark.Markdown) {
+	m.Renderer().AddOptions(renderer.WithNodeRenderers(
+		util.Prioritized(newTableScrollRenderer(), 200),
+	))
+}
+
+// tableScrollRenderer writes the box around a table and hands the table
+// itself to the table renderer of the GFM extension.
+type tableScrollRenderer struct {
+	tables renderer.NodeRenderer
+	table  renderer.NodeRendererFunc
+}
+
+// newTableScrollRenderer creates a renderer delegating tables to the GFM
+// table renderer.
+func newTableScrollRenderer() *tableScrollRenderer {
+	r := &tableScrollRenderer{tables: extension.NewTableHTMLRenderer()}
+	r.tables.RegisterFuncs(funcCapture{kind: extast.KindTable, fn: &r.table})
+	return r
+}
+
+// SetOption forwards renderer options such as html.WithXHTML to the table
+// renderer.
+func (r *tableScrollRenderer) SetOption(name renderer.OptionName, value any) {
+	if setter, ok := r.tables.(renderer.SetOptioner); ok {
+		setter.SetOption(name, value)
+	}
+}
+
+// RegisterFuncs implements renderer.NodeRenderer.
+func (r *tableScrollRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(extast.KindTable, r.renderTable)
+}
+
+// renderTable renders a table inside a div.table-scroll.
+func (r *tableScrollRenderer) renderTable(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		status, err := r.table(w, source, node, entering)
+		_, _ = w.WriteString("</div>\n")
+		return status, err
+	}
+	// The first child is the header row.
+	if node.ChildCount()-1 > LongTableRows {
+		_, _ = w.WriteString(`<div class="table-scroll table-long">` + "\n")
+	} else {
+		_, _ = w.WriteString(`<div class="table-scroll">` + "\n")
+	}
+	return r.table(w, source, node, entering)
+}
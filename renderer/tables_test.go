@@ -0,0 +1,27 @@
+package renderer
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTableScroll(t *testing.T) {
+	r := New()
+	html, err := r.Render([]byte("| Name | Size |\n|------|-----:|\n| a | 1 |\n\n{.sortable data-page-size=20}\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "<div class=\"table-scroll\">\n<table class=\"sortable\" data-page-size=\"20\">\n"
+	if !strings.HasPrefix(string(html), want) || !strings.HasSuffix(string(html), "</table>\n</div>\n") {
+		t.Errorf("expected the table wrapped in a scroll box with its attributes, got:\n%s", html)
+	}
+
+	long := "| N |\n|---|\n" + strings.Repeat("| x |\n", LongTableRows+1)
+	if html, _ := r.Render([]byte(long)); !strings.HasPrefix(string(html), `<div class="table-scroll table-long">`) {
+		t.Errorf("expected a table of %d rows marked long, got:\n%.80s", LongTableRows+1, html)
+	}
+	short := "| N |\n|---|\n" + strings.Repeat("| x |\n", LongTableRows)
+	if html, _ := r.Render([]byte(short)); strings.Contains(string(html), "table-long") {
+		t.Errorf("expected a table of %d rows not marked long", LongTableRows)
+	}
+}
@@ -0,0 +1,29 @@
+package renderer
+
+import (
+	"regexp"
+	"strings"
+)
+
+// visibilityPattern matches blockquotes marked print-only or screen-only,
+// e.g. `> [!PRINT]` followed by the content. Like admonitions, the marker is
+// either followed by <br> and more text or alone in its paragraph.
+var visibilityPattern = regexp.MustCompile(
+	`(?s)<blockquote>\s*<p>\[!(PRINT|SCREEN)\](<br>\n?|\s*</p>)`,
+)
+
+// TransformVisibilityMarkers turns `> [!PRINT]` blocks into content shown
+// only on paper, e.g. signature lines, and `> [!SCREEN]` blocks into content
+// hidden when printing, e.g. interactive widgets. The blockquote keeps
+// wrapping the content but loses its quote styling.
+func TransformVisibilityMarkers(htmlContent []byte) []byte {
+	return visibilityPattern.ReplaceAllFunc(htmlContent, func(match []byte) []byte {
+		sub := visibilityPattern.FindSubmatch(match)
+		class := strings.ToLower(string(sub[1])) + "-only"
+		open := `<blockquote class="visibility-block ` + class + `">`
+		if strings.HasPrefix(string(sub[2]), "<br>") {
+			return []byte(open + "\n<p>")
+		}
+		return []byte(open)
+	})
+}
@@ -0,0 +1,32 @@
+package renderer
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTransformVisibilityMarkers(t *testing.T) {
+	r := New()
+	html, err := r.RenderWithLinks([]byte("> [!PRINT]\n> Signature: ________\n\nText.\n\n> [!SCREEN]\n>\n> Click the button below.\n"), "")
+	if err != nil {
+		t.Fatalf("render failed: %v", err)
+	}
+	result := string(html)
+
+	if !strings.Contains(result, `<blockquote class="visibility-block print-only">`+"\n<p>Signature: ________</p>") {
+		t.Errorf("expected print-only block, got:\n%s", result)
+	}
+	if !strings.Contains(result, `<blockquote class="visibility-block screen-only">`) || !strings.Contains(result, "Click the button below.") {
+		t.Errorf("expected screen-only block, got:\n%s", result)
+	}
+	if strings.Contains(result, "[!") {
+		t.Errorf("expected markers to be removed, got:\n%s", result)
+	}
+}
+
+func TestTransformVisibilityMarkersIgnoresPlainQuotes(t *testing.T) {
+	input := "<blockquote>\n<p>[!PRINTER] is not a marker.</p>\n</blockquote>"
+	if result := string(TransformVisibilityMarkers([]byte(input))); result != input {
+		t.Errorf("expected plain blockquote to be unchanged, got:\n%s", result)
+	}
+}
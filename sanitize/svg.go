@@ -0,0 +1,178 @@
+// Package sanitize removes active content, such as scripts and event
+// handlers, from markup written by untrusted authors.
+package sanitize
+
+import (
+	"bytes"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// bodyContext parses fragments the way browsers parse page content.
+var bodyContext = &html.Node{Type: html.ElementNode, Data: "body", DataAtom: atom.Body}
+
+// droppedElements can run code or load other documents, and are removed
+// along with their content.
+var droppedElements = map[string]bool{
+	"script": true, "foreignobject": true, "iframe": true, "frame": true,
+	"object": true, "embed": true, "applet": true, "base": true,
+	"meta": true, "link": true, "handler": true, "listener": true,
+}
+
+// animationElements change the attributes of other elements, so they are
+// removed when they target a link or an event handler.
+var animationElements = map[string]bool{
+	"animate": true, "animatemotion": true, "animatetransform": true, "set": true,
+}
+
+// urlAttributes hold URLs that are followed or loaded.
+var urlAttributes = map[string]bool{
+	"href": true, "xlink:href": true, "src": true, "action": true, "formaction": true,
+}
+
+// allowedNamespaces are the only namespace declarations kept. Others could
+// bind a prefix to the SVG or XHTML namespace and hide a script element
+// behind it.
+var allowedNamespaces = map[string]string{
+	"xmlns":       "http://www.w3.org/2000/svg",
+	"xmlns:xlink": "http://www.w3.org/1999/xlink",
+}
+
+// SVG returns an SVG file without scripts, event handler attributes,
+// embedded documents, javascript: links, comments, processing
+// instructions, or a doctype.
+func SVG(data []byte) ([]byte, error) {
+	nodes, err := html.ParseFragment(bytes.NewReader(data), bodyContext)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	for _, n := range nodes {
+		if n.Type == html.CommentNode || n.Type == html.DoctypeNode {
+			continue
+		}
+		if n.Type == html.ElementNode {
+			if dropElement(n) {
+				continue
+			}
+			clean(n)
+		}
+		if err := html.Render(&buf, n); err != nil {
+			return nil, err
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// InlineSVG sanitizes the <svg> elements in an HTML fragment like SVG,
+// leaving the markup around them as it is. Fragments without SVG are
+// returned unchanged.
+func InlineSVG(fragment []byte) ([]byte, error) {
+	if !bytes.Contains(bytes.ToLower(fragment), []byte("<svg")) {
+		return fragment, nil
+	}
+	nodes, err := html.ParseFragment(bytes.NewReader(fragment), bodyContext)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	for _, n := range nodes {
+		cleanInline(n)
+		if err := html.Render(&buf, n); err != nil {
+			return nil, err
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// cleanInline sanitizes every SVG element below and including n.
+func cleanInline(n *html.Node) {
+	if n.Type == html.ElementNode && n.Namespace == "svg" && n.Data == "svg" {
+		clean(n)
+		return
+	}
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		cleanInline(child)
+	}
+}
+
+// clean removes active content from the element n and its descendants.
+func clean(n *html.Node) {
+	attrs := n.Attr[:0]
+	for _, attr := range n.Attr {
+		if !dropAttribute(attr) {
+			attrs = append(attrs, attr)
+		}
+	}
+	n.Attr = attrs
+
+	for child := n.FirstChild; child != nil; {
+		next := child.NextSibling
+		switch {
+		case child.Type == html.CommentNode:
+			n.RemoveChild(child)
+		case child.Type == html.ElementNode && dropElement(child):
+			n.RemoveChild(child)
+		case child.Type == html.ElementNode:
+			clean(child)
+		}
+		child = next
+	}
+}
+
+// dropElement reports whether the element n is removed entirely.
+func dropElement(n *html.Node) bool {
+	name := strings.ToLower(n.Data)
+	if droppedElements[name] || strings.Contains(name, ":") {
+		return true
+	}
+	if animationElements[name] {
+		for _, attr := range n.Attr {
+			if strings.ToLower(attr.Key) != "attributename" {
+				continue
+			}
+			target := strings.ToLower(strings.TrimSpace(attr.Val))
+			return urlAttributes[target] || strings.HasPrefix(target, "on")
+		}
+	}
+	return false
+}
+
+// dropAttribute reports whether attr is an event handler, a link to a
+// script, or a foreign namespace declaration.
+func dropAttribute(attr html.Attribute) bool {
+	name := strings.ToLower(attr.Key)
+	if attr.Namespace != "" {
+		name = attr.Namespace + ":" + name
+	}
+	switch {
+	case strings.HasPrefix(name, "on"):
+		return true
+	case name == "xmlns" || strings.HasPrefix(name, "xmlns:"):
+		return allowedNamespaces[name] != strings.TrimSpace(attr.Val)
+	case urlAttributes[name]:
+		return unsafeURL(attr.Val)
+	}
+	return false
+}
+
+// unsafeURL reports whether value is a javascript: or vbscript: URL, or a
+// data: URL that is not an image. Browsers ignore whitespace and control
+// characters inside the scheme, so they are ignored here too.
+func unsafeURL(value string) bool {
+	scheme := strings.Map(func(r rune) rune {
+		if r <= ' ' {
+			return -1
+		}
+		return r
+	}, strings.ToLower(value))
+	switch {
+	case strings.HasPrefix(scheme, "javascript:"), strings.HasPrefix(scheme, "vbscript:"):
+		return true
+	case strings.HasPrefix(scheme, "data:"):
+		return !strings.HasPrefix(scheme, "data:image/")
+	}
+	return false
+}
@@ -0,0 +1,55 @@
+package sanitize
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSVG(t *testing.T) {
+	in := `<?xml version="1.0"?>
+<!DOCTYPE svg>
+<svg xmlns="http://www.w3.org/2000/svg" xmlns:xlink="http://www.w3.org/1999/xlink" xmlns:x="http://www.w3.org/2000/svg" viewBox="0 0 10 10" onload="alert(1)">
+<!-- note --><script>alert(2)</script><x:script>alert(3)</x:script>
+<a xlink:href="java&#x0A;script:alert(4)"><rect width="1" height="1" onclick="alert(5)"/></a>
+<a href="https://example.com"><linearGradient id="g"/></a>
+<set attributeName="href" to="javascript:alert(6)"/><set attributeName="fill" to="red"/>
+<foreignObject><img src="x" onerror="alert(7)"></foreignObject>
+<image href="data:image/png;base64,AAAA"/><image href="data:text/html,hi"/>
+</svg>`
+	out, err := SVG([]byte(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := string(out)
+	for _, unwanted := range []string{"alert", "<?xml", "DOCTYPE", "note", "xmlns:x=", "data:text"} {
+		if strings.Contains(got, unwanted) {
+			t.Errorf("expected %q removed, got:\n%s", unwanted, got)
+		}
+	}
+	for _, wanted := range []string{`viewBox="0 0 10 10"`, `xmlns:xlink="http://www.w3.org/1999/xlink"`, `<linearGradient id="g">`,
+		`href="https://example.com"`, `attributeName="fill"`, `href="data:image/png;base64,AAAA"`} {
+		if !strings.Contains(got, wanted) {
+			t.Errorf("expected %q kept, got:\n%s", wanted, got)
+		}
+	}
+}
+
+func TestInlineSVG(t *testing.T) {
+	in := "<p onclick=\"keep()\">Text</p>\n<svg onload=\"alert(1)\"><circle r=\"4\"/></svg>\n"
+	out, err := InlineSVG([]byte(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := string(out)
+	if strings.Contains(got, "alert") || !strings.Contains(got, `<circle r="4">`) {
+		t.Errorf("expected the SVG sanitized, got:\n%s", got)
+	}
+	if !strings.Contains(got, `<p onclick="keep()">Text</p>`) {
+		t.Errorf("expected markup outside the SVG left alone, got:\n%s", got)
+	}
+
+	plain := []byte("<p>No images &amp; no SVG</p>\n")
+	if out, _ := InlineSVG(plain); string(out) != string(plain) {
+		t.Errorf("expected fragments without SVG unchanged, got %q", out)
+	}
+}
@@ -0,0 +1,96 @@
+package scanner
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// DefaultAttachmentTypes are the file extensions listed as attachments
+// when no allowlist is given.
+var DefaultAttachmentTypes = []string{"pdf", "doc", "docx", "xls", "xlsx", "ppt", "pptx", "odt", "ods", "odp", "zip", "txt", "csv"}
+
+// DefaultCodeTypes are the file extensions of text and source files shown
+// as highlighted pages when no list is given.
+var DefaultCodeTypes = []string{"txt", "json", "yaml", "yml", "toml", "ini", "conf", "xml", "sql", "sh", "bash", "ps1", "py", "go", "js", "ts", "java", "rb", "rs", "c", "h", "cpp", "cs", "php", "tf"}
+
+// ScanAttachments finds the files in fsys whose extension, without the dot
+// and ignoring case, is one of types. Attachments are routed under their
+// file path, so /manuals/setup.pdf serves manuals/setup.pdf. Files below a
+// directory hidden by _dir.yml are marked hidden.
+func ScanAttachments(fsys fs.FS, types []string) ([]FileEntry, error) {
+	var entries []FileEntry
+	orders := orderCache{}
+	err := walkFiles(fsys, func(name string, d fs.DirEntry, inHiddenDir bool) error {
+		if !IsAttachmentType(name, types) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		entries = append(entries, FileEntry{
+			RelPath:    filepath.FromSlash(name),
+			Name:       d.Name(),
+			URLPath:    "/" + name,
+			Hidden:     inHiddenDir,
+			Attachment: true,
+			Size:       info.Size(),
+			Order:      orders.positions(fsys, name),
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].RelPath < entries[j].RelPath
+	})
+	return entries, nil
+}
+
+// IsAttachmentType reports whether the extension of name is in types.
+// Markdown files are never attachments.
+func IsAttachmentType(name string, types []string) bool {
+	ext := strings.TrimPrefix(strings.ToLower(path.Ext(name)), ".")
+	return ext != "" && ext != "md" && slices.Contains(types, ext)
+}
+
+// renderAttachment renders a download link for an attachment, or a link to
+// its preview, labeled with its size. The type drives the icon chosen by
+// the stylesheet.
+func renderAttachment(sb *strings.Builder, node *TreeNode) {
+	ext := strings.TrimPrefix(strings.ToLower(path.Ext(node.Name)), ".")
+	href, download := node.Path, " download"
+	if node.Preview != "" {
+		href, download = node.Preview, ""
+	}
+	sb.WriteString("<a href=\"")
+	sb.WriteString(escapeHTML(href))
+	sb.WriteString("\" class=\"file attachment\" data-type=\"")
+	sb.WriteString(escapeHTML(ext))
+	sb.WriteString("\"" + download + ">")
+	sb.WriteString(escapeHTML(node.Name))
+	sb.WriteString("</a> <span class=\"attachment-size\">")
+	sb.WriteString(FormatSize(node.Size))
+	sb.WriteString("</span>")
+}
+
+// FormatSize formats a byte count for people, e.g. 1.5 MB.
+func FormatSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	value, prefix := float64(size)/unit, 0
+	for value >= unit && prefix < 3 {
+		value /= unit
+		prefix++
+	}
+	return fmt.Sprintf("%.1f %cB", value, "KMGT"[prefix])
+}
@@ -0,0 +1,83 @@
+package scanner
+
+import (
+	"io/fs"
+	"path"
+	"strings"
+)
+
+// OrderFile lists the pages and folders of a directory in navigation
+// order, one "- name" item per line, e.g. "- intro.md" or "- guides".
+// Page names may leave out the .md extension. Listed entries come first,
+// in the listed order; the others follow in the usual order.
+const OrderFile = "_order.yml"
+
+// orderCache holds the parsed order file of each directory, keyed by the
+// directory path, so each file is read once per scan.
+type orderCache map[string]map[string]int
+
+// positions returns the 1-based position of every segment of name in the
+// order file of the directory above it, or 0 when it is not listed.
+func (c orderCache) positions(fsys fs.FS, name string) []int {
+	segments := strings.Split(name, "/")
+	order := make([]int, len(segments))
+	dir := "."
+	for i, segment := range segments {
+		order[i] = c.listing(fsys, dir)[segment]
+		dir = path.Join(dir, segment)
+	}
+	return order
+}
+
+// listing returns the order file of dir, reading it on first use.
+func (c orderCache) listing(fsys fs.FS, dir string) map[string]int {
+	if listing, found := c[dir]; found {
+		return listing
+	}
+	listing := make(map[string]int)
+	if content, err := fs.ReadFile(fsys, path.Join(dir, OrderFile)); err == nil {
+		for _, name := range ParseOrder(string(content)) {
+			if _, found := listing[name]; !found {
+				listing[name] = len(listing) + 1
+			}
+			if page := name + ".md"; !strings.HasSuffix(strings.ToLower(name), ".md") {
+				if _, found := listing[page]; !found {
+					listing[page] = listing[name]
+				}
+			}
+		}
+	}
+	c[dir] = listing
+	return listing
+}
+
+// ParseOrder returns the names listed in the content of an order file.
+// Blank lines and comments are ignored, and so are lines that are not list
+// items.
+func ParseOrder(content string) []string {
+	var names []string
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, "- ") {
+			continue
+		}
+		name := strings.TrimSpace(line[2:])
+		if i := strings.Index(name, " #"); i >= 0 {
+			name = strings.TrimSpace(name[:i])
+		}
+		name = strings.Trim(name, `"'`)
+		if name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// FormatOrder returns the content of an order file listing names.
+func FormatOrder(names []string) string {
+	var sb strings.Builder
+	for _, name := range names {
+		sb.WriteString("- " + name + "\n")
+	}
+	return sb.String()
+}
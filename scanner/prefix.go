@@ -0,0 +1,84 @@
+package scanner
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// ScanOptions configures how discovered files map to display names and URLs.
+type ScanOptions struct {
+	// StripNumericPrefix removes ordering prefixes such as "01-" from
+	// displayed names and URLs. The prefix still drives the sort order.
+	StripNumericPrefix bool
+	// MaxPageBytes is the size of the largest markdown file that is read
+	// for rendering and search. Larger files stay in the navigation but
+	// are neither rendered nor indexed. Zero means no limit.
+	MaxPageBytes int64
+}
+
+// TooLarge reports whether a markdown file of size bytes is over the page
+// size limit.
+func (o ScanOptions) TooLarge(size int64) bool {
+	return o.MaxPageBytes > 0 && size > o.MaxPageBytes
+}
+
+// numericPrefixPattern matches an ordering prefix like "01-", "2_" or "10. ".
+var numericPrefixPattern = regexp.MustCompile(`^\d+[-_. ]+`)
+
+// StripNumericPrefix removes a leading ordering prefix from a single name.
+// Names consisting only of the prefix are returned unchanged.
+func StripNumericPrefix(name string) string {
+	stripped := numericPrefixPattern.ReplaceAllString(name, "")
+	if stripped == "" {
+		return name
+	}
+	return stripped
+}
+
+// StripNumericPrefixes removes ordering prefixes from every segment of a
+// slash-separated URL path.
+func StripNumericPrefixes(urlPath string) string {
+	segments := strings.Split(urlPath, "/")
+	for i, segment := range segments {
+		segments[i] = StripNumericPrefix(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+// urlPathFor converts a relative markdown file path into its route.
+func urlPathFor(relPath string, opts ScanOptions) string {
+	urlPath := filepath.ToSlash(strings.TrimSuffix(relPath, filepath.Ext(relPath)))
+	if opts.StripNumericPrefix {
+		urlPath = StripNumericPrefixes(urlPath)
+	}
+	return "/" + urlPath
+}
+
+// entryURLPath returns the entry's route, deriving it from the file path
+// for entries that were not produced by a scan.
+func entryURLPath(entry FileEntry) string {
+	if entry.URLPath != "" {
+		return entry.URLPath
+	}
+	return urlPathFor(entry.RelPath, ScanOptions{})
+}
+
+// displayNames returns the tree labels for each raw path segment of an
+// entry, taking them from the entry's route so stripped prefixes stay hidden.
+// File labels keep their extension, matching the unstripped tree.
+// Attachments are served under their file path, so they keep their names.
+func displayNames(parts []string, entry FileEntry) []string {
+	if entry.Attachment {
+		return parts
+	}
+	segments := strings.Split(strings.TrimPrefix(entryURLPath(entry), "/"), "/")
+	if len(segments) != len(parts) {
+		return parts
+	}
+	names := make([]string, len(parts))
+	copy(names, segments)
+	last := len(parts) - 1
+	names[last] = segments[last] + filepath.Ext(parts[last])
+	return names
+}
@@ -3,67 +3,101 @@
 package scanner
 
 import (
+	"io"
+	"io/fs"
 	"os"
+	"path"
 	"path/filepath"
 	"sort"
 	"strings"
+
+	"gomdoc/renderer"
 )
 
+// maxFrontmatterBytes is how much of a markdown file is read to find the
+// frontmatter deciding whether it is hidden.
+const maxFrontmatterBytes = 64 << 10
+
+// DirMetaFile is the per-directory metadata file. It accepts the same
+// "key: value" lines as page frontmatter (e.g. "nav: false").
+const DirMetaFile = "_dir.yml"
+
 // FileEntry represents a discovered markdown file.
 type FileEntry struct {
 	// RelPath is the path relative to the base directory.
 	RelPath string
 	// Name is the file name without extension.
 	Name string
+	// URLPath is the route serving the file, e.g. /guide/setup.
+	URLPath string
+	// Hidden excludes the file from navigation. It stays reachable by URL
+	// and search, which suits changelog archives and appendices.
+	Hidden bool
+	// Attachment marks a downloadable non-markdown file found by
+	// ScanAttachments. Size is its length in bytes.
+	Attachment bool
+	Size       int64
+	// Preview is the route of an HTML preview of an attachment. When set,
+	// the tree links the preview instead of downloading the file.
+	Preview string
+	// Order holds the position of each segment of RelPath in the _order.yml
+	// of the directory above it, starting at 1, or 0 when it is not listed.
+	Order []int
 }
 
 // TreeNode represents a node in the file tree (file or directory).
 type TreeNode struct {
-	Name     string
-	Path     string // URL path for files, empty for directories
-	IsDir    bool
-	Children []*TreeNode
+	Name       string
+	Path       string // URL path for files, empty for directories
+	IsDir      bool
+	Attachment bool   // downloadable non-markdown file
+	Size       int64  // attachment size in bytes
+	Preview    string // route of the attachment's HTML preview, if any
+	Children   []*TreeNode
+	sortName   string // raw file or directory name, used for ordering
+	order      int    // position in the directory's _order.yml, 0 if unlisted
 }
 
 // ScanDirectory recursively finds all markdown files in the given root directory.
 func ScanDirectory(root string) ([]FileEntry, error) {
-	var entries []FileEntry
-
-	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
+	return ScanDirectoryWithOptions(root, ScanOptions{})
+}
 
-		// Skip hidden directories and files
-		if strings.HasPrefix(info.Name(), ".") {
-			if info.IsDir() {
-				return filepath.SkipDir
-			}
-			return nil
-		}
+// ScanDirectoryWithOptions recursively finds all markdown files, deriving
+// names and routes as described by opts.
+func ScanDirectoryWithOptions(root string, opts ScanOptions) ([]FileEntry, error) {
+	if _, err := os.Stat(root); err != nil {
+		return nil, err
+	}
+	return ScanFS(os.DirFS(root), opts)
+}
 
+// ScanFS recursively finds all markdown files in fsys, such as a local
+// directory or an object storage bucket. RelPath uses the separator of the
+// operating system, like the other scan functions.
+func ScanFS(fsys fs.FS, opts ScanOptions) ([]FileEntry, error) {
+	var entries []FileEntry
+	orders := orderCache{}
+	err := walkFiles(fsys, func(name string, d fs.DirEntry, inHiddenDir bool) error {
 		// Only process markdown files
-		if info.IsDir() {
-			return nil
-		}
-		if !strings.HasSuffix(strings.ToLower(info.Name()), ".md") {
+		if !strings.HasSuffix(strings.ToLower(d.Name()), ".md") {
 			return nil
 		}
 
-		relPath, err := filepath.Rel(root, path)
-		if err != nil {
-			return err
+		relPath := filepath.FromSlash(name)
+		baseName := strings.TrimSuffix(d.Name(), path.Ext(d.Name()))
+		if opts.StripNumericPrefix {
+			baseName = StripNumericPrefix(baseName)
 		}
-
-		name := strings.TrimSuffix(info.Name(), filepath.Ext(info.Name()))
 		entries = append(entries, FileEntry{
 			RelPath: relPath,
-			Name:    name,
+			Name:    baseName,
+			URLPath: urlPathFor(relPath, opts),
+			Hidden:  inHiddenDir || isFileHidden(fsys, name, opts),
+			Order:   orders.positions(fsys, name),
 		})
-
 		return nil
 	})
-
 	if err != nil {
 		return nil, err
 	}
@@ -76,8 +110,84 @@ func ScanDirectory(root string) ([]FileEntry, error) {
 	return entries, nil
 }
 
-// BuildTree constructs a tree structure from a list of file entries.
+// walkFiles calls visit for every file in fsys, skipping names that start
+// with a dot. inHiddenDir reports whether a _dir.yml above the file hides
+// it from navigation.
+func walkFiles(fsys fs.FS, visit func(name string, d fs.DirEntry, inHiddenDir bool) error) error {
+	var hiddenDirs []string
+	return fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		// Skip hidden directories and files
+		if name != "." && strings.HasPrefix(d.Name(), ".") {
+			if d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+
+		if d.IsDir() {
+			if name != "." && isDirHidden(fsys, name) {
+				hiddenDirs = append(hiddenDirs, name+"/")
+			}
+			return nil
+		}
+		return visit(name, d, hasAnyPrefix(name, hiddenDirs))
+	})
+}
+
+// isDirHidden reports whether a directory opts out of navigation via its
+// metadata file.
+func isDirHidden(fsys fs.FS, dir string) bool {
+	content, err := fs.ReadFile(fsys, path.Join(dir, DirMetaFile))
+	if err != nil {
+		return false
+	}
+	return renderer.ParseMetadata(string(content)).Hidden
+}
+
+// isFileHidden reports whether a markdown file opts out of navigation via
+// its frontmatter, read from the start of the file. Files over the page
+// size limit are not read and stay in the navigation.
+func isFileHidden(fsys fs.FS, name string, opts ScanOptions) bool {
+	if info, err := fs.Stat(fsys, name); err != nil || opts.TooLarge(info.Size()) {
+		return false
+	}
+	file, err := fsys.Open(name)
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+	content, err := io.ReadAll(io.LimitReader(file, maxFrontmatterBytes))
+	if err != nil {
+		return false
+	}
+	frontmatter, _ := renderer.ParseFrontmatter(content)
+	return frontmatter.Hidden
+}
+
+// hasAnyPrefix reports whether path starts with one of the given prefixes.
+func hasAnyPrefix(path string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// BuildTree constructs a tree structure from a list of file entries using
+// natural sort order. Hidden entries are left out so they do not clutter
+// the navigation.
 func BuildTree(entries []FileEntry) *TreeNode {
+	return BuildTreeSorted(entries, SortOptions{})
+}
+
+// BuildTreeSorted constructs a tree structure ordering siblings as
+// described by opts.
+func BuildTreeSorted(entries []FileEntry, opts SortOptions) *TreeNode {
 	root := &TreeNode{
 		Name:     "root",
 		IsDir:    true,
@@ -85,16 +195,20 @@ func BuildTree(entries []FileEntry) *TreeNode {
 	}
 
 	for _, entry := range entries {
+		if entry.Hidden {
+			continue
+		}
 		parts := strings.Split(filepath.ToSlash(entry.RelPath), "/")
-		insertNode(root, parts, entry)
+		insertNode(root, parts, displayNames(parts, entry), entry)
 	}
 
-	sortTree(root)
+	sortTree(root, opts.comparator())
 	return root
 }
 
 // insertNode adds a file entry to the tree, creating intermediate directories as needed.
-func insertNode(parent *TreeNode, parts []string, entry FileEntry) {
+// parts are the raw path segments and names the matching display labels.
+func insertNode(parent *TreeNode, parts, names []string, entry FileEntry) {
 	if len(parts) == 0 {
 		return
 	}
@@ -102,7 +216,7 @@ func insertNode(parent *TreeNode, parts []string, entry FileEntry) {
 	// Find or create the child node
 	var child *TreeNode
 	for _, c := range parent.Children {
-		if c.Name == parts[0] {
+		if c.sortName == parts[0] {
 			child = c
 			break
 		}
@@ -111,40 +225,26 @@ func insertNode(parent *TreeNode, parts []string, entry FileEntry) {
 	if child == nil {
 		isFile := len(parts) == 1
 		child = &TreeNode{
-			Name:     parts[0],
+			Name:     names[0],
 			IsDir:    !isFile,
 			Children: make([]*TreeNode, 0),
+			sortName: parts[0],
+		}
+		if i := len(entry.Order) - len(parts); i >= 0 {
+			child.order = entry.Order[i]
 		}
 		if isFile {
-			// Convert path to URL (remove .md extension)
-			urlPath := strings.TrimSuffix(entry.RelPath, ".md")
-			urlPath = strings.TrimSuffix(urlPath, ".MD")
-			child.Path = "/" + filepath.ToSlash(urlPath)
+			child.Path = entryURLPath(entry)
+			child.Attachment = entry.Attachment
+			child.Size = entry.Size
+			child.Preview = entry.Preview
 		}
 		parent.Children = append(parent.Children, child)
 	}
 
 	// Recurse for directories
 	if len(parts) > 1 {
-		insertNode(child, parts[1:], entry)
-	}
-}
-
-// sortTree recursively sorts the tree nodes (directories first, then alphabetically).
-func sortTree(node *TreeNode) {
-	sort.Slice(node.Children, func(i, j int) bool {
-		// Directories first
-		if node.Children[i].IsDir != node.Children[j].IsDir {
-			return node.Children[i].IsDir
-		}
-		// Then alphabetically
-		return strings.ToLower(node.Children[i].Name) < strings.ToLower(node.Children[j].Name)
-	})
-
-	for _, child := range node.Children {
-		if child.IsDir {
-			sortTree(child)
-		}
+		insertNode(child, parts[1:], names[1:], entry)
 	}
 }
 
@@ -156,19 +256,41 @@ func RenderTree(node *TreeNode) string {
 // RenderTreeWithActive generates an HTML tree view with the active page highlighted.
 func RenderTreeWithActive(node *TreeNode, currentPath string) string {
 	var sb strings.Builder
-	renderTreeNode(&sb, node, currentPath, 0, "")
+	renderTreeNode(&sb, node, currentPath, 0, "", false)
 	return sb.String()
 }
 
+// RenderTreeLazy renders the tree like RenderTreeWithActive, but leaves out
+// the entries of folders that start collapsed. Those folders carry a
+// data-lazy attribute, and the browser loads their entries from /api/tree
+// when they are opened, which keeps pages small on huge trees.
+func RenderTreeLazy(node *TreeNode, currentPath string) string {
+	var sb strings.Builder
+	renderTreeNode(&sb, node, currentPath, 0, "", true)
+	return sb.String()
+}
+
+// containsPath reports whether a file below node has the URL path p.
+func containsPath(node *TreeNode, p string) bool {
+	for _, child := range node.Children {
+		if (!child.IsDir && child.Path == p && p != "") || containsPath(child, p) {
+			return true
+		}
+	}
+	return false
+}
+
 // renderTreeNode recursively renders a tree node as HTML.
 // Directories use <details>/<summary> for collapsible folders.
-// Depth 1 folders default to open; deeper folders default to collapsed.
-func renderTreeNode(sb *strings.Builder, node *TreeNode, currentPath string, depth int, parentPath string) {
+// Depth 1 folders and the folders holding the current page default to
+// open, so it shows without JavaScript; other folders default to collapsed.
+// With lazy set, collapsed folders are rendered without their entries.
+func renderTreeNode(sb *strings.Builder, node *TreeNode, currentPath string, depth int, parentPath string, lazy bool) {
 	// Skip the root node itself, just render its children
 	if depth == 0 {
 		sb.WriteString("<ul class=\"file-tree\">\n")
 		for _, child := range node.Children {
-			renderTreeNode(sb, child, currentPath, depth+1, "")
+			renderTreeNode(sb, child, currentPath, depth+1, "", lazy)
 		}
 		sb.WriteString("</ul>\n")
 		return
@@ -179,27 +301,35 @@ func renderTreeNode(sb *strings.Builder, node *TreeNode, currentPath string, dep
 	sb.WriteString("<li>")
 	if node.IsDir {
 		openAttr := ""
-		if depth == 1 {
+		if depth == 1 || containsPath(node, currentPath) {
 			openAttr = " open"
 		}
 		sb.WriteString("<details class=\"folder-details\" data-folder=\"")
 		sb.WriteString(escapeHTML(folderPath))
 		sb.WriteString("\"")
 		sb.WriteString(openAttr)
+		deferred := lazy && openAttr == "" && len(node.Children) > 0
+		if deferred {
+			sb.WriteString(" data-lazy")
+		}
 		sb.WriteString(">\n")
 		sb.WriteString("<summary class=\"folder\">")
 		sb.WriteString(escapeHTML(node.Name))
 		sb.WriteString("</summary>\n")
-		if len(node.Children) > 0 {
+		if deferred {
+			sb.WriteString("<ul></ul>\n")
+		} else if len(node.Children) > 0 {
 			sb.WriteString("<ul>\n")
 			for _, child := range node.Children {
-				renderTreeNode(sb, child, currentPath, depth+1, folderPath)
+				renderTreeNode(sb, child, currentPath, depth+1, folderPath, lazy)
 			}
 			sb.WriteString("</ul>\n")
 		}
 		sb.WriteString("</details>\n")
 	}
-	if !node.IsDir {
+	if node.Attachment {
+		renderAttachment(sb, node)
+	} else if !node.IsDir {
 		classes := "file"
 		if node.Path == currentPath {
 			classes = "file active"
@@ -216,6 +346,7 @@ func renderTreeNode(sb *strings.Builder, node *TreeNode, currentPath string, dep
 }
 
 // FlatPaths returns an ordered list of URL paths from the tree (depth-first).
+// Attachments are left out, so page navigation only steps through pages.
 func FlatPaths(node *TreeNode) []PathEntry {
 	var result []PathEntry
 	flattenNode(node, &result)
@@ -230,6 +361,9 @@ type PathEntry struct {
 
 // flattenNode recursively collects file paths in tree order.
 func flattenNode(node *TreeNode, result *[]PathEntry) {
+	if node.Attachment {
+		return
+	}
 	if !node.IsDir && node.Path != "" {
 		*result = append(*result, PathEntry{Path: node.Path, Name: node.Name})
 		return
@@ -0,0 +1,351 @@
+package scanner
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+// writeFiles creates the given files (relative path to content) under dir.
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		os.MkdirAll(filepath.Dir(path), 0o755)
+		os.WriteFile(path, []byte(content), 0o644)
+	}
+}
+
+func TestScanDirectoryMarksHiddenEntries(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"visible.md":         "# Visible",
+		"appendix.md":        "---\nnav: false\n---\n# Appendix",
+		"archive/_dir.yml":   "hidden: true\n",
+		"archive/2020.md":    "# Old changelog",
+		"guide/_dir.yml":     "title: Guide\n",
+		"guide/start.md":     "# Start",
+		".hidden/ignored.md": "# Ignored",
+	})
+
+	entries, err := ScanDirectory(dir)
+	if err != nil {
+		t.Fatalf("ScanDirectory failed: %v", err)
+	}
+
+	hidden := make(map[string]bool)
+	for _, entry := range entries {
+		hidden[filepath.ToSlash(entry.RelPath)] = entry.Hidden
+	}
+
+	want := map[string]bool{
+		"visible.md":      false,
+		"appendix.md":     true,
+		"archive/2020.md": true,
+		"guide/start.md":  false,
+	}
+	if len(hidden) != len(want) {
+		t.Fatalf("expected %d entries, got %v", len(want), hidden)
+	}
+	for path, wantHidden := range want {
+		if hidden[path] != wantHidden {
+			t.Errorf("%s: expected Hidden=%v, got %v", path, wantHidden, hidden[path])
+		}
+	}
+}
+
+// countingFS counts the bytes read from the files of an fs.FS.
+type countingFS struct {
+	fs.FS
+	read *int64
+}
+
+func (c countingFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	return fs.ReadDir(c.FS, name)
+}
+
+func (c countingFS) Open(name string) (fs.File, error) {
+	f, err := c.FS.Open(name)
+	return countingFile{f, c.read}, err
+}
+
+type countingFile struct {
+	fs.File
+	read *int64
+}
+
+func (c countingFile) Read(p []byte) (int, error) {
+	n, err := c.File.Read(p)
+	*c.read += int64(n)
+	return n, err
+}
+
+func TestScanFSReadsBoundedFrontmatter(t *testing.T) {
+	body := strings.Repeat("Lorem ipsum dolor sit amet.\n", 1<<16)
+	var read int64
+	fsys := countingFS{fstest.MapFS{
+		"huge.md":    {Data: []byte("---\nnav: false\n---\n" + body + body)},
+		"long.md":    {Data: []byte("---\nnav: false\n---\n" + body)},
+		"visible.md": {Data: []byte("# Visible\n")},
+	}, &read}
+
+	entries, err := ScanFS(fsys, ScanOptions{MaxPageBytes: int64(len(body)) + 100})
+	if err != nil {
+		t.Fatalf("ScanFS failed: %v", err)
+	}
+	hidden := make(map[string]bool)
+	for _, entry := range entries {
+		hidden[entry.RelPath] = entry.Hidden
+	}
+	if hidden["huge.md"] || !hidden["long.md"] || hidden["visible.md"] {
+		t.Errorf("expected only long.md hidden, got %v", hidden)
+	}
+	if read > 2*maxFrontmatterBytes {
+		t.Errorf("expected at most the start of each file read, read %d bytes", read)
+	}
+}
+
+func TestScanFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"index.md":          {Data: []byte("# Home")},
+		"guide/01-start.md": {Data: []byte("# Start")},
+		"guide/notes.txt":   {Data: []byte("not markdown")},
+		"drafts/_dir.yml":   {Data: []byte("hidden: true\n")},
+		"drafts/idea.md":    {Data: []byte("# Idea")},
+		".git/HEAD.md":      {Data: []byte("# Ignored")},
+	}
+
+	entries, err := ScanFS(fsys, ScanOptions{StripNumericPrefix: true})
+	if err != nil {
+		t.Fatalf("ScanFS failed: %v", err)
+	}
+
+	var got []string
+	for _, entry := range entries {
+		got = append(got, entry.URLPath)
+		if entry.URLPath == "/drafts/idea" && !entry.Hidden {
+			t.Errorf("expected entry in hidden directory to be hidden")
+		}
+	}
+	want := "/drafts/idea /guide/start /index"
+	if strings.Join(got, " ") != want {
+		t.Errorf("expected %s, got %v", want, got)
+	}
+}
+
+func TestBuildTreeFollowsOrderFiles(t *testing.T) {
+	fsys := fstest.MapFS{
+		"_order.yml":         {Data: []byte("# Sidebar\n- intro\n- guides\n- \"faq.md\"\n")},
+		"intro.md":           {Data: []byte("# Intro")},
+		"faq.md":             {Data: []byte("# FAQ")},
+		"about.md":           {Data: []byte("# About")},
+		"api/index.md":       {Data: []byte("# API")},
+		"guides/_order.yml":  {Data: []byte("- setup\n")},
+		"guides/advanced.md": {Data: []byte("# Advanced")},
+		"guides/setup.md":    {Data: []byte("# Setup")},
+	}
+	entries, err := ScanFS(fsys, ScanOptions{})
+	if err != nil {
+		t.Fatalf("ScanFS failed: %v", err)
+	}
+
+	var got []string
+	for _, entry := range FlatPaths(BuildTree(entries)) {
+		got = append(got, entry.Path)
+	}
+	want := "/intro /guides/setup /guides/advanced /faq /api/index /about"
+	if strings.Join(got, " ") != want {
+		t.Errorf("expected %s, got %v", want, got)
+	}
+}
+
+func TestBuildTreeSkipsHiddenEntries(t *testing.T) {
+	tree := BuildTree([]FileEntry{
+		{RelPath: "visible.md", Name: "visible"},
+		{RelPath: "archive/2020.md", Name: "2020", Hidden: true},
+	})
+
+	if len(tree.Children) != 1 {
+		t.Fatalf("expected 1 visible node, got %d", len(tree.Children))
+	}
+	if tree.Children[0].Path != "/visible" {
+		t.Errorf("expected /visible, got %s", tree.Children[0].Path)
+	}
+
+	flat := FlatPaths(tree)
+	if len(flat) != 1 {
+		t.Errorf("expected hidden pages to be excluded from prev/next order, got %v", flat)
+	}
+}
+
+func TestRenderTreeOpensFoldersOfCurrentPage(t *testing.T) {
+	tree := BuildTree([]FileEntry{
+		{RelPath: "guide/setup/install.md", Name: "install"},
+		{RelPath: "guide/setup/upgrade.md", Name: "upgrade"},
+		{RelPath: "reference/other/api.md", Name: "api"},
+	})
+
+	html := RenderTreeWithActive(tree, "/guide/setup/install")
+	if !strings.Contains(html, `data-folder="/guide/setup" open>`) {
+		t.Errorf("expected the folder of the current page open, got:\n%s", html)
+	}
+	if strings.Contains(html, `data-folder="/reference/other" open>`) {
+		t.Errorf("expected other nested folders collapsed, got:\n%s", html)
+	}
+}
+
+func TestRenderTreeLazy(t *testing.T) {
+	tree := BuildTree([]FileEntry{
+		{RelPath: "guide/setup/install.md", Name: "install"},
+		{RelPath: "reference/other/api.md", Name: "api"},
+	})
+
+	html := RenderTreeLazy(tree, "/guide/setup/install")
+	if !strings.Contains(html, `data-folder="/guide/setup" open>`) || !strings.Contains(html, `href="/guide/setup/install"`) {
+		t.Errorf("expected the folders of the current page rendered, got:\n%s", html)
+	}
+	if !strings.Contains(html, `data-folder="/reference/other" data-lazy>`) || strings.Contains(html, "/reference/other/api") {
+		t.Errorf("expected collapsed folders left for the browser, got:\n%s", html)
+	}
+
+	if node := Subtree(tree, "/reference/other"); node == nil || len(node.Children) != 1 || node.Children[0].Path != "/reference/other/api" {
+		t.Errorf("expected the folder found by its path, got %+v", node)
+	}
+	if Subtree(tree, "/") != tree || Subtree(tree, "/missing") != nil || Subtree(tree, "/guide/setup/install.md") != nil {
+		t.Errorf("expected the root for / and nothing for unknown folders or pages")
+	}
+}
+
+// childNames returns the names of the root's children in order.
+func childNames(tree *TreeNode) []string {
+	var names []string
+	for _, child := range tree.Children {
+		names = append(names, child.Name)
+	}
+	return names
+}
+
+func TestBuildTreeNaturalSort(t *testing.T) {
+	entries := []FileEntry{
+		{RelPath: "chapter10.md", Name: "chapter10"},
+		{RelPath: "chapter2.md", Name: "chapter2"},
+		{RelPath: "Chapter1.md", Name: "Chapter1"},
+	}
+
+	got := strings.Join(childNames(BuildTree(entries)), ",")
+	if got != "Chapter1.md,chapter2.md,chapter10.md" {
+		t.Errorf("unexpected natural order: %s", got)
+	}
+
+	got = strings.Join(childNames(BuildTreeSorted(entries, SortOptions{Lexical: true})), ",")
+	if got != "Chapter1.md,chapter10.md,chapter2.md" {
+		t.Errorf("unexpected lexical order: %s", got)
+	}
+}
+
+func TestBuildTreeLocaleSort(t *testing.T) {
+	entries := []FileEntry{
+		{RelPath: "zebra.md", Name: "zebra"},
+		{RelPath: "äpfel.md", Name: "äpfel"},
+		{RelPath: "birne.md", Name: "birne"},
+	}
+
+	got := strings.Join(childNames(BuildTreeSorted(entries, SortOptions{Locale: "de"})), ",")
+	if got != "äpfel.md,birne.md,zebra.md" {
+		t.Errorf("unexpected German collation: %s", got)
+	}
+}
+
+func TestSortOptionsValidate(t *testing.T) {
+	if err := (SortOptions{Locale: "de"}).Validate(); err != nil {
+		t.Errorf("expected valid locale, got %v", err)
+	}
+	if err := (SortOptions{Locale: "not a locale!"}).Validate(); err == nil {
+		t.Error("expected error for invalid locale")
+	}
+}
+
+func TestStripNumericPrefix(t *testing.T) {
+	cases := map[string]string{
+		"01-introduction": "introduction",
+		"2_setup":         "setup",
+		"10. appendix":    "appendix",
+		"2024":            "2024",
+		"plain":           "plain",
+	}
+	for input, want := range cases {
+		if got := StripNumericPrefix(input); got != want {
+			t.Errorf("StripNumericPrefix(%q) = %q, want %q", input, got, want)
+		}
+	}
+
+	if got := StripNumericPrefixes("02-guides/01-setup"); got != "guides/setup" {
+		t.Errorf("expected guides/setup, got %s", got)
+	}
+}
+
+func TestScanDirectoryStripsNumericPrefixes(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"10-appendix.md":        "# Appendix",
+		"02-usage.md":           "# Usage",
+		"01-introduction.md":    "# Intro",
+		"03-guides/01-setup.md": "# Setup",
+	})
+
+	entries, err := ScanDirectoryWithOptions(dir, ScanOptions{StripNumericPrefix: true})
+	if err != nil {
+		t.Fatalf("scan failed: %v", err)
+	}
+
+	tree := BuildTree(entries)
+	var paths []string
+	for _, entry := range FlatPaths(tree) {
+		paths = append(paths, entry.Path)
+	}
+	got := strings.Join(paths, ",")
+	if got != "/guides/setup,/introduction,/usage,/appendix" {
+		t.Errorf("unexpected routes or order: %s", got)
+	}
+	if tree.Children[0].Name != "guides" || tree.Children[1].Name != "introduction.md" {
+		t.Errorf("expected prefixes stripped from labels, got %s, %s", tree.Children[0].Name, tree.Children[1].Name)
+	}
+}
+
+func TestScanAttachments(t *testing.T) {
+	fsys := fstest.MapFS{
+		"index.md":            {Data: []byte("# Home")},
+		"manuals/setup.PDF":   {Data: make([]byte, 2048)},
+		"manuals/notes.txt":   {Data: []byte("notes")},
+		"manuals/photo.png":   {Data: []byte("png")},
+		"drafts/_dir.yml":     {Data: []byte("hidden: true\n")},
+		"drafts/plan.pdf":     {Data: []byte("pdf")},
+		".private/secret.pdf": {Data: []byte("pdf")},
+	}
+
+	entries, err := ScanAttachments(fsys, []string{"pdf"})
+	if err != nil {
+		t.Fatalf("ScanAttachments failed: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 attachments, got %+v", entries)
+	}
+	if entries[0].URLPath != "/drafts/plan.pdf" || !entries[0].Hidden {
+		t.Errorf("expected attachment in hidden directory to be hidden, got %+v", entries[0])
+	}
+	setup := entries[1]
+	if setup.URLPath != "/manuals/setup.PDF" || setup.Size != 2048 || !setup.Attachment {
+		t.Errorf("unexpected attachment %+v", setup)
+	}
+
+	html := RenderTree(BuildTree(entries))
+	if !strings.Contains(html, `<a href="/manuals/setup.PDF" class="file attachment" data-type="pdf" download>setup.PDF</a> <span class="attachment-size">2.0 KB</span>`) {
+		t.Errorf("expected attachment link with size, got:\n%s", html)
+	}
+	if len(FlatPaths(BuildTree(entries))) != 0 {
+		t.Errorf("expected attachments left out of page navigation")
+	}
+}
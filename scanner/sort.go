@@ -0,0 +1,130 @@
+package scanner
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/collate"
+	"golang.org/x/text/language"
+)
+
+// SortOptions configures how sibling nodes in the tree are ordered.
+type SortOptions struct {
+	// Lexical disables natural ordering, restoring plain case-insensitive
+	// string comparison (so chapter10 sorts before chapter2).
+	Lexical bool
+	// Locale, when set, collates names with the rules of that BCP 47
+	// language tag (e.g. "de", "sv") instead of byte order.
+	Locale string
+}
+
+// Validate returns an error if the locale is not a valid BCP 47 language tag.
+func (o SortOptions) Validate() error {
+	if o.Locale == "" {
+		return nil
+	}
+	if _, err := language.Parse(o.Locale); err != nil {
+		return fmt.Errorf("invalid sort locale %q: %w", o.Locale, err)
+	}
+	return nil
+}
+
+// lessFunc reports whether name a sorts before name b.
+type lessFunc func(a, b string) bool
+
+// comparator returns the name comparison described by the options.
+// A collator is created per call because collate.Collator is not safe
+// for concurrent use.
+func (o SortOptions) comparator() lessFunc {
+	if o.Locale != "" {
+		var options []collate.Option
+		options = append(options, collate.IgnoreCase)
+		if !o.Lexical {
+			options = append(options, collate.Numeric)
+		}
+		collator := collate.New(language.Make(o.Locale), options...)
+		return func(a, b string) bool {
+			return collator.CompareString(a, b) < 0
+		}
+	}
+	if o.Lexical {
+		return func(a, b string) bool {
+			return strings.ToLower(a) < strings.ToLower(b)
+		}
+	}
+	return naturalLess
+}
+
+// naturalLess compares names case-insensitively, treating runs of digits
+// as numbers so that chapter2 sorts before chapter10.
+func naturalLess(a, b string) bool {
+	ra, rb := []rune(strings.ToLower(a)), []rune(strings.ToLower(b))
+	i, j := 0, 0
+	for i < len(ra) && j < len(rb) {
+		if unicode.IsDigit(ra[i]) && unicode.IsDigit(rb[j]) {
+			numA, nextI := digitRun(ra, i)
+			numB, nextJ := digitRun(rb, j)
+			if cmp := compareNumeric(numA, numB); cmp != 0 {
+				return cmp < 0
+			}
+			i, j = nextI, nextJ
+			continue
+		}
+		if ra[i] != rb[j] {
+			return ra[i] < rb[j]
+		}
+		i++
+		j++
+	}
+	if len(ra)-i != len(rb)-j {
+		return len(ra)-i < len(rb)-j
+	}
+	return a < b
+}
+
+// digitRun returns the digits starting at start (without leading zeros)
+// and the index just past the run.
+func digitRun(runes []rune, start int) (string, int) {
+	end := start
+	for end < len(runes) && unicode.IsDigit(runes[end]) {
+		end++
+	}
+	digits := strings.TrimLeft(string(runes[start:end]), "0")
+	return digits, end
+}
+
+// compareNumeric compares two digit strings without leading zeros by value.
+func compareNumeric(a, b string) int {
+	if len(a) != len(b) {
+		return len(a) - len(b)
+	}
+	return strings.Compare(a, b)
+}
+
+// sortTree recursively sorts the tree nodes (entries listed in _order.yml
+// first, then directories, then by raw name so numeric ordering prefixes
+// apply even when they are not displayed).
+func sortTree(node *TreeNode, less lessFunc) {
+	sort.SliceStable(node.Children, func(i, j int) bool {
+		a, b := node.Children[i], node.Children[j]
+		if a.order != b.order && (a.order == 0 || b.order == 0) {
+			return a.order != 0
+		}
+		if a.order != b.order {
+			return a.order < b.order
+		}
+		// Directories first
+		if node.Children[i].IsDir != node.Children[j].IsDir {
+			return node.Children[i].IsDir
+		}
+		return less(node.Children[i].sortName, node.Children[j].sortName)
+	})
+
+	for _, child := range node.Children {
+		if child.IsDir {
+			sortTree(child, less)
+		}
+	}
+}
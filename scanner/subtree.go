@@ -0,0 +1,27 @@
+package scanner
+
+import "strings"
+
+// Subtree returns the folder of the tree at the URL path dir, such as
+// /guide/advanced, matching the data-folder attributes of rendered trees.
+// "/" is the root. It returns nil when there is no such folder.
+func Subtree(root *TreeNode, dir string) *TreeNode {
+	node := root
+	for segment := range strings.SplitSeq(strings.Trim(dir, "/"), "/") {
+		if segment == "" {
+			continue
+		}
+		var next *TreeNode
+		for _, child := range node.Children {
+			if child.IsDir && child.Name == segment {
+				next = child
+				break
+			}
+		}
+		if next == nil {
+			return nil
+		}
+		node = next
+	}
+	return node
+}
@@ -0,0 +1,108 @@
+package search
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"gomdoc/clock"
+)
+
+// AuthorPage is a document on an author page.
+type AuthorPage struct {
+	// Title is the document title.
+	Title string `json:"title"`
+	// Path is the URL path.
+	Path string `json:"path"`
+	// Summary is the description frontmatter or the first paragraph.
+	Summary string `json:"summary,omitempty"`
+	// Date is the document date.
+	Date time.Time `json:"date"`
+}
+
+// AuthorPages are the documents of one author, newest first.
+type AuthorPages struct {
+	// Name is the author as written in the frontmatter or the git history.
+	Name string `json:"name"`
+	// Pages are the author's documents.
+	Pages []AuthorPage `json:"pages"`
+}
+
+// blame is a cached git author of a file.
+type blame struct {
+	// commitTime is the last commit time of the file when it was blamed.
+	commitTime time.Time
+	// author is the author of most lines.
+	author string
+}
+
+// SetGitAuthors sets whether Build attributes documents without author
+// frontmatter to the git author of most of their lines. It takes effect
+// with the next Build.
+func (idx *Index) SetGitAuthors(enabled bool) {
+	idx.gitAuthors.Store(enabled)
+}
+
+// blameAuthor returns the git author of the file at name below baseDir,
+// running git blame only when the file was committed since it was last
+// blamed.
+func (idx *Index) blameAuthor(baseDir, name string, commitTime time.Time) string {
+	key := baseDir + "\x00" + name
+	if cached, ok := idx.blames.Load(key); ok && cached.(blame).commitTime.Equal(commitTime) {
+		return cached.(blame).author
+	}
+	author := gitBlameAuthor(baseDir, name)
+	idx.blames.Store(key, blame{commitTime: commitTime, author: author})
+	return author
+}
+
+// SplitAuthors splits an author frontmatter value naming several authors
+// separated by commas.
+func SplitAuthors(value string) []string {
+	var authors []string
+	for _, author := range strings.Split(value, ",") {
+		if author = strings.TrimSpace(author); author != "" {
+			authors = append(authors, author)
+		}
+	}
+	return authors
+}
+
+// Authors groups the published documents by author, sorted by name
+// ignoring case. A document with several authors is listed under each.
+// Documents without an author are left out.
+func (idx *Index) Authors() []AuthorPages {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	now := clock.Now()
+	byAuthor := make(map[string][]AuthorPage)
+	for _, doc := range idx.docs {
+		if !doc.schedule.Live(now) {
+			continue
+		}
+		page := AuthorPage{Title: doc.title, Path: doc.path, Summary: doc.summary, Date: doc.date}
+		for _, author := range SplitAuthors(doc.meta.Author) {
+			byAuthor[author] = append(byAuthor[author], page)
+		}
+	}
+
+	authors := make([]AuthorPages, 0, len(byAuthor))
+	for name, pages := range byAuthor {
+		sort.Slice(pages, func(i, j int) bool {
+			if !pages[i].Date.Equal(pages[j].Date) {
+				return pages[i].Date.After(pages[j].Date)
+			}
+			return pages[i].Path < pages[j].Path
+		})
+		authors = append(authors, AuthorPages{Name: name, Pages: pages})
+	}
+	sort.Slice(authors, func(i, j int) bool {
+		a, b := authors[i].Name, authors[j].Name
+		if !strings.EqualFold(a, b) {
+			return strings.ToLower(a) < strings.ToLower(b)
+		}
+		return a < b
+	})
+	return authors
+}
@@ -0,0 +1,91 @@
+package search
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestAuthors(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"a.md":     "---\nauthor: zoe\ndate: 2024-01-10\n---\n# A\n",
+		"b.md":     "---\nauthor: Anna, zoe\ndate: 2024-03-01\n---\n# B\n",
+		"c.md":     "# C\n",
+		"later.md": "---\nauthor: Anna\npublish_at: 2999-01-01\n---\n# Later\n",
+	}
+	for name, content := range files {
+		os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644)
+	}
+
+	idx := NewIndex()
+	if err := idx.Build(dir); err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+
+	got := make(map[string][]string)
+	var names []string
+	for _, author := range idx.Authors() {
+		names = append(names, author.Name)
+		for _, page := range author.Pages {
+			got[author.Name] = append(got[author.Name], page.Path)
+		}
+	}
+	if want := []string{"Anna", "zoe"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("expected authors %q, got %q", want, names)
+	}
+	want := map[string][]string{"Anna": {"/b"}, "zoe": {"/b", "/a"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected pages %v, got %v", want, got)
+	}
+}
+
+func TestGitAuthors(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	dir := t.TempDir()
+	commit := func(name string) {
+		t.Helper()
+		for _, args := range [][]string{{"add", "-A"}, {"commit", "-q", "-m", "update"}} {
+			cmd := exec.Command("git", args...)
+			cmd.Dir = dir
+			cmd.Env = append(os.Environ(), "GIT_AUTHOR_NAME="+name, "GIT_AUTHOR_EMAIL=a@example.com",
+				"GIT_COMMITTER_NAME=c", "GIT_COMMITTER_EMAIL=c@example.com")
+			if out, err := cmd.CombinedOutput(); err != nil {
+				t.Fatalf("git %v: %v\n%s", args, err, out)
+			}
+		}
+	}
+	if out, err := exec.Command("git", "init", "-q", dir).CombinedOutput(); err != nil {
+		t.Fatalf("git init: %v\n%s", err, out)
+	}
+	os.WriteFile(filepath.Join(dir, "guide.md"), []byte("# Guide\n\nOne.\n"), 0o644)
+	os.WriteFile(filepath.Join(dir, "signed.md"), []byte("---\nauthor: Carla\n---\n# Signed\n"), 0o644)
+	commit("Bea")
+	os.WriteFile(filepath.Join(dir, "guide.md"), []byte("# Guide\n\nOne.\n\nTwo.\n\nThree.\n"), 0o644)
+	commit("Dan")
+
+	authors := func(git bool) map[string][]string {
+		idx := NewIndex()
+		idx.SetGitAuthors(git)
+		if err := idx.Build(dir); err != nil {
+			t.Fatalf("Build failed: %v", err)
+		}
+		got := make(map[string][]string)
+		for _, author := range idx.Authors() {
+			for _, page := range author.Pages {
+				got[author.Name] = append(got[author.Name], page.Path)
+			}
+		}
+		return got
+	}
+	if got, want := authors(false), map[string][]string{"Carla": {"/signed"}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected only frontmatter authors without git authors, got %v", got)
+	}
+	if got, want := authors(true), map[string][]string{"Carla": {"/signed"}, "Dan": {"/guide"}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
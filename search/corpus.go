@@ -0,0 +1,231 @@
+package search
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gomdoc/clock"
+)
+
+// DefaultChunkSize is the longest chunk Chunks makes, in characters, when
+// no size is given. It keeps chunks well within the input limits of common
+// embedding models.
+const DefaultChunkSize = 2000
+
+// Chunk is a passage of a document as plain text with its context, for
+// embedding pipelines and retrieval-augmented chat over the docs.
+type Chunk struct {
+	// ID identifies the chunk: the document path and its position.
+	ID string `json:"id"`
+	// Path is the URL path of the document.
+	Path string `json:"path"`
+	// Title is the document title.
+	Title string `json:"title"`
+	// Headings are the headings the passage is under, outermost first.
+	Headings []string `json:"headings,omitempty"`
+	// Tags is the list of frontmatter tags.
+	Tags []string `json:"tags,omitempty"`
+	// Text is the passage without markdown syntax, paragraphs separated
+	// by blank lines.
+	Text string `json:"text"`
+}
+
+// tableRulePattern matches the line under a table header, like |---|:-:|.
+var tableRulePattern = regexp.MustCompile(`^\|?\s*:?-+:?\s*(\|\s*:?-+:?\s*)*\|?$`)
+
+// blockPrefixPattern matches the markers that start quotes and list items.
+var blockPrefixPattern = regexp.MustCompile(`^(>\s?)+|^([-*+]|\d+[.)])\s+(\[[ xX]\]\s+)?`)
+
+// Chunks splits the published documents into plain-text passages of at
+// most size characters, sorted by path. Documents are split at their
+// headings first, then between paragraphs, and paragraphs longer than
+// size between words. A size of zero or less uses DefaultChunkSize.
+func (idx *Index) Chunks(size int) []Chunk {
+	if size <= 0 {
+		size = DefaultChunkSize
+	}
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	now := clock.Now()
+	docs := make([]document, 0, len(idx.docs))
+	for _, doc := range idx.docs {
+		if doc.schedule.Live(now) {
+			docs = append(docs, doc)
+		}
+	}
+	sort.Slice(docs, func(i, j int) bool { return docs[i].path < docs[j].path })
+
+	var chunks []Chunk
+	for _, doc := range docs {
+		n := 0
+		for _, section := range documentSections(doc) {
+			for _, text := range splitText(section.text, size) {
+				n++
+				chunks = append(chunks, Chunk{
+					ID:       fmt.Sprintf("%s#%d", doc.path, n),
+					Path:     doc.path,
+					Title:    doc.title,
+					Headings: section.headings,
+					Tags:     doc.meta.Tags,
+					Text:     text,
+				})
+			}
+		}
+	}
+	return chunks
+}
+
+// corpusSection is the plain text under one heading of a document.
+type corpusSection struct {
+	headings []string
+	text     string
+}
+
+// documentSections splits a document at its headings, skipping lines in
+// code blocks that look like headings, and cleans the text of each part,
+// leaving out parts without text.
+func documentSections(doc document) []corpusSection {
+	var sections []corpusSection
+	var trail []Heading
+	var body []string
+	flush := func() {
+		if text := cleanMarkdown(body); text != "" {
+			section := corpusSection{text: text}
+			for _, heading := range trail {
+				section.headings = append(section.headings, heading.Text)
+			}
+			sections = append(sections, section)
+		}
+		body = nil
+	}
+	inCode := false
+	for _, line := range strings.Split(doc.raw, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			inCode = !inCode
+		}
+		match := headingPattern.FindStringSubmatch(line)
+		if inCode || match == nil {
+			body = append(body, line)
+			continue
+		}
+		flush()
+		level := len(match[1])
+		for len(trail) > 0 && trail[len(trail)-1].Level >= level {
+			trail = trail[:len(trail)-1]
+		}
+		text := headingAttributesPattern.ReplaceAllString(strings.TrimSpace(match[2]), "")
+		trail = append(trail, Heading{Level: level, Text: plainText(text)})
+	}
+	flush()
+	return sections
+}
+
+// cleanMarkdown turns markdown lines into plain text paragraphs. Code
+// blocks keep their lines; other text loses its markdown syntax.
+func cleanMarkdown(lines []string) string {
+	var paragraphs []string
+	var current []string
+	inCode := false
+	end := func() {
+		if len(current) > 0 {
+			paragraphs = append(paragraphs, strings.Join(current, "\n"))
+			current = nil
+		}
+	}
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			end()
+			inCode = !inCode
+			continue
+		}
+		if inCode {
+			if trimmed != "" {
+				current = append(current, strings.TrimRight(line, " \t"))
+			}
+			continue
+		}
+		if trimmed == "" {
+			end()
+			continue
+		}
+		if tableRulePattern.MatchString(trimmed) {
+			continue
+		}
+		trimmed = blockPrefixPattern.ReplaceAllString(trimmed, "")
+		if strings.HasPrefix(trimmed, "|") {
+			cells := strings.Split(strings.Trim(trimmed, "|"), "|")
+			for i, cell := range cells {
+				cells[i] = strings.TrimSpace(cell)
+			}
+			trimmed = strings.Join(cells, " | ")
+		}
+		if text := plainText(trimmed); text != "" {
+			current = append(current, text)
+		}
+	}
+	end()
+	return strings.Join(paragraphs, "\n\n")
+}
+
+// splitText cuts text into pieces of at most size characters between
+// paragraphs, or between words inside paragraphs that are too long.
+func splitText(text string, size int) []string {
+	var pieces []string
+	var current string
+	for _, paragraph := range strings.Split(text, "\n\n") {
+		for _, part := range splitWords(paragraph, size) {
+			switch {
+			case current == "":
+				current = part
+			case len([]rune(current))+2+len([]rune(part)) <= size:
+				current += "\n\n" + part
+			default:
+				pieces = append(pieces, current)
+				current = part
+			}
+		}
+	}
+	if current != "" {
+		pieces = append(pieces, current)
+	}
+	return pieces
+}
+
+// splitWords cuts a paragraph into pieces of at most size characters
+// between words. Words longer than size are cut.
+func splitWords(paragraph string, size int) []string {
+	if len([]rune(paragraph)) <= size {
+		return []string{paragraph}
+	}
+	var pieces []string
+	var current []rune
+	for _, word := range strings.Fields(paragraph) {
+		runes := []rune(word)
+		for len(runes) > size {
+			if len(current) > 0 {
+				pieces = append(pieces, string(current))
+				current = nil
+			}
+			pieces = append(pieces, string(runes[:size]))
+			runes = runes[size:]
+		}
+		switch {
+		case len(current) == 0:
+			current = runes
+		case len(current)+1+len(runes) <= size:
+			current = append(append(current, ' '), runes...)
+		default:
+			pieces = append(pieces, string(current))
+			current = runes
+		}
+	}
+	if len(current) > 0 {
+		pieces = append(pieces, string(current))
+	}
+	return pieces
+}
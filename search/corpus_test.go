@@ -0,0 +1,58 @@
+package search
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestChunks(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"guide.md": "---\ntitle: Install Guide\ntags: [setup]\n---\nRead this **first**.\n\n## Linux\n\nRun the [installer](setup.md).\n\n```bash\n# not a heading\nmake install\n```\n\n### Debian\n\n| Package | Version |\n|---|---|\n| gomdoc | 1.0 |\n\n## Windows\n\n- Download the *zip*\n- Unpack it\n",
+		"long.md":  "# Long\n\n" + strings.Repeat("word ", 60) + "\n\n" + strings.Repeat("text ", 10) + "\n",
+		"later.md": "---\npublish_at: 2999-01-01\n---\n# Later\n\nNot yet.\n",
+	}
+	for name, content := range files {
+		os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644)
+	}
+	idx := NewIndex()
+	if err := idx.Build(dir); err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+
+	var guide []Chunk
+	for _, chunk := range idx.Chunks(0) {
+		if chunk.Path == "/later" {
+			t.Errorf("expected unpublished pages left out, got %+v", chunk)
+		}
+		if chunk.Path == "/guide" {
+			guide = append(guide, chunk)
+		}
+	}
+	want := []struct{ headings, text string }{
+		{"", "Read this first."},
+		{"Linux", "Run the installer.\n\n# not a heading\nmake install"},
+		{"Linux/Debian", "Package | Version\ngomdoc | 1.0"},
+		{"Windows", "Download the zip\nUnpack it"},
+	}
+	if len(guide) != len(want) {
+		t.Fatalf("expected %d chunks, got %+v", len(want), guide)
+	}
+	for i, w := range want {
+		chunk := guide[i]
+		if got := strings.Join(chunk.Headings, "/"); got != w.headings || chunk.Text != w.text {
+			t.Errorf("chunk %d: expected %q under %q, got %q under %q", i, w.text, w.headings, chunk.Text, got)
+		}
+		if chunk.Title != "Install Guide" || strings.Join(chunk.Tags, ",") != "setup" || chunk.ID != "/guide#"+string(rune('1'+i)) {
+			t.Errorf("chunk %d: unexpected metadata %+v", i, chunk)
+		}
+	}
+
+	for _, chunk := range idx.Chunks(100) {
+		if chunk.Path == "/long" && len(chunk.Text) > 100 {
+			t.Errorf("expected chunks of at most 100 characters, got %d", len(chunk.Text))
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package search
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DefaultDateFormat is the layout pages show their dates in unless the
+// site configures another.
+const DefaultDateFormat = "January 2, 2006"
+
+// dateNames are the month and weekday names of a language, long and
+// abbreviated, indexed like time.Month-1 and time.Weekday.
+type dateNames struct {
+	months, shortMonths [12]string
+	days, shortDays     [7]string
+}
+
+// dateLocales maps language codes to their month and weekday names.
+var dateLocales = map[string]dateNames{
+	"en": {
+		months:      [12]string{"January", "February", "March", "April", "May", "June", "July", "August", "September", "October", "November", "December"},
+		shortMonths: [12]string{"Jan", "Feb", "Mar", "Apr", "May", "Jun", "Jul", "Aug", "Sep", "Oct", "Nov", "Dec"},
+		days:        [7]string{"Sunday", "Monday", "Tuesday", "Wednesday", "Thursday", "Friday", "Saturday"},
+		shortDays:   [7]string{"Sun", "Mon", "Tue", "Wed", "Thu", "Fri", "Sat"},
+	},
+	"de": {
+		months:      [12]string{"Januar", "Februar", "März", "April", "Mai", "Juni", "Juli", "August", "September", "Oktober", "November", "Dezember"},
+		shortMonths: [12]string{"Jan", "Feb", "Mär", "Apr", "Mai", "Jun", "Jul", "Aug", "Sep", "Okt", "Nov", "Dez"},
+		days:        [7]string{"Sonntag", "Montag", "Dienstag", "Mittwoch", "Donnerstag", "Freitag", "Samstag"},
+		shortDays:   [7]string{"So", "Mo", "Di", "Mi", "Do", "Fr", "Sa"},
+	},
+	"es": {
+		months:      [12]string{"enero", "febrero", "marzo", "abril", "mayo", "junio", "julio", "agosto", "septiembre", "octubre", "noviembre", "diciembre"},
+		shortMonths: [12]string{"ene", "feb", "mar", "abr", "may", "jun", "jul", "ago", "sept", "oct", "nov", "dic"},
+		days:        [7]string{"domingo", "lunes", "martes", "miércoles", "jueves", "viernes", "sábado"},
+		shortDays:   [7]string{"dom", "lun", "mar", "mié", "jue", "vie", "sáb"},
+	},
+	"fr": {
+		months:      [12]string{"janvier", "février", "mars", "avril", "mai", "juin", "juillet", "août", "septembre", "octobre", "novembre", "décembre"},
+		shortMonths: [12]string{"janv.", "févr.", "mars", "avr.", "mai", "juin", "juil.", "août", "sept.", "oct.", "nov.", "déc."},
+		days:        [7]string{"dimanche", "lundi", "mardi", "mercredi", "jeudi", "vendredi", "samedi"},
+		shortDays:   [7]string{"dim.", "lun.", "mar.", "mer.", "jeu.", "ven.", "sam."},
+	},
+	"it": {
+		months:      [12]string{"gennaio", "febbraio", "marzo", "aprile", "maggio", "giugno", "luglio", "agosto", "settembre", "ottobre", "novembre", "dicembre"},
+		shortMonths: [12]string{"gen", "feb", "mar", "apr", "mag", "giu", "lug", "ago", "set", "ott", "nov", "dic"},
+		days:        [7]string{"domenica", "lunedì", "martedì", "mercoledì", "giovedì", "venerdì", "sabato"},
+		shortDays:   [7]string{"dom", "lun", "mar", "mer", "gio", "ven", "sab"},
+	},
+	"nl": {
+		months:      [12]string{"januari", "februari", "maart", "april", "mei", "juni", "juli", "augustus", "september", "oktober", "november", "december"},
+		shortMonths: [12]string{"jan", "feb", "mrt", "apr", "mei", "jun", "jul", "aug", "sep", "okt", "nov", "dec"},
+		days:        [7]string{"zondag", "maandag", "dinsdag", "woensdag", "donderdag", "vrijdag", "zaterdag"},
+		shortDays:   [7]string{"zo", "ma", "di", "wo", "do", "vr", "za"},
+	},
+	"pt": {
+		months:      [12]string{"janeiro", "fevereiro", "março", "abril", "maio", "junho", "julho", "agosto", "setembro", "outubro", "novembro", "dezembro"},
+		shortMonths: [12]string{"jan", "fev", "mar", "abr", "mai", "jun", "jul", "ago", "set", "out", "nov", "dez"},
+		days:        [7]string{"domingo", "segunda-feira", "terça-feira", "quarta-feira", "quinta-feira", "sexta-feira", "sábado"},
+		shortDays:   [7]string{"dom", "seg", "ter", "qua", "qui", "sex", "sáb"},
+	},
+}
+
+// DateLocales returns the language codes FormatDate knows, sorted.
+func DateLocales() []string {
+	codes := make([]string, 0, len(dateLocales))
+	for code := range dateLocales {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	return codes
+}
+
+// localeNames returns the names of the language of locale, such as de or
+// de-AT, and whether it is known. The empty locale is English.
+func localeNames(locale string) (dateNames, bool) {
+	if locale == "" {
+		return dateLocales["en"], true
+	}
+	language, _, _ := strings.Cut(strings.ToLower(locale), "-")
+	language, _, _ = strings.Cut(language, "_")
+	names, ok := dateLocales[language]
+	return names, ok
+}
+
+// ValidateDateLocale returns an error unless FormatDate knows the language
+// of locale.
+func ValidateDateLocale(locale string) error {
+	if _, ok := localeNames(locale); !ok {
+		return fmt.Errorf("unknown date locale %q (available: %s)", locale, strings.Join(DateLocales(), ", "))
+	}
+	return nil
+}
+
+// ValidateDateFormat returns an error unless layout is a Go time layout
+// showing at least part of a date.
+func ValidateDateFormat(layout string) error {
+	reference := time.Date(2001, time.February, 3, 0, 0, 0, 0, time.UTC)
+	if layout == "" || reference.Format(layout) == layout {
+		return fmt.Errorf("date format %q shows no date; write the date 2006-01-02 the way it should look, e.g. %q", layout, DefaultDateFormat)
+	}
+	return nil
+}
+
+// FormatDate formats t with the Go time layout, writing month and weekday
+// names in the language of locale.
+func FormatDate(t time.Time, layout, locale string) string {
+	names, ok := localeNames(locale)
+	if !ok {
+		names = dateLocales["en"]
+	}
+	var out strings.Builder
+	for layout != "" {
+		// Find the first name in the layout; longer names go first, as Jan
+		// is a prefix of January.
+		at, name := -1, ""
+		for _, token := range []string{"January", "Monday", "Jan", "Mon"} {
+			if i := strings.Index(layout, token); i >= 0 && (at < 0 || i < at) {
+				at, name = i, token
+			}
+		}
+		if at < 0 {
+			out.WriteString(t.Format(layout))
+			break
+		}
+		if at > 0 {
+			out.WriteString(t.Format(layout[:at]))
+		}
+		switch name {
+		case "January":
+			out.WriteString(names.months[t.Month()-1])
+		case "Jan":
+			out.WriteString(names.shortMonths[t.Month()-1])
+		case "Monday":
+			out.WriteString(names.days[t.Weekday()])
+		case "Mon":
+			out.WriteString(names.shortDays[t.Weekday()])
+		}
+		layout = layout[at+len(name):]
+	}
+	return out.String()
+}
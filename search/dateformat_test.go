@@ -0,0 +1,76 @@
+package search
+
+import (
+	"testing"
+	"time"
+
+	"gomdoc/renderer"
+)
+
+func TestParseDateFormats(t *testing.T) {
+	want := time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC)
+	for _, value := range []string{
+		"2024-03-01", "2024/03/01", "1.3.2024", "01.03.2024", "March 1, 2024", "Mar 1, 2024", "1 March 2024",
+		"2024-03-01T09:30:00Z", "2024-03-01T09:30", "2024-03-01 09:30:00 +0100", "Fri, 01 Mar 2024 09:30:00 +0000",
+	} {
+		if got, err := ParseDate(value); err != nil || !got.Equal(want) {
+			t.Errorf("ParseDate(%q) = %v, %v; want %v", value, got, err, want)
+		}
+	}
+	for _, value := range []string{"03/01/2024", "next week", "2024-13-01"} {
+		if _, err := ParseDate(value); err == nil {
+			t.Errorf("ParseDate(%q) accepted", value)
+		}
+	}
+}
+
+func TestFrontmatterDate(t *testing.T) {
+	for _, tc := range []struct {
+		fm   renderer.Frontmatter
+		want string
+	}{
+		{renderer.Frontmatter{Date: "2024-03-01", Updated: "2025-01-15"}, "2025-01-15"},
+		{renderer.Frontmatter{Date: "2024-03-01", Updated: "soon"}, "2024-03-01"},
+		{renderer.Frontmatter{Date: "2024-03-01"}, "2024-03-01"},
+		{renderer.Frontmatter{}, ""},
+	} {
+		got, ok := FrontmatterDate(tc.fm)
+		if tc.want == "" {
+			if ok {
+				t.Errorf("FrontmatterDate(%+v) = %v, want none", tc.fm, got)
+			}
+			continue
+		}
+		if !ok || got.Format("2006-01-02") != tc.want {
+			t.Errorf("FrontmatterDate(%+v) = %v, %v; want %s", tc.fm, got, ok, tc.want)
+		}
+	}
+}
+
+func TestFormatDate(t *testing.T) {
+	date := time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC)
+	for _, tc := range []struct{ layout, locale, want string }{
+		{DefaultDateFormat, "", "March 1, 2024"},
+		{"Monday, 2 Jan 2006", "en", "Friday, 1 Mar 2024"},
+		{"2. January 2006", "de", "1. März 2024"},
+		{"Mon 2 Jan 2006", "fr-CA", "ven. 1 mars 2024"},
+		{"Monday 2 January 2006", "pt_BR", "sexta-feira 1 março 2024"},
+		{"2006-01-02", "nl", "2024-03-01"},
+	} {
+		if got := FormatDate(date, tc.layout, tc.locale); got != tc.want {
+			t.Errorf("FormatDate(%q, %q) = %q, want %q", tc.layout, tc.locale, got, tc.want)
+		}
+	}
+
+	if err := ValidateDateLocale("sv"); err == nil {
+		t.Error("ValidateDateLocale accepted an unknown language")
+	}
+	if err := ValidateDateLocale("de-CH"); err != nil {
+		t.Errorf("ValidateDateLocale(de-CH) = %v", err)
+	}
+	for layout, valid := range map[string]bool{"2 Jan 2006": true, "01/02": true, "": false, "date": false} {
+		if err := ValidateDateFormat(layout); (err == nil) != valid {
+			t.Errorf("ValidateDateFormat(%q) = %v", layout, err)
+		}
+	}
+}
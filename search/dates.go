@@ -0,0 +1,63 @@
+package search
+
+import (
+	"sort"
+	"time"
+
+	"gomdoc/clock"
+	"gomdoc/renderer"
+)
+
+// FrontmatterDate returns when the page with fm was last updated according
+// to its frontmatter: its updated: date, else its date: date. It reports
+// false when neither is set or parses.
+func FrontmatterDate(fm renderer.Frontmatter) (time.Time, bool) {
+	for _, value := range []string{fm.Updated, fm.Date} {
+		if date, err := ParseDate(value); value != "" && err == nil {
+			return date, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// DocumentDate is the date of a document: its updated or date frontmatter,
+// the last git commit of the file, or its modification time, in that
+// order.
+type DocumentDate struct {
+	// Title is the document title.
+	Title string `json:"title"`
+	// Path is the URL path.
+	Path string `json:"path"`
+	// Date is when the document was last updated.
+	Date time.Time `json:"date"`
+}
+
+// Date returns the date of the document at docPath.
+func (idx *Index) Date(docPath string) (time.Time, bool) {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	for _, doc := range idx.docs {
+		if doc.path == docPath {
+			return doc.date, !doc.date.IsZero()
+		}
+	}
+	return time.Time{}, false
+}
+
+// Dates returns the dates of all published documents, oldest first.
+func (idx *Index) Dates() []DocumentDate {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	now := clock.Now()
+	dates := make([]DocumentDate, 0, len(idx.docs))
+	for _, doc := range idx.docs {
+		if doc.date.IsZero() || !doc.schedule.Live(now) {
+			continue
+		}
+		dates = append(dates, DocumentDate{Title: doc.title, Path: doc.path, Date: doc.date})
+	}
+	sort.SliceStable(dates, func(i, j int) bool { return dates[i].Date.Before(dates[j].Date) })
+	return dates
+}
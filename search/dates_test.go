@@ -0,0 +1,59 @@
+package search
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestDatesFromGit(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	dir := t.TempDir()
+	git := func(env []string, args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), env...)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	commit := func(date string) {
+		env := []string{"GIT_AUTHOR_DATE=" + date, "GIT_COMMITTER_DATE=" + date,
+			"GIT_AUTHOR_NAME=t", "GIT_AUTHOR_EMAIL=t@example.com", "GIT_COMMITTER_NAME=t", "GIT_COMMITTER_EMAIL=t@example.com"}
+		git(env, "add", "-A")
+		git(env, "commit", "-q", "-m", "update")
+	}
+
+	git(nil, "init", "-q")
+	os.WriteFile(filepath.Join(dir, "old.md"), []byte("# Old\n"), 0o644)
+	os.WriteFile(filepath.Join(dir, "new.md"), []byte("# New\n"), 0o644)
+	commit("2021-01-01T12:00:00Z")
+	os.WriteFile(filepath.Join(dir, "new.md"), []byte("# New\n\nEdited.\n"), 0o644)
+	commit("2023-06-01T12:00:00Z")
+	os.WriteFile(filepath.Join(dir, "dated.md"), []byte("---\ndate: 2019-05-05\n---\n# Dated\n"), 0o644)
+	os.WriteFile(filepath.Join(dir, "untracked.md"), []byte("# Untracked\n"), 0o644)
+
+	idx := NewIndex()
+	if err := idx.Build(dir); err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+
+	dates := idx.Dates()
+	want := []string{"/dated", "/old", "/new", "/untracked"}
+	if len(dates) != len(want) {
+		t.Fatalf("expected %d dates, got %+v", len(want), dates)
+	}
+	for i, path := range want {
+		if dates[i].Path != path {
+			t.Errorf("expected %s at position %d, got %+v", path, i, dates)
+		}
+	}
+	if date, _ := idx.Date("/new"); !date.Equal(time.Date(2023, 6, 1, 12, 0, 0, 0, time.UTC)) {
+		t.Errorf("expected last commit time for /new, got %v", date)
+	}
+}
@@ -0,0 +1,26 @@
+package search
+
+// WithoutDrafts returns the index as seen by readers who may not see
+// pages with status: draft: a view sharing the documents of idx, leaving
+// out the drafts. The view is made once per build of idx.
+func (idx *Index) WithoutDrafts() *Index {
+	idx.mu.RLock()
+	view := idx.published
+	idx.mu.RUnlock()
+	if view != nil {
+		return view
+	}
+
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	if idx.published == nil {
+		view := &Index{options: idx.options}
+		for _, doc := range idx.docs {
+			if !isDraft(doc) {
+				view.docs = append(view.docs, doc)
+			}
+		}
+		idx.published = view
+	}
+	return idx.published
+}
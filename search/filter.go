@@ -0,0 +1,278 @@
+package search
+
+import (
+	"fmt"
+	"html"
+	"path"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"gomdoc/clock"
+)
+
+// Filter narrows search results by document metadata. The zero value
+// matches every document.
+type Filter struct {
+	// Tags keeps documents with at least one of the tags.
+	Tags []string
+	// Dir keeps documents below a directory, given as a URL path such as
+	// /guides.
+	Dir string
+	// Author keeps documents whose author contains the value, ignoring case.
+	Author string
+	// From and To bound the document date, inclusive. The date comes from
+	// the date frontmatter, falling back to the last git commit of the file
+	// and then its modification time.
+	From time.Time
+	To   time.Time
+	// NoDrafts leaves out documents with status: draft. It only applies
+	// along with a query or another condition, and IsZero ignores it.
+	NoDrafts bool
+}
+
+// IsZero reports whether the filter has no conditions.
+func (f Filter) IsZero() bool {
+	return len(f.Tags) == 0 && f.Dir == "" && f.Author == "" && f.From.IsZero() && f.To.IsZero()
+}
+
+// matches reports whether doc satisfies every condition of the filter.
+func (f Filter) matches(doc document) bool {
+	if len(f.Tags) > 0 {
+		lowerTags := make([]string, len(f.Tags))
+		for i, t := range f.Tags {
+			lowerTags[i] = strings.ToLower(t)
+		}
+		if !docHasTag(doc, lowerTags) {
+			return false
+		}
+	}
+	if f.Dir != "" {
+		dir := path.Clean("/" + strings.Trim(f.Dir, "/"))
+		if dir != "/" && doc.path != dir && !strings.HasPrefix(doc.path, dir+"/") {
+			return false
+		}
+	}
+	if f.NoDrafts && isDraft(doc) {
+		return false
+	}
+	if f.Author != "" && !strings.Contains(strings.ToLower(doc.meta.Author), strings.ToLower(f.Author)) {
+		return false
+	}
+	if !f.From.IsZero() && doc.date.Before(f.From) {
+		return false
+	}
+	// To covers the whole day.
+	if !f.To.IsZero() && !doc.date.Before(f.To.AddDate(0, 0, 1)) {
+		return false
+	}
+	return true
+}
+
+// dateLayouts are the date formats accepted in frontmatter and filters.
+// Numeric dates are year first, or day first with dots, since 03/01/2024
+// means different days in different countries.
+var dateLayouts = []string{
+	"2006-01-02", time.RFC3339, "2006-01-02T15:04:05", "2006-01-02T15:04",
+	"2006-01-02 15:04", "2006-01-02 15:04:05", "2006-01-02 15:04:05 -0700", "2006-01-02 15:04:05 -0700 MST",
+	"2006/01/02", "2.1.2006", "January 2, 2006", "Jan 2, 2006", "2 January 2006", "2 Jan 2006",
+	time.RFC1123Z, time.RFC1123, "2006-01", "2006",
+}
+
+// ParseDate parses a date such as 2024-03-01, 2024-03, or 2024, and also
+// 2024/03/01, 1.3.2024, March 1, 2024, 1 March 2024, and timestamps such
+// as 2024-03-01T09:30:00Z. Time of day is dropped, so dates compare by
+// calendar day.
+func ParseDate(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	for _, layout := range dateLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid date %q, use YYYY-MM-DD", value)
+}
+
+// ParseQuery splits filter operators out of a search query, returning the
+// remaining keywords and the filter. Supported operators are tag:, dir:,
+// author:, from:, and to:; values with spaces are quoted, as in
+// author:"Jane Doe".
+func ParseQuery(query string) (string, Filter, error) {
+	var filter Filter
+	var keywords []string
+	for _, field := range splitQuery(query) {
+		name, value, ok := strings.Cut(field, ":")
+		value = strings.Trim(value, `"`)
+		if !ok || value == "" {
+			keywords = append(keywords, field)
+			continue
+		}
+		var err error
+		switch strings.ToLower(name) {
+		case "tag":
+			filter.Tags = append(filter.Tags, value)
+		case "dir":
+			filter.Dir = value
+		case "author":
+			filter.Author = value
+		case "from":
+			filter.From, err = ParseDate(value)
+		case "to":
+			filter.To, err = ParseDate(value)
+		default:
+			keywords = append(keywords, field)
+		}
+		if err != nil {
+			return "", Filter{}, err
+		}
+	}
+	return strings.Join(keywords, " "), filter, nil
+}
+
+// splitQuery splits a query on spaces outside double quotes.
+func splitQuery(query string) []string {
+	var fields []string
+	var current strings.Builder
+	quoted := false
+	for _, r := range query {
+		switch {
+		case r == '"':
+			quoted = !quoted
+			current.WriteRune(r)
+		case unicode.IsSpace(r) && !quoted:
+			if current.Len() > 0 {
+				fields = append(fields, current.String())
+				current.Reset()
+			}
+		default:
+			current.WriteRune(r)
+		}
+	}
+	if current.Len() > 0 {
+		fields = append(fields, current.String())
+	}
+	return fields
+}
+
+// SearchFiltered performs keyword search over the documents matching the
+// filter. Without keywords it lists the matching documents, so a filter on
+// its own works as a browse query.
+func (idx *Index) SearchFiltered(query string, filter Filter, maxResults int) []Result {
+	keywords := tokenize(query)
+	if len(keywords) == 0 {
+		if filter.IsZero() {
+			return nil
+		}
+		return idx.filterDocuments(filter, maxResults)
+	}
+
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	type scored struct {
+		doc   document
+		score float64
+		pos   int // byte position of first match for snippet
+	}
+
+	now := clock.Now()
+	var matches []scored
+	for _, doc := range idx.docs {
+		if !doc.schedule.Live(now) || !filter.matches(doc) {
+			continue
+		}
+		score, firstPos := scoreDocument(doc, keywords)
+		if score == 0 {
+			continue
+		}
+		matches = append(matches, scored{doc: doc, score: score, pos: firstPos})
+	}
+
+	// Sort by score descending
+	sort.Slice(matches, func(i, j int) bool {
+		return matches[i].score > matches[j].score
+	})
+
+	limit := min(maxResults, len(matches))
+	results := make([]Result, limit)
+	for i := 0; i < limit; i++ {
+		m := matches[i]
+		queryLen := len(keywords[0]) // use first keyword for snippet centering
+		snippet := extractSnippet(m.doc.raw, m.pos, queryLen)
+		results[i] = Result{
+			Title:       m.doc.title,
+			Path:        m.doc.path,
+			Snippet:     snippet,
+			Highlighted: highlightSnippet(snippet, keywords),
+			Summary:     m.doc.summary,
+			Score:       m.score,
+			Meta:        m.doc.meta,
+		}
+	}
+
+	return results
+}
+
+// filterDocuments returns documents matching the filter, without keyword
+// scoring.
+func (idx *Index) filterDocuments(filter Filter, maxResults int) []Result {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	now := clock.Now()
+	var results []Result
+	for _, doc := range idx.docs {
+		if !doc.schedule.Live(now) || !filter.matches(doc) {
+			continue
+		}
+		results = append(results, Result{
+			Title:   doc.title,
+			Path:    doc.path,
+			Summary: doc.summary,
+			Meta:    doc.meta,
+		})
+		if len(results) >= maxResults {
+			break
+		}
+	}
+
+	return results
+}
+
+// highlightSnippet escapes a snippet as HTML and wraps words starting with
+// one of the keywords in <mark> elements.
+func highlightSnippet(snippet string, keywords []string) string {
+	isWord := func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) }
+
+	var sb strings.Builder
+	last := 0
+	for start := 0; start < len(snippet); {
+		r, size := utf8.DecodeRuneInString(snippet[start:])
+		if !isWord(r) {
+			start += size
+			continue
+		}
+		end := start
+		for end < len(snippet) {
+			r, size := utf8.DecodeRuneInString(snippet[end:])
+			if !isWord(r) {
+				break
+			}
+			end += size
+		}
+		word := strings.ToLower(snippet[start:end])
+		for _, kw := range keywords {
+			if strings.HasPrefix(word, kw) {
+				sb.WriteString(html.EscapeString(snippet[last:start]))
+				sb.WriteString("<mark>" + html.EscapeString(snippet[start:end]) + "</mark>")
+				last = end
+				break
+			}
+		}
+		start = end
+	}
+	sb.WriteString(html.EscapeString(snippet[last:]))
+	return sb.String()
+}
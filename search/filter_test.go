@@ -0,0 +1,128 @@
+package search
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// setupFilterTestDir creates documents with authors, tags, and dates in
+// nested directories.
+func setupFilterTestDir(t *testing.T) *Index {
+	t.Helper()
+	dir := t.TempDir()
+
+	files := map[string]string{
+		"guides/install.md": "---\nauthor: Jane Doe\ntags: [setup]\ndate: 2024-03-10\n---\n# Install\nInstall the server.",
+		"guides/upgrade.md": "---\nauthor: Bob\ntags: [setup]\ndate: 2024-06-01\n---\n# Upgrade\nInstall the new release.",
+		"reference/cli.md":  "---\nauthor: Jane Doe\ndate: 2023-12-31\nstatus: draft\n---\n# CLI\nInstall flags.",
+		"notes.md":          "# Notes\nInstall notes without a date.",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		os.MkdirAll(filepath.Dir(path), 0o755)
+		os.WriteFile(path, []byte(content), 0o644)
+	}
+	old := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	os.Chtimes(filepath.Join(dir, "notes.md"), old, old)
+
+	idx := NewIndex()
+	if err := idx.Build(dir); err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+	return idx
+}
+
+// resultPaths returns the set of result paths.
+func resultPaths(results []Result) map[string]bool {
+	paths := make(map[string]bool)
+	for _, r := range results {
+		paths[r.Path] = true
+	}
+	return paths
+}
+
+func TestSearchFiltered(t *testing.T) {
+	idx := setupFilterTestDir(t)
+	date := func(s string) time.Time {
+		d, err := ParseDate(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return d
+	}
+
+	tests := []struct {
+		name   string
+		filter Filter
+		want   []string
+	}{
+		{"directory", Filter{Dir: "guides/"}, []string{"/guides/install", "/guides/upgrade"}},
+		{"author", Filter{Author: "jane"}, []string{"/guides/install", "/reference/cli"}},
+		{"tag and author", Filter{Tags: []string{"SETUP"}, Author: "bob"}, []string{"/guides/upgrade"}},
+		{"from", Filter{From: date("2024-01-01")}, []string{"/guides/install", "/guides/upgrade"}},
+		{"to is inclusive", Filter{To: date("2024-03-10")}, []string{"/guides/install", "/reference/cli", "/notes"}},
+		{"range", Filter{From: date("2024-03"), To: date("2024-05-31")}, []string{"/guides/install"}},
+		{"no drafts", Filter{NoDrafts: true}, []string{"/guides/install", "/guides/upgrade", "/notes"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			paths := resultPaths(idx.SearchFiltered("install", tt.filter, 10))
+			if len(paths) != len(tt.want) {
+				t.Errorf("expected %v, got %v", tt.want, paths)
+			}
+			for _, want := range tt.want {
+				if !paths[want] {
+					t.Errorf("expected %s in %v", want, paths)
+				}
+			}
+		})
+	}
+
+	if results := idx.SearchFiltered("", Filter{Dir: "/reference"}, 10); len(results) != 1 || results[0].Path != "/reference/cli" {
+		t.Errorf("expected filter without keywords to list /reference/cli, got %+v", results)
+	}
+	if results := idx.SearchFiltered("", Filter{}, 10); results != nil {
+		t.Errorf("expected no results without query or filter, got %+v", results)
+	}
+}
+
+func TestParseQuery(t *testing.T) {
+	query, filter, err := ParseQuery(`install tag:setup author:"Jane Doe" dir:guides from:2024-01-01 to:2024 http://x`)
+	if err != nil {
+		t.Fatalf("ParseQuery failed: %v", err)
+	}
+	if query != "install http://x" {
+		t.Errorf("unexpected query %q", query)
+	}
+	if len(filter.Tags) != 1 || filter.Tags[0] != "setup" || filter.Author != "Jane Doe" || filter.Dir != "guides" {
+		t.Errorf("unexpected filter %+v", filter)
+	}
+	if filter.From.Format("2006-01-02") != "2024-01-01" || filter.To.Format("2006-01-02") != "2024-01-01" {
+		t.Errorf("unexpected date range %v - %v", filter.From, filter.To)
+	}
+
+	if _, _, err := ParseQuery("from:yesterday"); err == nil {
+		t.Error("expected error for invalid date")
+	}
+}
+
+func TestHighlightSnippet(t *testing.T) {
+	got := highlightSnippet(`Installing <b> & install-guide, reinstall`, []string{"install"})
+	want := `<mark>Installing</mark> &lt;b&gt; &amp; <mark>install</mark>-guide, reinstall`
+	if got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
+
+func TestSearchKeywordsHighlights(t *testing.T) {
+	idx := setupFilterTestDir(t)
+	results := idx.SearchKeywords("upgrade", 10)
+	if len(results) == 0 || results[0].Highlighted == "" {
+		t.Fatalf("expected highlighted snippet, got %+v", results)
+	}
+	if want := "<mark>Upgrade</mark>"; !contains(results[0].Highlighted, want) {
+		t.Errorf("expected %q in %q", want, results[0].Highlighted)
+	}
+}
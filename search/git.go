@@ -0,0 +1,65 @@
+package search
+
+import (
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// gitCommitTimes returns the last commit time of every file below baseDir
+// tracked by git, keyed by slash-separated path relative to baseDir. It
+// returns nil when baseDir is not in a git work tree or git is unavailable.
+// Checkouts reset file modification times, so commit times tell the age of
+// a page more reliably.
+func gitCommitTimes(baseDir string) map[string]time.Time {
+	cmd := exec.Command("git", "-c", "core.quotePath=false", "log", "--format=%x00%ct", "--name-only", "--relative", "--", ".")
+	cmd.Dir = baseDir
+	out, err := cmd.Output()
+	if err != nil {
+		return nil
+	}
+
+	times := make(map[string]time.Time)
+	var commitTime time.Time
+	for _, line := range strings.Split(string(out), "\n") {
+		if seconds, ok := strings.CutPrefix(line, "\x00"); ok {
+			unix, _ := strconv.ParseInt(seconds, 10, 64)
+			commitTime = time.Unix(unix, 0)
+			continue
+		}
+		// The log lists the newest commits first.
+		if _, seen := times[line]; line != "" && !seen {
+			times[line] = commitTime
+		}
+	}
+	return times
+}
+
+// gitBlameAuthor returns the author of most lines of the file at name, a
+// slash-separated path relative to baseDir, as git blame attributes them.
+// Uncommitted lines are not counted and ties go to the name sorting first.
+// It returns "" when the file has no committed lines or git is
+// unavailable.
+func gitBlameAuthor(baseDir, name string) string {
+	cmd := exec.Command("git", "blame", "--line-porcelain", "--", name)
+	cmd.Dir = baseDir
+	out, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+
+	lines := make(map[string]int)
+	for _, line := range strings.Split(string(out), "\n") {
+		if author, ok := strings.CutPrefix(line, "author "); ok && author != "Not Committed Yet" {
+			lines[author]++
+		}
+	}
+	var top string
+	for author, count := range lines {
+		if top == "" || count > lines[top] || count == lines[top] && author < top {
+			top = author
+		}
+	}
+	return top
+}
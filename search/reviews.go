@@ -0,0 +1,163 @@
+package search
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// OwnedPage is a document in an ownership report.
+type OwnedPage struct {
+	// Title is the document title.
+	Title string `json:"title"`
+	// Path is the URL path.
+	Path string `json:"path"`
+	// Owner is the owner from the frontmatter, empty for unowned pages.
+	Owner string `json:"owner,omitempty"`
+	// ReviewBy is the review_by date as written in the frontmatter.
+	ReviewBy string `json:"review_by,omitempty"`
+	// Overdue is set when the review_by date has passed.
+	Overdue bool `json:"overdue,omitempty"`
+	// Invalid is set when review_by is not a valid date.
+	Invalid bool `json:"invalid,omitempty"`
+}
+
+// OwnerPages are the documents of one owner, sorted by path.
+type OwnerPages struct {
+	// Owner is the owner, empty for the pages without one.
+	Owner string `json:"owner"`
+	// Pages are the owner's documents.
+	Pages []OwnedPage `json:"pages"`
+}
+
+// Ownership lists documents per owner and the documents overdue for review.
+type Ownership struct {
+	// Owners are sorted by name, with unowned pages last.
+	Owners []OwnerPages `json:"owners"`
+	// Overdue are the documents whose review_by date lies before the report
+	// date, most overdue first. Documents with an invalid date are included
+	// so the mistake gets noticed.
+	Overdue []OwnedPage `json:"overdue"`
+}
+
+// ReviewOverdue reports whether a review_by date has passed on the day of
+// now. The second result is false when the date cannot be parsed.
+func ReviewOverdue(reviewBy string, now time.Time) (overdue, valid bool) {
+	due, err := ParseDate(reviewBy)
+	if err != nil {
+		return false, false
+	}
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	return due.Before(today), true
+}
+
+// Ownership groups the indexed documents by owner and collects the reviews
+// overdue at now.
+func (idx *Index) Ownership(now time.Time) Ownership {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	byOwner := make(map[string][]OwnedPage)
+	report := Ownership{Owners: []OwnerPages{}, Overdue: []OwnedPage{}}
+	for _, doc := range idx.docs {
+		page := OwnedPage{
+			Title:    doc.title,
+			Path:     doc.path,
+			Owner:    doc.meta.Owner,
+			ReviewBy: doc.meta.ReviewBy,
+		}
+		if page.ReviewBy != "" {
+			overdue, valid := ReviewOverdue(page.ReviewBy, now)
+			page.Overdue = overdue
+			page.Invalid = !valid
+			if overdue || !valid {
+				report.Overdue = append(report.Overdue, page)
+			}
+		}
+		byOwner[page.Owner] = append(byOwner[page.Owner], page)
+	}
+
+	for owner, pages := range byOwner {
+		sort.Slice(pages, func(i, j int) bool { return pages[i].Path < pages[j].Path })
+		report.Owners = append(report.Owners, OwnerPages{Owner: owner, Pages: pages})
+	}
+	sort.Slice(report.Owners, func(i, j int) bool {
+		a, b := report.Owners[i].Owner, report.Owners[j].Owner
+		if a == "" || b == "" {
+			return a != "" && b == ""
+		}
+		if !strings.EqualFold(a, b) {
+			return strings.ToLower(a) < strings.ToLower(b)
+		}
+		return a < b
+	})
+
+	// Invalid dates sort first, then the oldest due dates.
+	sort.SliceStable(report.Overdue, func(i, j int) bool {
+		a, b := report.Overdue[i], report.Overdue[j]
+		if a.Invalid != b.Invalid {
+			return a.Invalid
+		}
+		dueA, _ := ParseDate(a.ReviewBy)
+		dueB, _ := ParseDate(b.ReviewBy)
+		if !dueA.Equal(dueB) {
+			return dueA.Before(dueB)
+		}
+		return a.Path < b.Path
+	})
+	return report
+}
+
+// Deadline kinds.
+const (
+	// DeadlineReview is a review_by date.
+	DeadlineReview = "review"
+	// DeadlineExpiry is an expire_at time.
+	DeadlineExpiry = "expiry"
+)
+
+// Deadline is a date by which something must happen to a document: its
+// review, or its expiry.
+type Deadline struct {
+	// Kind is DeadlineReview or DeadlineExpiry.
+	Kind string `json:"kind"`
+	// Title is the document title.
+	Title string `json:"title"`
+	// Path is the URL path.
+	Path string `json:"path"`
+	// Owner is the owner from the frontmatter.
+	Owner string `json:"owner,omitempty"`
+	// Time is the review date, at midnight UTC, or the expiry time.
+	Time time.Time `json:"time"`
+}
+
+// Deadlines returns the review_by dates and expire_at times of the indexed
+// documents, earliest first. Invalid dates are left out.
+func (idx *Index) Deadlines() []Deadline {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	deadlines := []Deadline{}
+	for _, doc := range idx.docs {
+		deadline := Deadline{Title: doc.title, Path: doc.path, Owner: doc.meta.Owner}
+		if due, err := ParseDate(doc.meta.ReviewBy); doc.meta.ReviewBy != "" && err == nil {
+			deadline.Kind, deadline.Time = DeadlineReview, due
+			deadlines = append(deadlines, deadline)
+		}
+		if !doc.schedule.ExpireAt.IsZero() {
+			deadline.Kind, deadline.Time = DeadlineExpiry, doc.schedule.ExpireAt
+			deadlines = append(deadlines, deadline)
+		}
+	}
+	sort.SliceStable(deadlines, func(i, j int) bool {
+		a, b := deadlines[i], deadlines[j]
+		if !a.Time.Equal(b.Time) {
+			return a.Time.Before(b.Time)
+		}
+		if a.Path != b.Path {
+			return a.Path < b.Path
+		}
+		return a.Kind > b.Kind
+	})
+	return deadlines
+}
@@ -0,0 +1,106 @@
+package search
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestOwnership(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"a.md":     "---\nowner: zoe\nreview_by: 2024-01-10\n---\n# A\n",
+		"b.md":     "---\nowner: Anna\nreview_by: 2024-03-01\n---\n# B\n",
+		"c.md":     "---\nowner: zoe\nreview_by: 2023-12-01\n---\n# C\n",
+		"d.md":     "---\nowner: anna\nreview_by: soon\n---\n# D\n",
+		"e.md":     "# E\n",
+		"today.md": "---\nowner: zoe\nreview_by: 2024-02-01\n---\n# Today\n",
+	}
+	for name, content := range files {
+		os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644)
+	}
+
+	idx := NewIndex()
+	if err := idx.Build(dir); err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+
+	report := idx.Ownership(time.Date(2024, 2, 1, 15, 0, 0, 0, time.UTC))
+
+	var owners []string
+	for _, group := range report.Owners {
+		owners = append(owners, group.Owner)
+	}
+	wantOwners := []string{"Anna", "anna", "zoe", ""}
+	if len(owners) != len(wantOwners) {
+		t.Fatalf("expected owners %q, got %q", wantOwners, owners)
+	}
+	for i, owner := range wantOwners {
+		if owners[i] != owner {
+			t.Errorf("expected owners %q, got %q", wantOwners, owners)
+			break
+		}
+	}
+	if len(report.Owners[2].Pages) != 3 {
+		t.Errorf("expected 3 pages owned by zoe, got %+v", report.Owners[2])
+	}
+
+	var overdue []string
+	for _, page := range report.Overdue {
+		overdue = append(overdue, page.Path)
+	}
+	wantOverdue := []string{"/d", "/c", "/a"}
+	if len(overdue) != len(wantOverdue) {
+		t.Fatalf("expected overdue %q, got %q", wantOverdue, overdue)
+	}
+	for i, path := range wantOverdue {
+		if overdue[i] != path {
+			t.Errorf("expected overdue %q, got %q", wantOverdue, overdue)
+			break
+		}
+	}
+	if !report.Overdue[0].Invalid {
+		t.Errorf("expected /d flagged as invalid, got %+v", report.Overdue[0])
+	}
+}
+
+func TestDeadlines(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"policy.md": "---\nowner: zoe\nreview_by: 2024-03-01\nexpire_at: 2024-06-30 17:00\n---\n# Policy\n",
+		"guide.md":  "---\nreview_by: 2024-01-15\n---\n# Guide\n",
+		"broken.md": "---\nreview_by: soon\n---\n# Broken\n",
+		"plain.md":  "# Plain\n",
+	}
+	for name, content := range files {
+		os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644)
+	}
+
+	idx := NewIndex()
+	if err := idx.Build(dir); err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+
+	deadlines := idx.Deadlines()
+	want := []struct {
+		kind, path string
+		time       time.Time
+	}{
+		{DeadlineReview, "/guide", time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)},
+		{DeadlineReview, "/policy", time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)},
+		{DeadlineExpiry, "/policy", time.Date(2024, 6, 30, 17, 0, 0, 0, time.Local)},
+	}
+	if len(deadlines) != len(want) {
+		t.Fatalf("expected %d deadlines, got %+v", len(want), deadlines)
+	}
+	for i, w := range want {
+		d := deadlines[i]
+		if d.Kind != w.kind || d.Path != w.path || !d.Time.Equal(w.time) {
+			t.Errorf("deadline %d: expected %s of %s at %v, got %+v", i, w.kind, w.path, w.time, d)
+		}
+	}
+	if deadlines[1].Owner != "zoe" {
+		t.Errorf("expected the owner on the deadline, got %+v", deadlines[1])
+	}
+}
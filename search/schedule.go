@@ -0,0 +1,101 @@
+package search
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"gomdoc/renderer"
+)
+
+// Schedule is the publication window of a document, set with publish_at
+// and expire_at frontmatter. Zero times leave the window open on that side.
+type Schedule struct {
+	// PublishAt is when the document appears.
+	PublishAt time.Time
+	// ExpireAt is when the document disappears.
+	ExpireAt time.Time
+}
+
+// ScheduleStatus is the state of a document relative to its schedule.
+type ScheduleStatus int
+
+const (
+	// Published documents are inside their publication window.
+	Published ScheduleStatus = iota
+	// Pending documents have a publish_at time in the future.
+	Pending
+	// Expired documents have an expire_at time in the past.
+	Expired
+)
+
+// timestampLayouts are the formats accepted for publish_at and expire_at.
+var timestampLayouts = []string{time.RFC3339, "2006-01-02T15:04:05", "2006-01-02T15:04", "2006-01-02 15:04:05", "2006-01-02 15:04", "2006-01-02"}
+
+// ParseTimestamp parses a publication time such as 2024-03-01 09:00 or
+// 2024-03-01T09:00:00+02:00. Times without a zone are in the server's time
+// zone, and a date alone means midnight.
+func ParseTimestamp(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	for _, layout := range timestampLayouts {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q, use YYYY-MM-DD or YYYY-MM-DD HH:MM", value)
+}
+
+// ParseSchedule reads the publication window from frontmatter. Invalid
+// times are reported and leave their side of the window open.
+func ParseSchedule(fm renderer.Frontmatter) (Schedule, error) {
+	var schedule Schedule
+	var errs []string
+	if fm.PublishAt != "" {
+		t, err := ParseTimestamp(fm.PublishAt)
+		if err != nil {
+			errs = append(errs, "publish_at: "+err.Error())
+		}
+		schedule.PublishAt = t
+	}
+	if fm.ExpireAt != "" {
+		t, err := ParseTimestamp(fm.ExpireAt)
+		if err != nil {
+			errs = append(errs, "expire_at: "+err.Error())
+		}
+		schedule.ExpireAt = t
+	}
+	if len(errs) > 0 {
+		return schedule, fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+	return schedule, nil
+}
+
+// Status returns the state of the window at now.
+func (s Schedule) Status(now time.Time) ScheduleStatus {
+	if !s.ExpireAt.IsZero() && !now.Before(s.ExpireAt) {
+		return Expired
+	}
+	if !s.PublishAt.IsZero() && now.Before(s.PublishAt) {
+		return Pending
+	}
+	return Published
+}
+
+// Live reports whether the window is open at now.
+func (s Schedule) Live(now time.Time) bool {
+	return s.Status(now) == Published
+}
+
+// Schedule returns the publication window of the document at docPath. The
+// second result is false when the document is not indexed.
+func (idx *Index) Schedule(docPath string) (Schedule, bool) {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	for _, doc := range idx.docs {
+		if doc.path == docPath {
+			return doc.schedule, true
+		}
+	}
+	return Schedule{}, false
+}
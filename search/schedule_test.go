@@ -0,0 +1,57 @@
+package search
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gomdoc/renderer"
+)
+
+func TestScheduleStatus(t *testing.T) {
+	schedule, err := ParseSchedule(renderer.Frontmatter{PublishAt: "2024-03-01 09:00", ExpireAt: "2024-04-01"})
+	if err != nil {
+		t.Fatalf("ParseSchedule failed: %v", err)
+	}
+	for now, want := range map[time.Time]ScheduleStatus{
+		time.Date(2024, 3, 1, 8, 59, 0, 0, time.Local):   Pending,
+		time.Date(2024, 3, 1, 9, 0, 0, 0, time.Local):    Published,
+		time.Date(2024, 3, 31, 23, 59, 0, 0, time.Local): Published,
+		time.Date(2024, 4, 1, 0, 0, 0, 0, time.Local):    Expired,
+	} {
+		if got := schedule.Status(now); got != want {
+			t.Errorf("Status(%v) = %v, want %v", now, got, want)
+		}
+	}
+
+	if _, err := ParseSchedule(renderer.Frontmatter{ExpireAt: "someday"}); err == nil {
+		t.Error("expected error for invalid expire_at")
+	}
+}
+
+func TestScheduledDocumentsLeaveIndex(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "live.md"), []byte("# Live\n\nRelease notes.\n"), 0o644)
+	os.WriteFile(filepath.Join(dir, "future.md"), []byte("---\npublish_at: 2999-01-01\n---\n# Future\n\nRelease notes.\n"), 0o644)
+	os.WriteFile(filepath.Join(dir, "past.md"), []byte("---\nexpire_at: 2000-01-01T00:00:00Z\n---\n# Past\n\nRelease notes.\n"), 0o644)
+
+	idx := NewIndex()
+	if err := idx.Build(dir); err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+
+	results := idx.SearchKeywords("release", 10)
+	if len(results) != 1 || results[0].Path != "/live" {
+		t.Errorf("expected only /live in search results, got %+v", results)
+	}
+	if docs := idx.Documents(); len(docs) != 1 {
+		t.Errorf("expected only /live in documents, got %+v", docs)
+	}
+	if _, found := idx.Preview("/future"); found {
+		t.Error("expected no preview for unpublished page")
+	}
+	if schedule, ok := idx.Schedule("/past"); !ok || schedule.Status(time.Now()) != Expired {
+		t.Errorf("expected /past to be expired, got %+v", schedule)
+	}
+}
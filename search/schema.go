@@ -0,0 +1,28 @@
+package search
+
+import (
+	"fmt"
+	"strings"
+
+	"gomdoc/renderer"
+)
+
+// schemaTypes are the schema.org types pages can describe themselves as
+// with schema_type frontmatter, by lowercase name.
+var schemaTypes = map[string]string{
+	"article":     "Article",
+	"techarticle": "TechArticle",
+}
+
+// ParseSchemaType returns the schema.org type of a page for its
+// structured data: the schema_type frontmatter, Article or TechArticle in
+// any case, else TechArticle.
+func ParseSchemaType(frontmatter renderer.Frontmatter) (string, error) {
+	if frontmatter.SchemaType == "" {
+		return "TechArticle", nil
+	}
+	if schemaType, ok := schemaTypes[strings.ToLower(frontmatter.SchemaType)]; ok {
+		return schemaType, nil
+	}
+	return "TechArticle", fmt.Errorf("schema_type: unknown type %q: use Article or TechArticle", frontmatter.SchemaType)
+}
@@ -4,15 +4,20 @@
 package search
 
 import (
+	"io/fs"
+	"log"
 	"os"
 	"path/filepath"
 	"regexp"
 	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 	"unicode"
 	"unicode/utf8"
 
+	"gomdoc/clock"
 	"gomdoc/renderer"
 	"gomdoc/scanner"
 )
@@ -25,6 +30,8 @@ type Metadata struct {
 	Status string `json:"status,omitempty"`
 	// Date is the document date.
 	Date string `json:"date,omitempty"`
+	// Updated is the date of the document's last update.
+	Updated string `json:"updated,omitempty"`
 	// Tags is a list of classification tags.
 	Tags []string `json:"tags,omitempty"`
 	// Category is the document category.
@@ -33,6 +40,17 @@ type Metadata struct {
 	Version string `json:"version,omitempty"`
 	// Reviewers is a list of document reviewers.
 	Reviewers []string `json:"reviewers,omitempty"`
+	// Owner is the person or team responsible for the document.
+	Owner string `json:"owner,omitempty"`
+	// ReviewBy is the date by which the document is due for review.
+	ReviewBy string `json:"review_by,omitempty"`
+	// Acknowledge is set when readers are asked to confirm they have read
+	// the document.
+	Acknowledge bool `json:"acknowledge,omitempty"`
+	// Series is the series the document belongs to, and SeriesPart its
+	// position in it.
+	Series     string `json:"series,omitempty"`
+	SeriesPart int    `json:"series_part,omitempty"`
 }
 
 // Result represents a single search match.
@@ -43,6 +61,11 @@ type Result struct {
 	Path string `json:"path"`
 	// Snippet is a text excerpt around the match.
 	Snippet string `json:"snippet"`
+	// Highlighted is the snippet as escaped HTML with the matched terms
+	// wrapped in <mark>. Only set by keyword search.
+	Highlighted string `json:"highlighted,omitempty"`
+	// Summary is the document description or first paragraph.
+	Summary string `json:"summary,omitempty"`
 	// Score indicates relevance (higher is better). Only set by keyword search.
 	Score float64 `json:"score,omitempty"`
 	// Meta holds extended frontmatter metadata.
@@ -72,6 +95,16 @@ type DocumentOutline struct {
 	Meta Metadata `json:"meta,omitempty"`
 }
 
+// Preview is a compact description of a document, used for link hover cards.
+type Preview struct {
+	// Title is the document title.
+	Title string `json:"title"`
+	// Path is the URL path.
+	Path string `json:"path"`
+	// Summary is the description frontmatter or the first paragraph as plain text.
+	Summary string `json:"summary"`
+}
+
 // Section holds the content under a specific heading.
 type Section struct {
 	// Heading is the matched heading text.
@@ -91,12 +124,26 @@ type document struct {
 	headings []Heading       // parsed headings with line numbers
 	keywords map[string]int // word frequency map for keyword search
 	meta     Metadata       // frontmatter metadata
+	summary  string         // description frontmatter or first paragraph
+	date     time.Time      // updated or date frontmatter, git commit time, or file modification time
+	schedule Schedule       // publication window from publish_at and expire_at
 }
 
 // Index holds the in-memory search index.
 type Index struct {
-	mu   sync.RWMutex
-	docs []document
+	mu      sync.RWMutex
+	docs    []document
+	options scanner.ScanOptions
+	// published is the view of the index without drafts, built on first
+	// use after each build.
+	published *Index
+
+	// gitAuthors attributes documents without author frontmatter to the
+	// git author of most of their lines.
+	gitAuthors atomic.Bool
+	// blames caches the git authors by file, valid while the last commit
+	// time of the file is unchanged.
+	blames sync.Map
 }
 
 // NewIndex creates an empty search index.
@@ -104,24 +151,61 @@ func NewIndex() *Index {
 	return &Index{}
 }
 
+// NewIndexWithOptions creates an empty search index whose document paths
+// follow the given scan options, matching the routes the server uses.
+func NewIndexWithOptions(opts scanner.ScanOptions) *Index {
+	return &Index{options: opts}
+}
+
 // Build scans the base directory and indexes all markdown files.
 func (idx *Index) Build(baseDir string) error {
-	entries, err := scanner.ScanDirectory(baseDir)
+	if _, err := os.Stat(baseDir); err != nil {
+		return err
+	}
+	commitTimes := gitCommitTimes(baseDir)
+	var authorOf func(name string) string
+	if idx.gitAuthors.Load() && commitTimes != nil {
+		authorOf = func(name string) string {
+			return idx.blameAuthor(baseDir, name, commitTimes[name])
+		}
+	}
+	return idx.build(os.DirFS(baseDir), commitTimes, authorOf)
+}
+
+// BuildFS indexes all markdown files in fsys. Documents without date
+// frontmatter are dated by their modification time.
+func (idx *Index) BuildFS(fsys fs.FS) error {
+	return idx.build(fsys, nil, nil)
+}
+
+// build indexes the markdown files in fsys, dating them by commitTimes
+// where available and attributing them by authorOf, if set, when their
+// frontmatter names no author.
+func (idx *Index) build(fsys fs.FS, commitTimes map[string]time.Time, authorOf func(name string) string) error {
+	entries, err := scanner.ScanFS(fsys, idx.options)
 	if err != nil {
 		return err
 	}
 
 	var docs []document
 	for _, entry := range entries {
-		doc, err := indexFile(baseDir, entry)
+		if info, err := fs.Stat(fsys, filepath.ToSlash(entry.RelPath)); err == nil && idx.options.TooLarge(info.Size()) {
+			log.Printf("Warning: not indexing %s: %s is over the page size limit", entry.RelPath, scanner.FormatSize(info.Size()))
+			continue
+		}
+		doc, err := indexFile(fsys, entry, commitTimes)
 		if err != nil {
 			continue // skip unreadable files
 		}
+		if doc.meta.Author == "" && authorOf != nil {
+			doc.meta.Author = authorOf(filepath.ToSlash(entry.RelPath))
+		}
 		docs = append(docs, doc)
 	}
 
 	idx.mu.Lock()
 	idx.docs = docs
+	idx.published = nil
 	idx.mu.Unlock()
 
 	return nil
@@ -138,8 +222,12 @@ func (idx *Index) Search(query string, maxResults int) []Result {
 	idx.mu.RLock()
 	defer idx.mu.RUnlock()
 
+	now := clock.Now()
 	var results []Result
 	for _, doc := range idx.docs {
+		if !doc.schedule.Live(now) {
+			continue
+		}
 		pos := strings.Index(doc.content, lowerQuery)
 		if pos == -1 {
 			continue
@@ -150,6 +238,7 @@ func (idx *Index) Search(query string, maxResults int) []Result {
 			Title:   doc.title,
 			Path:    doc.path,
 			Snippet: snippet,
+			Summary: doc.summary,
 		})
 
 		if len(results) >= maxResults {
@@ -163,72 +252,92 @@ func (idx *Index) Search(query string, maxResults int) []Result {
 // SearchKeywords finds documents matching any of the given keywords,
 // ranked by a relevance score based on keyword frequency and match count.
 func (idx *Index) SearchKeywords(query string, maxResults int) []Result {
-	if query == "" {
-		return nil
-	}
+	return idx.SearchFiltered(query, Filter{}, maxResults)
+}
 
-	keywords := tokenize(query)
-	if len(keywords) == 0 {
-		return nil
+// Outline returns the heading structure for a specific document by path.
+func (idx *Index) Outline(docPath string) (DocumentOutline, bool) {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	for _, doc := range idx.docs {
+		if doc.path == docPath && doc.schedule.Live(clock.Now()) {
+			return DocumentOutline{
+				Title:    doc.title,
+				Path:     doc.path,
+				Headings: doc.headings,
+				Meta:     doc.meta,
+			}, true
+		}
 	}
 
+	return DocumentOutline{}, false
+}
+
+// Preview returns the title and summary of the document at docPath.
+func (idx *Index) Preview(docPath string) (Preview, bool) {
 	idx.mu.RLock()
 	defer idx.mu.RUnlock()
 
-	type scored struct {
-		doc   document
-		score float64
-		pos   int // byte position of first match for snippet
+	for _, doc := range idx.docs {
+		if doc.path == docPath && doc.schedule.Live(clock.Now()) {
+			return Preview{Title: doc.title, Path: doc.path, Summary: doc.summary}, true
+		}
 	}
 
-	var matches []scored
+	return Preview{}, false
+}
+
+// Documents returns the title, path, and summary of every published
+// document in index order.
+func (idx *Index) Documents() []Preview {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	now := clock.Now()
+	previews := make([]Preview, 0, len(idx.docs))
 	for _, doc := range idx.docs {
-		score, firstPos := scoreDocument(doc, keywords)
-		if score == 0 {
+		if !doc.schedule.Live(now) {
 			continue
 		}
-		matches = append(matches, scored{doc: doc, score: score, pos: firstPos})
+		previews = append(previews, Preview{Title: doc.title, Path: doc.path, Summary: doc.summary})
 	}
+	return previews
+}
 
-	// Sort by score descending
-	sort.Slice(matches, func(i, j int) bool {
-		return matches[i].score > matches[j].score
-	})
+// AcknowledgePages returns the published documents that ask readers to
+// confirm they have read them, sorted by path.
+func (idx *Index) AcknowledgePages() []Preview {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
 
-	limit := min(maxResults, len(matches))
-	results := make([]Result, limit)
-	for i := 0; i < limit; i++ {
-		m := matches[i]
-		queryLen := len(keywords[0]) // use first keyword for snippet centering
-		results[i] = Result{
-			Title:   m.doc.title,
-			Path:    m.doc.path,
-			Snippet: extractSnippet(m.doc.raw, m.pos, queryLen),
-			Score:   m.score,
-			Meta:    m.doc.meta,
+	now := clock.Now()
+	var previews []Preview
+	for _, doc := range idx.docs {
+		if doc.meta.Acknowledge && doc.schedule.Live(now) {
+			previews = append(previews, Preview{Title: doc.title, Path: doc.path, Summary: doc.summary})
 		}
 	}
-
-	return results
+	sort.Slice(previews, func(i, j int) bool { return previews[i].Path < previews[j].Path })
+	return previews
 }
 
-// Outline returns the heading structure for a specific document by path.
-func (idx *Index) Outline(docPath string) (DocumentOutline, bool) {
+// IsDraft reports whether the document at docPath has status: draft.
+func (idx *Index) IsDraft(docPath string) bool {
 	idx.mu.RLock()
 	defer idx.mu.RUnlock()
 
 	for _, doc := range idx.docs {
 		if doc.path == docPath {
-			return DocumentOutline{
-				Title:    doc.title,
-				Path:     doc.path,
-				Headings: doc.headings,
-				Meta:     doc.meta,
-			}, true
+			return isDraft(doc)
 		}
 	}
+	return false
+}
 
-	return DocumentOutline{}, false
+// isDraft reports whether doc has status: draft.
+func isDraft(doc document) bool {
+	return strings.EqualFold(doc.meta.Status, "draft")
 }
 
 // AllTopics returns headings across all documents, grouped by document.
@@ -236,9 +345,10 @@ func (idx *Index) AllTopics() []DocumentOutline {
 	idx.mu.RLock()
 	defer idx.mu.RUnlock()
 
+	now := clock.Now()
 	var topics []DocumentOutline
 	for _, doc := range idx.docs {
-		if len(doc.headings) == 0 {
+		if len(doc.headings) == 0 || !doc.schedule.Live(now) {
 			continue
 		}
 		topics = append(topics, DocumentOutline{
@@ -261,7 +371,7 @@ func (idx *Index) FindSection(docPath, headingQuery string) (Section, bool) {
 	lowerQuery := strings.ToLower(headingQuery)
 
 	for _, doc := range idx.docs {
-		if doc.path != docPath {
+		if doc.path != docPath || !doc.schedule.Live(clock.Now()) {
 			continue
 		}
 		return extractSection(doc, lowerQuery)
@@ -274,90 +384,7 @@ func (idx *Index) FindSection(docPath, headingQuery string) (Section, bool) {
 // Only documents that have at least one of the specified tags are returned.
 // If tags is empty, it behaves identically to SearchKeywords.
 func (idx *Index) SearchKeywordsWithTags(query string, tags []string, maxResults int) []Result {
-	if len(tags) == 0 {
-		return idx.SearchKeywords(query, maxResults)
-	}
-
-	if query == "" {
-		return idx.filterByTags(tags, maxResults)
-	}
-
-	keywords := tokenize(query)
-	if len(keywords) == 0 {
-		return idx.filterByTags(tags, maxResults)
-	}
-
-	idx.mu.RLock()
-	defer idx.mu.RUnlock()
-
-	type scored struct {
-		doc   document
-		score float64
-		pos   int
-	}
-
-	lowerTags := make([]string, len(tags))
-	for i, t := range tags {
-		lowerTags[i] = strings.ToLower(t)
-	}
-
-	var matches []scored
-	for _, doc := range idx.docs {
-		if !docHasTag(doc, lowerTags) {
-			continue
-		}
-		score, firstPos := scoreDocument(doc, keywords)
-		if score == 0 {
-			continue
-		}
-		matches = append(matches, scored{doc: doc, score: score, pos: firstPos})
-	}
-
-	sort.Slice(matches, func(i, j int) bool {
-		return matches[i].score > matches[j].score
-	})
-
-	limit := min(maxResults, len(matches))
-	results := make([]Result, limit)
-	for i := 0; i < limit; i++ {
-		m := matches[i]
-		queryLen := len(keywords[0])
-		results[i] = Result{
-			Title:   m.doc.title,
-			Path:    m.doc.path,
-			Snippet: extractSnippet(m.doc.raw, m.pos, queryLen),
-			Score:   m.score,
-		}
-	}
-
-	return results
-}
-
-// filterByTags returns documents matching any of the given tags, without keyword scoring.
-func (idx *Index) filterByTags(tags []string, maxResults int) []Result {
-	idx.mu.RLock()
-	defer idx.mu.RUnlock()
-
-	lowerTags := make([]string, len(tags))
-	for i, t := range tags {
-		lowerTags[i] = strings.ToLower(t)
-	}
-
-	var results []Result
-	for _, doc := range idx.docs {
-		if !docHasTag(doc, lowerTags) {
-			continue
-		}
-		results = append(results, Result{
-			Title: doc.title,
-			Path:  doc.path,
-		})
-		if len(results) >= maxResults {
-			break
-		}
-	}
-
-	return results
+	return idx.SearchFiltered(query, Filter{Tags: tags}, maxResults)
 }
 
 // docHasTag checks whether a document has at least one of the given lowercase tags.
@@ -376,24 +403,35 @@ func docHasTag(doc document, lowerTags []string) bool {
 // headingPattern matches markdown headings (# through ######).
 var headingPattern = regexp.MustCompile(`^(#{1,6})\s+(.+)$`)
 
-// indexFile reads and indexes a single markdown file.
-func indexFile(baseDir string, entry scanner.FileEntry) (document, error) {
-	filePath := filepath.Join(baseDir, entry.RelPath)
-	content, err := os.ReadFile(filePath)
+// headingAttributesPattern matches a trailing {#id .class} attribute list,
+// which is not part of the heading text.
+var headingAttributesPattern = regexp.MustCompile(`\s+\{\s*(?:[#.]|[\w-]+=)[^{}]*\}$`)
+
+// indexFile reads and indexes a single markdown file. commitTimes holds the
+// git commit times used to date files without date frontmatter.
+func indexFile(fsys fs.FS, entry scanner.FileEntry, commitTimes map[string]time.Time) (document, error) {
+	filePath := filepath.ToSlash(entry.RelPath)
+	content, err := fs.ReadFile(fsys, filePath)
 	if err != nil {
 		return document{}, err
 	}
 
 	frontmatter, body := renderer.ParseFrontmatter(content)
 
+	date, ok := FrontmatterDate(frontmatter)
+	if !ok {
+		if commitTime, ok := commitTimes[filePath]; ok {
+			date = commitTime
+		} else if info, statErr := fs.Stat(fsys, filePath); statErr == nil {
+			date = clock.Clamp(info.ModTime())
+		}
+	}
+
 	title := frontmatter.Title
 	if title == "" {
 		title = entry.Name
 	}
 
-	urlPath := strings.TrimSuffix(entry.RelPath, filepath.Ext(entry.RelPath))
-	urlPath = "/" + filepath.ToSlash(urlPath)
-
 	raw := string(body)
 	headings := parseHeadings(raw)
 	keywords := buildKeywordMap(raw)
@@ -406,24 +444,37 @@ func indexFile(baseDir string, entry scanner.FileEntry) (document, error) {
 		keywords[k] += v
 	}
 
+	schedule, _ := ParseSchedule(frontmatter)
+	seriesPart, _ := ParseSeriesPart(frontmatter)
+
 	meta := Metadata{
 		Author:    frontmatter.Author,
 		Status:    frontmatter.Status,
 		Date:      frontmatter.Date,
+		Updated:   frontmatter.Updated,
 		Tags:      frontmatter.Tags,
 		Category:  frontmatter.Category,
 		Version:   frontmatter.Version,
 		Reviewers: frontmatter.Reviewers,
+		Owner:     frontmatter.Owner,
+		ReviewBy:  frontmatter.ReviewBy,
+
+		Acknowledge: frontmatter.Acknowledge,
+		Series:      strings.TrimSpace(frontmatter.Series),
+		SeriesPart:  seriesPart,
 	}
 
 	return document{
 		title:    title,
-		path:     urlPath,
+		path:     entry.URLPath,
 		content:  strings.ToLower(raw),
 		raw:      raw,
 		headings: headings,
 		keywords: keywords,
 		meta:     meta,
+		summary:  summarize(frontmatter, raw),
+		date:     date,
+		schedule: schedule,
 	}, nil
 }
 
@@ -439,7 +490,7 @@ func parseHeadings(text string) []Heading {
 		}
 		headings = append(headings, Heading{
 			Level: len(match[1]),
-			Text:  strings.TrimSpace(match[2]),
+			Text:  headingAttributesPattern.ReplaceAllString(strings.TrimSpace(match[2]), ""),
 			Line:  i + 1,
 		})
 	}
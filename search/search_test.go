@@ -4,6 +4,8 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+
+	"gomdoc/scanner"
 )
 
 // setupTestDir creates a temporary directory with markdown test files.
@@ -165,6 +167,16 @@ func TestParseHeadings(t *testing.T) {
 	}
 }
 
+func TestParseHeadingsStripsAttributes(t *testing.T) {
+	headings := parseHeadings("## Setup {#install .step}\n## Sets {a, b}\n")
+	if headings[0].Text != "Setup" {
+		t.Errorf("expected attribute list to be stripped, got '%s'", headings[0].Text)
+	}
+	if headings[1].Text != "Sets {a, b}" {
+		t.Errorf("expected literal braces to be kept, got '%s'", headings[1].Text)
+	}
+}
+
 func TestOutline(t *testing.T) {
 	dir := setupTestDir(t)
 	idx := NewIndex()
@@ -462,3 +474,80 @@ func contains(s, substr string) bool {
 	}
 	return false
 }
+
+func TestPreview(t *testing.T) {
+	dir := setupTestDir(t)
+	idx := NewIndex()
+	idx.Build(dir)
+
+	preview, found := idx.Preview("/guide")
+	if !found {
+		t.Fatal("expected preview for /guide")
+	}
+	if preview.Title != "User Guide" {
+		t.Errorf("expected title 'User Guide', got '%s'", preview.Title)
+	}
+	if preview.Summary != "Follow these steps to begin." {
+		t.Errorf("expected first paragraph as summary, got '%s'", preview.Summary)
+	}
+
+	if _, found := idx.Preview("/missing"); found {
+		t.Error("expected no preview for missing document")
+	}
+}
+
+func TestFirstParagraph(t *testing.T) {
+	markdown := "# Title\n\n```go\ncode := 1\n```\n\n- list item\n\nThe **first** paragraph links to [the guide](guide.md)\nand continues here.\n\nSecond paragraph."
+
+	got := firstParagraph(markdown)
+	want := "The first paragraph links to the guide and continues here."
+	if got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
+
+func TestSummaryPrefersDescription(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "doc.md"), []byte("---\ndescription: Curated summary.\n---\nFirst paragraph.\n"), 0o644)
+	idx := NewIndex()
+	idx.Build(dir)
+
+	results := idx.SearchKeywords("paragraph", 10)
+	if len(results) != 1 {
+		t.Fatalf("expected 1 result, got %d", len(results))
+	}
+	if results[0].Summary != "Curated summary." {
+		t.Errorf("expected description as summary, got '%s'", results[0].Summary)
+	}
+}
+
+func TestDocuments(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "a.md"), []byte("# Alpha\nFirst.\n"), 0o644)
+	os.WriteFile(filepath.Join(dir, "b.md"), []byte("# Beta\nSecond.\n"), 0o644)
+	idx := NewIndex()
+	idx.Build(dir)
+
+	docs := idx.Documents()
+	if len(docs) != 2 {
+		t.Fatalf("expected 2 documents, got %d", len(docs))
+	}
+	if docs[0].Path != "/a" || docs[1].Summary != "Second." {
+		t.Errorf("unexpected documents: %+v", docs)
+	}
+}
+
+func TestBuildSkipsLargeFiles(t *testing.T) {
+	dir := setupTestDir(t)
+	idx := NewIndexWithOptions(scanner.ScanOptions{MaxPageBytes: 150})
+
+	if err := idx.Build(dir); err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+	if len(idx.docs) != 2 {
+		t.Fatalf("expected the large guide to be skipped, got %d documents", len(idx.docs))
+	}
+	if results := idx.Search("installer", 10); len(results) != 0 {
+		t.Errorf("expected no results from the skipped file, got %v", results)
+	}
+}
@@ -0,0 +1,66 @@
+package search
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gomdoc/clock"
+	"gomdoc/renderer"
+)
+
+// SeriesPart is a page of a series.
+type SeriesPart struct {
+	// Title is the document title.
+	Title string `json:"title"`
+	// Path is the URL path.
+	Path string `json:"path"`
+	// Part is the series_part number, zero when unset.
+	Part int `json:"part,omitempty"`
+}
+
+// ParseSeriesPart parses the series_part frontmatter of a page, a whole
+// number from 1. It returns zero when the key is unset.
+func ParseSeriesPart(frontmatter renderer.Frontmatter) (int, error) {
+	if frontmatter.SeriesPart == "" {
+		return 0, nil
+	}
+	part, err := strconv.Atoi(frontmatter.SeriesPart)
+	if err != nil || part < 1 {
+		return 0, fmt.Errorf("series_part: invalid part %q: use a whole number from 1", frontmatter.SeriesPart)
+	}
+	if frontmatter.Series == "" {
+		return part, fmt.Errorf("series_part: %d without a series: name", part)
+	}
+	return part, nil
+}
+
+// Series returns the published pages of the series name, compared
+// ignoring case, in reading order: by series_part, then pages without
+// one, each by path. Drafts are left out unless drafts is set.
+func (idx *Index) Series(name string, drafts bool) []SeriesPart {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil
+	}
+	now := clock.Now()
+	var parts []SeriesPart
+	for _, doc := range idx.docs {
+		if !strings.EqualFold(doc.meta.Series, name) || !doc.schedule.Live(now) || !drafts && isDraft(doc) {
+			continue
+		}
+		parts = append(parts, SeriesPart{Title: doc.title, Path: doc.path, Part: doc.meta.SeriesPart})
+	}
+	sort.Slice(parts, func(i, j int) bool {
+		a, b := parts[i], parts[j]
+		if a.Part != b.Part {
+			return a.Part != 0 && (b.Part == 0 || a.Part < b.Part)
+		}
+		return a.Path < b.Path
+	})
+	return parts
+}
@@ -0,0 +1,67 @@
+package search
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"gomdoc/renderer"
+)
+
+func TestSeries(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "later"), 0o755)
+	files := map[string]string{
+		"intro.md":      "---\ntitle: Intro\nseries: Onboarding\nseries_part: 1\n---\n# Intro\n",
+		"later/deep.md": "---\ntitle: Deep Dive\nseries: onboarding\nseries_part: 10\n---\n# Deep\n",
+		"setup.md":      "---\ntitle: Setup\nseries: Onboarding\nseries_part: 2\n---\n# Setup\n",
+		"extra.md":      "---\ntitle: Extra\nseries: Onboarding\n---\n# Extra\n",
+		"draft.md":      "---\ntitle: Draft\nseries: Onboarding\nseries_part: 3\nstatus: draft\n---\n# Draft\n",
+		"other.md":      "---\ntitle: Other\nseries: Other\nseries_part: 1\n---\n# Other\n",
+	}
+	for name, content := range files {
+		os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644)
+	}
+
+	idx := NewIndex()
+	if err := idx.Build(dir); err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+
+	paths := func(parts []SeriesPart) []string {
+		var list []string
+		for _, part := range parts {
+			list = append(list, part.Path)
+		}
+		return list
+	}
+	if got, want := paths(idx.Series("Onboarding", false)), []string{"/intro", "/setup", "/later/deep", "/extra"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+	if got, want := paths(idx.Series("Onboarding", true)), []string{"/intro", "/setup", "/draft", "/later/deep", "/extra"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected drafts included, %q, got %q", want, got)
+	}
+	if parts := idx.Series("", true); parts != nil {
+		t.Errorf("expected no parts without a series name, got %+v", parts)
+	}
+}
+
+func TestParseSeriesPart(t *testing.T) {
+	for _, tc := range []struct {
+		series, part string
+		want         int
+		valid        bool
+	}{
+		{"Onboarding", "", 0, true},
+		{"Onboarding", "3", 3, true},
+		{"Onboarding", "0", 0, false},
+		{"Onboarding", "third", 0, false},
+		{"", "2", 2, false},
+	} {
+		part, err := ParseSeriesPart(renderer.Frontmatter{Series: tc.series, SeriesPart: tc.part})
+		if part != tc.want || (err == nil) != tc.valid {
+			t.Errorf("ParseSeriesPart(%q, %q) = %d, %v", tc.series, tc.part, part, err)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package search
+
+import (
+	"sort"
+
+	"gomdoc/clock"
+)
+
+// StaticDocument is a document in the client-side search index of exported
+// sites, which search in the browser without a server.
+type StaticDocument struct {
+	// Title is the document title.
+	Title string `json:"title"`
+	// Path is the URL path.
+	Path string `json:"path"`
+	// Summary is the description frontmatter or the first paragraph.
+	Summary string `json:"summary,omitempty"`
+	// Tags is the list of frontmatter tags.
+	Tags []string `json:"tags,omitempty"`
+	// Headings is the text of the document's headings, in order.
+	Headings []string `json:"headings,omitempty"`
+	// Text is the markdown body, searched and quoted in snippets.
+	Text string `json:"text"`
+}
+
+// StaticDocuments returns the published documents with their text for a
+// client-side search index, sorted by path.
+func (idx *Index) StaticDocuments() []StaticDocument {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	now := clock.Now()
+	docs := make([]StaticDocument, 0, len(idx.docs))
+	for _, doc := range idx.docs {
+		if !doc.schedule.Live(now) {
+			continue
+		}
+		static := StaticDocument{
+			Title:   doc.title,
+			Path:    doc.path,
+			Summary: doc.summary,
+			Tags:    doc.meta.Tags,
+			Text:    doc.raw,
+		}
+		for _, heading := range doc.headings {
+			static.Headings = append(static.Headings, heading.Text)
+		}
+		docs = append(docs, static)
+	}
+	sort.Slice(docs, func(i, j int) bool { return docs[i].Path < docs[j].Path })
+	return docs
+}
@@ -0,0 +1,95 @@
+package search
+
+import (
+	"regexp"
+	"strings"
+
+	"gomdoc/renderer"
+)
+
+// maxSummaryRunes caps summaries so previews and listings stay compact.
+const maxSummaryRunes = 300
+
+var (
+	// summaryImagePattern matches markdown images, which carry no summary text.
+	summaryImagePattern = regexp.MustCompile(`!\[[^\]]*\]\([^)]*\)`)
+	// summaryLinkPattern matches markdown links, keeping the link text.
+	summaryLinkPattern = regexp.MustCompile(`\[([^\]]*)\]\([^)]*\)`)
+	// summaryHTMLPattern matches inline HTML tags.
+	summaryHTMLPattern = regexp.MustCompile(`<[^>]+>`)
+	// summaryEmphasisPattern matches emphasis and inline code markers.
+	summaryEmphasisPattern = regexp.MustCompile("[*`]+|~~")
+)
+
+// summarize picks the document summary: the description frontmatter when
+// present, otherwise the first paragraph of the body.
+func summarize(frontmatter renderer.Frontmatter, body string) string {
+	if frontmatter.Description != "" {
+		return truncateRunes(frontmatter.Description, maxSummaryRunes)
+	}
+	return firstParagraph(body)
+}
+
+// firstParagraph returns the first prose paragraph of a markdown body as
+// plain text. Headings, code fences, lists, tables, and quotes are skipped
+// because they rarely make a readable summary.
+func firstParagraph(markdown string) string {
+	var paragraph []string
+	inFence := false
+
+	for _, line := range strings.Split(markdown, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			inFence = !inFence
+			continue
+		}
+		if inFence {
+			continue
+		}
+		if trimmed == "" || !isProseLine(trimmed) {
+			if len(paragraph) > 0 {
+				break
+			}
+			continue
+		}
+		paragraph = append(paragraph, trimmed)
+	}
+
+	return truncateRunes(plainText(strings.Join(paragraph, " ")), maxSummaryRunes)
+}
+
+// isProseLine reports whether a trimmed line belongs to a regular paragraph.
+func isProseLine(line string) bool {
+	for _, prefix := range []string{"#", ">", "|", "- ", "* ", "+ ", "<", "![", "---", "==="} {
+		if strings.HasPrefix(line, prefix) {
+			return false
+		}
+	}
+	return !orderedListPattern.MatchString(line)
+}
+
+// orderedListPattern matches ordered list items like "1. Step".
+var orderedListPattern = regexp.MustCompile(`^\d+[.)]\s`)
+
+// plainText strips inline markdown syntax, keeping the readable text.
+func plainText(text string) string {
+	text = summaryImagePattern.ReplaceAllString(text, "")
+	text = summaryLinkPattern.ReplaceAllString(text, "$1")
+	text = summaryHTMLPattern.ReplaceAllString(text, "")
+	text = summaryEmphasisPattern.ReplaceAllString(text, "")
+	return strings.Join(strings.Fields(text), " ")
+}
+
+// truncateRunes shortens text to at most limit runes, cutting at a word
+// boundary and appending an ellipsis when truncated.
+func truncateRunes(text string, limit int) string {
+	runes := []rune(text)
+	if len(runes) <= limit {
+		return text
+	}
+	cut := string(runes[:limit])
+	if space := strings.LastIndex(cut, " "); space > 0 {
+		cut = cut[:space]
+	}
+	return cut + "..."
+}
@@ -0,0 +1,129 @@
+// Package semantic answers questions about the docs with the passages
+// closest in meaning, comparing embeddings computed by an OpenAI-compatible
+// API such as OpenAI itself, Ollama, or LocalAI.
+package semantic
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// DefaultModel is the embedding model used when Options.Model is empty.
+const DefaultModel = "text-embedding-3-small"
+
+// batchSize is how many texts are embedded per request.
+const batchSize = 64
+
+// Options configures the embedding provider and the vector cache.
+type Options struct {
+	// Endpoint is the base URL of an OpenAI-compatible API, such as
+	// https://api.openai.com/v1 or http://localhost:11434/v1 for Ollama.
+	// Its /embeddings route computes the embeddings.
+	Endpoint string
+	// Model names the embedding model. Defaults to DefaultModel.
+	Model string
+	// APIKey is sent as a bearer token, unless empty.
+	APIKey string
+	// CacheFile keeps computed embeddings between runs, so only new and
+	// changed passages are sent to the provider. Empty keeps them in
+	// memory only.
+	CacheFile string
+	// Client sends the requests. Nil uses a client with a one-minute
+	// timeout.
+	Client *http.Client
+}
+
+// Enabled reports whether an embedding provider is configured.
+func (o Options) Enabled() bool {
+	return o.Endpoint != ""
+}
+
+// withDefaults fills in the zero values of o.
+func (o Options) withDefaults() Options {
+	if o.Model == "" {
+		o.Model = DefaultModel
+	}
+	if o.Client == nil {
+		o.Client = &http.Client{Timeout: time.Minute}
+	}
+	o.Endpoint = strings.TrimSuffix(o.Endpoint, "/")
+	return o
+}
+
+// embeddingRequest is the body of an /embeddings request.
+type embeddingRequest struct {
+	Model string   `json:"model"`
+	Input []string `json:"input"`
+}
+
+// embeddingResponse is the body of an /embeddings response.
+type embeddingResponse struct {
+	Data []struct {
+		Index     int       `json:"index"`
+		Embedding []float32 `json:"embedding"`
+	} `json:"data"`
+}
+
+// embed returns the embeddings of texts, in the same order, requesting
+// them in batches.
+func embed(ctx context.Context, opts Options, texts []string) ([][]float32, error) {
+	vectors := make([][]float32, 0, len(texts))
+	for start := 0; start < len(texts); start += batchSize {
+		batch := texts[start:min(start+batchSize, len(texts))]
+		embedded, err := embedBatch(ctx, opts, batch)
+		if err != nil {
+			return nil, err
+		}
+		vectors = append(vectors, embedded...)
+	}
+	return vectors, nil
+}
+
+// embedBatch sends one /embeddings request.
+func embedBatch(ctx context.Context, opts Options, texts []string) ([][]float32, error) {
+	body, err := json.Marshal(embeddingRequest{Model: opts.Model, Input: texts})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, opts.Endpoint+"/embeddings", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if opts.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+opts.APIKey)
+	}
+	resp, err := opts.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("embeddings: %s: %s", resp.Status, strings.TrimSpace(string(message)))
+	}
+
+	var parsed embeddingResponse
+	if err := json.NewDecoder(resp.Body).Decode(&parsed); err != nil {
+		return nil, fmt.Errorf("embeddings: %w", err)
+	}
+	vectors := make([][]float32, len(texts))
+	for _, item := range parsed.Data {
+		if item.Index < 0 || item.Index >= len(texts) {
+			return nil, fmt.Errorf("embeddings: index %d out of range", item.Index)
+		}
+		vectors[item.Index] = item.Embedding
+	}
+	for i, vector := range vectors {
+		if len(vector) == 0 {
+			return nil, fmt.Errorf("embeddings: no embedding for input %d", i)
+		}
+	}
+	return vectors, nil
+}
@@ -0,0 +1,210 @@
+package semantic
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"math"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"gomdoc/search"
+)
+
+// ErrNotReady is returned by Ask before the first Build finished.
+var ErrNotReady = errors.New("the semantic index is still being built")
+
+// Passage is a part of a page found by Ask.
+type Passage struct {
+	// Path is the URL path of the page.
+	Path string `json:"path"`
+	// Title is the page title.
+	Title string `json:"title"`
+	// Headings are the headings the passage is under, outermost first.
+	Headings []string `json:"headings,omitempty"`
+	// Text is the passage as plain text.
+	Text string `json:"text"`
+	// Score is the cosine similarity to the question, up to 1.
+	Score float64 `json:"score"`
+}
+
+// entry is an indexed passage with its normalized embedding.
+type entry struct {
+	chunk  search.Chunk
+	vector []float32
+}
+
+// Index is an in-memory vector index of the passages of the docs.
+type Index struct {
+	opts Options
+
+	// build serializes Build calls.
+	build sync.Mutex
+
+	mu      sync.RWMutex
+	entries []entry
+	ready   bool
+	// cache maps the hash of the model and a passage's text to its
+	// embedding.
+	cache map[string][]float32
+}
+
+// New returns an empty index that embeds passages as opts describes,
+// starting from the embeddings in opts.CacheFile.
+func New(opts Options) *Index {
+	x := &Index{opts: opts.withDefaults(), cache: make(map[string][]float32)}
+	if x.opts.CacheFile != "" {
+		if data, err := os.ReadFile(x.opts.CacheFile); err == nil {
+			json.Unmarshal(data, &x.cache)
+		}
+	}
+	return x
+}
+
+// Build indexes chunks, embedding the ones whose text is not cached.
+// Embeddings of passages that no longer exist are dropped from the cache.
+func (x *Index) Build(ctx context.Context, chunks []search.Chunk) error {
+	x.build.Lock()
+	defer x.build.Unlock()
+
+	x.mu.RLock()
+	cache := x.cache
+	x.mu.RUnlock()
+
+	keys := make([]string, len(chunks))
+	var missing []string
+	var missingKeys []string
+	for i, chunk := range chunks {
+		text := embeddingText(chunk)
+		keys[i] = x.key(text)
+		if _, found := cache[keys[i]]; !found && !contains(missingKeys, keys[i]) {
+			missing = append(missing, text)
+			missingKeys = append(missingKeys, keys[i])
+		}
+	}
+	vectors, err := embed(ctx, x.opts, missing)
+	if err != nil {
+		return err
+	}
+
+	next := make(map[string][]float32, len(chunks))
+	for i, key := range missingKeys {
+		next[key] = normalize(vectors[i])
+	}
+	entries := make([]entry, 0, len(chunks))
+	for i, chunk := range chunks {
+		vector, found := next[keys[i]]
+		if !found {
+			vector = cache[keys[i]]
+			next[keys[i]] = vector
+		}
+		entries = append(entries, entry{chunk: chunk, vector: vector})
+	}
+
+	x.mu.Lock()
+	x.entries = entries
+	x.cache = next
+	x.ready = true
+	x.mu.Unlock()
+	return x.saveCache(next)
+}
+
+// Ask returns the limit passages closest in meaning to question, best
+// first.
+func (x *Index) Ask(ctx context.Context, question string, limit int) ([]Passage, error) {
+	x.mu.RLock()
+	ready := x.ready
+	x.mu.RUnlock()
+	if !ready {
+		return nil, ErrNotReady
+	}
+	vectors, err := embed(ctx, x.opts, []string{question})
+	if err != nil {
+		return nil, err
+	}
+	query := normalize(vectors[0])
+
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	passages := make([]Passage, 0, len(x.entries))
+	for _, e := range x.entries {
+		passages = append(passages, Passage{
+			Path:     e.chunk.Path,
+			Title:    e.chunk.Title,
+			Headings: e.chunk.Headings,
+			Text:     e.chunk.Text,
+			Score:    dot(query, e.vector),
+		})
+	}
+	sort.SliceStable(passages, func(i, j int) bool { return passages[i].Score > passages[j].Score })
+	if len(passages) > limit {
+		passages = passages[:limit]
+	}
+	return passages, nil
+}
+
+// key returns the cache key of a passage's text.
+func (x *Index) key(text string) string {
+	sum := sha256.Sum256([]byte(x.opts.Model + "\x00" + text))
+	return hex.EncodeToString(sum[:])
+}
+
+// saveCache writes the embeddings to the cache file, unless there is none.
+func (x *Index) saveCache(cache map[string][]float32) error {
+	if x.opts.CacheFile == "" {
+		return nil
+	}
+	data, err := json.Marshal(cache)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(x.opts.CacheFile, data, 0o644)
+}
+
+// embeddingText is the text embedded for a chunk: its title and headings
+// give the passage its context.
+func embeddingText(chunk search.Chunk) string {
+	context := append([]string{chunk.Title}, chunk.Headings...)
+	return strings.Join(context, " > ") + "\n\n" + chunk.Text
+}
+
+// normalize scales vector to unit length, so the dot product of two
+// vectors is their cosine similarity.
+func normalize(vector []float32) []float32 {
+	var sum float64
+	for _, v := range vector {
+		sum += float64(v) * float64(v)
+	}
+	norm := math.Sqrt(sum)
+	if norm == 0 {
+		return vector
+	}
+	scaled := make([]float32, len(vector))
+	for i, v := range vector {
+		scaled[i] = float32(float64(v) / norm)
+	}
+	return scaled
+}
+
+// dot returns the dot product of two vectors, over the shorter length.
+func dot(a, b []float32) float64 {
+	var sum float64
+	for i := range min(len(a), len(b)) {
+		sum += float64(a[i]) * float64(b[i])
+	}
+	return sum
+}
+
+// contains reports whether keys holds key.
+func contains(keys []string, key string) bool {
+	for _, k := range keys {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
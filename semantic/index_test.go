@@ -0,0 +1,114 @@
+package semantic
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"gomdoc/search"
+)
+
+// fakeVocabulary are the words the fake provider embeds, one dimension
+// each.
+var fakeVocabulary = []string{"install", "deploy", "backup"}
+
+// newFakeProvider serves /embeddings with vectors counting the words of
+// fakeVocabulary, and counts the texts it embedded.
+func newFakeProvider(t *testing.T, embedded *atomic.Int64) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/embeddings" || r.Header.Get("Authorization") != "Bearer secret" {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		var req embeddingRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		var resp embeddingResponse
+		resp.Data = make([]struct {
+			Index     int       `json:"index"`
+			Embedding []float32 `json:"embedding"`
+		}, len(req.Input))
+		for i, text := range req.Input {
+			vector := make([]float32, len(fakeVocabulary)+1)
+			vector[len(fakeVocabulary)] = 0.1
+			for j, word := range fakeVocabulary {
+				vector[j] = float32(strings.Count(strings.ToLower(text), word))
+			}
+			resp.Data[i].Index = i
+			resp.Data[i].Embedding = vector
+		}
+		embedded.Add(int64(len(req.Input)))
+		json.NewEncoder(w).Encode(resp)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestAskRanksPassagesByMeaning(t *testing.T) {
+	var embedded atomic.Int64
+	provider := newFakeProvider(t, &embedded)
+	x := New(Options{Endpoint: provider.URL + "/v1/", APIKey: "secret"})
+
+	if _, err := x.Ask(context.Background(), "install", 1); err != ErrNotReady {
+		t.Fatalf("Ask before Build = %v, want ErrNotReady", err)
+	}
+	chunks := []search.Chunk{
+		{Path: "/setup", Title: "Setup", Text: "Install the binary, then install the service."},
+		{Path: "/ops", Title: "Operations", Headings: []string{"Backups"}, Text: "Take a backup before every deploy."},
+	}
+	if err := x.Build(context.Background(), chunks); err != nil {
+		t.Fatal(err)
+	}
+	passages, err := x.Ask(context.Background(), "how do I make a backup?", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(passages) != 2 || passages[0].Path != "/ops" || passages[0].Headings[0] != "Backups" {
+		t.Fatalf("passages = %+v, want /ops first", passages)
+	}
+	if passages[0].Score <= passages[1].Score {
+		t.Errorf("scores not descending: %v, %v", passages[0].Score, passages[1].Score)
+	}
+}
+
+func TestBuildReusesCachedEmbeddings(t *testing.T) {
+	var embedded atomic.Int64
+	provider := newFakeProvider(t, &embedded)
+	opts := Options{Endpoint: provider.URL + "/v1", APIKey: "secret", CacheFile: filepath.Join(t.TempDir(), "embeddings.json")}
+	chunks := []search.Chunk{
+		{Path: "/a", Title: "A", Text: "install"},
+		{Path: "/b", Title: "B", Text: "deploy"},
+	}
+	if err := New(opts).Build(context.Background(), chunks); err != nil {
+		t.Fatal(err)
+	}
+	if embedded.Load() != 2 {
+		t.Fatalf("embedded %d texts, want 2", embedded.Load())
+	}
+
+	chunks[1].Text = "deploy again"
+	if err := New(opts).Build(context.Background(), chunks); err != nil {
+		t.Fatal(err)
+	}
+	if embedded.Load() != 3 {
+		t.Errorf("embedded %d texts in total, want only the changed one again", embedded.Load())
+	}
+}
+
+func TestBuildReportsProviderErrors(t *testing.T) {
+	var embedded atomic.Int64
+	provider := newFakeProvider(t, &embedded)
+	x := New(Options{Endpoint: provider.URL + "/v1", APIKey: "wrong"})
+	err := x.Build(context.Background(), []search.Chunk{{Path: "/a", Title: "A", Text: "install"}})
+	if err == nil || !strings.Contains(err.Error(), "400") {
+		t.Errorf("Build error = %v, want the provider's 400", err)
+	}
+}
@@ -0,0 +1,217 @@
+package server
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"log"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"gomdoc/renderer"
+	"gomdoc/search"
+	"gomdoc/templates"
+)
+
+// acknowledgmentsBucket is the database bucket holding the read receipts
+// of each page, keyed by the siteKey of the page path.
+const acknowledgmentsBucket = "acknowledgments"
+
+// acknowledgmentDateFormat is how receipt dates are shown.
+const acknowledgmentDateFormat = "January 2, 2006 15:04"
+
+// pageReceipts are the read receipts of a page.
+type pageReceipts struct {
+	Receipts []receipt `json:"receipts"`
+}
+
+// receipt records that a user confirmed reading a version of a page.
+type receipt struct {
+	User    string    `json:"user"`
+	Version string    `json:"version"`
+	Time    time.Time `json:"time"`
+}
+
+// pageVersion identifies the version of a page readers acknowledge: its
+// version frontmatter when set, otherwise a hash of its source, so that
+// any edit asks for a new confirmation.
+func pageVersion(frontmatter renderer.Frontmatter, source []byte) string {
+	if frontmatter.Version != "" {
+		return frontmatter.Version
+	}
+	sum := sha256.Sum256(source)
+	return hex.EncodeToString(sum[:6])
+}
+
+// find returns the receipt of user for version.
+func (p pageReceipts) find(user, version string) (receipt, bool) {
+	for _, r := range p.Receipts {
+		if r.User == user && r.Version == version {
+			return r, true
+		}
+	}
+	return receipt{}, false
+}
+
+// acknowledgedOn returns the date user confirmed reading version of the
+// page at pagePath, empty if they have not.
+func (s *Server) acknowledgedOn(pagePath, user, version string) string {
+	var receipts pageReceipts
+	if _, err := s.db.Get(acknowledgmentsBucket, s.siteKey(pagePath), &receipts); err != nil {
+		log.Printf("Error reading acknowledgments of %s: %v", pagePath, err)
+		return ""
+	}
+	if found, ok := receipts.find(user, version); ok {
+		return found.Time.Format(acknowledgmentDateFormat)
+	}
+	return ""
+}
+
+// acknowledgePage reads the page at pagePath for acknowledgment: its
+// frontmatter and current version. It fails for missing and unpublished
+// pages, and for pages without acknowledge: true.
+func (s *Server) acknowledgePage(pagePath string) (string, bool) {
+	source, err := s.readDocument(strings.TrimPrefix(pagePath, "/"))
+	if err != nil || s.scheduleStatus(pagePath) != search.Published {
+		return "", false
+	}
+	frontmatter, _ := renderer.ParseFrontmatter(source)
+	if !frontmatter.Acknowledge {
+		return "", false
+	}
+	return pageVersion(frontmatter, source), true
+}
+
+// handleAcknowledge records that the signed-in user has read the current
+// version of the page posted in the path field, then sends them back to
+// the page. Cross-origin posts are refused.
+func (s *Server) handleAcknowledge(w http.ResponseWriter, r *http.Request) {
+	user := s.currentUser(r)
+	if user == "" {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodPost {
+		methodNotAllowed(w, http.MethodPost)
+		return
+	}
+	if err := http.NewCrossOriginProtection().Check(r); err != nil {
+		http.Error(w, "Cross-origin request refused", http.StatusForbidden)
+		return
+	}
+	pagePath := r.PostFormValue("path")
+	version, ok := s.acknowledgePage(pagePath)
+	if !ok {
+		http.Error(w, "No page asking for acknowledgment at path", http.StatusNotFound)
+		return
+	}
+
+	var receipts pageReceipts
+	err := s.db.Update(acknowledgmentsBucket, s.siteKey(pagePath), &receipts, func() error {
+		if _, found := receipts.find(user, version); !found {
+			receipts.Receipts = append(receipts.Receipts, receipt{User: user, Version: version, Time: time.Now().UTC()})
+		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("Error storing acknowledgment: %v", err)
+		http.Error(w, "Failed to store acknowledgment", http.StatusInternalServerError)
+		return
+	}
+	s.audit(r, auditEvent{Action: auditAcknowledge, Path: pagePath})
+	http.Redirect(w, r, pagePath, http.StatusSeeOther)
+}
+
+// handleAcknowledgments renders the acknowledgment report: the pages
+// asking for acknowledgment, or with the path parameter, who has and who
+// has not confirmed reading the current version of one of them.
+func (s *Server) handleAcknowledgments(w http.ResponseWriter, r *http.Request) {
+	data := templates.AcknowledgmentsData{
+		SiteLayout: s.layout(),
+	}
+	pages := s.index.AcknowledgePages()
+	selected := r.URL.Query().Get("path")
+	for _, page := range pages {
+		version, ok := s.acknowledgePage(page.Path)
+		if !ok {
+			continue
+		}
+		var receipts pageReceipts
+		s.db.Get(acknowledgmentsBucket, s.siteKey(page.Path), &receipts)
+		entry := templates.AcknowledgmentPage{Title: page.Title, Path: page.Path}
+		for _, r := range receipts.Receipts {
+			if r.Version == version {
+				entry.Acknowledged++
+			}
+		}
+		data.Pages = append(data.Pages, entry)
+		if page.Path == selected {
+			data.Page = &entry
+			data.Version = version
+			data.Current, data.Outdated, data.Pending = s.readers(receipts, version)
+		}
+	}
+	if selected != "" && data.Page == nil {
+		http.Error(w, "No page asking for acknowledgment at path", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	if err := templates.RenderAcknowledgments(w, data); err != nil {
+		log.Printf("Error rendering acknowledgment report: %v", err)
+	}
+}
+
+// readers sorts the known readers of a page by whether they confirmed its
+// current version, only an earlier one, or none. Known readers are the
+// basic auth user, the allowed OAuth2 addresses, and everyone who
+// acknowledged any page.
+func (s *Server) readers(receipts pageReceipts, version string) (current, outdated []templates.Acknowledgment, pending []string) {
+	latest := make(map[string]receipt)
+	for _, r := range receipts.Receipts {
+		if previous, found := latest[r.User]; !found || previous.Version != version {
+			latest[r.User] = r
+		}
+	}
+	for _, r := range latest {
+		entry := templates.Acknowledgment{User: r.User, Version: r.Version, Date: r.Time.Format(acknowledgmentDateFormat)}
+		if r.Version == version {
+			current = append(current, entry)
+		} else {
+			outdated = append(outdated, entry)
+		}
+	}
+	for _, user := range s.knownReaders() {
+		if _, found := latest[user]; !found {
+			pending = append(pending, user)
+		}
+	}
+	byUser := func(list []templates.Acknowledgment) {
+		sort.Slice(list, func(i, j int) bool { return list[i].User < list[j].User })
+	}
+	byUser(current)
+	byUser(outdated)
+	return current, outdated, pending
+}
+
+// knownReaders returns the users who can sign in or have acknowledged a
+// page, sorted.
+func (s *Server) knownReaders() []string {
+	var users []string
+	if s.authUser != "" {
+		users = append(users, s.authUser)
+	}
+	users = append(users, s.oauth2Config.AllowedEmails...)
+	for _, pagePath := range s.siteKeys(acknowledgmentsBucket) {
+		var receipts pageReceipts
+		s.db.Get(acknowledgmentsBucket, s.siteKey(pagePath), &receipts)
+		for _, r := range receipts.Receipts {
+			users = append(users, r.User)
+		}
+	}
+	slices.Sort(users)
+	return slices.Compact(users)
+}
@@ -0,0 +1,67 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAcknowledgments(t *testing.T) {
+	dir := t.TempDir()
+	policy := filepath.Join(dir, "policy.md")
+	os.WriteFile(policy, []byte("---\ntitle: Travel Policy\nacknowledge: true\n---\n# Travel\n\nBook early.\n"), 0o644)
+	os.WriteFile(filepath.Join(dir, "guide.md"), []byte("# Guide\n"), 0o644)
+	s := NewWithAuth(dir, 0, "Docs", "writer", "secret", OAuth2Config{}, "", "test")
+	handler := s.Handler()
+
+	get := func(target string) string {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		req.SetBasicAuth("writer", "secret")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Body.String()
+	}
+	acknowledge := func(page string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/acknowledge", strings.NewReader(url.Values{"path": {page}}.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.SetBasicAuth("writer", "secret")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if body := get("/policy"); !strings.Contains(body, "I have read this") {
+		t.Errorf("expected the acknowledge button, got\n%s", body)
+	}
+	if body := get("/guide"); strings.Contains(body, "I have read this") {
+		t.Error("expected no button on pages without acknowledge: true")
+	}
+	if rec := acknowledge("/guide"); rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for a page without acknowledge: true, got %d", rec.Code)
+	}
+	if body := get("/admin/acknowledgments?path=/policy"); !strings.Contains(body, "<h2>Not Acknowledged (1)</h2>\n        <ul>\n            <li>writer</li>") {
+		t.Errorf("expected writer pending, got\n%s", body)
+	}
+
+	if rec := acknowledge("/policy"); rec.Code != http.StatusSeeOther || rec.Header().Get("Location") != "/policy" {
+		t.Fatalf("expected a redirect back to the page, got %d %q", rec.Code, rec.Header().Get("Location"))
+	}
+	if body := get("/policy"); !strings.Contains(body, "You confirmed reading this version on") {
+		t.Errorf("expected the confirmation, got\n%s", body)
+	}
+	if body := get("/admin/acknowledgments"); !strings.Contains(body, `<a href="/admin/acknowledgments?path=%2fpolicy">Travel Policy</a></td><td>1</td>`) {
+		t.Errorf("expected one acknowledgment listed, got\n%s", body)
+	}
+
+	os.WriteFile(policy, []byte("---\ntitle: Travel Policy\nacknowledge: true\n---\n# Travel\n\nBook two weeks early.\n"), 0o644)
+	if body := get("/policy"); !strings.Contains(body, "I have read this") {
+		t.Error("expected an edit to ask for a new acknowledgment")
+	}
+	if body := get("/admin/acknowledgments?path=/policy"); !strings.Contains(body, "Earlier Version Only (1)") || !strings.Contains(body, "Acknowledged (0)") {
+		t.Errorf("expected writer to have acknowledged an earlier version, got\n%s", body)
+	}
+}
@@ -0,0 +1,291 @@
+package server
+
+import (
+	"errors"
+	"io/fs"
+	"log"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"gomdoc/templates"
+)
+
+// revisionsBucket is the database bucket holding the edits proposed for
+// approval, keyed by the siteKey of the revision ID.
+const revisionsBucket = "revisions"
+
+// approvalsPath is the approvals queue; each revision is reviewed below
+// it, at /admin/approvals/<id>.
+const approvalsPath = "/admin/approvals"
+
+// decidedShown is how many approved and rejected revisions the queue
+// lists below the pending ones.
+const decidedShown = 20
+
+// Revision states.
+const (
+	revisionPending  = "pending"
+	revisionApproved = "approved"
+	revisionRejected = "rejected"
+)
+
+// errRevisionDecided is returned when a revision was approved or
+// rejected in the meantime.
+var errRevisionDecided = errors.New("revision already decided")
+
+// revision is an edit of a page waiting for, or decided by, a reviewer.
+// Original is the page source the edit started from and Base its hash;
+// the edit is only published while the page still has that source.
+type revision struct {
+	ID       string    `json:"id"`
+	Path     string    `json:"path"`
+	File     string    `json:"file"`
+	Author   string    `json:"author"`
+	Summary  string    `json:"summary,omitempty"`
+	Base     string    `json:"base"`
+	Original string    `json:"original"`
+	Source   string    `json:"source"`
+	Created  time.Time `json:"created"`
+	Status   string    `json:"status"`
+	Reviewer string    `json:"reviewer,omitempty"`
+	Decided  time.Time `json:"decided,omitzero"`
+}
+
+// approvalsEnabled reports whether edits from the page editor wait for a
+// reviewer.
+func (s *Server) approvalsEnabled() bool {
+	return s.options.Approvals && s.uploadsEnabled()
+}
+
+// isReviewer reports whether the user signed in with r may approve and
+// reject edits: a listed reviewer, or an admin when roles are on. OAuth2
+// addresses are compared case-insensitively.
+func (s *Server) isReviewer(r *http.Request) bool {
+	user := s.currentUser(r)
+	if user == "" {
+		return false
+	}
+	if s.rolesEnabled() && s.role(r) >= RoleAdmin {
+		return true
+	}
+	return slices.ContainsFunc(s.options.Reviewers, func(reviewer string) bool {
+		return strings.EqualFold(reviewer, user)
+	})
+}
+
+// proposeRevision stores the edit in data as a pending revision of file.
+func (s *Server) proposeRevision(user, file string, data templates.EditData, original string) (revision, error) {
+	id, err := randomHex(8)
+	if err != nil {
+		return revision{}, err
+	}
+	rev := revision{
+		ID:       id,
+		Path:     data.Path,
+		File:     file,
+		Author:   user,
+		Summary:  data.Summary,
+		Base:     data.Base,
+		Original: original,
+		Source:   data.Source,
+		Created:  time.Now().UTC(),
+		Status:   revisionPending,
+	}
+	return rev, s.db.Put(revisionsBucket, s.siteKey(id), rev)
+}
+
+// revisions returns the stored revisions, oldest first.
+func (s *Server) revisions() []revision {
+	var list []revision
+	for _, id := range s.siteKeys(revisionsBucket) {
+		var rev revision
+		if found, err := s.db.Get(revisionsBucket, s.siteKey(id), &rev); err != nil || !found {
+			continue
+		}
+		list = append(list, rev)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Created.Before(list[j].Created) })
+	return list
+}
+
+// revisionView returns the revision as shown in the approval pages.
+func (s *Server) revisionView(rev revision) templates.Revision {
+	return templates.Revision{
+		ID:       rev.ID,
+		Path:     rev.Path,
+		Title:    s.pageTitle(rev.Path),
+		Author:   rev.Author,
+		Summary:  rev.Summary,
+		Created:  revisionTime(rev.Created),
+		Status:   rev.Status,
+		Reviewer: rev.Reviewer,
+		Decided:  revisionTime(rev.Decided),
+	}
+}
+
+// revisionTime formats a revision date, empty when unset.
+func revisionTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(acknowledgmentDateFormat)
+}
+
+// handleApprovals renders the approvals queue: the pending revisions,
+// oldest first, and the latest decided ones.
+func (s *Server) handleApprovals(w http.ResponseWriter, r *http.Request) {
+	data := templates.ApprovalsData{
+		SiteLayout: s.layout(),
+		Reviewer:   s.isReviewer(r),
+	}
+	var decided []revision
+	for _, rev := range s.revisions() {
+		if rev.Status == revisionPending {
+			data.Pending = append(data.Pending, s.revisionView(rev))
+		} else {
+			decided = append(decided, rev)
+		}
+	}
+	sort.Slice(decided, func(i, j int) bool { return decided[i].Decided.After(decided[j].Decided) })
+	for _, rev := range decided[:min(len(decided), decidedShown)] {
+		data.Decided = append(data.Decided, s.revisionView(rev))
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	if err := templates.RenderApprovals(w, data); err != nil {
+		log.Printf("Error rendering approvals queue: %v", err)
+	}
+}
+
+// handleRevision shows a revision with its diff. Reviewers approve or
+// reject it with a POST of the action field; approving publishes the
+// edit.
+func (s *Server) handleRevision(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimPrefix(r.URL.Path, approvalsPath+"/")
+	var rev revision
+	if found, err := s.db.Get(revisionsBucket, s.siteKey(id), &rev); err != nil || !found {
+		http.NotFound(w, r)
+		return
+	}
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+	case http.MethodPost:
+		s.decideRevision(w, r, rev)
+		return
+	default:
+		methodNotAllowed(w, editMethods...)
+		return
+	}
+
+	user := s.currentUser(r)
+	data := templates.RevisionData{
+		SiteLayout: s.layout(),
+		Revision:   s.revisionView(rev),
+	}
+	var err error
+	if data.Diff, err = revisionDiff(rev); err != nil {
+		data.DiffError = err.Error()
+	}
+	if rev.Status == revisionPending {
+		data.Outdated = !s.revisionCurrent(rev)
+		data.CanReview = s.isReviewer(r) && !strings.EqualFold(user, rev.Author)
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	if err := templates.RenderRevision(w, data); err != nil {
+		log.Printf("Error rendering revision: %v", err)
+	}
+}
+
+// revisionDiff returns the changes of a revision to the page it started
+// from, with the unchanged lines around them, or errDiffTooLarge.
+func revisionDiff(rev revision) ([]templates.DiffLine, error) {
+	lines, err := diffLines(splitLines(rev.Original), splitLines(rev.Source))
+	if err != nil {
+		return nil, err
+	}
+	return templateDiff(diffHunks(lines)), nil
+}
+
+// splitLines splits source into lines without their line endings.
+func splitLines(source string) []string {
+	source = strings.TrimSuffix(strings.ReplaceAll(source, "\r\n", "\n"), "\n")
+	if source == "" {
+		return nil
+	}
+	return strings.Split(source, "\n")
+}
+
+// revisionCurrent reports whether the page still has the source the
+// revision started from.
+func (s *Server) revisionCurrent(rev revision) bool {
+	current, err := fs.ReadFile(s.fsys(), rev.File)
+	return err == nil && sourceHash(current) == rev.Base
+}
+
+// decideRevision approves or rejects a pending revision for a reviewer
+// other than its author. Approving fails when the page changed since the
+// revision was written. Cross-origin posts are refused.
+func (s *Server) decideRevision(w http.ResponseWriter, r *http.Request, rev revision) {
+	if err := http.NewCrossOriginProtection().Check(r); err != nil {
+		http.Error(w, "Cross-origin request refused", http.StatusForbidden)
+		return
+	}
+	user := s.currentUser(r)
+	if !s.isReviewer(r) {
+		http.Error(w, "Only reviewers can approve or reject edits", http.StatusForbidden)
+		return
+	}
+	if strings.EqualFold(user, rev.Author) {
+		http.Error(w, "Reviewers cannot approve or reject their own edits", http.StatusForbidden)
+		return
+	}
+	status, action := revisionApproved, auditApprove
+	switch r.PostFormValue("action") {
+	case "approve":
+		if !s.revisionCurrent(rev) {
+			http.Error(w, "The page changed since this edit was made; it can only be rejected", http.StatusConflict)
+			return
+		}
+	case "reject":
+		status, action = revisionRejected, auditReject
+	default:
+		http.Error(w, "Invalid action: use approve or reject", http.StatusBadRequest)
+		return
+	}
+
+	// The revision is claimed before the page is written, so that two
+	// reviewers deciding at once cannot both publish it.
+	err := s.db.Update(revisionsBucket, s.siteKey(rev.ID), &rev, func() error {
+		if rev.Status != revisionPending {
+			return errRevisionDecided
+		}
+		rev.Status, rev.Reviewer, rev.Decided = status, user, time.Now().UTC()
+		return nil
+	})
+	if errors.Is(err, errRevisionDecided) {
+		http.Error(w, "This edit was already "+rev.Status, http.StatusConflict)
+		return
+	}
+	if err != nil {
+		log.Printf("Error storing revision %s: %v", rev.ID, err)
+		http.Error(w, "Failed to store the decision", http.StatusInternalServerError)
+		return
+	}
+	if status == revisionApproved {
+		if err := s.writePage(rev.File, rev.Source); err != nil {
+			log.Printf("Error publishing revision %s of %s: %v", rev.ID, rev.File, err)
+			rev.Status, rev.Reviewer, rev.Decided = revisionPending, "", time.Time{}
+			s.db.Put(revisionsBucket, s.siteKey(rev.ID), rev)
+			http.Error(w, "Failed to publish the edit", http.StatusInternalServerError)
+			return
+		}
+		s.contentChanged()
+	}
+	s.audit(r, auditEvent{Action: action, Path: rev.Path, Target: rev.ID})
+	http.Redirect(w, r, approvalsPath+"/"+rev.ID, http.StatusSeeOther)
+}
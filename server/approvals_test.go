@@ -0,0 +1,197 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEditPage(t *testing.T) {
+	dir := t.TempDir()
+	page := filepath.Join(dir, "guide.md")
+	os.WriteFile(page, []byte("# Guide\n\nOld text.\n"), 0o644)
+	s := NewWithAuth(dir, 0, "Docs", "writer", "secret", OAuth2Config{}, "", "test")
+	s.Configure(Options{Edit: true})
+	handler := s.Handler()
+
+	req := httptest.NewRequest(http.MethodGet, "/guide", nil)
+	req.SetBasicAuth("writer", "secret")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if !strings.Contains(rec.Body.String(), `<a href="/edit/guide">`) {
+		t.Errorf("expected an Edit button, got\n%s", rec.Body.String())
+	}
+
+	save := func(source, base string) *httptest.ResponseRecorder {
+		form := url.Values{"source": {source}, "base": {base}}
+		req := httptest.NewRequest(http.MethodPost, "/edit/guide", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.SetBasicAuth("writer", "secret")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+	base := sourceHash([]byte("# Guide\n\nOld text.\n"))
+	if rec := save("# Guide\r\n\r\nNew text.\r\n", base); rec.Code != http.StatusSeeOther || rec.Header().Get("Location") != "/guide" {
+		t.Fatalf("expected a redirect to the page, got %d %q", rec.Code, rec.Header().Get("Location"))
+	}
+	if data, _ := os.ReadFile(page); string(data) != "# Guide\n\nNew text.\n" {
+		t.Errorf("expected the page saved with LF line endings, got %q", data)
+	}
+	if rec := save("# Guide\n\nStale text.\n", base); rec.Code != http.StatusConflict {
+		t.Errorf("expected 409 for an edit of an older version, got %d", rec.Code)
+	}
+}
+
+func TestApprovals(t *testing.T) {
+	dir := t.TempDir()
+	page := filepath.Join(dir, "guide.md")
+	original := "# Guide\n\nOld text.\n"
+	os.WriteFile(page, []byte(original), 0o644)
+	s := NewWithAuth(dir, 0, "Docs", "", "", validOAuth2Config(), "", "test")
+	s.Configure(Options{Edit: true, Approvals: true, Reviewers: []string{"Reviewer@example.org"}})
+	handler := s.Handler()
+
+	session := func(email string) *http.Cookie {
+		rec := httptest.NewRecorder()
+		s.writeSignedCookie(rec, oauth2SessionCookie, oauth2Session{Email: email, Expires: time.Now().Add(time.Hour).Unix()}, time.Hour)
+		return rec.Result().Cookies()[0]
+	}
+	author, reviewer := session("user@example.com"), session("reviewer@example.org")
+	serve := func(method, target string, cookie *http.Cookie, form url.Values) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.AddCookie(cookie)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := serve(http.MethodPost, "/edit/guide", author, url.Values{
+		"source":  {"# Guide\n\nNew text.\n"},
+		"base":    {sourceHash([]byte(original))},
+		"summary": {"Reword"},
+	})
+	if rec.Code != http.StatusSeeOther || !strings.HasPrefix(rec.Header().Get("Location"), approvalsPath+"/") {
+		t.Fatalf("expected a redirect to the revision, got %d %q", rec.Code, rec.Header().Get("Location"))
+	}
+	revisionURL := rec.Header().Get("Location")
+	if data, _ := os.ReadFile(page); string(data) != original {
+		t.Errorf("expected the page unchanged before approval, got %q", data)
+	}
+
+	if body := serve(http.MethodGet, approvalsPath, author, nil).Body.String(); !strings.Contains(body, "Pending (1)") || !strings.Contains(body, "Reword") {
+		t.Errorf("expected the edit in the queue, got\n%s", body)
+	}
+	body := serve(http.MethodGet, revisionURL, reviewer, nil).Body.String()
+	for _, want := range []string{
+		`<tr class="diff-delete"><td class="diff-num">3</td><td class="diff-num"></td><td class="diff-sign">-</td><td class="diff-text">Old text.</td></tr>`,
+		`<tr class="diff-add"><td class="diff-num"></td><td class="diff-num">3</td><td class="diff-sign">+</td><td class="diff-text">New text.</td></tr>`,
+		`value="approve"`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected %s in the revision, got\n%s", want, body)
+		}
+	}
+
+	approve := url.Values{"action": {"approve"}}
+	if rec := serve(http.MethodPost, revisionURL, author, approve); rec.Code != http.StatusForbidden {
+		t.Errorf("expected 403 for a user who is not a reviewer, got %d", rec.Code)
+	}
+	if rec := serve(http.MethodPost, revisionURL, reviewer, approve); rec.Code != http.StatusSeeOther {
+		t.Fatalf("expected the approval to redirect, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if data, _ := os.ReadFile(page); string(data) != "# Guide\n\nNew text.\n" {
+		t.Errorf("expected the edit published, got %q", data)
+	}
+	if rec := serve(http.MethodPost, revisionURL, reviewer, approve); rec.Code != http.StatusConflict {
+		t.Errorf("expected 409 for a decided revision, got %d", rec.Code)
+	}
+	if body := serve(http.MethodGet, approvalsPath, reviewer, nil).Body.String(); !strings.Contains(body, "Pending (0)") || !strings.Contains(body, "<td>approved</td><td>reviewer@example.org</td>") {
+		t.Errorf("expected the edit decided, got\n%s", body)
+	}
+}
+
+func TestApprovalOfOutdatedRevision(t *testing.T) {
+	dir := t.TempDir()
+	page := filepath.Join(dir, "guide.md")
+	os.WriteFile(page, []byte("# Guide\n"), 0o644)
+	s := NewWithAuth(dir, 0, "Docs", "", "", validOAuth2Config(), "", "test")
+	s.Configure(Options{Edit: true, Approvals: true, Reviewers: []string{"reviewer@example.org"}})
+	s.db.Put(revisionsBucket, "abc", revision{
+		ID: "abc", Path: "/guide", File: "guide.md", Author: "user@example.com",
+		Base: sourceHash([]byte("# Old guide\n")), Original: "# Old guide\n", Source: "# New guide\n", Status: revisionPending,
+	})
+
+	rec := httptest.NewRecorder()
+	s.writeSignedCookie(rec, oauth2SessionCookie, oauth2Session{Email: "reviewer@example.org", Expires: time.Now().Add(time.Hour).Unix()}, time.Hour)
+	req := httptest.NewRequest(http.MethodPost, approvalsPath+"/abc", strings.NewReader("action=approve"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.AddCookie(rec.Result().Cookies()[0])
+	rec = httptest.NewRecorder()
+	s.Handler().ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusConflict {
+		t.Errorf("expected 409 for an edit of an older version, got %d", rec.Code)
+	}
+	if data, _ := os.ReadFile(page); string(data) != "# Guide\n" {
+		t.Errorf("expected the page unchanged, got %q", data)
+	}
+}
+
+func TestDiffLines(t *testing.T) {
+	lines, err := diffLines([]string{"a", "b", "c", "d"}, []string{"a", "c", "x", "d"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, line := range lines {
+		got = append(got, line.kind+" "+line.text)
+	}
+	want := "same a|delete b|same c|add x|same d"
+	if strings.Join(got, "|") != want {
+		t.Errorf("diffLines = %s, want %s", strings.Join(got, "|"), want)
+	}
+
+	lines, _ = diffLines(strings.Split("1 2 3 4 5 6 7 8 9 10", " "), strings.Split("1 2 3 4 5 6 7 8 9 ten", " "))
+	hunks := diffHunks(lines)
+	if len(hunks) != 6 || hunks[0].kind != diffSkip || hunks[1].text != "7" {
+		t.Errorf("expected a skip before three lines of context, got %+v", hunks)
+	}
+
+	numbered := func(prefix string, n int) []string {
+		var lines []string
+		for i := range n {
+			lines = append(lines, fmt.Sprintf("%s%d", prefix, i))
+		}
+		return lines
+	}
+	old, current := numbered("a", maxDiffEdits/2), numbered("b", maxDiffEdits/2)
+	lines, err = diffLines(old, current)
+	if err != nil {
+		t.Fatalf("expected a diff of %d changes, got %v", maxDiffEdits, err)
+	}
+	var rebuilt []string
+	for _, line := range lines {
+		if line.kind != diffDelete {
+			rebuilt = append(rebuilt, line.text)
+		}
+	}
+	if !slices.Equal(rebuilt, current) {
+		t.Errorf("expected the diff to rebuild the new text")
+	}
+	if _, err := diffLines(old, numbered("b", maxDiffEdits/2+1)); !errors.Is(err, errDiffTooLarge) {
+		t.Errorf("expected errDiffTooLarge beyond %d changes, got %v", maxDiffEdits, err)
+	}
+	if _, err := diffLines(numbered("a", maxDiffLines+1), nil); !errors.Is(err, errDiffTooLarge) {
+		t.Errorf("expected errDiffTooLarge beyond %d lines, got %v", maxDiffLines, err)
+	}
+}
@@ -0,0 +1,113 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"gomdoc/search"
+	"gomdoc/semantic"
+	"gomdoc/templates"
+)
+
+// askTimeout limits embedding all passages of the docs.
+const askTimeout = 10 * time.Minute
+
+// askPassages is the number of passages the ask page shows, and the
+// default for /api/ask.
+const askPassages = 5
+
+// maxAskPassages caps the limit parameter of /api/ask.
+const maxAskPassages = 50
+
+// buildAskIndex embeds the passages of the published pages for /ask,
+// without drafts, since answers are shown to every reader.
+func (s *Server) buildAskIndex(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, askTimeout)
+	defer cancel()
+	if err := s.ask.Build(ctx, s.index.WithoutDrafts().Chunks(search.DefaultChunkSize)); err != nil {
+		return fmt.Errorf("building semantic index: %w", err)
+	}
+	return nil
+}
+
+// askResponse is the body of an /api/ask response.
+type askResponse struct {
+	Question string             `json:"question"`
+	Passages []semantic.Passage `json:"passages"`
+}
+
+// handleAsk responds with the passages closest in meaning to the question
+// in the q parameter, best first. The limit parameter sets how many.
+func (s *Server) handleAsk(w http.ResponseWriter, r *http.Request) {
+	question := strings.TrimSpace(r.URL.Query().Get("q"))
+	if question == "" {
+		http.Error(w, "q is required", http.StatusBadRequest)
+		return
+	}
+	limit := askPassages
+	if value := r.URL.Query().Get("limit"); value != "" {
+		var err error
+		if limit, err = strconv.Atoi(value); err != nil || limit < 1 || limit > maxAskPassages {
+			http.Error(w, "limit must be a number from 1 to "+strconv.Itoa(maxAskPassages), http.StatusBadRequest)
+			return
+		}
+	}
+	passages, err := s.ask.Ask(r.Context(), question, limit)
+	if err != nil {
+		http.Error(w, err.Error(), askStatus(err))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(askResponse{Question: question, Passages: passages})
+}
+
+// handleAskPage serves the ask page, with the passages closest in meaning
+// to the question in the q parameter.
+func (s *Server) handleAskPage(w http.ResponseWriter, r *http.Request) {
+	data := templates.AskData{
+		SiteLayout: s.layout(),
+		Query:      r.URL.Query().Get("q"),
+	}
+	if question := strings.TrimSpace(data.Query); question != "" {
+		data.Asked = true
+		passages, err := s.ask.Ask(r.Context(), question, askPassages)
+		if err != nil {
+			if s.options.Privacy {
+				log.Printf("Error answering a question: %v", err)
+			} else {
+				log.Printf("Error answering %q: %v", question, err)
+			}
+			data.Error = "The question could not be answered. Try again later."
+			if errors.Is(err, semantic.ErrNotReady) {
+				data.Error = "The docs are still being indexed. Try again in a moment."
+			}
+		}
+		for _, passage := range passages {
+			data.Passages = append(data.Passages, templates.AskPassage{
+				Title:   passage.Title,
+				Path:    passage.Path,
+				Section: strings.Join(passage.Headings, " › "),
+				Text:    passage.Text,
+			})
+		}
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := templates.RenderAsk(w, data); err != nil {
+		log.Printf("Error rendering ask page: %v", err)
+	}
+}
+
+// askStatus returns the HTTP status for an error answering a question.
+func askStatus(err error) int {
+	if errors.Is(err, semantic.ErrNotReady) {
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusBadGateway
+}
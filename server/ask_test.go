@@ -0,0 +1,93 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"gomdoc/semantic"
+)
+
+// newKeywordEmbedder serves OpenAI-compatible embeddings with one
+// dimension per word of vocabulary, counting its occurrences.
+func newKeywordEmbedder(t *testing.T, vocabulary ...string) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Input []string `json:"input"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		type item struct {
+			Index     int       `json:"index"`
+			Embedding []float32 `json:"embedding"`
+		}
+		var data []item
+		for i, text := range req.Input {
+			vector := []float32{0.1}
+			for _, word := range vocabulary {
+				vector = append(vector, float32(strings.Count(strings.ToLower(text), word)))
+			}
+			data = append(data, item{Index: i, Embedding: vector})
+		}
+		json.NewEncoder(w).Encode(map[string]any{"data": data})
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestAsk(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "install.md"), []byte("# Install\n\nDownload the installer and install it.\n"), 0o644)
+	os.WriteFile(filepath.Join(dir, "backup.md"), []byte("# Backups\n\nRestore a backup with the restore command.\n"), 0o644)
+	embedder := newKeywordEmbedder(t, "install", "backup", "restore")
+	s := NewWithAuth(dir, 0, "Docs", "", "", OAuth2Config{}, "", "test")
+	s.Configure(Options{Ask: semantic.Options{Endpoint: embedder.URL}})
+	handler := s.Handler()
+
+	var rec *httptest.ResponseRecorder
+	for deadline := time.Now().Add(5 * time.Second); ; time.Sleep(10 * time.Millisecond) {
+		rec = httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/ask?q=how+to+restore+a+backup&limit=1", nil))
+		if rec.Code != http.StatusServiceUnavailable || time.Now().After(deadline) {
+			break
+		}
+	}
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var resp struct {
+		Passages []semantic.Passage `json:"passages"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Passages) != 1 || resp.Passages[0].Path != "/backup" {
+		t.Errorf("expected the backup page, got %+v", resp.Passages)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/ask?q=install", nil))
+	if body := rec.Body.String(); !strings.Contains(body, `href="/install"`) || !strings.Contains(body, "Download the installer") {
+		t.Errorf("ask page lacks the install passage:\n%s", body)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/ask", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 without a question, got %d", rec.Code)
+	}
+}
+
+func TestAskDisabled(t *testing.T) {
+	handler := NewWithAuth(t.TempDir(), 0, "Docs", "", "", OAuth2Config{}, "", "test").Handler()
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/ask?q=x", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404 without -ask-endpoint, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,134 @@
+package server
+
+import (
+	"fmt"
+	"html/template"
+	"io/fs"
+	"log"
+	"mime"
+	"net/http"
+	"path"
+	"slices"
+	"strings"
+
+	"gomdoc/scanner"
+	"gomdoc/templates"
+)
+
+// treeEntries lists the markdown files and, when Options.Attachments or
+// Options.CodeFiles is set, the files shown beside them in the navigation
+// tree. Files with a preview or code page link to it.
+func (s *Server) treeEntries() ([]scanner.FileEntry, error) {
+	entries, err := s.scanEntries()
+	types := s.fileTypes()
+	if err != nil || len(types) == 0 {
+		return entries, err
+	}
+	files, err := scanner.ScanAttachments(s.fsys(), types)
+	if err != nil {
+		return nil, err
+	}
+	for i, file := range files {
+		if s.previewable(file.URLPath) || s.codeFile(file.URLPath) {
+			files[i].Preview = file.URLPath + previewSuffix
+		}
+	}
+	return append(entries, files...), nil
+}
+
+// fileTypes returns the extensions of every non-markdown file served from
+// the documentation tree: attachments and code files.
+func (s *Server) fileTypes() []string {
+	types := slices.Clone(s.options.Attachments)
+	for _, ext := range s.options.CodeFiles {
+		if !slices.Contains(types, ext) {
+			types = append(types, ext)
+		}
+	}
+	return types
+}
+
+// serveFile serves a non-markdown file: an upload, stylesheet, or script in
+// the assets folder, media embedded in pages, an attachment of an allowed
+// type, or a code file as plain text. It reports
+// false when urlPath names none of them, so other handlers can take over.
+func (s *Server) serveFile(w http.ResponseWriter, r *http.Request, urlPath string) bool {
+	upload := strings.HasPrefix(urlPath, uploadFolder+"/") && uploadTypes[strings.ToLower(path.Ext(urlPath))]
+	attachment := scanner.IsAttachmentType(urlPath, s.options.Attachments)
+	pageAsset := s.isPageAsset(urlPath)
+	media := mediaType(urlPath)
+	if !upload && !attachment && !pageAsset && media == "" && !s.codeFile(urlPath) {
+		return false
+	}
+	if strings.HasPrefix(urlPath, ".") || strings.Contains(urlPath, "/.") {
+		return false // dot files and directories are never served
+	}
+	info, err := fs.Stat(s.fsys(), urlPath)
+	if err != nil || !info.Mode().IsRegular() {
+		return false
+	}
+	if media != "" && !s.mediaAllowed(info.Size()) {
+		log.Printf("Warning: %s exceeds the media size limit of %s", urlPath, scanner.FormatSize(s.options.MaxMediaBytes))
+		http.Error(w, "Media file too large", http.StatusRequestEntityTooLarge)
+		return true
+	}
+	code := !upload && !attachment && !pageAsset && media == ""
+	switch {
+	case media != "":
+		w.Header().Set("Content-Type", media)
+	case code:
+		// Code files are shown, never run: an .html or .js file listed as
+		// code must not execute in the site's origin.
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	}
+	s.setFileType(w, urlPath, code)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	if attachment && s.options.SafeMode && isSVG(urlPath) {
+		s.serveSVG(w, r, urlPath, info)
+		return true
+	}
+	http.ServeFileFS(w, r, s.fsys(), urlPath)
+	return true
+}
+
+// setFileType sets the Content-Type and Content-Disposition configured
+// for the extension of urlPath in Options.FileTypes. Code files keep
+// their plain text type.
+func (s *Server) setFileType(w http.ResponseWriter, urlPath string, code bool) {
+	fileType, ok := s.options.FileTypes[strings.ToLower(path.Ext(urlPath))]
+	if !ok {
+		return
+	}
+	if fileType.ContentType != "" && !code {
+		w.Header().Set("Content-Type", fileType.ContentType)
+	}
+	if fileType.Disposition != "" {
+		w.Header().Set("Content-Disposition", mime.FormatMediaType(fileType.Disposition, map[string]string{"filename": path.Base(urlPath)}))
+	}
+}
+
+// renderFilePage renders a page for the file at name inside the site
+// chrome: its name, a link to the file itself labeled action, its size,
+// and body.
+func (s *Server) renderFilePage(w http.ResponseWriter, r *http.Request, name string, info fs.FileInfo, action, body string) {
+	var sb strings.Builder
+	fileName := path.Base(name)
+	fmt.Fprintf(&sb, "<h1>%s</h1>\n", template.HTMLEscapeString(fileName))
+	fmt.Fprintf(&sb, "<p class=\"file-actions\"><a href=\"/%s\" class=\"nav-btn\" download>%s %s</a> <span class=\"attachment-size\">%s</span></p>\n",
+		template.HTMLEscapeString(name), action, template.HTMLEscapeString(fileName), scanner.FormatSize(info.Size()))
+	sb.WriteString(body)
+
+	entries, _ := s.visibleEntries(r)
+	data := templates.PageData{
+		SiteLayout:  s.layout(),
+		Title:       fileName,
+		Content:     template.HTML(sb.String()),
+		Path:        "/" + name,
+		Breadcrumbs: buildBreadcrumbs("/" + name),
+		TreeHTML:    s.renderTree(s.buildTree(entries), r.URL.Path),
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := traceTemplate(r, "page", func() error { return templates.RenderPage(w, data) }); err != nil {
+		log.Printf("Error rendering page for %s: %v", name, err)
+	}
+}
@@ -0,0 +1,95 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gomdoc/config"
+)
+
+func TestAttachments(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "manuals"), 0o755)
+	os.WriteFile(filepath.Join(dir, "index.md"), []byte("# Home\n"), 0o644)
+	os.WriteFile(filepath.Join(dir, "manuals", "setup.pdf"), []byte("%PDF-1.4"), 0o644)
+	os.WriteFile(filepath.Join(dir, "manuals", "tool.exe"), []byte("MZ"), 0o644)
+
+	get := func(handler http.Handler, target string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		return rec
+	}
+
+	off := NewWithAuth(dir, 0, "Docs", "", "", OAuth2Config{}, "", "test").Handler()
+	if rec := get(off, "/manuals/setup.pdf"); rec.Code != http.StatusNotFound {
+		t.Errorf("expected attachments unreachable by default, got %d", rec.Code)
+	}
+
+	s := NewWithAuth(dir, 0, "Docs", "", "", OAuth2Config{}, "", "test")
+	s.Configure(Options{Attachments: []string{"pdf"}})
+	handler := s.Handler()
+
+	rec := get(handler, "/manuals/setup.pdf")
+	if rec.Code != http.StatusOK || rec.Body.String() != "%PDF-1.4" || rec.Header().Get("Content-Type") != "application/pdf" {
+		t.Errorf("expected PDF served, got %d %q", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	if rec := get(handler, "/manuals/tool.exe"); rec.Code != http.StatusNotFound {
+		t.Errorf("expected types outside the allowlist unreachable, got %d", rec.Code)
+	}
+	body := get(handler, "/browse").Body.String()
+	if !strings.Contains(body, `href="/manuals/setup.pdf" class="file attachment" data-type="pdf" download`) {
+		t.Errorf("expected attachment in the tree, got:\n%s", body)
+	}
+	if strings.Contains(body, "tool.exe") {
+		t.Errorf("expected types outside the allowlist left out of the tree")
+	}
+	if body := get(handler, "/manuals").Body.String(); !strings.Contains(body, `href="/manuals/setup.pdf"`) {
+		t.Errorf("expected attachment in the directory listing, got:\n%s", body)
+	}
+}
+
+func TestFileTypes(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "index.md"), []byte("# Home\n"), 0o644)
+	os.WriteFile(filepath.Join(dir, "flow.drawio"), []byte("<mxfile/>"), 0o644)
+	os.WriteFile(filepath.Join(dir, "session.har"), []byte("{}"), 0o644)
+	os.WriteFile(filepath.Join(dir, "guide.pdf"), []byte("%PDF-1.4"), 0o644)
+	os.WriteFile(filepath.Join(dir, "page.html"), []byte("<script>alert(1)</script>"), 0o644)
+
+	s := NewWithAuth(dir, 0, "Docs", "", "", OAuth2Config{}, "", "test")
+	s.Configure(Options{
+		Attachments: []string{"drawio", "har", "pdf"},
+		CodeFiles:   []string{"html"},
+		FileTypes: map[string]config.FileType{
+			".drawio": {ContentType: "application/vnd.jgraph.mxfile", Disposition: "attachment"},
+			".har":    {Disposition: "attachment"},
+			".pdf":    {Disposition: "inline"},
+			".html":   {ContentType: "text/html", Disposition: "inline"},
+		},
+	})
+	handler := s.Handler()
+
+	for target, want := range map[string][2]string{
+		"/flow.drawio": {"application/vnd.jgraph.mxfile", `attachment; filename=flow.drawio`},
+		"/session.har": {"", `attachment; filename=session.har`},
+		"/guide.pdf":   {"application/pdf", "inline; filename=guide.pdf"},
+		"/page.html":   {"text/plain; charset=utf-8", "inline; filename=page.html"},
+	} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		if rec.Code != http.StatusOK {
+			t.Errorf("%s: expected 200, got %d", target, rec.Code)
+			continue
+		}
+		if want[0] != "" && rec.Header().Get("Content-Type") != want[0] {
+			t.Errorf("%s: expected Content-Type %q, got %q", target, want[0], rec.Header().Get("Content-Type"))
+		}
+		if got := rec.Header().Get("Content-Disposition"); got != want[1] {
+			t.Errorf("%s: expected Content-Disposition %q, got %q", target, want[1], got)
+		}
+	}
+}
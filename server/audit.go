@@ -0,0 +1,171 @@
+package server
+
+import (
+	"encoding/json"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+)
+
+// Audit actions written to Options.AuditLog.
+const (
+	auditLogin       = "login"
+	auditLoginFailed = "login_failed"
+	auditLogout      = "logout"
+	auditView        = "view"
+	auditUpload      = "upload"
+	auditEdit        = "edit"
+	auditDelete      = "delete"
+	auditCopy        = "copy"
+	auditMove        = "move"
+	auditMkdir       = "mkdir"
+	auditAdmin       = "admin"
+	auditShare       = "share"
+	auditAcknowledge = "acknowledge"
+	auditPropose     = "propose"
+	auditApprove     = "approve"
+	auditReject      = "reject"
+)
+
+// davAuditActions names the audit action of each WebDAV write method.
+var davAuditActions = map[string]string{
+	http.MethodPut:    auditEdit,
+	http.MethodDelete: auditDelete,
+	"COPY":            auditCopy,
+	"MOVE":            auditMove,
+	"MKCOL":           auditMkdir,
+}
+
+// auditEvent is one line of the audit log.
+type auditEvent struct {
+	Time   time.Time `json:"time"`
+	User   string    `json:"user,omitempty"`
+	Action string    `json:"action"`
+	Path   string    `json:"path,omitempty"`
+	// Target is the destination of a copy or move.
+	Target string `json:"target,omitempty"`
+	Remote string `json:"remote,omitempty"`
+}
+
+// auditLog appends events to a file as JSON lines. The file is opened for
+// every event, so that it can be rotated or shipped while gomdoc runs.
+type auditLog struct {
+	mu   sync.Mutex
+	path string
+	// sessions holds the users and addresses whose basic auth login was
+	// recorded, since browsers send the credentials with every request.
+	sessions map[string]bool
+}
+
+// newAuditLog returns an audit log appending to the file at path.
+func newAuditLog(path string) *auditLog {
+	return &auditLog{path: path, sessions: make(map[string]bool)}
+}
+
+// write appends event to the log.
+func (a *auditLog) write(event auditEvent) {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	f, err := os.OpenFile(a.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		log.Printf("Error writing audit log: %v", err)
+		return
+	}
+	defer f.Close()
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		log.Printf("Error writing audit log: %v", err)
+	}
+}
+
+// firstLogin reports whether user signed in from remote for the first
+// time since the start, remembering it.
+func (a *auditLog) firstLogin(user, remote string) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	key := user + "\x00" + remote
+	if a.sessions[key] {
+		return false
+	}
+	a.sessions[key] = true
+	return true
+}
+
+// audit records an action of the user signed in on r, when the audit log
+// is enabled. An empty user in event is filled in from r.
+func (s *Server) audit(r *http.Request, event auditEvent) {
+	if s.auditLog == nil {
+		return
+	}
+	event.Time = time.Now().UTC()
+	if event.User == "" {
+		event.User = s.currentUser(r)
+	}
+	event.Remote = s.clientAddress(r)
+	s.auditLog.write(event)
+}
+
+// auditBasicLogin records the first request of a basic auth user from an
+// address as a login, and wrong credentials as a failed login.
+func (s *Server) auditBasicLogin(r *http.Request, user string, ok bool) {
+	if s.auditLog == nil {
+		return
+	}
+	if !ok {
+		s.audit(r, auditEvent{User: user, Action: auditLoginFailed})
+	} else if s.auditLog.firstLogin(user, s.clientAddress(r)) {
+		s.audit(r, auditEvent{User: user, Action: auditLogin})
+	}
+}
+
+// auditViews records successful page views, when Options.AuditViews is
+// set outside privacy mode.
+func (s *Server) auditViews(next http.HandlerFunc) http.HandlerFunc {
+	if s.auditLog == nil || !s.options.AuditViews || s.options.Privacy {
+		return next
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next(rec, r)
+		if r.Method == http.MethodGet && rec.status == http.StatusOK {
+			s.audit(r, auditEvent{Action: auditView, Path: r.URL.Path})
+		}
+	}
+}
+
+// auditAdmin records every visit to an admin page.
+func (s *Server) auditAdmin(next http.HandlerFunc) http.HandlerFunc {
+	if s.auditLog == nil {
+		return next
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		s.audit(r, auditEvent{Action: auditAdmin, Path: r.URL.Path})
+		next(w, r)
+	}
+}
+
+// auditDAV records a successful WebDAV write.
+func (s *Server) auditDAV(r *http.Request) {
+	event := auditEvent{Action: davAuditActions[r.Method], Path: r.URL.Path}
+	if destination := r.Header.Get("Destination"); destination != "" {
+		if u, err := url.Parse(destination); err == nil {
+			event.Target = u.Path
+		}
+	}
+	s.audit(r, event)
+}
+
+// remoteHost returns the address of the client of r without the port.
+func remoteHost(r *http.Request) string {
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return host
+	}
+	return r.RemoteAddr
+}
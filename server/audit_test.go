@@ -0,0 +1,64 @@
+package server
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAuditLog(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "intro.md"), []byte("# Intro\n"), 0o644)
+	auditFile := filepath.Join(t.TempDir(), "audit.jsonl")
+	s := NewWithAuth(dir, 0, "Docs", "writer", "secret", OAuth2Config{}, "", "test")
+	s.Configure(Options{Edit: true, AuditLog: auditFile, AuditViews: true})
+	handler := s.Handler()
+
+	serve := func(req *http.Request) {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+	}
+	for _, page := range []string{"/intro", "/intro", "/missing", "/admin"} {
+		req := httptest.NewRequest(http.MethodGet, page, nil)
+		req.SetBasicAuth("writer", "secret")
+		serve(req)
+	}
+	req := httptest.NewRequest(http.MethodGet, "/intro", nil)
+	req.SetBasicAuth("writer", "guess")
+	serve(req)
+	serve(uploadRequest(t, "shot.png", "png data", "/intro"))
+
+	f, err := os.Open(auditFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var got []string
+	lines := bufio.NewScanner(f)
+	for lines.Scan() {
+		var event auditEvent
+		if err := json.Unmarshal(lines.Bytes(), &event); err != nil {
+			t.Fatalf("invalid line %q: %v", lines.Text(), err)
+		}
+		if event.Time.IsZero() || event.Remote != "192.0.2.1" {
+			t.Errorf("expected a time and the client address, got %+v", event)
+		}
+		got = append(got, strings.TrimSpace(event.User+" "+event.Action+" "+event.Path))
+	}
+	want := []string{
+		"writer login",
+		"writer view /intro",
+		"writer view /intro",
+		"writer admin /admin",
+		"writer login_failed",
+		"writer upload /assets/shot.png",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("expected events\n%s\ngot\n%s", strings.Join(want, "\n"), strings.Join(got, "\n"))
+	}
+}
@@ -0,0 +1,117 @@
+package server
+
+import (
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+
+	"gomdoc/config"
+	"gomdoc/search"
+	"gomdoc/templates"
+)
+
+// authorsPath is the route of the author list. Each author's pages are
+// listed below it, at /authors/<slug>.
+const authorsPath = "/authors"
+
+// authorDateFormat is how page dates are shown on author pages.
+const authorDateFormat = "January 2, 2006"
+
+// author is an author with their published pages, newest first.
+type author struct {
+	profile templates.AuthorProfile
+	pages   []search.AuthorPage
+}
+
+// authors returns the authors of the published pages the reader of r may
+// see, sorted by name, with their bios from authors.yml. Names matching the key or name of the
+// same entry in authors.yml are listed as one author under its name.
+func (s *Server) authors(r *http.Request) []author {
+	bios, err := config.LoadAuthors(s.fsys())
+	if err != nil {
+		log.Printf("Warning: %v", err)
+	}
+
+	var list []author
+	bySlug := make(map[string]int)
+	for _, group := range s.indexFor(r).Authors() {
+		profile := templates.AuthorProfile{Name: group.Name}
+		for _, bio := range bios {
+			if strings.EqualFold(group.Name, bio.Key) || strings.EqualFold(group.Name, bio.Name) {
+				profile = templates.AuthorProfile{Name: bio.Name, Bio: bio.Bio, URL: bio.URL, Avatar: bio.Avatar}
+				break
+			}
+		}
+		profile.Slug = templates.Slugify(profile.Name)
+		if profile.Slug == "" {
+			continue
+		}
+		i, found := bySlug[profile.Slug]
+		if !found {
+			bySlug[profile.Slug] = len(list)
+			list = append(list, author{profile: profile, pages: group.Pages})
+			continue
+		}
+		for _, page := range group.Pages {
+			if !containsPage(list[i].pages, page.Path) {
+				list[i].pages = append(list[i].pages, page)
+			}
+		}
+		sort.SliceStable(list[i].pages, func(a, b int) bool {
+			return list[i].pages[a].Date.After(list[i].pages[b].Date)
+		})
+	}
+
+	for i := range list {
+		list[i].profile.Pages = len(list[i].pages)
+	}
+	sort.SliceStable(list, func(i, j int) bool {
+		return strings.ToLower(list[i].profile.Name) < strings.ToLower(list[j].profile.Name)
+	})
+	return list
+}
+
+// containsPage reports whether pages contain the page at pagePath.
+func containsPage(pages []search.AuthorPage, pagePath string) bool {
+	for _, page := range pages {
+		if page.Path == pagePath {
+			return true
+		}
+	}
+	return false
+}
+
+// handleAuthors renders the list of authors at /authors and the pages of
+// one author at /authors/<slug>. Authors come from the author frontmatter
+// of the published pages and, with GitAuthors, the git history.
+func (s *Server) handleAuthors(w http.ResponseWriter, r *http.Request) {
+	slug := strings.Trim(strings.TrimPrefix(r.URL.Path, authorsPath), "/")
+	data := templates.AuthorsData{
+		SiteLayout: s.layout(),
+	}
+	for _, a := range s.authors(r) {
+		data.Authors = append(data.Authors, a.profile)
+		if a.profile.Slug != slug {
+			continue
+		}
+		profile := a.profile
+		data.Author = &profile
+		for _, page := range a.pages {
+			entry := templates.AuthorPage{Title: page.Title, Path: page.Path, Summary: page.Summary}
+			if !page.Date.IsZero() {
+				entry.Date = page.Date.Format(authorDateFormat)
+			}
+			data.Pages = append(data.Pages, entry)
+		}
+	}
+	if slug != "" && data.Author == nil {
+		s.renderNotFound(w, r, http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := templates.RenderAuthors(w, data); err != nil {
+		log.Printf("Error rendering author page: %v", err)
+	}
+}
@@ -0,0 +1,52 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAuthors(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"api.md":      "---\ntitle: API Guide\nauthor: jane\ndate: 2024-02-01\n---\n# API\n",
+		"setup.md":    "---\ntitle: Setup\nauthor: Jane Doe\ndate: 2024-03-01\ndescription: Installing the server.\n---\n# Setup\n",
+		"faq.md":      "---\ntitle: FAQ\nauthor: Max\n---\n# FAQ\n",
+		"authors.yml": "jane:\n  name: Jane Doe\n  bio: Maintains the **API** guides.\n",
+	}
+	for name, content := range files {
+		os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644)
+	}
+	s := NewWithAuth(dir, 0, "Docs", "", "", OAuth2Config{}, "", "test")
+	handler := s.Handler()
+
+	get := func(target string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		return rec
+	}
+
+	body := get("/authors").Body.String()
+	jane := strings.Index(body, `<a href="/authors/jane-doe">Jane Doe</a> <span class="meta-item">2 pages</span>`)
+	max := strings.Index(body, `<a href="/authors/max">Max</a> <span class="meta-item">1 page</span>`)
+	if jane < 0 || max < jane {
+		t.Errorf("expected Jane Doe with both pages, then Max, got\n%s", body)
+	}
+
+	body = get("/authors/jane-doe").Body.String()
+	if !strings.Contains(body, "<strong>API</strong>") {
+		t.Errorf("expected the bio from authors.yml, got\n%s", body)
+	}
+	setup := strings.Index(body, `<a href="/setup">Setup</a> <span class="meta-item">March 1, 2024</span>`)
+	api := strings.Index(body, `<a href="/api">API Guide</a>`)
+	if setup < 0 || api < setup || !strings.Contains(body, "<p>Installing the server.</p>") {
+		t.Errorf("expected the pages newest first with their summaries, got\n%s", body)
+	}
+
+	if rec := get("/authors/nobody"); rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for an unknown author, got %d", rec.Code)
+	}
+}
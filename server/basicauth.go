@@ -0,0 +1,65 @@
+package server
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"fmt"
+	"strings"
+	"sync/atomic"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// isPasswordHash reports whether the -auth password is a bcrypt hash, as
+// written by gomdoc hash-password or htpasswd -B, rather than plain text.
+func isPasswordHash(password string) bool {
+	return strings.HasPrefix(password, "$2a$") || strings.HasPrefix(password, "$2b$") || strings.HasPrefix(password, "$2y$")
+}
+
+// ValidateAuthPassword checks that an -auth password given as a bcrypt
+// hash is a complete one, e.g. not cut short by the shell expanding $.
+// Plain passwords are always valid.
+func ValidateAuthPassword(password string) error {
+	if !isPasswordHash(password) {
+		return nil
+	}
+	if _, err := bcrypt.Cost([]byte(password)); err != nil || len(password) != 60 {
+		return fmt.Errorf("invalid bcrypt hash; on the command line, quote it in single quotes so the shell keeps its $ signs")
+	}
+	return nil
+}
+
+// HashPassword returns the bcrypt hash of password to use in -auth
+// instead of the password itself.
+func HashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	return string(hash), err
+}
+
+// verifiedPassword remembers the SHA-256 of the last password that
+// matched the bcrypt hash of -auth, so that only the first request of a
+// session pays for bcrypt.
+type verifiedPassword struct {
+	sum atomic.Pointer[[sha256.Size]byte]
+}
+
+// validBasicAuth reports whether user and password match the -auth
+// credentials, comparing in constant time. When the configured password
+// is a bcrypt hash, the password is checked against it.
+func (s *Server) validBasicAuth(user, password string) bool {
+	userOK := subtle.ConstantTimeCompare([]byte(user), []byte(s.authUser)) == 1
+	if !isPasswordHash(s.authPass) {
+		passOK := subtle.ConstantTimeCompare([]byte(password), []byte(s.authPass)) == 1
+		return userOK && passOK
+	}
+
+	sum := sha256.Sum256([]byte(password))
+	if verified := s.verified.sum.Load(); verified != nil && subtle.ConstantTimeCompare(sum[:], verified[:]) == 1 {
+		return userOK
+	}
+	if bcrypt.CompareHashAndPassword([]byte(s.authPass), []byte(password)) != nil {
+		return false
+	}
+	s.verified.sum.Store(&sum)
+	return userOK
+}
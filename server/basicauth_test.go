@@ -0,0 +1,64 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestBasicAuthPasswordHash(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "intro.md"), []byte("# Intro\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte("secret"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := NewWithAuth(dir, 0, "Docs", "writer", string(hash), OAuth2Config{}, "", "test")
+	handler := s.Handler()
+
+	get := func(user, password string) int {
+		req := httptest.NewRequest(http.MethodGet, "/intro", nil)
+		req.SetBasicAuth(user, password)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+	for _, tc := range []struct {
+		user, password string
+		want           int
+	}{
+		{"writer", "secret", http.StatusOK},
+		{"writer", "secret", http.StatusOK}, // from the cache
+		{"writer", "wrong", http.StatusUnauthorized},
+		{"other", "secret", http.StatusUnauthorized},
+		{"writer", string(hash), http.StatusUnauthorized},
+	} {
+		if got := get(tc.user, tc.password); got != tc.want {
+			t.Errorf("%s:%s: expected %d, got %d", tc.user, tc.password, tc.want, got)
+		}
+	}
+}
+
+func TestValidateAuthPassword(t *testing.T) {
+	hash, err := HashPassword("secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(hash), []byte("secret")); err != nil {
+		t.Errorf("expected a hash of the password, got %v", err)
+	}
+	for _, valid := range []string{hash, "plain-password"} {
+		if err := ValidateAuthPassword(valid); err != nil {
+			t.Errorf("ValidateAuthPassword(%q): %v", valid, err)
+		}
+	}
+	if err := ValidateAuthPassword(hash[:20]); err == nil {
+		t.Error("expected an error for a truncated hash")
+	}
+}
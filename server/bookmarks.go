@@ -0,0 +1,152 @@
+package server
+
+import (
+	"encoding/json"
+	"log"
+	"mime"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// bookmarksBucket is the database bucket holding each user's bookmarks,
+// keyed by the siteKey of the user name.
+const bookmarksBucket = "bookmarks"
+
+// maxBookmarkRequest caps the size of a bookmark request body.
+const maxBookmarkRequest = 16 << 10
+
+// bookmarkMethods are the methods accepted by /api/bookmarks.
+var bookmarkMethods = []string{http.MethodGet, http.MethodHead, http.MethodPost, http.MethodDelete}
+
+// bookmarkList is a user's bookmarked pages and saved searches.
+type bookmarkList struct {
+	Pages    []pageBookmark `json:"pages"`
+	Searches []savedSearch  `json:"searches"`
+}
+
+// pageBookmark is a bookmarked page.
+type pageBookmark struct {
+	Path  string `json:"path"`
+	Title string `json:"title"`
+}
+
+// savedSearch is a saved search query, shown under its name.
+type savedSearch struct {
+	Name  string `json:"name"`
+	Query string `json:"query"`
+}
+
+// bookmarkRequest adds a page bookmark when Path is set, otherwise a saved
+// search for Query.
+type bookmarkRequest struct {
+	Path  string `json:"path"`
+	Query string `json:"query"`
+	Name  string `json:"name"`
+}
+
+// handleBookmarks lists, adds, and removes the bookmarks of the signed-in
+// user. It is only available with authentication, since bookmarks are
+// stored per user.
+func (s *Server) handleBookmarks(w http.ResponseWriter, r *http.Request) {
+	user := s.currentUser(r)
+	if user == "" {
+		http.NotFound(w, r)
+		return
+	}
+
+	var list bookmarkList
+	var err error
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+		_, err = s.db.Get(bookmarksBucket, s.siteKey(user), &list)
+	case http.MethodPost:
+		var req bookmarkRequest
+		if !s.decodeBookmarkRequest(w, r, &req) {
+			return
+		}
+		err = s.db.Update(bookmarksBucket, s.siteKey(user), &list, func() error {
+			list.add(req)
+			return nil
+		})
+	case http.MethodDelete:
+		query := r.URL.Query()
+		err = s.db.Update(bookmarksBucket, s.siteKey(user), &list, func() error {
+			list.remove(query.Get("path"), query.Get("query"))
+			return nil
+		})
+	default:
+		methodNotAllowed(w, bookmarkMethods...)
+		return
+	}
+	if err != nil {
+		log.Printf("Error storing bookmarks for %s: %v", user, err)
+		http.Error(w, "Failed to store bookmarks", http.StatusInternalServerError)
+		return
+	}
+
+	if list.Pages == nil {
+		list.Pages = []pageBookmark{}
+	}
+	if list.Searches == nil {
+		list.Searches = []savedSearch{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(list)
+}
+
+// decodeBookmarkRequest reads and validates a bookmark request, responding
+// with an error and returning false when it is invalid. Requiring a JSON
+// body keeps other sites from adding bookmarks through plain form posts.
+func (s *Server) decodeBookmarkRequest(w http.ResponseWriter, r *http.Request, req *bookmarkRequest) bool {
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType != "application/json" {
+		http.Error(w, "Content-Type must be application/json", http.StatusUnsupportedMediaType)
+		return false
+	}
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBookmarkRequest)).Decode(req); err != nil {
+		http.Error(w, "Invalid bookmark: "+err.Error(), http.StatusBadRequest)
+		return false
+	}
+
+	req.Query = strings.TrimSpace(req.Query)
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Path != "" {
+		preview, found := s.indexFor(r).Preview(req.Path)
+		if !found {
+			http.Error(w, "Invalid bookmark: page not found", http.StatusBadRequest)
+			return false
+		}
+		req.Name = preview.Title
+		return true
+	}
+	if req.Query == "" {
+		http.Error(w, "Invalid bookmark: path or query is required", http.StatusBadRequest)
+		return false
+	}
+	if req.Name == "" {
+		req.Name = req.Query
+	}
+	return true
+}
+
+// add adds a page bookmark or saved search, moving an existing entry to
+// the front instead of duplicating it.
+func (l *bookmarkList) add(req bookmarkRequest) {
+	if req.Path != "" {
+		l.Pages = slices.DeleteFunc(l.Pages, func(p pageBookmark) bool { return p.Path == req.Path })
+		l.Pages = slices.Insert(l.Pages, 0, pageBookmark{Path: req.Path, Title: req.Name})
+		return
+	}
+	l.Searches = slices.DeleteFunc(l.Searches, func(q savedSearch) bool { return q.Query == req.Query })
+	l.Searches = slices.Insert(l.Searches, 0, savedSearch{Name: req.Name, Query: req.Query})
+}
+
+// remove removes the bookmark of a page or the saved search for a query.
+func (l *bookmarkList) remove(path, query string) {
+	if path != "" {
+		l.Pages = slices.DeleteFunc(l.Pages, func(p pageBookmark) bool { return p.Path == path })
+	}
+	if query != "" {
+		l.Searches = slices.DeleteFunc(l.Searches, func(q savedSearch) bool { return q.Query == query })
+	}
+}
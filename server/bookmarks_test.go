@@ -0,0 +1,130 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gomdoc/store"
+)
+
+// doBookmarkRequest sends a bookmark API request as the given basic auth user.
+func doBookmarkRequest(t *testing.T, handler http.Handler, method, target, body, user string) (*httptest.ResponseRecorder, bookmarkList) {
+	t.Helper()
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if user != "" {
+		req.SetBasicAuth(user, "secret")
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	var list bookmarkList
+	if rec.Code == http.StatusOK {
+		if err := json.NewDecoder(rec.Body).Decode(&list); err != nil {
+			t.Fatalf("invalid JSON: %v", err)
+		}
+	}
+	return rec, list
+}
+
+func TestBookmarks(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "guide.md"), []byte("---\ntitle: Guide\n---\n# Guide\n"), 0o644)
+	dbPath := filepath.Join(t.TempDir(), "gomdoc.db")
+	db, err := store.Open(dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := NewWithAuth(dir, 0, "Docs", "jane", "secret", OAuth2Config{}, "", "test")
+	s.db = db
+	handler := s.Handler()
+
+	rec, list := doBookmarkRequest(t, handler, http.MethodGet, "/api/bookmarks", "", "jane")
+	if rec.Code != http.StatusOK || len(list.Pages) != 0 || list.Searches == nil {
+		t.Fatalf("expected empty bookmark lists, got %d %+v", rec.Code, list)
+	}
+
+	doBookmarkRequest(t, handler, http.MethodPost, "/api/bookmarks", `{"path": "/guide"}`, "jane")
+	doBookmarkRequest(t, handler, http.MethodPost, "/api/bookmarks", `{"path": "/guide"}`, "jane")
+	_, list = doBookmarkRequest(t, handler, http.MethodPost, "/api/bookmarks", `{"query": "install tag:setup"}`, "jane")
+	if len(list.Pages) != 1 || list.Pages[0] != (pageBookmark{Path: "/guide", Title: "Guide"}) {
+		t.Errorf("expected one page bookmark, got %+v", list.Pages)
+	}
+	if len(list.Searches) != 1 || list.Searches[0].Name != "install tag:setup" {
+		t.Errorf("expected one saved search, got %+v", list.Searches)
+	}
+
+	reopened, _ := store.Open(dbPath)
+	var stored bookmarkList
+	if ok, _ := reopened.Get(bookmarksBucket, "jane", &stored); !ok || len(stored.Pages) != 1 {
+		t.Errorf("expected bookmarks persisted for jane, got %+v", stored)
+	}
+
+	_, list = doBookmarkRequest(t, handler, http.MethodDelete, "/api/bookmarks?path=/guide", "", "jane")
+	if len(list.Pages) != 0 || len(list.Searches) != 1 {
+		t.Errorf("expected page bookmark removed, got %+v", list)
+	}
+}
+
+func TestBookmarksOfVirtualHosts(t *testing.T) {
+	teamA, teamB := t.TempDir(), t.TempDir()
+	os.WriteFile(filepath.Join(teamA, "guide.md"), []byte("---\ntitle: Guide A\n---\n# Guide A\n"), 0o644)
+	os.WriteFile(filepath.Join(teamB, "guide.md"), []byte("---\ntitle: Guide B\n---\n# Guide B\n"), 0o644)
+	s := NewWithAuth(t.TempDir(), 0, "Docs", "jane", "secret", OAuth2Config{}, "", "test")
+	s.Configure(Options{VirtualHosts: []VirtualHost{{Host: "a.local", Dir: teamA}, {Host: "b.local", Dir: teamB}}})
+	handler := s.Handler()
+	onHost := func(host string) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			r.Host = host
+			handler.ServeHTTP(w, r)
+		})
+	}
+
+	doBookmarkRequest(t, onHost("a.local"), http.MethodPost, "/api/bookmarks", `{"path": "/guide"}`, "jane")
+	if _, list := doBookmarkRequest(t, onHost("a.local"), http.MethodGet, "/api/bookmarks", "", "jane"); len(list.Pages) != 1 || list.Pages[0].Title != "Guide A" {
+		t.Errorf("expected the bookmark on its own host, got %+v", list.Pages)
+	}
+	if _, list := doBookmarkRequest(t, onHost("b.local"), http.MethodGet, "/api/bookmarks", "", "jane"); len(list.Pages) != 0 {
+		t.Errorf("expected no bookmarks on another host, got %+v", list.Pages)
+	}
+}
+
+func TestBookmarksRejectInvalidRequests(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "guide.md"), []byte("# Guide\n"), 0o644)
+	s := NewWithAuth(dir, 0, "Docs", "jane", "secret", OAuth2Config{}, "", "test")
+	handler := s.Handler()
+
+	for _, tt := range []struct {
+		body, contentType string
+		want              int
+	}{
+		{`{"path": "/missing"}`, "application/json", http.StatusBadRequest},
+		{`{}`, "application/json", http.StatusBadRequest},
+		{`path=/guide`, "application/x-www-form-urlencoded", http.StatusUnsupportedMediaType},
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/api/bookmarks", strings.NewReader(tt.body))
+		req.Header.Set("Content-Type", tt.contentType)
+		req.SetBasicAuth("jane", "secret")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != tt.want {
+			t.Errorf("%s: expected %d, got %d", tt.body, tt.want, rec.Code)
+		}
+	}
+}
+
+func TestBookmarksRequireAuthentication(t *testing.T) {
+	s := NewWithAuth(t.TempDir(), 0, "Docs", "", "", OAuth2Config{}, "", "test")
+	rec, _ := doBookmarkRequest(t, s.Handler(), http.MethodGet, "/api/bookmarks", "", "")
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404 without authentication, got %d", rec.Code)
+	}
+}
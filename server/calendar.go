@@ -0,0 +1,95 @@
+package server
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"gomdoc/search"
+)
+
+// calendarPath is the iCalendar feed of review and expiry dates.
+const calendarPath = "/calendar.ics"
+
+// calendarLineLength is the longest content line iCalendar allows, in
+// octets, before it must be folded.
+const calendarLineLength = 75
+
+// calendarEscaper escapes iCalendar TEXT values.
+var calendarEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+
+// handleCalendar responds with the review_by dates and expire_at times of
+// the pages as an iCalendar feed, so that owners can subscribe to it and
+// see their deadlines next to their meetings. ?owner= keeps the pages of
+// one owner. Reviews are all-day events; expiries happen at their time.
+func (s *Server) handleCalendar(w http.ResponseWriter, r *http.Request) {
+	owner := r.URL.Query().Get("owner")
+	host := r.Host
+	if base, err := url.Parse(s.options.BaseURL); err == nil && base.Host != "" {
+		host = base.Host
+	}
+	stamp := time.Now().UTC().Format("20060102T150405Z")
+
+	var sb strings.Builder
+	name := s.title + " reviews"
+	if owner != "" {
+		name += " of " + owner
+	}
+	writeCalendarLine(&sb, "BEGIN", "VCALENDAR")
+	writeCalendarLine(&sb, "VERSION", "2.0")
+	writeCalendarLine(&sb, "PRODID", "-//gomdoc//"+s.version+"//EN")
+	writeCalendarLine(&sb, "CALSCALE", "GREGORIAN")
+	writeCalendarLine(&sb, "METHOD", "PUBLISH")
+	writeCalendarLine(&sb, "X-WR-CALNAME", calendarEscaper.Replace(name))
+	for _, deadline := range s.index.Deadlines() {
+		if owner != "" && !strings.EqualFold(deadline.Owner, owner) {
+			continue
+		}
+		link := s.absoluteURL(r, deadline.Path)
+		writeCalendarLine(&sb, "BEGIN", "VEVENT")
+		writeCalendarLine(&sb, "UID", calendarEscaper.Replace(deadline.Kind+deadline.Path+"@"+host))
+		writeCalendarLine(&sb, "DTSTAMP", stamp)
+		switch deadline.Kind {
+		case search.DeadlineReview:
+			writeCalendarLine(&sb, "DTSTART;VALUE=DATE", deadline.Time.Format("20060102"))
+			writeCalendarLine(&sb, "DTEND;VALUE=DATE", deadline.Time.AddDate(0, 0, 1).Format("20060102"))
+			writeCalendarLine(&sb, "SUMMARY", calendarEscaper.Replace("Review due: "+deadline.Title))
+			writeCalendarLine(&sb, "TRANSP", "TRANSPARENT")
+		case search.DeadlineExpiry:
+			writeCalendarLine(&sb, "DTSTART", deadline.Time.UTC().Format("20060102T150405Z"))
+			writeCalendarLine(&sb, "SUMMARY", calendarEscaper.Replace("Expires: "+deadline.Title))
+		}
+		description := link
+		if deadline.Owner != "" {
+			description = "Owner: " + deadline.Owner + "\n" + link
+		}
+		writeCalendarLine(&sb, "DESCRIPTION", calendarEscaper.Replace(description))
+		writeCalendarLine(&sb, "URL", link)
+		writeCalendarLine(&sb, "END", "VEVENT")
+	}
+	writeCalendarLine(&sb, "END", "VCALENDAR")
+
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Write([]byte(sb.String()))
+}
+
+// writeCalendarLine writes an iCalendar content line, folding it into
+// continuation lines that start with a space once it gets too long.
+// Lines are only broken between UTF-8 characters.
+func writeCalendarLine(sb *strings.Builder, name, value string) {
+	line := name + ":" + value
+	limit := calendarLineLength
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && line[cut]&0xC0 == 0x80 {
+			cut--
+		}
+		sb.WriteString(line[:cut] + "\r\n ")
+		line = line[cut:]
+		// The leading space of continuation lines counts.
+		limit = calendarLineLength - 1
+	}
+	sb.WriteString(line + "\r\n")
+}
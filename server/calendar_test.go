@@ -0,0 +1,67 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCalendar(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "policy.md"), []byte("---\ntitle: Travel, Expenses; Policy\nowner: zoe\nreview_by: 2024-03-01\nexpire_at: 2024-06-30T17:00:00Z\n---\n# Policy\n"), 0o644)
+	os.WriteFile(filepath.Join(dir, "guide.md"), []byte("---\ntitle: Guide\nowner: anna\nreview_by: 2024-01-15\n---\n# Guide\n"), 0o644)
+	s := NewWithAuth(dir, 0, "Docs", "", "", OAuth2Config{}, "", "test")
+	s.Configure(Options{BaseURL: "https://docs.example.com"})
+	handler := s.Handler()
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/calendar.ics", nil))
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "text/calendar; charset=utf-8" {
+		t.Fatalf("expected a calendar, got %d %q", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	body := rec.Body.String()
+	for _, want := range []string{
+		"BEGIN:VCALENDAR\r\nVERSION:2.0\r\n",
+		"X-WR-CALNAME:Docs reviews\r\n",
+		"UID:review/policy@docs.example.com\r\n",
+		"DTSTART;VALUE=DATE:20240301\r\nDTEND;VALUE=DATE:20240302\r\nSUMMARY:Review due: Travel\\, Expenses\\; Policy\r\n",
+		"UID:expiry/policy@docs.example.com\r\n",
+		"DTSTART:20240630T170000Z\r\nSUMMARY:Expires: Travel\\, Expenses\\; Policy\r\n",
+		"DESCRIPTION:Owner: zoe\\nhttps://docs.example.com/policy\r\n",
+		"END:VCALENDAR\r\n",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected %q in the calendar, got\n%s", want, body)
+		}
+	}
+	if strings.Index(body, "Review due: Guide") > strings.Index(body, "Review due: Travel") {
+		t.Errorf("expected the earliest deadline first, got\n%s", body)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/calendar.ics?owner=Anna", nil))
+	body = rec.Body.String()
+	if strings.Count(body, "BEGIN:VEVENT") != 1 || !strings.Contains(body, "Review due: Guide") {
+		t.Errorf("expected only the review of anna's page, got\n%s", body)
+	}
+}
+
+func TestWriteCalendarLine(t *testing.T) {
+	var sb strings.Builder
+	writeCalendarLine(&sb, "SUMMARY", strings.Repeat("é", 60))
+	lines := strings.Split(strings.TrimSuffix(sb.String(), "\r\n"), "\r\n")
+	if len(lines) != 2 || !strings.HasPrefix(lines[1], " ") {
+		t.Fatalf("expected a folded line, got %q", lines)
+	}
+	for _, line := range lines {
+		if len(line) > calendarLineLength {
+			t.Errorf("expected lines of at most %d octets, got %d", calendarLineLength, len(line))
+		}
+	}
+	if unfolded := lines[0] + lines[1][1:]; unfolded != "SUMMARY:"+strings.Repeat("é", 60) {
+		t.Errorf("expected the folding to keep the characters, got %q", unfolded)
+	}
+}
@@ -0,0 +1,76 @@
+package server
+
+import (
+	"io/fs"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+)
+
+// canonicalPath returns the canonical form of a page route (without
+// leading slash): duplicate and trailing slashes are dropped, and so are a
+// .md extension, an index or index.html segment that is not a page of its
+// own, as in links to an exported site, and, with prefix stripping
+// enabled, ordering prefixes.
+func (s *Server) canonicalPath(urlPath string) string {
+	canonical := strings.TrimPrefix(path.Clean("/"+urlPath), "/")
+	canonical = s.prettyPath(canonical)
+
+	if ext := path.Ext(canonical); strings.EqualFold(ext, ".md") {
+		if s.hasDocument(strings.TrimSuffix(canonical, ext)) {
+			canonical = strings.TrimSuffix(canonical, ext)
+		}
+	}
+	if dir, name := path.Split(canonical); name == "index.html" || name == "index" {
+		_, fileErr := fs.Stat(s.fsys(), canonical)
+		if !s.hasDocument(canonical) && fileErr != nil {
+			canonical = strings.TrimSuffix(dir, "/")
+		}
+	}
+	return canonical
+}
+
+// redirectCanonical answers requests for a non-canonical page route with a
+// permanent redirect to the canonical one, keeping the query. It reports
+// whether it redirected.
+func (s *Server) redirectCanonical(w http.ResponseWriter, r *http.Request, urlPath string) bool {
+	canonical := s.canonicalPath(urlPath)
+	if canonical == urlPath {
+		return false
+	}
+	target := (&url.URL{Path: "/" + canonical, RawQuery: r.URL.RawQuery}).String()
+	http.Redirect(w, r, target, http.StatusMovedPermanently)
+	return true
+}
+
+// cleanPaths permanently redirects GET and HEAD requests for paths with
+// duplicate slashes or dot segments to their clean form, which the mux
+// would only redirect temporarily.
+func cleanPaths(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		clean := path.Clean("/" + r.URL.Path)
+		if strings.HasSuffix(r.URL.Path, "/") && clean != "/" {
+			clean += "/"
+		}
+		if clean == r.URL.Path || (r.Method != http.MethodGet && r.Method != http.MethodHead) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		target := (&url.URL{Path: clean, RawQuery: r.URL.RawQuery}).String()
+		http.Redirect(w, r, target, http.StatusMovedPermanently)
+	})
+}
+
+// canonicalURL returns the absolute URL of the page at pagePath for
+// canonical links and og:url, or an empty string when Options.BaseURL is
+// not set. The home document is linked as the site root.
+func (s *Server) canonicalURL(pagePath string) string {
+	if s.options.BaseURL == "" {
+		return ""
+	}
+	if pagePath != "/" && pagePath == "/"+s.homeDocument() {
+		pagePath = "/"
+	}
+	return strings.TrimSuffix(s.options.BaseURL, "/") + (&url.URL{Path: pagePath}).EscapedPath()
+}
@@ -0,0 +1,65 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCanonicalRedirects(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "guide"), 0o755)
+	os.WriteFile(filepath.Join(dir, "index.md"), []byte("# Welcome\n"), 0o644)
+	os.WriteFile(filepath.Join(dir, "guide", "setup.md"), []byte("# Setup\n"), 0o644)
+
+	s := NewWithAuth(dir, 0, "Docs", "", "", OAuth2Config{}, "", "test")
+	s.Configure(Options{BaseURL: "https://docs.example.com/"})
+	handler := s.Handler()
+
+	for request, want := range map[string]string{
+		"/guide/":                "/guide",
+		"/guide/setup/":          "/guide/setup",
+		"/guide/setup.md":        "/guide/setup",
+		"/guide/setup.md?x=1":    "/guide/setup?x=1",
+		"/guide/index.html":      "/guide",
+		"/guide/setup/index":     "/guide/setup",
+		"/guide//setup":          "/guide/setup",
+		"/browse/":               "/browse",
+		"/guide/setup/index.htm": "",
+	} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, request, nil))
+		if want == "" {
+			if rec.Code == http.StatusMovedPermanently {
+				t.Errorf("%s: expected no redirect, got %s", request, rec.Header().Get("Location"))
+			}
+			continue
+		}
+		if rec.Code != http.StatusMovedPermanently || rec.Header().Get("Location") != want {
+			t.Errorf("%s: expected 301 to %s, got %d %q", request, want, rec.Code, rec.Header().Get("Location"))
+		}
+	}
+
+	for request, want := range map[string]string{
+		"/guide/setup": "https://docs.example.com/guide/setup",
+		"/":            "https://docs.example.com/",
+		"/index":       "https://docs.example.com/",
+		"/guide":       "https://docs.example.com/guide",
+		"/browse":      "https://docs.example.com/browse",
+	} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, request, nil))
+		if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `<link rel="canonical" href="`+want+`">`) {
+			t.Errorf("%s: expected canonical link to %s, got %d:\n%s", request, want, rec.Code, rec.Body.String())
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/guide/setup", nil))
+	if !strings.Contains(rec.Body.String(), `<meta property="og:url" content="https://docs.example.com/guide/setup">`) {
+		t.Errorf("expected og:url tag, got:\n%s", rec.Body.String())
+	}
+}
@@ -0,0 +1,239 @@
+package server
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"log"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gomdoc/search"
+)
+
+// maxChangeEvents is how many changes the journal remembers. Clients whose
+// cursor is older start over with a full listing.
+const maxChangeEvents = 10000
+
+// Change actions reported by /api/changes.
+const (
+	changeAdded    = "added"
+	changeModified = "modified"
+	changeRemoved  = "removed"
+)
+
+// fileChange is one entry in the /api/changes response.
+type fileChange struct {
+	Action string `json:"action"`
+	Path   string `json:"path"`
+	File   string `json:"file"`
+	ETag   string `json:"etag,omitempty"`
+	// Content is the markdown source, included with content=true.
+	Content *string `json:"content,omitempty"`
+}
+
+// changesResponse is the body of /api/changes.
+type changesResponse struct {
+	// Cursor is passed as since to the next request.
+	Cursor string `json:"cursor"`
+	// Reset tells the client to replace its copy with Changes, which list
+	// every page as added, because its cursor was missing, too old, or from
+	// before a restart.
+	Reset   bool         `json:"reset"`
+	Changes []fileChange `json:"changes"`
+}
+
+// changeEvent records that a page changed.
+type changeEvent struct {
+	seq    int64
+	action string
+	path   string
+	file   string
+}
+
+// snapshotFile is a published page as last seen by the journal.
+type snapshotFile struct {
+	file string
+	etag string
+}
+
+// changeJournal numbers the changes to the published pages, so clients can
+// ask for those after a cursor. It learns of changes by comparing
+// snapshots of the pages, taken whenever the indexes are rebuilt and
+// whenever a client asks.
+type changeJournal struct {
+	mu sync.Mutex
+	// epoch tells cursors of this journal from those of an earlier run.
+	epoch  string
+	seq    int64
+	files  map[string]snapshotFile
+	events []changeEvent
+}
+
+// newChangeJournal returns an empty journal.
+func newChangeJournal() *changeJournal {
+	return &changeJournal{epoch: strconv.FormatInt(time.Now().UnixNano(), 36)}
+}
+
+// record compares files with the previous snapshot and numbers the
+// differences. The first snapshot records nothing.
+func (j *changeJournal) record(files map[string]snapshotFile) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.files != nil {
+		for path, file := range files {
+			old, found := j.files[path]
+			switch {
+			case !found:
+				j.add(changeAdded, path, file.file)
+			case old.etag != file.etag:
+				j.add(changeModified, path, file.file)
+			}
+		}
+		for path, old := range j.files {
+			if _, found := files[path]; !found {
+				j.add(changeRemoved, path, old.file)
+			}
+		}
+		if len(j.events) > maxChangeEvents {
+			j.events = append([]changeEvent(nil), j.events[len(j.events)-maxChangeEvents:]...)
+		}
+	}
+	j.files = files
+}
+
+// add appends an event. The caller holds j.mu.
+func (j *changeJournal) add(action, path, file string) {
+	j.seq++
+	j.events = append(j.events, changeEvent{seq: j.seq, action: action, path: path, file: file})
+}
+
+// since returns the changes after cursor, the latest per page, and the
+// cursor to continue from. It lists every page instead, with reset set,
+// when cursor does not belong to this journal or is older than the
+// changes it remembers.
+func (j *changeJournal) since(cursor string) (changes []fileChange, next string, reset bool) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	next = j.epoch + "." + strconv.FormatInt(j.seq, 10)
+
+	epoch, seqText, _ := strings.Cut(cursor, ".")
+	seq, err := strconv.ParseInt(seqText, 10, 64)
+	oldest := j.seq
+	if len(j.events) > 0 {
+		oldest = j.events[0].seq - 1
+	}
+	if epoch != j.epoch || err != nil || seq < oldest || seq > j.seq {
+		for path, file := range j.files {
+			changes = append(changes, fileChange{Action: changeAdded, Path: path, File: file.file, ETag: file.etag})
+		}
+		sort.Slice(changes, func(a, b int) bool { return changes[a].Path < changes[b].Path })
+		return changes, next, true
+	}
+
+	latest := make(map[string]int)
+	for _, event := range j.events {
+		if event.seq <= seq {
+			continue
+		}
+		change := fileChange{Action: event.action, Path: event.path, File: event.file}
+		if file, found := j.files[event.path]; found && event.action != changeRemoved {
+			change.File, change.ETag = file.file, file.etag
+		}
+		if i, found := latest[event.path]; found {
+			changes[i] = change
+			continue
+		}
+		latest[event.path] = len(changes)
+		changes = append(changes, change)
+	}
+	return changes, next, false
+}
+
+// snapshotPages hashes the source of every published page. Drafts are
+// left out, since the feed is read by mirrors of the public docs, and so
+// are pages over the page size limit, which are not served either.
+func (s *Server) snapshotPages() (map[string]snapshotFile, error) {
+	entries, err := s.scanEntries()
+	if err != nil {
+		return nil, err
+	}
+	files := make(map[string]snapshotFile)
+	for _, entry := range entries {
+		if s.scheduleStatus(entry.URLPath) != search.Published || s.index.IsDraft(entry.URLPath) {
+			continue
+		}
+		name := strings.TrimPrefix(entry.URLPath, "/") + ".md"
+		if entry.RelPath != "" {
+			name = filepath.ToSlash(entry.RelPath)
+		}
+		if info, err := fs.Stat(s.fsys(), name); err != nil || s.options.Scan.TooLarge(info.Size()) {
+			continue
+		}
+		data, err := fs.ReadFile(s.fsys(), name)
+		if err != nil {
+			continue // removed since the scan
+		}
+		files[entry.URLPath] = snapshotFile{file: name, etag: contentETag(data)}
+	}
+	return files, nil
+}
+
+// contentETag returns the entity tag of a page's markdown source.
+func contentETag(data []byte) string {
+	sum := sha256.Sum256(data)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// recordChanges updates the change journal from the published pages.
+func (s *Server) recordChanges() error {
+	files, err := s.snapshotPages()
+	if err != nil {
+		return err
+	}
+	s.changes.record(files)
+	return nil
+}
+
+// handleChanges responds with the pages added, modified, or removed since
+// the since cursor, for mirrors and search appliances that sync the docs.
+// With content=true, added and modified pages carry their markdown.
+func (s *Server) handleChanges(w http.ResponseWriter, r *http.Request) {
+	if err := s.recordChanges(); err != nil {
+		log.Printf("Error listing changes: %v", err)
+		http.Error(w, "Error listing changes", http.StatusInternalServerError)
+		return
+	}
+	changes, cursor, reset := s.changes.since(r.URL.Query().Get("since"))
+	if changes == nil {
+		changes = []fileChange{}
+	}
+	if withContent, _ := strconv.ParseBool(r.URL.Query().Get("content")); withContent {
+		for i, change := range changes {
+			if change.Action == changeRemoved {
+				continue
+			}
+			data, err := fs.ReadFile(s.fsys(), change.File)
+			if err != nil {
+				http.Error(w, fmt.Sprintf("Error reading %s", change.File), http.StatusInternalServerError)
+				return
+			}
+			// The page may have changed since the snapshot, so the tag
+			// follows the content sent.
+			content := string(data)
+			changes[i].Content = &content
+			changes[i].ETag = contentETag(data)
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(changesResponse{Cursor: cursor, Reset: reset, Changes: changes})
+}
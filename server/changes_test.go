@@ -0,0 +1,86 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gomdoc/scanner"
+)
+
+func TestChanges(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "install.md"), []byte("# Install\n"), 0o644)
+	os.WriteFile(filepath.Join(dir, "upgrade.md"), []byte("# Upgrade\n"), 0o644)
+	os.WriteFile(filepath.Join(dir, "later.md"), []byte("---\npublish_at: 2999-01-01\n---\n# Later\n"), 0o644)
+
+	handler := NewWithAuth(dir, 0, "Docs", "", "", OAuth2Config{}, "", "test").Handler()
+	get := func(target string) changesResponse {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected 200 for %s, got %d", target, rec.Code)
+		}
+		var resp changesResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("invalid response: %v", err)
+		}
+		return resp
+	}
+
+	first := get("/api/changes")
+	if !first.Reset || len(first.Changes) != 2 || first.Changes[0].Path != "/install" || first.Changes[1].Path != "/upgrade" {
+		t.Fatalf("expected a full listing of the published pages, got %+v", first)
+	}
+	if first.Changes[0].ETag == "" || first.Changes[0].File != "install.md" {
+		t.Errorf("expected the file and its tag, got %+v", first.Changes[0])
+	}
+
+	if resp := get("/api/changes?since=" + first.Cursor); resp.Reset || len(resp.Changes) != 0 || resp.Cursor != first.Cursor {
+		t.Errorf("expected no changes, got %+v", resp)
+	}
+
+	os.WriteFile(filepath.Join(dir, "install.md"), []byte("# Install\n\nNew step.\n"), 0o644)
+	os.Remove(filepath.Join(dir, "upgrade.md"))
+	os.WriteFile(filepath.Join(dir, "faq.md"), []byte("# FAQ\n"), 0o644)
+	resp := get("/api/changes?content=true&since=" + first.Cursor)
+	got := make(map[string]fileChange)
+	for _, change := range resp.Changes {
+		got[change.Path] = change
+	}
+	if resp.Reset || len(got) != 3 || got["/install"].Action != "modified" || got["/upgrade"].Action != "removed" || got["/faq"].Action != "added" {
+		t.Fatalf("expected one change of each kind, got %+v", resp)
+	}
+	if got["/install"].ETag == first.Changes[0].ETag || got["/install"].Content == nil || *got["/install"].Content != "# Install\n\nNew step.\n" {
+		t.Errorf("expected the new tag and content, got %+v", got["/install"])
+	}
+	if got["/upgrade"].File != "upgrade.md" || got["/upgrade"].Content != nil {
+		t.Errorf("expected the removed file without content, got %+v", got["/upgrade"])
+	}
+
+	if resp := get("/api/changes?since=stale.3"); !resp.Reset || len(resp.Changes) != 2 {
+		t.Errorf("expected a cursor from another run to reset, got %+v", resp)
+	}
+}
+
+func TestChangesSkipLargePages(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "guide"), 0o755)
+	os.WriteFile(filepath.Join(dir, "guide", "install.md"), []byte("# Install\n"), 0o644)
+	os.WriteFile(filepath.Join(dir, "huge.md"), []byte("# Huge\n\n"+strings.Repeat("Text. ", 1<<10)), 0o644)
+	s := NewWithAuth(dir, 0, "Docs", "", "", OAuth2Config{}, "", "test")
+	s.Configure(Options{Scan: scanner.ScanOptions{MaxPageBytes: 1 << 10}})
+
+	files, err := s.snapshotPages()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := files["/huge"]; ok || files["/guide/install"].file != "guide/install.md" {
+		t.Errorf("expected the nested page by its slash path and no page over the size limit, got %+v", files)
+	}
+}
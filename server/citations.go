@@ -0,0 +1,77 @@
+package server
+
+import (
+	"bytes"
+	"errors"
+	"io/fs"
+	"log"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"gomdoc/cite"
+	"gomdoc/renderer"
+)
+
+// resolveCitations replaces [@key] citations in a rendered page using the
+// site bibliography and the page's own bibliography, which wins for keys
+// defined in both. Pages without citations are returned unchanged. A page
+// bibliography that does not exist is returned as missing.
+func (s *Server) resolveCitations(html []byte, urlPath string, frontmatter renderer.Frontmatter) ([]byte, []missingAsset) {
+	if s.options.Bibliography == "" && frontmatter.Bibliography == "" {
+		return html, nil
+	}
+	if !bytes.Contains(html, []byte("[@")) {
+		return html, nil
+	}
+
+	var missing []missingAsset
+	bib := cite.Bibliography{}
+	if site := s.options.Bibliography; site != "" {
+		var loaded cite.Bibliography
+		var err error
+		if s.source() != nil && !filepath.IsAbs(site) {
+			loaded, err = cite.LoadFS(s.fsys(), filepath.ToSlash(filepath.Clean(site)))
+		} else {
+			if !filepath.IsAbs(site) {
+				site = filepath.Join(s.baseDir, site)
+			}
+			loaded, err = cite.Load(site)
+		}
+		if err != nil {
+			log.Printf("Warning: loading bibliography: %v", err)
+		}
+		bib = bib.Merge(loaded)
+	}
+	if page := frontmatter.Bibliography; page != "" {
+		rel, ok := pageBibliographyPath(s.sourcePath(urlPath), page)
+		if !ok {
+			log.Printf("Warning: %s: bibliography %q is outside the documentation directory", urlPath, page)
+			return cite.Process(html, bib), nil
+		}
+		loaded, err := cite.LoadFS(s.fsys(), rel)
+		if errors.Is(err, fs.ErrNotExist) {
+			missing = append(missing, missingAsset{Kind: "bibliography", Ref: page})
+		} else if err != nil {
+			log.Printf("Warning: %s: loading bibliography: %v", urlPath, err)
+		}
+		bib = bib.Merge(loaded)
+	}
+	return cite.Process(html, bib), missing
+}
+
+// pageBibliographyPath resolves a page's bibliography path relative to the
+// page's directory, or to the site root when it starts with "/". It
+// reports false for paths escaping the documentation directory.
+func pageBibliographyPath(sourcePath, bibliography string) (string, bool) {
+	var rel string
+	if strings.HasPrefix(bibliography, "/") {
+		rel = path.Clean(strings.TrimPrefix(bibliography, "/"))
+	} else {
+		rel = path.Join(path.Dir(sourcePath), bibliography)
+	}
+	if rel == ".." || strings.HasPrefix(rel, "../") {
+		return "", false
+	}
+	return rel, true
+}
@@ -0,0 +1,49 @@
+package server
+
+import (
+	"io/fs"
+	"log"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"gomdoc/scanner"
+)
+
+// codeFile reports whether the file at name is shown as a highlighted
+// code page.
+func (s *Server) codeFile(name string) bool {
+	return scanner.IsAttachmentType(name, s.options.CodeFiles)
+}
+
+// serveCodePage renders a text or source file as a read-only highlighted
+// page inside the site chrome, with a link to the raw file. It reports
+// false when urlPath is no code page route.
+func (s *Server) serveCodePage(w http.ResponseWriter, r *http.Request, urlPath string) bool {
+	name, ok := strings.CutSuffix(urlPath, previewSuffix)
+	if !ok || !s.codeFile(name) || strings.HasPrefix(name, ".") || strings.Contains(name, "/.") {
+		return false
+	}
+	info, err := fs.Stat(s.fsys(), name)
+	if err != nil || !info.Mode().IsRegular() {
+		return false
+	}
+	content, err := fs.ReadFile(s.fsys(), name)
+	if err != nil {
+		return false
+	}
+
+	body := "<p class=\"file-error\">This file is not text. Download it to read it.</p>\n"
+	if utf8.Valid(content) {
+		html, err := s.renderWithin(r.Context(), name, func() ([]byte, error) {
+			return s.renderer.RenderFile(name, content)
+		})
+		if err != nil {
+			log.Printf("Warning: highlighting %s: %v", name, err)
+		} else {
+			body = "<div class=\"code-file\">\n" + string(html) + "</div>\n"
+		}
+	}
+	s.renderFilePage(w, r, name, info, "Raw", body)
+	return true
+}
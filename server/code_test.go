@@ -0,0 +1,54 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCodeFiles(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "deploy"), 0o755)
+	os.WriteFile(filepath.Join(dir, "index.md"), []byte("# Home\n"), 0o644)
+	os.WriteFile(filepath.Join(dir, "deploy", "config.yaml"), []byte("replicas: 3\n"), 0o644)
+	os.WriteFile(filepath.Join(dir, "deploy", "page.html"), []byte("<script>alert(1)</script>"), 0o644)
+	os.WriteFile(filepath.Join(dir, "deploy", "data.json"), []byte{0xff, 0xfe, 0x00}, 0o644)
+
+	get := func(handler http.Handler, target string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		return rec
+	}
+
+	off := NewWithAuth(dir, 0, "Docs", "", "", OAuth2Config{}, "", "test").Handler()
+	if rec := get(off, "/deploy/config.yaml.html"); rec.Code != http.StatusNotFound {
+		t.Errorf("expected code pages off by default, got %d", rec.Code)
+	}
+
+	s := NewWithAuth(dir, 0, "Docs", "", "", OAuth2Config{}, "", "test")
+	s.Configure(Options{CodeFiles: []string{"yaml", "json", "html"}})
+	handler := s.Handler()
+
+	rec := get(handler, "/deploy/config.yaml.html")
+	body := rec.Body.String()
+	if rec.Code != http.StatusOK || !strings.Contains(body, `<div class="code-file">`) || !strings.Contains(body, ">replicas</span>") {
+		t.Fatalf("expected highlighted code page, got %d:\n%s", rec.Code, body)
+	}
+	if !strings.Contains(body, `<a href="/deploy/config.yaml" class="nav-btn" download>Raw config.yaml</a>`) {
+		t.Errorf("expected raw link on the code page")
+	}
+	if !strings.Contains(body, `href="/deploy/config.yaml.html" class="file attachment" data-type="yaml"`) {
+		t.Errorf("expected the tree to link the code page, got:\n%s", body)
+	}
+
+	rec = get(handler, "/deploy/page.html")
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "text/plain; charset=utf-8" {
+		t.Errorf("expected raw code files served as plain text, got %d %q", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	if body := get(handler, "/deploy/data.json.html").Body.String(); !strings.Contains(body, "This file is not text.") {
+		t.Errorf("expected binary files not rendered, got:\n%s", body)
+	}
+}
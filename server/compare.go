@@ -0,0 +1,204 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os/exec"
+	"path"
+	"strings"
+
+	"gomdoc/search"
+	"gomdoc/templates"
+)
+
+// comparePath is the route comparing two pages, or two git revisions of
+// one page: /compare?a=<path>&b=<path>, with the revisions in from and to.
+const comparePath = "/compare"
+
+// errNoRevision is returned for a revision git does not know, or that has
+// no version of the page.
+var errNoRevision = errors.New("no such revision")
+
+// errRevisionRole is returned when a reader below the editor role asks
+// for a revision.
+var errRevisionRole = errors.New("comparing revisions needs the " + RoleEditor.String() + " role")
+
+// handleCompare shows the changes of the markdown of page a at revision
+// from to page b, which defaults to a, at revision to. An empty revision
+// is the page as it is now. The diff is inline, or side by side with
+// view=split.
+func (s *Server) handleCompare(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	data := templates.CompareData{
+		SiteLayout: s.layout(),
+		A:          templates.CompareSide{Path: query.Get("a"), Revision: query.Get("from")},
+		B:          templates.CompareSide{Path: query.Get("b"), Revision: query.Get("to")},
+		Split:      query.Get("view") == "split",
+	}
+	if data.B.Path == "" {
+		data.B.Path = data.A.Path
+	}
+
+	status := http.StatusOK
+	if data.A.Path != "" {
+		old, err := s.compareSource(r, &data.A)
+		if err == nil {
+			var current []byte
+			current, err = s.compareSource(r, &data.B)
+			var lines []diffLine
+			if err == nil {
+				lines, err = diffLines(splitLines(string(old)), splitLines(string(current)))
+			}
+			if err == nil {
+				data.Compared = true
+				lines = diffHunks(lines)
+				if len(lines) > 0 && !(len(lines) == 1 && lines[0].kind == diffSkip) {
+					data.Diff = templateDiff(lines)
+					data.Rows = splitDiff(data.Diff)
+				}
+			}
+		}
+		if err != nil {
+			data.Error = err.Error()
+			switch {
+			case errors.Is(err, errRevisionRole):
+				status = http.StatusForbidden
+			case errors.Is(err, errDiffTooLarge), errors.Is(err, errTooLarge):
+				status = http.StatusRequestEntityTooLarge
+			default:
+				status = http.StatusNotFound
+			}
+		}
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	if err := traceTemplate(r, "compare", func() error { return templates.RenderCompare(w, data) }); err != nil {
+		log.Printf("Error rendering comparison: %v", err)
+	}
+}
+
+// compareSource reads the markdown of one side of a comparison for the
+// reader of r, filling in its title and link. Pages the reader may not
+// see are reported as missing, and revisions need the editor role, since
+// the history keeps text removed from the docs.
+func (s *Server) compareSource(r *http.Request, side *templates.CompareSide) ([]byte, error) {
+	urlPath, ok := comparePage(side.Path)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a page", side.Path)
+	}
+	side.URL = "/" + urlPath
+	side.Title = s.pageTitle(side.URL)
+	if s.scheduleStatus(side.URL) != search.Published || s.hidesDraft(r, side.URL) {
+		return nil, fmt.Errorf("%s does not exist", side.Path)
+	}
+	if side.Revision == "" {
+		content, err := s.readDocument(urlPath)
+		if errors.Is(err, errTooLarge) {
+			return nil, err
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s does not exist", side.Path)
+		}
+		return content, nil
+	}
+	if s.role(r) < RoleEditor {
+		return nil, errRevisionRole
+	}
+	content, err := s.readRevision(r, urlPath, side.Revision)
+	if errors.Is(err, errNoRevision) {
+		return nil, fmt.Errorf("%s has no revision %s", side.Path, side.Revision)
+	}
+	return content, err
+}
+
+// comparePage returns the route of the page named by value, which may be
+// written as a URL path or a file name such as guide/setup.md, and whether
+// it names one.
+func comparePage(value string) (string, bool) {
+	name := strings.Trim(value, "/")
+	for _, ext := range []string{".md", ".MD"} {
+		name = strings.TrimSuffix(name, ext)
+	}
+	if name == "" || path.Clean(name) != name || strings.HasPrefix(name, "..") {
+		return "", false
+	}
+	return name, true
+}
+
+// readRevision returns the markdown of the page at urlPath as it was in
+// the git revision rev of the base directory, such as a commit, a tag, or
+// HEAD~3.
+func (s *Server) readRevision(r *http.Request, urlPath, rev string) ([]byte, error) {
+	if s.source() != nil {
+		return nil, errors.New("comparing revisions needs a local git work tree")
+	}
+	// A leading dash would be read as an option and a colon would change
+	// the path looked up.
+	if strings.HasPrefix(rev, "-") || strings.ContainsAny(rev, ": \t\n") {
+		return nil, errNoRevision
+	}
+	relPath := s.sourcePath(urlPath)
+	for _, ext := range []string{".md", ".MD"} {
+		cmd := exec.CommandContext(r.Context(), "git", "show", rev+":./"+relPath+ext)
+		cmd.Dir = s.baseDir
+		content, err := cmd.Output()
+		if err == nil {
+			if s.options.Scan.TooLarge(int64(len(content))) {
+				return nil, fmt.Errorf("%s at %s is too large: %w", urlPath, rev, errTooLarge)
+			}
+			return content, nil
+		}
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) {
+			return nil, fmt.Errorf("running git: %w", err)
+		}
+		if stderr := string(exitErr.Stderr); strings.Contains(stderr, "not a git repository") {
+			return nil, errors.New("comparing revisions needs a local git work tree")
+		}
+	}
+	return nil, errNoRevision
+}
+
+// templateDiff converts diff lines for the templates.
+func templateDiff(lines []diffLine) []templates.DiffLine {
+	converted := make([]templates.DiffLine, len(lines))
+	for i, line := range lines {
+		converted[i] = templates.DiffLine{Kind: line.kind, Old: line.old, New: line.new, Text: line.text}
+	}
+	return converted
+}
+
+// splitDiff lays a diff out side by side: unchanged lines on both sides,
+// and each run of deleted lines next to the added lines following it.
+func splitDiff(lines []templates.DiffLine) []templates.DiffRow {
+	var rows []templates.DiffRow
+	for i := 0; i < len(lines); {
+		switch lines[i].Kind {
+		case diffSame, diffSkip:
+			rows = append(rows, templates.DiffRow{Old: lines[i], New: lines[i]})
+			i++
+			continue
+		}
+		var deleted, added []templates.DiffLine
+		for ; i < len(lines) && lines[i].Kind == diffDelete; i++ {
+			deleted = append(deleted, lines[i])
+		}
+		for ; i < len(lines) && lines[i].Kind == diffAdd; i++ {
+			added = append(added, lines[i])
+		}
+		for j := 0; j < max(len(deleted), len(added)); j++ {
+			var row templates.DiffRow
+			if j < len(deleted) {
+				row.Old = deleted[j]
+			}
+			if j < len(added) {
+				row.New = added[j]
+			}
+			rows = append(rows, row)
+		}
+	}
+	return rows
+}
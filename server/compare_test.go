@@ -0,0 +1,140 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gomdoc/templates"
+)
+
+func TestCompare(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "guide"), 0o755)
+	os.WriteFile(filepath.Join(dir, "guide", "setup.md"), []byte("# Setup\n\nInstall it.\n\nRun it.\n"), 0o644)
+	os.WriteFile(filepath.Join(dir, "guide", "setup-v2.md"), []byte("# Setup\n\nDownload it.\n\nRun it.\n\nDone.\n"), 0o644)
+	os.WriteFile(filepath.Join(dir, "plan.md"), []byte("---\nstatus: draft\n---\n# Plan\n"), 0o644)
+	os.WriteFile(filepath.Join(dir, "old.md"), []byte(strings.Repeat("old line\n", maxDiffEdits)), 0o644)
+	os.WriteFile(filepath.Join(dir, "new.md"), []byte(strings.Repeat("new line\n", maxDiffEdits)), 0o644)
+
+	s := NewWithAuth(dir, 0, "Docs", "", "", OAuth2Config{}, "", "test")
+	s.Configure(Options{Roles: Roles{Members: map[Role][]string{RoleAdmin: {"root"}}}})
+	handler := s.Handler()
+	get := func(target string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		return rec
+	}
+
+	rec := get("/compare?a=/guide/setup&b=guide/setup-v2.md")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /compare = %d\n%s", rec.Code, rec.Body.String())
+	}
+	body := rec.Body.String()
+	for _, want := range []string{
+		`<tr class="diff-delete"><td class="diff-num">3</td><td class="diff-num"></td><td class="diff-sign">-</td><td class="diff-text">Install it.</td></tr>`,
+		`<tr class="diff-add"><td class="diff-num"></td><td class="diff-num">3</td><td class="diff-sign">+</td><td class="diff-text">Download it.</td></tr>`,
+		`<td class="diff-text">Done.</td>`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("inline diff lacks %s", want)
+		}
+	}
+
+	if rec := get("/compare?a=old&b=new"); rec.Code != http.StatusRequestEntityTooLarge || !strings.Contains(rec.Body.String(), errDiffTooLarge.Error()) {
+		t.Errorf("expected 413 for too many changes, got %d", rec.Code)
+	}
+
+	body = get("/compare?a=guide/setup&b=guide/setup-v2&view=split").Body.String()
+	for _, want := range []string{
+		`<td class="diff-num">3</td><td class="diff-text diff-delete">Install it.</td><td class="diff-num">3</td><td class="diff-text diff-add">Download it.</td>`,
+		`<td class="diff-num"></td><td class="diff-text diff-none"></td><td class="diff-num">7</td><td class="diff-text diff-add">Done.</td>`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("side by side diff lacks %s\n%s", want, body)
+		}
+	}
+
+	if body := get("/compare?a=guide/setup").Body.String(); !strings.Contains(body, "The pages are the same.") {
+		t.Error("comparing a page with itself shows changes")
+	}
+	for _, target := range []string{"/compare?a=guide/gone", "/compare?a=plan&b=guide/setup", "/compare?a=../secret"} {
+		if rec := get(target); rec.Code != http.StatusNotFound {
+			t.Errorf("GET %s = %d, want 404", target, rec.Code)
+		}
+	}
+	if rec := get("/compare?a=guide/setup&from=HEAD"); rec.Code != http.StatusForbidden || !strings.Contains(rec.Body.String(), "needs the editor role") {
+		t.Errorf("anonymous revision comparison = %d", rec.Code)
+	}
+}
+
+func TestCompareRevisions(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	dir := t.TempDir()
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), "GIT_AUTHOR_NAME=a", "GIT_AUTHOR_EMAIL=a@example.com",
+			"GIT_COMMITTER_NAME=c", "GIT_COMMITTER_EMAIL=c@example.com")
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	git("init", "-q")
+	os.WriteFile(filepath.Join(dir, "guide.md"), []byte("# Guide\n\nFirst draft.\n"), 0o644)
+	git("add", "-A")
+	git("commit", "-q", "-m", "first")
+	git("tag", "v1")
+	os.WriteFile(filepath.Join(dir, "guide.md"), []byte("# Guide\n\nRewritten.\n"), 0o644)
+
+	s := NewWithAuth(dir, 0, "Docs", "", "", OAuth2Config{}, "", "test")
+	handler := s.Handler()
+	get := func(target string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		return rec
+	}
+
+	body := get("/compare?a=guide&from=v1").Body.String()
+	for _, want := range []string{`<td class="diff-text">First draft.</td>`, `<td class="diff-text">Rewritten.</td>`, "at <code>v1</code>"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("revision diff lacks %s\n%s", want, body)
+		}
+	}
+	if body := get("/compare?a=guide&from=v1&to=HEAD").Body.String(); !strings.Contains(body, "The pages are the same.") {
+		t.Error("v1 and HEAD differ")
+	}
+	for _, rev := range []string{"v9", "--output=x", "v1:other.md"} {
+		if rec := get("/compare?a=guide&from=" + rev); rec.Code != http.StatusNotFound || !strings.Contains(rec.Body.String(), "has no revision") {
+			t.Errorf("revision %s = %d\n%s", rev, rec.Code, rec.Body.String())
+		}
+	}
+}
+
+func TestSplitDiff(t *testing.T) {
+	lines := []templates.DiffLine{
+		{Kind: diffSame, Old: 1, New: 1, Text: "a"},
+		{Kind: diffDelete, Old: 2, Text: "b"},
+		{Kind: diffDelete, Old: 3, Text: "c"},
+		{Kind: diffAdd, New: 2, Text: "B"},
+		{Kind: diffAdd, New: 3, Text: "x"},
+		{Kind: diffAdd, New: 4, Text: "y"},
+	}
+	rows := splitDiff(lines)
+	if len(rows) != 4 {
+		t.Fatalf("got %d rows, want 4: %+v", len(rows), rows)
+	}
+	if rows[1].Old.Text != "b" || rows[1].New.Text != "B" || rows[2].Old.Text != "c" || rows[2].New.Text != "x" {
+		t.Errorf("changed lines not paired: %+v", rows)
+	}
+	if rows[3].Old.Kind != "" || rows[3].New.Text != "y" {
+		t.Errorf("unpaired addition: %+v", rows[3])
+	}
+}
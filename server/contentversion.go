@@ -0,0 +1,71 @@
+package server
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+)
+
+// contentVersionHeader carries the content version a response was served
+// at, so caches and mirrors in front of gomdoc can tell updates apart.
+const contentVersionHeader = "X-Content-Version"
+
+// contentVersion numbers the states of the documentation tree. Every
+// update, whether an edit, an approved revision, a WebDAV write, or a
+// source refresh, bumps it through contentChanged, and everything derived
+// from the pages records the version it was built at: the search and MCP
+// indexes with their metadata, and the office previews. Whatever is older
+// than the current version is rebuilt before it is served.
+type contentVersion struct {
+	// current is the version of the documentation tree.
+	current atomic.Int64
+	// indexed is the version the indexes were last built at.
+	indexed atomic.Int64
+	// rebuild lets one index rebuild run at a time.
+	rebuild sync.Mutex
+}
+
+// contentChanged records an update of the documentation tree: caches
+// built before it are no longer served, and the indexes are rebuilt in
+// the background.
+func (s *Server) contentChanged() {
+	s.content.current.Add(1)
+	s.queueRebuild()
+}
+
+// syncIndexes rebuilds the indexes unless they are up to date with the
+// current content version. Concurrent calls wait for the running rebuild
+// instead of starting their own. A failed rebuild still counts as done, so
+// requests are not held up by rebuilding over and over; the error is
+// returned and shown on the admin page.
+func (s *Server) syncIndexes() error {
+	version := s.content.current.Load()
+	if s.content.indexed.Load() >= version {
+		return nil
+	}
+	s.content.rebuild.Lock()
+	defer s.content.rebuild.Unlock()
+	version = s.content.current.Load()
+	if s.content.indexed.Load() >= version {
+		return nil
+	}
+	err := s.rebuildIndexes()
+	s.content.indexed.Store(version)
+	return err
+}
+
+// freshContent makes requests wait until the indexes caught up with the
+// last update, so no page, search result, or listing is served from
+// before it, and names the content version in the X-Content-Version
+// header. Requests while the indexes are current pass straight through.
+func (s *Server) freshContent(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := s.syncIndexes(); err != nil {
+			log.Printf("Warning: %v", err)
+		}
+		w.Header().Set(contentVersionHeader, strconv.FormatInt(s.content.indexed.Load(), 10))
+		next.ServeHTTP(w, r)
+	})
+}
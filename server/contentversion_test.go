@@ -0,0 +1,48 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestContentVersion(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "guide.md"), []byte("# Guide\n"), 0o644)
+	s := NewWithAuth(dir, 0, "Docs", "", "", OAuth2Config{}, "", "test")
+	s.Configure(Options{Workers: 1})
+	handler := s.Handler()
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/guide", nil))
+	if got := rec.Header().Get(contentVersionHeader); got != "0" {
+		t.Errorf("expected content version 0 at startup, got %q", got)
+	}
+
+	// Keep the only worker busy, so the queued rebuild cannot run before
+	// the next request.
+	release := make(chan struct{})
+	defer close(release)
+	s.jobs.Submit("busy", func(ctx context.Context) error {
+		<-release
+		return nil
+	})
+	os.WriteFile(filepath.Join(dir, "added.md"), []byte("# Added\n\nA quokka appears.\n"), 0o644)
+	s.contentChanged()
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/search?q=quokka", nil))
+	if !strings.Contains(rec.Body.String(), `"/added"`) {
+		t.Errorf("expected the search after an update to find the new page, got %s", rec.Body.String())
+	}
+	if got := rec.Header().Get(contentVersionHeader); got != "1" {
+		t.Errorf("expected content version 1 after an update, got %q", got)
+	}
+	if err := s.syncIndexes(); err != nil {
+		t.Errorf("expected the indexes up to date, got %v", err)
+	}
+}
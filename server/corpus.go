@@ -0,0 +1,26 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+)
+
+// handleCorpus responds with the published pages as plain-text chunks,
+// one JSON object per line, for embedding pipelines and chatbots. The
+// chunk_size parameter sets the longest chunk in characters.
+func (s *Server) handleCorpus(w http.ResponseWriter, r *http.Request) {
+	size := 0
+	if value := r.URL.Query().Get("chunk_size"); value != "" {
+		var err error
+		if size, err = strconv.Atoi(value); err != nil || size < 1 {
+			http.Error(w, "chunk_size must be a positive number", http.StatusBadRequest)
+			return
+		}
+	}
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	encoder := json.NewEncoder(w)
+	for _, chunk := range s.indexFor(r).Chunks(size) {
+		encoder.Encode(chunk)
+	}
+}
@@ -0,0 +1,44 @@
+package server
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gomdoc/search"
+)
+
+func TestCorpus(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "install.md"), []byte("# Install\n\nRun the installer.\n\n## Upgrade\n\nRun it again.\n"), 0o644)
+	handler := NewWithAuth(dir, 0, "Docs", "", "", OAuth2Config{}, "", "test").Handler()
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/corpus", nil))
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "application/x-ndjson" {
+		t.Fatalf("expected JSON lines, got %d %q", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	var chunks []search.Chunk
+	lines := bufio.NewScanner(strings.NewReader(rec.Body.String()))
+	for lines.Scan() {
+		var chunk search.Chunk
+		if err := json.Unmarshal(lines.Bytes(), &chunk); err != nil {
+			t.Fatalf("invalid line %q: %v", lines.Text(), err)
+		}
+		chunks = append(chunks, chunk)
+	}
+	if len(chunks) != 2 || chunks[1].Text != "Run it again." || strings.Join(chunks[1].Headings, "/") != "Install/Upgrade" {
+		t.Errorf("unexpected chunks %+v", chunks)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/corpus?chunk_size=0", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for an invalid chunk size, got %d", rec.Code)
+	}
+}
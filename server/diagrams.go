@@ -0,0 +1,37 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"html"
+	"log"
+	"regexp"
+	"time"
+)
+
+// diagramTimeout bounds a run of the Mermaid pre-rendering command.
+const diagramTimeout = 30 * time.Second
+
+// mermaidBlock matches the code blocks the renderer writes for mermaid
+// fences.
+var mermaidBlock = regexp.MustCompile(`(?s)<pre><code class="language-mermaid">(.*?)</code></pre>`)
+
+// renderDiagrams replaces the mermaid code blocks of a rendered page with
+// SVG drawn by Options.MermaidCommand. Blocks that fail to render are left
+// for the browser to draw, with a warning in the log.
+func (s *Server) renderDiagrams(ctx context.Context, page []byte) []byte {
+	if s.diagrams == nil || !bytes.Contains(page, []byte(`class="language-mermaid"`)) {
+		return page
+	}
+	return mermaidBlock.ReplaceAllFunc(page, func(block []byte) []byte {
+		source := html.UnescapeString(string(mermaidBlock.FindSubmatch(block)[1]))
+		ctx, cancel := context.WithTimeout(ctx, diagramTimeout)
+		defer cancel()
+		svg, err := s.diagrams.Render(ctx, source)
+		if err != nil {
+			log.Printf("Warning: mermaid diagram not pre-rendered: %v", err)
+			return block
+		}
+		return []byte(`<div class="mermaid-static">` + svg + `</div>`)
+	})
+}
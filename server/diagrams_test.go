@@ -0,0 +1,31 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRenderDiagrams(t *testing.T) {
+	if _, err := exec.LookPath("cp"); err != nil {
+		t.Skip("cp not available")
+	}
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "page.md"), []byte("# Page\n\n```mermaid\n<svg id=\"drawn\"></svg>\n```\n\n```mermaid\ngraph TD\n  A --> B\n```\n"), 0o644)
+
+	s := NewWithAuth(dir, 0, "Docs", "", "", OAuth2Config{}, "", "test")
+	s.Configure(Options{MermaidCommand: "cp {input} {output}"})
+	rec := httptest.NewRecorder()
+	s.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/page", nil))
+	body := rec.Body.String()
+	if !strings.Contains(body, `<div class="mermaid-static"><svg id="drawn"></svg>`) {
+		t.Errorf("expected the diagram pre-rendered, got:\n%s", body)
+	}
+	if !strings.Contains(body, `<pre><code class="language-mermaid">graph TD`) {
+		t.Errorf("expected a diagram that fails to render left for the browser, got:\n%s", body)
+	}
+}
@@ -0,0 +1,168 @@
+package server
+
+import (
+	"errors"
+	"slices"
+)
+
+// Kinds of diffLine.
+const (
+	diffSame   = "same"
+	diffAdd    = "add"
+	diffDelete = "delete"
+	// diffSkip stands for unchanged lines left out between hunks.
+	diffSkip = "skip"
+)
+
+// diffContext is how many unchanged lines are kept around each change.
+const diffContext = 3
+
+// diffLine is a line of a line diff. Old and New are its 1-based line
+// numbers in the old and new text, zero where it does not appear.
+type diffLine struct {
+	kind     string
+	old, new int
+	text     string
+}
+
+// Limits of diffLines. The search for the shortest edit script takes time
+// in proportion to the lines times the edits and keeps state in proportion
+// to the square of the edits, so larger rewrites are not diffed.
+const (
+	maxDiffLines = 50000
+	maxDiffEdits = 1000
+)
+
+// errDiffTooLarge is returned by diffLines for texts over its limits.
+var errDiffTooLarge = errors.New("too many changes to show a diff")
+
+// diffLines returns the changes turning a into b, found with Myers'
+// algorithm after trimming the common start and end. Texts with more than
+// maxDiffLines lines or maxDiffEdits changed lines between the common
+// start and end return errDiffTooLarge.
+func diffLines(a, b []string) ([]diffLine, error) {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	middle, err := myers(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])
+	if err != nil {
+		return nil, err
+	}
+
+	var lines []diffLine
+	for i := 0; i < prefix; i++ {
+		lines = append(lines, diffLine{kind: diffSame, old: i + 1, new: i + 1, text: a[i]})
+	}
+	for _, line := range middle {
+		if line.old > 0 {
+			line.old += prefix
+		}
+		if line.new > 0 {
+			line.new += prefix
+		}
+		lines = append(lines, line)
+	}
+	for i := suffix; i > 0; i-- {
+		lines = append(lines, diffLine{kind: diffSame, old: len(a) - i + 1, new: len(b) - i + 1, text: a[len(a)-i]})
+	}
+	return lines, nil
+}
+
+// myers returns the shortest edit script turning a into b, or
+// errDiffTooLarge when it is longer than maxDiffEdits.
+func myers(a, b []string) ([]diffLine, error) {
+	n, m := len(a), len(b)
+	if n+m > maxDiffLines {
+		return nil, errDiffTooLarge
+	}
+	limit := min(n+m, maxDiffEdits)
+	offset := limit + 1
+	v := make([]int, 2*limit+3)
+	// trace keeps the diagonals -d-1 to d+1 of v as they were before each
+	// round d, the part the walk back reads to find the moves taken.
+	var trace [][]int
+	found := false
+	for d := 0; d <= limit && !found; d++ {
+		trace = append(trace, slices.Clone(v[offset-d-1:offset+d+2]))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				found = true
+				break
+			}
+		}
+	}
+	if !found {
+		return nil, errDiffTooLarge
+	}
+
+	var reversed []diffLine
+	x, y := n, m
+	for d := len(trace) - 1; d >= 0; d-- {
+		window := trace[d]
+		at := func(k int) int { return window[k+d+1] }
+		k := x - y
+		prevK := k - 1
+		if k == -d || (k != d && at(k-1) < at(k+1)) {
+			prevK = k + 1
+		}
+		prevX := at(prevK)
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			reversed = append(reversed, diffLine{kind: diffSame, old: x, new: y, text: a[x-1]})
+			x--
+			y--
+		}
+		if d == 0 {
+			break
+		}
+		if x == prevX {
+			reversed = append(reversed, diffLine{kind: diffAdd, new: y, text: b[y-1]})
+		} else {
+			reversed = append(reversed, diffLine{kind: diffDelete, old: x, text: a[x-1]})
+		}
+		x, y = prevX, prevY
+	}
+	slices.Reverse(reversed)
+	return reversed, nil
+}
+
+// diffHunks keeps the changes of a diff with diffContext unchanged lines
+// around each, replacing longer runs of unchanged lines by a skip line.
+func diffHunks(lines []diffLine) []diffLine {
+	keep := make([]bool, len(lines))
+	for i, line := range lines {
+		if line.kind == diffSame {
+			continue
+		}
+		for j := max(0, i-diffContext); j <= min(len(lines)-1, i+diffContext); j++ {
+			keep[j] = true
+		}
+	}
+	var hunks []diffLine
+	for i, line := range lines {
+		switch {
+		case keep[i]:
+			hunks = append(hunks, line)
+		case len(hunks) == 0 || hunks[len(hunks)-1].kind != diffSkip:
+			hunks = append(hunks, diffLine{kind: diffSkip})
+		}
+	}
+	return hunks
+}
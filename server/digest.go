@@ -0,0 +1,367 @@
+package server
+
+import (
+	"fmt"
+	"log"
+	"mime"
+	"net"
+	"net/http"
+	"net/mail"
+	"net/smtp"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"gomdoc/renderer"
+	"gomdoc/templates"
+)
+
+// digestPath is the page where signed-in users subscribe to the digest.
+const digestPath = "/digest"
+
+// digestBucket is the database bucket holding the digest subscriptions,
+// keyed by the siteKey of the user name.
+const digestBucket = "digest"
+
+// digestStateBucket is the database bucket holding, per frequency, the
+// pages as they were when the last digest was sent, keyed by the siteKey
+// of the frequency.
+const digestStateBucket = "digest-state"
+
+// digestCheckInterval is how often the digest job looks for digests due.
+const digestCheckInterval = 15 * time.Minute
+
+// maxDigestSections is how many changed sections a digest names per page.
+const maxDigestSections = 3
+
+// Digest frequencies.
+const (
+	digestDaily  = "daily"
+	digestWeekly = "weekly"
+)
+
+// digestPeriods is the time between two digests of each frequency.
+var digestPeriods = map[string]time.Duration{
+	digestDaily:  24 * time.Hour,
+	digestWeekly: 7 * 24 * time.Hour,
+}
+
+// SMTPOptions configure the mail server sending the digests.
+type SMTPOptions struct {
+	// Addr is the host:port of the mail server. Empty disables digests.
+	Addr string
+	// User and Password authenticate with PLAIN auth, which is only sent
+	// over TLS or to localhost. The connection is upgraded with STARTTLS
+	// when the server offers it.
+	User     string
+	Password string
+	// From is the sender address.
+	From string
+}
+
+// Enabled reports whether digests are sent.
+func (o SMTPOptions) Enabled() bool {
+	return o.Addr != ""
+}
+
+// send delivers msg to the address to.
+func (o SMTPOptions) send(to string, msg []byte) error {
+	var auth smtp.Auth
+	if o.User != "" {
+		host, _, _ := net.SplitHostPort(o.Addr)
+		auth = smtp.PlainAuth("", o.User, o.Password, host)
+	}
+	return smtp.SendMail(o.Addr, auth, o.From, []string{to}, msg)
+}
+
+// digestSubscription is a user's choice of digest.
+type digestSubscription struct {
+	Email     string `json:"email"`
+	Frequency string `json:"frequency"`
+}
+
+// digestState is the snapshot of the published pages the next digest of
+// a frequency compares against.
+type digestState struct {
+	Sent  time.Time             `json:"sent"`
+	Pages map[string]digestPage `json:"pages"`
+}
+
+// digestPage is a published page in a digest snapshot.
+type digestPage struct {
+	Title  string `json:"title"`
+	Source string `json:"source"`
+}
+
+// digestChange is a page listed in a digest.
+type digestChange struct {
+	Action  string
+	Path    string
+	Title   string
+	Author  string
+	Summary string
+}
+
+// digestEnabled reports whether users can subscribe to digests, which
+// needs a mail server and signed-in users.
+func (s *Server) digestEnabled() bool {
+	return s.options.SMTP.Enabled() && (s.authUser != "" || s.oauth2Config.Enabled())
+}
+
+// runDigests sends the digests of the site that are due, now and every
+// digestCheckInterval. Start runs it for every site.
+func (s *Server) runDigests() {
+	ticker := time.NewTicker(digestCheckInterval)
+	defer ticker.Stop()
+	for {
+		s.sendDueDigests(time.Now())
+		<-ticker.C
+	}
+}
+
+// sendDueDigests mails the pages changed since the last digest of each
+// frequency whose period has passed to its subscribers, then takes a new
+// snapshot. The first run only takes the snapshots.
+func (s *Server) sendDueDigests(now time.Time) {
+	current, err := s.digestPages()
+	if err != nil {
+		log.Printf("Error listing pages for the digest: %v", err)
+		return
+	}
+	for _, frequency := range []string{digestDaily, digestWeekly} {
+		var state digestState
+		found, err := s.db.Get(digestStateBucket, s.siteKey(frequency), &state)
+		if err != nil {
+			log.Printf("Error reading the %s digest state: %v", frequency, err)
+			continue
+		}
+		if found && now.Sub(state.Sent) < digestPeriods[frequency] {
+			continue
+		}
+		if changes := digestChanges(state.Pages, current); found && len(changes) > 0 {
+			for _, subscription := range s.digestSubscribers(frequency) {
+				msg := s.digestMessage(subscription, state.Sent, changes, now)
+				if err := s.sendMail(subscription.Email, msg); err != nil {
+					log.Printf("Error sending the %s digest to %s: %v", frequency, subscription.Email, err)
+				}
+			}
+		}
+		if err := s.db.Put(digestStateBucket, s.siteKey(frequency), digestState{Sent: now, Pages: current}); err != nil {
+			log.Printf("Error storing the %s digest state: %v", frequency, err)
+		}
+	}
+}
+
+// sendMail delivers a message through the configured mail server, or the
+// mailer tests install.
+func (s *Server) sendMail(to string, msg []byte) error {
+	if s.mailer != nil {
+		return s.mailer(to, msg)
+	}
+	return s.options.SMTP.send(to, msg)
+}
+
+// digestPages returns the published pages with their sources, leaving out
+// drafts, keyed by path.
+func (s *Server) digestPages() (map[string]digestPage, error) {
+	files, err := s.snapshotPages()
+	if err != nil {
+		return nil, err
+	}
+	pages := make(map[string]digestPage)
+	for pagePath := range files {
+		if s.index.IsDraft(pagePath) {
+			continue
+		}
+		source, err := s.readDocument(strings.TrimPrefix(pagePath, "/"))
+		if err != nil {
+			continue // removed since the snapshot
+		}
+		pages[pagePath] = digestPage{Title: s.pageTitle(pagePath), Source: string(source)}
+	}
+	return pages, nil
+}
+
+// digestSubscribers returns the subscriptions of a frequency.
+func (s *Server) digestSubscribers(frequency string) []digestSubscription {
+	var subscribers []digestSubscription
+	for _, user := range s.siteKeys(digestBucket) {
+		var subscription digestSubscription
+		if found, err := s.db.Get(digestBucket, s.siteKey(user), &subscription); err == nil && found && subscription.Frequency == frequency {
+			subscribers = append(subscribers, subscription)
+		}
+	}
+	return subscribers
+}
+
+// digestChanges lists the pages added, modified, and removed between two
+// snapshots, sorted by path.
+func digestChanges(old, current map[string]digestPage) []digestChange {
+	var changes []digestChange
+	for pagePath, page := range current {
+		previous, found := old[pagePath]
+		if found && previous.Source == page.Source {
+			continue
+		}
+		frontmatter, _ := renderer.ParseFrontmatter([]byte(page.Source))
+		change := digestChange{Action: changeModified, Path: pagePath, Title: page.Title, Author: frontmatter.Author}
+		if found {
+			change.Summary = diffSummary(previous.Source, page.Source)
+		} else {
+			change.Action = changeAdded
+			change.Summary = fmt.Sprintf("New page, %d lines", len(splitLines(page.Source)))
+		}
+		changes = append(changes, change)
+	}
+	for pagePath, page := range old {
+		if _, found := current[pagePath]; !found {
+			changes = append(changes, digestChange{Action: changeRemoved, Path: pagePath, Title: page.Title})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+	return changes
+}
+
+// diffSummary describes the change from old to current: the number of
+// lines added and removed, and the sections they are in.
+func diffSummary(old, current string) string {
+	var added, removed int
+	var sections []string
+	heading, fenced := "", false
+	lines, err := diffLines(splitLines(old), splitLines(current))
+	if err != nil {
+		return "rewritten"
+	}
+	for _, line := range lines {
+		text := strings.TrimSpace(line.text)
+		if strings.HasPrefix(text, "```") || strings.HasPrefix(text, "~~~") {
+			fenced = !fenced
+		} else if !fenced && strings.HasPrefix(text, "#") && line.kind != diffDelete {
+			heading = strings.TrimSpace(strings.TrimLeft(text, "#"))
+		}
+		switch line.kind {
+		case diffAdd:
+			added++
+		case diffDelete:
+			removed++
+		default:
+			continue
+		}
+		if heading != "" && !slices.Contains(sections, heading) {
+			sections = append(sections, heading)
+		}
+	}
+	summary := fmt.Sprintf("+%d -%d lines", added, removed)
+	if len(sections) > maxDigestSections {
+		summary += fmt.Sprintf(", in %s and %d more sections", strings.Join(sections[:maxDigestSections], ", "), len(sections)-maxDigestSections)
+	} else if len(sections) > 0 {
+		summary += ", in " + strings.Join(sections, ", ")
+	}
+	return summary
+}
+
+// digestMessage composes the digest email of the changes since a date.
+func (s *Server) digestMessage(subscription digestSubscription, since time.Time, changes []digestChange, now time.Time) []byte {
+	var body strings.Builder
+	pages := "pages"
+	if len(changes) == 1 {
+		pages = "page"
+	}
+	fmt.Fprintf(&body, "%d %s of %s changed since %s:\n", len(changes), pages, s.title, since.Format("January 2, 2006"))
+	for _, change := range changes {
+		fmt.Fprintf(&body, "\n* %s (%s", change.Title, change.Action)
+		if change.Author != "" {
+			fmt.Fprintf(&body, ", by %s", change.Author)
+		}
+		body.WriteString(")\n  " + s.digestLink(change.Path) + "\n")
+		if change.Summary != "" {
+			body.WriteString("  " + change.Summary + "\n")
+		}
+	}
+	fmt.Fprintf(&body, "\nYou get this digest %s. Change or cancel it at %s\n", subscription.Frequency, s.digestLink(digestPath))
+
+	subject := fmt.Sprintf("%s: %d %s changed", s.title, len(changes), pages)
+	var msg strings.Builder
+	fmt.Fprintf(&msg, "From: %s\r\n", s.options.SMTP.From)
+	fmt.Fprintf(&msg, "To: %s\r\n", subscription.Email)
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&msg, "Date: %s\r\n", now.Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\nContent-Type: text/plain; charset=utf-8\r\nContent-Transfer-Encoding: 8bit\r\n\r\n")
+	msg.WriteString(strings.ReplaceAll(body.String(), "\n", "\r\n"))
+	return []byte(msg.String())
+}
+
+// digestLink returns the absolute URL of a site path when a base URL is
+// configured, otherwise the path.
+func (s *Server) digestLink(sitePath string) string {
+	if s.options.BaseURL == "" {
+		return sitePath
+	}
+	return strings.TrimSuffix(s.options.BaseURL, "/") + sitePath
+}
+
+// handleDigest shows and saves the digest subscription of the signed-in
+// user. Cross-origin posts are refused.
+func (s *Server) handleDigest(w http.ResponseWriter, r *http.Request) {
+	user := s.currentUser(r)
+	if user == "" {
+		http.NotFound(w, r)
+		return
+	}
+	var subscription digestSubscription
+	if _, err := s.db.Get(digestBucket, s.siteKey(user), &subscription); err != nil {
+		log.Printf("Error reading the digest subscription of %s: %v", user, err)
+	}
+	data := templates.DigestData{
+		SiteLayout: s.layout(),
+		Email:      subscription.Email,
+		Frequency:  subscription.Frequency,
+	}
+	if data.Email == "" && strings.Contains(user, "@") {
+		data.Email = user
+	}
+
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+		data.Saved = r.URL.Query().Has("saved")
+	case http.MethodPost:
+		if err := http.NewCrossOriginProtection().Check(r); err != nil {
+			http.Error(w, "Cross-origin request refused", http.StatusForbidden)
+			return
+		}
+		data.Email = strings.TrimSpace(r.PostFormValue("email"))
+		data.Frequency = r.PostFormValue("frequency")
+		if err := s.saveDigest(user, data.Email, data.Frequency); err != nil {
+			data.Error = "Not saved: " + err.Error()
+			break
+		}
+		http.Redirect(w, r, digestPath+"?saved", http.StatusSeeOther)
+		return
+	default:
+		methodNotAllowed(w, editMethods...)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	if data.Error != "" {
+		w.WriteHeader(http.StatusBadRequest)
+	}
+	if err := templates.RenderDigest(w, data); err != nil {
+		log.Printf("Error rendering digest page: %v", err)
+	}
+}
+
+// saveDigest stores the subscription of user, or removes it when the
+// frequency is neither daily nor weekly.
+func (s *Server) saveDigest(user, email, frequency string) error {
+	if _, ok := digestPeriods[frequency]; !ok {
+		return s.db.Delete(digestBucket, s.siteKey(user))
+	}
+	address, err := mail.ParseAddress(email)
+	if err != nil {
+		return fmt.Errorf("invalid email address %q", email)
+	}
+	return s.db.Put(digestBucket, s.siteKey(user), digestSubscription{Email: address.Address, Frequency: frequency})
+}
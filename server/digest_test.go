@@ -0,0 +1,129 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDigest(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "guide.md"), []byte("---\ntitle: Guide\n---\n# Guide\n\n## Setup\n\nRun it.\n"), 0o644)
+	os.WriteFile(filepath.Join(dir, "old.md"), []byte("# Old\n"), 0o644)
+	s := NewWithAuth(dir, 0, "Docs", "writer", "secret", OAuth2Config{}, "", "test")
+	s.Configure(Options{BaseURL: "https://docs.example.com", SMTP: SMTPOptions{Addr: "localhost:25", From: "docs@example.com"}})
+	sent := make(map[string]string)
+	s.mailer = func(to string, msg []byte) error {
+		sent[to] = string(msg)
+		return nil
+	}
+	handler := s.Handler()
+
+	form := url.Values{"email": {"writer@example.com"}, "frequency": {"weekly"}}
+	req := httptest.NewRequest(http.MethodPost, digestPath, strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth("writer", "secret")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusSeeOther {
+		t.Fatalf("expected the subscription to redirect, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	start := time.Date(2026, 3, 2, 8, 0, 0, 0, time.UTC)
+	s.sendDueDigests(start)
+	os.WriteFile(filepath.Join(dir, "guide.md"), []byte("---\ntitle: Guide\nauthor: Jane\n---\n# Guide\n\n## Setup\n\nRun it twice.\n"), 0o644)
+	os.WriteFile(filepath.Join(dir, "new.md"), []byte("---\ntitle: News\n---\n# News\n\nFresh.\n"), 0o644)
+	os.Remove(filepath.Join(dir, "old.md"))
+	s.rebuildIndexes()
+
+	s.sendDueDigests(start.Add(24 * time.Hour))
+	if len(sent) != 0 {
+		t.Fatalf("expected no digest before a week passed, got %v", sent)
+	}
+	s.sendDueDigests(start.Add(7 * 24 * time.Hour))
+	msg := sent["writer@example.com"]
+	for _, want := range []string{
+		"To: writer@example.com\r\n",
+		"Subject: Docs: 3 pages changed\r\n",
+		"3 pages of Docs changed since March 2, 2026:",
+		"* Guide (modified, by Jane)\r\n  https://docs.example.com/guide\r\n  +2 -1 lines, in Setup\r\n",
+		"* News (added)\r\n  https://docs.example.com/new\r\n  New page, 6 lines\r\n",
+		"* old (removed)\r\n",
+		"You get this digest weekly. Change or cancel it at https://docs.example.com/digest",
+	} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("expected %q in the digest, got\n%s", want, msg)
+		}
+	}
+}
+
+func TestDigestOfVirtualHosts(t *testing.T) {
+	mainDir, teamDir := t.TempDir(), t.TempDir()
+	os.WriteFile(filepath.Join(mainDir, "main.md"), []byte("# Main\n"), 0o644)
+	os.WriteFile(filepath.Join(teamDir, "team.md"), []byte("# Team\n"), 0o644)
+	s := NewWithAuth(mainDir, 0, "Docs", "writer", "secret", OAuth2Config{}, "", "test")
+	s.Configure(Options{SMTP: SMTPOptions{Addr: "localhost:25", From: "docs@example.com"}, VirtualHosts: []VirtualHost{{Host: "team.local", Dir: teamDir}}})
+	var sent []string
+	s.mailer = func(to string, msg []byte) error {
+		sent = append(sent, string(msg))
+		return nil
+	}
+	handler := s.Handler()
+	team := s.vhosts["team.local"]
+
+	form := url.Values{"email": {"writer@example.com"}, "frequency": {"weekly"}}
+	req := httptest.NewRequest(http.MethodPost, digestPath, strings.NewReader(form.Encode()))
+	req.Host = "team.local"
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth("writer", "secret")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	start := time.Date(2026, 3, 2, 8, 0, 0, 0, time.UTC)
+	s.sendDueDigests(start)
+	team.sendDueDigests(start)
+	os.WriteFile(filepath.Join(mainDir, "main.md"), []byte("# Main\n\nChanged.\n"), 0o644)
+	os.WriteFile(filepath.Join(teamDir, "team.md"), []byte("# Team\n\nChanged.\n"), 0o644)
+	s.rebuildIndexes()
+	team.rebuildIndexes()
+
+	s.sendDueDigests(start.Add(7 * 24 * time.Hour))
+	if len(sent) != 0 {
+		t.Fatalf("expected no digest of the main site for a subscriber of the virtual host, got %v", sent)
+	}
+	team.sendDueDigests(start.Add(7 * 24 * time.Hour))
+	if len(sent) != 1 || !strings.Contains(sent[0], "* team (modified)") || strings.Contains(sent[0], "main") {
+		t.Errorf("expected one digest of the virtual host's pages, got %v", sent)
+	}
+}
+
+func TestDigestSubscription(t *testing.T) {
+	s := NewWithAuth(t.TempDir(), 0, "Docs", "writer", "secret", OAuth2Config{}, "", "test")
+	s.Configure(Options{SMTP: SMTPOptions{Addr: "localhost:25", From: "docs@example.com"}})
+	handler := s.Handler()
+	post := func(email, frequency string) int {
+		form := url.Values{"email": {email}, "frequency": {frequency}}
+		req := httptest.NewRequest(http.MethodPost, digestPath, strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.SetBasicAuth("writer", "secret")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	if code := post("not an address", "daily"); code != http.StatusBadRequest {
+		t.Errorf("expected 400 for an invalid address, got %d", code)
+	}
+	post("writer@example.com", "daily")
+	if subscribers := s.digestSubscribers(digestDaily); len(subscribers) != 1 {
+		t.Errorf("expected one daily subscriber, got %v", subscribers)
+	}
+	post("writer@example.com", "")
+	if subscribers := s.digestSubscribers(digestDaily); len(subscribers) != 0 {
+		t.Errorf("expected the subscription cancelled, got %v", subscribers)
+	}
+}
@@ -0,0 +1,35 @@
+package server
+
+import (
+	"log"
+	"net/http"
+
+	"gomdoc/export"
+)
+
+// downloadPath is the route of the offline copy of the site.
+const downloadPath = "/download/site.zip"
+
+// downloadHandler streams the site exported to static HTML as a zip
+// archive. Pages are rendered through site, the routes behind the
+// authentication middleware, so the download needs the same credentials as
+// the pages but renders without them. HEAD answers without rendering, so
+// the index page can probe for the download cheaply.
+func (s *Server) downloadHandler(site http.Handler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		entries, err := s.Entries()
+		if err != nil {
+			http.Error(w, "Error scanning directory", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/zip")
+		w.Header().Set("Content-Disposition", `attachment; filename="site.zip"`)
+		if r.Method == http.MethodHead {
+			return
+		}
+		// Errors after the first byte can only end the response early.
+		if _, err := export.Zip(site, entries, w); err != nil {
+			log.Printf("Error streaming %s: %v", downloadPath, err)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package server
+
+import (
+	"archive/zip"
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestOfflineDownload(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "guide"), 0o755)
+	os.WriteFile(filepath.Join(dir, "index.md"), []byte("# Home\n"), 0o644)
+	os.WriteFile(filepath.Join(dir, "guide", "setup.md"), []byte("# Setup\n"), 0o644)
+
+	off := NewWithAuth(dir, 0, "Docs", "", "", OAuth2Config{}, "", "test").Handler()
+	rec := httptest.NewRecorder()
+	off.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/download/site.zip", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("expected no download by default, got %d", rec.Code)
+	}
+
+	s := NewWithAuth(dir, 0, "Docs", "admin", "secret", OAuth2Config{}, "", "test")
+	s.Configure(Options{OfflineDownload: true})
+	handler := s.Handler()
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/download/site.zip", nil))
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("expected the download behind authentication, got %d", rec.Code)
+	}
+
+	req := httptest.NewRequest(http.MethodHead, "/download/site.zip", nil)
+	req.SetBasicAuth("admin", "secret")
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK || rec.Body.Len() != 0 {
+		t.Errorf("expected HEAD to answer without rendering, got %d with %d bytes", rec.Code, rec.Body.Len())
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/download/site.zip", nil)
+	req.SetBasicAuth("admin", "secret")
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Header().Get("Content-Type") != "application/zip" {
+		t.Fatalf("expected a zip, got %d %q", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	archive, err := zip.NewReader(bytes.NewReader(rec.Body.Bytes()), int64(rec.Body.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := make(map[string]bool)
+	for _, f := range archive.File {
+		files[f.Name] = true
+	}
+	for _, want := range []string{"index.html", "browse/index.html", "guide/setup/index.html", "static/style.css"} {
+		if !files[want] {
+			t.Errorf("expected %s in the archive, got %v", want, files)
+		}
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/browse", nil)
+	req.SetBasicAuth("admin", "secret")
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if !strings.Contains(rec.Body.String(), `id="offline-download" href="/download/site.zip"`) {
+		t.Errorf("expected the download link on the file index")
+	}
+}
@@ -0,0 +1,200 @@
+package server
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/fs"
+	"log"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"gomdoc/templates"
+)
+
+// editPrefix is the route of the page editor: /edit/guide/install edits
+// the page served at /guide/install.
+const editPrefix = "/edit/"
+
+// maxEditBytes caps the size of a page saved from the editor.
+const maxEditBytes = 4 << 20
+
+// editMethods are the methods accepted by the page editor.
+var editMethods = []string{http.MethodGet, http.MethodHead, http.MethodPost}
+
+// pageFile returns the file, relative to the base directory, of the
+// markdown page served at urlPath, trying .md before .MD.
+func (s *Server) pageFile(urlPath string) (string, bool) {
+	relPath := s.sourcePath(urlPath)
+	for _, ext := range []string{".md", ".MD"} {
+		if info, err := fs.Stat(s.fsys(), relPath+ext); err == nil && !info.IsDir() {
+			return relPath + ext, true
+		}
+	}
+	return "", false
+}
+
+// sourceHash identifies a version of a page source. The editor sends it
+// back on save, so that an edit never overwrites a change made since the
+// editor was opened.
+func sourceHash(source []byte) string {
+	sum := sha256.Sum256(source)
+	return hex.EncodeToString(sum[:])
+}
+
+// editURL returns the editor route of the page at pagePath, empty when
+// the reader cannot edit it.
+func (s *Server) editURL(r *http.Request, urlPath string) string {
+	if !s.uploadsEnabled() || s.currentUser(r) == "" || s.role(r) < RoleEditor {
+		return ""
+	}
+	if _, ok := s.pageFile(urlPath); !ok {
+		return ""
+	}
+	return editPrefix + urlPath
+}
+
+// handleEdit serves the page editor in edit mode. GET shows the page
+// source in a form; POST saves it, or with approvals enabled stores it as
+// a revision waiting for a reviewer.
+func (s *Server) handleEdit(w http.ResponseWriter, r *http.Request) {
+	user := s.currentUser(r)
+	if !s.uploadsEnabled() || user == "" {
+		http.NotFound(w, r)
+		return
+	}
+	urlPath := strings.TrimPrefix(r.URL.Path, editPrefix)
+	pagePath := "/" + urlPath
+	file, ok := s.pageFile(urlPath)
+	if urlPath == "" || path.Clean(pagePath) != pagePath || !ok {
+		http.NotFound(w, r)
+		return
+	}
+	current, err := fs.ReadFile(s.fsys(), file)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	data := templates.EditData{
+		SiteLayout: s.layout(),
+		Title:      s.pageTitle(pagePath),
+		Path:       pagePath,
+		Source:     string(current),
+		Base:       sourceHash(current),
+		Approvals:  s.approvalsEnabled(),
+	}
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+		s.renderEditor(w, http.StatusOK, data)
+	case http.MethodPost:
+		s.saveEdit(w, r, user, file, current, data)
+	default:
+		methodNotAllowed(w, editMethods...)
+	}
+}
+
+// saveEdit publishes or proposes the source posted from the editor.
+// Cross-origin posts are refused, and so are edits of a page that changed
+// since the editor was opened.
+func (s *Server) saveEdit(w http.ResponseWriter, r *http.Request, user, file string, current []byte, data templates.EditData) {
+	if err := http.NewCrossOriginProtection().Check(r); err != nil {
+		http.Error(w, "Cross-origin request refused", http.StatusForbidden)
+		return
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, maxEditBytes)
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Invalid edit: "+err.Error(), http.StatusRequestEntityTooLarge)
+		return
+	}
+	edited := editedSource(r.PostFormValue("source"), current)
+	data.Source = edited
+	data.Summary = strings.TrimSpace(r.PostFormValue("summary"))
+	if r.PostFormValue("base") != data.Base {
+		data.Error = "The page changed since you opened the editor. Copy your changes, reload the page, and apply them again."
+		s.renderEditor(w, http.StatusConflict, data)
+		return
+	}
+	if edited == string(current) {
+		http.Redirect(w, r, data.Path, http.StatusSeeOther)
+		return
+	}
+
+	if s.approvalsEnabled() {
+		rev, err := s.proposeRevision(user, file, data, string(current))
+		if err != nil {
+			log.Printf("Error storing revision of %s: %v", data.Path, err)
+			http.Error(w, "Failed to store the edit", http.StatusInternalServerError)
+			return
+		}
+		s.audit(r, auditEvent{Action: auditPropose, Path: data.Path, Target: rev.ID})
+		http.Redirect(w, r, approvalsPath+"/"+rev.ID, http.StatusSeeOther)
+		return
+	}
+	if err := s.writePage(file, edited); err != nil {
+		log.Printf("Error saving %s: %v", file, err)
+		http.Error(w, "Failed to save the page", http.StatusInternalServerError)
+		return
+	}
+	s.contentChanged()
+	s.audit(r, auditEvent{Action: auditEdit, Path: data.Path})
+	http.Redirect(w, r, data.Path, http.StatusSeeOther)
+}
+
+// editedSource normalizes the source posted from a textarea, which
+// browsers send with CRLF line endings, to the line endings of the
+// current page.
+func editedSource(posted string, current []byte) string {
+	edited := strings.ReplaceAll(posted, "\r\n", "\n")
+	if bytes.Contains(current, []byte("\r\n")) {
+		edited = strings.ReplaceAll(edited, "\n", "\r\n")
+	}
+	return edited
+}
+
+// writePage replaces a page file, relative to the base directory, by
+// renaming a temporary file over it, so that readers never see half a
+// page.
+func (s *Server) writePage(file, content string) error {
+	target := filepath.Join(s.baseDir, filepath.FromSlash(file))
+	f, err := os.CreateTemp(filepath.Dir(target), ".gomdoc-edit-*")
+	if err != nil {
+		return err
+	}
+	_, err = f.WriteString(content)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(f.Name(), 0o644)
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), target)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+	}
+	return err
+}
+
+// pageTitle returns the indexed title of the page at pagePath, falling
+// back to the path for pages missing from the index.
+func (s *Server) pageTitle(pagePath string) string {
+	if preview, found := s.index.Preview(pagePath); found && preview.Title != "" {
+		return preview.Title
+	}
+	return pagePath
+}
+
+// renderEditor renders the page editor with status.
+func (s *Server) renderEditor(w http.ResponseWriter, status int, data templates.EditData) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	if err := templates.RenderEdit(w, data); err != nil {
+		log.Printf("Error rendering editor: %v", err)
+	}
+}
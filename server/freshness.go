@@ -0,0 +1,135 @@
+package server
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"gomdoc/clock"
+	"gomdoc/renderer"
+	"gomdoc/search"
+	"gomdoc/templates"
+)
+
+// maxStaleReport caps the number of pages in the admin stale page report.
+const maxStaleReport = 50
+
+// ParseAge parses a page age such as 180d, 26w, or 72h. Days and weeks are
+// added to the units of time.ParseDuration; 0 disables the check.
+func ParseAge(value string) (time.Duration, error) {
+	value = strings.TrimSpace(value)
+	if value == "0" {
+		return 0, nil
+	}
+	for suffix, unit := range map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour} {
+		if number, ok := strings.CutSuffix(value, suffix); ok {
+			n, err := strconv.Atoi(number)
+			if err != nil || n < 0 {
+				return 0, fmt.Errorf("invalid age %q", value)
+			}
+			return time.Duration(n) * unit, nil
+		}
+	}
+	age, err := time.ParseDuration(value)
+	if err != nil || age < 0 {
+		return 0, fmt.Errorf("invalid age %q, use e.g. 180d or 26w", value)
+	}
+	return age, nil
+}
+
+// formatAge formats an age in days when it is a whole number of days,
+// matching how ParseAge accepts it.
+func formatAge(age time.Duration) string {
+	if age%(24*time.Hour) == 0 {
+		return fmt.Sprintf("%d days", age/(24*time.Hour))
+	}
+	return age.String()
+}
+
+// staleAfter returns the age after which a page counts as outdated: the
+// page's stale_after frontmatter, else the site setting. Zero disables the
+// warning.
+func (s *Server) staleAfter(pagePath string, fm renderer.Frontmatter) time.Duration {
+	if fm.StaleAfter == "" {
+		return s.options.StaleAfter
+	}
+	if strings.EqualFold(fm.StaleAfter, "never") {
+		return 0
+	}
+	age, err := ParseAge(fm.StaleAfter)
+	if err != nil {
+		log.Printf("Warning: %s: %v in stale_after frontmatter", pagePath, err)
+		return s.options.StaleAfter
+	}
+	return age
+}
+
+// staleSince returns the date a page was last updated when it is older
+// than its stale_after age, and false for fresh pages.
+func (s *Server) staleSince(pagePath string, fm renderer.Frontmatter) (time.Time, bool) {
+	limit := s.staleAfter(pagePath, fm)
+	if limit <= 0 {
+		return time.Time{}, false
+	}
+	date, found := search.FrontmatterDate(fm)
+	if !found {
+		if date, found = s.index.Date(pagePath); !found {
+			return time.Time{}, false
+		}
+	}
+	return date, clock.Now().Sub(date) > limit
+}
+
+// formatDate formats a date shown on pages in the site's date format and
+// locale.
+func (s *Server) formatDate(date time.Time) string {
+	layout := s.options.DateFormat
+	if layout == "" {
+		layout = search.DefaultDateFormat
+	}
+	return search.FormatDate(date, layout, s.options.DateLocale)
+}
+
+// displayDate formats a date from the frontmatter of the page at pagePath
+// for the page header. Dates that do not parse are shown as written, with
+// a warning in the log.
+func (s *Server) displayDate(pagePath, value string) string {
+	if value == "" {
+		return ""
+	}
+	date, err := search.ParseDate(value)
+	if err != nil {
+		log.Printf("Warning: %s: %v in frontmatter", pagePath, err)
+		return value
+	}
+	return s.formatDate(date)
+}
+
+// staleReport lists the outdated pages, stalest first, for the admin page.
+// Pages are checked like in the banner, so stale_after frontmatter applies.
+func (s *Server) staleReport() []templates.AdminStalePage {
+	var pages []templates.AdminStalePage
+	for _, doc := range s.index.Dates() {
+		if len(pages) == maxStaleReport {
+			break
+		}
+		content, err := s.readDocument(strings.TrimPrefix(doc.Path, "/"))
+		if err != nil {
+			continue
+		}
+		fm, _ := renderer.ParseFrontmatter(content)
+		date, stale := s.staleSince(doc.Path, fm)
+		if !stale {
+			continue
+		}
+		pages = append(pages, templates.AdminStalePage{
+			Title:   doc.Title,
+			Path:    doc.Path,
+			Updated: date.Format("2006-01-02"),
+			AgeDays: int(clock.Now().Sub(date).Hours() / 24),
+		})
+	}
+	return pages
+}
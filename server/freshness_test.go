@@ -0,0 +1,102 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseAge(t *testing.T) {
+	for input, want := range map[string]time.Duration{
+		"180d": 180 * 24 * time.Hour,
+		"2w":   14 * 24 * time.Hour,
+		"36h":  36 * time.Hour,
+		"0":    0,
+	} {
+		if got, err := ParseAge(input); err != nil || got != want {
+			t.Errorf("ParseAge(%q) = %v, %v; want %v", input, got, err, want)
+		}
+	}
+	for _, input := range []string{"", "soon", "-5d", "1.5d"} {
+		if _, err := ParseAge(input); err == nil {
+			t.Errorf("expected error for %q", input)
+		}
+	}
+}
+
+func TestStaleBannerAndReport(t *testing.T) {
+	dir := t.TempDir()
+	recent := time.Now().AddDate(0, 0, -10).Format("2006-01-02")
+	os.WriteFile(filepath.Join(dir, "old.md"), []byte("---\ntitle: Old Page\ndate: 2020-01-15\n---\n# Old\n"), 0o644)
+	os.WriteFile(filepath.Join(dir, "evergreen.md"), []byte("---\ndate: 2020-01-15\nstale_after: never\n---\n# Evergreen\n"), 0o644)
+	os.WriteFile(filepath.Join(dir, "fresh.md"), []byte("---\ndate: "+recent+"\n---\n# Fresh\n"), 0o644)
+	os.WriteFile(filepath.Join(dir, "strict.md"), []byte("---\ndate: "+recent+"\nstale_after: 7d\n---\n# Strict\n"), 0o644)
+
+	s := NewWithAuth(dir, 0, "Docs", "", "", OAuth2Config{}, "", "test")
+	s.Configure(Options{StaleAfter: 180 * 24 * time.Hour})
+	handler := s.Handler()
+
+	for page, wantBanner := range map[string]bool{"/old": true, "/evergreen": false, "/fresh": false, "/strict": true} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, page, nil))
+		if got := strings.Contains(rec.Body.String(), `class="stale-banner"`); got != wantBanner {
+			t.Errorf("%s: expected banner %v, got %v", page, wantBanner, got)
+		}
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/old", nil))
+	if !strings.Contains(rec.Body.String(), "It was last updated on January 15, 2020.") {
+		t.Errorf("expected last update date in banner, got:\n%s", rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/admin", nil))
+	body := rec.Body.String()
+	if !strings.Contains(body, "Pages not updated within 180 days") || !strings.Contains(body, `<a href="/old">Old Page</a></td><td>2020-01-15</td>`) {
+		t.Errorf("expected stale page report, got:\n%s", body)
+	}
+	if !strings.Contains(body, `href="/strict"`) {
+		t.Errorf("expected page with stricter stale_after in report")
+	}
+	if strings.Contains(body, `href="/fresh"`) || strings.Contains(body, `href="/evergreen"`) {
+		t.Errorf("expected fresh and never-stale pages missing from report")
+	}
+}
+
+func TestPageDates(t *testing.T) {
+	dir := t.TempDir()
+	recent := time.Now().AddDate(0, 0, -10).Format("2006-01-02")
+	os.WriteFile(filepath.Join(dir, "revised.md"), []byte("---\ndate: 2020-01-15\nupdated: "+recent+"\n---\n# Revised\n"), 0o644)
+	os.WriteFile(filepath.Join(dir, "old.md"), []byte("---\ndate: 1 March 2021\nupdated: sometime\n---\n# Old\n"), 0o644)
+
+	s := NewWithAuth(dir, 0, "Docs", "", "", OAuth2Config{}, "", "test")
+	s.Configure(Options{StaleAfter: 180 * 24 * time.Hour, DateFormat: "2. January 2006", DateLocale: "de-AT"})
+	handler := s.Handler()
+	get := func(target string) string {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		return rec.Body.String()
+	}
+
+	body := get("/revised")
+	if !strings.Contains(body, `<span class="meta-item meta-date">15. Januar 2020</span>`) || !strings.Contains(body, `<span class="meta-item meta-updated">Updated `) {
+		t.Errorf("expected formatted dates in header, got:\n%s", body)
+	}
+	if strings.Contains(body, `class="stale-banner"`) {
+		t.Error("page updated recently shows the outdated banner")
+	}
+	body = get("/old")
+	if !strings.Contains(body, "It was last updated on 1. März 2021.") {
+		t.Errorf("expected banner dated by date: when updated: does not parse, got:\n%s", body)
+	}
+	if !strings.Contains(body, `<span class="meta-item meta-updated">Updated sometime</span>`) {
+		t.Error("unparsable date not shown as written")
+	}
+	if sitemap := get("/sitemap.xml"); !strings.Contains(sitemap, "<lastmod>"+recent+"</lastmod>") {
+		t.Errorf("sitemap does not date the page by updated:, got:\n%s", sitemap)
+	}
+}
@@ -0,0 +1,49 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"path/filepath"
+	"strings"
+)
+
+// homeCandidates are the top-level documents that replace the generated
+// index as landing page, in order of preference.
+var homeCandidates = []string{"index", "home"}
+
+// homeDocument returns the route (without leading slash) of the curated
+// landing page, or an empty string when the file tree index should be shown.
+func (s *Server) homeDocument() string {
+	if s.options.Home != "" {
+		route := filepath.ToSlash(strings.TrimPrefix(s.options.Home, "/"))
+		route = strings.TrimSuffix(strings.TrimSuffix(route, ".md"), ".MD")
+		return s.prettyPath(route)
+	}
+	for _, name := range homeCandidates {
+		if s.hasDocument(name) {
+			return name
+		}
+	}
+	return ""
+}
+
+// handleHome serves the landing page: the curated home document when one
+// is configured or present, otherwise the file tree index.
+func (s *Server) handleHome(w http.ResponseWriter, r *http.Request) {
+	home := s.homeDocument()
+	if home == "" {
+		s.handleIndex(w, r)
+		return
+	}
+
+	content, err := s.readDocument(home)
+	if errors.Is(err, errTooLarge) {
+		s.handleTooLarge(w, r, err)
+		return
+	}
+	if err != nil {
+		s.handleIndex(w, r)
+		return
+	}
+	s.renderDocument(w, r, home, content)
+}
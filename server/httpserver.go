@@ -0,0 +1,106 @@
+package server
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+)
+
+// Default HTTP server limits. They bound how long a client may hold a
+// connection, so slow or idle clients cannot exhaust the server.
+const (
+	defaultReadHeaderTimeout = 10 * time.Second
+	defaultReadTimeout       = 30 * time.Second
+	defaultWriteTimeout      = 60 * time.Second
+	defaultIdleTimeout       = 120 * time.Second
+	defaultMaxHeaderBytes    = 64 << 10
+)
+
+// HTTPOptions tunes the underlying http.Server. Zero values select the
+// defaults; a negative duration disables that timeout.
+type HTTPOptions struct {
+	// ReadHeaderTimeout limits the time to read request headers.
+	ReadHeaderTimeout time.Duration
+	// ReadTimeout limits the time to read the whole request.
+	ReadTimeout time.Duration
+	// WriteTimeout limits the time to write a response. The MCP SSE stream
+	// is exempt because it is long-lived by design.
+	WriteTimeout time.Duration
+	// IdleTimeout limits how long keep-alive connections stay open.
+	IdleTimeout time.Duration
+	// MaxHeaderBytes caps the size of request headers.
+	MaxHeaderBytes int
+	// TLSCertFile and TLSKeyFile enable HTTPS, which also enables HTTP/2.
+	TLSCertFile string
+	TLSKeyFile  string
+}
+
+// withDefaults fills unset limits with their defaults and turns negative
+// timeouts into zero, which http.Server treats as no timeout.
+func (o HTTPOptions) withDefaults() HTTPOptions {
+	o.ReadHeaderTimeout = durationOrDefault(o.ReadHeaderTimeout, defaultReadHeaderTimeout)
+	o.ReadTimeout = durationOrDefault(o.ReadTimeout, defaultReadTimeout)
+	o.WriteTimeout = durationOrDefault(o.WriteTimeout, defaultWriteTimeout)
+	o.IdleTimeout = durationOrDefault(o.IdleTimeout, defaultIdleTimeout)
+	if o.MaxHeaderBytes <= 0 {
+		o.MaxHeaderBytes = defaultMaxHeaderBytes
+	}
+	return o
+}
+
+// TLSEnabled reports whether HTTPS is configured.
+func (o HTTPOptions) TLSEnabled() bool {
+	return o.TLSCertFile != "" || o.TLSKeyFile != ""
+}
+
+// ValidateHTTPOptions returns an error for an incomplete TLS configuration.
+func ValidateHTTPOptions(o HTTPOptions) error {
+	if o.TLSEnabled() && (o.TLSCertFile == "" || o.TLSKeyFile == "") {
+		return fmt.Errorf("both -tls-cert and -tls-key are required for HTTPS")
+	}
+	return nil
+}
+
+// durationOrDefault returns fallback for zero, zero for negative values,
+// and value otherwise.
+func durationOrDefault(value, fallback time.Duration) time.Duration {
+	if value == 0 {
+		return fallback
+	}
+	if value < 0 {
+		return 0
+	}
+	return value
+}
+
+// newHTTPServer builds the http.Server with the configured limits.
+func newHTTPServer(handler http.Handler, opts HTTPOptions) *http.Server {
+	opts = opts.withDefaults()
+	return &http.Server{
+		Handler:           handler,
+		ReadHeaderTimeout: opts.ReadHeaderTimeout,
+		ReadTimeout:       opts.ReadTimeout,
+		WriteTimeout:      opts.WriteTimeout,
+		IdleTimeout:       opts.IdleTimeout,
+		MaxHeaderBytes:    opts.MaxHeaderBytes,
+	}
+}
+
+// serveListener serves a single listener, over TLS when configured.
+// http.Server negotiates HTTP/2 automatically for TLS connections.
+func serveListener(httpServer *http.Server, l net.Listener, opts HTTPOptions) error {
+	if opts.TLSEnabled() {
+		return httpServer.ServeTLS(l, opts.TLSCertFile, opts.TLSKeyFile)
+	}
+	return httpServer.Serve(l)
+}
+
+// noWriteTimeout lifts the write deadline for long-lived streaming
+// responses such as the MCP SSE endpoint.
+func noWriteTimeout(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = http.NewResponseController(w).SetWriteDeadline(time.Time{})
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,34 @@
+package server
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHTTPOptionsWithDefaults(t *testing.T) {
+	opts := HTTPOptions{WriteTimeout: -time.Second, IdleTimeout: time.Minute}.withDefaults()
+	if opts.ReadHeaderTimeout != defaultReadHeaderTimeout || opts.ReadTimeout != defaultReadTimeout {
+		t.Errorf("expected default read timeouts, got %+v", opts)
+	}
+	if opts.WriteTimeout != 0 {
+		t.Errorf("expected negative write timeout to disable it, got %v", opts.WriteTimeout)
+	}
+	if opts.IdleTimeout != time.Minute {
+		t.Errorf("expected explicit idle timeout to be kept, got %v", opts.IdleTimeout)
+	}
+	if opts.MaxHeaderBytes != defaultMaxHeaderBytes {
+		t.Errorf("expected default max header bytes, got %d", opts.MaxHeaderBytes)
+	}
+}
+
+func TestValidateHTTPOptions(t *testing.T) {
+	if err := ValidateHTTPOptions(HTTPOptions{}); err != nil {
+		t.Errorf("expected plain HTTP to be valid, got %v", err)
+	}
+	if err := ValidateHTTPOptions(HTTPOptions{TLSCertFile: "cert.pem"}); err == nil {
+		t.Error("expected error for certificate without key")
+	}
+	if err := ValidateHTTPOptions(HTTPOptions{TLSCertFile: "cert.pem", TLSKeyFile: "key.pem"}); err != nil {
+		t.Errorf("expected complete TLS config to be valid, got %v", err)
+	}
+}
@@ -0,0 +1,74 @@
+package server
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"gomdoc/jobs"
+	"gomdoc/templates"
+)
+
+// defaultWorkers is the number of background workers when Options.Workers
+// is zero.
+const defaultWorkers = 2
+
+// shutdownTimeout bounds how long Start waits on shutdown for requests to
+// complete and background jobs to drain.
+const shutdownTimeout = 30 * time.Second
+
+// newJobs starts the pool running the background jobs of the server.
+func newJobs(workers int) *jobs.Pool {
+	if workers <= 0 {
+		workers = defaultWorkers
+	}
+	return jobs.New(workers)
+}
+
+// jobName names a background job of the site, telling the sites of
+// virtual hosts, which share the pool, apart.
+func (s *Server) jobName(what string) string {
+	if s.baseDir == "" {
+		return what
+	}
+	return what + " of " + s.baseDir
+}
+
+// queueRebuild brings the search and MCP indexes and the change journal
+// up to date with the content version in the background. Rebuilds asked
+// for while one is waiting are covered by it, so a burst of writes
+// rebuilds once or twice rather than for every file.
+func (s *Server) queueRebuild() {
+	s.jobs.Submit(s.jobName("search index"), func(ctx context.Context) error {
+		return s.syncIndexes()
+	})
+}
+
+// queueAskIndex embeds the passages of the published pages for /ask in
+// the background.
+func (s *Server) queueAskIndex() {
+	s.jobs.Submit(s.jobName("semantic index"), s.buildAskIndex)
+}
+
+// drainJobs waits for the background jobs to finish, canceling those still
+// running when ctx ends.
+func (s *Server) drainJobs(ctx context.Context) {
+	if err := s.jobs.Drain(ctx); err != nil {
+		log.Printf("Warning: background jobs did not finish: %v", err)
+	}
+}
+
+// adminJobs returns the background jobs for the admin page, newest first.
+func (s *Server) adminJobs() []templates.AdminJob {
+	var list []templates.AdminJob
+	for _, job := range s.jobs.Jobs() {
+		list = append(list, templates.AdminJob{
+			Name:     job.Name,
+			State:    string(job.State),
+			Queued:   job.Queued.Format("2006-01-02 15:04:05"),
+			Duration: job.Duration().Round(time.Millisecond).String(),
+			Error:    job.Error,
+		})
+	}
+	return list
+}
@@ -0,0 +1,60 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"gomdoc/jobs"
+)
+
+// waitForJobs waits until the background jobs of s are finished.
+func waitForJobs(t *testing.T, s *Server) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		busy := false
+		for _, job := range s.jobs.Jobs() {
+			busy = busy || job.State == jobs.Queued || job.State == jobs.Running
+		}
+		if !busy {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatal("background jobs did not finish")
+}
+
+func TestBackgroundJobs(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "guide.md"), []byte("# Guide\n"), 0o644)
+	s := NewWithAuth(dir, 0, "Docs", "", "", OAuth2Config{}, "", "test")
+	s.Configure(Options{Workers: 1})
+	handler := s.Handler()
+
+	os.WriteFile(filepath.Join(dir, "added.md"), []byte("# Added\n\nA quokka appears.\n"), 0o644)
+	s.contentChanged()
+	s.jobs.Submit("link check", func(ctx context.Context) error { return errors.New("3 dead links") })
+	waitForJobs(t, s)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/search?q=quokka", nil))
+	if !strings.Contains(rec.Body.String(), `"/added"`) {
+		t.Errorf("expected the rebuilt index to find the new page, got %s", rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/admin", nil))
+	body := rec.Body.String()
+	for _, want := range []string{"Background Jobs", "search index of " + dir, "<td>done</td>", "failed: 3 dead links"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected the admin page to contain %q", want)
+		}
+	}
+}
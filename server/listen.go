@@ -0,0 +1,206 @@
+package server
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+
+	"gomdoc/browser"
+	"gomdoc/mdns"
+)
+
+const (
+	// unixPrefix marks a listen address as a Unix domain socket path.
+	unixPrefix = "unix:"
+	// systemdAddr selects the sockets passed in by systemd socket activation.
+	systemdAddr = "systemd"
+	// systemdFirstFD is the first file descriptor systemd passes (SD_LISTEN_FDS_START).
+	systemdFirstFD = 3
+)
+
+// listenAddrs returns the addresses to listen on. Explicit -listen addresses
+// win; otherwise the server binds the configured host (all interfaces when
+// empty) on its port.
+func (s *Server) listenAddrs() []string {
+	if len(s.options.Listen) > 0 {
+		return s.options.Listen
+	}
+	return []string{net.JoinHostPort(s.options.Host, strconv.Itoa(s.port))}
+}
+
+// openListeners opens a listener for every configured address. On error the
+// listeners opened so far are closed.
+func (s *Server) openListeners() ([]net.Listener, error) {
+	if len(s.options.Listen) == 0 && s.options.PortRetry > 0 {
+		l, err := s.listenWithRetry()
+		if err != nil {
+			return nil, err
+		}
+		return []net.Listener{l}, nil
+	}
+
+	var listeners []net.Listener
+	for _, addr := range s.listenAddrs() {
+		opened, err := listen(addr)
+		if err != nil {
+			closeListeners(listeners)
+			return nil, fmt.Errorf("listen on %s: %w", addr, err)
+		}
+		listeners = append(listeners, opened...)
+	}
+	return listeners, nil
+}
+
+// listenWithRetry listens on the configured port, probing up to PortRetry
+// following ports when it is busy. The error for the configured port is
+// returned when all of them fail.
+func (s *Server) listenWithRetry() (net.Listener, error) {
+	var firstErr error
+	for offset := 0; offset <= s.options.PortRetry; offset++ {
+		port := s.port + offset
+		l, err := net.Listen("tcp", net.JoinHostPort(s.options.Host, strconv.Itoa(port)))
+		if err == nil {
+			if offset > 0 {
+				log.Printf("Port %d is busy, using port %d instead", s.port, port)
+			}
+			return l, nil
+		}
+		if firstErr == nil {
+			firstErr = fmt.Errorf("listen on port %d: %w", port, err)
+		}
+	}
+	return nil, firstErr
+}
+
+// listen opens the listeners for a single address: "host:port",
+// "unix:/path.sock", or "systemd" for socket activation.
+func listen(addr string) ([]net.Listener, error) {
+	if addr == systemdAddr {
+		return systemdListeners()
+	}
+	if path, ok := strings.CutPrefix(addr, unixPrefix); ok {
+		l, err := listenUnix(path)
+		if err != nil {
+			return nil, err
+		}
+		return []net.Listener{l}, nil
+	}
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	return []net.Listener{l}, nil
+}
+
+// listenUnix listens on a Unix domain socket, removing a stale socket left
+// behind by a previous run.
+func listenUnix(path string) (net.Listener, error) {
+	if path == "" {
+		return nil, fmt.Errorf("missing socket path")
+	}
+	if info, err := os.Lstat(path); err == nil && info.Mode()&os.ModeSocket != 0 {
+		if err := os.Remove(path); err != nil {
+			return nil, err
+		}
+	}
+	return net.Listen("unix", path)
+}
+
+// systemdListeners returns the sockets passed by systemd socket activation,
+// as announced by the LISTEN_PID and LISTEN_FDS environment variables.
+func systemdListeners() ([]net.Listener, error) {
+	if os.Getenv("LISTEN_PID") != strconv.Itoa(os.Getpid()) {
+		return nil, fmt.Errorf("no sockets passed by systemd")
+	}
+	count, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil || count < 1 {
+		return nil, fmt.Errorf("no sockets passed by systemd")
+	}
+
+	var listeners []net.Listener
+	for fd := systemdFirstFD; fd < systemdFirstFD+count; fd++ {
+		file := os.NewFile(uintptr(fd), "systemd-socket-"+strconv.Itoa(fd))
+		l, err := net.FileListener(file)
+		file.Close()
+		if err != nil {
+			closeListeners(listeners)
+			return nil, err
+		}
+		listeners = append(listeners, l)
+	}
+	return listeners, nil
+}
+
+// closeListeners closes every listener, ignoring errors.
+func closeListeners(listeners []net.Listener) {
+	for _, l := range listeners {
+		l.Close()
+	}
+}
+
+// listenerURL returns a human-readable base URL for a listener, used in
+// startup logs.
+func (s *Server) listenerURL(l net.Listener) string {
+	addr := l.Addr()
+	if addr.Network() == "unix" {
+		return unixPrefix + addr.String()
+	}
+	scheme := "http://"
+	if s.options.HTTP.TLSEnabled() {
+		scheme = "https://"
+	}
+	if tcp, ok := addr.(*net.TCPAddr); ok && tcp.IP.IsUnspecified() {
+		return scheme + "localhost:" + strconv.Itoa(tcp.Port)
+	}
+	return scheme + addr.String()
+}
+
+// announce reports the port picked for -port 0 or by -port-retry, prints
+// the LAN QR codes, and opens the browser when requested, using the first
+// TCP listener.
+func (s *Server) announce(listeners []net.Listener) {
+	if s.options.PrintQR {
+		s.printQRCodes()
+	}
+	for _, l := range listeners {
+		tcp, ok := l.Addr().(*net.TCPAddr)
+		if !ok {
+			continue
+		}
+		if (s.port == 0 || tcp.Port != s.port) && len(s.options.Listen) == 0 {
+			fmt.Printf("Using free port %d\n", tcp.Port)
+		}
+		if s.options.OpenBrowser {
+			if err := browser.Open(s.listenerURL(l)); err != nil {
+				log.Printf("Warning: %v", err)
+			}
+		}
+		return
+	}
+}
+
+// advertise announces the first TCP listener on the LAN via mDNS when
+// enabled. Failures are logged, since discovery is a convenience.
+func (s *Server) advertise(listeners []net.Listener) *mdns.Advertiser {
+	if !s.options.MDNS {
+		return nil
+	}
+	for _, l := range listeners {
+		tcp, ok := l.Addr().(*net.TCPAddr)
+		if !ok || tcp.IP.IsLoopback() {
+			continue
+		}
+		advertiser, err := mdns.Advertise(mdns.Service{Instance: s.title, Port: tcp.Port, TXT: []string{"path=/"}})
+		if err != nil {
+			log.Printf("Warning: mDNS advertisement failed: %v", err)
+			return nil
+		}
+		log.Printf("Advertising %q on the local network via mDNS", s.title)
+		return advertiser
+	}
+	log.Printf("Warning: mDNS advertisement skipped: no TCP listener reachable from the network")
+	return nil
+}
@@ -0,0 +1,81 @@
+package server
+
+import (
+	"net"
+	"path/filepath"
+	"testing"
+)
+
+func TestListenAddrs(t *testing.T) {
+	s := &Server{port: 8080, options: Options{Host: "127.0.0.1"}}
+	if got := s.listenAddrs(); len(got) != 1 || got[0] != "127.0.0.1:8080" {
+		t.Errorf("expected host and port, got %v", got)
+	}
+
+	s.options.Listen = []string{":9000", "unix:/tmp/gomdoc.sock"}
+	if got := s.listenAddrs(); len(got) != 2 || got[1] != "unix:/tmp/gomdoc.sock" {
+		t.Errorf("expected explicit listen addresses, got %v", got)
+	}
+}
+
+func TestListenUnixSocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "gomdoc.sock")
+	listeners, err := listen("unix:" + path)
+	if err != nil {
+		t.Fatalf("listen failed: %v", err)
+	}
+	s := &Server{}
+	if got := s.listenerURL(listeners[0]); got != "unix:"+path {
+		t.Errorf("unexpected listener URL %q", got)
+	}
+
+	conn, err := net.Dial("unix", path)
+	if err != nil {
+		t.Fatalf("dial failed: %v", err)
+	}
+	conn.Close()
+	closeListeners(listeners)
+}
+
+func TestOpenListenersMultiple(t *testing.T) {
+	s := &Server{options: Options{Listen: []string{"127.0.0.1:0", "127.0.0.1:0"}}}
+	listeners, err := s.openListeners()
+	if err != nil {
+		t.Fatalf("openListeners failed: %v", err)
+	}
+	defer closeListeners(listeners)
+	if len(listeners) != 2 {
+		t.Errorf("expected 2 listeners, got %d", len(listeners))
+	}
+}
+
+func TestListenSystemdWithoutSockets(t *testing.T) {
+	t.Setenv("LISTEN_PID", "")
+	if _, err := listen("systemd"); err == nil {
+		t.Error("expected error without systemd sockets")
+	}
+}
+
+func TestOpenListenersRetriesBusyPort(t *testing.T) {
+	busy, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen failed: %v", err)
+	}
+	defer busy.Close()
+	port := busy.Addr().(*net.TCPAddr).Port
+
+	s := &Server{port: port, options: Options{Host: "127.0.0.1", PortRetry: 5}}
+	listeners, err := s.openListeners()
+	if err != nil {
+		t.Skipf("no free port after %d: %v", port, err)
+	}
+	defer closeListeners(listeners)
+	if got := listeners[0].Addr().(*net.TCPAddr).Port; got <= port || got > port+5 {
+		t.Errorf("expected a port after %d, got %d", port, got)
+	}
+
+	s.options.PortRetry = 0
+	if _, err := s.openListeners(); err == nil {
+		t.Error("expected busy port to fail without retries")
+	}
+}
@@ -0,0 +1,133 @@
+package server
+
+import (
+	"fmt"
+	"html/template"
+	"log"
+	"net/http"
+	"path"
+	"strings"
+
+	"gomdoc/scanner"
+	"gomdoc/templates"
+)
+
+// handleDirectory renders a listing of a directory's child pages with their
+// summaries, so directory URLs are useful landing pages instead of 404s.
+func (s *Server) handleDirectory(w http.ResponseWriter, r *http.Request, urlPath string) {
+	entries, err := s.visibleEntries(r)
+	if err != nil {
+		http.Error(w, "Error scanning directory", http.StatusInternalServerError)
+		log.Printf("Error scanning directory for listing %s: %v", urlPath, err)
+		return
+	}
+
+	children := childEntries(entries, "/"+urlPath+"/")
+	if len(children) == 0 {
+		s.handleNotFound(w, r)
+		return
+	}
+	offset, limit, err := pageRange(r, listingPageSize)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	items := s.childItems(children, urlPath)
+	if offset > 0 && offset >= len(items) {
+		s.handleNotFound(w, r)
+		return
+	}
+
+	tree := s.buildTree(entries)
+	data := templates.PageData{
+		SiteLayout:  s.layout(),
+		Title:       path.Base(urlPath),
+		Content:     renderChildListing(r, items, urlPath, offset, limit),
+		Path:        r.URL.Path,
+		Canonical:   s.canonicalURL(r.URL.Path),
+		Breadcrumbs: buildBreadcrumbs(r.URL.Path),
+		TreeHTML:    s.renderTree(tree, r.URL.Path),
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := traceTemplate(r, "page", func() error { return templates.RenderPage(w, data) }); err != nil {
+		log.Printf("Error rendering directory listing: %v", err)
+	}
+}
+
+// childEntries returns the visible entries whose route lies below prefix.
+func childEntries(entries []scanner.FileEntry, prefix string) []scanner.FileEntry {
+	var children []scanner.FileEntry
+	for _, entry := range entries {
+		if !entry.Hidden && strings.HasPrefix(entry.URLPath, prefix) {
+			children = append(children, entry)
+		}
+	}
+	return children
+}
+
+// childItem is one entry of a directory listing: a page or a
+// subdirectory.
+type childItem struct {
+	href, title, summary string
+}
+
+// childItems returns the pages and subdirectories directly below dirPath.
+func (s *Server) childItems(children []scanner.FileEntry, dirPath string) []childItem {
+	var items []childItem
+	prefix := "/" + dirPath + "/"
+	seenDirs := make(map[string]bool)
+	for _, entry := range children {
+		rest := strings.TrimPrefix(entry.URLPath, prefix)
+		if sub, _, nested := strings.Cut(rest, "/"); nested {
+			if !seenDirs[sub] {
+				seenDirs[sub] = true
+				items = append(items, childItem{href: prefix + sub, title: sub + "/"})
+			}
+			continue
+		}
+		title, summary := entry.Name, ""
+		if preview, found := s.index.Preview(entry.URLPath); found {
+			title, summary = preview.Title, preview.Summary
+		}
+		items = append(items, childItem{href: entry.URLPath, title: title, summary: summary})
+	}
+	return items
+}
+
+// renderChildListing builds the HTML list of the page of items starting
+// at offset, with links to the neighboring pages when there are more
+// items than limit.
+func renderChildListing(r *http.Request, items []childItem, dirPath string, offset, limit int) template.HTML {
+	var sb strings.Builder
+	sb.WriteString("<h1>" + template.HTMLEscapeString(path.Base(dirPath)) + "</h1>\n")
+	sb.WriteString("<ul class=\"child-listing\">\n")
+	start, end := pageBounds(len(items), offset, limit)
+	for _, item := range items[start:end] {
+		writeChildItem(&sb, item.href, item.title, item.summary)
+	}
+	sb.WriteString("</ul>\n")
+
+	if start > 0 || end < len(items) {
+		sb.WriteString("<nav class=\"pager\">")
+		if start > 0 {
+			sb.WriteString("<a href=\"" + template.HTMLEscapeString(pageURL(r, max(start-limit, 0))) + "\" rel=\"prev\">&larr; Previous</a>")
+		}
+		fmt.Fprintf(&sb, "<span class=\"pager-status\">%d–%d of %d</span>", start+1, end, len(items))
+		if end < len(items) {
+			sb.WriteString("<a href=\"" + template.HTMLEscapeString(pageURL(r, end)) + "\" rel=\"next\">Next &rarr;</a>")
+		}
+		sb.WriteString("</nav>\n")
+	}
+	return template.HTML(sb.String())
+}
+
+// writeChildItem writes one entry of a child-page listing.
+func writeChildItem(sb *strings.Builder, href, title, summary string) {
+	sb.WriteString("<li><a href=\"" + template.HTMLEscapeString(href) + "\">")
+	sb.WriteString(template.HTMLEscapeString(title) + "</a>")
+	if summary != "" {
+		sb.WriteString("<p class=\"child-summary\">" + template.HTMLEscapeString(summary) + "</p>")
+	}
+	sb.WriteString("</li>\n")
+}
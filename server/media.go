@@ -0,0 +1,53 @@
+package server
+
+import (
+	"maps"
+	"path"
+	"slices"
+	"strings"
+
+	"gomdoc/scanner"
+)
+
+// mediaTypes maps the extensions of media pages embed with image syntax to
+// their content types. They are served from anywhere in the documentation
+// tree, since pages reference them by relative path.
+var mediaTypes = map[string]string{
+	".cast": "application/x-asciicast",
+	".mp4":  "video/mp4",
+	".webm": "video/webm",
+	".mp3":  "audio/mpeg",
+}
+
+// mediaType returns the content type of the media file at urlPath, or ""
+// when it is no embeddable media.
+func mediaType(urlPath string) string {
+	return mediaTypes[strings.ToLower(path.Ext(urlPath))]
+}
+
+// mediaAllowed reports whether a media file of size bytes is within
+// Options.MaxMediaBytes.
+func (s *Server) mediaAllowed(size int64) bool {
+	return s.options.MaxMediaBytes <= 0 || size <= s.options.MaxMediaBytes
+}
+
+// mediaEntries lists the media files in the documentation tree as hidden
+// attachments, so export copies them next to the pages embedding them.
+// Files over the size limit are left out.
+func (s *Server) mediaEntries() ([]scanner.FileEntry, error) {
+	var types []string
+	for _, ext := range slices.Sorted(maps.Keys(mediaTypes)) {
+		types = append(types, strings.TrimPrefix(ext, "."))
+	}
+	files, err := scanner.ScanAttachments(s.fsys(), types)
+	if err != nil {
+		return nil, err
+	}
+	files = slices.DeleteFunc(files, func(file scanner.FileEntry) bool {
+		return !s.mediaAllowed(file.Size)
+	})
+	for i := range files {
+		files[i].Hidden = true
+	}
+	return files, nil
+}
@@ -0,0 +1,81 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gomdoc/static"
+)
+
+func TestCastRecordings(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "guide"), 0o755)
+	os.WriteFile(filepath.Join(dir, "guide", "deploy.md"), []byte("# Deploy\n\n![Deploying](deploy.cast)\n"), 0o644)
+	os.WriteFile(filepath.Join(dir, "guide", "deploy.cast"), []byte(`{"version": 2, "width": 80, "height": 24}`+"\n"), 0o644)
+
+	s := NewWithAuth(dir, 0, "Docs", "", "", OAuth2Config{}, "", "test")
+	handler := s.Handler()
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/guide/deploy", nil))
+	if body := rec.Body.String(); !strings.Contains(body, `class="cast-player" data-src="deploy.cast"`) || !strings.Contains(body, `<script src="`+static.URL("gomdoc.js")+`">`) {
+		t.Errorf("expected cast player and the script bundle on the page")
+	}
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, static.URL("gomdoc.js"), nil))
+	if !strings.Contains(rec.Body.String(), "Screen.prototype.write") {
+		t.Errorf("expected the cast player in the script bundle")
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/guide/deploy.cast", nil))
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "application/x-asciicast" {
+		t.Errorf("expected recording served, got %d %q", rec.Code, rec.Header().Get("Content-Type"))
+	}
+
+	entries, err := s.Entries()
+	if err != nil {
+		t.Fatal(err)
+	}
+	found := false
+	for _, entry := range entries {
+		found = found || (entry.URLPath == "/guide/deploy.cast" && entry.Hidden)
+	}
+	if !found {
+		t.Errorf("expected recording among the exported entries, got %v", entries)
+	}
+}
+
+func TestVideoServing(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "intro.mp4"), []byte("0123456789"), 0o644)
+	os.WriteFile(filepath.Join(dir, "huge.webm"), make([]byte, 2<<20), 0o644)
+
+	s := NewWithAuth(dir, 0, "Docs", "", "", OAuth2Config{}, "", "test")
+	s.Configure(Options{MaxMediaBytes: 1 << 20})
+	handler := s.Handler()
+
+	req := httptest.NewRequest(http.MethodGet, "/intro.mp4", nil)
+	req.Header.Set("Range", "bytes=2-5")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusPartialContent || rec.Body.String() != "2345" || rec.Header().Get("Content-Type") != "video/mp4" {
+		t.Errorf("expected seekable video, got %d %q %q", rec.Code, rec.Body.String(), rec.Header().Get("Content-Type"))
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/huge.webm", nil))
+	if rec.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("expected files over the limit refused, got %d", rec.Code)
+	}
+	entries, _ := s.Entries()
+	for _, entry := range entries {
+		if entry.URLPath == "/huge.webm" {
+			t.Errorf("expected files over the limit left out of the export")
+		}
+	}
+}
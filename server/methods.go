@@ -0,0 +1,114 @@
+package server
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// defaultMaxURLLength caps request URLs. Documentation routes are short, so
+// anything longer is almost certainly abuse.
+const defaultMaxURLLength = 4096
+
+// corsMaxAge is how long browsers may cache a CORS preflight response.
+const corsMaxAge = 600
+
+var (
+	// readMethods are the methods accepted by content routes.
+	readMethods = []string{http.MethodGet, http.MethodHead}
+	// apiMethods are the methods accepted by the JSON API.
+	apiMethods = []string{http.MethodGet, http.MethodHead, http.MethodOptions}
+)
+
+// methodNotAllowed responds with 405 and an Allow header listing the
+// accepted methods.
+func methodNotAllowed(w http.ResponseWriter, allowed ...string) {
+	w.Header().Set("Allow", strings.Join(allowed, ", "))
+	http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+}
+
+// readOnly restricts a content route to GET and HEAD.
+func readOnly(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !slices.Contains(readMethods, r.Method) {
+			methodNotAllowed(w, readMethods...)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// apiOnly restricts a JSON API route to GET and HEAD, answering OPTIONS
+// with the accepted methods.
+func apiOnly(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodOptions {
+			w.Header().Set("Allow", strings.Join(apiMethods, ", "))
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		if !slices.Contains(apiMethods, r.Method) {
+			methodNotAllowed(w, apiMethods...)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// limitURLLength rejects requests whose URL exceeds the configured length
+// with 414 URI Too Long.
+func (s *Server) limitURLLength(next http.Handler) http.Handler {
+	limit := s.options.MaxURLLength
+	if limit <= 0 {
+		limit = defaultMaxURLLength
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(r.URL.RequestURI()) > limit {
+			http.Error(w, "URI too long", http.StatusRequestURITooLong)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// corsMiddleware adds CORS headers to JSON API responses for the configured
+// origins. Preflight requests are answered here, before authentication,
+// because browsers send them without credentials.
+func (s *Server) corsMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		if !strings.HasPrefix(r.URL.Path, "/api/") || origin == "" || !s.corsAllowed(origin) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		header := w.Header()
+		header.Add("Vary", "Origin")
+		if slices.Contains(s.options.CORSOrigins, "*") {
+			header.Set("Access-Control-Allow-Origin", "*")
+		} else {
+			header.Set("Access-Control-Allow-Origin", origin)
+			header.Set("Access-Control-Allow-Credentials", "true")
+		}
+
+		if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+			header.Set("Access-Control-Allow-Methods", strings.Join(apiMethods, ", "))
+			header.Set("Access-Control-Allow-Headers", "Authorization, Content-Type")
+			header.Set("Access-Control-Max-Age", strconv.Itoa(corsMaxAge))
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// corsAllowed reports whether origin may call the JSON API.
+func (s *Server) corsAllowed(origin string) bool {
+	for _, allowed := range s.options.CORSOrigins {
+		if allowed == "*" || strings.EqualFold(allowed, origin) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,88 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestReadOnlyRejectsWrites(t *testing.T) {
+	handler := readOnly(func(w http.ResponseWriter, r *http.Request) {})
+
+	rec := httptest.NewRecorder()
+	handler(rec, httptest.NewRequest(http.MethodPost, "/guide", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("expected 405, got %d", rec.Code)
+	}
+	if got := rec.Header().Get("Allow"); got != "GET, HEAD" {
+		t.Errorf("unexpected Allow header %q", got)
+	}
+
+	rec = httptest.NewRecorder()
+	handler(rec, httptest.NewRequest(http.MethodHead, "/guide", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("expected HEAD to pass, got %d", rec.Code)
+	}
+}
+
+func TestAPIOnlyAnswersOptions(t *testing.T) {
+	handler := apiOnly(func(w http.ResponseWriter, r *http.Request) {})
+
+	rec := httptest.NewRecorder()
+	handler(rec, httptest.NewRequest(http.MethodOptions, "/api/search", nil))
+	if rec.Code != http.StatusNoContent || rec.Header().Get("Allow") != "GET, HEAD, OPTIONS" {
+		t.Errorf("expected 204 with Allow header, got %d %q", rec.Code, rec.Header().Get("Allow"))
+	}
+
+	rec = httptest.NewRecorder()
+	handler(rec, httptest.NewRequest(http.MethodDelete, "/api/search", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected 405, got %d", rec.Code)
+	}
+}
+
+func TestLimitURLLength(t *testing.T) {
+	s := &Server{options: Options{MaxURLLength: 32}}
+	handler := s.limitURLLength(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/"+strings.Repeat("a", 40), nil))
+	if rec.Code != http.StatusRequestURITooLong {
+		t.Errorf("expected 414, got %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/short", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("expected short URL to pass, got %d", rec.Code)
+	}
+}
+
+func TestCORSMiddleware(t *testing.T) {
+	s := &Server{options: Options{CORSOrigins: []string{"https://portal.example.com"}}}
+	reached := false
+	handler := s.corsMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reached = true
+	}))
+
+	req := httptest.NewRequest(http.MethodOptions, "/api/search", nil)
+	req.Header.Set("Origin", "https://portal.example.com")
+	req.Header.Set("Access-Control-Request-Method", http.MethodGet)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNoContent || reached {
+		t.Fatalf("expected preflight to be answered directly, got %d", rec.Code)
+	}
+	if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "https://portal.example.com" {
+		t.Errorf("unexpected allowed origin %q", got)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/search", nil)
+	req.Header.Set("Origin", "https://evil.example.net")
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Header().Get("Access-Control-Allow-Origin") != "" || !reached {
+		t.Errorf("expected unlisted origin to get no CORS headers")
+	}
+}
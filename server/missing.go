@@ -0,0 +1,228 @@
+package server
+
+import (
+	"fmt"
+	"html"
+	"io/fs"
+	"log"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"gomdoc/templates"
+)
+
+// Handling of pages referring to missing files, Options.MissingAssets.
+const (
+	// MissingWarn renders the page with a visible warning in place of
+	// each missing image or player, and above the content for missing
+	// stylesheets, scripts, and bibliographies.
+	MissingWarn = "warn"
+	// MissingFail answers pages with missing files with an error listing
+	// them, so an export fails instead of publishing them.
+	MissingFail = "fail"
+)
+
+// ValidateMissingAssets returns an error unless mode is empty or names a
+// way to handle missing files.
+func ValidateMissingAssets(mode string) error {
+	switch mode {
+	case "", MissingWarn, MissingFail:
+		return nil
+	}
+	return fmt.Errorf("unknown missing asset handling %q (use %s or %s)", mode, MissingWarn, MissingFail)
+}
+
+// missingAsset is a file a page refers to that does not exist.
+type missingAsset struct {
+	// Kind is what the page uses the file as: image, video, audio,
+	// recording, stylesheet, script, or bibliography.
+	Kind string
+	// Ref is the reference as written in the page.
+	Ref string
+}
+
+// String describes the missing file for warnings and errors.
+func (m missingAsset) String() string {
+	return "missing " + m.Kind + " " + m.Ref
+}
+
+// mediaRef matches the elements of rendered pages that load a file: the
+// src of images, video and audio players, and the data-src of terminal
+// recording placeholders.
+var mediaRef = regexp.MustCompile(`<(img|video|audio|span class="cast-player")[^>]*?\s(?:data-)?src="([^"]*)"[^>]*>`)
+
+// mediaElementEnd finds the end of the player element whose start tag
+// mediaRef matched, so a missing player is replaced together with its
+// fallback link.
+var mediaElementEnd = map[string]string{
+	"video":                    "</video>",
+	"audio":                    "</audio>",
+	`span class="cast-player"`: "</a></span>",
+}
+
+// mediaKinds names the kind of file each element of mediaRef loads.
+var mediaKinds = map[string]string{
+	"img":                      "image",
+	"video":                    "video",
+	"audio":                    "audio",
+	`span class="cast-player"`: "recording",
+}
+
+// markMissingAssets finds the images and players of the page at pagePath
+// whose files do not exist. In warn mode each is replaced with a warning,
+// and a warning about the missing files in other, which are not part of
+// the content, goes above it. The page's missing files are recorded for
+// the admin page and returned.
+func (s *Server) markMissingAssets(pagePath string, page []byte, other []missingAsset) ([]byte, []missingAsset) {
+	missing := other
+	var out strings.Builder
+	rest := string(page)
+	for {
+		loc := mediaRef.FindStringSubmatchIndex(rest)
+		if loc == nil {
+			break
+		}
+		element, src := rest[loc[2]:loc[3]], html.UnescapeString(rest[loc[4]:loc[5]])
+		end := loc[1]
+		if closing := mediaElementEnd[element]; closing != "" {
+			if i := strings.Index(rest[end:], closing); i >= 0 {
+				end += i + len(closing)
+			}
+		}
+		out.WriteString(rest[:loc[0]])
+		if s.localFileMissing(pagePath, src) {
+			m := missingAsset{Kind: mediaKinds[element], Ref: src}
+			missing = append(missing, m)
+			out.WriteString(missingWarning("span", m))
+		} else {
+			out.WriteString(rest[loc[0]:end])
+		}
+		rest = rest[end:]
+	}
+	out.WriteString(rest)
+
+	if s.missing.record(pagePath, missing) {
+		log.Printf("Warning: %s: %s", pagePath, joinMissing(missing))
+	}
+	if len(missing) == 0 {
+		return page, nil
+	}
+	if s.options.MissingAssets == MissingFail {
+		return page, missing
+	}
+	var above strings.Builder
+	for _, m := range other {
+		above.WriteString(missingWarning("div", m) + "\n")
+	}
+	return []byte(above.String() + out.String()), missing
+}
+
+// localFileMissing reports whether src, as referenced from the page at
+// pagePath, names a file of the site that does not exist. External URLs,
+// data URLs, and the site's own routes are never missing.
+func (s *Server) localFileMissing(pagePath, src string) bool {
+	u, err := url.Parse(src)
+	if err != nil || u.Scheme != "" || u.Host != "" || u.Path == "" {
+		return false
+	}
+	name := u.Path
+	if !strings.HasPrefix(name, "/") {
+		name = path.Join(path.Dir(pagePath), name)
+	}
+	name = strings.TrimPrefix(path.Clean(name), "/")
+	if name == "" || name == "." || strings.HasPrefix(name, "..") {
+		return false
+	}
+	for _, prefix := range generatedPrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return false
+		}
+	}
+	_, err = fs.Stat(s.fsys(), name)
+	return err != nil
+}
+
+// generatedPrefixes are the folders of routes gomdoc generates, such as
+// its stylesheet and the site download, rather than serving from files.
+var generatedPrefixes = []string{"static/", "api/", "download/"}
+
+// missingWarning renders the warning standing in for a missing file as an
+// element, span inside the content and div above it.
+func missingWarning(element string, m missingAsset) string {
+	return `<` + element + ` class="missing-asset" role="note">Missing ` + m.Kind + `: <code>` +
+		html.EscapeString(m.Ref) + `</code></` + element + `>`
+}
+
+// joinMissing lists missing files for a log line or error.
+func joinMissing(missing []missingAsset) string {
+	parts := make([]string, len(missing))
+	for i, m := range missing {
+		parts[i] = m.String()
+	}
+	return strings.Join(parts, ", ")
+}
+
+// missingAssetsError answers a page with missing files in fail mode.
+func missingAssetsError(w http.ResponseWriter, r *http.Request, pagePath string, missing []missingAsset) {
+	http.Error(w, fmt.Sprintf("%s refers to files that do not exist: %s (request %s)", pagePath, joinMissing(missing), requestIDOf(r)), http.StatusInternalServerError)
+}
+
+// missingReport collects the missing files of every page rendered since
+// the start, for the admin page. A page's entry is replaced whenever it
+// renders again, so fixed pages drop out.
+type missingReport struct {
+	mu    sync.Mutex
+	pages map[string]missingPage
+}
+
+// missingPage is the missing files of a page when it last rendered.
+type missingPage struct {
+	assets []missingAsset
+	seen   time.Time
+}
+
+// newMissingReport returns an empty report.
+func newMissingReport() *missingReport {
+	return &missingReport{pages: make(map[string]missingPage)}
+}
+
+// record replaces the missing files of the page at pagePath, reporting
+// whether there are missing files that differ from the last render, so
+// each is logged once rather than on every view.
+func (m *missingReport) record(pagePath string, missing []missingAsset) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	previous, ok := m.pages[pagePath]
+	if len(missing) == 0 {
+		delete(m.pages, pagePath)
+		return false
+	}
+	m.pages[pagePath] = missingPage{assets: missing, seen: time.Now()}
+	return !ok || !slices.Equal(previous.assets, missing)
+}
+
+// report lists the missing files by page path for the admin page.
+func (m *missingReport) report() []templates.AdminMissingAsset {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var report []templates.AdminMissingAsset
+	for pagePath, page := range m.pages {
+		for _, asset := range page.assets {
+			report = append(report, templates.AdminMissingAsset{
+				Page: pagePath,
+				Kind: asset.Kind,
+				Ref:  asset.Ref,
+				Seen: page.seen.Format("2006-01-02 15:04"),
+			})
+		}
+	}
+	sort.SliceStable(report, func(i, j int) bool { return report[i].Page < report[j].Page })
+	return report
+}
@@ -0,0 +1,79 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMissingAssets(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "guide", "img"), 0o755)
+	os.WriteFile(filepath.Join(dir, "guide", "img", "shot.png"), []byte("png"), 0o644)
+	os.WriteFile(filepath.Join(dir, "guide", "setup.md"), []byte("---\ncss: gone.css\n---\n# Setup\n\n"+
+		"![Shot](img/shot.png) ![Lost](img/lost.png) ![Logo](https://example.com/logo.png)\n\n"+
+		"![Demo](demo.mp4)\n\n![Deploy](/casts/deploy.cast)\n"), 0o644)
+	os.WriteFile(filepath.Join(dir, "fine.md"), []byte("# Fine\n"), 0o644)
+
+	get := func(handler http.Handler, target string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		return rec
+	}
+
+	s := NewWithAuth(dir, 0, "Docs", "", "", OAuth2Config{}, "", "test")
+	handler := s.Handler()
+	rec := get(handler, "/guide/setup")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /guide/setup = %d, want 200 in warn mode", rec.Code)
+	}
+	body := rec.Body.String()
+	for _, want := range []string{
+		`<img src="img/shot.png" alt="Shot">`,
+		`https://example.com/logo.png`,
+		`<span class="missing-asset" role="note">Missing image: <code>img/lost.png</code></span>`,
+		`<span class="missing-asset" role="note">Missing video: <code>demo.mp4</code></span>`,
+		`<span class="missing-asset" role="note">Missing recording: <code>/casts/deploy.cast</code></span>`,
+		`<div class="missing-asset" role="note">Missing stylesheet: <code>gone.css</code></div>`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("page lacks %s", want)
+		}
+	}
+	if strings.Contains(body, "<video") || strings.Contains(body, "cast-player") {
+		t.Errorf("missing players were kept:\n%s", body)
+	}
+
+	admin := get(handler, "/admin").Body.String()
+	for _, want := range []string{"<td><code>img/lost.png</code></td>", "<td><code>gone.css</code></td>"} {
+		if !strings.Contains(admin, want) {
+			t.Errorf("admin page lacks %s", want)
+		}
+	}
+	if strings.Contains(admin, "shot.png") {
+		t.Error("admin page lists an existing image")
+	}
+
+	os.WriteFile(filepath.Join(dir, "guide", "setup.md"), []byte("# Setup\n\n![Shot](img/shot.png)\n"), 0o644)
+	get(handler, "/guide/setup")
+	if admin := get(handler, "/admin").Body.String(); !strings.Contains(admin, "No page rendered since the server started refers to a missing file.") {
+		t.Error("fixed page still listed on the admin page")
+	}
+
+	os.WriteFile(filepath.Join(dir, "guide", "setup.md"), []byte("# Setup\n\n![Lost](img/lost.png)\n"), 0o644)
+	strict := NewWithAuth(dir, 0, "Docs", "", "", OAuth2Config{}, "", "test")
+	strict.Configure(Options{MissingAssets: MissingFail})
+	handler = strict.Handler()
+	if rec := get(handler, "/guide/setup"); rec.Code != http.StatusInternalServerError || !strings.Contains(rec.Body.String(), "missing image img/lost.png") {
+		t.Errorf("fail mode: GET /guide/setup = %d %q", rec.Code, rec.Body.String())
+	}
+	if rec := get(handler, "/fine"); rec.Code != http.StatusOK {
+		t.Errorf("fail mode: GET /fine = %d", rec.Code)
+	}
+	if err := ValidateMissingAssets("ignore"); err == nil {
+		t.Error("ValidateMissingAssets accepted an unknown mode")
+	}
+}
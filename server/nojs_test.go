@@ -0,0 +1,74 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"gomdoc/static"
+)
+
+func TestNoJS(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "assets"), 0o755)
+	os.WriteFile(filepath.Join(dir, "assets", "demo.js"), []byte("console.log(1)"), 0o644)
+	os.WriteFile(filepath.Join(dir, "install.md"), []byte("---\njs: demo.js\n---\n# Install\n\nRun the installer.\n"), 0o644)
+
+	get := func(handler http.Handler, target string) string {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		return rec.Body.String()
+	}
+
+	s := NewWithAuth(dir, 0, "Docs", "", "", OAuth2Config{}, "", "test")
+	s.Configure(Options{NoJS: true})
+	handler := s.Handler()
+	for _, target := range []string{"/install", "/browse", "/search?q=installer", "/missing"} {
+		body := get(handler, target)
+		if strings.Contains(body, "<script") || strings.Contains(body, "onclick=") {
+			t.Errorf("expected no scripts on %s, got:\n%s", target, body)
+		}
+		if strings.Contains(target, "search") || target == "/missing" {
+			continue
+		}
+		if !strings.Contains(body, `<form class="search-box" action="/search" method="get"`) {
+			t.Errorf("expected the search box to submit to the search page on %s", target)
+		}
+	}
+
+	body := get(handler, "/search?q=installer")
+	if !strings.Contains(body, `<a class="search-result" href="/install">`) || !strings.Contains(body, "<mark>installer</mark>") {
+		t.Errorf("expected server-rendered results, got:\n%s", body)
+	}
+	if body := get(handler, "/search?q=nothing-matches"); !strings.Contains(body, "No results found") {
+		t.Errorf("expected a no results message, got:\n%s", body)
+	}
+	if body := get(handler, "/search?q=from:yesterday"); !strings.Contains(body, `class="search-no-results"`) {
+		t.Errorf("expected an invalid filter explained, got:\n%s", body)
+	}
+}
+
+func TestNoInlineScripts(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "index.md"), []byte("---\nlayout: landing\n---\n# Welcome\n"), 0o644)
+	os.WriteFile(filepath.Join(dir, "install.md"), []byte("# Install\n\n```mermaid\ngraph TD; A-->B\n```\n"), 0o644)
+
+	s := NewWithAuth(dir, 0, "Docs", "", "", OAuth2Config{}, "", "test")
+	handler := s.Handler()
+	inline := regexp.MustCompile(`<script>|<script [^>]*>[^<]|\son[a-z]+=`)
+	for _, target := range []string{"/", "/install", "/browse", "/search?q=installer", "/missing", "/admin"} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		body := rec.Body.String()
+		if inline.MatchString(body) {
+			t.Errorf("expected no inline scripts on %s, got:\n%s", target, body)
+		}
+		if !strings.Contains(body, `<script src="`+static.URL("gomdoc.js")+`"></script>`) {
+			t.Errorf("expected the script bundle on %s", target)
+		}
+	}
+}
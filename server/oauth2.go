@@ -39,6 +39,10 @@ type OAuth2Config struct {
 	AllowedDomains []string
 	CookieSecret   string
 	SessionTTL     time.Duration
+	// RolesClaim is the userinfo claim, such as groups or roles, whose
+	// values reader, editor, and admin grant that role. A dotted name
+	// reaches into nested objects.
+	RolesClaim string
 }
 
 type oauth2State struct {
@@ -50,6 +54,7 @@ type oauth2State struct {
 type oauth2Session struct {
 	Email   string `json:"email"`
 	Expires int64  `json:"expires"`
+	Role    Role   `json:"role,omitempty"`
 }
 
 type userInfoResponse struct {
@@ -67,7 +72,8 @@ func (c OAuth2Config) Enabled() bool {
 		c.CookieSecret != "" ||
 		len(c.Scopes) > 0 ||
 		len(c.AllowedEmails) > 0 ||
-		len(c.AllowedDomains) > 0
+		len(c.AllowedDomains) > 0 ||
+		c.RolesClaim != ""
 }
 
 func (c OAuth2Config) withDefaults() OAuth2Config {
@@ -155,7 +161,7 @@ func (s *Server) handleOAuth2Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		methodNotAllowed(w, http.MethodGet)
 		return
 	}
 
@@ -184,7 +190,7 @@ func (s *Server) handleOAuth2Callback(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		methodNotAllowed(w, http.MethodGet)
 		return
 	}
 	if errText := r.URL.Query().Get("error"); errText != "" {
@@ -214,12 +220,13 @@ func (s *Server) handleOAuth2Callback(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	email, err := s.fetchOAuth2Email(r.Context(), token)
+	email, role, err := s.fetchOAuth2User(r.Context(), token)
 	if err != nil {
 		http.Error(w, "OAuth2 userinfo rejected", http.StatusUnauthorized)
 		return
 	}
 	if !s.isAllowedOAuth2Email(email) {
+		s.audit(r, auditEvent{User: email, Action: auditLoginFailed})
 		http.Error(w, "OAuth2 email is not allowed", http.StatusForbidden)
 		return
 	}
@@ -227,7 +234,9 @@ func (s *Server) handleOAuth2Callback(w http.ResponseWriter, r *http.Request) {
 	s.writeSignedCookie(w, oauth2SessionCookie, oauth2Session{
 		Email:   strings.ToLower(strings.TrimSpace(email)),
 		Expires: time.Now().Add(s.oauth2Config.SessionTTL).Unix(),
+		Role:    role,
 	}, s.oauth2Config.SessionTTL)
+	s.audit(r, auditEvent{User: strings.ToLower(strings.TrimSpace(email)), Action: auditLogin})
 	http.Redirect(w, r, sanitizeNext(stored.Next), http.StatusFound)
 }
 
@@ -237,9 +246,12 @@ func (s *Server) handleOAuth2Logout(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	if r.Method != http.MethodGet && r.Method != http.MethodPost {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		methodNotAllowed(w, http.MethodGet, http.MethodPost)
 		return
 	}
+	if s.currentUser(r) != "" {
+		s.audit(r, auditEvent{Action: auditLogout})
+	}
 	s.clearCookie(w, oauth2SessionCookie)
 	http.Redirect(w, r, "/", http.StatusFound)
 }
@@ -271,39 +283,45 @@ func (s *Server) httpClient() *http.Client {
 	return http.DefaultClient
 }
 
-func (s *Server) fetchOAuth2Email(ctx context.Context, token *oauth2.Token) (string, error) {
+// fetchOAuth2User returns the verified email of the signed-in user and the
+// role named in their RolesClaim.
+func (s *Server) fetchOAuth2User(ctx context.Context, token *oauth2.Token) (string, Role, error) {
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.oauth2Config.UserInfoURL, nil)
 	if err != nil {
-		return "", err
+		return "", 0, err
 	}
 	req.Header.Set("Authorization", "Bearer "+token.AccessToken)
 	req.Header.Set("Accept", "application/json")
 
 	resp, err := s.httpClient().Do(req)
 	if err != nil {
-		return "", err
+		return "", 0, err
 	}
 	defer resp.Body.Close()
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return "", fmt.Errorf("userinfo returned %s", resp.Status)
+		return "", 0, fmt.Errorf("userinfo returned %s", resp.Status)
 	}
 	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
 	if err != nil {
-		return "", err
+		return "", 0, err
 	}
 
 	var info userInfoResponse
 	if err := json.Unmarshal(body, &info); err != nil {
-		return "", err
+		return "", 0, err
 	}
 	email := strings.ToLower(strings.TrimSpace(info.Email))
 	if email == "" {
-		return "", errors.New("userinfo response did not include email")
+		return "", 0, errors.New("userinfo response did not include email")
 	}
 	if info.EmailVerified != nil && !*info.EmailVerified {
-		return "", errors.New("userinfo email is not verified")
+		return "", 0, errors.New("userinfo email is not verified")
+	}
+	var role Role
+	if s.oauth2Config.RolesClaim != "" {
+		role = claimRole(body, s.oauth2Config.RolesClaim)
 	}
-	return email, nil
+	return email, role, nil
 }
 
 func (s *Server) isAllowedOAuth2Email(email string) bool {
@@ -391,7 +409,7 @@ func (s *Server) clearCookie(w http.ResponseWriter, name string) {
 }
 
 func wantsHTML(r *http.Request) bool {
-	if r.URL.Path == "/api/search" || strings.HasPrefix(r.URL.Path, "/mcp/") {
+	if strings.HasPrefix(r.URL.Path, "/api/") || strings.HasPrefix(r.URL.Path, "/mcp/") {
 		return false
 	}
 	accept := r.Header.Get("Accept")
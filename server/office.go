@@ -0,0 +1,123 @@
+package server
+
+import (
+	"context"
+	"io/fs"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"gomdoc/office"
+	"gomdoc/scanner"
+	"gomdoc/tracing"
+)
+
+// previewSuffix is appended to an attachment's route for its preview page,
+// e.g. /manuals/setup.docx.html, which static hosts serve as HTML too.
+const previewSuffix = ".html"
+
+// converterTimeout bounds a run of the external office converter.
+const converterTimeout = 30 * time.Second
+
+// converterTypes are the attachment types an external converter previews.
+var converterTypes = []string{"doc", "docx", "xls", "xlsx", "ppt", "pptx", "odt", "ods", "odp"}
+
+// previewCache keeps converted documents until the file changes, since
+// conversion reads and parses the whole document.
+type previewCache struct {
+	mu      sync.Mutex
+	entries map[string]cachedPreview
+}
+
+// cachedPreview is a converted document, the file version it is for, and
+// the content version it was converted at.
+type cachedPreview struct {
+	modTime time.Time
+	size    int64
+	version int64
+	html    string
+}
+
+// newPreviewCache creates an empty preview cache.
+func newPreviewCache() *previewCache {
+	return &previewCache{entries: make(map[string]cachedPreview)}
+}
+
+// previewable reports whether the attachment at name gets an HTML preview:
+// DOCX and XLSX always, other office types with an external converter.
+func (s *Server) previewable(name string) bool {
+	if !s.options.OfficePreview || !scanner.IsAttachmentType(name, s.options.Attachments) {
+		return false
+	}
+	return office.Supported(name) || (s.options.OfficeConverter != "" && scanner.IsAttachmentType(name, converterTypes))
+}
+
+// serveOfficePreview renders the preview page of an office attachment
+// inside the site chrome, with a download link as fallback. It reports
+// false when urlPath is no preview route.
+func (s *Server) serveOfficePreview(w http.ResponseWriter, r *http.Request, urlPath string) bool {
+	name, ok := strings.CutSuffix(urlPath, previewSuffix)
+	if !ok || !s.previewable(name) || strings.HasPrefix(name, ".") || strings.Contains(name, "/.") {
+		return false
+	}
+	info, err := fs.Stat(s.fsys(), name)
+	if err != nil || !info.Mode().IsRegular() {
+		return false
+	}
+
+	body := "<p class=\"file-error\">This document cannot be previewed. Download it to read it.</p>\n"
+	if preview, err := s.officePreview(r.Context(), name, info); err != nil {
+		log.Printf("Warning: previewing %s: %v", name, err)
+	} else {
+		body = "<div class=\"office-preview\">\n" + preview + "</div>\n"
+	}
+	s.renderFilePage(w, r, name, info, "Download", body)
+	return true
+}
+
+// officePreview converts the attachment at name, reusing the cached
+// result while the file is unchanged and no update happened since, which
+// also catches rewrites keeping the size and modification time.
+func (s *Server) officePreview(ctx context.Context, name string, info fs.FileInfo) (html string, err error) {
+	ctx, span := tracing.Start(ctx, "cache.office_preview")
+	span.SetAttribute("gomdoc.file", name)
+	defer func() {
+		span.SetError(err)
+		span.End()
+	}()
+	version := s.content.current.Load()
+	if s.previews != nil {
+		s.previews.mu.Lock()
+		cached, ok := s.previews.entries[name]
+		s.previews.mu.Unlock()
+		if ok && cached.version == version && cached.modTime.Equal(info.ModTime()) && cached.size == info.Size() {
+			span.SetAttribute("gomdoc.cache_hit", true)
+			return cached.html, nil
+		}
+	}
+	span.SetAttribute("gomdoc.cache_hit", false)
+
+	data, err := fs.ReadFile(s.fsys(), name)
+	if err != nil {
+		return "", err
+	}
+	if s.options.OfficeConverter != "" {
+		ctx, cancel := context.WithTimeout(ctx, converterTimeout)
+		html, err = office.Convert(ctx, s.options.OfficeConverter, name, data)
+		cancel()
+	} else {
+		html, err = office.ToHTML(name, data)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	if s.previews != nil {
+		s.previews.mu.Lock()
+		s.previews.entries[name] = cachedPreview{modTime: info.ModTime(), size: info.Size(), version: version, html: html}
+		s.previews.mu.Unlock()
+	}
+	return html, nil
+}
@@ -0,0 +1,64 @@
+package server
+
+import (
+	"archive/zip"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestOfficePreview(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "manuals"), 0o755)
+	os.WriteFile(filepath.Join(dir, "index.md"), []byte("# Home\n"), 0o644)
+	os.WriteFile(filepath.Join(dir, "manuals", "broken.docx"), []byte("not a zip"), 0o644)
+
+	f, err := os.Create(filepath.Join(dir, "manuals", "setup.docx"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw := zip.NewWriter(f)
+	w, _ := zw.Create("word/document.xml")
+	w.Write([]byte(`<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"><w:body><w:p><w:r><w:t>Install the agent</w:t></w:r></w:p></w:body></w:document>`))
+	zw.Close()
+	f.Close()
+
+	get := func(handler http.Handler, target string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		return rec
+	}
+
+	s := NewWithAuth(dir, 0, "Docs", "", "", OAuth2Config{}, "", "test")
+	s.Configure(Options{Attachments: []string{"docx"}})
+	if rec := get(s.Handler(), "/manuals/setup.docx.html"); rec.Code != http.StatusNotFound {
+		t.Errorf("expected previews off by default, got %d", rec.Code)
+	}
+
+	s = NewWithAuth(dir, 0, "Docs", "", "", OAuth2Config{}, "", "test")
+	s.Configure(Options{Attachments: []string{"docx"}, OfficePreview: true})
+	handler := s.Handler()
+
+	rec := get(handler, "/manuals/setup.docx.html")
+	body := rec.Body.String()
+	if rec.Code != http.StatusOK || !strings.Contains(body, "<p>Install the agent</p>") {
+		t.Fatalf("expected document preview, got %d:\n%s", rec.Code, body)
+	}
+	if !strings.Contains(body, `<a href="/manuals/setup.docx" class="nav-btn" download>`) {
+		t.Errorf("expected download link on the preview page")
+	}
+	if !strings.Contains(body, `href="/manuals/setup.docx.html" class="file attachment" data-type="docx"`) {
+		t.Errorf("expected the tree to link the preview, got:\n%s", body)
+	}
+
+	body = get(handler, "/manuals/broken.docx.html").Body.String()
+	if !strings.Contains(body, "cannot be previewed") || !strings.Contains(body, `href="/manuals/broken.docx"`) {
+		t.Errorf("expected download fallback for unreadable documents, got:\n%s", body)
+	}
+	if rec := get(handler, "/manuals/setup.docx"); rec.Code != http.StatusOK || rec.Header().Get("Content-Type") == "text/html; charset=utf-8" {
+		t.Errorf("expected original document still downloadable, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,253 @@
+package server
+
+import (
+	"io/fs"
+	"time"
+
+	"gomdoc/config"
+	"gomdoc/diagram"
+	"gomdoc/renderer"
+	"gomdoc/scanner"
+	"gomdoc/search"
+	"gomdoc/semantic"
+	"gomdoc/templates"
+)
+
+// Options holds optional server features. The zero value matches the
+// behavior of a server created with New.
+type Options struct {
+	// Render configures markdown rendering.
+	Render renderer.Options
+	// Sort configures the ordering of the navigation tree.
+	Sort scanner.SortOptions
+	// Scan configures how files map to names and routes.
+	Scan scanner.ScanOptions
+	// Bibliography is a BibTeX (.bib) or CSL-JSON (.json) file resolving
+	// [@key] citations on every page, relative to the base directory.
+	// Pages can add their own with bibliography: frontmatter.
+	Bibliography string
+	// StaleAfter is the age after which pages show an outdated-page
+	// banner and appear in the stale page report on /admin. Pages override
+	// it with stale_after frontmatter. Zero disables the warnings.
+	StaleAfter time.Duration
+	// DateFormat is the Go time layout of the dates shown on pages, such
+	// as the date: and updated: frontmatter, search.DefaultDateFormat when
+	// empty. DateLocale is the language of their month and weekday names,
+	// English when empty.
+	DateFormat string
+	DateLocale string
+	// GitAuthors lists pages without author frontmatter on the author
+	// pages under the git author of most of their lines. It needs a local
+	// base directory in a git work tree.
+	GitAuthors bool
+	// Database is the file storing per-user data such as bookmarks. When
+	// empty, the data is kept in memory and lost on restart.
+	Database string
+	// Source serves the documentation from a file system other than the
+	// base directory, such as an archive, an object storage bucket, or an
+	// embed.FS compiled into the program. See source.Open.
+	Source fs.FS
+	// SourceRefresh is how often a Source that can reload its listing,
+	// like object storage, is refreshed and the indexes rebuilt. Zero
+	// disables refreshing.
+	SourceRefresh time.Duration
+	// Attachments lists the extensions, without the dot, of non-markdown
+	// files listed in the navigation tree as downloads and served as they
+	// are, e.g. pdf and zip. Empty keeps other files unreachable.
+	// scanner.DefaultAttachmentTypes is a sensible list.
+	Attachments []string
+	// FileTypes overrides the Content-Type and Content-Disposition of
+	// served files by lowercase extension, from the types: section of
+	// gomdoc.yml, e.g. to download .drawio files instead of showing them.
+	// Code files keep their plain text type.
+	FileTypes map[string]config.FileType
+	// OfflineDownload serves the site exported to static HTML as a zip
+	// archive at /download/site.zip, linked from the file index.
+	OfflineDownload bool
+	// MaxMediaBytes limits the size of video, audio, and terminal
+	// recordings served for embedding; larger files answer 413. Zero
+	// means no limit.
+	MaxMediaBytes int64
+	// SafeMode stops pages from running their own scripts: js: frontmatter
+	// is ignored, scripts in the assets folder are not served, and scripts
+	// and event handlers are stripped from SVG attachments and inline SVG.
+	SafeMode bool
+	// NoJS serves pages that work without JavaScript, for browsers that
+	// block it: no scripts or controls that need them, the folders around
+	// the current page open in the tree, and search results rendered on
+	// the server. Mermaid diagrams need MermaidCommand.
+	NoJS bool
+	// LazyTree renders the navigation tree without the entries of
+	// collapsed folders, which the browser loads from /api/tree when they
+	// are opened. It is ignored with NoJS.
+	LazyTree bool
+	// CodeFiles lists the extensions of text and source files, such as
+	// yaml or json, listed in the navigation tree and shown as highlighted
+	// read-only pages at their path plus .html. The files themselves are
+	// served as plain text.
+	CodeFiles []string
+	// OfficePreview shows DOCX and XLSX attachments as HTML previews with
+	// a download link, converted in pure Go. It needs the types listed in
+	// Attachments.
+	OfficePreview bool
+	// OfficeConverter is an external command converting office documents
+	// to HTML on standard output, with {file} standing for the document,
+	// e.g. "pandoc {file} -t html". When set, it previews every office
+	// type instead of the built-in converter. Its output is trusted.
+	OfficeConverter string
+	// MermaidCommand pre-renders mermaid code blocks to SVG on the server,
+	// with {input} standing for the diagram source file and {output} for
+	// the SVG it writes, e.g. "mmdc -i {input} -o {output}". Diagrams that
+	// fail to render are drawn in the browser as usual.
+	MermaidCommand string
+	// MermaidCache is the directory pre-rendered diagrams are kept in
+	// between runs. Empty keeps them in memory only.
+	MermaidCache string
+	// Ask answers questions on /ask and /api/ask with the passages
+	// closest in meaning, using embeddings from an OpenAI-compatible API.
+	// The passages are embedded in the background at startup and after
+	// the docs change. Virtual hosts keep their embeddings in the cache
+	// file name followed by a dot and the host name.
+	Ask semantic.Options
+	// SlackSigningSecret verifies that slash commands on
+	// /api/slack/command come from Slack, which lets them past the site's
+	// authentication. Without it, the route needs the same sign-in as
+	// every other.
+	SlackSigningSecret string
+	// Edit enables edit mode for signed-in users: pages can be changed in
+	// the editor at /edit/, and images and attachments dropped onto a page
+	// are uploaded to the assets folder through /api/upload. It needs
+	// authentication and a local base directory.
+	Edit bool
+	// MaxUploadBytes limits the size of files uploaded in edit mode;
+	// larger uploads answer 413 and are logged. Zero selects the default
+	// of 32 MB.
+	MaxUploadBytes int64
+	// Approvals makes page edits from the editor at /edit/ wait as pending
+	// revisions until one of the Reviewers approves them on
+	// /admin/approvals. Without it, edits are published when saved.
+	Approvals bool
+	// Reviewers are the users, basic auth names or OAuth2 addresses, who
+	// may approve and reject edits, along with every admin when Roles are
+	// set. Authors cannot approve their own.
+	Reviewers []string
+	// Roles limit what signed-in users may do: readers read published
+	// pages, editors also see drafts and edit, and admins also open the
+	// admin pages. Without them, every signed-in user may do everything.
+	Roles Roles
+	// WebDAV serves the base directory read-write over WebDAV at /dav/,
+	// so writers can mount it as a network drive. It requires basic
+	// authentication and a local base directory.
+	WebDAV bool
+	// AuditLog is a file to which logins, logouts, uploads, WebDAV
+	// writes, and visits to the admin pages are appended as JSON lines,
+	// with the time and the user name. It needs authentication.
+	AuditLog string
+	// AuditViews also records every page view in the AuditLog. It is
+	// ignored in privacy mode.
+	AuditViews bool
+	// Privacy is the mode for sites that must collect as little personal
+	// data as possible, such as public documentation hosted in the EU:
+	// client addresses are anonymized as AnonymizeIPs says before they
+	// are recorded, page views are never tracked, and traces leave out
+	// user agents.
+	Privacy bool
+	// AnonymizeIPs is how client addresses are anonymized in privacy
+	// mode: AnonymizeTruncate, the default, or AnonymizeHash.
+	AnonymizeIPs string
+	// ShareMaxAge is the longest a signed link from /api/share can be
+	// valid. Signed-in users create them to give someone without an
+	// account read access to one page and its files. Zero disables
+	// sharing, and so does a site without authentication.
+	ShareMaxAge time.Duration
+	// ShareSecret signs the share links. When empty, a random key is
+	// used, and links stop working on restart.
+	ShareSecret string
+	// SMTP is the mail server sending the daily and weekly digests of
+	// changed pages that signed-in users subscribe to on /digest.
+	SMTP SMTPOptions
+	// VirtualHosts serve other documentation trees under their own host
+	// names, each with a separate search index. Requests for other hosts
+	// get the base directory.
+	VirtualHosts []VirtualHost
+	// BaseURL is the public URL of the site, e.g. https://docs.example.com,
+	// used for canonical links and og:url tags. Empty leaves them out.
+	BaseURL string
+	// Home is the markdown file, relative to the base directory, served as
+	// the landing page. When empty, a top-level index.md or home.md is used
+	// if present; otherwise the generated file tree is shown.
+	Home string
+	// Host is the interface to bind, e.g. 127.0.0.1. Empty binds all
+	// interfaces. Ignored when Listen is set.
+	Host string
+	// Listen lists the addresses to serve on: "host:port", ":port",
+	// "unix:/path.sock", or "systemd" for socket activation. When empty the
+	// server listens on Host and its port.
+	Listen []string
+	// PortRetry is the number of following ports to try when the port is
+	// busy. Ignored when Listen is set.
+	PortRetry int
+	// OpenBrowser opens the default browser at the server URL once the
+	// server is listening.
+	OpenBrowser bool
+	// PrintQR prints a QR code of every LAN URL on startup, for opening the
+	// docs on a phone. The codes are always shown on the /admin page.
+	PrintQR bool
+	// MDNS advertises the server on the local network as an _http._tcp
+	// service named after the site title.
+	MDNS bool
+	// HTTP tunes timeouts, header limits, and TLS of the HTTP server.
+	HTTP HTTPOptions
+	// MaxURLLength caps request URLs; longer ones get 414. Zero selects
+	// the default of 4096 bytes.
+	MaxURLLength int
+	// MissingAssets is how pages referring to images, media, stylesheets,
+	// scripts, or bibliographies that do not exist are served:
+	// MissingWarn, the default, or MissingFail.
+	MissingAssets string
+	// RenderTimeout limits how long rendering one page may take, so a
+	// malformed document cannot hold up its readers; slower pages answer
+	// 503. Zero selects the default of 10 seconds, and a negative value
+	// disables the limit.
+	RenderTimeout time.Duration
+	// CORSOrigins lists the origins allowed to call the JSON API from a
+	// browser. "*" allows any origin without credentials.
+	CORSOrigins []string
+	// Pprof serves the runtime profiles of net/http/pprof at
+	// /debug/pprof/, for finding what slows a busy server down. Only
+	// admins may read them, which is everyone without authentication.
+	Pprof bool
+	// Workers is the number of background jobs, such as index rebuilds
+	// after edits or source refreshes, that run at the same time. Zero
+	// selects the default of 2.
+	Workers int
+	// Build holds the commit and build date reported by /api/version.
+	// The version itself is passed to the constructor.
+	Build BuildInfo
+	// Footer customizes the footer text, links, and copyright line.
+	Footer templates.Footer
+}
+
+// Configure applies optional features to the server. Call it before Start.
+func (s *Server) Configure(opts Options) {
+	s.options = opts
+	if opts.Workers > 0 {
+		s.jobs = newJobs(opts.Workers)
+	}
+	s.renderer = renderer.NewWithOptions(opts.Render)
+	s.index = search.NewIndexWithOptions(opts.Scan)
+	s.diagrams = nil
+	if opts.MermaidCommand != "" {
+		s.diagrams = diagram.New(opts.MermaidCommand, opts.MermaidCache)
+	}
+	s.ask = nil
+	if opts.Ask.Enabled() {
+		s.ask = semantic.New(opts.Ask)
+	}
+	s.auditLog = nil
+	if opts.AuditLog != "" {
+		s.auditLog = newAuditLog(opts.AuditLog)
+	}
+	s.shareKey = newShareKey(opts.ShareSecret)
+	s.addressKey = newAddressKey()
+}
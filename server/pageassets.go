@@ -0,0 +1,94 @@
+package server
+
+import (
+	"io/fs"
+	"log"
+	"path"
+	"strings"
+
+	"gomdoc/renderer"
+	"gomdoc/scanner"
+)
+
+// pageAssetTypes are the extensions of stylesheets and scripts pages can
+// include from the assets folder with css: and js: frontmatter.
+var pageAssetTypes = []string{"css", "js"}
+
+// pageAssets resolves the css: and js: frontmatter of the page at urlPath
+// to URLs below the assets folder. Entries are relative to the folder, so
+// css: calculator.css includes /assets/calculator.css. Files outside it,
+// of another type, or missing are skipped and returned as missing, and
+// scripts in safe mode are skipped with a warning. No-JS mode drops
+// scripts silently.
+func (s *Server) pageAssets(urlPath string, fm renderer.Frontmatter) (styles, scripts []string, missing []missingAsset) {
+	for _, name := range fm.CSS {
+		if url, ok := s.pageAsset(urlPath, name, ".css"); ok {
+			styles = append(styles, url)
+		} else {
+			missing = append(missing, missingAsset{Kind: "stylesheet", Ref: name})
+		}
+	}
+	if s.options.NoJS {
+		return styles, nil, missing
+	}
+	if s.options.SafeMode && len(fm.JS) > 0 {
+		log.Printf("Warning: %s: js: frontmatter ignored in safe mode", urlPath)
+		return styles, nil, missing
+	}
+	for _, name := range fm.JS {
+		if url, ok := s.pageAsset(urlPath, name, ".js"); ok {
+			scripts = append(scripts, url)
+		} else {
+			missing = append(missing, missingAsset{Kind: "script", Ref: name})
+		}
+	}
+	return styles, scripts, missing
+}
+
+// pageAsset resolves one css: or js: entry to its URL, reporting false
+// when it does not name an existing file of type ext in the assets folder.
+// Entries that cannot name one are logged; missing files are left to the
+// caller.
+func (s *Server) pageAsset(urlPath, name, ext string) (string, bool) {
+	rel := path.Clean(strings.TrimPrefix(strings.TrimPrefix(name, "/"), uploadFolder+"/"))
+	file := uploadFolder + "/" + rel
+	switch {
+	case strings.HasPrefix(rel, ".") || strings.Contains(rel, "/."):
+		log.Printf("Warning: %s: %q is not a file in %s/", urlPath, name, uploadFolder)
+	case strings.ToLower(path.Ext(rel)) != ext:
+		log.Printf("Warning: %s: %q is not a %s file", urlPath, name, ext)
+	default:
+		if info, err := fs.Stat(s.fsys(), file); err != nil || !info.Mode().IsRegular() {
+			return "", false
+		}
+		return "/" + file, true
+	}
+	return "", false
+}
+
+// isPageAsset reports whether urlPath is a stylesheet or, outside safe
+// mode, a script in the assets folder that pages may include.
+func (s *Server) isPageAsset(urlPath string) bool {
+	if !strings.HasPrefix(urlPath, uploadFolder+"/") {
+		return false
+	}
+	ext := strings.ToLower(path.Ext(urlPath))
+	return ext == ".css" || (ext == ".js" && !s.options.SafeMode)
+}
+
+// pageAssetEntries lists the stylesheets and scripts in the assets folder
+// as hidden attachments, so export copies them next to the pages.
+func (s *Server) pageAssetEntries() ([]scanner.FileEntry, error) {
+	files, err := scanner.ScanAttachments(s.fsys(), pageAssetTypes)
+	if err != nil {
+		return nil, err
+	}
+	var entries []scanner.FileEntry
+	for _, file := range files {
+		if s.isPageAsset(strings.TrimPrefix(file.URLPath, "/")) {
+			file.Hidden = true
+			entries = append(entries, file)
+		}
+	}
+	return entries, nil
+}
@@ -0,0 +1,54 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPageAssets(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "assets"), 0o755)
+	os.WriteFile(filepath.Join(dir, "assets", "demo.css"), []byte(".demo { color: red; }"), 0o644)
+	os.WriteFile(filepath.Join(dir, "assets", "calculator.js"), []byte("console.log(1)"), 0o644)
+	os.WriteFile(filepath.Join(dir, "secret.js"), []byte("leak()"), 0o644)
+	os.WriteFile(filepath.Join(dir, "demo.md"), []byte("---\ncss: demo.css\njs: [assets/calculator.js, ../secret.js, missing.js]\n---\n# Demo\n"), 0o644)
+
+	get := func(handler http.Handler, target string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		return rec
+	}
+
+	handler := NewWithAuth(dir, 0, "Docs", "", "", OAuth2Config{}, "", "test").Handler()
+	body := get(handler, "/demo").Body.String()
+	if !strings.Contains(body, `<link rel="stylesheet" href="/assets/demo.css">`) || !strings.Contains(body, `<script src="/assets/calculator.js" defer></script>`) {
+		t.Errorf("expected page stylesheet and script in the head, got:\n%s", body)
+	}
+	if strings.Contains(body, `secret.js" defer`) || strings.Contains(body, `missing.js" defer`) {
+		t.Errorf("expected files outside assets/ and missing files skipped")
+	}
+	if !strings.Contains(body, `Missing script: <code>missing.js</code>`) || !strings.Contains(body, `Missing script: <code>../secret.js</code>`) {
+		t.Errorf("expected warnings about the skipped scripts, got:\n%s", body)
+	}
+	if rec := get(handler, "/assets/calculator.js"); rec.Code != http.StatusOK || rec.Body.String() != "console.log(1)" {
+		t.Errorf("expected script served, got %d", rec.Code)
+	}
+	if rec := get(handler, "/secret.js"); rec.Code == http.StatusOK && rec.Body.String() == "leak()" {
+		t.Errorf("expected scripts outside assets/ not served")
+	}
+
+	s := NewWithAuth(dir, 0, "Docs", "", "", OAuth2Config{}, "", "test")
+	s.Configure(Options{SafeMode: true})
+	safe := s.Handler()
+	body = get(safe, "/demo").Body.String()
+	if strings.Contains(body, "calculator.js") || !strings.Contains(body, "/assets/demo.css") {
+		t.Errorf("expected only stylesheets in safe mode, got:\n%s", body)
+	}
+	if rec := get(safe, "/assets/calculator.js"); rec.Code == http.StatusOK && rec.Body.String() == "console.log(1)" {
+		t.Errorf("expected scripts not served in safe mode")
+	}
+}
@@ -0,0 +1,68 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// listingPageSize is the number of entries a directory listing shows per
+// page unless the request asks for another limit.
+const listingPageSize = 200
+
+// maxPageSize caps the limit parameter, so that a request cannot undo the
+// pagination of huge directories.
+const maxPageSize = 1000
+
+// pageRange reads the offset and limit parameters of r. Without a limit
+// parameter, limit is def, where 0 means everything.
+func pageRange(r *http.Request, def int) (offset, limit int, err error) {
+	query := r.URL.Query()
+	if value := query.Get("offset"); value != "" {
+		offset, err = strconv.Atoi(value)
+		if err != nil || offset < 0 {
+			return 0, 0, fmt.Errorf("invalid offset %q: use a number of entries to skip", value)
+		}
+	}
+	limit = def
+	if value := query.Get("limit"); value != "" {
+		limit, err = strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > maxPageSize {
+			return 0, 0, fmt.Errorf("invalid limit %q: use a number from 1 to %d", value, maxPageSize)
+		}
+	}
+	return offset, limit, nil
+}
+
+// pageBounds returns the slice bounds of the page of total entries that
+// starts at offset and holds up to limit entries, or all when limit is 0.
+func pageBounds(total, offset, limit int) (start, end int) {
+	start, end = min(offset, total), total
+	if limit > 0 {
+		end = min(start+limit, total)
+	}
+	return start, end
+}
+
+// pageURL returns the URL of r with the offset parameter set, keeping the
+// other parameters such as limit.
+func pageURL(r *http.Request, offset int) string {
+	query := r.URL.Query()
+	if offset > 0 {
+		query.Set("offset", strconv.Itoa(offset))
+	} else {
+		query.Del("offset")
+	}
+	u := url.URL{Path: r.URL.Path, RawQuery: query.Encode()}
+	return u.String()
+}
+
+// setPageHeaders describes a page of a paginated API response: the total
+// number of entries in X-Total-Count, and a Link header to the next page.
+func setPageHeaders(w http.ResponseWriter, r *http.Request, total, end int) {
+	w.Header().Set("X-Total-Count", strconv.Itoa(total))
+	if end < total {
+		w.Header().Set("Link", "<"+pageURL(r, end)+`>; rel="next"`)
+	}
+}
@@ -0,0 +1,20 @@
+package server
+
+import (
+	"net/http"
+	"net/http/pprof"
+)
+
+// pprofPrefix is where the runtime profiles are served with Options.Pprof.
+const pprofPrefix = "/debug/pprof/"
+
+// registerPprof serves the net/http/pprof profiles on mux to admins. CPU
+// profiles and traces run for as long as the seconds parameter asks, so
+// they are exempt from the write timeout.
+func (s *Server) registerPprof(mux *http.ServeMux) {
+	mux.HandleFunc(pprofPrefix, s.requireRole(RoleAdmin, pprof.Index))
+	mux.HandleFunc(pprofPrefix+"cmdline", s.requireRole(RoleAdmin, pprof.Cmdline))
+	mux.HandleFunc(pprofPrefix+"symbol", s.requireRole(RoleAdmin, pprof.Symbol))
+	mux.Handle(pprofPrefix+"profile", noWriteTimeout(s.requireRole(RoleAdmin, pprof.Profile)))
+	mux.Handle(pprofPrefix+"trace", noWriteTimeout(s.requireRole(RoleAdmin, pprof.Trace)))
+}
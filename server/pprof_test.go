@@ -0,0 +1,31 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPprof(t *testing.T) {
+	dir := t.TempDir()
+	serve := func(options Options) int {
+		s := NewWithAuth(dir, 0, "Docs", "writer", "secret", OAuth2Config{}, "", "test")
+		s.Configure(options)
+		req := httptest.NewRequest(http.MethodGet, "/debug/pprof/cmdline", nil)
+		req.SetBasicAuth("writer", "secret")
+		rec := httptest.NewRecorder()
+		s.Handler().ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	if code := serve(Options{}); code != http.StatusNotFound {
+		t.Errorf("expected 404 without -pprof, got %d", code)
+	}
+	if code := serve(Options{Pprof: true}); code != http.StatusOK {
+		t.Errorf("expected admins to read profiles, got %d", code)
+	}
+	readers := Roles{Members: map[Role][]string{RoleAdmin: {"someone"}}}
+	if code := serve(Options{Pprof: true, Roles: readers}); code != http.StatusForbidden {
+		t.Errorf("expected 403 for readers, got %d", code)
+	}
+}
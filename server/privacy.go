@@ -0,0 +1,110 @@
+package server
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"regexp"
+)
+
+// Client address anonymization of Options.AnonymizeIPs in privacy mode.
+const (
+	// AnonymizeTruncate zeroes the host part of client addresses: the
+	// last octet of IPv4 addresses and all but the first 48 bits of IPv6
+	// ones.
+	AnonymizeTruncate = "truncate"
+	// AnonymizeHash replaces client addresses with a keyed hash whose key
+	// changes on every start, so events of one client can be linked
+	// within a run without the address being kept.
+	AnonymizeHash = "hash"
+)
+
+// ValidateAnonymizeIPs returns an error unless mode is empty or names a
+// client address anonymization.
+func ValidateAnonymizeIPs(mode string) error {
+	switch mode {
+	case "", AnonymizeTruncate, AnonymizeHash:
+		return nil
+	}
+	return fmt.Errorf("unknown IP anonymization %q (use %s or %s)", mode, AnonymizeTruncate, AnonymizeHash)
+}
+
+// newAddressKey returns the key hashing client addresses, valid until the
+// server stops.
+func newAddressKey() []byte {
+	key := make([]byte, 32)
+	rand.Read(key)
+	return key
+}
+
+// clientAddress returns the address of the client of r as it may be
+// recorded: as is, or anonymized in privacy mode.
+func (s *Server) clientAddress(r *http.Request) string {
+	host := remoteHost(r)
+	if !s.options.Privacy {
+		return host
+	}
+	return s.anonymize(host)
+}
+
+// anonymize returns the privacy mode form of the address host.
+func (s *Server) anonymize(host string) string {
+	if s.options.AnonymizeIPs == AnonymizeHash {
+		return hashAddress(s.addressKey, host)
+	}
+	return truncateAddress(host)
+}
+
+// logAddress matches the client addresses net/http writes to its error
+// log, such as "203.0.113.7:51234" or "[2001:db8::1]:51234".
+var logAddress = regexp.MustCompile(`\[([0-9A-Fa-f.]*:[0-9A-Fa-f:.]*)\](:\d+)?|\b(\d{1,3}(?:\.\d{1,3}){3})(:\d+)?\b`)
+
+// addressScrubber writes the error log of the HTTP server, such as TLS
+// handshake failures, to the standard logger with client addresses
+// anonymized.
+type addressScrubber struct {
+	s *Server
+}
+
+// Write anonymizes the addresses in a log line and passes it on.
+func (w addressScrubber) Write(line []byte) (int, error) {
+	scrubbed := logAddress.ReplaceAllStringFunc(string(line), func(match string) string {
+		m := logAddress.FindStringSubmatch(match)
+		if m[1] != "" {
+			return "[" + w.s.anonymize(m[1]) + "]"
+		}
+		return w.s.anonymize(m[3])
+	})
+	if _, err := io.WriteString(log.Writer(), scrubbed); err != nil {
+		return 0, err
+	}
+	return len(line), nil
+}
+
+// truncateAddress zeroes the host part of an IP address: the last octet
+// of IPv4 and everything after the first 48 bits of IPv6. Anything else,
+// such as a Unix socket peer, becomes "-".
+func truncateAddress(host string) string {
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return "-"
+	}
+	if v4 := ip.To4(); v4 != nil {
+		return v4.Mask(net.CIDRMask(24, 32)).String()
+	}
+	return ip.Mask(net.CIDRMask(48, 128)).String()
+}
+
+// hashAddress returns a short keyed hash standing in for the address
+// host.
+func hashAddress(key []byte, host string) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(host))
+	return "anon-" + hex.EncodeToString(mac.Sum(nil)[:8])
+}
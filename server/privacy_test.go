@@ -0,0 +1,89 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPrivacyAuditLog(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "intro.md"), []byte("# Intro\n"), 0o644)
+	auditFile := filepath.Join(t.TempDir(), "audit.jsonl")
+	s := NewWithAuth(dir, 0, "Docs", "writer", "secret", OAuth2Config{}, "", "test")
+	s.Configure(Options{AuditLog: auditFile, AuditViews: true, Privacy: true})
+	handler := s.Handler()
+
+	for _, page := range []string{"/intro", "/admin"} {
+		req := httptest.NewRequest(http.MethodGet, page, nil)
+		req.RemoteAddr = "203.0.113.77:51234"
+		req.SetBasicAuth("writer", "secret")
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	f, err := os.Open(auditFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var got []string
+	lines := bufio.NewScanner(f)
+	for lines.Scan() {
+		var event auditEvent
+		if err := json.Unmarshal(lines.Bytes(), &event); err != nil {
+			t.Fatalf("invalid line %q: %v", lines.Text(), err)
+		}
+		if event.Remote != "203.0.113.0" {
+			t.Errorf("client address not truncated: %+v", event)
+		}
+		got = append(got, event.Action+" "+event.Path)
+	}
+	if want := []string{"login ", "admin /admin"}; strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("events = %q, want %q (no page views)", got, want)
+	}
+}
+
+func TestAnonymize(t *testing.T) {
+	for _, tc := range []struct{ host, want string }{
+		{"203.0.113.77", "203.0.113.0"},
+		{"2001:db8:abcd:12:1:2:3:4", "2001:db8:abcd::"},
+		{"::ffff:198.51.100.9", "198.51.100.0"},
+		{"@", "-"},
+	} {
+		if got := truncateAddress(tc.host); got != tc.want {
+			t.Errorf("truncateAddress(%q) = %q, want %q", tc.host, got, tc.want)
+		}
+	}
+
+	s := NewWithAuth(t.TempDir(), 0, "Docs", "", "", OAuth2Config{}, "", "test")
+	s.Configure(Options{Privacy: true, AnonymizeIPs: AnonymizeHash})
+	first, again, other := s.anonymize("203.0.113.77"), s.anonymize("203.0.113.77"), s.anonymize("203.0.113.78")
+	if !strings.HasPrefix(first, "anon-") || first != again || first == other || strings.Contains(first, "203.0.113") {
+		t.Errorf("hashed addresses %q, %q, %q", first, again, other)
+	}
+	if err := ValidateAnonymizeIPs("scramble"); err == nil {
+		t.Error("ValidateAnonymizeIPs accepted an unknown mode")
+	}
+}
+
+func TestAddressScrubber(t *testing.T) {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+
+	s := NewWithAuth(t.TempDir(), 0, "Docs", "", "", OAuth2Config{}, "", "test")
+	s.Configure(Options{Privacy: true})
+	logger := log.New(addressScrubber{s}, "", 0)
+	logger.Printf("http: TLS handshake error from 203.0.113.77:51234: EOF")
+	logger.Printf("http: TLS handshake error from [2001:db8:abcd:12::1]:443: EOF")
+	if got, want := buf.String(), "http: TLS handshake error from 203.0.113.0: EOF\nhttp: TLS handshake error from [2001:db8:abcd::]: EOF\n"; got != want {
+		t.Errorf("scrubbed log = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,174 @@
+package server
+
+import (
+	"fmt"
+	"html/template"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"gomdoc/templates"
+
+	"rsc.io/qr"
+)
+
+// qrQuietZone is the blank border, in modules, scanners need around a code.
+const qrQuietZone = 2
+
+// lanURLs returns the URLs under which the first TCP listener is reachable
+// from other machines: one per non-loopback IPv4 address when it listens on
+// all interfaces, or its own address when bound to a LAN interface.
+func (s *Server) lanURLs(listeners []net.Listener) []string {
+	scheme := "http://"
+	if s.options.HTTP.TLSEnabled() {
+		scheme = "https://"
+	}
+	for _, l := range listeners {
+		tcp, ok := l.Addr().(*net.TCPAddr)
+		if !ok {
+			continue
+		}
+		port := strconv.Itoa(tcp.Port)
+		if !tcp.IP.IsUnspecified() {
+			if tcp.IP.IsLoopback() {
+				return nil
+			}
+			return []string{scheme + net.JoinHostPort(tcp.IP.String(), port)}
+		}
+		var urls []string
+		for _, ip := range localIPv4Addrs() {
+			urls = append(urls, scheme+net.JoinHostPort(ip.String(), port))
+		}
+		return urls
+	}
+	return nil
+}
+
+// localIPv4Addrs lists the non-loopback IPv4 addresses of this machine.
+func localIPv4Addrs() []net.IP {
+	addrs, err := net.InterfaceAddrs()
+	if err != nil {
+		return nil
+	}
+	var ips []net.IP
+	for _, addr := range addrs {
+		ipNet, ok := addr.(*net.IPNet)
+		if ok && !ipNet.IP.IsLoopback() && ipNet.IP.To4() != nil {
+			ips = append(ips, ipNet.IP.To4())
+		}
+	}
+	return ips
+}
+
+// printQRCodes prints a terminal QR code for every LAN URL, so the docs can
+// be opened on a phone by scanning the console.
+func (s *Server) printQRCodes() {
+	if len(s.lan) == 0 {
+		log.Printf("Warning: no LAN address to show as QR code; the server is not reachable from the network")
+		return
+	}
+	for _, url := range s.lan {
+		code, err := qrTerminal(url)
+		if err != nil {
+			log.Printf("Warning: %v", err)
+			continue
+		}
+		fmt.Printf("\nScan to open %s\n%s", url, code)
+	}
+}
+
+// qrTerminal renders a QR code with Unicode half blocks, two modules per
+// character row. Light modules and the quiet zone are drawn as blocks, so
+// the code scans on the usual dark terminal background.
+func qrTerminal(text string) (string, error) {
+	code, err := qr.Encode(text, qr.M)
+	if err != nil {
+		return "", fmt.Errorf("encode QR code: %w", err)
+	}
+
+	var sb strings.Builder
+	for y := -qrQuietZone; y < code.Size+qrQuietZone; y += 2 {
+		for x := -qrQuietZone; x < code.Size+qrQuietZone; x++ {
+			top, bottom := code.Black(x, y), code.Black(x, y+1)
+			switch {
+			case top && bottom:
+				sb.WriteString(" ")
+			case top:
+				sb.WriteString("▄")
+			case bottom:
+				sb.WriteString("▀")
+			default:
+				sb.WriteString("█")
+			}
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String(), nil
+}
+
+// qrSVG renders a QR code as an inline SVG with one rect per dark module.
+func qrSVG(text string) (template.HTML, error) {
+	code, err := qr.Encode(text, qr.M)
+	if err != nil {
+		return "", fmt.Errorf("encode QR code: %w", err)
+	}
+	size := code.Size + 2*qrQuietZone
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, `<svg class="qr-code" xmlns="http://www.w3.org/2000/svg" viewBox="0 0 %d %d" shape-rendering="crispEdges">`, size, size)
+	fmt.Fprintf(&sb, `<rect width="%d" height="%d" fill="#fff"/>`, size, size)
+	for y := 0; y < code.Size; y++ {
+		for x := 0; x < code.Size; x++ {
+			if code.Black(x, y) {
+				fmt.Fprintf(&sb, `<rect x="%d" y="%d" width="1" height="1"/>`, x+qrQuietZone, y+qrQuietZone)
+			}
+		}
+	}
+	sb.WriteString(`</svg>`)
+	return template.HTML(sb.String()), nil
+}
+
+// handleAdmin renders the admin page with the server version and a QR code
+// for every LAN URL. When the LAN URLs are unknown, e.g. behind a proxy, the
+// URL of the request itself is shown.
+func (s *Server) handleAdmin(w http.ResponseWriter, r *http.Request) {
+	urls := s.lan
+	if len(urls) == 0 {
+		scheme := "http://"
+		if r.TLS != nil {
+			scheme = "https://"
+		}
+		urls = []string{scheme + r.Host}
+	}
+
+	build := s.buildInfo()
+	data := templates.AdminData{
+		SiteLayout: s.layout(),
+		Commit:     build.Commit,
+		BuildDate:  build.Date,
+		GoVersion:  build.GoVersion,
+		Approvals:  s.approvalsEnabled(),
+		Jobs:       s.adminJobs(),
+		Missing:    s.missing.report(),
+	}
+	if s.options.StaleAfter > 0 {
+		data.StaleAfter = formatAge(s.options.StaleAfter)
+		data.StalePages = s.staleReport()
+	}
+	for _, url := range urls {
+		code, err := qrSVG(url)
+		if err != nil {
+			log.Printf("Warning: %v", err)
+			continue
+		}
+		data.Links = append(data.Links, templates.AdminLink{URL: url, QRCode: code})
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	if err := templates.RenderAdmin(w, data); err != nil {
+		log.Printf("Error rendering admin page: %v", err)
+	}
+}
@@ -0,0 +1,63 @@
+package server
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestLANURLsSkipsLoopback(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen failed: %v", err)
+	}
+	defer l.Close()
+
+	s := &Server{}
+	if got := s.lanURLs([]net.Listener{l}); len(got) != 0 {
+		t.Errorf("expected no LAN URLs for a loopback listener, got %v", got)
+	}
+}
+
+func TestQRTerminal(t *testing.T) {
+	code, err := qrTerminal("http://192.168.1.20:7331")
+	if err != nil {
+		t.Fatalf("qrTerminal failed: %v", err)
+	}
+	lines := strings.Split(strings.TrimSuffix(code, "\n"), "\n")
+	width := len([]rune(lines[0]))
+	// Each character row holds two module rows of a square code.
+	if want := (width + 1) / 2; len(lines) != want {
+		t.Errorf("expected %d rows for width %d, got %d", want, width, len(lines))
+	}
+	if strings.Trim(lines[0], "█") != "" {
+		t.Errorf("expected a light quiet zone in the first row, got %q", lines[0])
+	}
+}
+
+func TestHandleAdmin(t *testing.T) {
+	s := NewWithAuth(t.TempDir(), 0, "Docs", "", "", OAuth2Config{}, "", "test")
+	s.lan = []string{"http://192.168.1.20:7331"}
+
+	rec := httptest.NewRecorder()
+	s.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/admin", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	body := rec.Body.String()
+	if !strings.Contains(body, `<svg class="qr-code"`) || !strings.Contains(body, "http://192.168.1.20:7331") {
+		t.Errorf("expected QR code for the LAN URL, got %s", body)
+	}
+}
+
+func TestHandleAdminFallsBackToRequestHost(t *testing.T) {
+	s := NewWithAuth(t.TempDir(), 0, "Docs", "", "", OAuth2Config{}, "", "test")
+
+	rec := httptest.NewRecorder()
+	s.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "http://docs.example.com/admin", nil))
+	if !strings.Contains(rec.Body.String(), "http://docs.example.com") {
+		t.Errorf("expected the request URL on the admin page, got %s", rec.Body.String())
+	}
+}
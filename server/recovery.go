@@ -0,0 +1,255 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"runtime"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"gomdoc/tracing"
+)
+
+// requestIDHeader carries the ID that ties a response to its log lines.
+// An ID sent by a proxy in front of gomdoc is kept.
+const requestIDHeader = "X-Request-ID"
+
+// defaultRenderTimeout is how long rendering one page may take when
+// Options.RenderTimeout is zero.
+const defaultRenderTimeout = 10 * time.Second
+
+// errRenderTimeout is returned by renderWithin when rendering takes longer
+// than the render timeout.
+var errRenderTimeout = errors.New("rendering took too long")
+
+// requestIDKey is the context key of the request ID.
+type requestIDKey struct{}
+
+// recoverPanics gives every request an ID, sent back in the X-Request-ID
+// header, and turns a panic in next into a 500 response naming the ID,
+// with the stack logged under the same ID, instead of a dropped
+// connection.
+func recoverPanics(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(requestIDHeader)
+		if !validRequestID(id) {
+			id, _ = randomHex(8)
+		}
+		w.Header().Set(requestIDHeader, id)
+		r = r.WithContext(context.WithValue(r.Context(), requestIDKey{}, id))
+		rw := &wroteRecorder{ResponseWriter: w}
+		defer func() {
+			v := recover()
+			if v == nil {
+				return
+			}
+			if v == http.ErrAbortHandler {
+				panic(v)
+			}
+			log.Printf("Panic serving %s %s (request %s): %v\n%s", r.Method, r.URL.Path, id, v, debug.Stack())
+			if !rw.wrote {
+				http.Error(w, "Internal server error (request "+id+")", http.StatusInternalServerError)
+			}
+		}()
+		next.ServeHTTP(rw, r)
+	})
+}
+
+// validRequestID reports whether id, taken from a request header, is safe
+// to log and echo: short, and only letters, digits, dashes, and dots.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > 64 {
+		return false
+	}
+	for _, c := range id {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '.' || c == '_') {
+			return false
+		}
+	}
+	return true
+}
+
+// requestIDOf returns the ID recoverPanics gave r, or "-" outside it.
+func requestIDOf(r *http.Request) string {
+	if id, ok := r.Context().Value(requestIDKey{}).(string); ok {
+		return id
+	}
+	return "-"
+}
+
+// wroteRecorder remembers whether a response was started, so a panic
+// after the headers went out does not write a second status.
+type wroteRecorder struct {
+	http.ResponseWriter
+	wrote bool
+}
+
+// WriteHeader records that the response started before passing it on.
+func (w *wroteRecorder) WriteHeader(status int) {
+	w.wrote = true
+	w.ResponseWriter.WriteHeader(status)
+}
+
+// Write records that the response started before passing it on.
+func (w *wroteRecorder) Write(data []byte) (int, error) {
+	w.wrote = true
+	return w.ResponseWriter.Write(data)
+}
+
+// Flush passes flushes on, so streaming responses such as the MCP event
+// stream keep working.
+func (w *wroteRecorder) Flush() {
+	w.wrote = true
+	_ = http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// Unwrap returns the wrapped writer for http.ResponseController.
+func (w *wroteRecorder) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// maxRenders returns how many pages are rendered at once: two per CPU.
+func maxRenders() int {
+	return 2 * runtime.GOMAXPROCS(0)
+}
+
+// overdueRenders is the set of pages whose render ran out of time and is
+// still running.
+type overdueRenders struct {
+	mu    sync.Mutex
+	pages map[string]*renderTask
+}
+
+// renderTask is one render of a page. finished is guarded by the mutex
+// of the overdueRenders it is started with.
+type renderTask struct {
+	finished bool
+}
+
+// newOverdueRenders returns an empty set.
+func newOverdueRenders() *overdueRenders {
+	return &overdueRenders{pages: make(map[string]*renderTask)}
+}
+
+// running reports whether the render of name is overdue and still
+// running.
+func (o *overdueRenders) running(name string) bool {
+	if o == nil {
+		return false
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.pages[name] != nil
+}
+
+// abandon marks task, a render of name, as overdue unless it finished.
+func (o *overdueRenders) abandon(name string, task *renderTask) {
+	if o == nil {
+		return
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if !task.finished {
+		o.pages[name] = task
+	}
+}
+
+// finish records that task, a render of name, finished, and clears its
+// overdue mark.
+func (o *overdueRenders) finish(name string, task *renderTask) {
+	if o == nil {
+		return
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	task.finished = true
+	if o.pages[name] == task {
+		delete(o.pages, name)
+	}
+}
+
+// renderWithin runs render, which turns the document at name into HTML,
+// for at most the render timeout. A render that runs out of time keeps
+// going in the background, since the renderer cannot be interrupted, but
+// the request is answered with errRenderTimeout, and a panic in render
+// becomes an error, so a malformed document can neither wedge a request
+// nor crash the server. Renders wait for one of the render slots, which
+// they keep until they finish, and a page whose last render is still
+// running overdue answers errRenderTimeout at once, so requests for slow
+// pages cannot pile up renders. The render is traced as a render span of
+// the request in ctx.
+func (s *Server) renderWithin(ctx context.Context, name string, render func() ([]byte, error)) (html []byte, err error) {
+	_, span := tracing.Start(ctx, "render")
+	span.SetAttribute("gomdoc.page", name)
+	defer func() {
+		span.SetError(err)
+		span.End()
+	}()
+
+	if s.overdue.running(name) {
+		return nil, errRenderTimeout
+	}
+	timeout := durationOrDefault(s.options.RenderTimeout, defaultRenderTimeout)
+	var expired <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		expired = timer.C
+	}
+	if s.renderSlots != nil {
+		select {
+		case s.renderSlots <- struct{}{}:
+		case <-expired:
+			log.Printf("Warning: no render slot for %s within %s", name, timeout)
+			return nil, errRenderTimeout
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	type result struct {
+		html []byte
+		err  error
+	}
+	done := make(chan result, 1)
+	task := new(renderTask)
+	go func() {
+		defer func() {
+			if s.renderSlots != nil {
+				<-s.renderSlots
+			}
+			s.overdue.finish(name, task)
+		}()
+		defer func() {
+			if v := recover(); v != nil {
+				log.Printf("Panic rendering %s: %v\n%s", name, v, debug.Stack())
+				done <- result{err: fmt.Errorf("panic rendering %s: %v", name, v)}
+			}
+		}()
+		html, err := render()
+		done <- result{html, err}
+	}()
+
+	select {
+	case res := <-done:
+		return res.html, res.err
+	case <-expired:
+		log.Printf("Warning: rendering %s took longer than %s", name, timeout)
+		s.overdue.abandon(name, task)
+		return nil, errRenderTimeout
+	}
+}
+
+// renderError answers a request whose page failed to render: 503 when it
+// ran out of time, 500 otherwise, naming the request ID for the logs.
+func renderError(w http.ResponseWriter, r *http.Request, err error) {
+	if errors.Is(err, errRenderTimeout) {
+		http.Error(w, "This page took too long to render (request "+requestIDOf(r)+")", http.StatusServiceUnavailable)
+		return
+	}
+	http.Error(w, fmt.Sprintf("Error rendering markdown: %v (request %s)", err, requestIDOf(r)), http.StatusInternalServerError)
+}
@@ -0,0 +1,127 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRecoverPanics(t *testing.T) {
+	handler := recoverPanics(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/late" {
+			w.Write([]byte("partial"))
+		}
+		panic("malformed document")
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/page", nil))
+	id := rec.Header().Get(requestIDHeader)
+	if rec.Code != http.StatusInternalServerError || id == "" || !strings.Contains(rec.Body.String(), id) {
+		t.Errorf("expected a 500 naming request ID %q, got %d %q", id, rec.Code, rec.Body.String())
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/page", nil)
+	req.Header.Set(requestIDHeader, "proxy-42")
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if got := rec.Header().Get(requestIDHeader); got != "proxy-42" {
+		t.Errorf("expected the proxy's request ID to be kept, got %q", got)
+	}
+
+	req.Header.Set(requestIDHeader, "bad id\n")
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if got := rec.Header().Get(requestIDHeader); got == "bad id\n" || got == "" {
+		t.Errorf("expected an invalid request ID to be replaced, got %q", got)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/late", nil))
+	if rec.Code != http.StatusOK || rec.Body.String() != "partial" {
+		t.Errorf("expected a started response to be left alone, got %d %q", rec.Code, rec.Body.String())
+	}
+}
+
+func TestRenderWithin(t *testing.T) {
+	s := NewWithAuth(t.TempDir(), 0, "Docs", "", "", OAuth2Config{}, "", "test")
+	s.Configure(Options{RenderTimeout: 10 * time.Millisecond})
+
+	html, err := s.renderWithin(context.Background(), "/fast", func() ([]byte, error) { return []byte("<p>ok</p>"), nil })
+	if err != nil || string(html) != "<p>ok</p>" {
+		t.Errorf("expected the rendered page, got %q (%v)", html, err)
+	}
+	if _, err := s.renderWithin(context.Background(), "/slow", func() ([]byte, error) {
+		time.Sleep(time.Second)
+		return nil, nil
+	}); !errors.Is(err, errRenderTimeout) {
+		t.Errorf("expected a render timeout, got %v", err)
+	}
+	if _, err := s.renderWithin(context.Background(), "/broken", func() ([]byte, error) { panic("runaway") }); err == nil || !strings.Contains(err.Error(), "runaway") {
+		t.Errorf("expected the panic as an error, got %v", err)
+	}
+
+	var started atomic.Int32
+	slow := func() ([]byte, error) {
+		started.Add(1)
+		time.Sleep(time.Second)
+		return nil, nil
+	}
+	for range 3 {
+		if _, err := s.renderWithin(context.Background(), "/slow", slow); !errors.Is(err, errRenderTimeout) {
+			t.Errorf("expected a render timeout while /slow is overdue, got %v", err)
+		}
+	}
+	if n := started.Load(); n != 0 {
+		t.Errorf("expected no new render while /slow is overdue, got %d", n)
+	}
+
+	limited := NewWithAuth(t.TempDir(), 0, "Docs", "", "", OAuth2Config{}, "", "test")
+	limited.Configure(Options{RenderTimeout: 10 * time.Millisecond})
+	limited.renderSlots = make(chan struct{}, 1)
+	limited.renderWithin(context.Background(), "/slow", slow)
+	if _, err := limited.renderWithin(context.Background(), "/other", slow); !errors.Is(err, errRenderTimeout) {
+		t.Errorf("expected a render timeout with every slot taken, got %v", err)
+	}
+	if n := started.Load(); n != 1 {
+		t.Errorf("expected only the first render to start, got %d", n)
+	}
+
+	rec := httptest.NewRecorder()
+	renderError(rec, httptest.NewRequest(http.MethodGet, "/slow", nil), errRenderTimeout)
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected 503 for a render timeout, got %d", rec.Code)
+	}
+}
+
+func TestRenderWithinClearsOverduePages(t *testing.T) {
+	s := NewWithAuth(t.TempDir(), 0, "Docs", "", "", OAuth2Config{}, "", "test")
+	s.Configure(Options{RenderTimeout: 5 * time.Millisecond})
+
+	// Renders finishing right at the timeout must not stay overdue.
+	for i := range 100 {
+		s.renderWithin(context.Background(), fmt.Sprintf("/page%d", i), func() ([]byte, error) {
+			time.Sleep(5 * time.Millisecond)
+			return nil, nil
+		})
+	}
+	deadline := time.Now().Add(time.Second)
+	for {
+		s.overdue.mu.Lock()
+		stuck := len(s.overdue.pages)
+		s.overdue.mu.Unlock()
+		if stuck == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected every finished render cleared, %d pages still overdue", stuck)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,193 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"gomdoc/clock"
+	"gomdoc/scanner"
+	"gomdoc/search"
+	"gomdoc/tracing"
+)
+
+// scanEntries lists the markdown files using the configured scan options.
+// Pages outside their publication window are hidden from navigation.
+func (s *Server) scanEntries() ([]scanner.FileEntry, error) {
+	entries, err := scanner.ScanFS(s.fsys(), s.options.Scan)
+	if err != nil {
+		return nil, err
+	}
+	for i, entry := range entries {
+		if s.scheduleStatus(entry.URLPath) != search.Published {
+			entries[i].Hidden = true
+		}
+	}
+	return entries, nil
+}
+
+// Entries lists the pages the server currently publishes, leaving out
+// pages before their publish_at or after their expire_at time, followed by
+// the attachments, code files, and their pages when enabled, and the
+// stylesheets, scripts, and media pages can include. The index is built by
+// Handler, so call it first.
+func (s *Server) Entries() ([]scanner.FileEntry, error) {
+	entries, err := s.treeEntries()
+	if err != nil {
+		return nil, err
+	}
+	entries = slices.DeleteFunc(entries, func(entry scanner.FileEntry) bool {
+		return !entry.Attachment && s.scheduleStatus(entry.URLPath) != search.Published
+	})
+	for _, entry := range entries {
+		if entry.Preview != "" {
+			entries = append(entries, scanner.FileEntry{URLPath: entry.Preview, RelPath: entry.RelPath, Attachment: true, Hidden: true})
+		}
+	}
+	assets, err := s.pageAssetEntries()
+	if err != nil {
+		return nil, err
+	}
+	media, err := s.mediaEntries()
+	if err != nil {
+		return nil, err
+	}
+	return append(append(entries, assets...), media...), nil
+}
+
+// source returns the file system the documentation is served from:
+// Options.Source, or the one passed to NewFS. It is nil when the server
+// reads its base directory.
+func (s *Server) source() fs.FS {
+	if s.options.Source != nil {
+		return s.options.Source
+	}
+	return s.files
+}
+
+// Files returns the documentation files the server reads.
+func (s *Server) Files() fs.FS {
+	return s.fsys()
+}
+
+// fsys returns the documentation files: the source when set, otherwise the
+// base directory.
+func (s *Server) fsys() fs.FS {
+	if source := s.source(); source != nil {
+		return source
+	}
+	return os.DirFS(s.baseDir)
+}
+
+// buildIndex builds the search index from the documentation files. Local
+// directories also date pages by their git history and, with GitAuthors,
+// attribute them by it.
+func (s *Server) buildIndex() (err error) {
+	_, span := tracing.Start(context.Background(), "index.build")
+	defer func() {
+		span.SetError(err)
+		span.End()
+	}()
+	if source := s.source(); source != nil {
+		return s.index.BuildFS(source)
+	}
+	s.index.SetGitAuthors(s.options.GitAuthors)
+	return s.index.Build(s.baseDir)
+}
+
+// rebuildIndexes rebuilds the search and MCP indexes after the
+// documentation changed, and queues the semantic index. It returns what
+// failed.
+func (s *Server) rebuildIndexes() error {
+	var errs []error
+	if err := s.buildIndex(); err != nil {
+		errs = append(errs, fmt.Errorf("rebuilding search index: %w", err))
+	}
+	if s.mcp != nil {
+		if err := s.mcp.BuildIndex(); err != nil {
+			errs = append(errs, fmt.Errorf("rebuilding MCP index: %w", err))
+		}
+	}
+	if err := s.recordChanges(); err != nil {
+		errs = append(errs, fmt.Errorf("recording changes: %w", err))
+	}
+	if s.ask != nil {
+		s.queueAskIndex()
+	}
+	return errors.Join(errs...)
+}
+
+// scheduleStatus returns the publication state of the page at pagePath,
+// evaluated now against the index. Pages missing from the index, such as
+// files added after startup, count as published.
+func (s *Server) scheduleStatus(pagePath string) search.ScheduleStatus {
+	schedule, ok := s.index.Schedule(pagePath)
+	if !ok {
+		return search.Published
+	}
+	return schedule.Status(clock.Now())
+}
+
+// prettyPath returns the canonical form of a URL path (without leading
+// slash). With prefix stripping enabled, ordering prefixes are removed.
+func (s *Server) prettyPath(urlPath string) string {
+	if !s.options.Scan.StripNumericPrefix {
+		return urlPath
+	}
+	return scanner.StripNumericPrefixes(urlPath)
+}
+
+// errTooLarge is returned by readDocument for markdown files over the
+// page size limit.
+var errTooLarge = errors.New("over the page size limit")
+
+// readDocument reads the markdown source served at urlPath, trying the
+// lowercase .md extension first, then uppercase .MD. Files over the page
+// size limit are not read, and return an error wrapping errTooLarge.
+func (s *Server) readDocument(urlPath string) ([]byte, error) {
+	relPath := s.sourcePath(urlPath)
+	content, err := s.readMarkdown(relPath + ".md")
+	if err == nil || errors.Is(err, errTooLarge) {
+		return content, err
+	}
+	return s.readMarkdown(relPath + ".MD")
+}
+
+// readMarkdown reads the markdown file name unless it is over the page
+// size limit.
+func (s *Server) readMarkdown(name string) ([]byte, error) {
+	if info, err := fs.Stat(s.fsys(), name); err == nil && s.options.Scan.TooLarge(info.Size()) {
+		return nil, fmt.Errorf("%s is %s: %w", name, scanner.FormatSize(info.Size()), errTooLarge)
+	}
+	return fs.ReadFile(s.fsys(), name)
+}
+
+// hasDocument reports whether a markdown file is served at urlPath, even
+// one too large to render.
+func (s *Server) hasDocument(urlPath string) bool {
+	_, err := s.readDocument(urlPath)
+	return err == nil || errors.Is(err, errTooLarge)
+}
+
+// sourcePath maps a route back to its file path without extension. Routes
+// only differ from file paths when numeric prefixes are stripped.
+func (s *Server) sourcePath(urlPath string) string {
+	if !s.options.Scan.StripNumericPrefix {
+		return urlPath
+	}
+	entries, err := s.scanEntries()
+	if err != nil {
+		return urlPath
+	}
+	for _, entry := range entries {
+		if entry.URLPath == "/"+urlPath {
+			return strings.TrimSuffix(filepath.ToSlash(entry.RelPath), filepath.Ext(entry.RelPath))
+		}
+	}
+	return urlPath
+}
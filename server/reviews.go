@@ -0,0 +1,50 @@
+package server
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"gomdoc/search"
+	"gomdoc/templates"
+)
+
+// handleReviews renders the ownership report: the pages of each owner and
+// the pages whose review_by date has passed.
+func (s *Server) handleReviews(w http.ResponseWriter, r *http.Request) {
+	now := time.Now()
+	report := s.index.Ownership(now)
+
+	data := templates.ReviewsData{
+		SiteLayout: s.layout(),
+		Today:      now.Format("January 2, 2006"),
+	}
+	for _, owner := range report.Owners {
+		group := templates.ReviewOwner{Owner: owner.Owner}
+		for _, page := range owner.Pages {
+			group.Pages = append(group.Pages, reviewPage(page))
+		}
+		data.Owners = append(data.Owners, group)
+	}
+	for _, page := range report.Overdue {
+		data.Overdue = append(data.Overdue, reviewPage(page))
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	if err := templates.RenderReviews(w, data); err != nil {
+		log.Printf("Error rendering review report: %v", err)
+	}
+}
+
+// reviewPage converts a page of the ownership report for the template.
+func reviewPage(page search.OwnedPage) templates.ReviewPage {
+	return templates.ReviewPage{
+		Title:    page.Title,
+		Path:     page.Path,
+		Owner:    page.Owner,
+		ReviewBy: page.ReviewBy,
+		Overdue:  page.Overdue,
+		Invalid:  page.Invalid,
+	}
+}
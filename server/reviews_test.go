@@ -0,0 +1,49 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReviewsReport(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "overdue.md"), []byte("---\ntitle: Overdue Page\nowner: docs-team\nreview_by: 2020-01-01\n---\n# Overdue\n"), 0o644)
+	os.WriteFile(filepath.Join(dir, "later.md"), []byte("---\nowner: docs-team\nreview_by: 2999-01-01\n---\n# Later\n"), 0o644)
+	os.WriteFile(filepath.Join(dir, "orphan.md"), []byte("# Orphan\n"), 0o644)
+
+	handler := NewWithAuth(dir, 0, "Docs", "", "", OAuth2Config{}, "", "test").Handler()
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/admin/reviews", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	body := rec.Body.String()
+	overdue, owners, _ := strings.Cut(body, "Pages per Owner")
+	if !strings.Contains(overdue, `<a href="/overdue">Overdue Page</a></td><td>docs-team</td>`) {
+		t.Errorf("expected overdue page in report, got:\n%s", body)
+	}
+	if strings.Contains(overdue, `href="/later"`) {
+		t.Errorf("expected page due later missing from overdue reviews")
+	}
+	if !strings.Contains(owners, "docs-team (2)") || !strings.Contains(owners, "No owner (1)") {
+		t.Errorf("expected pages grouped by owner, got:\n%s", owners)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/overdue", nil))
+	body = rec.Body.String()
+	if !strings.Contains(body, "Owner: docs-team") || !strings.Contains(body, "Review by 2020-01-01 (overdue)") {
+		t.Errorf("expected owner and overdue review on page, got:\n%s", body)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/later", nil))
+	if strings.Contains(rec.Body.String(), "(overdue)") {
+		t.Errorf("expected review due later not marked overdue")
+	}
+}
@@ -0,0 +1,239 @@
+package server
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"strings"
+
+	"gomdoc/scanner"
+	"gomdoc/search"
+	"gomdoc/tracing"
+)
+
+// Role is what a signed-in user may do. Each role includes the ones
+// before it.
+type Role int
+
+const (
+	// RoleReader reads the published pages.
+	RoleReader Role = iota + 1
+	// RoleEditor also sees drafts, edits pages, uploads files, and
+	// follows the approvals queue.
+	RoleEditor
+	// RoleAdmin also opens the admin pages and reviews edits.
+	RoleAdmin
+)
+
+// roleNames are the names of the roles in flags, group files, and OAuth2
+// claims.
+var roleNames = map[string]Role{"reader": RoleReader, "editor": RoleEditor, "admin": RoleAdmin}
+
+// ParseRole returns the role named reader, editor, or admin.
+func ParseRole(name string) (Role, error) {
+	if role, ok := roleNames[strings.ToLower(strings.TrimSpace(name))]; ok {
+		return role, nil
+	}
+	return 0, fmt.Errorf("unknown role %q, use reader, editor, or admin", name)
+}
+
+// String returns the name of the role.
+func (r Role) String() string {
+	for name, role := range roleNames {
+		if role == r {
+			return name
+		}
+	}
+	return "none"
+}
+
+// Roles assigns roles to signed-in users. Without members and without an
+// OAuth2 roles claim, roles are off and every signed-in user may do
+// everything.
+type Roles struct {
+	// Members lists the users of each role: basic auth names, OAuth2
+	// addresses, or "@example.com" for everyone in a domain.
+	Members map[Role][]string
+	// Default is the role of signed-in users given no other, RoleReader
+	// when zero.
+	Default Role
+}
+
+// member returns the highest role listing user, zero if none does.
+func (r Roles) member(user string) Role {
+	var found Role
+	for role, members := range r.Members {
+		for _, member := range members {
+			if role > found && memberMatches(member, user) {
+				found = role
+			}
+		}
+	}
+	return found
+}
+
+// memberMatches reports whether a member entry names user, ignoring case.
+// Entries starting with @ match every address in the domain.
+func memberMatches(member, user string) bool {
+	if strings.HasPrefix(member, "@") {
+		return strings.HasSuffix(strings.ToLower(user), strings.ToLower(member))
+	}
+	return strings.EqualFold(member, user)
+}
+
+// ReadGroupFile reads role members from a group file in the format used
+// along with htpasswd files: one "group: user user" line per group, with
+// # comments. Groups named reader, editor, and admin grant their role;
+// other groups are ignored.
+func ReadGroupFile(name string) (map[Role][]string, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	members := make(map[Role][]string)
+	lines := bufio.NewScanner(f)
+	for n := 1; lines.Scan(); n++ {
+		line := strings.TrimSpace(lines.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		group, users, ok := strings.Cut(line, ":")
+		if !ok {
+			return nil, fmt.Errorf("%s:%d: expected \"group: user user\"", name, n)
+		}
+		if role, err := ParseRole(group); err == nil {
+			members[role] = append(members[role], strings.Fields(users)...)
+		}
+	}
+	return members, lines.Err()
+}
+
+// rolesEnabled reports whether routes check the role of the user.
+func (s *Server) rolesEnabled() bool {
+	return len(s.options.Roles.Members) > 0 || s.oauth2Config.RolesClaim != ""
+}
+
+// role returns the role of the user signed in with r: the highest of the
+// default role, the configured members, and the roles named in their
+// OAuth2 claim. It is zero for anonymous requests, and RoleAdmin for
+// everyone when roles are off.
+func (s *Server) role(r *http.Request) Role {
+	if !s.rolesEnabled() {
+		return RoleAdmin
+	}
+	user := s.currentUser(r)
+	if user == "" {
+		return 0
+	}
+	role := max(s.options.Roles.Default, RoleReader, s.options.Roles.member(user))
+	if session, ok := s.readOAuth2Session(r); ok && s.authUser == "" {
+		role = max(role, session.Role)
+	}
+	return role
+}
+
+// requireRole refuses requests of users below role.
+func (s *Server) requireRole(role Role, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.role(r) < role {
+			http.Error(w, "Forbidden: this needs the "+role.String()+" role", http.StatusForbidden)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// seesDrafts reports whether the reader of r may see pages with status:
+// draft.
+func (s *Server) seesDrafts(r *http.Request) bool {
+	return s.role(r) >= RoleEditor
+}
+
+// indexFor returns the search index as the reader of r may see it,
+// without drafts for readers who may not see them. Handlers listing or
+// previewing pages use it rather than s.index.
+func (s *Server) indexFor(r *http.Request) *search.Index {
+	if s.seesDrafts(r) {
+		return s.index
+	}
+	return s.index.WithoutDrafts()
+}
+
+// hidesDraft reports whether the page at pagePath is a draft the reader
+// of r may not see. A signed link shows its draft only if the link's
+// creator could see it.
+func (s *Server) hidesDraft(r *http.Request, pagePath string) bool {
+	if s.seesDrafts(r) || !s.index.IsDraft(pagePath) {
+		return false
+	}
+	shared, ok := sharedPageOf(r)
+	return !ok || !shared.draft || shared.path != pagePath
+}
+
+// readPage reads the markdown served at urlPath for the reader of r like
+// readDocument, reporting drafts the reader may not see as missing.
+func (s *Server) readPage(r *http.Request, urlPath string) ([]byte, error) {
+	if s.hidesDraft(r, "/"+urlPath) {
+		return nil, fs.ErrNotExist
+	}
+	return s.readDocument(urlPath)
+}
+
+// visibleEntries returns the tree entries for the reader of r, with drafts
+// hidden from navigation for readers who may not see them.
+func (s *Server) visibleEntries(r *http.Request) ([]scanner.FileEntry, error) {
+	_, span := tracing.Start(r.Context(), "scan")
+	entries, err := s.treeEntries()
+	span.SetAttribute("gomdoc.entries", len(entries))
+	span.SetError(err)
+	span.End()
+	if err != nil {
+		return entries, err
+	}
+	for i, entry := range entries {
+		if !entry.Attachment && s.hidesDraft(r, entry.URLPath) {
+			entries[i].Hidden = true
+		}
+	}
+	return entries, nil
+}
+
+// claimRole returns the highest role named in the claim of an OAuth2
+// userinfo response. The claim is a string or a list of strings, and a
+// dotted name such as realm_access.roles reaches into nested objects.
+func claimRole(body []byte, claim string) Role {
+	var value any
+	if err := json.Unmarshal(body, &value); err != nil {
+		return 0
+	}
+	for _, key := range strings.Split(claim, ".") {
+		object, ok := value.(map[string]any)
+		if !ok {
+			return 0
+		}
+		value = object[key]
+	}
+	var names []string
+	switch v := value.(type) {
+	case string:
+		names = strings.FieldsFunc(v, func(r rune) bool { return r == ',' || r == ' ' })
+	case []any:
+		for _, item := range v {
+			if name, ok := item.(string); ok {
+				names = append(names, name)
+			}
+		}
+	}
+	var found Role
+	for _, name := range names {
+		if role, err := ParseRole(name); err == nil {
+			found = max(found, role)
+		}
+	}
+	return found
+}
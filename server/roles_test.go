@@ -0,0 +1,175 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRoles(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "guide.md"), []byte("# Guide\n\nPublished install notes.\n"), 0o644)
+	os.WriteFile(filepath.Join(dir, "plans.md"), []byte("---\ntitle: Plans\nstatus: draft\n---\n# Plans\n\nDraft install notes.\n"), 0o644)
+
+	serve := func(role Role, target string) *httptest.ResponseRecorder {
+		s := NewWithAuth(dir, 0, "Docs", "writer", "secret", OAuth2Config{}, "", "test")
+		members := map[Role][]string{RoleAdmin: {"someone"}}
+		members[role] = append(members[role], "Writer")
+		s.Configure(Options{Edit: true, Roles: Roles{Members: members}})
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		req.SetBasicAuth("writer", "secret")
+		rec := httptest.NewRecorder()
+		s.Handler().ServeHTTP(rec, req)
+		return rec
+	}
+
+	tests := []struct {
+		role   Role
+		target string
+		status int
+	}{
+		{RoleReader, "/guide", http.StatusOK},
+		{RoleReader, "/plans", http.StatusNotFound},
+		{RoleReader, "/edit/guide", http.StatusForbidden},
+		{RoleReader, "/admin", http.StatusForbidden},
+		{RoleEditor, "/plans", http.StatusOK},
+		{RoleEditor, "/edit/guide", http.StatusOK},
+		{RoleEditor, "/admin", http.StatusForbidden},
+		{RoleAdmin, "/admin", http.StatusOK},
+	}
+	for _, tt := range tests {
+		if rec := serve(tt.role, tt.target); rec.Code != tt.status {
+			t.Errorf("%s %s: expected %d, got %d", tt.role, tt.target, tt.status, rec.Code)
+		}
+	}
+
+	if body := serve(RoleReader, "/api/search?q=install").Body.String(); strings.Contains(body, "/plans") || !strings.Contains(body, "/guide") {
+		t.Errorf("expected readers to find only published pages, got %s", body)
+	}
+	if body := serve(RoleEditor, "/api/search?q=install").Body.String(); !strings.Contains(body, "/plans") {
+		t.Errorf("expected editors to find drafts, got %s", body)
+	}
+	if body := serve(RoleReader, "/guide").Body.String(); strings.Contains(body, `href="/plans"`) || strings.Contains(body, `href="/edit/guide"`) {
+		t.Errorf("expected no draft in the navigation and no Edit button for readers, got\n%s", body)
+	}
+}
+
+func TestDraftsHidden(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "guide.md"), []byte("---\nauthor: Ana\n---\n# Guide\n\nPublished install notes.\n"), 0o644)
+	os.WriteFile(filepath.Join(dir, "plans.md"), []byte("---\ntitle: Plans\nauthor: Drafty\nstatus: draft\n---\n# Plans\n\nDraft install notes.\n"), 0o644)
+
+	serve := func(role Role, target string) *httptest.ResponseRecorder {
+		s := NewWithAuth(dir, 0, "Docs", "writer", "secret", OAuth2Config{}, "", "test")
+		s.Configure(Options{Roles: Roles{Members: map[Role][]string{role: {"writer"}}}})
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		req.SetBasicAuth("writer", "secret")
+		rec := httptest.NewRecorder()
+		s.Handler().ServeHTTP(rec, req)
+		return rec
+	}
+
+	for _, target := range []string{"/api/preview/plans", "/api/corpus", "/api/unfurl?url=/plans", "/authors", "/api/files", "/api/changes", "/sitemap.xml"} {
+		t.Run(target, func(t *testing.T) {
+			rec := serve(RoleReader, target)
+			if body := rec.Body.String(); strings.Contains(body, "/plans") || strings.Contains(body, "Draft") {
+				t.Errorf("expected no draft for readers, got %d %s", rec.Code, body)
+			}
+		})
+	}
+	if body := serve(RoleEditor, "/api/preview/plans").Body.String(); !strings.Contains(body, "Plans") {
+		t.Errorf("expected editors to preview drafts, got %s", body)
+	}
+	if rec := serve(RoleReader, "/authors/drafty"); rec.Code != http.StatusNotFound {
+		t.Errorf("expected no author page for authors of drafts only, got %d", rec.Code)
+	}
+}
+
+func TestShareLinksOfDrafts(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "plans.md"), []byte("---\nstatus: draft\n---\n# Plans\n\nDraft install notes.\n"), 0o644)
+
+	share := func(role Role) *httptest.ResponseRecorder {
+		s := NewWithAuth(dir, 0, "Docs", "writer", "secret", OAuth2Config{}, "", "test")
+		s.Configure(Options{ShareMaxAge: time.Hour, Roles: Roles{Members: map[Role][]string{role: {"writer"}}}})
+		handler := s.Handler()
+		req := httptest.NewRequest(http.MethodGet, "/api/share?path=/plans", nil)
+		req.SetBasicAuth("writer", "secret")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			return rec
+		}
+		var link shareLink
+		json.NewDecoder(rec.Body).Decode(&link)
+		u, _ := url.Parse(link.URL)
+		rec = httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, u.Path, nil))
+		req = httptest.NewRequest(http.MethodGet, "/plans", nil)
+		req.AddCookie(rec.Result().Cookies()[0])
+		rec = httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := share(RoleReader); rec.Code != http.StatusNotFound {
+		t.Errorf("expected readers refused a link to a draft, got %d", rec.Code)
+	}
+	if rec := share(RoleEditor); rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "Draft install notes") {
+		t.Errorf("expected the draft shared by an editor, got %d", rec.Code)
+	}
+}
+
+func TestRolesOff(t *testing.T) {
+	s := NewWithAuth(t.TempDir(), 0, "Docs", "writer", "secret", OAuth2Config{}, "", "test")
+	req := httptest.NewRequest(http.MethodGet, "/admin", nil)
+	req.SetBasicAuth("writer", "secret")
+	if role := s.role(req); role != RoleAdmin {
+		t.Errorf("expected every user to be an admin without roles, got %s", role)
+	}
+}
+
+func TestClaimRole(t *testing.T) {
+	tests := []struct {
+		body  string
+		claim string
+		want  Role
+	}{
+		{`{"groups":["staff","Editor"]}`, "groups", RoleEditor},
+		{`{"roles":"reader admin"}`, "roles", RoleAdmin},
+		{`{"realm_access":{"roles":["editor"]}}`, "realm_access.roles", RoleEditor},
+		{`{"groups":["staff"]}`, "groups", 0},
+		{`{"email":"a@example.com"}`, "groups", 0},
+	}
+	for _, tt := range tests {
+		if got := claimRole([]byte(tt.body), tt.claim); got != tt.want {
+			t.Errorf("claimRole(%s, %s) = %s, want %s", tt.body, tt.claim, got, tt.want)
+		}
+	}
+}
+
+func TestReadGroupFile(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "groups")
+	os.WriteFile(name, []byte("# docs roles\nadmin: alice\neditor: bob @example.com\nstaff: carol\n"), 0o644)
+	members, err := ReadGroupFile(name)
+	if err != nil {
+		t.Fatalf("ReadGroupFile failed: %v", err)
+	}
+	roles := Roles{Members: members}
+	for user, want := range map[string]Role{"alice": RoleAdmin, "bob": RoleEditor, "dana@example.com": RoleEditor, "carol": 0} {
+		if got := roles.member(user); got != want {
+			t.Errorf("%s: expected %s, got %s", user, want, got)
+		}
+	}
+
+	os.WriteFile(name, []byte("admin alice\n"), 0o644)
+	if _, err := ReadGroupFile(name); err == nil {
+		t.Error("expected an error for a line without a colon")
+	}
+}
@@ -0,0 +1,49 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestScheduledPages(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "live.md"), []byte("# Live\n"), 0o644)
+	os.WriteFile(filepath.Join(dir, "future.md"), []byte("---\npublish_at: 2999-01-01 09:00\n---\n# Future\n"), 0o644)
+	os.WriteFile(filepath.Join(dir, "past.md"), []byte("---\nexpire_at: 2000-01-01\n---\n# Past\n"), 0o644)
+
+	s := NewWithAuth(dir, 0, "Docs", "", "", OAuth2Config{}, "", "test")
+	handler := s.Handler()
+
+	for page, want := range map[string]int{"/live": http.StatusOK, "/future": http.StatusNotFound, "/past": http.StatusGone} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, page, nil))
+		if rec.Code != want {
+			t.Errorf("%s: expected status %d, got %d", page, want, rec.Code)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/past", nil))
+	if !strings.Contains(rec.Body.String(), "410 - Page Removed") {
+		t.Errorf("expected removed page message, got:\n%s", rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/browse", nil))
+	body := rec.Body.String()
+	if !strings.Contains(body, `href="/live"`) || strings.Contains(body, `href="/future"`) || strings.Contains(body, `href="/past"`) {
+		t.Errorf("expected only the live page in the tree, got:\n%s", body)
+	}
+
+	entries, err := s.Entries()
+	if err != nil {
+		t.Fatalf("Entries failed: %v", err)
+	}
+	if len(entries) != 1 || entries[0].URLPath != "/live" {
+		t.Errorf("expected only /live in entries, got %+v", entries)
+	}
+}
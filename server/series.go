@@ -0,0 +1,32 @@
+package server
+
+import (
+	"net/http"
+
+	"gomdoc/renderer"
+	"gomdoc/templates"
+)
+
+// seriesNav returns the series navigator of the page at pagePath, or nil
+// when its frontmatter names no series. The parts are the pages with the
+// same series: frontmatter the reader may see, wherever they are in the
+// tree, numbered by their position in reading order.
+func (s *Server) seriesNav(r *http.Request, pagePath string, frontmatter renderer.Frontmatter) *templates.Series {
+	if frontmatter.Series == "" {
+		return nil
+	}
+	parts := s.indexFor(r).Series(frontmatter.Series, true)
+	series := &templates.Series{Name: frontmatter.Series, Total: len(parts)}
+	for i, part := range parts {
+		current := part.Path == pagePath
+		if current {
+			series.Part = i + 1
+		}
+		series.Parts = append(series.Parts, templates.SeriesLink{Title: part.Title, Path: part.Path, Current: current})
+	}
+	if series.Part == 0 {
+		// The page is not indexed, e.g. over the size limit.
+		return nil
+	}
+	return series
+}
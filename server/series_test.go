@@ -0,0 +1,50 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSeriesNavigator(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "advanced"), 0o755)
+	files := map[string]string{
+		"basics.md":         "---\ntitle: Basics\nseries: Go Tour\nseries_part: 1\n---\n# Basics\n",
+		"advanced/chans.md": "---\ntitle: Channels\nseries: Go Tour\nseries_part: 3\n---\n# Channels\n",
+		"types.md":          "---\ntitle: Types\nseries: Go Tour\nseries_part: 2\n---\n# Types\n",
+		"plain.md":          "# Plain\n",
+	}
+	for name, content := range files {
+		os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644)
+	}
+	s := NewWithAuth(dir, 0, "Docs", "", "", OAuth2Config{}, "", "test")
+	handler := s.Handler()
+
+	get := func(target string) string {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		return rec.Body.String()
+	}
+
+	body := get("/types")
+	for _, want := range []string{
+		"Part 2 of 3: <strong>Go Tour</strong>",
+		`<li><a href="/basics">Basics</a></li>`,
+		`<li><span aria-current="page">Types</span></li>`,
+		`<li><a href="/advanced/chans">Channels</a></li>`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected %q in\n%s", want, body)
+		}
+	}
+	if strings.Index(body, "/basics\">Basics") > strings.Index(body, "/advanced/chans\">Channels") {
+		t.Error("expected the parts in series_part order")
+	}
+	if body := get("/plain"); strings.Contains(body, "series-nav") {
+		t.Error("expected no series navigator on pages outside a series")
+	}
+}
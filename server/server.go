@@ -2,25 +2,42 @@
 package server
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"html/template"
+	"io/fs"
 	"log"
+	"net"
 	"net/http"
+	"net/url"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"sort"
 	"strings"
+	"syscall"
+	"time"
 
+	"gomdoc/clock"
+	"gomdoc/diagram"
+	"gomdoc/jobs"
 	"gomdoc/mcpserver"
 	"gomdoc/renderer"
+	"gomdoc/sanitize"
 	"gomdoc/scanner"
 	"gomdoc/search"
+	"gomdoc/semantic"
+	"gomdoc/static"
+	"gomdoc/store"
 	"gomdoc/templates"
 )
 
 // Server is the markdown HTTP server.
 type Server struct {
 	baseDir      string
+	files        fs.FS
 	port         int
 	title        string
 	authUser     string
@@ -31,6 +48,54 @@ type Server struct {
 	version      string
 	renderer     *renderer.Renderer
 	index        *search.Index
+	options      Options
+	// mcp serves the MCP tools; siteHandler creates it.
+	mcp *mcpserver.Server
+	// db holds per-user data such as bookmarks. It is in memory unless
+	// Options.Database names a file, which Start opens.
+	db *store.DB
+	// lan holds the URLs reachable from other machines, set by Start.
+	lan []string
+	// previews caches office documents converted to HTML.
+	previews *previewCache
+	// changes numbers the changes to the published pages for /api/changes.
+	changes *changeJournal
+	// diagrams pre-renders Mermaid diagrams, when Options.MermaidCommand
+	// is set.
+	diagrams *diagram.Renderer
+	// ask answers questions on /ask, when Options.Ask is enabled.
+	ask *semantic.Index
+	// auditLog records authenticated actions, when Options.AuditLog is
+	// set.
+	auditLog *auditLog
+	// shareKey signs the tokens of /share links.
+	shareKey []byte
+	// addressKey hashes client addresses in privacy mode with
+	// AnonymizeHash.
+	addressKey []byte
+	// mailer replaces the SMTP delivery of digests in tests.
+	mailer func(to string, msg []byte) error
+	// jobs runs background work such as index rebuilds. The sites of
+	// virtual hosts share it.
+	jobs *jobs.Pool
+	// content numbers the updates of the documentation tree, so that
+	// nothing built from an older state is served.
+	content *contentVersion
+	// verified caches the last password matching a bcrypt authPass.
+	verified *verifiedPassword
+	// missing collects the missing files of rendered pages for /admin.
+	missing *missingReport
+	// renderSlots bounds the pages rendered at once, including renders
+	// still running after their request timed out. The sites of virtual
+	// hosts share it.
+	renderSlots chan struct{}
+	// overdue holds the pages whose render ran out of time and is still
+	// running, so that requests for them do not start another.
+	overdue *overdueRenders
+	// site is the host of a virtual host's site, empty for the main site.
+	site string
+	// vhosts holds the servers of the virtual hosts by host name.
+	vhosts map[string]*Server
 }
 
 // New creates a new Server instance.
@@ -41,6 +106,7 @@ func New(baseDir string, port int, title, authUser, authPass, mcpToken, version
 }
 
 // NewWithAuth creates a new Server instance with an explicit auth config.
+// authPass is the basic auth password in plain text or as a bcrypt hash.
 func NewWithAuth(baseDir string, port int, title, authUser, authPass string, oauth2Config OAuth2Config, mcpToken, version string) *Server {
 	return &Server{
 		baseDir:      baseDir,
@@ -53,13 +119,107 @@ func NewWithAuth(baseDir string, port int, title, authUser, authPass string, oau
 		version:      version,
 		renderer:     renderer.New(),
 		index:        search.NewIndex(),
+		db:           store.Memory(),
+		previews:     newPreviewCache(),
+		changes:      newChangeJournal(),
+		jobs:         newJobs(0),
+		content:      new(contentVersion),
+		verified:     new(verifiedPassword),
+		missing:      newMissingReport(),
+		renderSlots:  make(chan struct{}, maxRenders()),
+		overdue:      newOverdueRenders(),
 	}
 }
 
+// NewFS creates a Server that serves the documentation in files, such as
+// an archive, an embed.FS, or an fstest.MapFS in tests, instead of a
+// directory on disk.
+func NewFS(files fs.FS, port int, title, authUser, authPass string, oauth2Config OAuth2Config, mcpToken, version string) *Server {
+	s := NewWithAuth("", port, title, authUser, authPass, oauth2Config, mcpToken, version)
+	s.files = files
+	return s
+}
+
 // Start starts the HTTP server.
 func (s *Server) Start() error {
+	if s.options.Database != "" {
+		db, err := store.Open(s.options.Database)
+		if err != nil {
+			return fmt.Errorf("open database: %w", err)
+		}
+		s.db = db
+		log.Printf("Database: %s", db.Path())
+	}
+	handler := s.Handler()
+
+	listeners, err := s.openListeners()
+	if err != nil {
+		return err
+	}
+	for _, l := range listeners {
+		log.Printf("Starting gomdoc on %s", s.listenerURL(l))
+		log.Printf("MCP server available at %s/mcp/", s.listenerURL(l))
+	}
+	s.lan = s.lanURLs(listeners)
+	s.announce(listeners)
+	if advertiser := s.advertise(listeners); advertiser != nil {
+		defer advertiser.Close()
+	}
+	if s.mcpToken != "" {
+		log.Printf("MCP authentication: Bearer token required")
+		log.Printf("MCP token: %s", s.mcpToken)
+	} else {
+		log.Printf("MCP authentication: disabled (use -mcp-token or remove -mcp-no-auth)")
+	}
+	if s.source() == nil {
+		log.Printf("Serving files from: %s", s.baseDir)
+	}
+	if s.options.SourceRefresh > 0 {
+		go s.refreshSource(s.options.SourceRefresh)
+	}
+	if s.digestEnabled() {
+		go s.runDigests()
+		for _, site := range s.vhosts {
+			go site.runDigests()
+		}
+	}
+
+	return s.serve(listeners, handler)
+}
+
+// Handler builds the search indexes and returns the complete HTTP handler
+// with routing, authentication, and request limits. Start serves it over
+// the network; the export command renders pages through it directly.
+func (s *Server) Handler() http.Handler {
+	var handler http.Handler = s.siteHandler()
+	if len(s.options.VirtualHosts) > 0 {
+		handler = s.virtualHostHandler(handler)
+	}
+	handler = cleanPaths(handler)
+	site := handler
+
+	// Wrap with basic auth middleware if credentials are configured
+	if s.authUser != "" {
+		log.Printf("Basic authentication enabled")
+		handler = s.basicAuthMiddleware(handler)
+	} else if s.oauth2Config.Enabled() {
+		log.Printf("OAuth2 authentication enabled")
+		handler = s.oauth2Middleware(handler)
+	}
+	if s.options.SlackSigningSecret != "" {
+		handler = s.slackBypass(handler, site)
+	}
+	if s.sharingEnabled() {
+		handler = s.shareBypass(handler, site)
+	}
+	return recoverPanics(s.traceRequests(s.limitURLLength(s.corsMiddleware(handler))))
+}
+
+// siteHandler builds the search indexes of the base directory and returns
+// the routes serving it, kept up to date with the content version.
+func (s *Server) siteHandler() http.Handler {
 	// Build search index at startup
-	if err := s.index.Build(s.baseDir); err != nil {
+	if err := s.buildIndex(); err != nil {
 		log.Printf("Warning: failed to build search index: %v", err)
 	} else {
 		log.Printf("Search index built successfully")
@@ -67,9 +227,13 @@ func (s *Server) Start() error {
 
 	// Set up MCP server on the same port
 	mcpSrv := mcpserver.New(s.baseDir, s.version)
+	if source := s.source(); source != nil {
+		mcpSrv = mcpserver.NewFS(source, s.version)
+	}
 	if err := mcpSrv.BuildIndex(); err != nil {
 		log.Printf("Warning: failed to build MCP index: %v", err)
 	}
+	s.mcp = mcpSrv
 
 	mux := http.NewServeMux()
 
@@ -78,43 +242,118 @@ func (s *Server) Start() error {
 	if s.mcpToken != "" {
 		mcpHandler = s.bearerAuthMiddleware(mcpHandler)
 	}
-	mux.Handle("/mcp/", mcpHandler)
+	mux.Handle("/mcp/", noWriteTimeout(mcpHandler))
 	mux.HandleFunc("/oauth2/login", s.handleOAuth2Login)
 	mux.HandleFunc("/oauth2/callback", s.handleOAuth2Callback)
 	mux.HandleFunc("/oauth2/logout", s.handleOAuth2Logout)
-	mux.HandleFunc("/", s.handleRequest)
-	mux.HandleFunc("/api/search", s.handleSearch)
-	mux.HandleFunc("/static/", s.handleStatic)
-
-	addr := fmt.Sprintf(":%d", s.port)
-	log.Printf("Starting gomdoc on http://localhost%s", addr)
-	log.Printf("MCP server available at http://localhost%s/mcp/", addr)
-	if s.mcpToken != "" {
-		log.Printf("MCP authentication: Bearer token required")
-		log.Printf("MCP token: %s", s.mcpToken)
-	} else {
-		log.Printf("MCP authentication: disabled (use -mcp-token or remove -mcp-no-auth)")
+	mux.HandleFunc("/", readOnly(s.auditViews(s.handleRequest)))
+	mux.HandleFunc("/api/search", apiOnly(s.handleSearch))
+	mux.HandleFunc("/api/preview/", apiOnly(s.handlePreview))
+	mux.HandleFunc("/api/files", apiOnly(s.handleFiles))
+	mux.HandleFunc(treePath, apiOnly(s.handleTree))
+	mux.HandleFunc(treePath+"/", apiOnly(s.handleTree))
+	mux.HandleFunc("/api/changes", apiOnly(s.handleChanges))
+	mux.HandleFunc("/api/corpus", apiOnly(s.handleCorpus))
+	mux.HandleFunc("/api/unfurl", apiOnly(s.handleUnfurl))
+	mux.HandleFunc("/sitemap.xml", readOnly(s.handleSitemap))
+	mux.HandleFunc(slackCommandPath, s.handleSlackCommand)
+	mux.HandleFunc("/api/bookmarks", s.handleBookmarks)
+	mux.HandleFunc("/api/upload", s.requireRole(RoleEditor, s.handleUpload))
+	mux.HandleFunc("/api/version", apiOnly(s.handleVersion))
+	mux.HandleFunc("/static/", readOnly(s.handleStatic))
+	mux.HandleFunc("/search", readOnly(s.handleSearchPage))
+	mux.HandleFunc(comparePath, readOnly(s.handleCompare))
+	mux.HandleFunc(authorsPath, readOnly(s.handleAuthors))
+	mux.HandleFunc(authorsPath+"/", readOnly(s.handleAuthors))
+	mux.HandleFunc("/admin", readOnly(s.requireRole(RoleAdmin, s.auditAdmin(s.handleAdmin))))
+	mux.HandleFunc("/admin/reviews", readOnly(s.requireRole(RoleAdmin, s.auditAdmin(s.handleReviews))))
+	mux.HandleFunc(calendarPath, readOnly(s.requireRole(RoleEditor, s.handleCalendar)))
+	mux.HandleFunc("/admin/acknowledgments", readOnly(s.requireRole(RoleAdmin, s.auditAdmin(s.handleAcknowledgments))))
+	mux.HandleFunc("/api/acknowledge", s.handleAcknowledge)
+	if s.digestEnabled() {
+		mux.HandleFunc(digestPath, s.handleDigest)
 	}
-	log.Printf("Serving files from: %s", s.baseDir)
-
-	// Wrap with basic auth middleware if credentials are configured
-	var handler http.Handler = mux
-	if s.authUser != "" {
-		log.Printf("Basic authentication enabled")
-		handler = s.basicAuthMiddleware(mux)
-	} else if s.oauth2Config.Enabled() {
-		log.Printf("OAuth2 authentication enabled")
-		handler = s.oauth2Middleware(mux)
+	if s.uploadsEnabled() {
+		mux.HandleFunc(editPrefix, s.requireRole(RoleEditor, s.handleEdit))
 	}
+	if s.approvalsEnabled() {
+		mux.HandleFunc(approvalsPath, readOnly(s.requireRole(RoleEditor, s.auditAdmin(s.handleApprovals))))
+		mux.HandleFunc(approvalsPath+"/", s.requireRole(RoleEditor, s.auditAdmin(s.handleRevision)))
+	}
+	if s.sharingEnabled() {
+		mux.HandleFunc(sharePrefix, readOnly(s.handleShare))
+		mux.HandleFunc("/api/share", apiOnly(s.handleShareLink))
+	}
+	if s.ask != nil {
+		mux.HandleFunc("/ask", readOnly(s.handleAskPage))
+		mux.HandleFunc("/api/ask", apiOnly(s.handleAsk))
+		s.queueAskIndex()
+	}
+	if s.options.WebDAV {
+		switch {
+		case s.authUser == "":
+			log.Printf("Warning: WebDAV needs basic authentication (-auth); /dav/ is disabled")
+		case s.source() != nil:
+			log.Printf("Warning: WebDAV needs a local base directory; /dav/ is disabled")
+		default:
+			mux.Handle(davPrefix+"/", s.requireRole(RoleEditor, s.davHandler().ServeHTTP))
+		}
+	}
+	if s.options.Pprof {
+		if s.authUser == "" && !s.oauth2Config.Enabled() {
+			log.Printf("Warning: -pprof without authentication lets anyone read the profiles at %s", pprofPrefix)
+		}
+		s.registerPprof(mux)
+	}
+	if s.options.OfflineDownload {
+		mux.Handle(downloadPath, noWriteTimeout(readOnly(s.downloadHandler(mux))))
+	}
+	return s.freshContent(mux)
+}
 
-	return http.ListenAndServe(addr, handler)
+// serve serves handler on every listener until one of them fails or the
+// process is interrupted or terminated. On a signal, it lets the requests
+// in flight complete and drains the background jobs before returning nil.
+func (s *Server) serve(listeners []net.Listener, handler http.Handler) error {
+	httpServer := newHTTPServer(handler, s.options.HTTP)
+	if s.options.Privacy {
+		httpServer.ErrorLog = log.New(addressScrubber{s}, "", log.LstdFlags)
+	}
+	errs := make(chan error, len(listeners))
+	for _, l := range listeners {
+		go func(l net.Listener) {
+			errs <- serveListener(httpServer, l, s.options.HTTP)
+		}(l)
+	}
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	select {
+	case err := <-errs:
+		httpServer.Close()
+		return err
+	case sig := <-signals:
+		log.Printf("Received %v, shutting down", sig)
+		ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		if err := httpServer.Shutdown(ctx); err != nil {
+			httpServer.Close()
+		}
+		s.drainJobs(ctx)
+		return nil
+	}
 }
 
 // basicAuthMiddleware wraps a handler with HTTP Basic Authentication.
 func (s *Server) basicAuthMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		user, pass, ok := r.BasicAuth()
-		if !ok || user != s.authUser || pass != s.authPass {
+		valid := ok && s.validBasicAuth(user, pass)
+		if ok {
+			s.auditBasicLogin(r, user, valid)
+		}
+		if !valid {
 			w.Header().Set("WWW-Authenticate", `Basic realm="gomdoc"`)
 			http.Error(w, "Unauthorized", http.StatusUnauthorized)
 			return
@@ -123,6 +362,35 @@ func (s *Server) basicAuthMiddleware(next http.Handler) http.Handler {
 	})
 }
 
+// layout returns what every page of the site shows around its content:
+// the site title, the version, the footer, and whether to leave out
+// scripts.
+func (s *Server) layout() templates.SiteLayout {
+	return templates.SiteLayout{
+		SiteTitle:  s.title,
+		AppVersion: s.version,
+		Footer:     s.options.Footer,
+		NoJS:       s.options.NoJS,
+	}
+}
+
+// currentUser returns the authenticated user: the basic auth user name or
+// the OAuth2 email. It is empty when authentication is disabled.
+func (s *Server) currentUser(r *http.Request) string {
+	if s.authUser != "" {
+		if user, _, ok := r.BasicAuth(); ok && user == s.authUser {
+			return user
+		}
+		return ""
+	}
+	if s.oauth2Config.Enabled() {
+		if session, ok := s.readOAuth2Session(r); ok {
+			return session.Email
+		}
+	}
+	return ""
+}
+
 // bearerAuthMiddleware wraps a handler with Bearer token authentication.
 // It checks the Authorization header for a valid Bearer token. Clients that
 // cannot set headers (e.g. browser SSE) may pass the token as a query
@@ -155,8 +423,14 @@ func (s *Server) bearerAuthMiddleware(next http.Handler) http.Handler {
 func (s *Server) handleRequest(w http.ResponseWriter, r *http.Request) {
 	path := r.URL.Path
 
-	// Index page
+	// Landing page: a curated home document when present, else the tree
 	if path == "/" {
+		s.handleHome(w, r)
+		return
+	}
+
+	// The generated file tree stays reachable when a home page replaces it
+	if path == "/browse" {
 		s.handleIndex(w, r)
 		return
 	}
@@ -165,46 +439,87 @@ func (s *Server) handleRequest(w http.ResponseWriter, r *http.Request) {
 	s.handleMarkdown(w, r)
 }
 
+// buildTree builds the navigation tree using the configured sort order.
+func (s *Server) buildTree(entries []scanner.FileEntry) *scanner.TreeNode {
+	return scanner.BuildTreeSorted(entries, s.options.Sort)
+}
+
 // handleIndex renders the file tree index page.
 func (s *Server) handleIndex(w http.ResponseWriter, r *http.Request) {
-	entries, err := scanner.ScanDirectory(s.baseDir)
+	entries, err := s.visibleEntries(r)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Error scanning directory: %v", err), http.StatusInternalServerError)
 		return
 	}
 
-	tree := scanner.BuildTree(entries)
-	treeHTML := scanner.RenderTree(tree)
+	tree := s.buildTree(entries)
+	treeHTML := s.renderTree(tree, "")
+
+	// Without a home page, / and /browse both show the index
+	indexPath := "/browse"
+	if s.homeDocument() == "" {
+		indexPath = "/"
+	}
 
 	data := templates.IndexData{
-		Title:     "Index",
-		SiteTitle: s.title,
-		TreeHTML:  template.HTML(treeHTML),
+		SiteLayout: s.layout(),
+		Title:      "Index",
+		TreeHTML:   treeHTML,
+		Tree:       tree,
+		HasHome:    s.homeDocument() != "",
+		Canonical:  s.canonicalURL(indexPath),
+		Digest:     s.digestEnabled() && s.currentUser(r) != "",
 	}
 
 	w.Header().Set("Content-Type", "text/html; charset=utf-8")
-	if err := templates.RenderIndex(w, data); err != nil {
+	if err := traceTemplate(r, "index", func() error { return templates.RenderIndex(w, data) }); err != nil {
 		log.Printf("Error rendering index: %v", err)
 	}
 }
 
 // handleMarkdown renders a markdown file as HTML.
 func (s *Server) handleMarkdown(w http.ResponseWriter, r *http.Request) {
-	// Convert URL path to file path
 	urlPath := strings.TrimPrefix(r.URL.Path, "/")
-	filePath := filepath.Join(s.baseDir, urlPath+".md")
 
-	// Try lowercase .md first, then uppercase .MD
-	content, err := os.ReadFile(filePath)
+	// Redirect /guide/, /guide.md, the home page's own route, and prefixed
+	// URLs such as /01-intro to their canonical form
+	if s.redirectCanonical(w, r, urlPath) {
+		return
+	}
+
+	content, err := s.readDocument(urlPath)
+	if errors.Is(err, errTooLarge) {
+		s.handleTooLarge(w, r, err)
+		return
+	}
 	if err != nil {
-		filePath = filepath.Join(s.baseDir, urlPath+".MD")
-		content, err = os.ReadFile(filePath)
-		if err != nil {
-			s.handleNotFound(w, r)
-			return
+		if !s.serveFile(w, r, urlPath) && !s.serveOfficePreview(w, r, urlPath) && !s.serveCodePage(w, r, urlPath) {
+			s.handleDirectory(w, r, urlPath)
 		}
+		return
+	}
+
+	switch s.scheduleStatus("/" + urlPath) {
+	case search.Pending:
+		s.handleNotFound(w, r)
+		return
+	case search.Expired:
+		s.handleGone(w, r)
+		return
+	}
+	if s.hidesDraft(r, "/"+urlPath) {
+		s.handleNotFound(w, r)
+		return
 	}
 
+	s.renderDocument(w, r, urlPath, content)
+}
+
+// renderDocument renders markdown content as the page served at urlPath.
+func (s *Server) renderDocument(w http.ResponseWriter, r *http.Request, urlPath string, content []byte) {
+	pagePath := "/" + urlPath
+	source := content
+
 	// Parse frontmatter before rendering
 	frontmatter, content := renderer.ParseFrontmatter(content)
 
@@ -215,9 +530,29 @@ func (s *Server) handleMarkdown(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Render markdown to HTML
-	html, err := s.renderer.RenderWithLinks(content, currentDir)
+	pageRenderer := s.renderer
+	if frontmatter.Typographer != nil {
+		pageRenderer = pageRenderer.WithTypographer(*frontmatter.Typographer)
+	}
+	html, err := s.renderWithin(r.Context(), pagePath, func() ([]byte, error) {
+		return pageRenderer.RenderWithLinks(content, currentDir)
+	})
 	if err != nil {
-		http.Error(w, fmt.Sprintf("Error rendering markdown: %v", err), http.StatusInternalServerError)
+		renderError(w, r, err)
+		return
+	}
+	html, missingFiles := s.resolveCitations(html, urlPath, frontmatter)
+	if s.options.SafeMode {
+		if html, err = sanitize.InlineSVG(html); err != nil {
+			http.Error(w, fmt.Sprintf("Error sanitizing SVG: %v", err), http.StatusInternalServerError)
+			return
+		}
+	}
+	html = s.renderDiagrams(r.Context(), html)
+	styles, scripts, missingAssets := s.pageAssets(urlPath, frontmatter)
+	html, missing := s.markMissingAssets(pagePath, html, append(missingFiles, missingAssets...))
+	if len(missing) > 0 && s.options.MissingAssets == MissingFail {
+		missingAssetsError(w, r, pagePath, missing)
 		return
 	}
 
@@ -227,19 +562,26 @@ func (s *Server) handleMarkdown(w http.ResponseWriter, r *http.Request) {
 		title = filepath.Base(urlPath)
 	}
 
+	// Prefer the description frontmatter, falling back to the indexed summary
+	description := frontmatter.Description
+	if preview, found := s.indexFor(r).Preview(pagePath); description == "" && found {
+		description = preview.Summary
+	}
+
 	// Build navigation elements
-	breadcrumbs := buildBreadcrumbs(r.URL.Path)
+	breadcrumbs := buildBreadcrumbs(pagePath)
 
-	entries, scanErr := scanner.ScanDirectory(s.baseDir)
+	entries, scanErr := s.visibleEntries(r)
 	var treeHTML template.HTML
+	var tree *scanner.TreeNode
 	var prevPath, prevTitle, nextPath, nextTitle string
 	if scanErr == nil {
-		tree := scanner.BuildTree(entries)
-		treeHTML = template.HTML(scanner.RenderTreeWithActive(tree, r.URL.Path))
+		tree = s.buildTree(entries)
+		treeHTML = s.renderTree(tree, pagePath)
 
 		flat := scanner.FlatPaths(tree)
 		for i, entry := range flat {
-			if entry.Path != r.URL.Path {
+			if entry.Path != pagePath {
 				continue
 			}
 			if i > 0 {
@@ -255,31 +597,84 @@ func (s *Server) handleMarkdown(w http.ResponseWriter, r *http.Request) {
 	}
 
 	data := templates.PageData{
+		SiteLayout:  s.layout(),
 		Title:       title,
-		SiteTitle:   s.title,
+		Description: description,
 		Author:      frontmatter.Author,
 		Status:      frontmatter.Status,
 		Date:        frontmatter.Date,
+		Updated:     frontmatter.Updated,
 		Tags:        frontmatter.Tags,
 		Category:    frontmatter.Category,
 		Version:     frontmatter.Version,
 		Reviewers:   frontmatter.Reviewers,
+		Owner:       frontmatter.Owner,
+		ReviewBy:    frontmatter.ReviewBy,
 		Content:     template.HTML(html),
-		Path:        r.URL.Path,
+		Path:        pagePath,
+		Canonical:   s.canonicalURL(pagePath),
 		Breadcrumbs: breadcrumbs,
 		TreeHTML:    treeHTML,
+		Tree:        tree,
 		PrevPath:    prevPath,
 		PrevTitle:   prevTitle,
 		NextPath:    nextPath,
 		NextTitle:   nextTitle,
+		Series:      s.seriesNav(r, pagePath, frontmatter),
+		Subtitle:    frontmatter.Subtitle,
+		Logo:        frontmatter.Logo,
+		Cover:       frontmatter.HasCover(),
+		Layout:      pageLayout(urlPath, frontmatter),
+		Theme:       pageTheme(urlPath, frontmatter),
+	}
+	if date, stale := s.staleSince(pagePath, frontmatter); stale {
+		data.StaleSince = s.formatDate(date)
+	}
+	data.DisplayDate = s.displayDate(pagePath, frontmatter.Date)
+	data.StructuredData = s.structuredData(pagePath, title, description, frontmatter, tree)
+	data.DisplayUpdated = s.displayDate(pagePath, frontmatter.Updated)
+	if frontmatter.ReviewBy != "" {
+		data.ReviewOverdue, _ = search.ReviewOverdue(frontmatter.ReviewBy, clock.Now())
+	}
+	data.Styles, data.Scripts = styles, scripts
+	if shared, ok := sharedPageOf(r); ok {
+		data.SharedUntil = shared.expires.Format(shareTimeFormat)
+	} else {
+		if user := s.currentUser(r); frontmatter.Acknowledge && user != "" {
+			data.Acknowledge = true
+			data.AcknowledgedOn = s.acknowledgedOn(pagePath, user, pageVersion(frontmatter, source))
+		}
+		data.EditURL = s.editURL(r, urlPath)
 	}
 
 	w.Header().Set("Content-Type", "text/html; charset=utf-8")
-	if err := templates.RenderPage(w, data); err != nil {
+	if err := traceTemplate(r, "page", func() error { return templates.RenderPage(w, data) }); err != nil {
 		log.Printf("Error rendering page: %v", err)
 	}
 }
 
+// pageLayout returns the layout selected in the frontmatter, logging
+// unknown names and falling back to the default layout.
+func pageLayout(urlPath string, frontmatter renderer.Frontmatter) string {
+	if frontmatter.Layout == "" || templates.HasLayout(frontmatter.Layout) {
+		return frontmatter.Layout
+	}
+	log.Printf("Warning: %s: unknown layout %q, using the default (available: %s)",
+		urlPath, frontmatter.Layout, strings.Join(templates.Layouts(), ", "))
+	return ""
+}
+
+// pageTheme returns the theme selected in the frontmatter, logging unknown
+// names and falling back to the default theme.
+func pageTheme(urlPath string, frontmatter renderer.Frontmatter) string {
+	if frontmatter.Theme == "" || templates.HasTheme(frontmatter.Theme) {
+		return frontmatter.Theme
+	}
+	log.Printf("Warning: %s: unknown theme %q, using the default (available: %s)",
+		urlPath, frontmatter.Theme, strings.Join(templates.Themes(), ", "))
+	return ""
+}
+
 // buildBreadcrumbs generates HTML breadcrumb navigation from a URL path.
 func buildBreadcrumbs(urlPath string) template.HTML {
 	parts := strings.Split(strings.Trim(urlPath, "/"), "/")
@@ -303,24 +698,53 @@ func buildBreadcrumbs(urlPath string) template.HTML {
 
 // handleNotFound renders a custom 404 page with navigation and search.
 func (s *Server) handleNotFound(w http.ResponseWriter, r *http.Request) {
+	s.renderNotFound(w, r, http.StatusNotFound)
+}
+
+// handleGone renders the 404 page variant for expired documents with
+// status 410, telling clients and crawlers the page was removed on purpose.
+func (s *Server) handleGone(w http.ResponseWriter, r *http.Request) {
+	s.renderNotFound(w, r, http.StatusGone)
+}
+
+// handleTooLarge renders the error page variant for documents over the
+// page size limit with status 413, and logs the file.
+func (s *Server) handleTooLarge(w http.ResponseWriter, r *http.Request, err error) {
+	log.Printf("Warning: not rendering %s: %v", r.URL.Path, err)
+	s.renderNotFound(w, r, http.StatusRequestEntityTooLarge)
+}
+
+// renderNotFound renders the custom error page with the given status.
+func (s *Server) renderNotFound(w http.ResponseWriter, r *http.Request, status int) {
 	data := templates.NotFoundData{
-		SiteTitle:   s.title,
+		SiteLayout:  s.layout(),
 		RequestPath: r.URL.Path,
+		Gone:        status == http.StatusGone,
+	}
+	if status == http.StatusRequestEntityTooLarge {
+		data.SizeLimit = scanner.FormatSize(s.options.Scan.MaxPageBytes)
 	}
 	w.Header().Set("Content-Type", "text/html; charset=utf-8")
-	w.WriteHeader(http.StatusNotFound)
-	if err := templates.RenderNotFound(w, data); err != nil {
+	w.WriteHeader(status)
+	if err := traceTemplate(r, "not_found", func() error { return templates.RenderNotFound(w, data) }); err != nil {
 		log.Printf("Error rendering 404 page: %v", err)
 	}
 }
 
-// handleStatic serves embedded static files.
-func (s *Server) handleStatic(w http.ResponseWriter, r *http.Request) {
-	path := strings.TrimPrefix(r.URL.Path, "/static/")
+// init registers the embedded stylesheet, so pages link it by its hashed
+// URL.
+func init() {
+	static.Register("style.css", "text/css; charset=utf-8", []byte(styleCSS))
+}
 
-	if path == "style.css" {
-		w.Header().Set("Content-Type", "text/css; charset=utf-8")
-		w.Write([]byte(styleCSS))
+// handleStatic serves embedded static files: the assets, at their hashed
+// and plain URLs, and the search index.
+func (s *Server) handleStatic(w http.ResponseWriter, r *http.Request) {
+	if static.Serve(w, r) {
+		return
+	}
+	if r.URL.Path == searchIndexPath {
+		s.handleSearchIndex(w, r)
 		return
 	}
 
@@ -328,15 +752,16 @@ func (s *Server) handleStatic(w http.ResponseWriter, r *http.Request) {
 }
 
 // handleSearch responds with JSON search results for a query parameter.
+// Results can be filtered with tag, dir, author, from, and to parameters or
+// the matching operators in the query, such as "install tag:guide".
 func (s *Server) handleSearch(w http.ResponseWriter, r *http.Request) {
-	query := r.URL.Query().Get("q")
-	if query == "" {
-		w.Header().Set("Content-Type", "application/json")
-		w.Write([]byte("[]"))
+	query, filter, err := searchFilter(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	results := s.index.SearchKeywords(query, 20)
+	results := s.indexFor(r).SearchFiltered(query, filter, 20)
 	if results == nil {
 		results = []search.Result{}
 	}
@@ -345,6 +770,74 @@ func (s *Server) handleSearch(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(results)
 }
 
+// searchFilter reads the search query and filter from request parameters.
+// Parameters take precedence over operators in the query.
+func searchFilter(params url.Values) (string, search.Filter, error) {
+	query, filter, err := search.ParseQuery(params.Get("q"))
+	if err != nil {
+		return "", search.Filter{}, err
+	}
+	filter.Tags = append(filter.Tags, params["tag"]...)
+	if dir := params.Get("dir"); dir != "" {
+		filter.Dir = dir
+	}
+	if author := params.Get("author"); author != "" {
+		filter.Author = author
+	}
+	for name, date := range map[string]*time.Time{"from": &filter.From, "to": &filter.To} {
+		if value := params.Get(name); value != "" {
+			if *date, err = search.ParseDate(value); err != nil {
+				return "", search.Filter{}, fmt.Errorf("%s: %w", name, err)
+			}
+		}
+	}
+	return query, filter, nil
+}
+
+// fileListing is one page in the /api/files listing.
+type fileListing struct {
+	Title string `json:"title"`
+	Path  string `json:"path"`
+}
+
+// handleFiles responds with every indexed page as a flat JSON list of
+// titles and paths, sorted by path, used by the quick-open overlay. The
+// offset and limit parameters select a page of the list.
+func (s *Server) handleFiles(w http.ResponseWriter, r *http.Request) {
+	docs := s.indexFor(r).Documents()
+	files := make([]fileListing, 0, len(docs))
+	for _, doc := range docs {
+		files = append(files, fileListing{Title: doc.Title, Path: doc.Path})
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+	offset, limit, err := pageRange(r, 0)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	start, end := pageBounds(len(files), offset, limit)
+	if r.URL.Query().Has("offset") || r.URL.Query().Has("limit") {
+		setPageHeaders(w, r, len(files), end)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(files[start:end])
+}
+
+// handlePreview responds with the title and summary of a document as JSON,
+// used by the hover previews on internal links.
+func (s *Server) handlePreview(w http.ResponseWriter, r *http.Request) {
+	docPath := strings.TrimPrefix(r.URL.Path, "/api/preview")
+	preview, found := s.indexFor(r).Preview(docPath)
+	if !found {
+		http.Error(w, "Document not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(preview)
+}
+
 // styleCSS is the embedded CSS for styling the pages.
 const styleCSS = `/* Theme custom properties */
 :root {
@@ -468,6 +961,94 @@ body.has-sidebar {
     border-bottom: 1px solid var(--color-border);
 }
 
+.site-banner {
+    display: flex;
+    align-items: flex-start;
+    gap: 12px;
+    margin-bottom: 10px;
+    padding: 10px 14px;
+    border-left: 4px solid #0969da;
+    border-radius: 4px;
+    background-color: #ddf4ff;
+    color: #333;
+    font-size: 14px;
+}
+
+.site-banner-warning {
+    border-left-color: #9a6700;
+    background-color: #fff8c5;
+}
+
+.site-banner-danger {
+    border-left-color: #cf222e;
+    background-color: #ffebe9;
+}
+
+.site-banner-text {
+    flex: 1;
+}
+
+.site-banner a {
+    color: inherit;
+    text-decoration: underline;
+}
+
+.site-banner-close {
+    border: none;
+    background: none;
+    color: inherit;
+    font-size: 18px;
+    line-height: 1;
+    cursor: pointer;
+}
+
+.site-menu {
+    display: flex;
+    flex-wrap: wrap;
+    align-items: center;
+    gap: 4px 18px;
+    padding: 6px 0;
+    font-size: 14px;
+}
+
+.site-menu a, .site-menu summary {
+    color: var(--color-text-muted);
+    text-decoration: none;
+    cursor: pointer;
+}
+
+.site-menu a:hover, .site-menu summary:hover {
+    color: var(--color-link);
+}
+
+.site-menu-dropdown {
+    position: relative;
+}
+
+.site-menu-items {
+    position: absolute;
+    z-index: 20;
+    top: 100%;
+    left: 0;
+    min-width: 180px;
+    margin-top: 6px;
+    padding: 4px 0;
+    background: var(--color-surface);
+    border: 1px solid var(--color-border);
+    border-radius: 4px;
+    box-shadow: 0 4px 12px var(--color-shadow);
+}
+
+.site-menu-items a {
+    display: block;
+    padding: 6px 12px;
+    white-space: nowrap;
+}
+
+.site-menu-items a:hover {
+    background-color: var(--color-surface-hover);
+}
+
 .nav-btn {
     padding: 8px 16px;
     border: 1px solid var(--color-border-input);
@@ -567,6 +1148,49 @@ body.has-sidebar {
     content: "📄 ";
 }
 
+.file-tree .attachment::before { content: "📎 "; }
+.file-tree .attachment[data-type="pdf"]::before { content: "📕 "; }
+.file-tree .attachment:is([data-type="doc"], [data-type="docx"], [data-type="odt"], [data-type="txt"])::before { content: "📝 "; }
+.file-tree .attachment:is([data-type="xls"], [data-type="xlsx"], [data-type="ods"], [data-type="csv"])::before { content: "📊 "; }
+.file-tree .attachment:is([data-type="ppt"], [data-type="pptx"], [data-type="odp"])::before { content: "📽️ "; }
+.file-tree .attachment[data-type="zip"]::before { content: "🗜️ "; }
+.file-tree .attachment:is([data-type="json"], [data-type="yaml"], [data-type="yml"], [data-type="toml"], [data-type="ini"], [data-type="conf"], [data-type="xml"])::before { content: "⚙️ "; }
+.file-tree .attachment:is([data-type="sh"], [data-type="bash"], [data-type="ps1"], [data-type="py"], [data-type="go"], [data-type="js"], [data-type="ts"], [data-type="sql"])::before { content: "📜 "; }
+
+.attachment-size {
+    color: var(--color-text-muted);
+    font-size: 0.85em;
+}
+
+.file-actions {
+    display: flex;
+    align-items: center;
+    gap: 0.75rem;
+}
+
+.office-preview {
+    overflow-x: auto;
+}
+
+.office-preview table {
+    border-collapse: collapse;
+}
+
+.office-preview th,
+.office-preview td {
+    border: 1px solid var(--color-border);
+    padding: 0.25rem 0.5rem;
+    vertical-align: top;
+}
+
+.code-file pre {
+    overflow-x: auto;
+}
+
+.file-error {
+    color: var(--color-text-muted);
+}
+
 .file-tree a {
     color: var(--color-link);
     text-decoration: none;
@@ -595,6 +1219,18 @@ body.has-sidebar {
     color: var(--color-link);
 }
 
+.content a.external-link::after {
+    content: "\2197";
+    font-size: 0.8em;
+    margin-left: 2px;
+    text-decoration: none;
+    display: inline-block;
+}
+
+.content a.unapproved-link {
+    text-decoration: underline wavy #cf222e;
+}
+
 .content code {
     background-color: var(--color-surface-code);
     padding: 2px 6px;
@@ -669,107 +1305,675 @@ body.has-sidebar {
     -webkit-user-select: none;
 }
 
-.content pre code {
-    background: none;
-    padding: 0;
-    color: inherit;
+.content pre code {
+    background: none;
+    padding: 0;
+    color: inherit;
+}
+
+/* Terminal output with ANSI colors, in the VS Code terminal palette */
+.content pre.ansi {
+    background-color: #1e1e1e;
+    color: #cccccc;
+}
+
+.ansi-bold { font-weight: bold; }
+.ansi-dim { opacity: 0.7; }
+.ansi-italic { font-style: italic; }
+.ansi-underline { text-decoration: underline; }
+.ansi-fg-black { color: #000000; }
+.ansi-fg-bright-black { color: #7f7f7f; }
+.ansi-fg-red { color: #cd3131; }
+.ansi-fg-bright-red { color: #f14c4c; }
+.ansi-fg-green { color: #0dbc79; }
+.ansi-fg-bright-green { color: #23d18b; }
+.ansi-fg-yellow { color: #e5e510; }
+.ansi-fg-bright-yellow { color: #f5f543; }
+.ansi-fg-blue { color: #2472c8; }
+.ansi-fg-bright-blue { color: #3b8eea; }
+.ansi-fg-magenta { color: #bc3fbc; }
+.ansi-fg-bright-magenta { color: #d670d6; }
+.ansi-fg-cyan { color: #11a8cd; }
+.ansi-fg-bright-cyan { color: #29b8db; }
+.ansi-fg-white { color: #e5e5e5; }
+.ansi-fg-bright-white { color: #ffffff; }
+.ansi-bg-black { background-color: #000000; }
+.ansi-bg-bright-black { background-color: #7f7f7f; }
+.ansi-bg-red { background-color: #cd3131; }
+.ansi-bg-bright-red { background-color: #f14c4c; }
+.ansi-bg-green { background-color: #0dbc79; }
+.ansi-bg-bright-green { background-color: #23d18b; }
+.ansi-bg-yellow { background-color: #e5e510; }
+.ansi-bg-bright-yellow { background-color: #f5f543; }
+.ansi-bg-blue { background-color: #2472c8; }
+.ansi-bg-bright-blue { background-color: #3b8eea; }
+.ansi-bg-magenta { background-color: #bc3fbc; }
+.ansi-bg-bright-magenta { background-color: #d670d6; }
+.ansi-bg-cyan { background-color: #11a8cd; }
+.ansi-bg-bright-cyan { background-color: #29b8db; }
+.ansi-bg-white { background-color: #e5e5e5; }
+.ansi-bg-bright-white { background-color: #ffffff; }
+
+/* Embedded video and audio */
+.media-player {
+    display: block;
+    max-width: 100%;
+    margin: 1em 0;
+}
+
+audio.media-player {
+    width: 100%;
+    max-width: 40rem;
+}
+
+/* asciinema terminal recordings */
+.cast-player {
+    display: block;
+    margin: 1em 0;
+}
+
+.content .cast-screen {
+    min-height: 4em;
+    line-height: 1.25;
+    white-space: pre;
+}
+
+.cast-controls {
+    display: flex;
+    align-items: center;
+    gap: 0.5rem;
+    margin-top: 0.25rem;
+    font-size: 0.85em;
+    color: var(--color-text-muted);
+}
+
+.cast-controls input[type="range"] {
+    flex: 1;
+}
+
+.cast-play {
+    min-width: 2.5em;
+    cursor: pointer;
+}
+
+.cast-error::after {
+    content: " (recording could not be loaded)";
+    color: var(--color-text-muted);
+}
+
+.content blockquote {
+    border-left: 4px solid var(--color-blockquote-border);
+    margin: 1em 0;
+    padding: 0.5em 1em;
+    background-color: var(--color-blockquote-bg);
+    color: var(--color-text-quote);
+}
+
+/* Admonition/callout blocks (GitHub-style alerts) */
+.content .admonition {
+    border-radius: 6px;
+    padding: 12px 16px;
+    color: #333;
+}
+
+.content .admonition .admonition-title {
+    font-weight: 700;
+    margin: 0 0 0.4em 0;
+}
+
+.content .admonition-note {
+    border-left-color: #0969da;
+    background-color: #ddf4ff;
+}
+
+.content .admonition-note .admonition-title { color: #0969da; }
+
+.content .admonition-tip {
+    border-left-color: #1a7f37;
+    background-color: #dafbe1;
+}
+
+.content .admonition-tip .admonition-title { color: #1a7f37; }
+
+.content .admonition-important {
+    border-left-color: #8250df;
+    background-color: #fbefff;
+}
+
+.content .admonition-important .admonition-title { color: #8250df; }
+
+.content .admonition-warning {
+    border-left-color: #9a6700;
+    background-color: #fff8c5;
+}
+
+.content .admonition-warning .admonition-title { color: #9a6700; }
+
+.content .admonition-caution {
+    border-left-color: #cf222e;
+    background-color: #ffebe9;
+}
+
+.content .admonition-caution .admonition-title { color: #cf222e; }
+
+.content .admonition-danger {
+    border-left-color: #cf222e;
+    background-color: #ffebe9;
+}
+
+.content .admonition-danger .admonition-title { color: #cf222e; }
+
+.content table {
+    border-collapse: collapse;
+    width: 100%;
+    margin: 1em 0;
+}
+
+.content th, .content td {
+    border: 1px solid var(--color-table-border);
+    padding: 8px 12px;
+    text-align: left;
+}
+
+.content th {
+    background-color: var(--color-table-header-bg);
+    font-weight: bold;
+}
+
+.content tr:nth-child(even) {
+    background-color: var(--color-table-stripe);
+}
+
+/* Tables scroll sideways on small screens; long ones scroll within a box
+   under their header row */
+.table-scroll {
+    overflow-x: auto;
+}
+
+.table-long {
+    max-height: 75vh;
+    overflow-y: auto;
+}
+
+.table-long thead th {
+    position: sticky;
+    top: 0;
+    z-index: 1;
+}
+
+.table-pager {
+    display: flex;
+    gap: 12px;
+    align-items: center;
+    margin: -0.5em 0 1em;
+    font-size: 14px;
+}
+
+.table-pager .nav-btn:disabled {
+    opacity: 0.5;
+    cursor: default;
+}
+
+/* Sortable tables with a filter box */
+.table-tools {
+    display: flex;
+    gap: 12px;
+    align-items: center;
+    margin: 1em 0 -0.5em;
+}
+
+.table-filter {
+    padding: 6px 10px;
+    border: 1px solid var(--color-border-input);
+    border-radius: 6px;
+    background: var(--color-surface);
+    color: var(--color-text);
+    font-size: 14px;
+}
+
+.table-count {
+    color: var(--color-text-muted);
+    font-size: 13px;
+}
+
+.table-sort {
+    padding: 0;
+    border: none;
+    background: none;
+    color: inherit;
+    font: inherit;
+    text-align: inherit;
+    cursor: pointer;
+}
+
+.table-sort::after {
+    content: " \2195";
+    color: var(--color-text-faint);
+}
+
+th[aria-sort="ascending"] .table-sort::after {
+    content: " \2191";
+    color: inherit;
+}
+
+th[aria-sort="descending"] .table-sort::after {
+    content: " \2193";
+    color: inherit;
+}
+
+.content img {
+    max-width: 100%;
+    height: auto;
+}
+
+.content ul, .content ol {
+    margin: 1em 0;
+    padding-left: 2em;
+}
+
+.content li {
+    margin: 0.5em 0;
+}
+
+/* Link preview popover */
+.link-preview {
+    position: absolute;
+    max-width: 360px;
+    padding: 12px 14px;
+    background: var(--color-surface);
+    color: var(--color-text);
+    border: 1px solid var(--color-border-input);
+    border-radius: 6px;
+    box-shadow: 0 4px 12px var(--color-shadow-strong);
+    font-size: 13px;
+    line-height: 1.5;
+    z-index: 150;
+    pointer-events: none;
+}
+
+.link-preview-title {
+    font-weight: 600;
+    color: var(--color-heading);
+    margin-bottom: 4px;
+}
+
+.link-preview-summary {
+    color: var(--color-text-muted);
+}
+
+/* Quick-open overlay */
+.quick-open {
+    position: fixed;
+    inset: 0;
+    display: flex;
+    justify-content: center;
+    align-items: flex-start;
+    padding-top: 12vh;
+    background: rgba(0, 0, 0, 0.35);
+    z-index: 300;
+}
+
+.quick-open-dialog {
+    width: min(560px, 92vw);
+    background: var(--color-surface);
+    border: 1px solid var(--color-border-input);
+    border-radius: 8px;
+    box-shadow: 0 8px 24px var(--color-shadow-strong);
+    overflow: hidden;
+}
+
+.quick-open-input {
+    width: 100%;
+    box-sizing: border-box;
+    padding: 12px 14px;
+    border: none;
+    border-bottom: 1px solid var(--color-border-input);
+    background: transparent;
+    color: var(--color-text);
+    font-size: 16px;
+    outline: none;
+}
+
+.quick-open-list {
+    list-style: none;
+    margin: 0;
+    padding: 0;
+    max-height: 50vh;
+    overflow-y: auto;
+}
+
+.quick-open-list a {
+    display: block;
+    padding: 8px 14px;
+    color: var(--color-text);
+    text-decoration: none;
+}
+
+.quick-open-list a.active {
+    background: var(--color-search-result-border);
+}
+
+.quick-open-path {
+    display: block;
+    font-size: 12px;
+    color: var(--color-text-muted);
+}
+
+.quick-open-empty {
+    padding: 10px 14px;
+    color: var(--color-text-muted);
+}
+
+/* Warnings standing in for missing images, players, and page assets */
+.missing-asset {
+    display: inline-block;
+    padding: 2px 8px;
+    border-left: 4px solid #cf222e;
+    background: rgba(207, 34, 46, 0.1);
+    border-radius: 4px;
+    font-size: 14px;
+}
+
+div.missing-asset {
+    display: block;
+    margin-bottom: 1em;
+}
+
+/* Outdated page warning and signed link note */
+.stale-banner, .shared-banner {
+    margin-bottom: 1.5em;
+    padding: 10px 14px;
+    border-left: 4px solid #d29922;
+    background: rgba(210, 153, 34, 0.12);
+    border-radius: 4px;
+    font-size: 14px;
+}
+
+/* Read receipt button of pages with acknowledge: true */
+.acknowledge {
+    margin: 2em 0 1em;
+    padding-top: 1em;
+    border-top: 1px solid var(--color-border);
+}
+
+/* Page editor and approvals */
+.page-editor textarea, .page-editor input[type="text"] {
+    display: block;
+    width: 100%;
+    box-sizing: border-box;
+    margin-bottom: 1em;
+    padding: 8px;
+    font-family: inherit;
+    color: var(--color-text);
+    background: var(--color-surface);
+    border: 1px solid var(--color-border-input);
+    border-radius: 4px;
+}
+
+.page-editor textarea {
+    font-family: "SF Mono", Consolas, monospace;
+    font-size: 14px;
+}
+
+.edit-error {
+    padding: 10px 14px;
+    border-left: 4px solid #cf222e;
+    background: rgba(207, 34, 46, 0.1);
+    border-radius: 4px;
+}
+
+.diff {
+    width: 100%;
+    border-collapse: collapse;
+    font-family: "SF Mono", Consolas, monospace;
+    font-size: 13px;
+}
+
+.diff td {
+    padding: 0 6px;
+    vertical-align: top;
+}
+
+.diff .diff-num, .diff .diff-sign {
+    width: 1%;
+    color: var(--color-text-faint);
+    text-align: right;
+    user-select: none;
+}
+
+.diff .diff-text {
+    white-space: pre-wrap;
+    word-break: break-word;
+}
+
+.diff-add {
+    background: rgba(46, 160, 67, 0.15);
+}
+
+.diff-delete {
+    background: rgba(248, 81, 73, 0.15);
+}
+
+.diff-skip td {
+    color: var(--color-text-faint);
+    background: var(--color-surface-alt);
+    text-align: center;
+}
+
+.diff-split .diff-text {
+    width: 50%;
+}
+
+.diff-split .diff-none {
+    background: var(--color-surface-alt);
+}
+
+.compare-form {
+    display: flex;
+    flex-wrap: wrap;
+    gap: 0.5em 1em;
+    align-items: center;
+    margin-bottom: 1.5em;
+}
+
+.digest-form label {
+    display: block;
+    margin-bottom: 0.5em;
+}
+
+.digest-form fieldset {
+    margin: 1em 0;
+    border: 1px solid var(--color-border);
+    border-radius: 4px;
+}
+
+.approval-actions {
+    margin-top: 1.5em;
+}
+
+.stale-report, .job-report, .missing-report {
+    border-collapse: collapse;
+}
+
+.stale-report th, .stale-report td, .job-report th, .job-report td, .missing-report th, .missing-report td {
+    padding: 6px 12px;
+    text-align: left;
+    border-bottom: 1px solid var(--color-border-input);
+}
+
+.job-failed {
+    color: #cf222e;
+}
+
+/* Review report */
+.review-report {
+    border-collapse: collapse;
+}
+
+.review-report th, .review-report td {
+    padding: 6px 12px;
+    text-align: left;
+    border-bottom: 1px solid var(--color-border-input);
+}
+
+.review-overdue {
+    color: #cf222e;
 }
 
-.content blockquote {
-    border-left: 4px solid var(--color-blockquote-border);
-    margin: 1em 0;
-    padding: 0.5em 1em;
-    background-color: var(--color-blockquote-bg);
-    color: var(--color-text-quote);
+/* Author pages */
+.author-profile {
+    display: flex;
+    gap: 20px;
+    align-items: flex-start;
 }
 
-/* Admonition/callout blocks (GitHub-style alerts) */
-.content .admonition {
-    border-radius: 6px;
-    padding: 12px 16px;
-    color: #333;
+.author-avatar {
+    width: 96px;
+    height: 96px;
+    border-radius: 50%;
+    object-fit: cover;
 }
 
-.content .admonition .admonition-title {
-    font-weight: 700;
-    margin: 0 0 0.4em 0;
+.author-list li, .author-pages li {
+    margin-bottom: 8px;
 }
 
-.content .admonition-note {
-    border-left-color: #0969da;
-    background-color: #ddf4ff;
+.author-pages p {
+    margin: 4px 0 0;
+    color: var(--color-text-muted);
 }
 
-.content .admonition-note .admonition-title { color: #0969da; }
+/* Uploads */
+body.upload-dragging::after {
+    content: "Drop files to upload";
+    position: fixed;
+    inset: 12px;
+    display: flex;
+    align-items: center;
+    justify-content: center;
+    border: 3px dashed var(--color-border-input);
+    border-radius: 12px;
+    background: var(--color-bg);
+    opacity: 0.9;
+    font-size: 1.5em;
+    pointer-events: none;
+    z-index: 1000;
+}
 
-.content .admonition-tip {
-    border-left-color: #1a7f37;
-    background-color: #dafbe1;
+.upload-panel {
+    position: fixed;
+    right: 16px;
+    bottom: 16px;
+    width: min(480px, calc(100% - 32px));
+    padding: 12px 16px;
+    border: 1px solid var(--color-border-input);
+    border-radius: 6px;
+    background: var(--color-bg);
+    box-shadow: 0 4px 16px var(--color-shadow-strong);
+    z-index: 1001;
 }
 
-.content .admonition-tip .admonition-title { color: #1a7f37; }
+.upload-panel[hidden], .upload-panel textarea[hidden] {
+    display: none;
+}
 
-.content .admonition-important {
-    border-left-color: #8250df;
-    background-color: #fbefff;
+.upload-panel textarea {
+    display: block;
+    width: 100%;
+    margin: 8px 0;
+    font-family: monospace;
 }
 
-.content .admonition-important .admonition-title { color: #8250df; }
+.upload-errors {
+    color: #c0392b;
+    padding-left: 1.2em;
+}
 
-.content .admonition-warning {
-    border-left-color: #9a6700;
-    background-color: #fff8c5;
+/* Bookmarks */
+.bookmark-btn[hidden], .bookmarks-panel[hidden], .download-btn[hidden] {
+    display: none;
 }
 
-.content .admonition-warning .admonition-title { color: #9a6700; }
+.bookmarks-panel {
+    margin-bottom: 2em;
+    padding: 12px 16px;
+    border: 1px solid var(--color-border-input);
+    border-radius: 6px;
+}
 
-.content .admonition-caution {
-    border-left-color: #cf222e;
-    background-color: #ffebe9;
+.bookmarks-panel h2 {
+    margin-top: 0;
 }
 
-.content .admonition-caution .admonition-title { color: #cf222e; }
+.bookmark-list {
+    list-style: none;
+    padding-left: 0;
+}
 
-.content .admonition-danger {
-    border-left-color: #cf222e;
-    background-color: #ffebe9;
+.bookmark-list li {
+    display: flex;
+    align-items: center;
+    gap: 8px;
 }
 
-.content .admonition-danger .admonition-title { color: #cf222e; }
+.bookmark-search {
+    padding: 0;
+    border: none;
+    background: none;
+    color: var(--color-link);
+    font: inherit;
+    cursor: pointer;
+}
 
-.content table {
-    border-collapse: collapse;
-    width: 100%;
-    margin: 1em 0;
+.bookmark-remove {
+    border: none;
+    background: none;
+    color: var(--color-text-muted);
+    font-size: 16px;
+    cursor: pointer;
 }
 
-.content th, .content td {
-    border: 1px solid var(--color-table-border);
+.search-save {
+    display: block;
+    width: 100%;
     padding: 8px 12px;
+    border: none;
+    background: none;
+    color: var(--color-link);
+    font-size: 13px;
     text-align: left;
+    cursor: pointer;
 }
 
-.content th {
-    background-color: var(--color-table-header-bg);
-    font-weight: bold;
+/* Child page listings */
+.child-listing {
+    list-style: none;
+    padding-left: 0;
 }
 
-.content tr:nth-child(even) {
-    background-color: var(--color-table-stripe);
+.child-listing li {
+    margin: 0 0 1em 0;
 }
 
-.content img {
-    max-width: 100%;
-    height: auto;
+.child-listing a {
+    font-weight: 600;
 }
 
-.content ul, .content ol {
-    margin: 1em 0;
-    padding-left: 2em;
+.child-summary {
+    margin: 0.2em 0 0 0 !important;
+    color: var(--color-text-muted);
+    font-size: 0.95em;
 }
 
-.content li {
-    margin: 0.5em 0;
+.pager {
+    display: flex;
+    gap: 1em;
+    align-items: center;
+    margin: 1.5em 0;
+}
+
+.pager-status {
+    color: var(--color-text-muted);
 }
 
 /* Mermaid diagrams */
@@ -780,6 +1984,20 @@ body.has-sidebar {
     text-align: center;
 }
 
+/* Pre-rendered diagrams are drawn in the light theme */
+.mermaid-static {
+    background: #fff;
+    padding: 20px;
+    border-radius: 4px;
+    text-align: center;
+    overflow-x: auto;
+}
+
+.mermaid-static svg {
+    max-width: 100%;
+    height: auto;
+}
+
 /* Document metadata header */
 .doc-metadata {
     display: flex;
@@ -814,6 +2032,9 @@ body.has-sidebar {
 .meta-date { color: #6c757d; background: transparent; padding-left: 0; }
 .meta-tags { background: transparent; color: #6c757d; font-style: italic; }
 .meta-reviewers { background: transparent; color: #6c757d; margin-left: auto; }
+.meta-owner { background: #e2e3f1; color: #383d6e; }
+.meta-review-by { background: transparent; color: #6c757d; }
+.meta-review-overdue { background: #f8d7da; color: #721c24; }
 
 /* Search */
 .search-box {
@@ -883,6 +2104,45 @@ body.has-sidebar {
     line-height: 1.4;
 }
 
+.search-result-snippet mark {
+    background: none;
+    color: var(--color-text);
+    font-weight: 600;
+}
+
+.search-page-form {
+    display: flex;
+    gap: 8px;
+    margin-bottom: 16px;
+}
+
+.search-page-form input {
+    flex: 1;
+    padding: 8px 12px;
+    border: 1px solid var(--color-border-input);
+    border-radius: 4px;
+    font-size: 16px;
+    background: var(--color-surface);
+    color: var(--color-text);
+}
+
+.search-page-results .search-result-title {
+    font-size: 16px;
+}
+
+.search-page-results .search-result-snippet {
+    font-size: 14px;
+}
+
+.ask-results .search-result-snippet {
+    white-space: pre-line;
+}
+
+.ask-section {
+    font-weight: normal;
+    color: var(--color-text-quote);
+}
+
 .search-no-results {
     padding: 12px;
     color: var(--color-text-faint);
@@ -965,6 +2225,28 @@ body.has-sidebar {
 }
 
 /* Prev/Next navigation */
+/* Series navigator of pages with series: frontmatter */
+.series-nav {
+    margin-bottom: 24px;
+    padding: 12px 16px;
+    border: 1px solid var(--color-border);
+    border-radius: 6px;
+    background: var(--color-surface-alt);
+}
+
+.series-title {
+    margin: 0 0 8px;
+}
+
+.series-parts {
+    margin: 0;
+    padding-left: 24px;
+}
+
+.series-parts [aria-current] {
+    font-weight: 600;
+}
+
 .prev-next-nav {
     display: flex;
     justify-content: space-between;
@@ -1023,6 +2305,16 @@ body.has-sidebar {
     text-decoration: underline;
 }
 
+.site-footer > div + div,
+.site-footer > nav + div,
+.site-footer > div + nav {
+    margin-top: 6px;
+}
+
+.footer-links a + a {
+    margin-left: 16px;
+}
+
 /* Back to top button */
 .back-to-top {
     position: fixed;
@@ -1072,6 +2364,82 @@ body.has-sidebar {
     font-size: 1.1em;
 }
 
+/* Layouts (layout: frontmatter) */
+body.layout-wide {
+    max-width: none;
+}
+
+body.layout-wide .toc-sidebar {
+    display: none;
+}
+
+body.landing-page {
+    max-width: 960px;
+}
+
+.landing-content h1 {
+    font-size: 2.6em;
+    text-align: center;
+    border-bottom: none;
+}
+
+.landing-content h1 + p {
+    font-size: 1.2em;
+    text-align: center;
+    color: var(--color-text-muted);
+}
+
+/* Themes (theme: frontmatter) */
+body.theme-paper .content {
+    font-family: Georgia, 'Times New Roman', serif;
+    font-size: 1.1em;
+    line-height: 1.75;
+}
+
+body.theme-paper .content p,
+body.theme-paper .content li {
+    max-width: 42em;
+}
+
+body.theme-compact {
+    font-size: 14px;
+    line-height: 1.45;
+}
+
+body.theme-compact .content h1,
+body.theme-compact .content h2,
+body.theme-compact .content h3 {
+    margin-top: 1em;
+    margin-bottom: 0.4em;
+}
+
+/* Citations and bibliography */
+.citation-missing {
+    color: #d1242f;
+}
+
+.bibliography .references li {
+    margin-bottom: 0.5em;
+}
+
+/* Admin page */
+.admin-links {
+    display: flex;
+    flex-wrap: wrap;
+    gap: 24px;
+}
+
+.admin-link {
+    text-align: center;
+}
+
+.qr-code {
+    display: block;
+    width: 200px;
+    height: 200px;
+    margin-bottom: 8px;
+}
+
 /* Responsive: Tablet (768px) */
 @media (max-width: 768px) {
     body {
@@ -1267,8 +2635,22 @@ body.has-sidebar {
     }
 }
 
-/* Print header (hidden on screen) */
-.print-header {
+/* Print header and cover page (hidden on screen) */
+.print-header,
+.print-cover {
+    display: none;
+}
+
+/* Print-only and screen-only blocks ([!PRINT] and [!SCREEN] markers) */
+.content blockquote.visibility-block {
+    margin: 0;
+    padding: 0;
+    border: none;
+    background: none;
+    color: inherit;
+}
+
+.print-only {
     display: none;
 }
 
@@ -1288,7 +2670,7 @@ body.has-sidebar {
         padding: 12mm 16mm 24mm 12mm;
     }
 
-    .nav-buttons, .search-box, .sidebar, .breadcrumbs, .prev-next-nav, .back-to-top, .toc-sidebar {
+    .site-banner, .site-menu, .series-nav, .table-tools, .table-pager, .nav-buttons, .search-box, .sidebar, .breadcrumbs, .prev-next-nav, .back-to-top, .toc-sidebar, .link-preview, .quick-open, .bookmark-btn {
         display: none !important;
     }
 
@@ -1296,6 +2678,16 @@ body.has-sidebar {
         display: block;
     }
 
+    /* Print every row of long and paginated tables */
+    .table-scroll {
+        overflow: visible;
+        max-height: none;
+    }
+
+    .content tr[data-paged="out"] {
+        display: table-row !important;
+    }
+
     .site-footer {
         display: none !important;
     }
@@ -1318,6 +2710,68 @@ body.has-sidebar {
         color: #555;
     }
 
+    .print-only {
+        display: block !important;
+    }
+
+    .screen-only {
+        display: none !important;
+    }
+
+    .print-cover {
+        display: flex !important;
+        flex-direction: column;
+        justify-content: center;
+        min-height: 250mm;
+        text-align: center;
+        page-break-after: always;
+        break-after: page;
+    }
+
+    .print-cover-logo {
+        display: block;
+        max-width: 60mm;
+        max-height: 40mm;
+        margin: 0 auto 20mm;
+    }
+
+    .print-cover-title {
+        margin: 0;
+        font-size: 32pt;
+        border-bottom: none;
+    }
+
+    .print-cover-subtitle {
+        margin: 8mm 0 0;
+        font-size: 16pt;
+        color: #555;
+    }
+
+    .print-cover-meta {
+        display: grid;
+        grid-template-columns: auto auto;
+        justify-content: center;
+        gap: 2mm 6mm;
+        margin: 30mm 0 0;
+        font-size: 12pt;
+    }
+
+    .print-cover-meta dt {
+        font-weight: 600;
+        text-align: right;
+    }
+
+    .print-cover-meta dd {
+        margin: 0;
+        text-align: left;
+    }
+
+    .print-cover-site {
+        margin-top: 30mm;
+        font-size: 11pt;
+        color: #777;
+    }
+
     .content {
         box-shadow: none;
         padding: 0;
@@ -1,9 +1,21 @@
 package server
 
 import (
+	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
+
+	"gomdoc/renderer"
+	"gomdoc/scanner"
+	"gomdoc/search"
+	"gomdoc/templates"
 )
 
 func TestBearerAuthMiddleware_RejectsNoToken(t *testing.T) {
@@ -99,3 +111,412 @@ func TestBearerAuthMiddleware_HeaderTakesPrecedence(t *testing.T) {
 		t.Errorf("expected 200 (header takes precedence), got %d", rec.Code)
 	}
 }
+
+func TestHandlePreview(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "guide.md"), []byte("---\ntitle: Guide\n---\n# Guide\n\nStart here.\n"), 0o644)
+	idx := search.NewIndex()
+	idx.Build(dir)
+	s := &Server{index: idx}
+
+	rec := httptest.NewRecorder()
+	s.handlePreview(rec, httptest.NewRequest(http.MethodGet, "/api/preview/guide", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	var preview search.Preview
+	if err := json.NewDecoder(rec.Body).Decode(&preview); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if preview.Title != "Guide" || preview.Summary != "Start here." {
+		t.Errorf("unexpected preview: %+v", preview)
+	}
+
+	rec = httptest.NewRecorder()
+	s.handlePreview(rec, httptest.NewRequest(http.MethodGet, "/api/preview/missing", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for missing document, got %d", rec.Code)
+	}
+}
+
+func TestHandleMarkdownListsDirectoryChildren(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "guide", "advanced"), 0o755)
+	os.WriteFile(filepath.Join(dir, "guide", "intro.md"), []byte("---\ntitle: Introduction\n---\nWelcome aboard.\n"), 0o644)
+	os.WriteFile(filepath.Join(dir, "guide", "advanced", "tuning.md"), []byte("# Tuning\n"), 0o644)
+	idx := search.NewIndex()
+	idx.Build(dir)
+	s := &Server{baseDir: dir, index: idx, renderer: renderer.New()}
+
+	rec := httptest.NewRecorder()
+	s.handleMarkdown(rec, httptest.NewRequest(http.MethodGet, "/guide", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	body := rec.Body.String()
+	for _, want := range []string{`href="/guide/intro">Introduction</a>`, "Welcome aboard.", `href="/guide/advanced">advanced/</a>`} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected listing to contain %q", want)
+		}
+	}
+}
+
+func TestDirectoryListingPages(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "logs"), 0o755)
+	for i := range listingPageSize + 5 {
+		os.WriteFile(filepath.Join(dir, "logs", fmt.Sprintf("day%03d.md", i)), []byte("Entry.\n"), 0o644)
+	}
+	idx := search.NewIndex()
+	idx.Build(dir)
+	s := &Server{baseDir: dir, index: idx, renderer: renderer.New()}
+	get := func(target string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		s.handleMarkdown(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		return rec
+	}
+
+	body := get("/logs").Body.String()
+	if !strings.Contains(body, `href="/logs/day199">day199</a>`) || strings.Contains(body, `href="/logs/day200">day200</a>`) {
+		t.Errorf("expected the first %d entries", listingPageSize)
+	}
+	if !strings.Contains(body, `1–200 of 205`) || !strings.Contains(body, `<a href="/logs?offset=200" rel="next">`) {
+		t.Errorf("expected a pager to the next page")
+	}
+
+	body = get("/logs?offset=200").Body.String()
+	if !strings.Contains(body, `href="/logs/day204">day204</a>`) || strings.Contains(body, `href="/logs/day199">day199</a>`) || !strings.Contains(body, `<a href="/logs" rel="prev">`) {
+		t.Errorf("expected the last entries with a link back")
+	}
+	if body := get("/logs?limit=10&offset=10").Body.String(); !strings.Contains(body, `<a href="/logs?limit=10" rel="prev">`) || !strings.Contains(body, `<a href="/logs?limit=10&amp;offset=20" rel="next">`) {
+		t.Errorf("expected the limit kept in pager links")
+	}
+	if rec := get("/logs?offset=500"); rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404 past the last page, got %d", rec.Code)
+	}
+	if rec := get("/logs?limit=abc"); rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for an invalid limit, got %d", rec.Code)
+	}
+}
+
+func TestHandleMarkdownStripsNumericPrefixes(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "02-guides"), 0o755)
+	os.WriteFile(filepath.Join(dir, "02-guides", "01-setup.md"), []byte("# Setup\nInstall it.\n"), 0o644)
+	s := NewWithAuth(dir, 0, "Docs", "", "", OAuth2Config{}, "", "test")
+	s.Configure(Options{Scan: scanner.ScanOptions{StripNumericPrefix: true}})
+
+	rec := httptest.NewRecorder()
+	s.handleMarkdown(rec, httptest.NewRequest(http.MethodGet, "/02-guides/01-setup", nil))
+	if rec.Code != http.StatusMovedPermanently || rec.Header().Get("Location") != "/guides/setup" {
+		t.Fatalf("expected 301 to /guides/setup, got %d %q", rec.Code, rec.Header().Get("Location"))
+	}
+
+	rec = httptest.NewRecorder()
+	s.handleMarkdown(rec, httptest.NewRequest(http.MethodGet, "/guides/setup", nil))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "Install it.") {
+		t.Fatalf("expected pretty URL to render the prefixed file, got %d", rec.Code)
+	}
+}
+
+func TestHandleRequestServesHomePage(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "index.md"), []byte("# Welcome\nCurated start page.\n"), 0o644)
+	os.WriteFile(filepath.Join(dir, "guide.md"), []byte("# Guide\n"), 0o644)
+	s := NewWithAuth(dir, 0, "Docs", "", "", OAuth2Config{}, "", "test")
+
+	rec := httptest.NewRecorder()
+	s.handleRequest(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "Curated start page.") {
+		t.Fatalf("expected index.md as landing page, got %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	s.handleRequest(rec, httptest.NewRequest(http.MethodGet, "/browse", nil))
+	if !strings.Contains(rec.Body.String(), "File Index") || !strings.Contains(rec.Body.String(), `href="/guide"`) {
+		t.Errorf("expected file tree at /browse")
+	}
+
+	s.Configure(Options{Home: "guide.md"})
+	rec = httptest.NewRecorder()
+	s.handleRequest(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if !strings.Contains(rec.Body.String(), "<h1 id=\"guide\">Guide</h1>") {
+		t.Errorf("expected configured home page to be served")
+	}
+}
+
+func TestHandleRequestFallsBackToIndex(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "guide.md"), []byte("# Guide\n"), 0o644)
+	s := NewWithAuth(dir, 0, "Docs", "", "", OAuth2Config{}, "", "test")
+
+	rec := httptest.NewRecorder()
+	s.handleRequest(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if !strings.Contains(rec.Body.String(), "File Index") {
+		t.Errorf("expected generated index without a home page")
+	}
+}
+
+func TestHandleVersion(t *testing.T) {
+	s := NewWithAuth(t.TempDir(), 0, "Docs", "", "", OAuth2Config{}, "", "v1.2.3")
+	s.Configure(Options{Build: BuildInfo{Commit: "abc1234", Date: "2024-01-31"}})
+
+	rec := httptest.NewRecorder()
+	s.handleVersion(rec, httptest.NewRequest(http.MethodGet, "/api/version", nil))
+	var info BuildInfo
+	if err := json.NewDecoder(rec.Body).Decode(&info); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if info.Version != "v1.2.3" || info.Commit != "abc1234" || info.Date != "2024-01-31" || info.GoVersion == "" {
+		t.Errorf("unexpected build info: %+v", info)
+	}
+}
+
+func TestFooterShowsVersion(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "guide.md"), []byte("# Guide\n"), 0o644)
+	s := NewWithAuth(dir, 0, "Docs", "", "", OAuth2Config{}, "", "v1.2.3")
+
+	rec := httptest.NewRecorder()
+	s.handleMarkdown(rec, httptest.NewRequest(http.MethodGet, "/guide", nil))
+	if !strings.Contains(rec.Body.String(), "Documentation created by gomdoc v1.2.3") {
+		t.Errorf("expected version in footer")
+	}
+}
+
+func TestFooterCustomization(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "guide.md"), []byte("# Guide\n"), 0o644)
+	s := NewWithAuth(dir, 0, "Docs", "", "", OAuth2Config{}, "", "v1.2.3")
+	s.Configure(Options{Footer: templates.Footer{
+		Text:            "Maintained by the platform team",
+		Links:           []templates.FooterLink{{Label: "Imprint", URL: "https://example.com/imprint"}},
+		Copyright:       "© {year} Example Corp",
+		HideGeneratedBy: true,
+	}})
+
+	rec := httptest.NewRecorder()
+	s.handleMarkdown(rec, httptest.NewRequest(http.MethodGet, "/guide", nil))
+	body := rec.Body.String()
+	year := strconv.Itoa(time.Now().Year())
+	for _, want := range []string{"Maintained by the platform team", `<a href="https://example.com/imprint">Imprint</a>`, "© " + year + " Example Corp"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected footer to contain %q", want)
+		}
+	}
+	if strings.Contains(body, "Documentation created by gomdoc") {
+		t.Error("expected generated-by line to be hidden")
+	}
+}
+
+func TestPageLayoutAndTheme(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "wide.md"), []byte("---\nlayout: wide\ntheme: paper\n---\n# Wide\n"), 0o644)
+	os.WriteFile(filepath.Join(dir, "landing.md"), []byte("---\nlayout: Landing\n---\n# Welcome\n"), 0o644)
+	os.WriteFile(filepath.Join(dir, "unknown.md"), []byte("---\nlayout: poster\ntheme: neon\n---\n# Unknown\n"), 0o644)
+	s := NewWithAuth(dir, 0, "Docs", "", "", OAuth2Config{}, "", "test")
+
+	get := func(path string) string {
+		rec := httptest.NewRecorder()
+		s.handleMarkdown(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec.Body.String()
+	}
+
+	if body := get("/wide"); !strings.Contains(body, `<body class="has-sidebar layout-wide theme-paper">`) {
+		t.Errorf("expected wide layout and paper theme classes")
+	}
+	body := get("/landing")
+	if !strings.Contains(body, "landing-content") || strings.Contains(body, `class="sidebar"`) {
+		t.Errorf("expected landing template without sidebar")
+	}
+	if body := get("/unknown"); !strings.Contains(body, `<body class="has-sidebar">`) {
+		t.Errorf("expected unknown layout and theme to fall back to the defaults")
+	}
+}
+
+func TestPrintCoverPage(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "manual.md"), []byte("---\ntitle: Manual\nsubtitle: Operations Guide\nlogo: logo.svg\nversion: 2.1\n---\nText.\n"), 0o644)
+	os.WriteFile(filepath.Join(dir, "plain.md"), []byte("# Plain\n"), 0o644)
+	s := NewWithAuth(dir, 0, "Docs", "", "", OAuth2Config{}, "", "test")
+
+	rec := httptest.NewRecorder()
+	s.handleMarkdown(rec, httptest.NewRequest(http.MethodGet, "/manual", nil))
+	body := rec.Body.String()
+	for _, want := range []string{`<header class="print-cover">`, `src="logo.svg"`, "Operations Guide", "<dt>Version</dt><dd>2.1</dd>"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected cover page to contain %q", want)
+		}
+	}
+
+	rec = httptest.NewRecorder()
+	s.handleMarkdown(rec, httptest.NewRequest(http.MethodGet, "/plain", nil))
+	if body := rec.Body.String(); strings.Contains(body, "print-cover") || !strings.Contains(body, `<header class="print-header">`) {
+		t.Errorf("expected the compact print header without cover keys")
+	}
+}
+
+func TestCitations(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "papers"), 0o755)
+	os.WriteFile(filepath.Join(dir, "site.bib"), []byte("@book{site, author = {Roe, Richard}, title = {Site Wide}, year = 2021}"), 0o644)
+	os.WriteFile(filepath.Join(dir, "papers", "refs.json"), []byte(`[{"id": "page", "title": "Page Local", "issued": {"date-parts": [[2022]]}}]`), 0o644)
+	os.WriteFile(filepath.Join(dir, "papers", "study.md"), []byte("---\nbibliography: refs.json\n---\nAs shown [@page; @site].\n"), 0o644)
+	os.WriteFile(filepath.Join(dir, "papers", "escape.md"), []byte("---\nbibliography: ../../secret.bib\n---\nSee [@page].\n"), 0o644)
+	s := NewWithAuth(dir, 0, "Docs", "", "", OAuth2Config{}, "", "test")
+	s.Configure(Options{Bibliography: "site.bib"})
+
+	rec := httptest.NewRecorder()
+	s.handleMarkdown(rec, httptest.NewRequest(http.MethodGet, "/papers/study", nil))
+	body := rec.Body.String()
+	for _, want := range []string{`<a href="#ref-page">1</a>; <a href="#ref-site">2</a>`, "<em>Page Local</em>", "<em>Site Wide</em>"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected page to contain %q", want)
+		}
+	}
+
+	rec = httptest.NewRecorder()
+	s.handleMarkdown(rec, httptest.NewRequest(http.MethodGet, "/papers/escape", nil))
+	if !strings.Contains(rec.Body.String(), "citation-missing") {
+		t.Errorf("expected bibliography outside the base directory to be ignored")
+	}
+}
+
+func TestTypographerPageOverride(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "prose.md"), []byte("Say \"hi\".\n"), 0o644)
+	os.WriteFile(filepath.Join(dir, "api.md"), []byte("---\ntypographer: false\n---\nSet \"key\".\n"), 0o644)
+	s := NewWithAuth(dir, 0, "Docs", "", "", OAuth2Config{}, "", "test")
+	s.Configure(Options{Render: renderer.Options{Typographer: true}})
+
+	rec := httptest.NewRecorder()
+	s.handleMarkdown(rec, httptest.NewRequest(http.MethodGet, "/prose", nil))
+	if !strings.Contains(rec.Body.String(), "&ldquo;hi&rdquo;") {
+		t.Errorf("expected smart quotes from the site setting")
+	}
+
+	rec = httptest.NewRecorder()
+	s.handleMarkdown(rec, httptest.NewRequest(http.MethodGet, "/api", nil))
+	if !strings.Contains(rec.Body.String(), "&quot;key&quot;") {
+		t.Errorf("expected straight quotes from the page override")
+	}
+}
+
+func TestHandleSearchFilters(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "guides"), 0o755)
+	os.WriteFile(filepath.Join(dir, "guides", "setup.md"), []byte("---\nauthor: Jane\ndate: 2024-05-01\n---\n# Setup\n\nDeploy the app.\n"), 0o644)
+	os.WriteFile(filepath.Join(dir, "deploy.md"), []byte("---\nauthor: Bob\ndate: 2023-01-01\n---\n# Deploy\n\nDeploy steps.\n"), 0o644)
+	idx := search.NewIndex()
+	idx.Build(dir)
+	s := &Server{index: idx}
+
+	for _, target := range []string{
+		"/api/search?q=deploy&dir=/guides",
+		"/api/search?q=deploy+author:jane",
+		"/api/search?q=deploy&from=2024-01-01",
+	} {
+		rec := httptest.NewRecorder()
+		s.handleSearch(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		var results []search.Result
+		if err := json.NewDecoder(rec.Body).Decode(&results); err != nil {
+			t.Fatalf("%s: invalid JSON: %v", target, err)
+		}
+		if len(results) != 1 || results[0].Path != "/guides/setup" {
+			t.Errorf("%s: expected only /guides/setup, got %+v", target, results)
+		} else if !strings.Contains(results[0].Highlighted, "<mark>Deploy</mark>") {
+			t.Errorf("%s: expected highlighted snippet, got %q", target, results[0].Highlighted)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	s.handleSearch(rec, httptest.NewRequest(http.MethodGet, "/api/search?q=deploy&to=soon", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for invalid date, got %d", rec.Code)
+	}
+}
+
+func TestHandleFiles(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "guides"), 0o755)
+	os.WriteFile(filepath.Join(dir, "guides", "setup.md"), []byte("---\ntitle: Setup Guide\n---\n# Setup\n"), 0o644)
+	os.WriteFile(filepath.Join(dir, "about.md"), []byte("# About\n"), 0o644)
+	idx := search.NewIndex()
+	idx.Build(dir)
+	s := &Server{index: idx}
+
+	rec := httptest.NewRecorder()
+	s.handleFiles(rec, httptest.NewRequest(http.MethodGet, "/api/files", nil))
+	var files []fileListing
+	if err := json.NewDecoder(rec.Body).Decode(&files); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	want := []fileListing{{Title: "about", Path: "/about"}, {Title: "Setup Guide", Path: "/guides/setup"}}
+	if len(files) != len(want) || files[0] != want[0] || files[1] != want[1] {
+		t.Errorf("expected %+v, got %+v", want, files)
+	}
+}
+
+func TestHandleFilesPaginated(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a", "b", "c"} {
+		os.WriteFile(filepath.Join(dir, name+".md"), []byte("# "+name+"\n"), 0o644)
+	}
+	idx := search.NewIndex()
+	idx.Build(dir)
+	s := &Server{index: idx}
+
+	rec := httptest.NewRecorder()
+	s.handleFiles(rec, httptest.NewRequest(http.MethodGet, "/api/files?limit=2", nil))
+	var files []fileListing
+	json.NewDecoder(rec.Body).Decode(&files)
+	if len(files) != 2 || files[0].Path != "/a" || rec.Header().Get("X-Total-Count") != "3" {
+		t.Errorf("expected the first 2 of 3 files, got %+v (total %q)", files, rec.Header().Get("X-Total-Count"))
+	}
+	if got := rec.Header().Get("Link"); got != `</api/files?limit=2&offset=2>; rel="next"` {
+		t.Errorf("expected a link to the next page, got %q", got)
+	}
+
+	rec = httptest.NewRecorder()
+	s.handleFiles(rec, httptest.NewRequest(http.MethodGet, "/api/files?limit=2&offset=2", nil))
+	files = nil
+	json.NewDecoder(rec.Body).Decode(&files)
+	if len(files) != 1 || files[0].Path != "/c" || rec.Header().Get("Link") != "" {
+		t.Errorf("expected the last file and no next link, got %+v %q", files, rec.Header().Get("Link"))
+	}
+
+	for _, query := range []string{"limit=0", "limit=5000", "offset=-1", "offset=x"} {
+		rec = httptest.NewRecorder()
+		s.handleFiles(rec, httptest.NewRequest(http.MethodGet, "/api/files?"+query, nil))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("expected 400 for %s, got %d", query, rec.Code)
+		}
+	}
+}
+
+func TestPageSizeLimit(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "small.md"), []byte("# Small\n\nFits easily.\n"), 0o644)
+	os.WriteFile(filepath.Join(dir, "huge.md"), []byte("# Huge\n\n"+strings.Repeat("Runaway table row.\n", 100)), 0o644)
+	s := NewWithAuth(dir, 0, "Docs", "", "", OAuth2Config{}, "", "test")
+	s.Configure(Options{Scan: scanner.ScanOptions{MaxPageBytes: 1 << 10}})
+	handler := s.Handler()
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/huge", nil))
+	if rec.Code != http.StatusRequestEntityTooLarge || !strings.Contains(rec.Body.String(), "larger than the limit of 1.0 KB") {
+		t.Errorf("expected a 413 page naming the limit, got %d\n%s", rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/small", nil))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `href="/huge"`) {
+		t.Errorf("expected small pages rendered with the large one in the navigation, got %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/search?q=runaway", nil))
+	if strings.Contains(rec.Body.String(), "/huge") {
+		t.Errorf("expected the large page left out of search, got %s", rec.Body.String())
+	}
+}
@@ -0,0 +1,293 @@
+package server
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"html"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"gomdoc/renderer"
+	"gomdoc/search"
+)
+
+// Signed link routes and defaults.
+const (
+	// sharePrefix is the path of signed links, followed by the token.
+	sharePrefix = "/share/"
+	// shareCookie carries the token of the last signed link opened, so
+	// that the page's images and stylesheets load without signing in.
+	shareCookie = "gomdoc_share"
+	// defaultShareTTL is how long a link is valid when the request does
+	// not say.
+	defaultShareTTL = 24 * time.Hour
+	// shareTimeFormat is how the expiry is shown on shared pages.
+	shareTimeFormat = "January 2, 2006 15:04 MST"
+)
+
+// Errors of verifyShare.
+var (
+	errShareInvalid = errors.New("invalid share token")
+	errShareExpired = errors.New("share link expired")
+)
+
+// sharedPage is the page a signed link grants access to.
+type sharedPage struct {
+	path    string
+	expires time.Time
+	// draft is set when the page was a draft its creator could see, which
+	// the link then shows.
+	draft bool
+}
+
+// sharedPageKey is the context key of the sharedPage of a request let in
+// by a signed link.
+type sharedPageKey struct{}
+
+// shareLink is the response of /api/share.
+type shareLink struct {
+	URL     string    `json:"url"`
+	Path    string    `json:"path"`
+	Expires time.Time `json:"expires"`
+}
+
+// sharingEnabled reports whether signed links are offered: the site needs
+// signing in, and Options.ShareMaxAge allows them.
+func (s *Server) sharingEnabled() bool {
+	return s.options.ShareMaxAge > 0 && (s.authUser != "" || s.oauth2Config.Enabled())
+}
+
+// newShareKey returns the key signing share tokens: secret, or a random
+// key, which invalidates the links on restart.
+func newShareKey(secret string) []byte {
+	if secret != "" {
+		return []byte(secret)
+	}
+	key := make([]byte, 32)
+	rand.Read(key)
+	return key
+}
+
+// signShare returns a token granting read access to the page at pagePath
+// until expires, and to the draft at pagePath when draft is set.
+func (s *Server) signShare(pagePath string, expires time.Time, draft bool) string {
+	payload := strconv.FormatInt(expires.Unix(), 10) + ":"
+	if draft {
+		payload += "draft:"
+	}
+	payload += pagePath
+	return base64.RawURLEncoding.EncodeToString([]byte(payload)) + "." + base64.RawURLEncoding.EncodeToString(s.shareMAC([]byte(payload)))
+}
+
+// shareMAC returns the signature of a token's payload. It covers the
+// site, so that a link to a page of one virtual host opens nothing on
+// another.
+func (s *Server) shareMAC(payload []byte) []byte {
+	mac := hmac.New(sha256.New, s.shareKey)
+	mac.Write([]byte(s.site + "\x00"))
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
+
+// verifyShare returns the page a token grants access to.
+func (s *Server) verifyShare(token string) (sharedPage, error) {
+	encoded, signature, ok := strings.Cut(token, ".")
+	if !ok {
+		return sharedPage{}, errShareInvalid
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return sharedPage{}, errShareInvalid
+	}
+	given, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil {
+		return sharedPage{}, errShareInvalid
+	}
+	if !hmac.Equal(given, s.shareMAC(payload)) {
+		return sharedPage{}, errShareInvalid
+	}
+	seconds, pagePath, ok := strings.Cut(string(payload), ":")
+	unix, err := strconv.ParseInt(seconds, 10, 64)
+	if !ok || err != nil {
+		return sharedPage{}, errShareInvalid
+	}
+	pagePath, draft := strings.CutPrefix(pagePath, "draft:")
+	expires := time.Unix(unix, 0).UTC()
+	if time.Now().After(expires) {
+		return sharedPage{}, errShareExpired
+	}
+	return sharedPage{path: pagePath, expires: expires, draft: draft}, nil
+}
+
+// handleShareLink signs a link to the page named by the path parameter
+// for the signed-in user, valid for the expires duration, 24 hours by
+// default and at most Options.ShareMaxAge.
+func (s *Server) handleShareLink(w http.ResponseWriter, r *http.Request) {
+	if s.currentUser(r) == "" {
+		http.NotFound(w, r)
+		return
+	}
+	ttl := min(defaultShareTTL, s.options.ShareMaxAge)
+	if value := r.URL.Query().Get("expires"); value != "" {
+		var err error
+		if ttl, err = time.ParseDuration(value); err != nil || ttl <= 0 || ttl > s.options.ShareMaxAge {
+			http.Error(w, "expires must be a duration such as 72h of at most "+s.options.ShareMaxAge.String(), http.StatusBadRequest)
+			return
+		}
+	}
+	name := s.canonicalPath(r.URL.Query().Get("path"))
+	if name == "" {
+		name = s.homeDocument()
+	}
+	pagePath := "/" + name
+	if name == "" || !s.hasDocument(name) || s.scheduleStatus(pagePath) != search.Published || s.hidesDraft(r, pagePath) {
+		http.Error(w, "No page at path", http.StatusNotFound)
+		return
+	}
+	if name == s.homeDocument() {
+		pagePath = "/"
+	}
+
+	expires := time.Now().Add(ttl).UTC().Truncate(time.Second)
+	s.audit(r, auditEvent{Action: auditShare, Path: pagePath})
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(shareLink{
+		URL:     s.absoluteURL(r, sharePrefix+s.signShare(pagePath, expires, s.index.IsDraft("/"+name))),
+		Path:    pagePath,
+		Expires: expires,
+	})
+}
+
+// handleShare opens a signed link: it keeps the token in a cookie, which
+// lets the page's files load, and redirects to the page.
+func (s *Server) handleShare(w http.ResponseWriter, r *http.Request) {
+	shared, err := s.verifyShare(strings.TrimPrefix(r.URL.Path, sharePrefix))
+	switch {
+	case errors.Is(err, errShareExpired):
+		http.Error(w, "This link has expired. Ask for a new one.", http.StatusGone)
+		return
+	case err != nil:
+		http.NotFound(w, r)
+		return
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     shareCookie,
+		Value:    strings.TrimPrefix(r.URL.Path, sharePrefix),
+		Path:     "/",
+		Expires:  shared.expires,
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+	})
+	http.Redirect(w, r, shared.path, http.StatusFound)
+}
+
+// shareBypass lets signed links, and requests for the shared page and its
+// files from visitors holding the link's cookie, past the site's
+// authentication. Signed-in users always get the authenticated site.
+func (s *Server) shareBypass(authenticated, site http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, sharePrefix) {
+			site.ServeHTTP(w, r)
+			return
+		}
+		if _, _, basic := r.BasicAuth(); !basic && s.currentUser(r) == "" {
+			if cookie, err := r.Cookie(shareCookie); err == nil {
+				owner := s.siteOf(r)
+				if shared, err := owner.verifyShare(cookie.Value); err == nil && owner.shareCovers(shared, r.URL.Path) {
+					site.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), sharedPageKey{}, shared)))
+					return
+				}
+			}
+		}
+		authenticated.ServeHTTP(w, r)
+	})
+}
+
+// shareCovers reports whether a signed link to a page grants access to
+// urlPath: the page itself, the site's stylesheet, and the files the
+// rendered page loads or links to, such as its images. The search index
+// below /static/ holds the text of every page and is not covered.
+func (s *Server) shareCovers(shared sharedPage, urlPath string) bool {
+	if urlPath == shared.path || strings.HasPrefix(urlPath, "/static/") && urlPath != searchIndexPath {
+		return true
+	}
+	return s.sharedFiles(shared)[urlPath]
+}
+
+// fileRef matches the attributes of rendered pages that refer to a file:
+// the src of images and players, the data-src of terminal recordings, the
+// poster of videos, and the href of links.
+var fileRef = regexp.MustCompile(`\s(?:src|data-src|poster|href)="([^"]*)"`)
+
+// sharedFiles returns the URL paths of the files of the site the shared
+// page refers to, resolved against its folder: the targets of its images,
+// players, and links, and its css: and js: assets. Only existing files
+// are returned, never pages or the site's routes.
+func (s *Server) sharedFiles(shared sharedPage) map[string]bool {
+	name := strings.TrimPrefix(shared.path, "/")
+	if name == "" {
+		name = s.homeDocument()
+	}
+	content, err := s.readDocument(name)
+	if err != nil {
+		return nil
+	}
+	frontmatter, content := renderer.ParseFrontmatter(content)
+	dir := path.Dir(name)
+	if dir == "." {
+		dir = ""
+	}
+	rendered, err := s.renderer.RenderWithLinks(content, dir)
+	if err != nil {
+		return nil
+	}
+	pagePath := "/" + name
+	files := make(map[string]bool)
+	add := func(ref string) {
+		u, err := url.Parse(html.UnescapeString(ref))
+		if err != nil || u.Scheme != "" || u.Host != "" || u.Path == "" {
+			return
+		}
+		file := u.Path
+		if !strings.HasPrefix(file, "/") {
+			file = path.Join(path.Dir(pagePath), file)
+		}
+		file = strings.TrimPrefix(path.Clean(file), "/")
+		if file == "." || strings.HasPrefix(file, ".") || strings.Contains(file, "/.") || s.hasDocument(file) {
+			return
+		}
+		if info, err := fs.Stat(s.fsys(), file); err == nil && info.Mode().IsRegular() {
+			files["/"+file] = true
+		}
+	}
+	for _, match := range fileRef.FindAllSubmatch(rendered, -1) {
+		add(string(match[1]))
+	}
+	styles, scripts, _ := s.pageAssets(name, frontmatter)
+	for _, asset := range append(styles, scripts...) {
+		add(asset)
+	}
+	if frontmatter.Logo != "" {
+		add(frontmatter.Logo)
+	}
+	return files
+}
+
+// sharedPageOf returns the page a request was let in for by a signed
+// link.
+func sharedPageOf(r *http.Request) (sharedPage, bool) {
+	shared, ok := r.Context().Value(sharedPageKey{}).(sharedPage)
+	return shared, ok
+}
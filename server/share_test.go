@@ -0,0 +1,132 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestShareLinks(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "guide"), 0o755)
+	os.WriteFile(filepath.Join(dir, "guide", "setup.md"), []byte("# Setup\n\n![diagram](diagram.png)\n\nUse search, compare, and files. See [private](../private.md).\n"), 0o644)
+	os.WriteFile(filepath.Join(dir, "guide", "diagram.png"), []byte("png"), 0o644)
+	os.WriteFile(filepath.Join(dir, "guide", "secret.png"), []byte("png"), 0o644)
+	os.WriteFile(filepath.Join(dir, "private.md"), []byte("# Private\n\nTOPSECRET\n"), 0o644)
+	s := NewWithAuth(dir, 0, "Docs", "writer", "secret", OAuth2Config{}, "", "test")
+	s.Configure(Options{ShareMaxAge: 48 * time.Hour, Attachments: []string{"png"}})
+	handler := s.Handler()
+
+	serve := func(req *http.Request) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+	create := func(query string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/share?"+query, nil)
+		req.SetBasicAuth("writer", "secret")
+		return serve(req)
+	}
+
+	if rec := serve(httptest.NewRequest(http.MethodGet, "/api/share?path=/guide/setup", nil)); rec.Code != http.StatusUnauthorized {
+		t.Errorf("expected creating links to need signing in, got %d", rec.Code)
+	}
+	if rec := create("path=/guide/setup&expires=72h"); rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 beyond the maximum age, got %d", rec.Code)
+	}
+	if rec := create("path=/missing"); rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for a missing page, got %d", rec.Code)
+	}
+	rec := create("path=/guide/setup.md&expires=2h")
+	var link shareLink
+	if err := json.NewDecoder(rec.Body).Decode(&link); err != nil || link.Path != "/guide/setup" {
+		t.Fatalf("unexpected link %d %+v (%v)", rec.Code, link, err)
+	}
+	if until := time.Until(link.Expires); until < time.Hour || until > 2*time.Hour {
+		t.Errorf("expected the link to expire in 2h, got %s", link.Expires)
+	}
+
+	u, _ := url.Parse(link.URL)
+	rec = serve(httptest.NewRequest(http.MethodGet, u.Path, nil))
+	if rec.Code != http.StatusFound || rec.Header().Get("Location") != "/guide/setup" {
+		t.Fatalf("expected a redirect to the page, got %d %q", rec.Code, rec.Header().Get("Location"))
+	}
+	cookie := rec.Result().Cookies()[0]
+
+	for page, want := range map[string]int{
+		"/guide/setup":                       http.StatusOK,
+		"/guide/diagram.png":                 http.StatusOK,
+		"/static/style.css":                  http.StatusOK,
+		"/guide/secret.png":                  http.StatusUnauthorized,
+		"/private":                           http.StatusUnauthorized,
+		"/":                                  http.StatusUnauthorized,
+		"/search":                            http.StatusUnauthorized,
+		searchIndexPath:                      http.StatusUnauthorized,
+		"/api/search?q=TOPSECRET":            http.StatusUnauthorized,
+		"/api/files":                         http.StatusUnauthorized,
+		"/api/preview/private":               http.StatusUnauthorized,
+		"/compare?a=/private&b=/guide/setup": http.StatusUnauthorized,
+	} {
+		req := httptest.NewRequest(http.MethodGet, page, nil)
+		req.AddCookie(cookie)
+		if rec := serve(req); rec.Code != want {
+			t.Errorf("%s: expected %d with the share cookie, got %d", page, want, rec.Code)
+		} else if page == "/guide/setup" && (!strings.Contains(rec.Body.String(), "This link is valid until") || strings.Contains(rec.Body.String(), `class="sidebar"`)) {
+			t.Errorf("expected the shared page without navigation, got\n%s", rec.Body.String())
+		}
+	}
+
+	tampered := strings.Replace(u.Path, "/share/", "/share/x", 1)
+	if rec := serve(httptest.NewRequest(http.MethodGet, tampered, nil)); rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for a tampered token, got %d", rec.Code)
+	}
+	expired := sharePrefix + s.signShare("/guide/setup", time.Now().Add(-time.Minute), false)
+	if rec := serve(httptest.NewRequest(http.MethodGet, expired, nil)); rec.Code != http.StatusGone {
+		t.Errorf("expected 410 for an expired link, got %d", rec.Code)
+	}
+}
+
+func TestShareLinksStayOnTheirHost(t *testing.T) {
+	mainDir, teamDir := t.TempDir(), t.TempDir()
+	os.WriteFile(filepath.Join(mainDir, "guide.md"), []byte("# Main Guide\n"), 0o644)
+	os.WriteFile(filepath.Join(teamDir, "guide.md"), []byte("# Team Guide\n"), 0o644)
+	s := NewWithAuth(mainDir, 0, "Docs", "writer", "secret", OAuth2Config{}, "", "test")
+	s.Configure(Options{ShareMaxAge: 48 * time.Hour, VirtualHosts: []VirtualHost{{Host: "docs.team-a.local", Dir: teamDir}}})
+	handler := s.Handler()
+
+	serve := func(host, target string, cookie *http.Cookie) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		req.Host = host
+		if cookie != nil {
+			req.AddCookie(cookie)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	token := s.signShare("/guide", time.Now().Add(time.Hour), false)
+	if rec := serve("docs.team-a.local", sharePrefix+token, nil); rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for a link of another host, got %d", rec.Code)
+	}
+	cookie := &http.Cookie{Name: shareCookie, Value: token}
+	if rec := serve("docs.team-a.local", "/guide", cookie); rec.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 with the cookie of another host, got %d", rec.Code)
+	}
+	if rec := serve("localhost", "/guide", cookie); rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "Main Guide") {
+		t.Errorf("expected the shared page on its own host, got %d", rec.Code)
+	}
+	team := &http.Cookie{Name: shareCookie, Value: s.vhosts["docs.team-a.local"].signShare("/guide", time.Now().Add(time.Hour), false)}
+	if rec := serve("docs.team-a.local", "/guide", team); rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "Team Guide") {
+		t.Errorf("expected the virtual host's shared page, got %d", rec.Code)
+	}
+	if rec := serve("localhost", "/guide", team); rec.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 on the main site with the cookie of a virtual host, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,48 @@
+package server
+
+import (
+	"encoding/xml"
+	"net/http"
+	"sort"
+)
+
+// sitemapNamespace is the XML namespace of the sitemap protocol.
+const sitemapNamespace = "http://www.sitemaps.org/schemas/sitemap/0.9"
+
+// sitemapURLSet is the root element of sitemap.xml.
+type sitemapURLSet struct {
+	XMLName xml.Name     `xml:"urlset"`
+	Xmlns   string       `xml:"xmlns,attr"`
+	URLs    []sitemapURL `xml:"url"`
+}
+
+// sitemapURL is one page in sitemap.xml.
+type sitemapURL struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod,omitempty"`
+}
+
+// handleSitemap responds with the published pages as a sitemap, sorted by
+// path, with the date of each page when known. The URLs are below
+// Options.BaseURL when set, otherwise on the host of the request.
+func (s *Server) handleSitemap(w http.ResponseWriter, r *http.Request) {
+	documents := s.indexFor(r).Documents()
+	sort.Slice(documents, func(i, j int) bool { return documents[i].Path < documents[j].Path })
+
+	set := sitemapURLSet{Xmlns: sitemapNamespace, URLs: []sitemapURL{{Loc: s.absoluteURL(r, "/")}}}
+	for _, doc := range documents {
+		entry := sitemapURL{Loc: s.absoluteURL(r, doc.Path)}
+		if entry.Loc == set.URLs[0].Loc {
+			continue
+		}
+		if date, ok := s.index.Date(doc.Path); ok {
+			entry.LastMod = date.Format("2006-01-02")
+		}
+		set.URLs = append(set.URLs, entry)
+	}
+	w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+	w.Write([]byte(xml.Header))
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	encoder.Encode(set)
+}
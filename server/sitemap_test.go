@@ -0,0 +1,38 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSitemap(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "index.md"), []byte("# Home\n"), 0o644)
+	os.WriteFile(filepath.Join(dir, "install.md"), []byte("---\ndate: 2024-03-01\n---\n# Install\n"), 0o644)
+	os.WriteFile(filepath.Join(dir, "later.md"), []byte("---\npublish_at: 2999-01-01\n---\n# Later\n"), 0o644)
+	s := NewWithAuth(dir, 0, "Docs", "", "", OAuth2Config{}, "", "test")
+	s.Configure(Options{BaseURL: "https://docs.example.com"})
+
+	rec := httptest.NewRecorder()
+	s.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/sitemap.xml", nil))
+	if rec.Code != http.StatusOK || !strings.HasPrefix(rec.Header().Get("Content-Type"), "application/xml") {
+		t.Fatalf("expected XML, got %d %q", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	body := rec.Body.String()
+	for _, want := range []string{
+		`<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">`,
+		"<loc>https://docs.example.com/</loc>",
+		"<loc>https://docs.example.com/install</loc>\n    <lastmod>2024-03-01</lastmod>",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected %q in\n%s", want, body)
+		}
+	}
+	if strings.Count(body, "<url>") != 2 || strings.Contains(body, "later") {
+		t.Errorf("expected the home page once and no scheduled pages, got\n%s", body)
+	}
+}
@@ -0,0 +1,190 @@
+package server
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"gomdoc/search"
+	"gomdoc/templates"
+)
+
+// slackCommandPath is the route answering Slack slash commands.
+const slackCommandPath = "/api/slack/command"
+
+// slackResults is the number of pages a slash command search lists.
+const slackResults = 5
+
+// slackMaxAge is how old a signed Slack request may be, guarding against
+// replayed requests.
+const slackMaxAge = 5 * time.Minute
+
+// maxSlackBody caps the size of a slash command request.
+const maxSlackBody = 64 << 10
+
+// slackMessage is a Slack message with Block Kit blocks.
+type slackMessage struct {
+	ResponseType string       `json:"response_type,omitempty"`
+	Text         string       `json:"text,omitempty"`
+	Blocks       []slackBlock `json:"blocks"`
+}
+
+// slackBlock is a section or context block.
+type slackBlock struct {
+	Type     string      `json:"type"`
+	Text     *slackText  `json:"text,omitempty"`
+	Elements []slackText `json:"elements,omitempty"`
+}
+
+// slackText is a mrkdwn text object.
+type slackText struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+// handleSlackCommand answers a Slack slash command such as
+// "/docs search foo" with the best matching pages, visible only to the
+// user who asked. With Options.SlackSigningSecret, requests must carry a
+// valid Slack signature and skip the site's authentication.
+func (s *Server) handleSlackCommand(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		methodNotAllowed(w, http.MethodPost)
+		return
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxSlackBody))
+	if err != nil {
+		http.Error(w, "Request too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+	if secret := s.options.SlackSigningSecret; secret != "" {
+		err := verifySlackSignature(secret, r.Header.Get("X-Slack-Request-Timestamp"), r.Header.Get("X-Slack-Signature"), body, time.Now())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+	}
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		http.Error(w, "Invalid form", http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.slackCommand(r, form.Get("command"), form.Get("text")))
+}
+
+// slackCommand returns the reply to a slash command with the given text.
+// The text is a search query, optionally after the word search; help or
+// nothing shows the usage.
+func (s *Server) slackCommand(r *http.Request, command, text string) slackMessage {
+	if command == "" {
+		command = "/docs"
+	}
+	query := strings.TrimSpace(text)
+	if fields := strings.Fields(query); len(fields) > 0 && strings.EqualFold(fields[0], "search") {
+		query = strings.TrimSpace(query[len(fields[0]):])
+	}
+	if query == "" || strings.EqualFold(query, "help") {
+		return slackReply("Usage: `" + command + " search <words>` finds pages of " + slackEscape(s.title) +
+			". Filters such as `tag:setup` and `author:ana` work as on the search page.")
+	}
+
+	terms, filter, err := searchFilter(url.Values{"q": {query}})
+	if err != nil {
+		return slackReply("Invalid search: " + slackEscape(err.Error()))
+	}
+	results := s.indexFor(r).SearchFiltered(terms, filter, slackResults)
+	if len(results) == 0 {
+		return slackReply("No pages found for _" + slackEscape(query) + "_.")
+	}
+
+	more := s.absoluteURL(r, "/search") + "?" + url.Values{"q": {query}}.Encode()
+	reply := slackReply("Pages for _" + slackEscape(query) + "_:")
+	for _, result := range results {
+		summary := result.Summary
+		if summary == "" {
+			summary = result.Snippet
+		}
+		data := s.previewUnfurl(r, search.Preview{Title: result.Title, Path: result.Path, Summary: summary})
+		reply.Blocks = append(reply.Blocks, slackPageBlocks(data)...)
+	}
+	reply.Blocks = append(reply.Blocks, slackContext("<"+more+"|All results on "+slackEscape(s.title)+">"))
+	return reply
+}
+
+// slackReply returns an ephemeral reply consisting of text.
+func slackReply(text string) slackMessage {
+	return slackMessage{
+		ResponseType: "ephemeral",
+		Text:         text,
+		Blocks:       []slackBlock{{Type: "section", Text: &slackText{Type: "mrkdwn", Text: text}}},
+	}
+}
+
+// slackPageBlocks returns the blocks showing a page: its linked title and
+// description, then the site and date.
+func slackPageBlocks(data templates.UnfurlData) []slackBlock {
+	text := "*<" + data.URL + "|" + slackEscape(data.Title) + ">*"
+	if data.Description != "" {
+		text += "\n" + slackEscape(data.Description)
+	}
+	return []slackBlock{
+		{Type: "section", Text: &slackText{Type: "mrkdwn", Text: text}},
+		slackContext(slackEscape(unfurlContext(data))),
+	}
+}
+
+// slackContext returns a context block with one mrkdwn element.
+func slackContext(text string) slackBlock {
+	return slackBlock{Type: "context", Elements: []slackText{{Type: "mrkdwn", Text: text}}}
+}
+
+// slackEscaper escapes the characters Slack reserves for links and
+// mentions.
+var slackEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+// slackEscape escapes text for a mrkdwn field.
+func slackEscape(text string) string {
+	return slackEscaper.Replace(text)
+}
+
+// verifySlackSignature checks the signature Slack computes over the
+// request timestamp and body with the app's signing secret.
+func verifySlackSignature(secret, timestamp, signature string, body []byte, now time.Time) error {
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return errors.New("missing Slack request timestamp")
+	}
+	if age := now.Sub(time.Unix(seconds, 0)); age > slackMaxAge || age < -slackMaxAge {
+		return errors.New("stale Slack request")
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("v0:" + timestamp + ":"))
+	mac.Write(body)
+	expected := "v0=" + hex.EncodeToString(mac.Sum(nil))
+	if !hmac.Equal([]byte(expected), []byte(signature)) {
+		return errors.New("invalid Slack signature")
+	}
+	return nil
+}
+
+// slackBypass sends signed slash commands to site past the
+// authentication of authenticated, since Slack cannot sign in. Their
+// signature is checked by handleSlackCommand instead.
+func (s *Server) slackBypass(authenticated, site http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == slackCommandPath {
+			site.ServeHTTP(w, r)
+			return
+		}
+		authenticated.ServeHTTP(w, r)
+	})
+}
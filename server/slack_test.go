@@ -0,0 +1,74 @@
+package server
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// slackRequest returns a slash command request signed with secret.
+func slackRequest(secret, text string, sent time.Time) *http.Request {
+	body := url.Values{"command": {"/docs"}, "text": {text}}.Encode()
+	timestamp := strconv.FormatInt(sent.Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("v0:" + timestamp + ":" + body))
+	req := httptest.NewRequest(http.MethodPost, slackCommandPath, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("X-Slack-Request-Timestamp", timestamp)
+	req.Header.Set("X-Slack-Signature", "v0="+hex.EncodeToString(mac.Sum(nil)))
+	return req
+}
+
+func TestSlackCommand(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "backup.md"), []byte("# Backups\n\nRestore a backup with the restore command.\n"), 0o644)
+	s := NewWithAuth(dir, 0, "Docs", "admin", "secret", OAuth2Config{}, "", "test")
+	s.Configure(Options{SlackSigningSecret: "shh"})
+	handler := s.Handler()
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, slackRequest("shh", "search restore", time.Now()))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200 past basic auth, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var reply slackMessage
+	if err := json.Unmarshal(rec.Body.Bytes(), &reply); err != nil {
+		t.Fatal(err)
+	}
+	if reply.ResponseType != "ephemeral" || len(reply.Blocks) != 4 || !strings.Contains(reply.Blocks[1].Text.Text, "/backup|backup>*") {
+		t.Errorf("unexpected reply %+v", reply)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, slackRequest("shh", "help", time.Now()))
+	if !strings.Contains(rec.Body.String(), "Usage: `/docs search") {
+		t.Errorf("expected usage, got %s", rec.Body.String())
+	}
+
+	for name, req := range map[string]*http.Request{
+		"wrong secret": slackRequest("guess", "restore", time.Now()),
+		"replayed":     slackRequest("shh", "restore", time.Now().Add(-time.Hour)),
+	} {
+		rec = httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != http.StatusUnauthorized {
+			t.Errorf("%s: expected 401, got %d", name, rec.Code)
+		}
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/unfurl?url=/backup", nil))
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("expected other routes to need sign-in, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,36 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// refresher is implemented by sources that can reload their listing, such
+// as object storage.
+type refresher interface {
+	Refresh(ctx context.Context) error
+}
+
+// refreshSource reloads the source every interval and rebuilds the search
+// indexes, so pages published to object storage appear without a restart.
+// Sources that cannot refresh are left alone.
+func (s *Server) refreshSource(interval time.Duration) {
+	source, ok := s.source().(refresher)
+	if !ok {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		s.jobs.Submit(s.jobName("source refresh"), func(ctx context.Context) error {
+			ctx, cancel := context.WithTimeout(ctx, interval)
+			defer cancel()
+			if err := source.Refresh(ctx); err != nil {
+				return fmt.Errorf("refreshing source: %w", err)
+			}
+			s.content.current.Add(1)
+			return s.syncIndexes()
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestServeFromSource(t *testing.T) {
+	files := fstest.MapFS{
+		"guide/setup.md": {Data: []byte("---\ntitle: Setup Guide\n---\n# Setup\n\nInstall the widget.\n")},
+		"refs.bib":       {Data: []byte("@book{knuth, title={The Art of Computer Programming}, author={Knuth, Donald}, year={1968}}\n")},
+		"cited.md":       {Data: []byte("---\nbibliography: refs.bib\n---\n# Cited\n\nSee [@knuth].\n")},
+	}
+	s := NewWithAuth(t.TempDir(), 0, "Docs", "", "", OAuth2Config{}, "", "test")
+	s.Configure(Options{Source: files})
+	handler := s.Handler()
+
+	get := func(target string) string {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: expected 200, got %d", target, rec.Code)
+		}
+		return rec.Body.String()
+	}
+
+	if body := get("/guide/setup"); !strings.Contains(body, "Install the widget.") {
+		t.Errorf("expected page from the source, got:\n%s", body)
+	}
+	if body := get("/browse"); !strings.Contains(body, `href="/guide/setup"`) {
+		t.Errorf("expected source page in the tree, got:\n%s", body)
+	}
+	if body := get("/api/search?q=widget"); !strings.Contains(body, `"/guide/setup"`) {
+		t.Errorf("expected source page in search, got %s", body)
+	}
+	if body := get("/cited"); !strings.Contains(body, "The Art of Computer Programming") {
+		t.Errorf("expected bibliography read from the source, got:\n%s", body)
+	}
+}
+
+func TestNewFS(t *testing.T) {
+	files := fstest.MapFS{
+		"index.md": {Data: []byte("# Welcome\n\nServed from memory.\n")},
+	}
+	handler := NewFS(files, 0, "Docs", "", "", OAuth2Config{}, "", "test").Handler()
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/index", nil))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "Served from memory.") {
+		t.Errorf("expected page from the injected file system, got %d:\n%s", rec.Code, rec.Body.String())
+	}
+}
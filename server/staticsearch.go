@@ -0,0 +1,61 @@
+package server
+
+import (
+	"encoding/json"
+	"html/template"
+	"log"
+	"net/http"
+	"strings"
+
+	"gomdoc/search"
+	"gomdoc/templates"
+)
+
+// searchIndexPath is the client-side search index searched by the search
+// page and, on exported sites, by the search box.
+const searchIndexPath = "/static/search-index.json"
+
+// handleSearchIndex responds with the published documents and their text
+// as JSON for searching in the browser.
+func (s *Server) handleSearchIndex(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.indexFor(r).StaticDocuments())
+}
+
+// searchPageResults is the most results the search page lists.
+const searchPageResults = 50
+
+// handleSearchPage renders the search page with the results for its q
+// parameter, so it works without JavaScript. Exported sites request the
+// page without a query and search the client-side index in the browser.
+func (s *Server) handleSearchPage(w http.ResponseWriter, r *http.Request) {
+	data := templates.SearchData{
+		SiteLayout: s.layout(),
+		Query:      r.URL.Query().Get("q"),
+	}
+	if strings.TrimSpace(data.Query) != "" {
+		data.Searched = true
+		if query, filter, err := searchFilter(r.URL.Query()); err != nil {
+			data.Error = err.Error()
+		} else {
+			data.Results = s.searchPage(s.indexFor(r), query, filter)
+		}
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := templates.RenderSearch(w, data); err != nil {
+		log.Printf("Error rendering search page: %v", err)
+	}
+}
+
+// searchPage runs a search of index for the search page, with snippets as HTML.
+func (s *Server) searchPage(index *search.Index, query string, filter search.Filter) []templates.SearchResult {
+	var results []templates.SearchResult
+	for _, result := range index.SearchFiltered(query, filter, searchPageResults) {
+		snippet := result.Highlighted
+		if snippet == "" {
+			snippet = template.HTMLEscapeString(result.Snippet)
+		}
+		results = append(results, templates.SearchResult{Title: result.Title, Path: result.Path, Snippet: template.HTML(snippet)})
+	}
+	return results
+}
@@ -0,0 +1,49 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gomdoc/search"
+	"gomdoc/static"
+)
+
+func TestStaticSearchIndex(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "install.md"), []byte("---\ntitle: Install Guide\ntags: [setup]\n---\n# Install\n\n## Linux\n\nRun the installer.\n"), 0o644)
+	os.WriteFile(filepath.Join(dir, "later.md"), []byte("---\npublish_at: 2999-01-01\n---\n# Later\n"), 0o644)
+
+	handler := NewWithAuth(dir, 0, "Docs", "", "", OAuth2Config{}, "", "test").Handler()
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/static/search-index.json", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	var docs []search.StaticDocument
+	if err := json.Unmarshal(rec.Body.Bytes(), &docs); err != nil {
+		t.Fatalf("invalid search index: %v", err)
+	}
+	if len(docs) != 1 {
+		t.Fatalf("expected only the published page indexed, got %+v", docs)
+	}
+	doc := docs[0]
+	if doc.Title != "Install Guide" || doc.Path != "/install" || !strings.Contains(doc.Text, "Run the installer.") {
+		t.Errorf("unexpected document %+v", doc)
+	}
+	if strings.Join(doc.Headings, ",") != "Install,Linux" || strings.Join(doc.Tags, ",") != "setup" {
+		t.Errorf("expected headings and tags, got %+v", doc)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/search?q=install", nil))
+	body := rec.Body.String()
+	if rec.Code != http.StatusOK || !strings.Contains(body, `id="search-page-input"`) || !strings.Contains(body, static.URL("gomdoc.js")) {
+		t.Errorf("expected search page, got %d:\n%s", rec.Code, body)
+	}
+}
@@ -0,0 +1,129 @@
+package server
+
+import (
+	"encoding/json"
+	"html/template"
+	"log"
+	"net/url"
+	"strings"
+
+	"gomdoc/renderer"
+	"gomdoc/scanner"
+	"gomdoc/search"
+	"gomdoc/templates"
+)
+
+// The schema.org JSON-LD of a page: the article and the breadcrumb trail
+// leading to it.
+type (
+	jsonLD struct {
+		Context string `json:"@context"`
+		Graph   []any  `json:"@graph"`
+	}
+	jsonLDArticle struct {
+		Type             string        `json:"@type"`
+		Headline         string        `json:"headline"`
+		Description      string        `json:"description,omitempty"`
+		Author           []jsonLDThing `json:"author,omitempty"`
+		DatePublished    string        `json:"datePublished,omitempty"`
+		DateModified     string        `json:"dateModified,omitempty"`
+		Keywords         string        `json:"keywords,omitempty"`
+		Version          string        `json:"version,omitempty"`
+		URL              string        `json:"url"`
+		MainEntityOfPage string        `json:"mainEntityOfPage"`
+		IsPartOf         jsonLDThing   `json:"isPartOf"`
+	}
+	jsonLDThing struct {
+		Type string `json:"@type"`
+		Name string `json:"name"`
+		URL  string `json:"url,omitempty"`
+	}
+	jsonLDBreadcrumbs struct {
+		Type            string           `json:"@type"`
+		ItemListElement []jsonLDListItem `json:"itemListElement"`
+	}
+	jsonLDListItem struct {
+		Type     string `json:"@type"`
+		Position int    `json:"position"`
+		Name     string `json:"name"`
+		Item     string `json:"item"`
+	}
+)
+
+// structuredData returns the schema.org JSON-LD of the page at pagePath
+// for search engines: an Article or TechArticle with its title,
+// description, authors, dates, tags, and version, and the BreadcrumbList
+// of the folders leading to it in tree. It is empty without
+// Options.BaseURL, since search engines need absolute URLs.
+func (s *Server) structuredData(pagePath, title, description string, fm renderer.Frontmatter, tree *scanner.TreeNode) template.JS {
+	pageURL := s.canonicalURL(pagePath)
+	if pageURL == "" {
+		return ""
+	}
+	schemaType, err := search.ParseSchemaType(fm)
+	if err != nil {
+		log.Printf("Warning: %s: %v", pagePath, err)
+	}
+	siteURL := s.canonicalURL("/")
+	article := jsonLDArticle{
+		Type:             schemaType,
+		Headline:         title,
+		Description:      description,
+		Keywords:         strings.Join(fm.Tags, ", "),
+		Version:          fm.Version,
+		URL:              pageURL,
+		MainEntityOfPage: pageURL,
+		IsPartOf:         jsonLDThing{Type: "WebSite", Name: s.title, URL: siteURL},
+	}
+	for _, name := range strings.Split(fm.Author, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			article.Author = append(article.Author, jsonLDThing{Type: "Person", Name: name})
+		}
+	}
+	if published, err := search.ParseDate(fm.Date); fm.Date != "" && err == nil {
+		article.DatePublished = published.Format("2006-01-02")
+	}
+	if modified, ok := search.FrontmatterDate(fm); ok {
+		article.DateModified = modified.Format("2006-01-02")
+	} else if modified, ok := s.index.Date(pagePath); ok {
+		article.DateModified = modified.Format("2006-01-02")
+	}
+
+	data := jsonLD{Context: "https://schema.org", Graph: []any{article}}
+	if pageURL != siteURL {
+		data.Graph = append(data.Graph, s.breadcrumbList(pagePath, title, tree))
+	}
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		log.Printf("Warning: %s: encoding structured data: %v", pagePath, err)
+		return ""
+	}
+	// json.Marshal escapes <, >, and &, so the data cannot end the script
+	// element it is written into.
+	return template.JS(encoded)
+}
+
+// breadcrumbList returns the trail from the home page through the folders
+// of the page at pagePath to the page. Folders are named as in the
+// navigation tree, or by their path segment when the page is not in it,
+// and link to their listings.
+func (s *Server) breadcrumbList(pagePath, title string, tree *scanner.TreeNode) jsonLDBreadcrumbs {
+	segments := strings.Split(strings.Trim(pagePath, "/"), "/")
+	folders := templates.Ancestors(tree, pagePath)
+	list := jsonLDBreadcrumbs{Type: "BreadcrumbList"}
+	add := func(name, item string) {
+		list.ItemListElement = append(list.ItemListElement, jsonLDListItem{
+			Type: "ListItem", Position: len(list.ItemListElement) + 1, Name: name, Item: item,
+		})
+	}
+	add(s.title, s.canonicalURL("/"))
+	for i, segment := range segments[:len(segments)-1] {
+		name, _ := url.PathUnescape(segment)
+		if len(folders) == len(segments) {
+			name = folders[i+1].Name
+		}
+		add(name, s.canonicalURL("/"+strings.Join(segments[:i+1], "/")))
+	}
+	add(title, s.canonicalURL(pagePath))
+	return list
+}
@@ -0,0 +1,88 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// jsonLDScript matches the structured data in a page's head.
+var jsonLDScript = regexp.MustCompile(`<script type="application/ld\+json">(.*?)</script>`)
+
+func TestStructuredData(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "guide"), 0o755)
+	os.WriteFile(filepath.Join(dir, "index.md"), []byte("# Welcome\n"), 0o644)
+	os.WriteFile(filepath.Join(dir, "guide", "setup.md"), []byte("---\ntitle: Setup </script>\ndescription: Install & run\n"+
+		"author: Jane Doe, Max\ndate: 2024-03-01\nupdated: 15 January 2025\ntags: install, linux\nversion: 2.1\n---\n# Setup\n"), 0o644)
+	os.WriteFile(filepath.Join(dir, "guide", "notes.md"), []byte("---\nschema_type: article\n---\n# Notes\n"), 0o644)
+
+	page := func(s *Server, target string) string {
+		rec := httptest.NewRecorder()
+		s.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		return rec.Body.String()
+	}
+	s := NewWithAuth(dir, 0, "Docs", "", "", OAuth2Config{}, "", "test")
+	s.Configure(Options{BaseURL: "https://docs.example.com/"})
+
+	body := page(s, "/guide/setup")
+	m := jsonLDScript.FindStringSubmatch(body)
+	if m == nil {
+		t.Fatalf("no JSON-LD in page head:\n%s", body)
+	}
+	if strings.Contains(m[1], "</script>") || strings.Contains(m[1], "&amp;") {
+		t.Errorf("JSON-LD not escaped for the script element: %s", m[1])
+	}
+	var data struct {
+		Context string           `json:"@context"`
+		Graph   []map[string]any `json:"@graph"`
+	}
+	if err := json.Unmarshal([]byte(m[1]), &data); err != nil {
+		t.Fatalf("invalid JSON-LD %s: %v", m[1], err)
+	}
+	if data.Context != "https://schema.org" || len(data.Graph) != 2 {
+		t.Fatalf("unexpected JSON-LD %s", m[1])
+	}
+	article, breadcrumbs := data.Graph[0], data.Graph[1]
+	for key, want := range map[string]any{
+		"@type":         "TechArticle",
+		"headline":      "Setup </script>",
+		"description":   "Install & run",
+		"datePublished": "2024-03-01",
+		"dateModified":  "2025-01-15",
+		"keywords":      "install, linux",
+		"version":       "2.1",
+		"url":           "https://docs.example.com/guide/setup",
+	} {
+		if article[key] != want {
+			t.Errorf("article %s = %v, want %v", key, article[key], want)
+		}
+	}
+	if authors, _ := json.Marshal(article["author"]); string(authors) != `[{"@type":"Person","name":"Jane Doe"},{"@type":"Person","name":"Max"}]` {
+		t.Errorf("article authors = %s", authors)
+	}
+	items, _ := json.Marshal(breadcrumbs["itemListElement"])
+	want := `[{"@type":"ListItem","item":"https://docs.example.com/","name":"Docs","position":1},` +
+		`{"@type":"ListItem","item":"https://docs.example.com/guide","name":"guide","position":2},` +
+		`{"@type":"ListItem","item":"https://docs.example.com/guide/setup","name":"Setup \u003c/script\u003e","position":3}]`
+	if breadcrumbs["@type"] != "BreadcrumbList" || string(items) != want {
+		t.Errorf("breadcrumbs = %s\nwant %s", items, want)
+	}
+
+	if m := jsonLDScript.FindStringSubmatch(page(s, "/guide/notes")); m == nil || !strings.Contains(m[1], `"@type":"Article"`) {
+		t.Errorf("schema_type: article not applied: %v", m)
+	}
+	if m := jsonLDScript.FindStringSubmatch(page(s, "/")); m == nil || strings.Contains(m[1], "BreadcrumbList") {
+		t.Errorf("home page JSON-LD = %v, want an article without breadcrumbs", m)
+	}
+
+	local := NewWithAuth(dir, 0, "Docs", "", "", OAuth2Config{}, "", "test")
+	if strings.Contains(page(local, "/guide/setup"), "application/ld+json") {
+		t.Error("JSON-LD written without a base URL")
+	}
+}
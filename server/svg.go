@@ -0,0 +1,35 @@
+package server
+
+import (
+	"bytes"
+	"io/fs"
+	"net/http"
+	"path"
+	"strings"
+
+	"gomdoc/sanitize"
+)
+
+// isSVG reports whether urlPath names an SVG image.
+func isSVG(urlPath string) bool {
+	return strings.EqualFold(path.Ext(urlPath), ".svg")
+}
+
+// serveSVG serves the SVG file at urlPath without scripts or event
+// handlers, for Options.SafeMode. The policy header stops scripts that
+// slip through from running when the file is opened directly.
+func (s *Server) serveSVG(w http.ResponseWriter, r *http.Request, urlPath string, info fs.FileInfo) {
+	data, err := fs.ReadFile(s.fsys(), urlPath)
+	if err != nil {
+		http.Error(w, "Error reading file", http.StatusInternalServerError)
+		return
+	}
+	clean, err := sanitize.SVG(data)
+	if err != nil {
+		http.Error(w, "Error sanitizing SVG", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "image/svg+xml")
+	w.Header().Set("Content-Security-Policy", "script-src 'none'")
+	http.ServeContent(w, r, urlPath, info.ModTime(), bytes.NewReader(clean))
+}
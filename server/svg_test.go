@@ -0,0 +1,42 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSafeModeSanitizesSVG(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "logo.svg"), []byte(`<svg xmlns="http://www.w3.org/2000/svg" onload="alert(1)"><script>alert(2)</script><circle r="4"/></svg>`), 0o644)
+	os.WriteFile(filepath.Join(dir, "page.md"), []byte("# Page\n\n<svg onload=\"alert(3)\"><circle r=\"2\"/></svg>\n"), 0o644)
+
+	get := func(handler http.Handler, target string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		return rec
+	}
+
+	s := NewWithAuth(dir, 0, "Docs", "", "", OAuth2Config{}, "", "test")
+	s.Configure(Options{Attachments: []string{"svg"}})
+	if body := get(s.Handler(), "/logo.svg").Body.String(); !strings.Contains(body, "alert(2)") {
+		t.Errorf("expected SVG served as is without safe mode, got:\n%s", body)
+	}
+
+	s = NewWithAuth(dir, 0, "Docs", "", "", OAuth2Config{}, "", "test")
+	s.Configure(Options{Attachments: []string{"svg"}, SafeMode: true})
+	handler := s.Handler()
+	rec := get(handler, "/logo.svg")
+	if rec.Code != http.StatusOK || strings.Contains(rec.Body.String(), "alert") || !strings.Contains(rec.Body.String(), "<circle") {
+		t.Errorf("expected sanitized SVG, got %d:\n%s", rec.Code, rec.Body.String())
+	}
+	if got := rec.Header().Get("Content-Type"); got != "image/svg+xml" {
+		t.Errorf("expected image/svg+xml, got %q", got)
+	}
+	if body := get(handler, "/page").Body.String(); strings.Contains(body, "alert(3)") || !strings.Contains(body, `<circle r="2">`) {
+		t.Errorf("expected inline SVG sanitized, got:\n%s", body)
+	}
+}
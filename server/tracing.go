@@ -0,0 +1,56 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+
+	"gomdoc/tracing"
+)
+
+// traceRequests records a server span for every request while tracing is
+// configured, continuing the trace of an incoming traceparent header, so
+// the spans below it, such as scanning, rendering, and template
+// execution, join the trace of the proxy in front of gomdoc. The span
+// carries the request ID, tying the trace to the log lines, and the user
+// agent outside privacy mode.
+func (s *Server) traceRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !tracing.Enabled() {
+			next.ServeHTTP(w, r)
+			return
+		}
+		ctx := tracing.WithRemoteParent(r.Context(), r.Header.Get(tracing.TraceparentHeader))
+		ctx, span := tracing.StartKind(ctx, r.Method, tracing.Server)
+		span.SetAttribute("http.request.method", r.Method)
+		span.SetAttribute("url.path", r.URL.Path)
+		span.SetAttribute("gomdoc.request_id", requestIDOf(r))
+		if agent := r.UserAgent(); agent != "" && !s.options.Privacy {
+			span.SetAttribute("user_agent.original", agent)
+		}
+		rw := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		defer func() {
+			span.SetAttribute("http.response.status_code", rw.status)
+			if v := recover(); v != nil {
+				span.SetError(fmt.Errorf("panic: %v", v))
+				span.End()
+				panic(v)
+			}
+			if rw.status >= 500 {
+				span.SetError(fmt.Errorf("%d %s", rw.status, http.StatusText(rw.status)))
+			}
+			span.End()
+		}()
+		next.ServeHTTP(rw, r.WithContext(ctx))
+	})
+}
+
+// traceTemplate runs execute, which writes the named template, in a
+// template span of the request.
+func traceTemplate(r *http.Request, name string, execute func() error) error {
+	_, span := tracing.Start(r.Context(), "template")
+	span.SetAttribute("gomdoc.template", name)
+	err := execute()
+	span.SetError(err)
+	span.End()
+	return err
+}
@@ -0,0 +1,100 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"gomdoc/tracing"
+)
+
+func TestTraceRequests(t *testing.T) {
+	var mu sync.Mutex
+	var spans []map[string]any
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ResourceSpans []struct {
+				ScopeSpans []struct{ Spans []map[string]any }
+			}
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		mu.Lock()
+		defer mu.Unlock()
+		for _, rs := range req.ResourceSpans {
+			for _, ss := range rs.ScopeSpans {
+				spans = append(spans, ss.Spans...)
+			}
+		}
+	}))
+	defer collector.Close()
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "intro.md"), []byte("# Intro\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	handler := NewWithAuth(dir, 0, "Docs", "", "", OAuth2Config{}, "", "test").Handler()
+
+	shutdown, err := tracing.Configure(tracing.Config{Endpoint: collector.URL, SampleRatio: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer shutdown(context.Background())
+
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	req := httptest.NewRequest(http.MethodGet, "/intro", nil)
+	req.Header.Set("traceparent", "00-"+traceID+"-00f067aa0ba902b7-01")
+	req.Header.Set(requestIDHeader, "req-1")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /intro = %d", rec.Code)
+	}
+	if err := shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	byName := make(map[string]map[string]any)
+	for _, span := range spans {
+		if span["traceId"] != traceID {
+			t.Errorf("span %v is outside the incoming trace", span["name"])
+		}
+		byName[span["name"].(string)] = span
+	}
+	root, ok := byName["GET"]
+	if !ok || root["parentSpanId"] != "00f067aa0ba902b7" {
+		t.Fatalf("server span = %v (spans %v)", root, spans)
+	}
+	if !hasAttribute(root, "gomdoc.request_id", "req-1") || !hasAttribute(root, "url.path", "/intro") {
+		t.Errorf("server span attributes = %v", root["attributes"])
+	}
+	for _, name := range []string{"scan", "render", "template"} {
+		span, ok := byName[name]
+		if !ok {
+			t.Errorf("no %s span", name)
+			continue
+		}
+		if span["parentSpanId"] != root["spanId"] {
+			t.Errorf("%s span is not a child of the request", name)
+		}
+	}
+	if !hasAttribute(byName["render"], "gomdoc.page", "/intro") {
+		t.Errorf("render span attributes = %v", byName["render"]["attributes"])
+	}
+}
+
+// hasAttribute reports whether span has a string attribute key = value.
+func hasAttribute(span map[string]any, key, value string) bool {
+	attributes, _ := span["attributes"].([]any)
+	for _, a := range attributes {
+		a := a.(map[string]any)
+		if a["key"] == key && a["value"].(map[string]any)["stringValue"] == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,102 @@
+package server
+
+import (
+	"encoding/json"
+	"html/template"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+
+	"gomdoc/scanner"
+)
+
+// treePath is the route of the navigation tree API; the folder follows it,
+// e.g. /api/tree/guide.
+const treePath = "/api/tree"
+
+// treeListing is a node of the /api/tree response.
+type treeListing struct {
+	Name string `json:"name"`
+	// Path is the URL of the page or folder.
+	Path       string `json:"path"`
+	Dir        bool   `json:"dir,omitempty"`
+	Attachment bool   `json:"attachment,omitempty"`
+	// Preview is the route of an attachment's HTML preview, if any.
+	Preview string `json:"preview,omitempty"`
+	// Count is the number of entries of a folder, also when Children
+	// leaves them out below the requested depth.
+	Count    int           `json:"count,omitempty"`
+	Children []treeListing `json:"children,omitempty"`
+}
+
+// renderTree renders the navigation tree with the page at currentPath
+// highlighted, leaving collapsed folders to the browser with LazyTree.
+func (s *Server) renderTree(tree *scanner.TreeNode, currentPath string) template.HTML {
+	if s.options.LazyTree && !s.options.NoJS {
+		return template.HTML(scanner.RenderTreeLazy(tree, currentPath))
+	}
+	return template.HTML(scanner.RenderTreeWithActive(tree, currentPath))
+}
+
+// handleTree responds with a folder of the navigation tree as JSON, down
+// to the depth parameter (1, its entries, by default), so that the
+// sidebar can load folders as they are opened. The offset and limit
+// parameters select a page of the folder's entries.
+func (s *Server) handleTree(w http.ResponseWriter, r *http.Request) {
+	depth := 1
+	if value := r.URL.Query().Get("depth"); value != "" {
+		var err error
+		if depth, err = strconv.Atoi(value); err != nil || depth < 1 {
+			http.Error(w, "invalid depth "+strconv.Quote(value)+": use a number of levels from 1", http.StatusBadRequest)
+			return
+		}
+	}
+	offset, limit, err := pageRange(r, 0)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	entries, err := s.visibleEntries(r)
+	if err != nil {
+		http.Error(w, "Error scanning directory", http.StatusInternalServerError)
+		return
+	}
+	dir := path.Clean("/" + strings.TrimPrefix(r.URL.Path, treePath))
+	node := scanner.Subtree(s.buildTree(entries), dir)
+	if node == nil {
+		http.Error(w, "Folder not found", http.StatusNotFound)
+		return
+	}
+
+	listing := treeListing{Name: path.Base(dir), Path: dir, Dir: true, Count: len(node.Children)}
+	if dir == "/" {
+		listing.Name = ""
+	}
+	start, end := pageBounds(len(node.Children), offset, limit)
+	listing.Children = listTree(node.Children[start:end], dir, depth)
+	if r.URL.Query().Has("offset") || r.URL.Query().Has("limit") {
+		setPageHeaders(w, r, len(node.Children), end)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(listing)
+}
+
+// listTree converts the entries of the folder at dir, descending depth
+// levels.
+func listTree(nodes []*scanner.TreeNode, dir string, depth int) []treeListing {
+	listings := make([]treeListing, 0, len(nodes))
+	for _, node := range nodes {
+		listing := treeListing{Name: node.Name, Path: node.Path, Dir: node.IsDir, Attachment: node.Attachment, Preview: node.Preview}
+		if node.IsDir {
+			listing.Path = path.Join(dir, node.Name)
+			listing.Count = len(node.Children)
+			if depth > 1 {
+				listing.Children = listTree(node.Children, listing.Path, depth-1)
+			}
+		}
+		listings = append(listings, listing)
+	}
+	return listings
+}
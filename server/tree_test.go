@@ -0,0 +1,88 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTreeAPI(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "guide", "advanced"), 0o755)
+	os.WriteFile(filepath.Join(dir, "guide", "intro.md"), []byte("# Intro\n"), 0o644)
+	os.WriteFile(filepath.Join(dir, "guide", "setup.md"), []byte("# Setup\n"), 0o644)
+	os.WriteFile(filepath.Join(dir, "guide", "advanced", "tuning.md"), []byte("# Tuning\n"), 0o644)
+	os.WriteFile(filepath.Join(dir, "about.md"), []byte("# About\n"), 0o644)
+	s := NewWithAuth(dir, 0, "Docs", "", "", OAuth2Config{}, "", "test")
+	handler := s.Handler()
+	get := func(target string) (*httptest.ResponseRecorder, treeListing) {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		var listing treeListing
+		if rec.Code == http.StatusOK {
+			if err := json.NewDecoder(rec.Body).Decode(&listing); err != nil {
+				t.Fatalf("invalid JSON from %s: %v", target, err)
+			}
+		}
+		return rec, listing
+	}
+
+	_, root := get("/api/tree")
+	if root.Path != "/" || len(root.Children) != 2 || root.Children[1].Path != "/about" || !reflect.DeepEqual(root.Children[0], treeListing{Name: "guide", Path: "/guide", Dir: true, Count: 3}) {
+		t.Errorf("expected the top level with folders collapsed, got %+v", root)
+	}
+
+	_, guide := get("/api/tree/guide?depth=2")
+	if guide.Name != "guide" || guide.Count != 3 || len(guide.Children) != 3 {
+		t.Fatalf("expected the guide folder, got %+v", guide)
+	}
+	if advanced := guide.Children[0]; advanced.Path != "/guide/advanced" || len(advanced.Children) != 1 || advanced.Children[0].Path != "/guide/advanced/tuning" {
+		t.Errorf("expected the nested folder within the depth, got %+v", advanced)
+	}
+
+	rec, page := get("/api/tree/guide?limit=1&offset=1")
+	if len(page.Children) != 1 || page.Children[0].Path != "/guide/intro" || rec.Header().Get("X-Total-Count") != "3" {
+		t.Errorf("expected a page of the entries, got %+v", page)
+	}
+
+	for target, code := range map[string]int{
+		"/api/tree/missing":      http.StatusNotFound,
+		"/api/tree/guide/intro":  http.StatusNotFound,
+		"/api/tree?depth=0":      http.StatusBadRequest,
+		"/api/tree?depth=all":    http.StatusBadRequest,
+		"/api/tree?limit=100000": http.StatusBadRequest,
+	} {
+		if rec, _ := get(target); rec.Code != code {
+			t.Errorf("expected %d for %s, got %d", code, target, rec.Code)
+		}
+	}
+}
+
+func TestLazyTree(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "guide", "advanced"), 0o755)
+	os.WriteFile(filepath.Join(dir, "guide", "intro.md"), []byte("# Intro\n"), 0o644)
+	os.WriteFile(filepath.Join(dir, "guide", "advanced", "tuning.md"), []byte("# Tuning\n"), 0o644)
+	s := NewWithAuth(dir, 0, "Docs", "", "", OAuth2Config{}, "", "test")
+	s.Configure(Options{LazyTree: true})
+	handler := s.Handler()
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/guide/intro", nil))
+	body := rec.Body.String()
+	if !strings.Contains(body, `data-folder="/guide/advanced" data-lazy>`) || strings.Contains(body, `href="/guide/advanced/tuning" class="file"`) {
+		t.Errorf("expected collapsed folders loaded lazily, got:\n%s", body)
+	}
+
+	s.Configure(Options{LazyTree: true, NoJS: true})
+	rec = httptest.NewRecorder()
+	s.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/guide/intro", nil))
+	if !strings.Contains(rec.Body.String(), `href="/guide/advanced/tuning" class="file"`) {
+		t.Errorf("expected the whole tree without JavaScript")
+	}
+}
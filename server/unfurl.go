@@ -0,0 +1,129 @@
+package server
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"gomdoc/search"
+	"gomdoc/templates"
+)
+
+// unfurlDateFormat is how unfurls show the date a page was updated.
+const unfurlDateFormat = "Jan 2, 2006"
+
+// unfurl is the body of an /api/unfurl response.
+type unfurl struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	URL         string `json:"url"`
+	SiteName    string `json:"site_name"`
+	Updated     string `json:"updated,omitempty"`
+	// Slack holds Block Kit blocks for chat.unfurl.
+	Slack slackMessage `json:"slack"`
+	// Teams holds a card for a Teams message extension.
+	Teams teamsAttachment `json:"teams"`
+}
+
+// teamsAttachment is a Microsoft Teams thumbnail card.
+type teamsAttachment struct {
+	ContentType string    `json:"contentType"`
+	Content     teamsCard `json:"content"`
+}
+
+// teamsCard is the content of a Teams thumbnail card.
+type teamsCard struct {
+	Title    string      `json:"title"`
+	Subtitle string      `json:"subtitle,omitempty"`
+	Text     string      `json:"text,omitempty"`
+	Tap      teamsAction `json:"tap"`
+}
+
+// teamsAction opens a URL when a Teams card is clicked.
+type teamsAction struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+// handleUnfurl responds with the link preview of the page in the url
+// parameter, given as a path or as a URL of this site: as JSON with ready
+// Slack blocks and a Teams card, or with format=html as a bare page of
+// OpenGraph tags for unfurlers that cannot sign in.
+func (s *Server) handleUnfurl(w http.ResponseWriter, r *http.Request) {
+	target, err := url.Parse(r.URL.Query().Get("url"))
+	if err != nil || target.Path == "" {
+		http.Error(w, "url must be a page URL or path", http.StatusBadRequest)
+		return
+	}
+	pagePath := "/" + s.canonicalPath(target.Path)
+	preview, found := s.indexFor(r).Preview(pagePath)
+	if !found {
+		http.Error(w, "Document not found", http.StatusNotFound)
+		return
+	}
+	data := s.previewUnfurl(r, preview)
+	if r.URL.Query().Get("format") == "html" {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		if err := templates.RenderUnfurl(w, data); err != nil {
+			log.Printf("Error rendering unfurl: %v", err)
+		}
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(unfurl{
+		Title:       data.Title,
+		Description: data.Description,
+		URL:         data.URL,
+		SiteName:    data.SiteTitle,
+		Updated:     data.Updated,
+		Slack:       slackMessage{Blocks: slackPageBlocks(data)},
+		Teams: teamsAttachment{
+			ContentType: "application/vnd.microsoft.card.thumbnail",
+			Content: teamsCard{
+				Title:    data.Title,
+				Subtitle: unfurlContext(data),
+				Text:     data.Description,
+				Tap:      teamsAction{Type: "openUrl", Value: data.URL},
+			},
+		},
+	})
+}
+
+// absoluteURL returns the absolute URL of the page at pagePath: below
+// Options.BaseURL when set, otherwise on the host of the request.
+func (s *Server) absoluteURL(r *http.Request, pagePath string) string {
+	if canonical := s.canonicalURL(pagePath); canonical != "" {
+		return canonical
+	}
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	return (&url.URL{Scheme: scheme, Host: r.Host, Path: pagePath}).String()
+}
+
+// unfurlContext is the line under an unfurled title: the site name and,
+// when known, the date the page was updated.
+func unfurlContext(data templates.UnfurlData) string {
+	parts := []string{data.SiteTitle}
+	if data.Updated != "" {
+		parts = append(parts, "Updated "+data.Updated)
+	}
+	return strings.Join(parts, " · ")
+}
+
+// previewUnfurl returns the unfurl data of a search result or preview.
+func (s *Server) previewUnfurl(r *http.Request, preview search.Preview) templates.UnfurlData {
+	data := templates.UnfurlData{
+		SiteLayout:  s.layout(),
+		Title:       preview.Title,
+		Description: preview.Summary,
+		URL:         s.absoluteURL(r, preview.Path),
+	}
+	if date, ok := s.index.Date(preview.Path); ok {
+		data.Updated = date.Format(unfurlDateFormat)
+	}
+	return data
+}
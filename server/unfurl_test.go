@@ -0,0 +1,48 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestUnfurl(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "install.md"), []byte("---\ntitle: Install Guide\ndescription: Getting <gomdoc> running.\ndate: 2026-03-01\n---\n# Install\n"), 0o644)
+	s := NewWithAuth(dir, 0, "Docs", "", "", OAuth2Config{}, "", "test")
+	s.Configure(Options{BaseURL: "https://docs.example.com"})
+	handler := s.Handler()
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/unfurl?url=https://docs.example.com/install.md", nil))
+	var got unfurl
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatalf("invalid JSON %q: %v", rec.Body.String(), err)
+	}
+	if got.Title != "Install Guide" || got.URL != "https://docs.example.com/install" || got.Updated != "Mar 1, 2026" {
+		t.Errorf("unexpected unfurl %+v", got)
+	}
+	if text := got.Slack.Blocks[0].Text.Text; text != "*<https://docs.example.com/install|Install Guide>*\nGetting &lt;gomdoc&gt; running." {
+		t.Errorf("unexpected Slack block %q", text)
+	}
+	if got.Teams.Content.Tap.Value != got.URL {
+		t.Errorf("Teams card opens %q", got.Teams.Content.Tap.Value)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/unfurl?url=/install&format=html", nil))
+	if body := rec.Body.String(); !strings.Contains(body, `<meta property="og:url" content="https://docs.example.com/install">`) ||
+		!strings.Contains(body, `<meta name="twitter:data1" content="Mar 1, 2026">`) {
+		t.Errorf("unfurl page lacks OpenGraph tags:\n%s", body)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/unfurl?url=/missing", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for a missing page, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,216 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"gomdoc/scanner"
+)
+
+// uploadFolder is the folder below the base directory that uploads are
+// stored in.
+const uploadFolder = "assets"
+
+// defaultMaxUploadBytes caps the size of an uploaded file when
+// Options.MaxUploadBytes is zero.
+const defaultMaxUploadBytes = 32 << 20
+
+// uploadMethods are the methods accepted by /api/upload.
+var uploadMethods = []string{http.MethodGet, http.MethodHead, http.MethodPost}
+
+// imageTypes are the upload extensions embedded as images; the other
+// uploadTypes are linked as attachments. SVG is left out because it can
+// carry scripts.
+var imageTypes = map[string]bool{".png": true, ".jpg": true, ".jpeg": true, ".gif": true, ".webp": true}
+
+// uploadTypes are the file extensions that may be uploaded and served from
+// the upload folder.
+var uploadTypes = map[string]bool{
+	".png": true, ".jpg": true, ".jpeg": true, ".gif": true, ".webp": true,
+	".pdf": true, ".zip": true, ".txt": true, ".csv": true,
+	".docx": true, ".xlsx": true, ".pptx": true, ".odt": true, ".ods": true, ".odp": true,
+}
+
+// uploadResult tells the editor where a file was stored and what to paste.
+type uploadResult struct {
+	Path     string `json:"path"`
+	Markdown string `json:"markdown"`
+}
+
+// handleUpload stores images and attachments dropped onto a page in edit
+// mode. GET reports that uploads are available, so the page script only
+// enables dropping for users who may upload; POST takes a multipart file
+// and an optional page path used to build a relative link.
+func (s *Server) handleUpload(w http.ResponseWriter, r *http.Request) {
+	if !s.uploadsEnabled() || s.currentUser(r) == "" {
+		http.NotFound(w, r)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{"folder": uploadFolder, "maxBytes": s.maxUploadBytes()})
+	case http.MethodPost:
+		s.storeUpload(w, r)
+	default:
+		methodNotAllowed(w, uploadMethods...)
+	}
+}
+
+// maxUploadBytes returns the size of the largest file that may be
+// uploaded.
+func (s *Server) maxUploadBytes() int64 {
+	if s.options.MaxUploadBytes > 0 {
+		return s.options.MaxUploadBytes
+	}
+	return defaultMaxUploadBytes
+}
+
+// uploadsEnabled reports whether edit mode can write to a local base
+// directory.
+func (s *Server) uploadsEnabled() bool {
+	return s.options.Edit && s.source() == nil
+}
+
+// storeUpload saves the uploaded file under a collision-safe name and
+// responds with the markdown snippet linking to it. Cross-origin requests
+// are refused, since browsers send basic auth credentials along with form
+// posts from other sites.
+func (s *Server) storeUpload(w http.ResponseWriter, r *http.Request) {
+	if err := http.NewCrossOriginProtection().Check(r); err != nil {
+		http.Error(w, "Cross-origin upload refused", http.StatusForbidden)
+		return
+	}
+	limit := s.maxUploadBytes()
+	r.Body = http.MaxBytesReader(w, r.Body, limit+1<<20)
+	file, header, err := r.FormFile("file")
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) || (err == nil && header.Size > limit) {
+		if file != nil {
+			file.Close()
+		}
+		log.Printf("Warning: refused an upload by %s over the limit of %s", s.currentUser(r), scanner.FormatSize(limit))
+		http.Error(w, "Invalid upload: files are limited to "+scanner.FormatSize(limit), http.StatusRequestEntityTooLarge)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Invalid upload: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+
+	name := uploadName(header.Filename)
+	if !uploadTypes[path.Ext(name)] {
+		http.Error(w, fmt.Sprintf("Invalid upload: %s files are not allowed", path.Ext(name)), http.StatusUnsupportedMediaType)
+		return
+	}
+
+	stored, err := s.writeUpload(name, file)
+	if err != nil {
+		log.Printf("Error storing upload %s: %v", header.Filename, err)
+		http.Error(w, "Failed to store upload", http.StatusInternalServerError)
+		return
+	}
+
+	sitePath := "/" + uploadFolder + "/" + stored
+	s.audit(r, auditEvent{Action: auditUpload, Path: sitePath})
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(uploadResult{
+		Path:     sitePath,
+		Markdown: uploadMarkdown(header.Filename, stored, uploadLink(r.FormValue("page"), sitePath)),
+	})
+}
+
+// writeUpload writes the file to the upload folder and returns the name
+// it was stored under.
+func (s *Server) writeUpload(name string, content io.Reader) (string, error) {
+	f, err := createUpload(filepath.Join(s.baseDir, uploadFolder), name)
+	if err != nil {
+		return "", err
+	}
+	_, err = io.Copy(f, content)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+	return filepath.Base(f.Name()), nil
+}
+
+// createUpload creates a new file for name in dir, adding -1, -2, and so
+// on to the name until it is unused. Files are created exclusively, so
+// concurrent uploads of the same name never overwrite each other.
+func createUpload(dir, name string) (*os.File, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	ext := path.Ext(name)
+	stem := strings.TrimSuffix(name, ext)
+	for i := 0; i < 1000; i++ {
+		candidate := name
+		if i > 0 {
+			candidate = fmt.Sprintf("%s-%d%s", stem, i, ext)
+		}
+		f, err := os.OpenFile(filepath.Join(dir, candidate), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+		if !errors.Is(err, fs.ErrExist) {
+			return f, err
+		}
+	}
+	return nil, fmt.Errorf("no free name for %s", name)
+}
+
+// uploadName turns a client file name into a safe lowercase name of
+// letters, digits, dashes, and underscores plus the extension.
+func uploadName(filename string) string {
+	base := path.Base(strings.ReplaceAll(filename, `\`, "/"))
+	ext := strings.ToLower(path.Ext(base))
+	var sb strings.Builder
+	for _, r := range strings.ToLower(strings.TrimSuffix(base, path.Ext(base))) {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '-', r == '_':
+			sb.WriteRune(r)
+		case r == ' ', r == '.':
+			sb.WriteByte('-')
+		}
+	}
+	stem := strings.Trim(sb.String(), "-_")
+	if stem == "" {
+		stem = "upload"
+	}
+	return stem + ext
+}
+
+// uploadLink returns the link to sitePath from the page at pagePath, so
+// the snippet also works when the markdown is read on a code host. Without
+// a valid page the absolute path is used.
+func uploadLink(pagePath, sitePath string) string {
+	if !strings.HasPrefix(pagePath, "/") || path.Clean(pagePath) != pagePath {
+		return sitePath
+	}
+	depth := strings.Count(pagePath, "/") - 1
+	return strings.Repeat("../", depth) + strings.TrimPrefix(sitePath, "/")
+}
+
+// uploadMarkdown returns the snippet for an uploaded file: an image for
+// pictures, otherwise a link labeled with the original file name.
+func uploadMarkdown(filename, stored, link string) string {
+	if imageTypes[path.Ext(stored)] {
+		alt := strings.NewReplacer("-", " ", "_", " ").Replace(strings.TrimSuffix(stored, path.Ext(stored)))
+		return fmt.Sprintf("![%s](%s)", alt, link)
+	}
+	label := strings.NewReplacer("[", "", "]", "").Replace(path.Base(strings.ReplaceAll(filename, `\`, "/")))
+	return fmt.Sprintf("[%s](%s)", label, link)
+}
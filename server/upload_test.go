@@ -0,0 +1,130 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// uploadRequest builds an authenticated multipart upload of one file.
+func uploadRequest(t *testing.T, filename, content, page string) *http.Request {
+	t.Helper()
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, err := form.CreateFormFile("file", filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	part.Write([]byte(content))
+	form.WriteField("page", page)
+	form.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/api/upload", &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	req.SetBasicAuth("writer", "secret")
+	return req
+}
+
+func TestUpload(t *testing.T) {
+	dir := t.TempDir()
+	s := NewWithAuth(dir, 0, "Docs", "writer", "secret", OAuth2Config{}, "", "test")
+	s.Configure(Options{Edit: true})
+	handler := s.Handler()
+
+	var results []uploadResult
+	for range 2 {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, uploadRequest(t, "My Diagram.PNG", "png data", "/guide/setup"))
+		if rec.Code != http.StatusCreated {
+			t.Fatalf("expected 201, got %d: %s", rec.Code, rec.Body.String())
+		}
+		var result uploadResult
+		json.NewDecoder(rec.Body).Decode(&result)
+		results = append(results, result)
+	}
+	if results[0].Path != "/assets/my-diagram.png" || results[0].Markdown != "![my diagram](../assets/my-diagram.png)" {
+		t.Errorf("unexpected first upload: %+v", results[0])
+	}
+	if results[1].Path != "/assets/my-diagram-1.png" {
+		t.Errorf("expected second upload renamed, got %+v", results[1])
+	}
+	if data, err := os.ReadFile(filepath.Join(dir, "assets", "my-diagram.png")); err != nil || string(data) != "png data" {
+		t.Errorf("expected upload stored in assets, got %q, %v", data, err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/assets/my-diagram.png", nil)
+	req.SetBasicAuth("writer", "secret")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK || rec.Body.String() != "png data" || rec.Header().Get("Content-Type") != "image/png" {
+		t.Errorf("expected uploaded image served, got %d %q", rec.Code, rec.Header().Get("Content-Type"))
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, uploadRequest(t, "report.pdf", "%PDF", ""))
+	var result uploadResult
+	json.NewDecoder(rec.Body).Decode(&result)
+	if result.Markdown != "[report.pdf](/assets/report.pdf)" {
+		t.Errorf("expected attachment link, got %+v", result)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, uploadRequest(t, "page.html", "<script>", "/index"))
+	if rec.Code != http.StatusUnsupportedMediaType {
+		t.Errorf("expected HTML upload refused, got %d", rec.Code)
+	}
+
+	req = uploadRequest(t, "x.png", "png", "/index")
+	req.Header.Set("Sec-Fetch-Site", "cross-site")
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("expected cross-site upload refused, got %d", rec.Code)
+	}
+}
+
+func TestUploadDisabled(t *testing.T) {
+	s := NewWithAuth(t.TempDir(), 0, "Docs", "writer", "secret", OAuth2Config{}, "", "test")
+	rec := httptest.NewRecorder()
+	s.Handler().ServeHTTP(rec, uploadRequest(t, "x.png", "png", "/index"))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404 without edit mode, got %d", rec.Code)
+	}
+}
+
+func TestUploadSizeLimit(t *testing.T) {
+	s := NewWithAuth(t.TempDir(), 0, "Docs", "writer", "secret", OAuth2Config{}, "", "test")
+	s.Configure(Options{Edit: true, MaxUploadBytes: 8})
+	handler := s.Handler()
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, uploadRequest(t, "big.png", "more than eight bytes", ""))
+	if rec.Code != http.StatusRequestEntityTooLarge || !strings.Contains(rec.Body.String(), "limited to 8 B") {
+		t.Errorf("expected 413 naming the limit, got %d %q", rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, uploadRequest(t, "small.png", "png", ""))
+	if rec.Code != http.StatusCreated {
+		t.Errorf("expected uploads within the limit stored, got %d %q", rec.Code, rec.Body.String())
+	}
+}
+
+func TestUploadName(t *testing.T) {
+	for filename, want := range map[string]string{
+		"Screen Shot 1.2.PNG":     "screen-shot-1-2.png",
+		`C:\Users\me\diagram.jpg`: "diagram.jpg",
+		"../../etc/passwd.txt":    "passwd.txt",
+		"日本.pdf":                  "upload.pdf",
+	} {
+		if got := uploadName(filename); got != want {
+			t.Errorf("uploadName(%q) = %q, want %q", filename, got, want)
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"runtime"
+)
+
+// BuildInfo identifies the running binary, so deployed instances can be
+// matched to a release when debugging.
+type BuildInfo struct {
+	// Version is the release version, e.g. v2.3.0.
+	Version string `json:"version"`
+	// Commit is the source revision the binary was built from.
+	Commit string `json:"commit,omitempty"`
+	// Date is the build timestamp.
+	Date string `json:"date,omitempty"`
+	// GoVersion is the Go toolchain used for the build.
+	GoVersion string `json:"go_version"`
+}
+
+// buildInfo returns the build details, taking the version from the
+// server and the commit and date from the configured options.
+func (s *Server) buildInfo() BuildInfo {
+	info := s.options.Build
+	info.Version = s.version
+	info.GoVersion = runtime.Version()
+	return info
+}
+
+// handleVersion responds with the build details as JSON.
+func (s *Server) handleVersion(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.buildInfo())
+}
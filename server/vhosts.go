@@ -0,0 +1,134 @@
+package server
+
+import (
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"gomdoc/search"
+	"gomdoc/semantic"
+)
+
+// VirtualHost maps a host name to its own documentation tree.
+type VirtualHost struct {
+	// Host is the host name, e.g. docs.team-a.local, matched without the
+	// port and ignoring case.
+	Host string
+	// Dir is the base directory of the host's markdown files.
+	Dir string
+	// Title is the site title; empty uses the server's title.
+	Title string
+}
+
+// virtualHostHandler returns a handler that dispatches requests by host
+// name to the site of each virtual host, and to fallback for other hosts.
+// The virtual hosts share the server's options, authentication, and
+// database, but each has its own base directory, title, and indexes, and
+// keeps its records apart in the database with siteKey.
+func (s *Server) virtualHostHandler(fallback http.Handler) http.Handler {
+	sites := make(map[string]http.Handler, len(s.options.VirtualHosts))
+	servers := make(map[string]*Server, len(s.options.VirtualHosts))
+	for _, vhost := range s.options.VirtualHosts {
+		site := s.virtualHostServer(vhost)
+		log.Printf("Virtual host %s: serving files from %s", vhost.Host, site.baseDir)
+		sites[normalizeHost(vhost.Host)] = site.siteHandler()
+		servers[normalizeHost(vhost.Host)] = site
+	}
+	s.vhosts = servers
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if site, ok := sites[normalizeHost(r.Host)]; ok {
+			site.ServeHTTP(w, r)
+			return
+		}
+		fallback.ServeHTTP(w, r)
+	})
+}
+
+// virtualHostServer creates the server of a virtual host from s.
+func (s *Server) virtualHostServer(vhost VirtualHost) *Server {
+	site := *s
+	site.baseDir = vhost.Dir
+	site.site = normalizeHost(vhost.Host)
+	if vhost.Title != "" {
+		site.title = vhost.Title
+	}
+	site.options.VirtualHosts = nil
+	site.options.Source = nil
+	site.options.BaseURL = virtualHostURL(s.options.BaseURL, vhost.Host)
+	site.files = nil
+	site.previews = newPreviewCache()
+	site.missing = newMissingReport()
+	site.overdue = newOverdueRenders()
+	site.changes = newChangeJournal()
+	site.content = new(contentVersion)
+	site.index = search.NewIndexWithOptions(s.options.Scan)
+	if s.ask != nil {
+		ask := s.options.Ask
+		if ask.CacheFile != "" {
+			ask.CacheFile += "." + normalizeHost(vhost.Host)
+		}
+		site.ask = semantic.New(ask)
+	}
+	return &site
+}
+
+// siteOf returns the server of the site r is for: the virtual host's
+// named by its host, or s.
+func (s *Server) siteOf(r *http.Request) *Server {
+	if site, ok := s.vhosts[normalizeHost(r.Host)]; ok {
+		return site
+	}
+	return s
+}
+
+// siteKey returns the database key of key, such as a page path, on this
+// site: key itself on the main site, and key prefixed with the host on a
+// virtual host, so that the sites sharing the database keep apart.
+func (s *Server) siteKey(key string) string {
+	if s.site == "" {
+		return key
+	}
+	return "@" + s.site + " " + key
+}
+
+// siteKeys returns the keys of bucket stored by this site, without the
+// prefix siteKey adds.
+func (s *Server) siteKeys(bucket string) []string {
+	var keys []string
+	for _, key := range s.db.Keys(bucket) {
+		if s.site == "" {
+			if !strings.HasPrefix(key, "@") {
+				keys = append(keys, key)
+			}
+		} else if rest, ok := strings.CutPrefix(key, "@"+s.site+" "); ok {
+			keys = append(keys, rest)
+		}
+	}
+	return keys
+}
+
+// virtualHostURL returns baseURL with its host replaced by the virtual
+// host's, or an empty string when baseURL is empty.
+func virtualHostURL(baseURL, host string) string {
+	u, err := url.Parse(baseURL)
+	if baseURL == "" || err != nil {
+		return ""
+	}
+	if port := u.Port(); port != "" {
+		host = net.JoinHostPort(host, port)
+	}
+	u.Host = host
+	return u.String()
+}
+
+// normalizeHost lowercases a host and strips the port and a trailing dot,
+// so docs.example.com:8080 and DOCS.example.com. match docs.example.com.
+func normalizeHost(host string) string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return strings.TrimSuffix(strings.ToLower(host), ".")
+}
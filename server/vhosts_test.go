@@ -0,0 +1,88 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestVirtualHosts(t *testing.T) {
+	mainDir, teamDir := t.TempDir(), t.TempDir()
+	os.WriteFile(filepath.Join(mainDir, "main.md"), []byte("# Main\n\nShared word.\n"), 0o644)
+	os.WriteFile(filepath.Join(teamDir, "team.md"), []byte("# Team\n\nShared word.\n"), 0o644)
+
+	s := NewWithAuth(mainDir, 0, "Docs", "", "", OAuth2Config{}, "", "test")
+	s.Configure(Options{VirtualHosts: []VirtualHost{{Host: "docs.team-a.local", Dir: teamDir, Title: "Team A"}}})
+	handler := s.Handler()
+
+	get := func(host, target string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		req.Host = host
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := get("DOCS.team-a.local:7331", "/team"); rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "Team A") {
+		t.Errorf("expected team page with its title, got %d:\n%s", rec.Code, rec.Body.String())
+	}
+	if rec := get("docs.team-a.local", "/main"); rec.Code != http.StatusNotFound {
+		t.Errorf("expected main page missing on the virtual host, got %d", rec.Code)
+	}
+	if rec := get("localhost:7331", "/main"); rec.Code != http.StatusOK {
+		t.Errorf("expected main page on other hosts, got %d", rec.Code)
+	}
+
+	rec := get("docs.team-a.local", "/api/search?q=shared")
+	if body := rec.Body.String(); !strings.Contains(body, `"/team"`) || strings.Contains(body, `"/main"`) {
+		t.Errorf("expected search limited to the virtual host, got %s", body)
+	}
+	rec = get("localhost", "/api/search?q=shared")
+	if body := rec.Body.String(); !strings.Contains(body, `"/main"`) || strings.Contains(body, `"/team"`) {
+		t.Errorf("expected search limited to the base directory, got %s", body)
+	}
+}
+
+func TestVirtualHostsKeepRecordsApart(t *testing.T) {
+	mainDir, teamDir := t.TempDir(), t.TempDir()
+	policy := []byte("---\ntitle: Travel Policy\nacknowledge: true\n---\n# Travel\n\nBook early.\n")
+	os.WriteFile(filepath.Join(mainDir, "policy.md"), policy, 0o644)
+	os.WriteFile(filepath.Join(teamDir, "policy.md"), policy, 0o644)
+
+	s := NewWithAuth(mainDir, 0, "Docs", "writer", "secret", OAuth2Config{}, "", "test")
+	s.Configure(Options{VirtualHosts: []VirtualHost{{Host: "docs.team-a.local", Dir: teamDir}}})
+	handler := s.Handler()
+
+	serve := func(host string, req *http.Request) *httptest.ResponseRecorder {
+		req.Host = host
+		req.SetBasicAuth("writer", "secret")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/api/acknowledge", strings.NewReader("path=%2Fpolicy"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if rec := serve("docs.team-a.local", req); rec.Code != http.StatusSeeOther {
+		t.Fatalf("expected the acknowledgment stored, got %d", rec.Code)
+	}
+	if body := serve("docs.team-a.local", httptest.NewRequest(http.MethodGet, "/policy", nil)).Body.String(); !strings.Contains(body, "You confirmed reading this version on") {
+		t.Errorf("expected the acknowledgment on its own site, got\n%s", body)
+	}
+	if body := serve("localhost", httptest.NewRequest(http.MethodGet, "/policy", nil)).Body.String(); !strings.Contains(body, "I have read this") {
+		t.Errorf("expected the same page on the main site still unacknowledged, got\n%s", body)
+	}
+
+	team := s.virtualHostServer(s.options.VirtualHosts[0])
+	s.db.Put(revisionsBucket, "abc", revision{ID: "abc", Path: "/policy"})
+	team.db.Put(revisionsBucket, team.siteKey("def"), revision{ID: "def", Path: "/policy"})
+	if revs := s.revisions(); len(revs) != 1 || revs[0].ID != "abc" {
+		t.Errorf("expected only the main site's revision, got %+v", revs)
+	}
+	if revs := team.revisions(); len(revs) != 1 || revs[0].ID != "def" {
+		t.Errorf("expected only the virtual host's revision, got %+v", revs)
+	}
+}
@@ -0,0 +1,98 @@
+package server
+
+import (
+	"log"
+	"net/http"
+	"net/url"
+	"path"
+	"slices"
+	"strings"
+
+	"golang.org/x/net/webdav"
+)
+
+// davPrefix is the path the WebDAV handler is mounted at.
+const davPrefix = "/dav"
+
+// davWriteMethods change the documentation tree, so the indexes are
+// rebuilt after they succeed.
+var davWriteMethods = []string{http.MethodPut, http.MethodDelete, "MKCOL", "COPY", "MOVE"}
+
+// davHandler serves the base directory over WebDAV below /dav/, so writers
+// can mount the docs as a network drive. Successful writes rebuild the
+// search and MCP indexes so the rendered site follows the edits.
+func (s *Server) davHandler() http.Handler {
+	dav := &webdav.Handler{
+		Prefix:     davPrefix,
+		FileSystem: webdav.Dir(s.baseDir),
+		LockSystem: webdav.NewMemLS(),
+		Logger: func(r *http.Request, err error) {
+			if err != nil {
+				log.Printf("WebDAV %s %s: %v", r.Method, r.URL.Path, err)
+			}
+		},
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if davTouchesGit(r) {
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+		if !slices.Contains(davWriteMethods, r.Method) {
+			dav.ServeHTTP(w, r)
+			return
+		}
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		dav.ServeHTTP(rec, r)
+		if rec.status < 300 {
+			s.auditDAV(r)
+			s.contentChanged()
+		}
+	})
+}
+
+// davTouchesGit reports whether a request targets the .git directory, as
+// its source path or as the destination of a copy or move, in any case.
+// The destination is decoded as the WebDAV handler decodes it, so %2Egit
+// counts too, and one that cannot be parsed is refused. Repository
+// internals are never exposed, even to authenticated writers.
+func davTouchesGit(r *http.Request) bool {
+	paths := []string{r.URL.Path}
+	if destination := r.Header.Get("Destination"); destination != "" {
+		u, err := url.Parse(destination)
+		if err != nil {
+			return true
+		}
+		paths = append(paths, u.Path)
+	}
+	for _, p := range paths {
+		for _, segment := range strings.Split(path.Clean("/"+p), "/") {
+			if strings.EqualFold(segment, ".git") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// statusRecorder remembers the status code written through it.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+// WriteHeader records the status before passing it on.
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// Flush passes flushes on, so streaming responses such as the MCP event
+// stream keep working when traced.
+func (r *statusRecorder) Flush() {
+	_ = http.NewResponseController(r.ResponseWriter).Flush()
+}
+
+// Unwrap returns the wrapped writer for http.ResponseController.
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
@@ -0,0 +1,76 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWebDAV(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "index.md"), []byte("# Home\n"), 0o644)
+	s := NewWithAuth(dir, 0, "Docs", "writer", "secret", OAuth2Config{}, "", "test")
+	s.Configure(Options{WebDAV: true})
+	handler := s.Handler()
+
+	do := func(method, target, body string, header map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req.SetBasicAuth("writer", "secret")
+		for name, value := range header {
+			req.Header.Set(name, value)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := do(http.MethodPut, "/dav/guide.md", "# Guide\n\nMount the zephyr drive.\n", nil); rec.Code != http.StatusCreated {
+		t.Fatalf("PUT: expected 201, got %d", rec.Code)
+	}
+	if data, err := os.ReadFile(filepath.Join(dir, "guide.md")); err != nil || !strings.Contains(string(data), "zephyr") {
+		t.Fatalf("expected file written to the base directory, got %q, %v", data, err)
+	}
+	if rec := do(http.MethodGet, "/guide", "", nil); !strings.Contains(rec.Body.String(), "Mount the zephyr drive.") {
+		t.Errorf("expected rendered page for uploaded file, got %d", rec.Code)
+	}
+	waitForJobs(t, s)
+	if rec := do(http.MethodGet, "/api/search?q=zephyr", "", nil); !strings.Contains(rec.Body.String(), `"/guide"`) {
+		t.Errorf("expected search index rebuilt after PUT, got %s", rec.Body.String())
+	}
+	if rec := do("PROPFIND", "/dav/", "", map[string]string{"Depth": "1"}); rec.Code != http.StatusMultiStatus || !strings.Contains(rec.Body.String(), "/dav/index.md") {
+		t.Errorf("PROPFIND: expected listing, got %d:\n%s", rec.Code, rec.Body.String())
+	}
+	if rec := do(http.MethodPut, "/dav/.git/config", "x", nil); rec.Code != http.StatusForbidden {
+		t.Errorf("expected writes to .git forbidden, got %d", rec.Code)
+	}
+	os.Mkdir(filepath.Join(dir, ".git"), 0o755)
+	for _, destination := range []string{"/dav/.git/moved.md", "http://example.com/dav/%2Egit/moved.md", "/dav/%2egit/moved.md", "/dav/.GIT/moved.md", "/dav/sub/../.git/moved.md"} {
+		if rec := do("MOVE", "/dav/guide.md", "", map[string]string{"Destination": destination}); rec.Code != http.StatusForbidden {
+			t.Errorf("MOVE to %s: expected 403, got %d", destination, rec.Code)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "guide.md")); err != nil {
+		t.Errorf("expected guide.md left in place, got %v", err)
+	}
+
+	req := httptest.NewRequest("PROPFIND", "/dav/", nil)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 without credentials, got %d", rec.Code)
+	}
+}
+
+func TestWebDAVNeedsAuth(t *testing.T) {
+	s := NewWithAuth(t.TempDir(), 0, "Docs", "", "", OAuth2Config{}, "", "test")
+	s.Configure(Options{WebDAV: true})
+
+	rec := httptest.NewRecorder()
+	s.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodPut, "/dav/page.md", strings.NewReader("# Page\n")))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected WebDAV disabled without auth, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,186 @@
+package source
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/fs"
+	"maps"
+	"os"
+	"path"
+	"strings"
+	"time"
+)
+
+// archiveSuffixes are the file name suffixes of the supported archives.
+var archiveSuffixes = []string{".zip", ".tar", ".tar.gz", ".tgz"}
+
+// IsArchive reports whether spec names a zip or tar archive by its file
+// name suffix.
+func IsArchive(spec string) bool {
+	lower := strings.ToLower(spec)
+	for _, suffix := range archiveSuffixes {
+		if strings.HasSuffix(lower, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// OpenArchive opens a .zip, .tar, .tar.gz, or .tgz file as a read-only
+// file system. Zip archives are read in place, tar archives are loaded
+// into memory. An archive holding a single top-level directory, as made
+// by zip -r docs.zip docs, is served from inside that directory.
+func OpenArchive(name string) (fs.FS, error) {
+	var fsys fs.FS
+	var err error
+	if strings.HasSuffix(strings.ToLower(name), ".zip") {
+		fsys, err = zip.OpenReader(name)
+	} else {
+		fsys, err = openTar(name)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return unwrapArchive(fsys)
+}
+
+// unwrapArchive returns the single top-level directory of fsys, or fsys
+// itself when the root holds anything else. Hidden entries such as the
+// __MACOSX folder of Finder archives are ignored.
+func unwrapArchive(fsys fs.FS) (fs.FS, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+	var top []fs.DirEntry
+	for _, entry := range entries {
+		if !strings.HasPrefix(entry.Name(), ".") && !strings.HasPrefix(entry.Name(), "__") {
+			top = append(top, entry)
+		}
+	}
+	if len(top) != 1 || !top[0].IsDir() {
+		return fsys, nil
+	}
+	return fs.Sub(fsys, top[0].Name())
+}
+
+// openTar reads a tar archive, gzip-compressed or not, into memory.
+func openTar(name string) (*archiveFS, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	lower := strings.ToLower(name)
+	if strings.HasSuffix(lower, ".gz") || strings.HasSuffix(lower, ".tgz") {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	files := make(map[string]archiveFile)
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue // directories are derived from the files; links are not followed
+		}
+		fileName := path.Clean(strings.TrimPrefix(header.Name, "/"))
+		if !fs.ValidPath(fileName) || fileName == "." {
+			continue
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		files[fileName] = archiveFile{data: data, modTime: header.ModTime}
+	}
+	return &archiveFS{files: files, dirs: directories(maps.Keys(files))}, nil
+}
+
+// archiveFS is a read-only in-memory file system loaded from a tar
+// archive.
+type archiveFS struct {
+	files map[string]archiveFile
+	dirs  map[string][]string // directory name to sorted child names
+}
+
+// archiveFile is a regular file of a tar archive.
+type archiveFile struct {
+	data    []byte
+	modTime time.Time
+}
+
+// Open opens the named file or directory.
+func (a *archiveFS) Open(name string) (fs.File, error) {
+	info, err := a.Stat(name)
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	if info.IsDir() {
+		entries, _ := a.ReadDir(name)
+		return &openDir{info: info, entries: entries}, nil
+	}
+	return &openFile{Reader: bytes.NewReader(a.files[name].data), info: info}, nil
+}
+
+// Stat describes the named file or directory.
+func (a *archiveFS) Stat(name string) (fs.FileInfo, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: fs.ErrInvalid}
+	}
+	if file, ok := a.files[name]; ok {
+		return fileInfo{name: path.Base(name), size: int64(len(file.data)), modTime: file.modTime}, nil
+	}
+	if _, ok := a.dirs[name]; ok {
+		return fileInfo{name: path.Base(name), dir: true}, nil
+	}
+	return nil, &fs.PathError{Op: "stat", Path: name, Err: fs.ErrNotExist}
+}
+
+// ReadDir lists the named directory, sorted by name.
+func (a *archiveFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrInvalid}
+	}
+	children, ok := a.dirs[name]
+	if !ok {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrNotExist}
+	}
+	entries := make([]fs.DirEntry, 0, len(children))
+	for _, child := range children {
+		info, err := a.Stat(path.Join(name, child))
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, fs.FileInfoToDirEntry(info))
+	}
+	return entries, nil
+}
+
+// ReadFile returns a copy of the content of the named file.
+func (a *archiveFS) ReadFile(name string) ([]byte, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "read", Path: name, Err: fs.ErrInvalid}
+	}
+	file, ok := a.files[name]
+	if !ok {
+		return nil, &fs.PathError{Op: "read", Path: name, Err: fs.ErrNotExist}
+	}
+	return bytes.Clone(file.data), nil
+}
@@ -0,0 +1,119 @@
+package source
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+)
+
+// archiveFiles is the content written to the test archives.
+var archiveFiles = map[string]string{
+	"docs/index.md":       "# Home\n",
+	"docs/guide/setup.md": "# Setup\n",
+}
+
+func writeZip(t *testing.T, name string, files map[string]string) {
+	t.Helper()
+	f, err := os.Create(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	zw := zip.NewWriter(f)
+	for fileName, content := range files {
+		w, err := zw.Create(fileName)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(content))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func writeTarGz(t *testing.T, name string, files map[string]string) {
+	t.Helper()
+	f, err := os.Create(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+	tw.WriteHeader(&tar.Header{Name: "./docs/", Typeflag: tar.TypeDir, Mode: 0o755})
+	for fileName, content := range files {
+		tw.WriteHeader(&tar.Header{Name: "./" + fileName, Typeflag: tar.TypeReg, Mode: 0o644, Size: int64(len(content))})
+		tw.Write([]byte(content))
+	}
+	tw.WriteHeader(&tar.Header{Name: "../escape.md", Typeflag: tar.TypeReg, Mode: 0o644})
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestOpenArchive(t *testing.T) {
+	dir := t.TempDir()
+	zipPath := filepath.Join(dir, "docs.zip")
+	writeZip(t, zipPath, archiveFiles)
+	tarPath := filepath.Join(dir, "docs.tar.gz")
+	writeTarGz(t, tarPath, archiveFiles)
+
+	for _, name := range []string{zipPath, tarPath} {
+		fsys, err := Open(context.Background(), name)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if err := fstest.TestFS(fsys, "index.md", "guide/setup.md"); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+		data, err := fs.ReadFile(fsys, "guide/setup.md")
+		if err != nil || string(data) != "# Setup\n" {
+			t.Errorf("%s: expected setup page, got %q, %v", name, data, err)
+		}
+		if _, err := fs.Stat(fsys, "escape.md"); err == nil {
+			t.Errorf("%s: expected entry outside the archive root to be dropped", name)
+		}
+	}
+}
+
+func TestOpenArchiveKeepsRootWithSeveralEntries(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "docs.zip")
+	writeZip(t, name, map[string]string{
+		"index.md":         "# Home\n",
+		"guide/setup.md":   "# Setup\n",
+		"__MACOSX/._index": "",
+	})
+
+	fsys, err := OpenArchive(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fs.Stat(fsys, "guide/setup.md"); err != nil {
+		t.Errorf("expected archive served from its root: %v", err)
+	}
+}
+
+func TestIsArchive(t *testing.T) {
+	for spec, want := range map[string]bool{
+		"docs.zip":    true,
+		"docs.TGZ":    true,
+		"docs.tar.gz": true,
+		"docs.tar":    true,
+		"docs":        false,
+		"s3://bucket": false,
+	} {
+		if got := IsArchive(spec); got != want {
+			t.Errorf("IsArchive(%q) = %v, want %v", spec, got, want)
+		}
+	}
+}
@@ -0,0 +1,450 @@
+package source
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/fs"
+	"iter"
+	"maps"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// BucketConfig describes an S3-compatible bucket.
+type BucketConfig struct {
+	// Endpoint is the service URL, e.g. https://storage.googleapis.com.
+	// When empty, the AWS endpoint of Region is used with virtual-hosted
+	// bucket addressing; custom endpoints use path-style addressing, which
+	// MinIO and other S3-compatible stores expect.
+	Endpoint string
+	// Region is the signing region; defaults to us-east-1.
+	Region string
+	// Bucket is the bucket name.
+	Bucket string
+	// Prefix limits the source to keys below a folder, e.g. docs/.
+	Prefix string
+	// AccessKeyID and SecretAccessKey sign requests. Without them, requests
+	// are sent anonymously, which works for public buckets.
+	AccessKeyID     string
+	SecretAccessKey string
+	// SessionToken accompanies temporary credentials.
+	SessionToken string
+	// Client sends the requests; nil uses a client with a 30 second timeout.
+	Client *http.Client
+}
+
+// ParseBucketURL reads a bucket from s3://bucket/prefix or
+// gs://bucket/prefix. Credentials, region, and endpoint come from the
+// standard AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY, AWS_SESSION_TOKEN,
+// AWS_REGION, and AWS_ENDPOINT_URL variables; gs:// uses the Cloud Storage
+// XML API, which accepts HMAC keys in the same variables.
+func ParseBucketURL(rawURL string) (BucketConfig, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return BucketConfig{}, err
+	}
+	if u.Host == "" {
+		return BucketConfig{}, fmt.Errorf("%s: missing bucket name", rawURL)
+	}
+
+	config := BucketConfig{
+		Endpoint:        os.Getenv("AWS_ENDPOINT_URL"),
+		Region:          os.Getenv("AWS_REGION"),
+		Bucket:          u.Host,
+		Prefix:          strings.Trim(u.Path, "/"),
+		AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+		SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+	}
+	if config.Region == "" {
+		config.Region = os.Getenv("AWS_DEFAULT_REGION")
+	}
+	switch u.Scheme {
+	case "s3":
+	case "gs":
+		config.Endpoint = "https://storage.googleapis.com"
+		if config.Region == "" {
+			config.Region = "auto"
+		}
+	default:
+		return BucketConfig{}, fmt.Errorf("%s: unsupported scheme %q, use s3:// or gs://", rawURL, u.Scheme)
+	}
+	return config, nil
+}
+
+// Bucket is a read-only file system over the objects of a bucket. It
+// keeps the listing in memory and fetches each object on first use,
+// caching its content until a refresh shows the object changed. Put and
+// Delete change the objects themselves, e.g. to publish an export.
+type Bucket struct {
+	config BucketConfig
+	client *http.Client
+
+	mu      sync.RWMutex
+	objects map[string]object   // file name to object
+	dirs    map[string][]string // directory name to sorted child names
+	cache   map[string][]byte   // file name to content, valid for the object's ETag
+	etags   map[string]string   // ETag of each cached content
+}
+
+// object is a listed object of the bucket.
+type object struct {
+	key     string
+	size    int64
+	modTime time.Time
+	etag    string
+}
+
+// NewBucket creates a bucket file system. It is empty until the first
+// Refresh.
+func NewBucket(config BucketConfig) *Bucket {
+	if config.Region == "" {
+		config.Region = "us-east-1"
+	}
+	if config.Prefix = strings.Trim(config.Prefix, "/"); config.Prefix != "" {
+		config.Prefix += "/"
+	}
+	client := config.Client
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+	return &Bucket{
+		config:  config,
+		client:  client,
+		objects: map[string]object{},
+		dirs:    map[string][]string{".": nil},
+		cache:   map[string][]byte{},
+		etags:   map[string]string{},
+	}
+}
+
+// Refresh lists the bucket again. Cached content of objects that changed
+// or disappeared is dropped.
+func (b *Bucket) Refresh(ctx context.Context) error {
+	objects := make(map[string]object)
+	token := ""
+	for {
+		page, err := b.list(ctx, token)
+		if err != nil {
+			return err
+		}
+		for _, item := range page.Contents {
+			name := strings.TrimPrefix(item.Key, b.config.Prefix)
+			if name == "" || strings.HasSuffix(name, "/") || !fs.ValidPath(name) {
+				continue // folder markers and keys that are no valid file names
+			}
+			objects[name] = object{key: item.Key, size: item.Size, modTime: item.LastModified, etag: item.ETag}
+		}
+		if !page.IsTruncated || page.NextContinuationToken == "" {
+			break
+		}
+		token = page.NextContinuationToken
+	}
+
+	dirs := directories(maps.Keys(objects))
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for name, etag := range b.etags {
+		if obj, ok := objects[name]; !ok || obj.etag != etag {
+			delete(b.cache, name)
+			delete(b.etags, name)
+		}
+	}
+	b.objects = objects
+	b.dirs = dirs
+	return nil
+}
+
+// Open opens the named file or directory.
+func (b *Bucket) Open(name string) (fs.File, error) {
+	info, err := b.Stat(name)
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	if info.IsDir() {
+		entries, _ := b.ReadDir(name)
+		return &openDir{info: info, entries: entries}, nil
+	}
+	data, err := b.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	return &openFile{Reader: bytes.NewReader(data), info: info}, nil
+}
+
+// Stat describes the named file or directory without fetching it.
+func (b *Bucket) Stat(name string) (fs.FileInfo, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: fs.ErrInvalid}
+	}
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if obj, ok := b.objects[name]; ok {
+		return fileInfo{name: path.Base(name), size: obj.size, modTime: obj.modTime}, nil
+	}
+	if _, ok := b.dirs[name]; ok {
+		return fileInfo{name: path.Base(name), dir: true}, nil
+	}
+	return nil, &fs.PathError{Op: "stat", Path: name, Err: fs.ErrNotExist}
+}
+
+// ReadDir lists the named directory, sorted by name.
+func (b *Bucket) ReadDir(name string) ([]fs.DirEntry, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrInvalid}
+	}
+	b.mu.RLock()
+	children, ok := b.dirs[name]
+	b.mu.RUnlock()
+	if !ok {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrNotExist}
+	}
+
+	entries := make([]fs.DirEntry, 0, len(children))
+	for _, child := range children {
+		info, err := b.Stat(path.Join(name, child))
+		if err != nil {
+			continue // removed by a concurrent refresh
+		}
+		entries = append(entries, fs.FileInfoToDirEntry(info))
+	}
+	return entries, nil
+}
+
+// ReadFile returns the content of the named file, fetching it from the
+// bucket unless it is cached.
+func (b *Bucket) ReadFile(name string) ([]byte, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "read", Path: name, Err: fs.ErrInvalid}
+	}
+	b.mu.RLock()
+	obj, ok := b.objects[name]
+	data, cached := b.cache[name]
+	b.mu.RUnlock()
+	if !ok {
+		return nil, &fs.PathError{Op: "read", Path: name, Err: fs.ErrNotExist}
+	}
+	if cached {
+		return bytes.Clone(data), nil
+	}
+
+	data, err := b.get(context.Background(), obj.key)
+	if err != nil {
+		return nil, &fs.PathError{Op: "read", Path: name, Err: err}
+	}
+	b.mu.Lock()
+	if current, ok := b.objects[name]; ok && current.etag == obj.etag {
+		b.cache[name] = data
+		b.etags[name] = obj.etag
+	}
+	b.mu.Unlock()
+	return bytes.Clone(data), nil
+}
+
+// listResult is a page of a ListObjectsV2 response.
+type listResult struct {
+	Contents []struct {
+		Key          string
+		LastModified time.Time
+		ETag         string
+		Size         int64
+	}
+	IsTruncated           bool
+	NextContinuationToken string
+}
+
+// list fetches a page of the objects below the prefix.
+func (b *Bucket) list(ctx context.Context, token string) (listResult, error) {
+	query := url.Values{"list-type": {"2"}}
+	if b.config.Prefix != "" {
+		query.Set("prefix", b.config.Prefix)
+	}
+	if token != "" {
+		query.Set("continuation-token", token)
+	}
+	body, err := b.request(ctx, "", query)
+	if err != nil {
+		return listResult{}, err
+	}
+	var result listResult
+	if err := xml.Unmarshal(body, &result); err != nil {
+		return listResult{}, fmt.Errorf("parse listing: %w", err)
+	}
+	return result, nil
+}
+
+// get fetches the content of an object.
+func (b *Bucket) get(ctx context.Context, key string) ([]byte, error) {
+	return b.request(ctx, key, nil)
+}
+
+// request sends a signed GET request for key, or for the bucket itself
+// when key is empty, and returns the response body.
+func (b *Bucket) request(ctx context.Context, key string, query url.Values) ([]byte, error) {
+	return b.send(ctx, http.MethodGet, key, query, nil, nil)
+}
+
+// send sends a signed request with body and header for key, or for the
+// bucket itself when key is empty, and returns the response body.
+func (b *Bucket) send(ctx context.Context, method, key string, query url.Values, body []byte, header http.Header) ([]byte, error) {
+	u, err := b.objectURL(key)
+	if err != nil {
+		return nil, err
+	}
+	u.RawQuery = canonicalQuery(query)
+
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), reader)
+	if err != nil {
+		return nil, err
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	if b.config.AccessKeyID != "" {
+		signRequestPayload(req, b.config, time.Now(), hexSHA256(string(body)))
+	}
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
+		return nil, fmt.Errorf("%s %s: %s", method, u.Redacted(), errorMessage(resp.Status, data))
+	}
+	return data, nil
+}
+
+// objectURL returns the URL of an object, or of the bucket for an empty
+// key, using virtual-hosted addressing on AWS and path-style elsewhere.
+func (b *Bucket) objectURL(key string) (*url.URL, error) {
+	endpoint := b.config.Endpoint
+	virtualHosted := endpoint == ""
+	if virtualHosted {
+		endpoint = "https://s3." + b.config.Region + ".amazonaws.com"
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid endpoint: %w", err)
+	}
+
+	objectPath := "/" + key
+	if virtualHosted {
+		u.Host = b.config.Bucket + "." + u.Host
+	} else {
+		objectPath = "/" + b.config.Bucket + objectPath
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/") + objectPath
+	u.RawPath = escapePath(u.Path)
+	return u, nil
+}
+
+// errorMessage extracts the message of an S3 error response.
+func errorMessage(status string, body []byte) string {
+	var s3Error struct {
+		Code    string
+		Message string
+	}
+	if xml.Unmarshal(body, &s3Error) == nil && s3Error.Code != "" {
+		return fmt.Sprintf("%s: %s", s3Error.Code, s3Error.Message)
+	}
+	return status
+}
+
+// directories derives the directory tree of a set of file names, mapping
+// each directory to its sorted child names.
+func directories(files iter.Seq[string]) map[string][]string {
+	children := map[string]map[string]bool{".": {}}
+	for name := range files {
+		for child := name; child != "."; child = path.Dir(child) {
+			dir := path.Dir(child)
+			if children[dir] == nil {
+				children[dir] = make(map[string]bool)
+			}
+			children[dir][path.Base(child)] = true
+		}
+	}
+	dirs := make(map[string][]string, len(children))
+	for dir, names := range children {
+		dirs[dir] = slices.Sorted(maps.Keys(names))
+	}
+	return dirs
+}
+
+// fileInfo describes a file or a directory derived from the file names.
+type fileInfo struct {
+	name    string
+	size    int64
+	modTime time.Time
+	dir     bool
+}
+
+func (fi fileInfo) Name() string       { return fi.name }
+func (fi fileInfo) Size() int64        { return fi.size }
+func (fi fileInfo) ModTime() time.Time { return fi.modTime }
+func (fi fileInfo) IsDir() bool        { return fi.dir }
+func (fi fileInfo) Sys() any           { return nil }
+
+// Mode reports directories as such and files as read-only.
+func (fi fileInfo) Mode() fs.FileMode {
+	if fi.dir {
+		return fs.ModeDir | 0o555
+	}
+	return 0o444
+}
+
+// openFile is an opened file, read from memory.
+type openFile struct {
+	*bytes.Reader
+	info fs.FileInfo
+}
+
+func (f *openFile) Stat() (fs.FileInfo, error) { return f.info, nil }
+func (f *openFile) Close() error               { return nil }
+
+// openDir is an opened directory.
+type openDir struct {
+	info    fs.FileInfo
+	entries []fs.DirEntry
+	offset  int
+}
+
+func (d *openDir) Stat() (fs.FileInfo, error) { return d.info, nil }
+func (d *openDir) Close() error               { return nil }
+
+// Read fails, since directories have no content.
+func (d *openDir) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.info.Name(), Err: fs.ErrInvalid}
+}
+
+// ReadDir returns the next n entries, or all remaining ones for n <= 0.
+func (d *openDir) ReadDir(n int) ([]fs.DirEntry, error) {
+	remaining := d.entries[d.offset:]
+	if n <= 0 {
+		d.offset = len(d.entries)
+		return remaining, nil
+	}
+	if len(remaining) == 0 {
+		return nil, io.EOF
+	}
+	n = min(n, len(remaining))
+	d.offset += n
+	return remaining[:n], nil
+}
@@ -0,0 +1,194 @@
+package source
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"testing/fstest"
+)
+
+// fakeS3 serves ListObjectsV2, GetObject, PutObject, and DeleteObject for
+// a path-style bucket named docs, two keys per listing page.
+type fakeS3 struct {
+	mu      sync.Mutex
+	objects map[string]string
+	headers map[string]http.Header
+	gets    int
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=key/") {
+		http.Error(w, "unsigned", http.StatusForbidden)
+		return
+	}
+
+	key := strings.TrimPrefix(r.URL.Path, "/docs/")
+	switch r.Method {
+	case http.MethodPut:
+		body, _ := io.ReadAll(r.Body)
+		sum := sha256.Sum256(body)
+		if r.Header.Get("X-Amz-Content-Sha256") != hex.EncodeToString(sum[:]) {
+			http.Error(w, "payload hash mismatch", http.StatusBadRequest)
+			return
+		}
+		f.objects[key] = string(body)
+		if f.headers == nil {
+			f.headers = make(map[string]http.Header)
+		}
+		f.headers[key] = r.Header.Clone()
+		return
+	case http.MethodDelete:
+		delete(f.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	if r.URL.Path == "/docs/" {
+		var keys []string
+		for k := range f.objects {
+			if strings.HasPrefix(k, r.URL.Query().Get("prefix")) {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		start := 0
+		fmt.Sscan(r.URL.Query().Get("continuation-token"), &start)
+		end := min(start+2, len(keys))
+
+		fmt.Fprint(w, `<ListBucketResult>`)
+		for _, k := range keys[start:end] {
+			fmt.Fprintf(w, `<Contents><Key>%s</Key><LastModified>2024-05-01T10:00:00.000Z</LastModified><ETag>"%x"</ETag><Size>%d</Size></Contents>`,
+				k, len(f.objects[k])+len(k), len(f.objects[k]))
+		}
+		if end < len(keys) {
+			fmt.Fprintf(w, `<IsTruncated>true</IsTruncated><NextContinuationToken>%d</NextContinuationToken>`, end)
+		}
+		fmt.Fprint(w, `</ListBucketResult>`)
+		return
+	}
+	content, ok := f.objects[key]
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `<Error><Code>NoSuchKey</Code><Message>The specified key does not exist.</Message></Error>`)
+		return
+	}
+	f.gets++
+	fmt.Fprint(w, content)
+}
+
+func TestBucket(t *testing.T) {
+	fake := &fakeS3{objects: map[string]string{
+		"site/index.md":        "# Home\n",
+		"site/guide/setup.md":  "# Setup\n",
+		"site/guide/deploy.md": "# Deploy\n",
+		"site/guide/":          "",
+		"site/img/logo.svg":    "<svg/>",
+		"other/ignored.md":     "# Ignored\n",
+	}}
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+
+	bucket := NewBucket(BucketConfig{Endpoint: srv.URL, Bucket: "docs", Prefix: "/site/", AccessKeyID: "key", SecretAccessKey: "secret"})
+	if err := bucket.Refresh(context.Background()); err != nil {
+		t.Fatalf("Refresh failed: %v", err)
+	}
+
+	if err := fstest.TestFS(bucket, "index.md", "guide/setup.md", "guide/deploy.md", "img/logo.svg"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fs.Stat(bucket, "ignored.md"); err == nil {
+		t.Error("expected keys outside the prefix to be left out")
+	}
+
+	fake.gets = 0
+	for range 3 {
+		if data, err := fs.ReadFile(bucket, "guide/setup.md"); err != nil || string(data) != "# Setup\n" {
+			t.Fatalf("ReadFile = %q, %v", data, err)
+		}
+	}
+	if fake.gets != 0 {
+		t.Errorf("expected content cached by the earlier reads, got %d fetches", fake.gets)
+	}
+
+	fake.mu.Lock()
+	fake.objects["site/guide/setup.md"] = "# Setup v2\n"
+	delete(fake.objects, "site/index.md")
+	fake.mu.Unlock()
+	if err := bucket.Refresh(context.Background()); err != nil {
+		t.Fatalf("Refresh failed: %v", err)
+	}
+	if data, _ := fs.ReadFile(bucket, "guide/setup.md"); string(data) != "# Setup v2\n" {
+		t.Errorf("expected changed object fetched again, got %q", data)
+	}
+	if _, err := fs.Stat(bucket, "index.md"); err == nil {
+		t.Error("expected deleted object gone after refresh")
+	}
+}
+
+func TestBucketPutDelete(t *testing.T) {
+	fake := &fakeS3{objects: map[string]string{"site/old.html": "old"}}
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+
+	bucket := NewBucket(BucketConfig{Endpoint: srv.URL, Bucket: "docs", Prefix: "site", AccessKeyID: "key", SecretAccessKey: "secret"})
+	ctx := context.Background()
+	if err := bucket.Put(ctx, "guide/index.html", []byte("<h1>Guide</h1>"), "text/html; charset=utf-8", "no-cache"); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	if err := bucket.Delete(ctx, "old.html"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+
+	if got := fake.objects["site/guide/index.html"]; got != "<h1>Guide</h1>" {
+		t.Errorf("expected uploaded content, got %q", got)
+	}
+	header := fake.headers["site/guide/index.html"]
+	if header.Get("Content-Type") != "text/html; charset=utf-8" || header.Get("Cache-Control") != "no-cache" {
+		t.Errorf("unexpected upload headers %v", header)
+	}
+	if _, ok := fake.objects["site/old.html"]; ok {
+		t.Error("expected deleted object gone")
+	}
+
+	if err := bucket.Refresh(ctx); err != nil {
+		t.Fatalf("Refresh failed: %v", err)
+	}
+	if etags := bucket.ETags(); len(etags) != 1 || etags["guide/index.html"] == "" {
+		t.Errorf("unexpected ETags %v", etags)
+	}
+}
+
+func TestParseBucketURL(t *testing.T) {
+	t.Setenv("AWS_ENDPOINT_URL", "")
+	t.Setenv("AWS_REGION", "")
+	t.Setenv("AWS_DEFAULT_REGION", "eu-central-1")
+
+	config, err := ParseBucketURL("s3://my-docs/published/v2/")
+	if err != nil {
+		t.Fatalf("ParseBucketURL failed: %v", err)
+	}
+	if config.Bucket != "my-docs" || config.Prefix != "published/v2" || config.Region != "eu-central-1" {
+		t.Errorf("unexpected config %+v", config)
+	}
+	if u, _ := NewBucket(config).objectURL("a b.md"); u.String() != "https://my-docs.s3.eu-central-1.amazonaws.com/a%20b.md" {
+		t.Errorf("unexpected object URL %s", u)
+	}
+
+	config, _ = ParseBucketURL("gs://my-docs")
+	if config.Endpoint != "https://storage.googleapis.com" {
+		t.Errorf("expected Cloud Storage endpoint, got %+v", config)
+	}
+	if _, err := ParseBucketURL("ftp://my-docs"); err == nil {
+		t.Error("expected error for unsupported scheme")
+	}
+}
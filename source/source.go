@@ -0,0 +1,56 @@
+// Package source opens the file systems gomdoc serves documentation from:
+// local directories, zip and tar archives, and S3-compatible object
+// storage.
+package source
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+)
+
+// Refresher is implemented by sources that can reload their listing, such
+// as object storage buckets.
+type Refresher interface {
+	Refresh(ctx context.Context) error
+}
+
+// IsRemote reports whether spec names object storage rather than a local
+// directory.
+func IsRemote(spec string) bool {
+	return strings.HasPrefix(spec, "s3://") || strings.HasPrefix(spec, "gs://")
+}
+
+// Open returns the file system named by spec: s3://bucket/prefix for
+// Amazon S3 or another S3-compatible store, gs://bucket/prefix for Google
+// Cloud Storage, a .zip, .tar, .tar.gz, or .tgz archive, or a local
+// directory. Buckets are listed once before Open returns; call Refresh to
+// pick up changes.
+func Open(ctx context.Context, spec string) (fs.FS, error) {
+	if IsRemote(spec) {
+		config, err := ParseBucketURL(spec)
+		if err != nil {
+			return nil, err
+		}
+		bucket := NewBucket(config)
+		if err := bucket.Refresh(ctx); err != nil {
+			return nil, fmt.Errorf("list %s: %w", spec, err)
+		}
+		return bucket, nil
+	}
+
+	if IsArchive(spec) {
+		return OpenArchive(spec)
+	}
+
+	info, err := os.Stat(spec)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", spec)
+	}
+	return os.DirFS(spec), nil
+}
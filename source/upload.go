@@ -0,0 +1,42 @@
+package source
+
+import (
+	"context"
+	"net/http"
+	"strings"
+)
+
+// ETags returns the ETag of every listed object by file name, without the
+// quotes. For objects uploaded in one piece, it is the hex-encoded MD5 of
+// the content.
+func (b *Bucket) ETags() map[string]string {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	etags := make(map[string]string, len(b.objects))
+	for name, obj := range b.objects {
+		etags[name] = strings.Trim(obj.etag, `"`)
+	}
+	return etags
+}
+
+// Put uploads data as the named file with the given Content-Type and
+// Cache-Control headers. The listing is not updated until the next
+// Refresh.
+func (b *Bucket) Put(ctx context.Context, name string, data []byte, contentType, cacheControl string) error {
+	header := http.Header{"Content-Type": {contentType}}
+	if cacheControl != "" {
+		header.Set("Cache-Control", cacheControl)
+	}
+	if data == nil {
+		data = []byte{}
+	}
+	_, err := b.send(ctx, http.MethodPut, b.config.Prefix+name, nil, data, header)
+	return err
+}
+
+// Delete removes the named file from the bucket. The listing is not
+// updated until the next Refresh.
+func (b *Bucket) Delete(ctx context.Context, name string) error {
+	_, err := b.send(ctx, http.MethodDelete, b.config.Prefix+name, nil, nil, nil)
+	return err
+}
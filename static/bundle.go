@@ -0,0 +1,73 @@
+package static
+
+import "strings"
+
+// scriptType is the media type of scripts.
+const scriptType = "text/javascript; charset=utf-8"
+
+// Source is a script of a bundle.
+type Source struct {
+	// Name is the file name, such as theme.js.
+	Name string
+	// Content is the script. Strings must not span lines, since the
+	// bundle is minified line by line.
+	Content string
+}
+
+// RegisterScripts registers sources joined in order into one minified
+// script under name, and each source unminified below js/, which pages
+// include instead in debug mode. Every source runs on every page that
+// includes the bundle, so each must check for the elements it needs.
+func RegisterScripts(name string, sources []Source) {
+	var bundle strings.Builder
+	names := make([]string, 0, len(sources))
+	for _, source := range sources {
+		bundle.WriteString(minify(source.Content))
+		register(Asset{Name: "js/" + source.Name, ContentType: scriptType, Content: []byte(source.Content), Source: true})
+		names = append(names, "js/"+source.Name)
+	}
+	register(Asset{Name: name, ContentType: scriptType, Content: []byte(bundle.String())})
+	registry.Lock()
+	defer registry.Unlock()
+	registry.bundles[name] = names
+}
+
+// SetDebug selects whether pages include the unminified sources of
+// bundles rather than the bundles.
+func SetDebug(debug bool) {
+	registry.Lock()
+	defer registry.Unlock()
+	registry.debug = debug
+}
+
+// Scripts returns the URLs of the scripts to include for the bundle name:
+// the bundle, or in debug mode its sources in order.
+func Scripts(name string) []string {
+	registry.RLock()
+	sources, debug := registry.bundles[name], registry.debug
+	registry.RUnlock()
+	if !debug || len(sources) == 0 {
+		return []string{URL(name)}
+	}
+	urls := make([]string, len(sources))
+	for i, source := range sources {
+		urls[i] = URL(source)
+	}
+	return urls
+}
+
+// minify drops the indentation, blank lines, and comment lines of a
+// script. Line breaks stay, so that automatic semicolon insertion works as
+// in the source.
+func minify(script string) string {
+	var sb strings.Builder
+	for line := range strings.SplitSeq(script, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "//") {
+			continue
+		}
+		sb.WriteString(line)
+		sb.WriteByte('\n')
+	}
+	return sb.String()
+}
@@ -0,0 +1,158 @@
+// Package static keeps the embedded front-end assets, such as the
+// stylesheet, and serves each under a URL containing a hash of its
+// content, e.g. /static/style.3f2a1b9c0d.css. A changed asset gets a new
+// URL, so browsers can cache assets forever and still pick up a new theme
+// without a hard refresh.
+package static
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Prefix is the URL path below which assets are served.
+const Prefix = "/static/"
+
+// hashLength is the number of hex digits of the content hash in URLs.
+const hashLength = 10
+
+// Cache-Control values of hashed URLs, whose content never changes, and of
+// plain ones, which browsers revalidate with the ETag.
+const (
+	immutableCache = "public, max-age=31536000, immutable"
+	revalidate     = "no-cache"
+)
+
+// Asset is an embedded file.
+type Asset struct {
+	// Name is the file name, such as style.css.
+	Name string
+	// ContentType is the media type it is served with.
+	ContentType string
+	// Content is the file content.
+	Content []byte
+	// Hash is the start of the SHA-256 of the content, in hex.
+	Hash string
+	// Source marks the unminified source of a script bundle, which pages
+	// only include in debug mode.
+	Source bool
+}
+
+// URL returns the hashed URL of the asset.
+func (a Asset) URL() string {
+	ext := path.Ext(a.Name)
+	return Prefix + strings.TrimSuffix(a.Name, ext) + "." + a.Hash + ext
+}
+
+// registry holds the registered assets by name.
+var registry = struct {
+	sync.RWMutex
+	assets  map[string]Asset
+	bundles map[string][]string
+	debug   bool
+}{assets: make(map[string]Asset), bundles: make(map[string][]string)}
+
+// Register adds an asset, replacing an earlier one of the same name. It is
+// meant to be called from init functions, before serving.
+func Register(name, contentType string, content []byte) {
+	register(Asset{Name: name, ContentType: contentType, Content: content})
+}
+
+// register hashes and adds asset.
+func register(asset Asset) {
+	sum := sha256.Sum256(asset.Content)
+	asset.Hash = hex.EncodeToString(sum[:])[:hashLength]
+	registry.Lock()
+	defer registry.Unlock()
+	registry.assets[asset.Name] = asset
+}
+
+// URL returns the hashed URL of the asset name, or its plain URL below
+// Prefix when no such asset is registered.
+func URL(name string) string {
+	registry.RLock()
+	defer registry.RUnlock()
+	if asset, ok := registry.assets[name]; ok {
+		return asset.URL()
+	}
+	return Prefix + name
+}
+
+// URLs returns the hashed URLs of all assets pages include, sorted by
+// name, e.g. for exporting them. The sources of bundles are left out
+// unless in debug mode.
+func URLs() []string {
+	registry.RLock()
+	defer registry.RUnlock()
+	urls := make([]string, 0, len(registry.assets))
+	for _, asset := range registry.assets {
+		if asset.Source && !registry.debug {
+			continue
+		}
+		urls = append(urls, asset.URL())
+	}
+	slices.Sort(urls)
+	return urls
+}
+
+// Lookup returns the asset at urlPath, which is below Prefix, by its
+// hashed URL or its plain name. hashed reports which of the two it was.
+// A hashed URL with an outdated hash is not found.
+func Lookup(urlPath string) (asset Asset, hashed bool, ok bool) {
+	name, found := strings.CutPrefix(urlPath, Prefix)
+	if !found {
+		return Asset{}, false, false
+	}
+	registry.RLock()
+	defer registry.RUnlock()
+	if asset, ok := registry.assets[name]; ok {
+		return asset, false, true
+	}
+	ext := path.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+	dot := strings.LastIndexByte(base, '.')
+	if dot < 0 {
+		return Asset{}, false, false
+	}
+	asset, ok = registry.assets[base[:dot]+ext]
+	if !ok || asset.Hash != base[dot+1:] {
+		return Asset{}, false, false
+	}
+	return asset, true, true
+}
+
+// CacheControl returns the Cache-Control value for urlPath: a year for
+// hashed asset URLs, and revalidation on every use for anything else,
+// such as pages, whose content changes under the same URL.
+func CacheControl(urlPath string) string {
+	if _, hashed, ok := Lookup(urlPath); ok && hashed {
+		return immutableCache
+	}
+	return revalidate
+}
+
+// Serve writes the asset at r's path, and reports false when there is
+// none. Hashed URLs are cached for a year; plain ones are revalidated with
+// their ETag on every use.
+func Serve(w http.ResponseWriter, r *http.Request) bool {
+	asset, hashed, ok := Lookup(r.URL.Path)
+	if !ok {
+		return false
+	}
+	w.Header().Set("Content-Type", asset.ContentType)
+	w.Header().Set("ETag", `"`+asset.Hash+`"`)
+	if hashed {
+		w.Header().Set("Cache-Control", immutableCache)
+	} else {
+		w.Header().Set("Cache-Control", revalidate)
+	}
+	http.ServeContent(w, r, asset.Name, time.Time{}, bytes.NewReader(asset.Content))
+	return true
+}
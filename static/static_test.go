@@ -0,0 +1,93 @@
+package static
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestServe(t *testing.T) {
+	Register("test.css", "text/css; charset=utf-8", []byte("body { color: red; }"))
+	url := URL("test.css")
+	if !strings.HasPrefix(url, "/static/test.") || !strings.HasSuffix(url, ".css") || len(url) != len("/static/test..css")+hashLength {
+		t.Fatalf("expected a hashed URL, got %q", url)
+	}
+	if got := URL("missing.js"); got != "/static/missing.js" {
+		t.Errorf("expected the plain URL of unknown assets, got %q", got)
+	}
+
+	get := func(path string, header ...string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		for i := 0; i+1 < len(header); i += 2 {
+			req.Header.Set(header[i], header[i+1])
+		}
+		rec := httptest.NewRecorder()
+		if !Serve(rec, req) {
+			rec.WriteHeader(http.StatusNotFound)
+		}
+		return rec
+	}
+
+	rec := get(url)
+	if rec.Code != http.StatusOK || rec.Body.String() != "body { color: red; }" {
+		t.Fatalf("expected the asset at its hashed URL, got %d %q", rec.Code, rec.Body.String())
+	}
+	if got := rec.Header().Get("Cache-Control"); got != immutableCache {
+		t.Errorf("expected hashed URLs cached for good, got %q", got)
+	}
+	if got := rec.Header().Get("Content-Type"); got != "text/css; charset=utf-8" {
+		t.Errorf("expected the content type, got %q", got)
+	}
+
+	rec = get("/static/test.css")
+	if rec.Code != http.StatusOK || rec.Header().Get("Cache-Control") != revalidate {
+		t.Errorf("expected the plain URL served for revalidation, got %d %q", rec.Code, rec.Header().Get("Cache-Control"))
+	}
+	if rec := get("/static/test.css", "If-None-Match", rec.Header().Get("ETag")); rec.Code != http.StatusNotModified {
+		t.Errorf("expected 304 for a matching ETag, got %d", rec.Code)
+	}
+	if rec := get("/static/test.0123456789.css"); rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for an outdated hash, got %d", rec.Code)
+	}
+	if CacheControl(url) != immutableCache || CacheControl("/static/test.css") != revalidate || CacheControl("/guide/index.html") != revalidate {
+		t.Errorf("expected only hashed URLs cached for good")
+	}
+
+	Register("test.css", "text/css; charset=utf-8", []byte("body { color: blue; }"))
+	if URL("test.css") == url {
+		t.Errorf("expected a new URL for new content")
+	}
+}
+
+func TestRegisterScripts(t *testing.T) {
+	RegisterScripts("test.js", []Source{
+		{Name: "one.js", Content: "\n(function() {\n    // Say hello\n    console.log('one');\n})();\n"},
+		{Name: "two.js", Content: "\n    console.log('two');\n"},
+	})
+	t.Cleanup(func() { SetDebug(false) })
+
+	asset, _, ok := Lookup(URL("test.js"))
+	if want := "(function() {\nconsole.log('one');\n})();\nconsole.log('two');\n"; !ok || string(asset.Content) != want {
+		t.Errorf("expected the minified bundle %q, got %q", want, asset.Content)
+	}
+	if got := Scripts("test.js"); len(got) != 1 || got[0] != URL("test.js") {
+		t.Errorf("expected the bundle, got %v", got)
+	}
+	if slices.Contains(URLs(), URL("js/one.js")) {
+		t.Errorf("expected the sources left out of the assets to export")
+	}
+
+	SetDebug(true)
+	got := Scripts("test.js")
+	if len(got) != 2 || got[0] != URL("js/one.js") || got[1] != URL("js/two.js") {
+		t.Fatalf("expected the sources in debug mode, got %v", got)
+	}
+	if asset, _, ok := Lookup(got[0]); !ok || !strings.Contains(string(asset.Content), "    // Say hello\n") {
+		t.Errorf("expected the source unminified, got %q", asset.Content)
+	}
+	if !slices.Contains(URLs(), got[1]) {
+		t.Errorf("expected the sources exported in debug mode")
+	}
+}
@@ -0,0 +1,154 @@
+// Package store is a small embedded database for server-side state such as
+// per-user bookmarks. Values are JSON documents grouped in buckets and kept
+// in memory; a file-backed database rewrites its file atomically on every
+// change.
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// DB is a bucketed key-value store. It is safe for concurrent use.
+type DB struct {
+	mu      sync.Mutex
+	path    string
+	buckets map[string]map[string]json.RawMessage
+}
+
+// Open loads the database file at path, starting empty when the file does
+// not exist yet.
+func Open(path string) (*DB, error) {
+	db := &DB{path: path, buckets: make(map[string]map[string]json.RawMessage)}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return db, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &db.buckets); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return db, nil
+}
+
+// Memory returns a database that is never persisted.
+func Memory() *DB {
+	return &DB{buckets: make(map[string]map[string]json.RawMessage)}
+}
+
+// Path returns the database file, or "" for an in-memory database.
+func (db *DB) Path() string {
+	return db.path
+}
+
+// Get decodes the value stored under key into v, reporting whether the key
+// exists.
+func (db *DB) Get(bucket, key string, v any) (bool, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	return db.get(bucket, key, v)
+}
+
+// Put stores v under key, replacing the value stored before.
+func (db *DB) Put(bucket, key string, v any) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	return db.put(bucket, key, v)
+}
+
+// Update decodes the value stored under key into v, lets fn modify it, and
+// stores the result. The database stays locked in between, so concurrent
+// updates of the same key do not lose changes. An error from fn aborts the
+// update.
+func (db *DB) Update(bucket, key string, v any, fn func() error) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	if _, err := db.get(bucket, key, v); err != nil {
+		return err
+	}
+	if err := fn(); err != nil {
+		return err
+	}
+	return db.put(bucket, key, v)
+}
+
+// put encodes and stores a value; the caller holds the lock.
+func (db *DB) put(bucket, key string, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	if db.buckets[bucket] == nil {
+		db.buckets[bucket] = make(map[string]json.RawMessage)
+	}
+	db.buckets[bucket][key] = data
+	return db.save()
+}
+
+// Delete removes key from the bucket.
+func (db *DB) Delete(bucket, key string) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	if _, ok := db.buckets[bucket][key]; !ok {
+		return nil
+	}
+	delete(db.buckets[bucket], key)
+	return db.save()
+}
+
+// Keys returns the keys of a bucket in sorted order.
+func (db *DB) Keys(bucket string) []string {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	keys := make([]string, 0, len(db.buckets[bucket]))
+	for key := range db.buckets[bucket] {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// get decodes a value; the caller holds the lock.
+func (db *DB) get(bucket, key string, v any) (bool, error) {
+	data, ok := db.buckets[bucket][key]
+	if !ok {
+		return false, nil
+	}
+	return true, json.Unmarshal(data, v)
+}
+
+// save writes the database to a temporary file and renames it over the
+// database file, so a crash never leaves a partial file behind. The caller
+// holds the lock.
+func (db *DB) save() error {
+	if db.path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(db.buckets, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(db.path), filepath.Base(db.path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), db.path)
+}
@@ -0,0 +1,81 @@
+package store
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+type item struct {
+	Names []string `json:"names"`
+}
+
+func TestPersistence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "gomdoc.db")
+	db, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	if err := db.Put("users", "jane", item{Names: []string{"a"}}); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	var it item
+	if err := db.Update("users", "jane", &it, func() error {
+		it.Names = append(it.Names, "b")
+		return nil
+	}); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	db.Put("users", "bob", item{Names: []string{"x"}})
+	db.Put("users", "bob", item{})
+
+	reopened, err := Open(path)
+	if err != nil {
+		t.Fatalf("reopen failed: %v", err)
+	}
+	var got item
+	if ok, err := reopened.Get("users", "jane", &got); !ok || err != nil || len(got.Names) != 2 {
+		t.Errorf("expected persisted value, got %+v (%v, %v)", got, ok, err)
+	}
+	if keys := reopened.Keys("users"); len(keys) != 2 || keys[0] != "bob" {
+		t.Errorf("unexpected keys %v", keys)
+	}
+	var bob item
+	if ok, err := reopened.Get("users", "bob", &bob); !ok || err != nil || len(bob.Names) != 0 {
+		t.Errorf("expected Put to replace the value, got %+v (%v, %v)", bob, ok, err)
+	}
+
+	reopened.Delete("users", "bob")
+	if ok, _ := reopened.Get("users", "bob", &got); ok {
+		t.Error("expected deleted key to be gone")
+	}
+}
+
+func TestUpdateAbortsOnError(t *testing.T) {
+	db := Memory()
+	db.Put("b", "k", item{Names: []string{"keep"}})
+
+	var it item
+	errAbort := errors.New("abort")
+	err := db.Update("b", "k", &it, func() error {
+		it.Names = nil
+		return errAbort
+	})
+	if !errors.Is(err, errAbort) {
+		t.Fatalf("expected abort error, got %v", err)
+	}
+	var got item
+	db.Get("b", "k", &got)
+	if len(got.Names) != 1 {
+		t.Errorf("expected value unchanged, got %+v", got)
+	}
+}
+
+func TestOpenInvalidFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "broken.db")
+	os.WriteFile(path, []byte("{not json"), 0o600)
+	if _, err := Open(path); err == nil {
+		t.Error("expected error for invalid database file")
+	}
+}
@@ -0,0 +1,87 @@
+package templates
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	"gomdoc/clock"
+)
+
+// Banner is the site-wide notice set with SetBanner, shown above the menu
+// of every page.
+type Banner struct {
+	// Text is the notice in markdown.
+	Text string
+	// Severity is info, warning, or danger, and picks the color.
+	Severity string
+	// Expires is when the banner stops showing; zero shows it for good.
+	Expires time.Time
+	// Dismissible adds a close button remembered in a cookie.
+	Dismissible bool
+	// ID changes with the text, so a new notice shows again to readers
+	// who dismissed the last one. SetBanner fills it in.
+	ID string
+}
+
+// banner holds the notice returned by the banner function, set by
+// SetBanner.
+var banner Banner
+
+// SetBanner sets the site-wide notice, e.g. from the banner: section of
+// gomdoc.yml. A banner without text shows nothing. Call it before serving
+// or exporting.
+func SetBanner(b Banner) {
+	if b.Text != "" {
+		sum := sha256.Sum256([]byte(b.Severity + "\n" + b.Text))
+		b.ID = hex.EncodeToString(sum[:6])
+	}
+	banner = b
+}
+
+// currentBanner returns the banner for the banner function, or nil when
+// none is set or it has expired.
+func currentBanner() *Banner {
+	if banner.Text == "" || (!banner.Expires.IsZero() && !clock.Now().Before(banner.Expires)) {
+		return nil
+	}
+	return &banner
+}
+
+// bannerHTML is the site-wide notice shared by all page templates. The
+// close button stays hidden until the banner script runs, which also
+// hides dismissed banners and, in exported sites, expired ones.
+const bannerHTML = `{{- with banner}}
+    <div class="site-banner site-banner-{{.Severity}}" role="status" data-banner="{{.ID}}"{{if not .Expires.IsZero}} data-expires="{{.Expires.UTC.Format "2006-01-02T15:04:05Z"}}"{{end}}>
+        <div class="site-banner-text">{{markdownify .Text}}</div>
+        {{- if .Dismissible}}
+        <button type="button" class="site-banner-close" data-action="dismiss-banner" aria-label="Dismiss" hidden>&times;</button>
+        {{- end}}
+    </div>
+    {{- end}}`
+
+// bannerJS hides the banner once dismissed, remembering the dismissal in
+// a cookie named after the banner, and hides expired banners.
+const bannerJS = `
+(function() {
+    var el = document.querySelector('[data-banner]');
+    if (!el) return;
+    var id = el.getAttribute('data-banner');
+    var expires = el.getAttribute('data-expires');
+    if (expires && Date.now() >= Date.parse(expires)) {
+        el.remove();
+        return;
+    }
+    if (document.cookie.split('; ').indexOf('gomdoc-banner=' + id) >= 0) {
+        el.remove();
+        return;
+    }
+    var close = el.querySelector('[data-action="dismiss-banner"]');
+    if (!close) return;
+    close.hidden = false;
+    close.addEventListener('click', function() {
+        document.cookie = 'gomdoc-banner=' + id + '; path=/; max-age=31536000; SameSite=Lax';
+        el.remove();
+    });
+})();
+`
@@ -0,0 +1,112 @@
+package templates
+
+import (
+	"fmt"
+	"html/template"
+	"io/fs"
+	"maps"
+	"path"
+	"slices"
+	"strings"
+)
+
+// customizable maps the templates that custom templates replace, by file
+// name without extension, to their built-in blocks.
+var customizable = map[string]string{
+	"page":     pageTemplate,
+	"landing":  landingTemplate,
+	"index":    indexTemplate,
+	"notfound": notFoundTemplate,
+}
+
+// Load replaces and extends the built-in templates with the .html files in
+// fsys, each parsed with the template functions:
+//
+//   - page.html renders pages with the default and wide layouts
+//   - landing.html renders pages with the landing layout
+//   - index.html renders the file index at /browse
+//   - notfound.html renders the 404 and 410 pages
+//   - any other name.html adds a layout selected with layout: name
+//
+// A file is parsed over the built-in template it replaces, so a file
+// holding only {{define}} actions replaces those blocks of the base
+// template and keeps the rest, while a file with markup outside of them
+// replaces the whole page. New layouts start from the page template,
+// including the blocks of a custom page.html.
+//
+// Files starting with an underscore are partials, defined in every
+// template by their file name, e.g. {{template "_nav.html" .}}. values are
+// returned by the config function. Load is meant to be called once at
+// startup, before serving.
+func Load(fsys fs.FS, values map[string]string) error {
+	names, err := fs.Glob(fsys, "*.html")
+	if err != nil {
+		return err
+	}
+	var partials, files []string
+	for _, name := range names {
+		if strings.HasPrefix(name, "_") {
+			partials = append(partials, name)
+		} else {
+			files = append(files, name)
+		}
+	}
+
+	parsed := make(map[string]*template.Template)
+	for _, name := range files {
+		layout := strings.TrimSuffix(name, path.Ext(name))
+		blocks, builtin := customizable[layout]
+		if !builtin {
+			blocks = pageTemplate
+		}
+		tmpl := template.New(name).Funcs(funcs)
+		if _, err := tmpl.Parse(baseTemplate); err != nil {
+			return err
+		}
+		if _, err := tmpl.Parse(blocks); err != nil {
+			return err
+		}
+		for _, partial := range partials {
+			if err := parseFile(tmpl.New(partial), fsys, partial); err != nil {
+				return err
+			}
+		}
+		if !builtin && slices.Contains(files, "page.html") {
+			if err := parseFile(tmpl, fsys, "page.html"); err != nil {
+				return err
+			}
+		}
+		if err := parseFile(tmpl, fsys, name); err != nil {
+			return err
+		}
+		parsed[layout] = tmpl
+	}
+
+	for name, tmpl := range parsed {
+		switch name {
+		case "page":
+			pageTmpl = tmpl
+			layouts["default"], layouts["wide"] = tmpl, tmpl
+		case "index":
+			indexTmpl = tmpl
+		case "notfound":
+			notFoundTmpl = tmpl
+		default:
+			layouts[name] = tmpl
+		}
+	}
+	config = maps.Clone(values)
+	return nil
+}
+
+// parseFile parses the template file name from fsys into tmpl.
+func parseFile(tmpl *template.Template, fsys fs.FS, name string) error {
+	data, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return err
+	}
+	if _, err := tmpl.Parse(string(data)); err != nil {
+		return fmt.Errorf("template %s: %w", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,35 @@
+package templates
+
+import (
+	"strconv"
+	"strings"
+
+	"gomdoc/clock"
+)
+
+// yearPlaceholder in the copyright line is replaced with the current year,
+// so "© 2019-{year} Example Corp" stays current without redeploying config.
+const yearPlaceholder = "{year}"
+
+// Footer configures the site footer shown on every page.
+type Footer struct {
+	// Text is a free-form line, e.g. a team contact.
+	Text string
+	// Links are shown as a row of links, e.g. imprint and privacy policy.
+	Links []FooterLink
+	// Copyright is the copyright line. {year} expands to the current year.
+	Copyright string
+	// HideGeneratedBy removes the "Documentation created by gomdoc" line.
+	HideGeneratedBy bool
+}
+
+// FooterLink is a labeled link in the footer.
+type FooterLink struct {
+	Label string
+	URL   string
+}
+
+// CopyrightLine returns the copyright text with the year placeholder expanded.
+func (f Footer) CopyrightLine() string {
+	return strings.ReplaceAll(f.Copyright, yearPlaceholder, strconv.Itoa(clock.Now().Year()))
+}
@@ -0,0 +1,169 @@
+package templates
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"strings"
+	"time"
+	"unicode"
+
+	"gomdoc/clock"
+	"gomdoc/renderer"
+	"gomdoc/scanner"
+	"gomdoc/search"
+	"gomdoc/static"
+)
+
+// config holds the values returned by the config function, set by Load.
+var config = map[string]string{}
+
+// funcs are the functions available to every template, built-in and
+// custom. See the README section Custom Templates for examples.
+var funcs = template.FuncMap{
+	"date":        formatDate,
+	"now":         clock.Now,
+	"slugify":     Slugify,
+	"markdownify": markdownify,
+	"config":      lookupConfig,
+	"lower":       strings.ToLower,
+	"upper":       strings.ToUpper,
+	"join":        strings.Join,
+	"hasPrefix":   strings.HasPrefix,
+	"default":     defaultValue,
+	"pages":       pages,
+	"findNode":    findNode,
+	"ancestors":   Ancestors,
+	"contains":    contains,
+	"asset":       static.URL,
+	"scripts":     scripts,
+	"menu":        menuItems,
+	"banner":      currentBanner,
+	"tables":      tableSettings,
+	"mermaid":     mermaidScriptURL,
+}
+
+// formatDate formats a time or a frontmatter date string with a Go time
+// layout, e.g. date "January 2, 2006" .Date. Strings are parsed like the
+// dates of search filters, by calendar day; strings that are no date are
+// returned unchanged.
+func formatDate(layout string, value any) string {
+	switch v := value.(type) {
+	case time.Time:
+		return v.Format(layout)
+	case string:
+		if t, err := search.ParseDate(v); err == nil {
+			return t.Format(layout)
+		}
+		return v
+	}
+	return fmt.Sprint(value)
+}
+
+// Slugify turns s into a lowercase URL fragment of letters, digits, and
+// single dashes, e.g. "Getting Started!" becomes "getting-started".
+func Slugify(s string) string {
+	var sb strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(s) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if dash && sb.Len() > 0 {
+				sb.WriteByte('-')
+			}
+			sb.WriteRune(r)
+			dash = false
+		} else {
+			dash = true
+		}
+	}
+	return sb.String()
+}
+
+// markdownify renders markdown to HTML. A single paragraph is returned
+// without its <p> wrapper so the result fits inline, e.g. in a footer.
+func markdownify(s string) (template.HTML, error) {
+	html, err := renderer.New().Render([]byte(s))
+	if err != nil {
+		return "", err
+	}
+	html = bytes.TrimSpace(html)
+	if inner, ok := bytes.CutPrefix(html, []byte("<p>")); ok && bytes.Count(html, []byte("<p>")) == 1 {
+		if inner, ok = bytes.CutSuffix(inner, []byte("</p>")); ok {
+			html = inner
+		}
+	}
+	return template.HTML(html), nil
+}
+
+// lookupConfig returns the configured value for key, or "" when unset.
+func lookupConfig(key string) string {
+	return config[key]
+}
+
+// defaultValue returns value, or fallback when value is empty, e.g.
+// default "Untitled" .Title.
+func defaultValue(fallback, value any) any {
+	switch v := value.(type) {
+	case nil:
+		return fallback
+	case string:
+		if v == "" {
+			return fallback
+		}
+	case []string:
+		if len(v) == 0 {
+			return fallback
+		}
+	}
+	return value
+}
+
+// pages lists the pages below node in navigation order, leaving out
+// attachments.
+func pages(node *scanner.TreeNode) []scanner.PathEntry {
+	if node == nil {
+		return nil
+	}
+	return scanner.FlatPaths(node)
+}
+
+// findNode returns the node below root with the URL path urlPath, or nil.
+func findNode(root *scanner.TreeNode, urlPath string) *scanner.TreeNode {
+	if trail := Ancestors(root, urlPath); len(trail) > 0 {
+		for _, child := range trail[len(trail)-1].Children {
+			if child.Path == urlPath {
+				return child
+			}
+		}
+	}
+	return nil
+}
+
+// Ancestors returns the nodes from root down to the directory holding the
+// node with the URL path urlPath, or nil when the tree lacks it. Templates
+// use it for breadcrumbs and to expand the current section.
+func Ancestors(root *scanner.TreeNode, urlPath string) []*scanner.TreeNode {
+	if root == nil {
+		return nil
+	}
+	for _, child := range root.Children {
+		if child.Path == urlPath {
+			return []*scanner.TreeNode{root}
+		}
+		if child.IsDir {
+			if trail := Ancestors(child, urlPath); trail != nil {
+				return append([]*scanner.TreeNode{root}, trail...)
+			}
+		}
+	}
+	return nil
+}
+
+// contains reports whether node is or holds the node with the URL path
+// urlPath, e.g. to mark the active section of a navigation menu.
+func contains(node *scanner.TreeNode, urlPath string) bool {
+	if node == nil {
+		return false
+	}
+	return node.Path == urlPath || Ancestors(node, urlPath) != nil
+}
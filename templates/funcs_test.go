@@ -0,0 +1,252 @@
+package templates
+
+import (
+	"maps"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"gomdoc/scanner"
+)
+
+func TestTemplateFuncs(t *testing.T) {
+	if got := formatDate("January 2, 2006", "2024-03-01"); got != "March 1, 2024" {
+		t.Errorf("date: got %q", got)
+	}
+	if got := formatDate("2006", time.Date(2023, 5, 1, 0, 0, 0, 0, time.UTC)); got != "2023" {
+		t.Errorf("date of a time: got %q", got)
+	}
+	if got := formatDate("January 2006", "2024-03"); got != "March 2024" {
+		t.Errorf("date of a month: got %q", got)
+	}
+	if got := formatDate("2006", "soon"); got != "soon" {
+		t.Errorf("expected non-dates unchanged, got %q", got)
+	}
+	if got := Slugify("  Getting Started: Über-Guide! "); got != "getting-started-über-guide" {
+		t.Errorf("slugify: got %q", got)
+	}
+	if got, _ := markdownify("Made with **care**"); got != "Made with <strong>care</strong>" {
+		t.Errorf("markdownify: got %q", got)
+	}
+
+	tree := scanner.BuildTree([]scanner.FileEntry{
+		{RelPath: "index.md", Name: "index", URLPath: "/index"},
+		{RelPath: "guide/setup.md", Name: "setup", URLPath: "/guide/setup"},
+	})
+	trail := Ancestors(tree, "/guide/setup")
+	if len(trail) != 2 || trail[1].Name != "guide" {
+		t.Fatalf("expected root and guide as ancestors, got %v", trail)
+	}
+	if node := findNode(tree, "/guide/setup"); node == nil || node.Name != "setup.md" {
+		t.Errorf("expected setup node, got %v", node)
+	}
+	if !contains(trail[1], "/guide/setup") || contains(trail[1], "/index") {
+		t.Errorf("expected guide to contain only its own pages")
+	}
+	if got := pages(tree); len(got) != 2 {
+		t.Errorf("expected two pages, got %v", got)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	savedPage, savedIndex, savedNotFound, savedLayouts := pageTmpl, indexTmpl, notFoundTmpl, maps.Clone(layouts)
+	t.Cleanup(func() {
+		pageTmpl, indexTmpl, notFoundTmpl, layouts, config = savedPage, savedIndex, savedNotFound, savedLayouts, map[string]string{}
+	})
+
+	fsys := fstest.MapFS{
+		"_nav.html":   {Data: []byte(`<nav>{{range pages .Tree}}<a href="{{.Path}}">{{.Name}}</a>{{end}}</nav>`)},
+		"page.html":   {Data: []byte(`<title>{{.Title}} | {{config "title"}}</title>{{template "_nav.html" .}}<p id="{{slugify .Title}}">{{date "2 Jan 2006" .Date}}</p>{{template "footer" .}}`)},
+		"slides.html": {Data: []byte(`<section>{{.Content}}</section>`)},
+	}
+	if err := Load(fsys, map[string]string{"title": "Handbook"}); err != nil {
+		t.Fatal(err)
+	}
+
+	tree := scanner.BuildTree([]scanner.FileEntry{{RelPath: "intro.md", Name: "intro", URLPath: "/intro"}})
+	var sb strings.Builder
+	if err := RenderPage(&sb, PageData{Title: "Intro Page", Date: "2024-03-01", Tree: tree}); err != nil {
+		t.Fatal(err)
+	}
+	got := sb.String()
+	for _, want := range []string{"<title>Intro Page | Handbook</title>", `<a href="/intro">intro.md</a>`, `<p id="intro-page">1 Mar 2024</p>`, `class="site-footer"`} {
+		if !strings.Contains(got, want) {
+			t.Errorf("expected %q in custom page, got:\n%s", want, got)
+		}
+	}
+
+	if !HasLayout("slides") {
+		t.Fatalf("expected slides layout added")
+	}
+	sb.Reset()
+	RenderPage(&sb, PageData{Layout: "slides", Content: "<h1>Hi</h1>"})
+	if sb.String() != "<section><h1>Hi</h1></section>" {
+		t.Errorf("expected slides layout, got %q", sb.String())
+	}
+
+	if err := Load(fstest.MapFS{"page.html": {Data: []byte(`{{if}}`)}}, nil); err == nil || !strings.Contains(err.Error(), "page.html") {
+		t.Errorf("expected parse error naming the file, got %v", err)
+	}
+}
+
+func TestLoadBlocks(t *testing.T) {
+	savedPage, savedIndex, savedNotFound, savedLayouts := pageTmpl, indexTmpl, notFoundTmpl, maps.Clone(layouts)
+	t.Cleanup(func() {
+		pageTmpl, indexTmpl, notFoundTmpl, layouts, config = savedPage, savedIndex, savedNotFound, savedLayouts, map[string]string{}
+	})
+
+	fsys := fstest.MapFS{
+		"page.html":   {Data: []byte(`{{define "footer"}}<footer>{{config "company"}}</footer>{{end}}`)},
+		"index.html":  {Data: []byte(`{{define "nav"}}<nav>Custom index nav</nav>{{end}}`)},
+		"slides.html": {Data: []byte(`{{define "sidebar"}}<!-- no tree -->{{end}}{{define "content"}}<section>{{.Content}}</section>{{end}}`)},
+	}
+	if err := Load(fsys, map[string]string{"company": "Acme"}); err != nil {
+		t.Fatal(err)
+	}
+
+	var sb strings.Builder
+	if err := RenderPage(&sb, PageData{Title: "Intro", SiteLayout: SiteLayout{SiteTitle: "Docs"}, Content: "<p>Hello</p>"}); err != nil {
+		t.Fatal(err)
+	}
+	page := sb.String()
+	for _, want := range []string{"<title>Intro - Docs</title>", `<nav class="nav-buttons">`, "<p>Hello</p>", "<footer>Acme</footer>"} {
+		if !strings.Contains(page, want) {
+			t.Errorf("expected %q in the page, got:\n%s", want, page)
+		}
+	}
+	if strings.Contains(page, "site-footer") {
+		t.Errorf("expected the footer block replaced, got:\n%s", page)
+	}
+
+	sb.Reset()
+	if err := RenderIndex(&sb, IndexData{SiteLayout: SiteLayout{SiteTitle: "Docs"}}); err != nil {
+		t.Fatal(err)
+	}
+	if index := sb.String(); !strings.Contains(index, "<nav>Custom index nav</nav>") || !strings.Contains(index, "<h1>File Index</h1>") {
+		t.Errorf("expected only the index nav replaced, got:\n%s", index)
+	}
+
+	sb.Reset()
+	if err := RenderPage(&sb, PageData{Layout: "slides", Title: "Deck", SiteLayout: SiteLayout{SiteTitle: "Docs"}, Content: "<h1>Hi</h1>"}); err != nil {
+		t.Fatal(err)
+	}
+	slides := sb.String()
+	for _, want := range []string{"<title>Deck - Docs</title>", "<section><h1>Hi</h1></section>", "<footer>Acme</footer>"} {
+		if !strings.Contains(slides, want) {
+			t.Errorf("expected %q in the slides layout, got:\n%s", want, slides)
+		}
+	}
+	if strings.Contains(slides, `class="sidebar"`) {
+		t.Errorf("expected the sidebar block dropped, got:\n%s", slides)
+	}
+}
+
+func TestMenu(t *testing.T) {
+	t.Cleanup(func() { SetMenu(nil) })
+
+	var sb strings.Builder
+	if err := RenderIndex(&sb, IndexData{SiteLayout: SiteLayout{SiteTitle: "Docs"}}); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(sb.String(), "site-menu") {
+		t.Errorf("expected no menu when none is set, got:\n%s", sb.String())
+	}
+
+	SetMenu([]MenuItem{
+		{Title: "Guides", Children: []MenuItem{{Title: "Setup", URL: "/guide/setup"}}},
+		{Title: "GitHub", URL: "https://github.com/lacrioque/gomdoc", External: true},
+	})
+	for name, render := range map[string]func() error{
+		"page":  func() error { return RenderPage(&sb, PageData{Title: "Intro"}) },
+		"index": func() error { return RenderIndex(&sb, IndexData{}) },
+		"404":   func() error { return RenderNotFound(&sb, NotFoundData{}) },
+	} {
+		sb.Reset()
+		if err := render(); err != nil {
+			t.Fatal(err)
+		}
+		got := sb.String()
+		for _, want := range []string{`<nav class="site-menu"`, "<summary>Guides</summary>", `<a href="/guide/setup">Setup</a>`, `<a href="https://github.com/lacrioque/gomdoc" rel="noopener">GitHub</a>`} {
+			if !strings.Contains(got, want) {
+				t.Errorf("expected %q on the %s page, got:\n%s", want, name, got)
+			}
+		}
+	}
+}
+
+func TestBanner(t *testing.T) {
+	t.Cleanup(func() { SetBanner(Banner{}) })
+
+	SetBanner(Banner{Text: "Docs are **moving**", Severity: "warning", Dismissible: true})
+	var sb strings.Builder
+	if err := RenderPage(&sb, PageData{Title: "Intro"}); err != nil {
+		t.Fatal(err)
+	}
+	page := sb.String()
+	for _, want := range []string{`class="site-banner site-banner-warning"`, `data-banner="` + banner.ID + `"`, "Docs are <strong>moving</strong>", `data-action="dismiss-banner"`} {
+		if !strings.Contains(page, want) {
+			t.Errorf("expected %q in the page, got:\n%s", want, page)
+		}
+	}
+	if strings.Index(page, "site-banner") > strings.Index(page, "nav-buttons") {
+		t.Errorf("expected the banner above the nav")
+	}
+
+	id := banner.ID
+	SetBanner(Banner{Text: "Migration done", Severity: "info"})
+	if banner.ID == id {
+		t.Errorf("expected a new ID for a new text")
+	}
+
+	SetBanner(Banner{Text: "Old news", Severity: "info", Expires: time.Now().Add(-time.Minute)})
+	sb.Reset()
+	if err := RenderIndex(&sb, IndexData{}); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(sb.String(), "site-banner") {
+		t.Errorf("expected an expired banner hidden, got:\n%s", sb.String())
+	}
+}
+
+func TestMermaidScript(t *testing.T) {
+	t.Cleanup(func() { SetMermaidScript("") })
+
+	var sb strings.Builder
+	if err := RenderPage(&sb, PageData{Title: "Diagrams"}); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(sb.String(), "mermaid.min.js") || strings.Contains(sb.String(), "jsdelivr") {
+		t.Errorf("expected no Mermaid script by default, got:\n%s", sb.String())
+	}
+
+	SetMermaidScript("/assets/mermaid.min.js")
+	sb.Reset()
+	if err := RenderPage(&sb, PageData{Title: "Diagrams"}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(sb.String(), `<script src="/assets/mermaid.min.js"></script>`) {
+		t.Errorf("expected the Mermaid script loaded, got:\n%s", sb.String())
+	}
+}
+
+func TestTables(t *testing.T) {
+	t.Cleanup(func() { SetTables(Tables{}) })
+
+	var sb strings.Builder
+	if err := RenderPage(&sb, PageData{Title: "Reference"}); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(sb.String(), "data-sortable-tables") {
+		t.Errorf("expected tables left alone by default")
+	}
+
+	SetTables(Tables{Sortable: true, PageSize: 50})
+	sb.Reset()
+	if err := RenderPage(&sb, PageData{Title: "Reference"}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(sb.String(), `<main class="content" data-sortable-tables data-table-page-size="50">`) {
+		t.Errorf("expected the content marked for sorting, got:\n%s", sb.String())
+	}
+}
@@ -0,0 +1,45 @@
+package templates
+
+// MenuItem is an entry of the top-bar menu set with SetMenu: a link, or a
+// dropdown of links when Children is set.
+type MenuItem struct {
+	Title    string
+	URL      string
+	External bool
+	Children []MenuItem
+}
+
+// menu holds the items returned by the menu function, set by SetMenu.
+var menu []MenuItem
+
+// SetMenu sets the top-bar menu shown on every page, e.g. from the nav:
+// section of gomdoc.yml. Call it before serving or exporting.
+func SetMenu(items []MenuItem) {
+	menu = items
+}
+
+// menuItems returns the top-bar menu for the menu function.
+func menuItems() []MenuItem {
+	return menu
+}
+
+// menuHTML is the top-bar menu shared by all page templates. Dropdowns are
+// details elements, so they open without JavaScript.
+const menuHTML = `{{- with menu}}
+    <nav class="site-menu" aria-label="Site menu">
+        {{- range .}}
+        {{- if .Children}}
+        <details class="site-menu-dropdown">
+            <summary>{{.Title}}</summary>
+            <div class="site-menu-items">
+                {{- range .Children}}
+                <a href="{{.URL}}"{{if .External}} rel="noopener"{{end}}>{{.Title}}</a>
+                {{- end}}
+            </div>
+        </details>
+        {{- else}}
+        <a href="{{.URL}}"{{if .External}} rel="noopener"{{end}}>{{.Title}}</a>
+        {{- end}}
+        {{- end}}
+    </nav>
+    {{- end}}`
@@ -0,0 +1,57 @@
+package templates
+
+import "gomdoc/static"
+
+// bundleName is the name of the script bundle every page includes.
+const bundleName = "gomdoc.js"
+
+// bundle lists the scripts of the bundle in the order they run. The
+// theme comes first, so that later scripts see the page in its colors.
+var bundle = []static.Source{
+	{Name: "theme.js", Content: themeJS},
+	{Name: "actions.js", Content: actionsJS},
+	{Name: "banner.js", Content: bannerJS},
+	{Name: "mermaid.js", Content: mermaidJS},
+	{Name: "code-block.js", Content: codeBlockJS},
+	{Name: "tables.js", Content: tablesJS},
+	{Name: "static-search.js", Content: staticSearchJS},
+	{Name: "search.js", Content: searchJS},
+	{Name: "search-page.js", Content: searchPageJS},
+	{Name: "quick-open.js", Content: quickOpenJS},
+	{Name: "toc.js", Content: tocJS},
+	{Name: "link-preview.js", Content: linkPreviewJS},
+	{Name: "bookmarks.js", Content: bookmarksJS},
+	{Name: "upload.js", Content: uploadJS},
+	{Name: "cast-player.js", Content: castPlayerJS},
+	{Name: "lazy-tree.js", Content: lazyTreeJS},
+	{Name: "folder-toggle.js", Content: folderToggleJS},
+	{Name: "download.js", Content: downloadJS},
+	{Name: "back-to-top.js", Content: backToTopJS},
+}
+
+func init() {
+	static.RegisterScripts(bundleName, bundle)
+}
+
+// mermaidScript is the URL pages load Mermaid from, set by
+// SetMermaidScript.
+var mermaidScript string
+
+// SetMermaidScript sets the URL of the Mermaid script pages load to draw
+// diagrams in the browser, such as a copy below assets/. Empty, the
+// default, loads nothing from outside the binary, and diagrams not drawn
+// on the server show as code. Call it before serving or exporting.
+func SetMermaidScript(url string) {
+	mermaidScript = url
+}
+
+// mermaidScriptURL returns the URL for the mermaid function.
+func mermaidScriptURL() string {
+	return mermaidScript
+}
+
+// scripts returns the URLs of the scripts pages include: the bundle, or
+// its sources in debug mode.
+func scripts() []string {
+	return static.Scripts(bundleName)
+}
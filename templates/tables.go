@@ -0,0 +1,167 @@
+package templates
+
+// Tables configures the tables of every page, set with SetTables.
+type Tables struct {
+	// Sortable lets readers sort every table by a column and filter its
+	// rows. Without it, only tables with a {.sortable} attribute list do.
+	Sortable bool
+	// PageSize splits the rows of longer tables into pages of this many
+	// rows. Zero shows all rows, unless a table sets data-page-size.
+	PageSize int
+}
+
+// tables holds the settings returned by the tables function, set by
+// SetTables.
+var tables Tables
+
+// SetTables sets how the tables of every page behave, e.g. from the
+// tables: section of gomdoc.yml. Call it before serving or exporting.
+func SetTables(t Tables) {
+	tables = t
+}
+
+// tableSettings returns the table settings for the tables function.
+func tableSettings() Tables {
+	return tables
+}
+
+// tableAttributes marks the content of a page for the tables script with
+// the site's table settings.
+const tableAttributes = `{{with tables}}{{if .Sortable}} data-sortable-tables{{end}}{{if .PageSize}} data-table-page-size="{{.PageSize}}"{{end}}{{end}}`
+
+// tablesJS lets readers sort tables with a sortable class, or every table
+// with data-sortable-tables, by clicking a column header, and filter their
+// rows with a box above them. Columns of numbers sort by value, others in
+// natural order, so "Item 10" follows "Item 9". Tables with more rows than
+// their data-page-size, or the data-table-page-size of the page, are shown
+// a page of rows at a time.
+const tablesJS = `
+(function() {
+    document.querySelectorAll('.content table').forEach(function(table) {
+        var body = table.tBodies[0];
+        if (!table.tHead || !body || body.rows.length < 2) return;
+        var rows = Array.prototype.slice.call(body.rows);
+        var sortable = table.classList.contains('sortable') || table.closest('[data-sortable-tables]');
+        var settings = table.closest('[data-table-page-size]');
+        var pageSize = parseInt(table.getAttribute('data-page-size') || (settings && settings.getAttribute('data-table-page-size')), 10) || 0;
+        var paged = pageSize > 0 && rows.length > pageSize;
+        if (!sortable && !paged) return;
+        // Controls go around the scroll box, so they stay in view.
+        var box = table.parentNode.classList.contains('table-scroll') ? table.parentNode : table;
+        var page = 0;
+        var filter, count, pager, status, prev, next;
+
+        function update() {
+            var shown = rows.filter(function(row) { return row.dataset.filtered !== 'out'; });
+            var pages = paged ? Math.max(1, Math.ceil(shown.length / pageSize)) : 1;
+            page = Math.min(page, pages - 1);
+            rows.forEach(function(row) {
+                row.hidden = true;
+                delete row.dataset.paged;
+            });
+            shown.forEach(function(row, i) {
+                row.hidden = paged && Math.floor(i / pageSize) !== page;
+                if (row.hidden) row.dataset.paged = 'out';
+            });
+            if (count) {
+                count.textContent = filter.value.trim() ? shown.length + ' of ' + rows.length + ' rows' : '';
+            }
+            if (paged) {
+                status.textContent = 'Page ' + (page + 1) + ' of ' + pages;
+                prev.disabled = page === 0;
+                next.disabled = page >= pages - 1;
+            }
+        }
+
+        if (sortable) {
+            var tools = document.createElement('div');
+            tools.className = 'table-tools';
+            filter = document.createElement('input');
+            filter.type = 'search';
+            filter.className = 'table-filter';
+            filter.placeholder = 'Filter rows';
+            filter.setAttribute('aria-label', 'Filter table rows');
+            count = document.createElement('span');
+            count.className = 'table-count';
+            count.setAttribute('aria-live', 'polite');
+            tools.appendChild(filter);
+            tools.appendChild(count);
+            box.parentNode.insertBefore(tools, box);
+
+            filter.addEventListener('input', function() {
+                var query = filter.value.trim().toLowerCase();
+                rows.forEach(function(row) {
+                    if (query && row.textContent.toLowerCase().indexOf(query) === -1) {
+                        row.dataset.filtered = 'out';
+                    } else {
+                        delete row.dataset.filtered;
+                    }
+                });
+                page = 0;
+                update();
+            });
+
+            var headers = table.tHead.rows[table.tHead.rows.length - 1].cells;
+            var sortKey = function(row, column) {
+                var cell = row.cells[column];
+                var text = cell ? cell.textContent.trim() : '';
+                var number = text.replace(/[\s,]/g, '').replace(/^[$€£¥]/, '').replace(/%$/, '');
+                return {text: text, number: number !== '' && !isNaN(number) ? parseFloat(number) : null};
+            };
+            Array.prototype.forEach.call(headers, function(th, column) {
+                var button = document.createElement('button');
+                button.type = 'button';
+                button.className = 'table-sort';
+                while (th.firstChild) button.appendChild(th.firstChild);
+                th.appendChild(button);
+                button.addEventListener('click', function() {
+                    var ascending = th.getAttribute('aria-sort') !== 'ascending';
+                    Array.prototype.forEach.call(headers, function(other) {
+                        other.removeAttribute('aria-sort');
+                    });
+                    th.setAttribute('aria-sort', ascending ? 'ascending' : 'descending');
+                    var keyed = rows.map(function(row, i) {
+                        return {row: row, key: sortKey(row, column), index: i};
+                    });
+                    keyed.sort(function(a, b) {
+                        var order;
+                        if (a.key.number !== null && b.key.number !== null) {
+                            order = a.key.number - b.key.number;
+                        } else {
+                            order = a.key.text.localeCompare(b.key.text, undefined, {numeric: true, sensitivity: 'base'});
+                        }
+                        return (ascending ? order : -order) || a.index - b.index;
+                    });
+                    rows = keyed.map(function(entry) { return entry.row; });
+                    rows.forEach(function(row) { body.appendChild(row); });
+                    page = 0;
+                    update();
+                });
+            });
+        }
+
+        if (paged) {
+            pager = document.createElement('nav');
+            pager.className = 'table-pager';
+            pager.setAttribute('aria-label', 'Table pages');
+            prev = document.createElement('button');
+            prev.type = 'button';
+            prev.className = 'nav-btn';
+            prev.textContent = 'Previous';
+            status = document.createElement('span');
+            status.setAttribute('aria-live', 'polite');
+            next = document.createElement('button');
+            next.type = 'button';
+            next.className = 'nav-btn';
+            next.textContent = 'Next';
+            prev.addEventListener('click', function() { page--; update(); });
+            next.addEventListener('click', function() { page++; update(); });
+            pager.appendChild(prev);
+            pager.appendChild(status);
+            pager.appendChild(next);
+            box.parentNode.insertBefore(pager, box.nextSibling);
+            update();
+        }
+    });
+})();
+`
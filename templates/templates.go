@@ -4,28 +4,98 @@ package templates
 import (
 	"html/template"
 	"io"
+	"maps"
+	"slices"
 	"strings"
+
+	"gomdoc/scanner"
 )
 
+// SiteLayout holds what every page of the site shows around its
+// content. The data of each template embeds it.
+type SiteLayout struct {
+	SiteTitle string
+	// AppVersion is the gomdoc version shown in the footer.
+	AppVersion string
+	// Footer configures the site footer.
+	Footer Footer
+	// NoJS leaves out scripts and the controls that need them.
+	NoJS bool
+}
+
 // PageData holds data for rendering a markdown page.
 type PageData struct {
+	SiteLayout
 	Title       string
-	SiteTitle   string
+	Description string
 	Author      string
 	Status      string
-	Date        string
-	Tags        []string
-	Category    string
-	Version     string
-	Reviewers   []string
-	Content     template.HTML
-	Path        string
-	Breadcrumbs template.HTML
-	TreeHTML    template.HTML
-	PrevPath    string
-	PrevTitle   string
-	NextPath    string
-	NextTitle   string
+	// Date is when the page was written and Updated when it last changed
+	// substantially, as written in the date: and updated: frontmatter.
+	// DisplayDate and DisplayUpdated are the same in the site's date
+	// format.
+	Date           string
+	Updated        string
+	DisplayDate    string
+	DisplayUpdated string
+	Tags           []string
+	Category       string
+	Version        string
+	Reviewers      []string
+	Content        template.HTML
+	Path           string
+	Breadcrumbs    template.HTML
+	TreeHTML       template.HTML
+	PrevPath       string
+	PrevTitle      string
+	NextPath       string
+	NextTitle      string
+	// Series is the navigator of a page with series: frontmatter, nil for
+	// other pages.
+	Series *Series
+	// Tree is the navigation tree TreeHTML is rendered from, for custom
+	// templates building their own navigation.
+	Tree *scanner.TreeNode
+	// Owner is the person or team responsible for the page. ReviewBy is
+	// the date the page is due for review; ReviewOverdue is set once it
+	// has passed.
+	Owner         string
+	ReviewBy      string
+	ReviewOverdue bool
+	// Subtitle and Logo appear on the print cover page, which is rendered
+	// instead of the compact print header when Cover is set.
+	Subtitle string
+	Logo     string
+	Cover    bool
+	// Layout and Theme select the page template and style variant; empty
+	// selects the defaults. See HasLayout and HasTheme.
+	Layout string
+	Theme  string
+	// StaleSince is the last update of a page older than the stale_after
+	// age, shown in an outdated-page banner. Empty for fresh pages.
+	StaleSince string
+	// Styles and Scripts are the URLs of the page's own stylesheets and
+	// scripts, set with css: and js: frontmatter.
+	Styles  []string
+	Scripts []string
+	// Canonical is the absolute URL of the page for the canonical link
+	// and og:url, empty when no base URL is configured.
+	Canonical string
+	// StructuredData is the schema.org JSON-LD of the page, written into
+	// its head. Empty when no base URL is configured.
+	StructuredData template.JS
+	// Acknowledge shows the "I have read this" button of pages with
+	// acknowledge: true to signed-in readers. AcknowledgedOn is the date
+	// the reader confirmed the current version, which replaces the button.
+	Acknowledge    bool
+	AcknowledgedOn string
+	// SharedUntil is the expiry of the signed link the page is viewed
+	// through. When set, the page is rendered on its own, without the
+	// navigation and search that need signing in.
+	SharedUntil string
+	// EditURL is the page editor of the page, shown as an Edit button in
+	// edit mode. Empty for readers who cannot edit it.
+	EditURL string
 }
 
 // JoinTags returns tags as a comma-separated string.
@@ -40,30 +110,517 @@ func (p PageData) JoinReviewers() string {
 
 // HasMetadata returns true if any extended metadata field is set.
 func (p PageData) HasMetadata() bool {
-	return p.Status != "" || p.Date != "" || len(p.Tags) > 0 ||
-		p.Category != "" || p.Version != "" || len(p.Reviewers) > 0
+	return p.Status != "" || p.Date != "" || p.Updated != "" || len(p.Tags) > 0 ||
+		p.Category != "" || p.Version != "" || len(p.Reviewers) > 0 ||
+		p.Owner != "" || p.ReviewBy != ""
 }
 
 // IndexData holds data for rendering the index page.
 type IndexData struct {
-	Title     string
-	SiteTitle string
-	TreeHTML  template.HTML
+	SiteLayout
+	Title    string
+	TreeHTML template.HTML
+	// Tree is the navigation tree TreeHTML is rendered from.
+	Tree *scanner.TreeNode
+	// HasHome is set when a curated home page replaces the index at "/",
+	// so the index (served at /browse) links back to it.
+	HasHome bool
+	// Canonical is the absolute URL of the index for the canonical link,
+	// empty when no base URL is configured.
+	Canonical string
+	// Digest links the email digest subscription.
+	Digest bool
 }
 
 // NotFoundData holds data for the custom 404 page.
 type NotFoundData struct {
-	SiteTitle   string
+	SiteLayout
 	RequestPath string
+	// Gone renders the page for expired documents, served with status 410.
+	Gone bool
+	// SizeLimit renders the page for documents over the page size limit
+	// it names, served with status 413.
+	SizeLimit string
+}
+
+// AdminData holds data for the admin page.
+type AdminData struct {
+	SiteLayout
+	// Links are the URLs of the server with their QR codes.
+	Links []AdminLink
+	// Commit, BuildDate, and GoVersion identify the running binary.
+	Commit    string
+	BuildDate string
+	GoVersion string
+	// StaleAfter is the configured age of outdated pages, e.g. 180d. The
+	// stale page report is shown only when it is set.
+	StaleAfter string
+	// StalePages are the pages older than StaleAfter, stalest first.
+	StalePages []AdminStalePage
+	// Approvals links the approvals queue when edits need a reviewer.
+	Approvals bool
+	// Jobs are the waiting, running, and recently finished background
+	// jobs, newest first.
+	Jobs []AdminJob
+	// Missing lists the files that pages rendered since the start refer
+	// to but that do not exist.
+	Missing []AdminMissingAsset
+}
+
+// SearchData holds data for the search page.
+type SearchData struct {
+	SiteLayout
+	// Query is the search query in the URL.
+	Query string
+	// Searched is set when the server ran the query, so Results and Error
+	// are shown instead of searching in the browser.
+	Searched bool
+	// Results are the pages the server found for Query.
+	Results []SearchResult
+	// Error explains why Query could not be run.
+	Error string
+}
+
+// SearchResult is a page found by a server-side search.
+type SearchResult struct {
+	Title string
+	Path  string
+	// Snippet is the matching text with the search terms highlighted.
+	Snippet template.HTML
+}
+
+// AskData holds data for the ask page.
+type AskData struct {
+	SiteLayout
+	// Query is the question in the URL.
+	Query string
+	// Asked is set when Query was answered, so Passages and Error are
+	// shown.
+	Asked bool
+	// Passages are the parts of pages closest in meaning to Query.
+	Passages []AskPassage
+	// Error explains why Query could not be answered.
+	Error string
+}
+
+// AskPassage is a part of a page found by the ask page.
+type AskPassage struct {
+	Title string
+	Path  string
+	// Section is the heading trail of the passage, if any.
+	Section string
+	Text    string
+}
+
+// UnfurlData holds data for the unfurl page of a document.
+type UnfurlData struct {
+	SiteLayout
+	Title       string
+	Description string
+	// URL is the absolute URL of the document.
+	URL string
+	// Updated is the date the document was last updated, if known.
+	Updated string
+}
+
+// AdminStalePage is a page in the stale page report.
+type AdminStalePage struct {
+	Title   string
+	Path    string
+	Updated string
+	AgeDays int
+}
+
+// AdminMissingAsset is a file a page refers to that does not exist.
+type AdminMissingAsset struct {
+	Page string
+	// Kind is image, video, audio, recording, stylesheet, script, or
+	// bibliography.
+	Kind string
+	Ref  string
+	// Seen is when the page last rendered with the file missing.
+	Seen string
+}
+
+// AdminJob is a background job on the admin page.
+type AdminJob struct {
+	Name string
+	// State is queued, running, done, or failed.
+	State    string
+	Queued   string
+	Duration string
+	// Error is the reason a failed job failed.
+	Error string
+}
+
+// ReviewsData holds data for the ownership and review report.
+type ReviewsData struct {
+	SiteLayout
+	// Today is the date the report was made for.
+	Today string
+	// Owners lists the pages of each owner; Overdue lists the pages whose
+	// review date has passed or is invalid.
+	Owners  []ReviewOwner
+	Overdue []ReviewPage
+}
+
+// AcknowledgmentsData holds data for the acknowledgment report: the pages
+// asking readers to confirm they read them, or the readers of one page.
+type AcknowledgmentsData struct {
+	SiteLayout
+	// Pages lists the pages with acknowledge: true, when no page is
+	// selected.
+	Pages []AcknowledgmentPage
+	// Page is the selected page, with its current Version and the readers
+	// who confirmed it, who confirmed an earlier version only, and who
+	// have not confirmed any.
+	Page     *AcknowledgmentPage
+	Version  string
+	Current  []Acknowledgment
+	Outdated []Acknowledgment
+	Pending  []string
+}
+
+// AuthorsData holds data for the author pages: every author, or one
+// author with their pages.
+type AuthorsData struct {
+	SiteLayout
+	// Authors lists the authors, when no author is selected.
+	Authors []AuthorProfile
+	// Author is the selected author, with their Pages newest first.
+	Author *AuthorProfile
+	Pages  []AuthorPage
 }
 
-var pageTmpl = template.Must(template.New("page").Parse(pageTemplate))
-var indexTmpl = template.Must(template.New("index").Parse(indexTemplate))
-var notFoundTmpl = template.Must(template.New("notfound").Parse(notFoundTemplate))
+// EditData holds data for the page editor.
+type EditData struct {
+	SiteLayout
+	// Title and Path identify the page being edited.
+	Title string
+	Path  string
+	// Source is the markdown in the editor. Base is the hash of the page
+	// source the editor was opened with, which saving checks so that a
+	// newer version is never overwritten.
+	Source string
+	Base   string
+	// Summary describes the edit for reviewers.
+	Summary string
+	// Approvals tells the writer that the edit waits for a reviewer
+	// before it is published.
+	Approvals bool
+	// Error explains why the edit was not saved.
+	Error string
+}
+
+// DigestData holds data for the digest subscription page.
+type DigestData struct {
+	SiteLayout
+	// Email and Frequency are the subscription: daily, weekly, or empty
+	// when the user is not subscribed.
+	Email     string
+	Frequency string
+	// Saved confirms that the subscription was stored.
+	Saved bool
+	// Error explains why the subscription was not stored.
+	Error string
+}
+
+// ApprovalsData holds data for the approvals queue.
+type ApprovalsData struct {
+	SiteLayout
+	// Reviewer is set when the reader may approve and reject edits.
+	Reviewer bool
+	// Pending lists the edits waiting for a reviewer, oldest first;
+	// Decided the latest approved and rejected ones.
+	Pending []Revision
+	Decided []Revision
+}
+
+// RevisionData holds data for the review of one edit: its diff and the
+// approve and reject buttons.
+type RevisionData struct {
+	SiteLayout
+	Revision Revision
+	// Diff is the change to the page the edit started from.
+	Diff []DiffLine
+	// DiffError explains why Diff is missing, such as too many changes.
+	DiffError string
+	// Outdated is set when the page changed since the edit was made, so
+	// that it can only be rejected.
+	Outdated bool
+	// CanReview shows the approve and reject buttons, to reviewers other
+	// than the author while the edit is pending.
+	CanReview bool
+}
+
+// Revision is an edit of a page made with approvals enabled. Status is
+// pending, approved, or rejected; Reviewer and Decided tell who decided
+// and when.
+type Revision struct {
+	ID       string
+	Path     string
+	Title    string
+	Author   string
+	Summary  string
+	Created  string
+	Status   string
+	Reviewer string
+	Decided  string
+}
+
+// CompareData holds data for the comparison of two pages, or of two
+// revisions of one page.
+type CompareData struct {
+	SiteLayout
+	// A is the old side of the comparison and B the new one.
+	A CompareSide
+	B CompareSide
+	// Split shows the diff side by side rather than inline.
+	Split bool
+	// Error tells why the pages could not be compared.
+	Error string
+	// Compared is set once both sides were read. Diff is the change from
+	// A to B, and Rows the same laid out side by side; both are empty when
+	// the sides are the same.
+	Compared bool
+	Diff     []DiffLine
+	Rows     []DiffRow
+}
+
+// CompareSide is one side of a comparison: the page as entered, the git
+// revision, empty for the current version, and the page's title and URL
+// once found.
+type CompareSide struct {
+	Path     string
+	Revision string
+	Title    string
+	URL      string
+}
+
+// DiffRow is a row of a side by side diff: the old line and the new one.
+// A side without a line has an empty Kind.
+type DiffRow struct {
+	Old DiffLine
+	New DiffLine
+}
 
-// RenderPage renders a markdown page with navigation.
+// DiffLine is a line of a diff. Kind is "same", "add", "delete", or
+// "skip" for unchanged lines left out; Old and New are its line numbers,
+// zero where it does not appear.
+type DiffLine struct {
+	Kind string
+	Old  int
+	New  int
+	Text string
+}
+
+// AcknowledgmentPage is a page in the acknowledgment report, with the
+// number of readers who confirmed its current version.
+type AcknowledgmentPage struct {
+	Title        string
+	Path         string
+	Acknowledged int
+}
+
+// Series is the navigator of a page in a series, "Part 3 of 7: Name",
+// with links to the other parts in reading order.
+type Series struct {
+	Name  string
+	Part  int
+	Total int
+	Parts []SeriesLink
+}
+
+// SeriesLink is a part of a series. Current marks the page shown.
+type SeriesLink struct {
+	Title   string
+	Path    string
+	Current bool
+}
+
+// AuthorProfile is an author with the bio from authors.yml, if any, and
+// the number of their pages. Bio is markdown.
+type AuthorProfile struct {
+	Name   string
+	Slug   string
+	Bio    string
+	URL    string
+	Avatar string
+	Pages  int
+}
+
+// AuthorPage is a page on an author page.
+type AuthorPage struct {
+	Title   string
+	Path    string
+	Summary string
+	Date    string
+}
+
+// Acknowledgment is a reader's confirmation of a page version.
+type Acknowledgment struct {
+	User    string
+	Version string
+	Date    string
+}
+
+// ReviewOwner is an owner with their pages. Owner is empty for the pages
+// without one.
+type ReviewOwner struct {
+	Owner string
+	Pages []ReviewPage
+}
+
+// ReviewPage is a page in the review report.
+type ReviewPage struct {
+	Title    string
+	Path     string
+	Owner    string
+	ReviewBy string
+	Overdue  bool
+	Invalid  bool
+}
+
+// AdminLink is a server URL with a QR code for opening it on a phone.
+type AdminLink struct {
+	URL    string
+	QRCode template.HTML
+}
+
+// footerHTML is the site footer shared by all page templates. The version
+// helps to tell which build a deployed instance runs.
+const footerHTML = `<footer class="site-footer">
+        {{- with .Footer.Text}}
+        <div class="footer-text">{{.}}</div>
+        {{- end}}
+        {{- if .Footer.Links}}
+        <nav class="footer-links">{{range .Footer.Links}}<a href="{{.URL}}">{{.Label}}</a>{{end}}</nav>
+        {{- end}}
+        {{- with .Footer.CopyrightLine}}
+        <div class="footer-copyright">{{.}}</div>
+        {{- end}}
+        {{- if not .Footer.HideGeneratedBy}}
+        <div class="footer-generated">Documentation created by gomdoc{{with .AppVersion}} {{.}}{{end}}: <a href="https://github.com/lacrioque/gomdoc/">https://github.com/lacrioque/gomdoc/</a></div>
+        {{- end}}
+    </footer>`
+
+// baseTemplate is the skeleton of every page. Each template defines some
+// of its blocks, and custom templates can replace any single one:
+//
+//   - title: the page title
+//   - head: the title, meta tags, and stylesheets
+//   - header: the print header of pages
+//   - banner: the site-wide notice set with SetBanner
+//   - menu: the top-bar menu set with SetMenu
+//   - nav: the navigation bar
+//   - sidebar: the navigation tree of pages
+//   - content: the main content
+//   - footer: the site footer
+//   - scripts: the script bundle at the end of the body
+//
+// body-class adds attributes to the body, and main arranges the sidebar and
+// content, which the page layout wraps in its three columns.
+const baseTemplate = `<!DOCTYPE html>
+<html lang="en">
+<head>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+    {{- block "head" .}}
+    <title>{{block "title" .}}{{.SiteTitle}}{{end}}</title>
+    ` + faviconLink + `
+    <link rel="stylesheet" href="{{asset "style.css"}}">
+    {{- end}}
+</head>
+<body{{block "body-class" .}}{{end}}>
+    {{- block "header" .}}{{end}}
+    {{- block "banner" .}}
+    ` + bannerHTML + `
+    {{- end}}
+    {{- block "menu" .}}
+    ` + menuHTML + `
+    {{- end}}
+    {{- block "nav" .}}{{end}}
+    {{- block "main" .}}
+    {{- block "sidebar" .}}{{end}}
+    {{- block "content" .}}{{end}}
+    {{- end}}
+    {{- block "footer" .}}
+    ` + footerHTML + `
+    {{- end}}
+    {{- block "scripts" .}}
+    ` + scriptTags + `
+    {{- end}}
+</body>
+</html>`
+
+// parseBase parses the base template with the blocks defined in blocks.
+func parseBase(name, blocks string) *template.Template {
+	return template.Must(template.Must(template.New(name).Funcs(funcs).Parse(baseTemplate)).Parse(blocks))
+}
+
+var pageTmpl = parseBase("page", pageTemplate)
+var landingTmpl = parseBase("landing", landingTemplate)
+var indexTmpl = parseBase("index", indexTemplate)
+var notFoundTmpl = parseBase("notfound", notFoundTemplate)
+var adminTmpl = parseBase("admin", adminTemplate)
+var reviewsTmpl = parseBase("reviews", reviewsTemplate)
+var acknowledgmentsTmpl = parseBase("acknowledgments", acknowledgmentsTemplate)
+var authorsTmpl = parseBase("authors", authorsTemplate)
+var editTmpl = parseBase("edit", editTemplate)
+var digestTmpl = parseBase("digest", digestTemplate)
+var approvalsTmpl = parseBase("approvals", approvalsTemplate)
+var revisionTmpl = parseBase("revision", revisionTemplate)
+var compareTmpl = parseBase("compare", compareTemplate)
+var searchTmpl = parseBase("search", searchTemplate)
+var askTmpl = parseBase("ask", askTemplate)
+var unfurlTmpl = template.Must(template.New("unfurl").Parse(unfurlTemplate))
+var sharedTmpl = template.Must(template.New("shared").Funcs(funcs).Parse(sharedTemplate))
+
+// layouts maps the layout names accepted in frontmatter to their templates.
+// The wide layout shares the page template and differs only in CSS.
+var layouts = map[string]*template.Template{
+	"default": pageTmpl,
+	"wide":    pageTmpl,
+	"landing": landingTmpl,
+}
+
+// themes lists the style variants accepted in frontmatter. Each is a body
+// class styled in the embedded CSS and works in light and dark mode.
+var themes = []string{"default", "paper", "compact"}
+
+// HasLayout reports whether name is a registered layout.
+func HasLayout(name string) bool {
+	_, ok := layouts[name]
+	return ok
+}
+
+// HasTheme reports whether name is a registered theme.
+func HasTheme(name string) bool {
+	return slices.Contains(themes, name)
+}
+
+// Layouts returns the registered layout names, sorted.
+func Layouts() []string {
+	return slices.Sorted(maps.Keys(layouts))
+}
+
+// Themes returns the registered theme names.
+func Themes() []string {
+	return slices.Clone(themes)
+}
+
+// RenderPage renders a markdown page with the template of its layout,
+// falling back to the default layout for unknown names. Pages viewed
+// through a signed link use the shared page template instead.
 func RenderPage(w io.Writer, data PageData) error {
-	return pageTmpl.Execute(w, data)
+	if data.SharedUntil != "" {
+		return sharedTmpl.Execute(w, data)
+	}
+	tmpl, ok := layouts[data.Layout]
+	if !ok {
+		tmpl = pageTmpl
+	}
+	return tmpl.Execute(w, data)
 }
 
 // RenderIndex renders the index page with the file tree.
@@ -76,6 +633,67 @@ func RenderNotFound(w io.Writer, data NotFoundData) error {
 	return notFoundTmpl.Execute(w, data)
 }
 
+// RenderAdmin renders the admin page.
+func RenderAdmin(w io.Writer, data AdminData) error {
+	return adminTmpl.Execute(w, data)
+}
+
+// RenderReviews renders the ownership and review report.
+func RenderReviews(w io.Writer, data ReviewsData) error {
+	return reviewsTmpl.Execute(w, data)
+}
+
+// RenderAcknowledgments renders the acknowledgment report.
+func RenderAcknowledgments(w io.Writer, data AcknowledgmentsData) error {
+	return acknowledgmentsTmpl.Execute(w, data)
+}
+
+// RenderAuthors renders the author list or an author page.
+func RenderAuthors(w io.Writer, data AuthorsData) error {
+	return authorsTmpl.Execute(w, data)
+}
+
+// RenderEdit renders the page editor.
+func RenderEdit(w io.Writer, data EditData) error {
+	return editTmpl.Execute(w, data)
+}
+
+// RenderDigest renders the digest subscription page.
+func RenderDigest(w io.Writer, data DigestData) error {
+	return digestTmpl.Execute(w, data)
+}
+
+// RenderApprovals renders the approvals queue.
+func RenderApprovals(w io.Writer, data ApprovalsData) error {
+	return approvalsTmpl.Execute(w, data)
+}
+
+// RenderRevision renders the review of an edit.
+func RenderRevision(w io.Writer, data RevisionData) error {
+	return revisionTmpl.Execute(w, data)
+}
+
+// RenderCompare renders the comparison of two pages.
+func RenderCompare(w io.Writer, data CompareData) error {
+	return compareTmpl.Execute(w, data)
+}
+
+// RenderSearch renders the search page.
+func RenderSearch(w io.Writer, data SearchData) error {
+	return searchTmpl.Execute(w, data)
+}
+
+// RenderAsk renders the ask page.
+func RenderAsk(w io.Writer, data AskData) error {
+	return askTmpl.Execute(w, data)
+}
+
+// RenderUnfurl renders the OpenGraph tags of a document for link
+// previews.
+func RenderUnfurl(w io.Writer, data UnfurlData) error {
+	return unfurlTmpl.Execute(w, data)
+}
+
 // faviconLink is the favicon as an embedded SVG data URI.
 const faviconLink = `<link rel="icon" href="data:image/svg+xml,` +
 	`%3Csvg xmlns='http://www.w3.org/2000/svg' viewBox='0 0 100 100'%3E` +
@@ -89,24 +707,46 @@ const faviconLink = `<link rel="icon" href="data:image/svg+xml,` +
 	`%3Crect x='30' y='57' width='25' height='2' rx='1' fill='%23ccc'/%3E` +
 	`%3C/svg%3E">`
 
-// backToTopHTML is the back-to-top button markup and behavior.
+// backToTopHTML is the back-to-top button, shown by backToTopJS.
 const backToTopHTML = `
-    <button id="back-to-top" class="back-to-top" aria-label="Back to top" title="Back to top">&#8679;</button>
-    <script>
-    (function() {
-        var btn = document.getElementById('back-to-top');
-        window.addEventListener('scroll', function() {
-            if (window.scrollY > 300) {
-                btn.classList.add('visible');
-            } else {
-                btn.classList.remove('visible');
-            }
-        });
-        btn.addEventListener('click', function() {
-            window.scrollTo({ top: 0, behavior: 'smooth' });
-        });
-    })();
-    </script>`
+    <button id="back-to-top" class="back-to-top" aria-label="Back to top" title="Back to top">&#8679;</button>`
+
+// backToTopJS shows the back-to-top button once the page is scrolled.
+const backToTopJS = `
+(function() {
+    var btn = document.getElementById('back-to-top');
+    if (!btn) return;
+    window.addEventListener('scroll', function() {
+        if (window.scrollY > 300) {
+            btn.classList.add('visible');
+        } else {
+            btn.classList.remove('visible');
+        }
+    });
+    btn.addEventListener('click', function() {
+        window.scrollTo({ top: 0, behavior: 'smooth' });
+    });
+})();
+`
+
+// actionsJS runs the actions buttons name in data-action, since inline
+// onclick handlers would rule out a strict Content-Security-Policy.
+const actionsJS = `
+(function() {
+    document.addEventListener('click', function(e) {
+        var button = e.target.closest('[data-action]');
+        if (!button) return;
+        var action = button.getAttribute('data-action');
+        if (action === 'print') {
+            window.print();
+        } else if (action === 'back') {
+            history.back();
+        } else if (action === 'toggle-theme') {
+            window.gomdocToggleTheme();
+        }
+    });
+})();
+`
 
 const themeJS = `
 (function() {
@@ -215,7 +855,8 @@ const searchJS = `
 (function() {
     var input = document.getElementById('search-input');
     var resultsDiv = document.getElementById('search-results');
-    var debounceTimer;
+    var debounceTimer, lastQuery;
+    if (!input || !resultsDiv) return;
 
     input.addEventListener('input', function() {
         clearTimeout(debounceTimer);
@@ -227,7 +868,11 @@ const searchJS = `
         }
         debounceTimer = setTimeout(function() {
             fetch('/api/search?q=' + encodeURIComponent(query))
-                .then(function(r) { return r.json(); })
+                .then(function(r) {
+                    // Exported sites have no search API, only the static index.
+                    if (r.status === 404) return window.gomdocStaticSearch(query);
+                    return r.ok ? r.json() : [];
+                })
                 .then(function(results) {
                     if (results.length === 0) {
                         resultsDiv.innerHTML = '<div class="search-no-results">No results found</div>';
@@ -235,18 +880,37 @@ const searchJS = `
                         return;
                     }
                     var html = '';
+                    lastQuery = query;
                     results.forEach(function(r) {
                         html += '<a class="search-result" href="' + r.path + '">';
                         html += '<div class="search-result-title">' + escapeHtml(r.title) + '</div>';
-                        html += '<div class="search-result-snippet">' + escapeHtml(r.snippet) + '</div>';
+                        var snippet = r.highlighted || escapeHtml(r.snippet);
+                        html += '<div class="search-result-snippet">' + snippet + '</div>';
                         html += '</a>';
                     });
+                    if (window.gomdocSaveSearch) {
+                        html += '<button type="button" class="search-save">Save search</button>';
+                    }
                     resultsDiv.innerHTML = html;
                     resultsDiv.style.display = 'block';
                 });
         }, 200);
     });
 
+    input.addEventListener('keydown', function(e) {
+        if (e.key === 'Enter' && input.value.trim()) {
+            window.location.href = '/search?q=' + encodeURIComponent(input.value.trim());
+        }
+    });
+
+    resultsDiv.addEventListener('click', function(e) {
+        if (e.target.classList.contains('search-save')) {
+            window.gomdocSaveSearch(lastQuery);
+            e.target.textContent = 'Saved';
+            e.target.disabled = true;
+        }
+    });
+
     document.addEventListener('click', function(e) {
         if (!e.target.closest('.search-box')) {
             resultsDiv.style.display = 'none';
@@ -265,11 +929,716 @@ const searchJS = `
 })();
 `
 
+// staticSearchJS searches the client-side index at
+// /static/search-index.json, so that exported sites keep their search
+// without a server.
+const staticSearchJS = `
+(function() {
+    var loaded;
+
+    // load fetches the search index once.
+    function load() {
+        if (!loaded) {
+            loaded = fetch('/static/search-index.json')
+                .then(function(r) { return r.ok ? r.json() : []; })
+                .catch(function() { return []; });
+        }
+        return loaded;
+    }
+
+    function escapeHtml(text) {
+        return String(text).replace(/[&<>"']/g, function(c) {
+            return {'&': '&amp;', '<': '&lt;', '>': '&gt;', '"': '&quot;', "'": '&#39;'}[c];
+        });
+    }
+
+    function count(text, term) {
+        var n = 0, pos = text.indexOf(term);
+        while (pos !== -1 && n < 10) {
+            n++;
+            pos = text.indexOf(term, pos + term.length);
+        }
+        return n;
+    }
+
+    // score rates a document like the server does: matches in the title,
+    // headings, and tags weigh more than matches in the text, and documents
+    // matching more of the terms rank higher.
+    function score(doc, terms) {
+        var total = 0, matched = 0;
+        var title = doc.title.toLowerCase();
+        var headings = (doc.headings || []).join('\n').toLowerCase();
+        var tags = (doc.tags || []).map(function(t) { return t.toLowerCase(); });
+        terms.forEach(function(term) {
+            var s = 0, n = count(doc.lower, term);
+            if (n > 0) s += 1 + n * 0.1;
+            if (title.indexOf(term) !== -1) s += 2;
+            if (headings.indexOf(term) !== -1) s += 1;
+            if (tags.indexOf(term) !== -1) s += 1;
+            if (s > 0) matched++;
+            total += s;
+        });
+        return total * matched / terms.length;
+    }
+
+    // snippet quotes the text around the first match with the terms marked.
+    function snippet(doc, terms) {
+        var pos = -1;
+        terms.forEach(function(term) {
+            var p = doc.lower.indexOf(term);
+            if (p !== -1 && (pos === -1 || p < pos)) pos = p;
+        });
+        if (pos === -1) return escapeHtml(doc.summary || '');
+        var start = Math.max(0, pos - 60);
+        var text = doc.text.slice(start, pos + 120).replace(/\s+/g, ' ');
+        var html = escapeHtml(text);
+        terms.forEach(function(term) {
+            var escaped = escapeHtml(term).replace(/[.*+?^${}()|[\]\\]/g, '\\$&');
+            html = html.replace(new RegExp('(' + escaped + ')', 'gi'), '<mark>$1</mark>');
+        });
+        return (start > 0 ? '…' : '') + html + (pos + 120 < doc.text.length ? '…' : '');
+    }
+
+    // gomdocStaticSearch searches the exported search index and resolves to
+    // results shaped like those of /api/search.
+    window.gomdocStaticSearch = function(query, max) {
+        var terms = query.toLowerCase().split(/[^\p{L}\p{N}]+/u).filter(function(t) { return t.length >= 2; });
+        return load().then(function(docs) {
+            if (terms.length === 0) return [];
+            var results = [];
+            docs.forEach(function(doc) {
+                if (doc.lower === undefined) doc.lower = doc.text.toLowerCase();
+                var s = score(doc, terms);
+                if (s > 0) results.push({doc: doc, score: s});
+            });
+            results.sort(function(a, b) { return b.score - a.score; });
+            return results.slice(0, max || 20).map(function(r) {
+                return {title: r.doc.title, path: r.doc.path, summary: r.doc.summary, highlighted: snippet(r.doc, terms)};
+            });
+        });
+    };
+})();
+`
+
+// searchPageJS shows the results for the q parameter on the search page.
+const searchPageJS = `
+(function() {
+    var query = new URLSearchParams(window.location.search).get('q') || '';
+    var input = document.getElementById('search-page-input');
+    var list = document.getElementById('search-page-results');
+    if (!input || !list) return;
+    input.value = query;
+    // The live server already listed the results.
+    if (!query.trim() || list.hasAttribute('data-searched')) return;
+
+    window.gomdocStaticSearch(query, 50).then(function(results) {
+        list.textContent = '';
+        if (results.length === 0) {
+            var empty = document.createElement('p');
+            empty.className = 'search-no-results';
+            empty.textContent = 'No results found';
+            list.appendChild(empty);
+            return;
+        }
+        results.forEach(function(r) {
+            var link = document.createElement('a');
+            link.className = 'search-result';
+            link.href = r.path;
+            var title = document.createElement('div');
+            title.className = 'search-result-title';
+            title.textContent = r.title;
+            var snippet = document.createElement('div');
+            snippet.className = 'search-result-snippet';
+            snippet.innerHTML = r.highlighted;
+            link.appendChild(title);
+            link.appendChild(snippet);
+            list.appendChild(link);
+        });
+    });
+})();
+`
+
+const quickOpenJS = `
+(function() {
+    var overlay, input, list, files, matches = [], active = 0;
+
+    // score rates how well query matches text as a subsequence: consecutive
+    // characters and matches at word starts rank higher. -1 means no match.
+    function score(query, text) {
+        var t = text.toLowerCase(), total = 0, last = -1;
+        for (var i = 0; i < query.length; i++) {
+            var pos = t.indexOf(query[i], last + 1);
+            if (pos === -1) return -1;
+            total += pos === last + 1 ? 3 : 1;
+            if (pos === 0 || '/-_ .'.indexOf(t[pos - 1]) !== -1) total += 2;
+            last = pos;
+        }
+        return total - t.length / 100;
+    }
+
+    function escapeHtml(text) {
+        var div = document.createElement('div');
+        div.textContent = text;
+        return div.innerHTML;
+    }
+
+    function render() {
+        var query = input.value.trim().toLowerCase();
+        matches = [];
+        (files || []).forEach(function(f) {
+            var titleScore = score(query, f.title);
+            var s = query ? Math.max(titleScore < 0 ? -1 : titleScore + 1, score(query, f.path)) : 0;
+            if (s >= 0) matches.push({file: f, score: s});
+        });
+        matches.sort(function(a, b) { return b.score - a.score; });
+        matches = matches.slice(0, 50);
+        active = 0;
+        if (files === null) {
+            list.innerHTML = '<li class="quick-open-empty">Page list unavailable</li>';
+            return;
+        }
+        if (matches.length === 0) {
+            list.innerHTML = '<li class="quick-open-empty">No matching pages</li>';
+            return;
+        }
+        list.innerHTML = matches.map(function(m, i) {
+            return '<li><a href="' + escapeHtml(m.file.path) + '"' + (i === 0 ? ' class="active"' : '') + '>' +
+                escapeHtml(m.file.title) + '<span class="quick-open-path">' + escapeHtml(m.file.path) + '</span></a></li>';
+        }).join('');
+    }
+
+    function select(index) {
+        var links = list.querySelectorAll('a');
+        if (links.length === 0) return;
+        active = (index + links.length) % links.length;
+        links.forEach(function(a, i) { a.classList.toggle('active', i === active); });
+        links[active].scrollIntoView({block: 'nearest'});
+    }
+
+    function close() {
+        if (overlay) overlay.style.display = 'none';
+    }
+
+    function open() {
+        if (!overlay) {
+            overlay = document.createElement('div');
+            overlay.className = 'quick-open';
+            overlay.innerHTML = '<div class="quick-open-dialog" role="dialog" aria-label="Go to page">' +
+                '<input type="text" class="quick-open-input" placeholder="Go to page..." autocomplete="off">' +
+                '<ul class="quick-open-list"></ul></div>';
+            document.body.appendChild(overlay);
+            input = overlay.querySelector('input');
+            list = overlay.querySelector('ul');
+            input.addEventListener('input', render);
+            input.addEventListener('keydown', function(e) {
+                if (e.key === 'ArrowDown') { e.preventDefault(); select(active + 1); }
+                else if (e.key === 'ArrowUp') { e.preventDefault(); select(active - 1); }
+                else if (e.key === 'Enter' && matches[active]) { window.location.href = matches[active].file.path; }
+                else if (e.key === 'Escape') { close(); }
+            });
+            overlay.addEventListener('click', function(e) {
+                if (e.target === overlay) close();
+            });
+        }
+        overlay.style.display = 'flex';
+        input.value = '';
+        input.focus();
+        if (files === undefined) {
+            files = [];
+            fetch('/api/files')
+                .then(function(r) { return r.ok ? r.json() : null; })
+                .catch(function() { return null; })
+                .then(function(result) { files = result; render(); });
+        }
+        render();
+    }
+
+    document.addEventListener('keydown', function(e) {
+        if ((e.ctrlKey || e.metaKey) && e.key.toLowerCase() === 'k') {
+            e.preventDefault();
+            if (overlay && overlay.style.display !== 'none') close(); else open();
+        }
+    });
+})();
+`
+
+const bookmarksJS = `
+(function() {
+    var toggle = document.getElementById('bookmark-toggle');
+    var panel = document.getElementById('my-bookmarks');
+    var list;
+    if (!toggle && !panel) return;
+
+    function escapeHtml(text) {
+        var div = document.createElement('div');
+        div.textContent = text;
+        return div.innerHTML.replace(/"/g, '&quot;');
+    }
+
+    function send(method, url, body) {
+        var init = {method: method};
+        if (body) {
+            init.headers = {'Content-Type': 'application/json'};
+            init.body = JSON.stringify(body);
+        }
+        return fetch(url, init)
+            .then(function(r) { return r.ok ? r.json() : null; })
+            .then(function(updated) {
+                if (updated) {
+                    list = updated;
+                    render();
+                }
+            });
+    }
+
+    function isBookmarked() {
+        return list.pages.some(function(p) { return p.path === location.pathname; });
+    }
+
+    function render() {
+        if (toggle) {
+            var on = isBookmarked();
+            toggle.textContent = on ? '★ Bookmarked' : '☆ Bookmark';
+            toggle.setAttribute('aria-pressed', on ? 'true' : 'false');
+            toggle.hidden = false;
+        }
+        if (!panel) return;
+        if (list.pages.length === 0 && list.searches.length === 0) {
+            panel.hidden = true;
+            return;
+        }
+        var html = '<h2>My bookmarks</h2>';
+        if (list.pages.length > 0) {
+            html += '<ul class="bookmark-list">' + list.pages.map(function(p) {
+                return '<li><a href="' + escapeHtml(p.path) + '">' + escapeHtml(p.title) + '</a>' +
+                    '<button type="button" class="bookmark-remove" data-path="' + escapeHtml(p.path) + '" aria-label="Remove bookmark">×</button></li>';
+            }).join('') + '</ul>';
+        }
+        if (list.searches.length > 0) {
+            html += '<h3>Saved searches</h3><ul class="bookmark-list">' + list.searches.map(function(q) {
+                return '<li><button type="button" class="bookmark-search" data-query="' + escapeHtml(q.query) + '">' + escapeHtml(q.name) + '</button>' +
+                    '<button type="button" class="bookmark-remove" data-query="' + escapeHtml(q.query) + '" aria-label="Remove saved search">×</button></li>';
+            }).join('') + '</ul>';
+        }
+        panel.innerHTML = html;
+        panel.hidden = false;
+    }
+
+    fetch('/api/bookmarks')
+        .then(function(r) { return r.ok ? r.json() : null; })
+        .then(function(result) {
+            if (!result) return;
+            list = result;
+            window.gomdocSaveSearch = function(query) {
+                send('POST', '/api/bookmarks', {query: query});
+            };
+            render();
+        })
+        .catch(function() {});
+
+    if (toggle) {
+        toggle.addEventListener('click', function() {
+            if (isBookmarked()) {
+                send('DELETE', '/api/bookmarks?path=' + encodeURIComponent(location.pathname));
+            } else {
+                send('POST', '/api/bookmarks', {path: location.pathname});
+            }
+        });
+    }
+
+    if (panel) {
+        panel.addEventListener('click', function(e) {
+            var button = e.target.closest('button');
+            if (!button) return;
+            if (button.classList.contains('bookmark-remove')) {
+                var param = button.dataset.path ? 'path=' + encodeURIComponent(button.dataset.path) : 'query=' + encodeURIComponent(button.dataset.query);
+                send('DELETE', '/api/bookmarks?' + param);
+            } else if (button.classList.contains('bookmark-search')) {
+                var input = document.getElementById('search-input');
+                input.value = button.dataset.query;
+                input.focus();
+                input.dispatchEvent(new Event('input'));
+            }
+        });
+    }
+})();
+`
+
+// uploadJS enables dropping images and attachments onto a page in edit
+// mode. /api/upload answers 404 unless the signed-in user may upload, so
+// readers keep the browser's usual drop behavior.
+const uploadJS = `
+(function() {
+    function hasFiles(e) {
+        return e.dataTransfer && Array.prototype.indexOf.call(e.dataTransfer.types, 'Files') >= 0;
+    }
+
+    function upload(file) {
+        var form = new FormData();
+        form.append('file', file);
+        form.append('page', location.pathname);
+        return fetch('/api/upload', {method: 'POST', body: form}).then(function(r) {
+            if (r.ok) return r.json();
+            return r.text().then(function(text) { throw new Error(file.name + ': ' + text.trim()); });
+        });
+    }
+
+    function show(snippets, errors) {
+        var panel = document.getElementById('upload-panel');
+        if (!panel) {
+            panel = document.createElement('aside');
+            panel.id = 'upload-panel';
+            panel.className = 'upload-panel';
+            panel.setAttribute('role', 'status');
+            panel.innerHTML = '<strong>Uploaded</strong><textarea readonly rows="3"></textarea>' +
+                '<ul class="upload-errors"></ul><button type="button" class="nav-btn">Close</button>';
+            panel.querySelector('button').addEventListener('click', function() { panel.hidden = true; });
+            document.body.appendChild(panel);
+        }
+        var text = panel.querySelector('textarea');
+        text.value = snippets.join('\n');
+        text.hidden = snippets.length === 0;
+        panel.querySelector('.upload-errors').innerHTML = '';
+        errors.forEach(function(message) {
+            var li = document.createElement('li');
+            li.textContent = message;
+            panel.querySelector('.upload-errors').appendChild(li);
+        });
+        panel.hidden = false;
+        if (snippets.length > 0) {
+            text.select();
+            if (navigator.clipboard) navigator.clipboard.writeText(text.value).catch(function() {});
+        }
+    }
+
+    function enable() {
+        document.addEventListener('dragover', function(e) {
+            if (!hasFiles(e)) return;
+            e.preventDefault();
+            document.body.classList.add('upload-dragging');
+        });
+        document.addEventListener('dragleave', function(e) {
+            if (!e.relatedTarget) document.body.classList.remove('upload-dragging');
+        });
+        document.addEventListener('drop', function(e) {
+            if (!hasFiles(e)) return;
+            e.preventDefault();
+            document.body.classList.remove('upload-dragging');
+            var snippets = [], errors = [];
+            Promise.all(Array.prototype.map.call(e.dataTransfer.files, function(file) {
+                return upload(file).then(function(result) { snippets.push(result.markdown); },
+                    function(err) { errors.push(err.message); });
+            })).then(function() { show(snippets, errors); });
+        });
+    }
+
+    // Uploads go to pages, not to the index or the landing page.
+    if (!document.querySelector('.page-main')) return;
+    fetch('/api/upload')
+        .then(function(r) { if (r.ok) enable(); })
+        .catch(function() {});
+})();
+`
+
+// castPlayerJS plays asciinema recordings (.cast files embedded with image
+// syntax) in a small built-in terminal, without loading anything from a
+// CDN. It understands asciicast v1 and v2, colors, cursor movement, and
+// screen clearing.
+const castPlayerJS = `
+(function() {
+    var players = document.querySelectorAll('.cast-player');
+    if (!players.length) return;
+    var colors = ['black', 'red', 'green', 'yellow', 'blue', 'magenta', 'cyan', 'white'];
+
+    function escapeHtml(text) {
+        return text.replace(/&/g, '&amp;').replace(/</g, '&lt;').replace(/>/g, '&gt;');
+    }
+
+    function palette256(n) {
+        var basic = ['#000000', '#cd0000', '#00cd00', '#cdcd00', '#0000ee', '#cd00cd', '#00cdcd', '#e5e5e5',
+            '#7f7f7f', '#ff0000', '#00ff00', '#ffff00', '#5c5cff', '#ff00ff', '#00ffff', '#ffffff'];
+        if (n < 16) return basic[n];
+        if (n >= 232) { var g = 8 + (n - 232) * 10; return 'rgb(' + g + ',' + g + ',' + g + ')'; }
+        n -= 16;
+        function level(v) { return v ? 55 + v * 40 : 0; }
+        return 'rgb(' + level(Math.floor(n / 36)) + ',' + level(Math.floor(n / 6) % 6) + ',' + level(n % 6) + ')';
+    }
+
+    function Screen(cols, rows) {
+        this.cols = cols;
+        this.rows = rows;
+        this.reset();
+    }
+    Screen.prototype.reset = function() {
+        this.lines = [];
+        for (var i = 0; i < this.rows; i++) this.lines.push([]);
+        this.x = 0;
+        this.y = 0;
+        this.attr = {};
+        this.state = 'text';
+        this.params = '';
+    };
+    Screen.prototype.newline = function() {
+        this.y++;
+        if (this.y >= this.rows) {
+            this.lines.shift();
+            this.lines.push([]);
+            this.y = this.rows - 1;
+        }
+    };
+    Screen.prototype.put = function(ch) {
+        if (this.x >= this.cols) { this.x = 0; this.newline(); }
+        this.lines[this.y][this.x++] = {ch: ch, attr: this.attr};
+    };
+    Screen.prototype.erase = function(line, from, to) {
+        for (var i = from; i < to; i++) this.lines[line][i] = undefined;
+    };
+    Screen.prototype.sgr = function(params) {
+        var codes = params === '' ? [0] : params.split(';').map(function(c) { return parseInt(c, 10) || 0; });
+        var a = Object.assign({}, this.attr);
+        for (var i = 0; i < codes.length; i++) {
+            var c = codes[i];
+            if (c === 0) a = {};
+            else if (c === 1) a.bold = true;
+            else if (c === 2) a.dim = true;
+            else if (c === 3) a.italic = true;
+            else if (c === 4) a.underline = true;
+            else if (c === 22) { a.bold = false; a.dim = false; }
+            else if (c === 23) a.italic = false;
+            else if (c === 24) a.underline = false;
+            else if (c >= 30 && c <= 37) { a.fg = colors[c - 30]; a.fgRGB = ''; }
+            else if (c >= 90 && c <= 97) { a.fg = 'bright-' + colors[c - 90]; a.fgRGB = ''; }
+            else if (c >= 40 && c <= 47) { a.bg = colors[c - 40]; a.bgRGB = ''; }
+            else if (c >= 100 && c <= 107) { a.bg = 'bright-' + colors[c - 100]; a.bgRGB = ''; }
+            else if (c === 39) { a.fg = ''; a.fgRGB = ''; }
+            else if (c === 49) { a.bg = ''; a.bgRGB = ''; }
+            else if (c === 38 || c === 48) {
+                var color = '';
+                if (codes[i + 1] === 5) { color = palette256(codes[i + 2] || 0); i += 2; }
+                else if (codes[i + 1] === 2) { color = 'rgb(' + codes[i + 2] + ',' + codes[i + 3] + ',' + codes[i + 4] + ')'; i += 4; }
+                if (c === 38) { a.fg = ''; a.fgRGB = color; } else { a.bg = ''; a.bgRGB = color; }
+            }
+        }
+        this.attr = a;
+    };
+    Screen.prototype.csi = function(params, cmd) {
+        var args = params.replace(/^\?/, '').split(';').map(function(c) { return parseInt(c, 10); });
+        var n = args[0] || 1;
+        switch (cmd) {
+            case 'm': this.sgr(params); break;
+            case 'A': this.y = Math.max(0, this.y - n); break;
+            case 'B': this.y = Math.min(this.rows - 1, this.y + n); break;
+            case 'C': this.x = Math.min(this.cols - 1, this.x + n); break;
+            case 'D': this.x = Math.max(0, this.x - n); break;
+            case 'G': this.x = Math.min(this.cols - 1, n - 1); break;
+            case 'H': case 'f':
+                this.y = Math.min(this.rows - 1, (args[0] || 1) - 1);
+                this.x = Math.min(this.cols - 1, (args[1] || 1) - 1);
+                break;
+            case 'J':
+                if (args[0] === 2 || args[0] === 3) {
+                    for (var i = 0; i < this.rows; i++) this.lines[i] = [];
+                } else if (!args[0]) {
+                    this.erase(this.y, this.x, this.cols);
+                    for (var j = this.y + 1; j < this.rows; j++) this.lines[j] = [];
+                }
+                break;
+            case 'K':
+                if (args[0] === 2) this.lines[this.y] = [];
+                else if (args[0] === 1) this.erase(this.y, 0, this.x + 1);
+                else this.erase(this.y, this.x, this.cols);
+                break;
+        }
+    };
+    Screen.prototype.write = function(data) {
+        for (var i = 0; i < data.length; i++) {
+            var ch = data[i];
+            if (this.state === 'esc') {
+                this.state = ch === '[' ? 'csi' : ch === ']' ? 'osc' : 'text';
+                this.params = '';
+            } else if (this.state === 'csi') {
+                if (ch >= '@' && ch <= '~') { this.csi(this.params, ch); this.state = 'text'; }
+                else this.params += ch;
+            } else if (this.state === 'osc') {
+                if (ch === '\x07') this.state = 'text';
+                else if (ch === '\x1b') this.state = 'esc';
+            } else if (ch === '\x1b') this.state = 'esc';
+            else if (ch === '\r') this.x = 0;
+            else if (ch === '\n') this.newline();
+            else if (ch === '\b') this.x = Math.max(0, this.x - 1);
+            else if (ch === '\t') this.x = Math.min(this.cols - 1, (Math.floor(this.x / 8) + 1) * 8);
+            else if (ch >= ' ') this.put(ch);
+        }
+    };
+    Screen.prototype.html = function() {
+        var out = [];
+        this.lines.forEach(function(line) {
+            var html = '', open = null, text = '';
+            function flush() {
+                if (!text) return;
+                var classes = [], styles = [];
+                if (open) {
+                    ['bold', 'dim', 'italic', 'underline'].forEach(function(f) { if (open[f]) classes.push('ansi-' + f); });
+                    if (open.fg) classes.push('ansi-fg-' + open.fg);
+                    if (open.bg) classes.push('ansi-bg-' + open.bg);
+                    if (open.fgRGB) styles.push('color:' + open.fgRGB);
+                    if (open.bgRGB) styles.push('background-color:' + open.bgRGB);
+                }
+                if (classes.length || styles.length) {
+                    html += '<span' + (classes.length ? ' class="' + classes.join(' ') + '"' : '') +
+                        (styles.length ? ' style="' + styles.join(';') + '"' : '') + '>' + escapeHtml(text) + '</span>';
+                } else {
+                    html += escapeHtml(text);
+                }
+                text = '';
+            }
+            for (var i = 0; i < line.length; i++) {
+                var cell = line[i] || {ch: ' ', attr: null};
+                if (cell.attr !== open) { flush(); open = cell.attr; }
+                text += cell.ch;
+            }
+            flush();
+            out.push(html);
+        });
+        return out.join('\n');
+    };
+
+    function parseCast(text) {
+        var trimmed = text.trim();
+        if (trimmed.charAt(0) === '{' && trimmed.indexOf('\n') < 0 || /"stdout"\s*:/.test(trimmed.slice(0, 2000))) {
+            var v1 = JSON.parse(trimmed), time = 0;
+            return {width: v1.width, height: v1.height, events: (v1.stdout || []).map(function(e) {
+                time += e[0];
+                return [time, e[1]];
+            })};
+        }
+        var lines = trimmed.split('\n');
+        var header = JSON.parse(lines[0]);
+        var events = [], last = 0, shift = 0, limit = header.idle_time_limit;
+        for (var i = 1; i < lines.length; i++) {
+            if (!lines[i].trim()) continue;
+            var e = JSON.parse(lines[i]);
+            if (e[1] !== 'o') continue;
+            if (limit && e[0] - last > limit) shift += e[0] - last - limit;
+            last = e[0];
+            events.push([e[0] - shift, e[2]]);
+        }
+        return {width: header.width, height: header.height, events: events};
+    }
+
+    function formatTime(seconds) {
+        var s = Math.floor(seconds);
+        return Math.floor(s / 60) + ':' + ('0' + (s % 60)).slice(-2);
+    }
+
+    function setup(player, cast) {
+        var screen = new Screen(cast.width || 80, cast.height || 24);
+        var duration = cast.events.length ? cast.events[cast.events.length - 1][0] : 0;
+        player.innerHTML = '';
+        var pre = document.createElement('pre');
+        pre.className = 'ansi cast-screen';
+        var bar = document.createElement('span');
+        bar.className = 'cast-controls';
+        var button = document.createElement('button');
+        button.type = 'button';
+        button.className = 'cast-play';
+        var progress = document.createElement('input');
+        progress.type = 'range';
+        progress.min = 0;
+        progress.max = duration;
+        progress.step = 'any';
+        progress.value = 0;
+        progress.setAttribute('aria-label', 'Position');
+        var clock = document.createElement('span');
+        clock.className = 'cast-time';
+        var speed = document.createElement('select');
+        speed.setAttribute('aria-label', 'Speed');
+        [0.5, 1, 2, 4].forEach(function(s) {
+            var option = document.createElement('option');
+            option.value = s;
+            option.textContent = s + '×';
+            option.selected = s === 1;
+            speed.appendChild(option);
+        });
+        bar.appendChild(button);
+        bar.appendChild(progress);
+        bar.appendChild(clock);
+        bar.appendChild(speed);
+        player.appendChild(pre);
+        player.appendChild(bar);
+
+        var time = 0, next = 0, playing = false, lastTick = 0;
+        function draw() {
+            pre.innerHTML = screen.html();
+            progress.value = time;
+            clock.textContent = formatTime(time) + ' / ' + formatTime(duration);
+            button.textContent = playing ? '⏸' : '▶';
+            button.setAttribute('aria-label', playing ? 'Pause' : 'Play');
+        }
+        function advance(to) {
+            to = Math.min(to, duration);
+            if (to < time) { screen.reset(); next = 0; }
+            while (next < cast.events.length && cast.events[next][0] <= to) {
+                screen.write(cast.events[next][1]);
+                next++;
+            }
+            time = to;
+        }
+        function tick(now) {
+            if (!playing) return;
+            advance(Math.min(duration, time + (now - lastTick) / 1000 * parseFloat(speed.value)));
+            lastTick = now;
+            if (time >= duration) playing = false;
+            draw();
+            if (playing) requestAnimationFrame(tick);
+        }
+        button.addEventListener('click', function() {
+            playing = !playing;
+            if (playing) {
+                if (time >= duration) advance(0);
+                lastTick = performance.now();
+                requestAnimationFrame(tick);
+            }
+            draw();
+        });
+        progress.addEventListener('input', function() {
+            advance(parseFloat(progress.value));
+            draw();
+        });
+        draw();
+    }
+
+    players.forEach(function(player) {
+        fetch(player.dataset.src).then(function(response) {
+            if (!response.ok) throw new Error(response.status);
+            return response.text();
+        }).then(function(text) {
+            setup(player, parseCast(text));
+        }).catch(function() {
+            player.classList.add('cast-error');
+        });
+    });
+})();
+`
+
+// downloadJS shows the offline copy link of the file index when the server
+// offers the download, which exported copies and other servers don't.
+const downloadJS = `
+(function() {
+    var link = document.getElementById('offline-download');
+    if (!link || location.protocol === 'file:') return;
+    fetch(link.getAttribute('href'), {method: 'HEAD'}).then(function(r) {
+        if (r.ok) link.hidden = false;
+    }).catch(function() {});
+})();
+`
+
 const tocJS = `
 (function() {
     var sidebar = document.getElementById('toc-sidebar');
     var tocList = document.getElementById('toc-list');
     var headings = document.querySelectorAll('.content h1, .content h2, .content h3');
+    if (!sidebar || !tocList) return;
 
     if (headings.length < 2) {
         sidebar.style.display = 'none';
@@ -319,82 +1688,291 @@ const tocJS = `
 })();
 `
 
-const pageTemplate = `<!DOCTYPE html>
-<html lang="en">
-<head>
-    <meta charset="UTF-8">
-    <meta name="viewport" content="width=device-width, initial-scale=1.0">
-    <title>{{.Title}} - {{.SiteTitle}}</title>
+const linkPreviewJS = `
+(function() {
+    var cache = {};
+    var popover = null;
+    var hoverTimer;
+
+    function isInternal(link) {
+        if (link.origin !== window.location.origin) return false;
+        if (link.pathname === window.location.pathname) return false;
+        return link.pathname !== '/' && link.pathname.indexOf('/api/') !== 0 && link.pathname.indexOf('/static/') !== 0;
+    }
+
+    function fetchPreview(path) {
+        if (cache[path]) return cache[path];
+        cache[path] = fetch('/api/preview' + path)
+            .then(function(r) { return r.ok ? r.json() : null; })
+            .catch(function() { return null; });
+        return cache[path];
+    }
+
+    function show(link, preview) {
+        hide();
+        if (!preview || !preview.summary) return;
+        popover = document.createElement('div');
+        popover.className = 'link-preview';
+        var title = document.createElement('div');
+        title.className = 'link-preview-title';
+        title.textContent = preview.title;
+        var summary = document.createElement('div');
+        summary.className = 'link-preview-summary';
+        summary.textContent = preview.summary;
+        popover.appendChild(title);
+        popover.appendChild(summary);
+        document.body.appendChild(popover);
+        var rect = link.getBoundingClientRect();
+        popover.style.top = (window.scrollY + rect.bottom + 6) + 'px';
+        popover.style.left = Math.max(8, window.scrollX + rect.left) + 'px';
+    }
+
+    function hide() {
+        if (popover) {
+            popover.remove();
+            popover = null;
+        }
+    }
+
+    document.querySelectorAll('.page-main .content a[href]').forEach(function(link) {
+        if (!isInternal(link)) return;
+        link.addEventListener('mouseenter', function() {
+            hoverTimer = setTimeout(function() {
+                fetchPreview(link.pathname).then(function(preview) { show(link, preview); });
+            }, 300);
+        });
+        link.addEventListener('mouseleave', function() {
+            clearTimeout(hoverTimer);
+            hide();
+        });
+    });
+})();
+`
+
+// pageTemplate is the default layout: the page between the navigation
+// tree and the table of contents, with breadcrumbs and prev/next links.
+const pageTemplate = `{{define "title"}}{{.Title}} - {{.SiteTitle}}{{end}}
+{{define "head"}}
+    <title>{{template "title" .}}</title>
+    {{if .Description}}<meta name="description" content="{{.Description}}">
+    <meta property="og:description" content="{{.Description}}">{{end}}
+    <meta property="og:title" content="{{.Title}}">
+    <meta property="og:site_name" content="{{.SiteTitle}}">
+    <meta property="og:type" content="article">
+    <meta name="twitter:card" content="summary">
+    {{- with .Author}}
+    <meta name="twitter:label1" content="Written by">
+    <meta name="twitter:data1" content="{{.}}">{{end}}
+    {{- with or .DisplayUpdated .DisplayDate}}
+    <meta name="twitter:label2" content="Updated">
+    <meta name="twitter:data2" content="{{.}}">{{end}}
+    {{- with .Canonical}}
+    <link rel="canonical" href="{{.}}">
+    <meta property="og:url" content="{{.}}">{{end}}
+    {{- with .StructuredData}}
+    <script type="application/ld+json">{{.}}</script>{{end}}
     ` + faviconLink + `
-    <link rel="stylesheet" href="/static/style.css">
-</head>
-<body class="has-sidebar">
-    <header class="print-header">
+    <link rel="stylesheet" href="{{asset "style.css"}}">
+    ` + pageAssets + `
+{{- end}}
+{{define "body-class"}} class="has-sidebar` + layoutClasses + `"{{end}}
+{{define "header"}}
+    {{if .Cover}}<header class="print-cover">
+        {{with .Logo}}<img class="print-cover-logo" src="{{.}}" alt="">{{end}}
+        <h1 class="print-cover-title">{{.Title}}</h1>
+        {{with .Subtitle}}<p class="print-cover-subtitle">{{.}}</p>{{end}}
+        <dl class="print-cover-meta">
+            {{with .Author}}<dt>Author</dt><dd>{{.}}</dd>{{end}}
+            {{with .Version}}<dt>Version</dt><dd>{{.}}</dd>{{end}}
+            {{with .DisplayDate}}<dt>Date</dt><dd>{{.}}</dd>{{end}}
+        </dl>
+        <p class="print-cover-site">{{.SiteTitle}}</p>
+    </header>{{else}}<header class="print-header">
         <h1 class="print-title">{{.Title}}</h1>
         {{if .Author}}<p class="print-author">{{.Author}}</p>{{end}}
-    </header>
+    </header>{{end}}
+{{- end}}
+{{define "nav"}}
     <nav class="nav-buttons">
         <a href="/"><button class="nav-btn">Home</button></a>
-        <div class="search-box">
-            <input type="text" id="search-input" placeholder="Search..." autocomplete="off">
+        <form class="search-box" action="/search" method="get" role="search">
+            <input type="text" id="search-input" name="q" placeholder="Search..." title="Filter with tag:, dir:, author:, from:, or to:, e.g. install tag:guide from:2024-01-01" autocomplete="off">
             <div id="search-results" class="search-results"></div>
-        </div>
-        <button onclick="window.print()" class="nav-btn print-btn">Print</button>
-        <button id="theme-toggle" class="theme-toggle" onclick="gomdocToggleTheme()" aria-label="Toggle dark mode">🌙</button>
+        </form>
+        <button id="bookmark-toggle" class="nav-btn bookmark-btn" hidden>☆ Bookmark</button>
+        {{with .EditURL}}<a href="{{.}}"><button class="nav-btn">Edit</button></a>{{end}}
+        {{if not .NoJS}}<button type="button" data-action="print" class="nav-btn print-btn">Print</button>{{end}}
+        {{if not .NoJS}}<button type="button" id="theme-toggle" class="theme-toggle" data-action="toggle-theme" aria-label="Toggle dark mode">🌙</button>{{end}}
     </nav>
+{{- end}}
+{{define "main"}}
     {{.Breadcrumbs}}
     <div class="page-layout">
-        <aside class="sidebar">{{.TreeHTML}}</aside>
+        {{- template "sidebar" .}}
         <div class="page-main">
-            {{if .HasMetadata}}<div class="doc-metadata">
+            {{- template "content" .}}
+        </div>
+        {{- if not .NoJS}}
+        <aside id="toc-sidebar" class="toc-sidebar">
+            <nav class="toc-nav">
+                <h3 class="toc-title">On this page</h3>
+                <ul id="toc-list" class="toc-list"></ul>
+            </nav>
+        </aside>
+        {{- end}}
+    </div>
+{{- end}}
+{{define "sidebar"}}
+        <aside class="sidebar">{{.TreeHTML}}</aside>
+{{- end}}
+{{define "content"}}
+            {{with .StaleSince}}<div class="stale-banner" role="note">This page may be outdated. It was last updated on {{.}}.</div>
+            {{end}}{{if .HasMetadata}}<div class="doc-metadata">
                 {{if .Status}}<span class="meta-item meta-status meta-status-{{.Status}}">{{.Status}}</span>{{end}}
                 {{if .Category}}<span class="meta-item meta-category">{{.Category}}</span>{{end}}
                 {{if .Version}}<span class="meta-item meta-version">v{{.Version}}</span>{{end}}
-                {{if .Date}}<span class="meta-item meta-date">{{.Date}}</span>{{end}}
+                {{if .DisplayDate}}<span class="meta-item meta-date">{{.DisplayDate}}</span>{{end}}
+                {{if .DisplayUpdated}}<span class="meta-item meta-updated">Updated {{.DisplayUpdated}}</span>{{end}}
                 {{if .Tags}}<span class="meta-item meta-tags">{{.JoinTags}}</span>{{end}}
+                {{if .Owner}}<span class="meta-item meta-owner">Owner: {{.Owner}}</span>{{end}}
+                {{if .ReviewBy}}<span class="meta-item meta-review-by{{if .ReviewOverdue}} meta-review-overdue{{end}}">Review by {{.ReviewBy}}{{if .ReviewOverdue}} (overdue){{end}}</span>{{end}}
                 {{if .Reviewers}}<span class="meta-item meta-reviewers">Reviewers: {{.JoinReviewers}}</span>{{end}}
             </div>{{end}}
-            <main class="content">
+            {{with .Series}}<nav class="series-nav" aria-label="Series">
+                <p class="series-title">Part {{.Part}} of {{.Total}}: <strong>{{.Name}}</strong></p>
+                <ol class="series-parts">
+                    {{- range .Parts}}
+                    <li>{{if .Current}}<span aria-current="page">{{.Title}}</span>{{else}}<a href="{{.Path}}">{{.Title}}</a>{{end}}</li>
+                    {{- end}}
+                </ol>
+            </nav>
+            {{end}}<main class="content"` + tableAttributes + `>
                 {{.Content}}
             </main>
+            {{- if .Acknowledge}}
+            <form class="acknowledge" method="post" action="/api/acknowledge">
+                {{- with .AcknowledgedOn}}
+                <p>You confirmed reading this version on {{.}}.</p>
+                {{- else}}
+                <input type="hidden" name="path" value="{{.Path}}">
+                <button type="submit" class="nav-btn">I have read this</button>
+                {{- end}}
+            </form>
+            {{- end}}
             <nav class="prev-next-nav">
                 {{if .PrevPath}}<a href="{{.PrevPath}}" class="prev-next-btn prev-btn">&larr; {{.PrevTitle}}</a>{{end}}
                 <span class="prev-next-spacer"></span>
                 {{if .NextPath}}<a href="{{.NextPath}}" class="prev-next-btn next-btn">{{.NextTitle}} &rarr;</a>{{end}}
             </nav>
-        </div>
-        <aside id="toc-sidebar" class="toc-sidebar">
-            <nav class="toc-nav">
-                <h3 class="toc-title">On this page</h3>
-                <ul id="toc-list" class="toc-list"></ul>
-            </nav>
-        </aside>
-    </div>
-    <footer class="site-footer">
-        Documentation created by gomdoc: <a href="https://github.com/lacrioque/gomdoc/">https://github.com/lacrioque/gomdoc/</a>
-    </footer>
-    <script>` + themeJS + `</script>
-    <script src="https://cdn.jsdelivr.net/npm/mermaid/dist/mermaid.min.js"></script>
-    <script>
-        (function() {
-            var isDark = document.documentElement.getAttribute('data-theme') === 'dark' ||
-                (!document.documentElement.getAttribute('data-theme') &&
-                 window.matchMedia('(prefers-color-scheme: dark)').matches);
-            mermaid.initialize({ startOnLoad: true, theme: isDark ? 'dark' : 'default' });
-        })();
-        // Find all code blocks with class "language-mermaid" and convert them
-        document.querySelectorAll('pre > code.language-mermaid').forEach(function(codeBlock) {
-            var pre = codeBlock.parentElement;
-            var div = document.createElement('div');
-            div.className = 'mermaid';
-            div.textContent = codeBlock.textContent;
-            pre.parentNode.replaceChild(div, pre);
-        });
-        mermaid.init(undefined, '.mermaid');
-    </script>
-    <script>` + codeBlockJS + `</script>
-    <script>` + searchJS + `</script>
-    <script>` + tocJS + `</script>` + backToTopHTML + `
+{{- end}}
+{{define "scripts"}}
+    {{- if not .NoJS}}` + backToTopHTML + `
+    ` + mermaidHTML + `
+    ` + scriptTags + `
+    {{- end}}
+{{- end}}`
+
+// mermaidHTML loads Mermaid from the URL set with SetMermaidScript, if
+// any, for mermaidJS to start.
+const mermaidHTML = `{{with mermaid}}<script src="{{.}}"></script>{{end}}`
+
+// mermaidJS renders language-mermaid code blocks as diagrams in the
+// current color scheme, on pages that load Mermaid.
+const mermaidJS = `
+(function() {
+    if (typeof mermaid === 'undefined') return;
+    var isDark = document.documentElement.getAttribute('data-theme') === 'dark' ||
+        (!document.documentElement.getAttribute('data-theme') &&
+         window.matchMedia('(prefers-color-scheme: dark)').matches);
+    mermaid.initialize({ startOnLoad: true, theme: isDark ? 'dark' : 'default' });
+    // Find all code blocks with class "language-mermaid" and convert them
+    document.querySelectorAll('pre > code.language-mermaid').forEach(function(codeBlock) {
+        var pre = codeBlock.parentElement;
+        var div = document.createElement('div');
+        div.className = 'mermaid';
+        div.textContent = codeBlock.textContent;
+        pre.parentNode.replaceChild(div, pre);
+    });
+    mermaid.init(undefined, '.mermaid');
+})();
+`
+
+// scriptTags includes the script bundle, or its sources in debug mode.
+const scriptTags = `{{range scripts}}<script src="{{.}}"></script>{{end}}`
+
+// pageAssets includes the page's own stylesheets and scripts after the
+// site stylesheet, so they can override it.
+const pageAssets = `{{range .Styles}}<link rel="stylesheet" href="{{.}}">{{end}}{{range .Scripts}}<script src="{{.}}" defer></script>{{end}}`
+
+// layoutClasses adds the body classes of the page layout and theme.
+const layoutClasses = `{{with .Layout}} layout-{{.}}{{end}}{{with .Theme}} theme-{{.}}{{end}}`
+
+// landingTemplate is the landing layout: the content without sidebars,
+// breadcrumbs, or prev/next links, for homepages and overviews.
+const landingTemplate = `{{define "title"}}{{.Title}} - {{.SiteTitle}}{{end}}
+{{define "head"}}
+    <title>{{template "title" .}}</title>
+    {{if .Description}}<meta name="description" content="{{.Description}}">
+    <meta property="og:description" content="{{.Description}}">{{end}}
+    <meta property="og:title" content="{{.Title}}">
+    <meta property="og:site_name" content="{{.SiteTitle}}">
+    <meta property="og:type" content="website">
+    {{- with .Canonical}}
+    <link rel="canonical" href="{{.}}">
+    <meta property="og:url" content="{{.}}">{{end}}
+    {{- with .StructuredData}}
+    <script type="application/ld+json">{{.}}</script>{{end}}
+    ` + faviconLink + `
+    <link rel="stylesheet" href="{{asset "style.css"}}">
+    ` + pageAssets + `
+{{- end}}
+{{define "body-class"}} class="landing-page` + layoutClasses + `"{{end}}
+{{define "nav"}}
+    <nav class="nav-buttons">
+        <a href="/"><button class="nav-btn">Home</button></a>
+        <a href="/browse"><button class="nav-btn">Browse</button></a>
+        <form class="search-box" action="/search" method="get" role="search">
+            <input type="text" id="search-input" name="q" placeholder="Search..." title="Filter with tag:, dir:, author:, from:, or to:, e.g. install tag:guide from:2024-01-01" autocomplete="off">
+            <div id="search-results" class="search-results"></div>
+        </form>
+        {{if not .NoJS}}<button type="button" id="theme-toggle" class="theme-toggle" data-action="toggle-theme" aria-label="Toggle dark mode">🌙</button>{{end}}
+    </nav>
+{{- end}}
+{{define "content"}}
+    <main class="content landing-content"` + tableAttributes + `>
+        {{.Content}}
+    </main>
+{{- end}}
+{{define "scripts"}}
+    {{- if not .NoJS}}` + backToTopHTML + `
+    ` + mermaidHTML + `
+    ` + scriptTags + `
+    {{- end}}
+{{- end}}`
+
+// sharedTemplate shows a page opened through a signed link: the content
+// alone, with a note on how long the link is valid, since the rest of the
+// site needs signing in.
+const sharedTemplate = `<!DOCTYPE html>
+<html lang="en">
+<head>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+    <meta name="robots" content="noindex">
+    <title>{{.Title}} - {{.SiteTitle}}</title>
+    ` + faviconLink + `
+    <link rel="stylesheet" href="{{asset "style.css"}}">
+    ` + pageAssets + `
+</head>
+<body class="shared-page` + layoutClasses + `">
+    <main class="content landing-content"` + tableAttributes + `>
+        <div class="shared-banner" role="note">Shared from {{.SiteTitle}}. This link is valid until {{.SharedUntil}}.</div>
+        {{.Content}}
+    </main>
+    {{- if not .NoJS}}
+    ` + mermaidHTML + `
+    ` + scriptTags + `
+    {{- end}}
 </body>
 </html>`
 
@@ -443,63 +2021,651 @@ const folderToggleJS = `
 })();
 `
 
-const indexTemplate = `<!DOCTYPE html>
-<html lang="en">
-<head>
-    <meta charset="UTF-8">
-    <meta name="viewport" content="width=device-width, initial-scale=1.0">
-    <title>Index - {{.SiteTitle}}</title>
+// lazyTreeJS fills the folders of a lazy navigation tree from /api/tree
+// when they are first opened. Where there is no API, as on exported
+// sites, the folder links to its listing instead.
+const lazyTreeJS = `
+(function() {
+    function escapeHtml(text) {
+        var div = document.createElement('div');
+        div.textContent = text;
+        return div.innerHTML.replace(/"/g, '&quot;');
+    }
+
+    function entry(node) {
+        if (node.dir) {
+            return '<li><details class="folder-details" data-folder="' + escapeHtml(node.path) + '" data-lazy>' +
+                '<summary class="folder">' + escapeHtml(node.name) + '</summary>\n<ul></ul></details></li>';
+        }
+        if (node.attachment) {
+            var ext = node.name.indexOf('.') >= 0 ? node.name.split('.').pop().toLowerCase() : '';
+            return '<li><a href="' + escapeHtml(node.preview || node.path) + '" class="file attachment" data-type="' + escapeHtml(ext) + '"' +
+                (node.preview ? '' : ' download') + '>' + escapeHtml(node.name) + '</a></li>';
+        }
+        return '<li><a href="' + escapeHtml(node.path) + '" class="file">' + escapeHtml(node.name) + '</a></li>';
+    }
+
+    function load(details) {
+        if (!details.open || !details.hasAttribute('data-lazy')) return;
+        details.removeAttribute('data-lazy');
+        var folder = details.getAttribute('data-folder');
+        var list = details.querySelector('ul');
+        fetch('/api/tree' + folder.split('/').map(encodeURIComponent).join('/') + '?depth=1')
+            .then(function(r) {
+                if (!r.ok) throw new Error(r.status);
+                return r.json();
+            })
+            .then(function(tree) {
+                list.innerHTML = tree.children.map(entry).join('\n');
+            })
+            .catch(function() {
+                list.innerHTML = '<li><a href="' + escapeHtml(folder) + '" class="file">Open folder</a></li>';
+            });
+    }
+
+    // Folders added later are covered too, since toggle events are caught
+    // on the way down.
+    document.addEventListener('toggle', function(e) {
+        if (e.target.classList && e.target.classList.contains('folder-details')) load(e.target);
+    }, true);
+})();
+`
+
+// indexTemplate is the file index: the whole navigation tree.
+const indexTemplate = `{{define "title"}}Index - {{.SiteTitle}}{{end}}
+{{define "head"}}
+    <title>{{template "title" .}}</title>
+    {{- with .Canonical}}
+    <link rel="canonical" href="{{.}}">{{end}}
     ` + faviconLink + `
-    <link rel="stylesheet" href="/static/style.css">
-</head>
-<body>
+    <link rel="stylesheet" href="{{asset "style.css"}}">
+{{- end}}
+{{define "nav"}}
     <nav class="nav-buttons">
-        <span class="nav-title">{{.SiteTitle}}</span>
-        <div class="search-box">
-            <input type="text" id="search-input" placeholder="Search..." autocomplete="off">
+        {{if .HasHome}}<a href="/"><button class="nav-btn">Home</button></a>
+        {{end}}<span class="nav-title">{{.SiteTitle}}</span>
+        <form class="search-box" action="/search" method="get" role="search">
+            <input type="text" id="search-input" name="q" placeholder="Search..." title="Filter with tag:, dir:, author:, from:, or to:, e.g. install tag:guide from:2024-01-01" autocomplete="off">
             <div id="search-results" class="search-results"></div>
-        </div>
-        <button id="theme-toggle" class="theme-toggle" onclick="gomdocToggleTheme()" aria-label="Toggle dark mode">🌙</button>
+        </form>
+        <a id="offline-download" href="/download/site.zip" class="nav-btn download-btn" download hidden>Offline copy</a>
+        {{if .Digest}}<a href="/digest"><button class="nav-btn">Email digest</button></a>{{end}}
+        {{if not .NoJS}}<button type="button" id="theme-toggle" class="theme-toggle" data-action="toggle-theme" aria-label="Toggle dark mode">🌙</button>{{end}}
     </nav>
+{{- end}}
+{{define "content"}}
     <main class="content index-content">
+        <section id="my-bookmarks" class="bookmarks-panel" hidden></section>
         <h1>File Index</h1>
         {{.TreeHTML}}
     </main>
-    <footer class="site-footer">
-        Documentation created by gomdoc: <a href="https://github.com/lacrioque/gomdoc/">https://github.com/lacrioque/gomdoc/</a>
-    </footer>
-    <script>` + themeJS + `</script>
-    <script>` + searchJS + `</script>
-    <script>` + folderToggleJS + `</script>` + backToTopHTML + `
-</body>
-</html>`
+{{- end}}
+{{define "scripts"}}
+    {{- if not .NoJS}}` + backToTopHTML + `
+    ` + scriptTags + `
+    {{- end}}
+{{- end}}`
 
-const notFoundTemplate = `<!DOCTYPE html>
-<html lang="en">
-<head>
-    <meta charset="UTF-8">
-    <meta name="viewport" content="width=device-width, initial-scale=1.0">
-    <title>Page Not Found - {{.SiteTitle}}</title>
-    ` + faviconLink + `
-    <link rel="stylesheet" href="/static/style.css">
-</head>
-<body>
+const notFoundTemplate = `{{define "title"}}{{if .Gone}}Page Removed{{else if .SizeLimit}}Page Too Large{{else}}Page Not Found{{end}} - {{.SiteTitle}}{{end}}
+{{define "nav"}}
     <nav class="nav-buttons">
-        <button onclick="history.back()" class="nav-btn">Back</button>
-        <a href="/"><button class="nav-btn">Home</button></a>
-        <div class="search-box">
-            <input type="text" id="search-input" placeholder="Search..." autocomplete="off">
+        {{if not .NoJS}}<button type="button" data-action="back" class="nav-btn">Back</button>
+        {{end}}<a href="/"><button class="nav-btn">Home</button></a>
+        <form class="search-box" action="/search" method="get" role="search">
+            <input type="text" id="search-input" name="q" placeholder="Search..." title="Filter with tag:, dir:, author:, from:, or to:, e.g. install tag:guide from:2024-01-01" autocomplete="off">
             <div id="search-results" class="search-results"></div>
-        </div>
+        </form>
     </nav>
+{{- end}}
+{{define "content"}}
     <main class="content not-found-content">
+        {{- if .Gone}}
+        <h1>410 - Page Removed</h1>
+        <p>The page <code>{{.RequestPath}}</code> has expired and is no longer available.</p>
+        {{- else if .SizeLimit}}
+        <h1>413 - Page Too Large</h1>
+        <p>The page <code>{{.RequestPath}}</code> is larger than the limit of {{.SizeLimit}} and is not shown. Ask the maintainers of this site to split it into smaller pages.</p>
+        {{- else}}
         <h1>404 - Page Not Found</h1>
         <p>The page <code>{{.RequestPath}}</code> could not be found.</p>
+        {{- end}}
         <p>Try searching for what you need, or go back to the <a href="/">home page</a>.</p>
     </main>
-    <footer class="site-footer">
-        Documentation created by gomdoc: <a href="https://github.com/lacrioque/gomdoc/">https://github.com/lacrioque/gomdoc/</a>
-    </footer>
-    <script>` + searchJS + `</script>
+{{- end}}
+{{define "scripts"}}
+    {{- if not .NoJS}}
+    ` + scriptTags + `
+    {{- end}}
+{{- end}}`
+
+const adminTemplate = `{{define "title"}}Admin - {{.SiteTitle}}{{end}}
+{{define "nav"}}
+    <nav class="nav-buttons">
+        <button type="button" data-action="back" class="nav-btn">Back</button>
+        <a href="/"><button class="nav-btn">Home</button></a>
+    </nav>
+{{- end}}
+{{define "content"}}
+    <main class="content">
+        <h1>{{.SiteTitle}} Admin</h1>
+        <h2>Open on a Phone</h2>
+        <p>Scan a code to open the documentation on a device in the same network.</p>
+        <div class="admin-links">
+            {{- range .Links}}
+            <div class="admin-link">
+                {{.QRCode}}
+                <a href="{{.URL}}"><code>{{.URL}}</code></a>
+            </div>
+            {{- end}}
+        </div>
+        <h2>Build</h2>
+        <ul>
+            <li>Version: <code>{{.AppVersion}}</code></li>
+            {{- with .Commit}}
+            <li>Commit: <code>{{.}}</code></li>
+            {{- end}}
+            {{- with .BuildDate}}
+            <li>Built: <code>{{.}}</code></li>
+            {{- end}}
+            <li>Go: <code>{{.GoVersion}}</code></li>
+        </ul>
+        <h2>Reviews</h2>
+        <p>See <a href="/admin/reviews">pages per owner and overdue reviews</a>.</p>
+        <h2>Acknowledgments</h2>
+        <p>See <a href="/admin/acknowledgments">who has confirmed reading the pages that ask for it</a>.</p>
+        {{- if .Approvals}}
+        <h2>Approvals</h2>
+        <p>See <a href="/admin/approvals">the edits waiting for a reviewer</a>.</p>
+        {{- end}}
+        {{- if .StaleAfter}}
+        <h2>Stale Pages</h2>
+        {{- if .StalePages}}
+        <p>Pages not updated within {{.StaleAfter}} or the stale_after age set in their frontmatter, stalest first.</p>
+        <table class="stale-report">
+            <thead><tr><th>Page</th><th>Last updated</th><th>Age</th></tr></thead>
+            <tbody>
+            {{- range .StalePages}}
+            <tr><td><a href="{{.Path}}">{{.Title}}</a></td><td>{{.Updated}}</td><td>{{.AgeDays}} days</td></tr>
+            {{- end}}
+            </tbody>
+        </table>
+        {{- else}}
+        <p>All pages were updated within {{.StaleAfter}}.</p>
+        {{- end}}
+        {{- end}}
+        <h2>Missing Files</h2>
+        {{- if .Missing}}
+        <p>Images, players, stylesheets, scripts, and bibliographies that pages rendered since the server started refer to but that do not exist.</p>
+        <table class="missing-report">
+            <thead><tr><th>Page</th><th>Missing</th><th>File</th><th>Last seen</th></tr></thead>
+            <tbody>
+            {{- range .Missing}}
+            <tr><td><a href="{{.Page}}">{{.Page}}</a></td><td>{{.Kind}}</td><td><code>{{.Ref}}</code></td><td>{{.Seen}}</td></tr>
+            {{- end}}
+            </tbody>
+        </table>
+        {{- else}}
+        <p>No page rendered since the server started refers to a missing file.</p>
+        {{- end}}
+        <h2>Background Jobs</h2>
+        {{- if .Jobs}}
+        <table class="job-report">
+            <thead><tr><th>Job</th><th>State</th><th>Queued</th><th>Took</th></tr></thead>
+            <tbody>
+            {{- range .Jobs}}
+            <tr><td>{{.Name}}</td><td{{if eq .State "failed"}} class="job-failed" title="{{.Error}}"{{end}}>{{.State}}{{with .Error}}: {{.}}{{end}}</td><td>{{.Queued}}</td><td>{{.Duration}}</td></tr>
+            {{- end}}
+            </tbody>
+        </table>
+        {{- else}}
+        <p>No background jobs have run since the server started.</p>
+        {{- end}}
+    </main>
+{{- end}}`
+
+const reviewsTemplate = `{{define "title"}}Reviews - {{.SiteTitle}}{{end}}
+{{define "nav"}}
+    <nav class="nav-buttons">
+        <button type="button" data-action="back" class="nav-btn">Back</button>
+        <a href="/admin"><button class="nav-btn">Admin</button></a>
+    </nav>
+{{- end}}
+{{define "content"}}
+    <main class="content">
+        <h1>{{.SiteTitle}} Reviews</h1>
+        <p>Page ownership from the <code>owner</code> and <code>review_by</code> frontmatter, as of {{.Today}}. Review dates and expiries are also available as a <a href="/calendar.ics">calendar to subscribe to</a>.</p>
+        <h2>Overdue Reviews</h2>
+        {{- if .Overdue}}
+        <table class="review-report">
+            <thead><tr><th>Page</th><th>Owner</th><th>Review by</th></tr></thead>
+            <tbody>
+            {{- range .Overdue}}
+            <tr><td><a href="{{.Path}}">{{.Title}}</a></td><td>{{with .Owner}}{{.}}{{else}}<em>none</em>{{end}}</td><td class="review-overdue">{{.ReviewBy}}{{if .Invalid}} (invalid date){{end}}</td></tr>
+            {{- end}}
+            </tbody>
+        </table>
+        {{- else}}
+        <p>No reviews are overdue.</p>
+        {{- end}}
+        <h2>Pages per Owner</h2>
+        {{- range .Owners}}
+        <h3>{{with .Owner}}{{.}}{{else}}No owner{{end}} ({{len .Pages}})</h3>
+        <table class="review-report">
+            <thead><tr><th>Page</th><th>Review by</th></tr></thead>
+            <tbody>
+            {{- range .Pages}}
+            <tr><td><a href="{{.Path}}">{{.Title}}</a></td><td{{if or .Overdue .Invalid}} class="review-overdue"{{end}}>{{.ReviewBy}}</td></tr>
+            {{- end}}
+            </tbody>
+        </table>
+        {{- else}}
+        <p>No pages found.</p>
+        {{- end}}
+    </main>
+{{- end}}`
+
+const acknowledgmentsTemplate = `{{define "title"}}Acknowledgments - {{.SiteTitle}}{{end}}
+{{define "nav"}}
+    <nav class="nav-buttons">
+        <button type="button" data-action="back" class="nav-btn">Back</button>
+        <a href="/admin"><button class="nav-btn">Admin</button></a>
+    </nav>
+{{- end}}
+{{define "content"}}
+    <main class="content">
+        {{- with .Page}}
+        <h1><a href="{{.Path}}">{{.Title}}</a></h1>
+        {{- end}}
+        {{- if .Page}}
+        <p>Readers who confirmed reading version <code>{{.Version}}</code>, the current one.</p>
+        <h2>Acknowledged ({{len .Current}})</h2>
+        {{- if .Current}}
+        <table class="review-report">
+            <thead><tr><th>Reader</th><th>Date</th></tr></thead>
+            <tbody>
+            {{- range .Current}}
+            <tr><td>{{.User}}</td><td>{{.Date}}</td></tr>
+            {{- end}}
+            </tbody>
+        </table>
+        {{- else}}
+        <p>Nobody has confirmed this version yet.</p>
+        {{- end}}
+        {{- if .Outdated}}
+        <h2>Earlier Version Only ({{len .Outdated}})</h2>
+        <table class="review-report">
+            <thead><tr><th>Reader</th><th>Version</th><th>Date</th></tr></thead>
+            <tbody>
+            {{- range .Outdated}}
+            <tr><td>{{.User}}</td><td><code>{{.Version}}</code></td><td>{{.Date}}</td></tr>
+            {{- end}}
+            </tbody>
+        </table>
+        {{- end}}
+        <h2>Not Acknowledged ({{len .Pending}})</h2>
+        {{- if .Pending}}
+        <ul>
+            {{- range .Pending}}
+            <li>{{.}}</li>
+            {{- end}}
+        </ul>
+        {{- else}}
+        <p>Every known reader has confirmed this version.</p>
+        {{- end}}
+        {{- else}}
+        <h1>{{.SiteTitle}} Acknowledgments</h1>
+        <p>Pages with <code>acknowledge: true</code> frontmatter and how many readers confirmed their current version.</p>
+        {{- if .Pages}}
+        <table class="review-report">
+            <thead><tr><th>Page</th><th>Acknowledged</th></tr></thead>
+            <tbody>
+            {{- range .Pages}}
+            <tr><td><a href="/admin/acknowledgments?path={{.Path}}">{{.Title}}</a></td><td>{{.Acknowledged}}</td></tr>
+            {{- end}}
+            </tbody>
+        </table>
+        {{- else}}
+        <p>No pages ask for acknowledgment.</p>
+        {{- end}}
+        {{- end}}
+    </main>
+{{- end}}`
+
+const authorsTemplate = `{{define "title"}}{{with .Author}}{{.Name}}{{else}}Authors{{end}} - {{.SiteTitle}}{{end}}
+{{define "nav"}}
+    <nav class="nav-buttons">
+        <button type="button" data-action="back" class="nav-btn">Back</button>
+        {{- if .Author}}
+        <a href="/authors"><button class="nav-btn">Authors</button></a>
+        {{- else}}
+        <a href="/"><button class="nav-btn">Home</button></a>
+        {{- end}}
+    </nav>
+{{- end}}
+{{define "content"}}
+    <main class="content">
+        {{- with .Author}}
+        <header class="author-profile">
+            {{- with .Avatar}}
+            <img class="author-avatar" src="{{.}}" alt="">
+            {{- end}}
+            <div>
+                <h1>{{.Name}}</h1>
+                {{- with .Bio}}
+                <div class="author-bio">{{markdownify .}}</div>
+                {{- end}}
+                {{- with .URL}}
+                <p><a href="{{.}}">{{.}}</a></p>
+                {{- end}}
+            </div>
+        </header>
+        <h2>Pages ({{.Pages}})</h2>
+        {{- end}}
+        {{- if .Author}}
+        <ul class="author-pages">
+            {{- range .Pages}}
+            <li>
+                <a href="{{.Path}}">{{.Title}}</a>{{with .Date}} <span class="meta-item">{{.}}</span>{{end}}
+                {{- with .Summary}}
+                <p>{{.}}</p>
+                {{- end}}
+            </li>
+            {{- end}}
+        </ul>
+        {{- else}}
+        <h1>{{.SiteTitle}} Authors</h1>
+        {{- if .Authors}}
+        <ul class="author-list">
+            {{- range .Authors}}
+            <li><a href="/authors/{{.Slug}}">{{.Name}}</a> <span class="meta-item">{{.Pages}} {{if eq .Pages 1}}page{{else}}pages{{end}}</span></li>
+            {{- end}}
+        </ul>
+        {{- else}}
+        <p>No pages name an author.</p>
+        {{- end}}
+        {{- end}}
+    </main>
+{{- end}}`
+
+const editTemplate = `{{define "title"}}Edit {{.Title}} - {{.SiteTitle}}{{end}}
+{{define "nav"}}
+    <nav class="nav-buttons">
+        <a href="{{.Path}}"><button class="nav-btn">Cancel</button></a>
+    </nav>
+{{- end}}
+{{define "content"}}
+    <main class="content">
+        <h1>Edit <a href="{{.Path}}">{{.Title}}</a></h1>
+        {{- with .Error}}
+        <p class="edit-error" role="alert">{{.}}</p>
+        {{- end}}
+        <form class="page-editor" method="post">
+            <input type="hidden" name="base" value="{{.Base}}">
+            <textarea name="source" rows="30" spellcheck="true" aria-label="Markdown source">{{.Source}}</textarea>
+            <input type="text" name="summary" value="{{.Summary}}" placeholder="Describe your change" aria-label="Summary">
+            {{- if .Approvals}}
+            <p>Your edit is published once a reviewer approves it.</p>
+            <button type="submit" class="nav-btn">Submit for review</button>
+            {{- else}}
+            <button type="submit" class="nav-btn">Save</button>
+            {{- end}}
+        </form>
+    </main>
+{{- end}}`
+
+const digestTemplate = `{{define "title"}}Email Digest - {{.SiteTitle}}{{end}}
+{{define "nav"}}
+    <nav class="nav-buttons">
+        <a href="/"><button class="nav-btn">Home</button></a>
+    </nav>
+{{- end}}
+{{define "content"}}
+    <main class="content">
+        <h1>Email Digest</h1>
+        <p>Get a summary of the pages of {{.SiteTitle}} that were added, changed, or removed.</p>
+        {{- if .Saved}}
+        <p class="digest-saved" role="status">Your choice was saved.</p>
+        {{- end}}
+        {{- with .Error}}
+        <p class="edit-error" role="alert">{{.}}</p>
+        {{- end}}
+        <form class="digest-form" method="post">
+            <label>Email address <input type="email" name="email" value="{{.Email}}"></label>
+            <fieldset>
+                <legend>Send me</legend>
+                <label><input type="radio" name="frequency" value="daily"{{if eq .Frequency "daily"}} checked{{end}}> A daily digest</label>
+                <label><input type="radio" name="frequency" value="weekly"{{if eq .Frequency "weekly"}} checked{{end}}> A weekly digest</label>
+                <label><input type="radio" name="frequency" value=""{{if not .Frequency}} checked{{end}}> No digest</label>
+            </fieldset>
+            <button type="submit" class="nav-btn">Save</button>
+        </form>
+    </main>
+{{- end}}`
+
+const approvalsTemplate = `{{define "title"}}Approvals - {{.SiteTitle}}{{end}}
+{{define "nav"}}
+    <nav class="nav-buttons">
+        <button type="button" data-action="back" class="nav-btn">Back</button>
+        <a href="/admin"><button class="nav-btn">Admin</button></a>
+    </nav>
+{{- end}}
+{{define "content"}}
+    <main class="content">
+        <h1>{{.SiteTitle}} Approvals</h1>
+        <p>Edits made in the browser are published once a reviewer approves them.{{if not .Reviewer}} You can follow them here, but only reviewers can decide.{{end}}</p>
+        <h2>Pending ({{len .Pending}})</h2>
+        {{- if .Pending}}
+        <table class="review-report">
+            <thead><tr><th>Page</th><th>Author</th><th>Summary</th><th>Submitted</th></tr></thead>
+            <tbody>
+            {{- range .Pending}}
+            <tr><td><a href="/admin/approvals/{{.ID}}">{{.Title}}</a></td><td>{{.Author}}</td><td>{{.Summary}}</td><td>{{.Created}}</td></tr>
+            {{- end}}
+            </tbody>
+        </table>
+        {{- else}}
+        <p>No edits are waiting for review.</p>
+        {{- end}}
+        {{- if .Decided}}
+        <h2>Recently Decided</h2>
+        <table class="review-report">
+            <thead><tr><th>Page</th><th>Author</th><th>Status</th><th>Reviewer</th><th>Decided</th></tr></thead>
+            <tbody>
+            {{- range .Decided}}
+            <tr><td><a href="/admin/approvals/{{.ID}}">{{.Title}}</a></td><td>{{.Author}}</td><td>{{.Status}}</td><td>{{.Reviewer}}</td><td>{{.Decided}}</td></tr>
+            {{- end}}
+            </tbody>
+        </table>
+        {{- end}}
+    </main>
+{{- end}}`
+
+const revisionTemplate = `{{define "title"}}Edit of {{.Revision.Title}} - {{.SiteTitle}}{{end}}
+{{define "nav"}}
+    <nav class="nav-buttons">
+        <a href="/admin/approvals"><button class="nav-btn">Approvals</button></a>
+    </nav>
+{{- end}}
+{{define "content"}}
+    <main class="content">
+        {{- with .Revision}}
+        <h1>Edit of <a href="{{.Path}}">{{.Title}}</a></h1>
+        <ul>
+            <li>Author: {{.Author}}, {{.Created}}</li>
+            {{- with .Summary}}
+            <li>Summary: {{.}}</li>
+            {{- end}}
+            <li>Status: <span class="revision-status revision-{{.Status}}">{{.Status}}</span>{{with .Reviewer}} by {{.}}{{end}}{{with .Decided}}, {{.}}{{end}}</li>
+        </ul>
+        {{- end}}
+        {{- if .Outdated}}
+        <p class="edit-error" role="alert">The page changed since this edit was made, so it can no longer be approved.</p>
+        {{- end}}
+        {{- with .DiffError}}
+        <p class="edit-error" role="alert">No diff: {{.}}.</p>
+        {{- end}}
+        <table class="diff">
+            <tbody>
+            {{- range .Diff}}
+            {{- if eq .Kind "skip"}}
+            <tr class="diff-skip"><td colspan="4">⋯</td></tr>
+            {{- else}}
+            <tr class="diff-{{.Kind}}"><td class="diff-num">{{with .Old}}{{.}}{{end}}</td><td class="diff-num">{{with .New}}{{.}}{{end}}</td><td class="diff-sign">{{if eq .Kind "add"}}+{{else if eq .Kind "delete"}}-{{end}}</td><td class="diff-text">{{.Text}}</td></tr>
+            {{- end}}
+            {{- end}}
+            </tbody>
+        </table>
+        {{- if .CanReview}}
+        <form class="approval-actions" method="post">
+            {{- if not .Outdated}}
+            <button type="submit" name="action" value="approve" class="nav-btn">Approve and publish</button>
+            {{- end}}
+            <button type="submit" name="action" value="reject" class="nav-btn">Reject</button>
+        </form>
+        {{- end}}
+    </main>
+{{- end}}`
+
+const compareTemplate = `{{define "title"}}Compare{{with .A.Title}} {{.}}{{end}} - {{.SiteTitle}}{{end}}
+{{define "nav"}}
+    <nav class="nav-buttons">
+        <a href="/"><button class="nav-btn">Home</button></a>
+    </nav>
+{{- end}}
+{{define "content"}}
+    <main class="content">
+        <h1>Compare</h1>
+        <form class="compare-form" action="/compare" method="get">
+            <label>Page <input type="text" name="a" value="{{.A.Path}}" placeholder="guide/setup" required></label>
+            <label>at revision <input type="text" name="from" value="{{.A.Revision}}" placeholder="current"></label>
+            <label>with page <input type="text" name="b" value="{{.B.Path}}" placeholder="same page"></label>
+            <label>at revision <input type="text" name="to" value="{{.B.Revision}}" placeholder="current"></label>
+            <label>View <select name="view">
+                <option value="inline"{{if not .Split}} selected{{end}}>Inline</option>
+                <option value="split"{{if .Split}} selected{{end}}>Side by side</option>
+            </select></label>
+            <button type="submit" class="nav-btn">Compare</button>
+        </form>
+        {{- with .Error}}
+        <p class="edit-error" role="alert">{{.}}</p>
+        {{- end}}
+        {{- if .Compared}}
+        <h2><a href="{{.A.URL}}">{{.A.Title}}</a>{{with .A.Revision}} at <code>{{.}}</code>{{end}} → <a href="{{.B.URL}}">{{.B.Title}}</a>{{with .B.Revision}} at <code>{{.}}</code>{{end}}</h2>
+        {{- if not .Diff}}
+        <p>The pages are the same.</p>
+        {{- else if .Split}}
+        <table class="diff diff-split">
+            <tbody>
+            {{- range .Rows}}
+            {{- if eq .Old.Kind "skip"}}
+            <tr class="diff-skip"><td colspan="4">⋯</td></tr>
+            {{- else}}
+            <tr>{{with .Old}}<td class="diff-num">{{with .Old}}{{.}}{{end}}</td><td class="diff-text{{if .Kind}} diff-{{.Kind}}{{else}} diff-none{{end}}">{{.Text}}</td>{{end}}{{with .New}}<td class="diff-num">{{with .New}}{{.}}{{end}}</td><td class="diff-text{{if .Kind}} diff-{{.Kind}}{{else}} diff-none{{end}}">{{.Text}}</td>{{end}}</tr>
+            {{- end}}
+            {{- end}}
+            </tbody>
+        </table>
+        {{- else}}
+        <table class="diff">
+            <tbody>
+            {{- range .Diff}}
+            {{- if eq .Kind "skip"}}
+            <tr class="diff-skip"><td colspan="4">⋯</td></tr>
+            {{- else}}
+            <tr class="diff-{{.Kind}}"><td class="diff-num">{{with .Old}}{{.}}{{end}}</td><td class="diff-num">{{with .New}}{{.}}{{end}}</td><td class="diff-sign">{{if eq .Kind "add"}}+{{else if eq .Kind "delete"}}-{{end}}</td><td class="diff-text">{{.Text}}</td></tr>
+            {{- end}}
+            {{- end}}
+            </tbody>
+        </table>
+        {{- end}}
+        {{- end}}
+    </main>
+{{- end}}`
+
+const searchTemplate = `{{define "title"}}Search - {{.SiteTitle}}{{end}}
+{{define "nav"}}
+    <nav class="nav-buttons">
+        {{if not .NoJS}}<button type="button" data-action="back" class="nav-btn">Back</button>
+        {{end}}<a href="/"><button class="nav-btn">Home</button></a>
+    </nav>
+{{- end}}
+{{define "content"}}
+    <main class="content">
+        <h1>Search</h1>
+        <form class="search-page-form" action="/search" method="get">
+            <input type="search" name="q" id="search-page-input" value="{{.Query}}" placeholder="Search {{.SiteTitle}}..." autofocus>
+            <button type="submit" class="nav-btn">Search</button>
+        </form>
+        {{- if .Searched}}
+        <div id="search-page-results" class="search-page-results" data-searched>
+            {{- with .Error}}
+            <p class="search-no-results">{{.}}</p>
+            {{- else}}{{range .Results}}
+            <a class="search-result" href="{{.Path}}"><div class="search-result-title">{{.Title}}</div><div class="search-result-snippet">{{.Snippet}}</div></a>
+            {{- else}}
+            <p class="search-no-results">No results found</p>
+            {{- end}}{{end}}
+        </div>
+        {{- else}}
+        <div id="search-page-results" class="search-page-results"><noscript><p>Search needs JavaScript.</p></noscript></div>
+        {{- end}}
+    </main>
+{{- end}}
+{{define "scripts"}}
+    {{- if not .NoJS}}
+    ` + scriptTags + `
+    {{- end}}
+{{- end}}`
+
+const askTemplate = `{{define "title"}}Ask - {{.SiteTitle}}{{end}}
+{{define "nav"}}
+    <nav class="nav-buttons">
+        <a href="/"><button class="nav-btn">Home</button></a>
+        <a href="/search"><button class="nav-btn">Search</button></a>
+    </nav>
+{{- end}}
+{{define "content"}}
+    <main class="content">
+        <h1>Ask the docs</h1>
+        <form class="search-page-form" action="/ask" method="get">
+            <input type="search" name="q" value="{{.Query}}" placeholder="Ask a question about {{.SiteTitle}}..." autofocus>
+            <button type="submit" class="nav-btn">Ask</button>
+        </form>
+        {{- if .Asked}}
+        <div class="search-page-results ask-results">
+            {{- with .Error}}
+            <p class="search-no-results">{{.}}</p>
+            {{- else}}{{range .Passages}}
+            <a class="search-result" href="{{.Path}}"><div class="search-result-title">{{.Title}}{{with .Section}} <span class="ask-section">&rsaquo; {{.}}</span>{{end}}</div><div class="search-result-snippet">{{.Text}}</div></a>
+            {{- else}}
+            <p class="search-no-results">No passages found</p>
+            {{- end}}{{end}}
+        </div>
+        {{- end}}
+    </main>
+{{- end}}`
+
+const unfurlTemplate = `<!DOCTYPE html>
+<html lang="en">
+<head>
+    <meta charset="UTF-8">
+    <title>{{.Title}} - {{.SiteTitle}}</title>
+    {{- with .Description}}
+    <meta name="description" content="{{.}}">
+    <meta property="og:description" content="{{.}}">{{end}}
+    <meta property="og:title" content="{{.Title}}">
+    <meta property="og:site_name" content="{{.SiteTitle}}">
+    <meta property="og:type" content="article">
+    <meta property="og:url" content="{{.URL}}">
+    <meta name="twitter:card" content="summary">
+    {{- with .Updated}}
+    <meta name="twitter:label1" content="Updated">
+    <meta name="twitter:data1" content="{{.}}">{{end}}
+    <link rel="canonical" href="{{.URL}}">
+</head>
+<body>
+    <a href="{{.URL}}">{{.Title}}</a>
+    {{- with .Description}}
+    <p>{{.}}</p>{{end}}
 </body>
 </html>`
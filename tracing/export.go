@@ -0,0 +1,285 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// queueSize is how many finished spans wait for export; spans finished
+// while the queue is full are dropped.
+const queueSize = 2048
+
+// batchSize is the most spans sent in one request.
+const batchSize = 512
+
+// errShutdown is returned when spans could not all be sent before the
+// shutdown deadline.
+var errShutdown = errors.New("tracing: spans still queued at shutdown")
+
+// finishedSpan is a span waiting for export.
+type finishedSpan struct {
+	span *Span
+	end  time.Time
+}
+
+// exporter batches finished spans and posts them to an OTLP/HTTP
+// collector as JSON.
+type exporter struct {
+	url      string
+	headers  map[string]string
+	resource []otlpAttribute
+	ratio    float64
+	client   *http.Client
+
+	spans chan finishedSpan
+	stop  chan struct{}
+	done  chan struct{}
+	once  sync.Once
+
+	mu      sync.Mutex
+	dropped int
+}
+
+// newExporter starts an exporter sending to config.Endpoint.
+func newExporter(config Config) *exporter {
+	name := config.ServiceName
+	if name == "" {
+		name = "gomdoc"
+	}
+	resource := []otlpAttribute{stringAttribute("service.name", name)}
+	if config.ServiceVersion != "" {
+		resource = append(resource, stringAttribute("service.version", config.ServiceVersion))
+	}
+	client := config.Client
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	interval := config.FlushInterval
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+	e := &exporter{
+		url:      strings.TrimSuffix(config.Endpoint, "/") + "/v1/traces",
+		headers:  config.Headers,
+		resource: resource,
+		ratio:    config.SampleRatio,
+		client:   client,
+		spans:    make(chan finishedSpan, queueSize),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	go e.run(interval)
+	return e
+}
+
+// queue hands a finished span to the export loop, dropping it when the
+// queue is full rather than slowing the request down.
+func (e *exporter) queue(span finishedSpan) {
+	select {
+	case e.spans <- span:
+	default:
+		e.mu.Lock()
+		e.dropped++
+		e.mu.Unlock()
+	}
+}
+
+// run sends the queued spans every interval and whenever a full batch is
+// waiting, until shutdown.
+func (e *exporter) run(interval time.Duration) {
+	defer close(e.done)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	var batch []finishedSpan
+	flush := func() {
+		if len(batch) > 0 {
+			e.send(batch)
+			batch = nil
+		}
+	}
+	for {
+		select {
+		case span := <-e.spans:
+			if batch = append(batch, span); len(batch) >= batchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		case <-e.stop:
+			for {
+				select {
+				case span := <-e.spans:
+					if batch = append(batch, span); len(batch) >= batchSize {
+						flush()
+					}
+				default:
+					flush()
+					return
+				}
+			}
+		}
+	}
+}
+
+// shutdown sends the spans still queued and stops the export loop, giving
+// up when ctx ends first.
+func (e *exporter) shutdown(ctx context.Context) error {
+	e.once.Do(func() { close(e.stop) })
+	select {
+	case <-e.done:
+		return nil
+	case <-ctx.Done():
+		return errShutdown
+	}
+}
+
+// send posts a batch of spans, logging failures: losing spans is not
+// worth failing a request over.
+func (e *exporter) send(batch []finishedSpan) {
+	e.mu.Lock()
+	dropped := e.dropped
+	e.dropped = 0
+	e.mu.Unlock()
+	if dropped > 0 {
+		log.Printf("Warning: tracing queue full, dropped %d spans", dropped)
+	}
+
+	body, err := json.Marshal(e.request(batch))
+	if err != nil {
+		log.Printf("Warning: encoding spans: %v", err)
+		return
+	}
+	req, err := http.NewRequest(http.MethodPost, e.url, bytes.NewReader(body))
+	if err != nil {
+		log.Printf("Warning: sending spans: %v", err)
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range e.headers {
+		req.Header.Set(name, value)
+	}
+	resp, err := e.client.Do(req)
+	if err != nil {
+		log.Printf("Warning: sending spans: %v", err)
+		return
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		log.Printf("Warning: sending spans to %s: %s: %s", e.url, resp.Status, strings.TrimSpace(string(msg)))
+	}
+}
+
+// The OTLP/HTTP JSON encoding of an export request: IDs are hex, times
+// are nanoseconds since 1970 as decimal strings, and integers are strings
+// too.
+type (
+	otlpRequest struct {
+		ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+	}
+	otlpResourceSpans struct {
+		Resource   otlpResource     `json:"resource"`
+		ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+	}
+	otlpResource struct {
+		Attributes []otlpAttribute `json:"attributes"`
+	}
+	otlpScopeSpans struct {
+		Scope otlpScope  `json:"scope"`
+		Spans []otlpSpan `json:"spans"`
+	}
+	otlpScope struct {
+		Name string `json:"name"`
+	}
+	otlpSpan struct {
+		TraceID           string          `json:"traceId"`
+		SpanID            string          `json:"spanId"`
+		ParentSpanID      string          `json:"parentSpanId,omitempty"`
+		Name              string          `json:"name"`
+		Kind              Kind            `json:"kind"`
+		StartTimeUnixNano string          `json:"startTimeUnixNano"`
+		EndTimeUnixNano   string          `json:"endTimeUnixNano"`
+		Attributes        []otlpAttribute `json:"attributes,omitempty"`
+		Status            *otlpStatus     `json:"status,omitempty"`
+	}
+	otlpStatus struct {
+		Code    int    `json:"code"`
+		Message string `json:"message,omitempty"`
+	}
+	otlpAttribute struct {
+		Key   string    `json:"key"`
+		Value otlpValue `json:"value"`
+	}
+	otlpValue struct {
+		StringValue *string  `json:"stringValue,omitempty"`
+		IntValue    *string  `json:"intValue,omitempty"`
+		DoubleValue *float64 `json:"doubleValue,omitempty"`
+		BoolValue   *bool    `json:"boolValue,omitempty"`
+	}
+)
+
+// statusError is the OTLP status code of a failed span.
+const statusError = 2
+
+// request encodes a batch of spans as an export request.
+func (e *exporter) request(batch []finishedSpan) otlpRequest {
+	spans := make([]otlpSpan, 0, len(batch))
+	for _, finished := range batch {
+		s := finished.span
+		span := otlpSpan{
+			TraceID:           hex.EncodeToString(s.context.TraceID[:]),
+			SpanID:            hex.EncodeToString(s.context.SpanID[:]),
+			Name:              s.name,
+			Kind:              s.kind,
+			StartTimeUnixNano: strconv.FormatInt(s.start.UnixNano(), 10),
+			EndTimeUnixNano:   strconv.FormatInt(finished.end.UnixNano(), 10),
+		}
+		if s.parent != [8]byte{} {
+			span.ParentSpanID = hex.EncodeToString(s.parent[:])
+		}
+		s.mu.Lock()
+		for _, a := range s.attributes {
+			span.Attributes = append(span.Attributes, encodeAttribute(a))
+		}
+		if s.status != "" {
+			span.Status = &otlpStatus{Code: statusError, Message: s.status}
+		}
+		s.mu.Unlock()
+		spans = append(spans, span)
+	}
+	return otlpRequest{ResourceSpans: []otlpResourceSpans{{
+		Resource:   otlpResource{Attributes: e.resource},
+		ScopeSpans: []otlpScopeSpans{{Scope: otlpScope{Name: "gomdoc"}, Spans: spans}},
+	}}}
+}
+
+// stringAttribute returns an OTLP attribute with a string value.
+func stringAttribute(key, value string) otlpAttribute {
+	return otlpAttribute{Key: key, Value: otlpValue{StringValue: &value}}
+}
+
+// encodeAttribute converts a span attribute to OTLP.
+func encodeAttribute(a attribute) otlpAttribute {
+	switch v := a.value.(type) {
+	case int64:
+		s := strconv.FormatInt(v, 10)
+		return otlpAttribute{Key: a.key, Value: otlpValue{IntValue: &s}}
+	case float64:
+		return otlpAttribute{Key: a.key, Value: otlpValue{DoubleValue: &v}}
+	case bool:
+		return otlpAttribute{Key: a.key, Value: otlpValue{BoolValue: &v}}
+	default:
+		return stringAttribute(a.key, fmt.Sprint(v))
+	}
+}
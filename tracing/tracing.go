@@ -0,0 +1,338 @@
+// Package tracing records spans of the work behind a request, such as
+// scanning the tree, rendering a page, and executing its template, and
+// sends them to an OpenTelemetry collector over OTLP/HTTP. Trace context
+// travels in the W3C traceparent header, so gomdoc's spans join the traces
+// of the proxies and services around it.
+//
+// Tracing is off until Configure is called; Start then returns nil spans,
+// whose methods do nothing.
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Kind is the role of a span in a trace, numbered as in OTLP.
+type Kind int
+
+// Span kinds.
+const (
+	// Internal spans are work inside gomdoc.
+	Internal Kind = 1
+	// Server spans answer an incoming request.
+	Server Kind = 2
+	// Client spans wait on an outgoing request.
+	Client Kind = 3
+)
+
+// TraceparentHeader is the W3C Trace Context header carrying the trace and
+// parent span of a request.
+const TraceparentHeader = "traceparent"
+
+// SpanContext identifies a span within its trace.
+type SpanContext struct {
+	TraceID [16]byte
+	SpanID  [8]byte
+	// Sampled reports whether the trace is recorded.
+	Sampled bool
+}
+
+// Valid reports whether both IDs are set; all-zero IDs are invalid.
+func (c SpanContext) Valid() bool {
+	return c.TraceID != [16]byte{} && c.SpanID != [8]byte{}
+}
+
+// TraceIDString returns the trace ID in hex, as logged by other services.
+func (c SpanContext) TraceIDString() string {
+	return hex.EncodeToString(c.TraceID[:])
+}
+
+// Traceparent formats c as a version 00 traceparent header value.
+func (c SpanContext) Traceparent() string {
+	flags := "00"
+	if c.Sampled {
+		flags = "01"
+	}
+	return "00-" + hex.EncodeToString(c.TraceID[:]) + "-" + hex.EncodeToString(c.SpanID[:]) + "-" + flags
+}
+
+// ParseTraceparent parses a traceparent header value. Versions after 00
+// are accepted as long as they begin with the fields of version 00.
+func ParseTraceparent(value string) (SpanContext, error) {
+	var c SpanContext
+	parts := strings.Split(strings.TrimSpace(value), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || len(parts[1]) != 32 || len(parts[2]) != 16 || len(parts[3]) != 2 || hasUpper(value) {
+		return c, fmt.Errorf("malformed traceparent %q", value)
+	}
+	version, err := hex.DecodeString(parts[0])
+	if err != nil || version[0] == 0xff || version[0] == 0 && len(parts) != 4 {
+		return c, fmt.Errorf("unsupported traceparent version in %q", value)
+	}
+	flags, err := hex.DecodeString(parts[3])
+	_, traceErr := hex.Decode(c.TraceID[:], []byte(parts[1]))
+	_, spanErr := hex.Decode(c.SpanID[:], []byte(parts[2]))
+	if err != nil || traceErr != nil || spanErr != nil {
+		return SpanContext{}, fmt.Errorf("malformed traceparent %q", value)
+	}
+	if !c.Valid() {
+		return SpanContext{}, fmt.Errorf("traceparent %q has a zero ID", value)
+	}
+	c.Sampled = flags[0]&1 == 1
+	return c, nil
+}
+
+// hasUpper reports whether s has uppercase letters, which traceparent
+// does not allow in its hex fields.
+func hasUpper(s string) bool {
+	return strings.ToLower(s) != s
+}
+
+// Config configures where spans are sent.
+type Config struct {
+	// Endpoint is the base URL of an OTLP/HTTP collector, such as
+	// http://localhost:4318; spans are posted to its /v1/traces.
+	Endpoint string
+	// Headers are sent with every export, e.g. the API key of a hosted
+	// backend.
+	Headers map[string]string
+	// ServiceName names gomdoc in the traces; empty means "gomdoc".
+	ServiceName string
+	// ServiceVersion is recorded as the service.version resource
+	// attribute when set.
+	ServiceVersion string
+	// SampleRatio is the share of new traces recorded, from 0 to 1.
+	// Traces started upstream follow the sampled flag of their
+	// traceparent instead.
+	SampleRatio float64
+	// Client sends the exports; nil uses a client with a ten second
+	// timeout.
+	Client *http.Client
+	// FlushInterval is how often finished spans are sent; zero means
+	// five seconds.
+	FlushInterval time.Duration
+}
+
+// tracer is the exporter set by Configure; nil while tracing is off.
+var (
+	mu     sync.RWMutex
+	tracer *exporter
+)
+
+// Configure starts sending spans as config describes and returns a
+// function that sends the spans still queued and stops. It returns an
+// error when the endpoint is not an http or https URL.
+func Configure(config Config) (shutdown func(context.Context) error, err error) {
+	if !strings.HasPrefix(config.Endpoint, "http://") && !strings.HasPrefix(config.Endpoint, "https://") {
+		return nil, fmt.Errorf("trace endpoint %q is not an http or https URL", config.Endpoint)
+	}
+	if config.SampleRatio < 0 || config.SampleRatio > 1 || math.IsNaN(config.SampleRatio) {
+		return nil, fmt.Errorf("trace sample ratio %v is not between 0 and 1", config.SampleRatio)
+	}
+	e := newExporter(config)
+	mu.Lock()
+	previous := tracer
+	tracer = e
+	mu.Unlock()
+	if previous != nil {
+		previous.shutdown(context.Background())
+	}
+	return func(ctx context.Context) error {
+		mu.Lock()
+		if tracer == e {
+			tracer = nil
+		}
+		mu.Unlock()
+		return e.shutdown(ctx)
+	}, nil
+}
+
+// Enabled reports whether spans are being recorded.
+func Enabled() bool {
+	return current() != nil
+}
+
+// current returns the configured exporter, or nil.
+func current() *exporter {
+	mu.RLock()
+	defer mu.RUnlock()
+	return tracer
+}
+
+// Span is one timed operation of a trace. A nil Span, returned while
+// tracing is off, ignores every call.
+type Span struct {
+	exporter *exporter
+	name     string
+	kind     Kind
+	context  SpanContext
+	parent   [8]byte
+	start    time.Time
+
+	mu         sync.Mutex
+	attributes []attribute
+	status     string
+	ended      bool
+}
+
+// attribute is a key and a string, int64, float64, or bool value.
+type attribute struct {
+	key   string
+	value any
+}
+
+// spanKey is the context key of the current span or remote parent.
+type spanKey struct{}
+
+// Start begins an internal span named name, a child of the span in ctx or
+// the root of a new trace, and returns a context carrying it. End the
+// span when the work is done.
+func Start(ctx context.Context, name string) (context.Context, *Span) {
+	return StartKind(ctx, name, Internal)
+}
+
+// StartKind is Start for a span of the given kind.
+func StartKind(ctx context.Context, name string, kind Kind) (context.Context, *Span) {
+	e := current()
+	if e == nil {
+		return ctx, nil
+	}
+	span := &Span{exporter: e, name: name, kind: kind, start: time.Now()}
+	if parent, ok := SpanContextFrom(ctx); ok {
+		span.context.TraceID = parent.TraceID
+		span.context.Sampled = parent.Sampled
+		span.parent = parent.SpanID
+	} else {
+		rand.Read(span.context.TraceID[:])
+		span.context.Sampled = e.sample()
+	}
+	for span.context.SpanID == [8]byte{} {
+		rand.Read(span.context.SpanID[:])
+	}
+	return context.WithValue(ctx, spanKey{}, span.context), span
+}
+
+// WithRemoteParent returns a context whose next span continues the trace
+// of the traceparent header value, or ctx itself when the value is
+// missing or malformed.
+func WithRemoteParent(ctx context.Context, traceparent string) context.Context {
+	parent, err := ParseTraceparent(traceparent)
+	if err != nil {
+		return ctx
+	}
+	return context.WithValue(ctx, spanKey{}, parent)
+}
+
+// SpanContextFrom returns the context of the current span in ctx, or of
+// the remote parent set by WithRemoteParent.
+func SpanContextFrom(ctx context.Context) (SpanContext, bool) {
+	c, ok := ctx.Value(spanKey{}).(SpanContext)
+	return c, ok
+}
+
+// Inject sets the traceparent header of an outgoing request to the span
+// in ctx, so the service called continues the trace.
+func Inject(ctx context.Context, header http.Header) {
+	if c, ok := SpanContextFrom(ctx); ok {
+		header.Set(TraceparentHeader, c.Traceparent())
+	}
+}
+
+// Context returns the IDs of the span, or the zero SpanContext for a nil
+// span.
+func (s *Span) Context() SpanContext {
+	if s == nil {
+		return SpanContext{}
+	}
+	return s.context
+}
+
+// SetAttribute records a string, integer, float, or bool value about the
+// span; other values are recorded with their %v formatting.
+func (s *Span) SetAttribute(key string, value any) {
+	if s == nil {
+		return
+	}
+	switch v := value.(type) {
+	case string, bool, int64, float64:
+	case int:
+		value = int64(v)
+	case float32:
+		value = float64(v)
+	default:
+		value = fmt.Sprint(v)
+	}
+	s.mu.Lock()
+	s.attributes = append(s.attributes, attribute{key, value})
+	s.mu.Unlock()
+}
+
+// SetError marks the span as failed with err; a nil err does nothing.
+func (s *Span) SetError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+	s.mu.Lock()
+	s.status = err.Error()
+	s.mu.Unlock()
+}
+
+// End finishes the span and queues it for export when its trace is
+// sampled. Only the first call counts.
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	ended := s.ended
+	s.ended = true
+	s.mu.Unlock()
+	if ended || !s.context.Sampled {
+		return
+	}
+	s.exporter.queue(finishedSpan{span: s, end: time.Now()})
+}
+
+// sample decides whether a new trace is recorded.
+func (e *exporter) sample() bool {
+	switch {
+	case e.ratio >= 1:
+		return true
+	case e.ratio <= 0:
+		return false
+	}
+	n, err := rand.Int(rand.Reader, big.NewInt(1<<53))
+	return err == nil && float64(n.Int64()) < e.ratio*(1<<53)
+}
+
+// ParseHeaders parses export headers written like the
+// OTEL_EXPORTER_OTLP_HEADERS environment variable: comma-separated
+// name=value pairs with percent-encoded values.
+func ParseHeaders(s string) (map[string]string, error) {
+	headers := make(map[string]string)
+	for _, pair := range strings.Split(s, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(pair, "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("header %q is not name=value", strings.TrimSpace(pair))
+		}
+		decoded, err := url.PathUnescape(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("header %s: %v", name, err)
+		}
+		headers[name] = decoded
+	}
+	return headers, nil
+}
@@ -0,0 +1,170 @@
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+func TestParseTraceparent(t *testing.T) {
+	const value = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	c, err := ParseTraceparent(value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !c.Sampled || c.TraceIDString() != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("ParseTraceparent = %+v", c)
+	}
+	if got := c.Traceparent(); got != value {
+		t.Errorf("Traceparent() = %q, want %q", got, value)
+	}
+	if c, err := ParseTraceparent("01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00-extra"); err != nil || c.Sampled {
+		t.Errorf("future version = %+v, %v", c, err)
+	}
+
+	for _, bad := range []string{
+		"",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",
+		"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+		"00-4bf92f3577b34da6a3ce929d0e0e47zz-00f067aa0ba902b7-01",
+	} {
+		if _, err := ParseTraceparent(bad); err == nil {
+			t.Errorf("ParseTraceparent(%q) succeeded", bad)
+		}
+	}
+}
+
+func TestParseHeaders(t *testing.T) {
+	headers, err := ParseHeaders("api-key=abc%20def, x-team = docs,")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if headers["api-key"] != "abc def" || headers["x-team"] != "docs" || len(headers) != 2 {
+		t.Errorf("ParseHeaders = %v", headers)
+	}
+	if _, err := ParseHeaders("novalue"); err == nil {
+		t.Error("ParseHeaders accepted a pair without =")
+	}
+}
+
+func TestDisabled(t *testing.T) {
+	ctx, span := Start(context.Background(), "work")
+	if span != nil || Enabled() {
+		t.Fatal("span recorded without Configure")
+	}
+	span.SetAttribute("key", "value")
+	span.SetError(errors.New("failed"))
+	span.End()
+	if _, ok := SpanContextFrom(ctx); ok {
+		t.Error("disabled span stored in the context")
+	}
+}
+
+// collector is a fake OTLP/HTTP receiver keeping the spans posted to it.
+type collector struct {
+	mu      sync.Mutex
+	spans   []map[string]any
+	headers http.Header
+}
+
+func (c *collector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/v1/traces" || r.Header.Get("Content-Type") != "application/json" {
+		http.Error(w, "unexpected request", http.StatusBadRequest)
+		return
+	}
+	var req struct {
+		ResourceSpans []struct {
+			ScopeSpans []struct {
+				Spans []map[string]any
+			}
+		}
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.headers = r.Header
+	for _, rs := range req.ResourceSpans {
+		for _, ss := range rs.ScopeSpans {
+			c.spans = append(c.spans, ss.Spans...)
+		}
+	}
+}
+
+func TestExport(t *testing.T) {
+	var c collector
+	ts := httptest.NewServer(&c)
+	defer ts.Close()
+	shutdown, err := Configure(Config{Endpoint: ts.URL, Headers: map[string]string{"Api-Key": "secret"}, SampleRatio: 0})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := WithRemoteParent(context.Background(), "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	ctx, parent := StartKind(ctx, "GET", Server)
+	parent.SetAttribute("http.response.status_code", 200)
+	_, child := Start(ctx, "render")
+	child.SetError(errors.New("broken"))
+	child.End()
+	parent.End()
+	parent.End()
+	_, unsampled := Start(context.Background(), "dropped")
+	unsampled.End()
+
+	header := http.Header{}
+	Inject(ctx, header)
+	if got, want := header.Get(TraceparentHeader), parent.Context().Traceparent(); got != want {
+		t.Errorf("Inject set %q, want %q", got, want)
+	}
+
+	if err := shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if Enabled() {
+		t.Error("tracing still enabled after shutdown")
+	}
+	if len(c.spans) != 2 {
+		t.Fatalf("collector got %d spans, want 2: %v", len(c.spans), c.spans)
+	}
+	if c.headers.Get("Api-Key") != "secret" {
+		t.Errorf("export headers = %v", c.headers)
+	}
+	render, server := c.spans[0], c.spans[1]
+	if render["name"] != "render" || render["parentSpanId"] != server["spanId"] || render["traceId"] != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("render span = %v", render)
+	}
+	if status, _ := render["status"].(map[string]any); status["code"] != float64(statusError) || status["message"] != "broken" {
+		t.Errorf("render status = %v", render["status"])
+	}
+	if server["parentSpanId"] != "00f067aa0ba902b7" || server["kind"] != float64(Server) {
+		t.Errorf("server span = %v", server)
+	}
+	attributes, _ := server["attributes"].([]any)
+	if len(attributes) != 1 {
+		t.Fatalf("server attributes = %v", attributes)
+	}
+	if a := attributes[0].(map[string]any); a["key"] != "http.response.status_code" || a["value"].(map[string]any)["intValue"] != "200" {
+		t.Errorf("server attribute = %v", a)
+	}
+}
+
+func TestConfigureErrors(t *testing.T) {
+	for _, config := range []Config{
+		{Endpoint: "localhost:4318", SampleRatio: 1},
+		{Endpoint: "http://localhost:4318", SampleRatio: 2},
+	} {
+		if _, err := Configure(config); err == nil {
+			t.Errorf("Configure(%+v) succeeded", config)
+		}
+	}
+}